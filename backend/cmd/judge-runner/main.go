@@ -0,0 +1,14 @@
+// judge-runner 判题运行器，安装在调试器镜像中，由docker沙箱调用
+package main
+
+import (
+	"os"
+
+	"github.com/fansqz/fancode-backend/service/user_coding_service/judger/runner"
+)
+
+func main() {
+	// 运行器会重新执行自身进入命名空间等阶段
+	runner.Init()
+	os.Exit(runner.Main(os.Args[1:]))
+}
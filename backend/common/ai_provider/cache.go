@@ -0,0 +1,121 @@
+package ai_provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common"
+)
+
+// aiCacheKeyPrefix ai响应缓存的key前缀
+const aiCacheKeyPrefix = "ai:cache:"
+
+// Cache ai响应的缓存
+type Cache interface {
+	// Get 获取缓存，未命中时ok为false
+	Get(key string) (value string, ok bool)
+	// Set 设置缓存
+	Set(key string, value string, ttl time.Duration)
+}
+
+// redisCache 基于redis的缓存
+type redisCache struct{}
+
+// NewRedisCache 创建基于redis的缓存，redis未初始化时不缓存
+func NewRedisCache() Cache {
+	return &redisCache{}
+}
+
+func (c *redisCache) Get(key string) (string, bool) {
+	if common.Redis == nil {
+		return "", false
+	}
+	value, err := common.Redis.Get(key).Result()
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+func (c *redisCache) Set(key string, value string, ttl time.Duration) {
+	if common.Redis == nil {
+		return
+	}
+	common.Redis.Set(key, value, ttl)
+}
+
+// CachedProvider 为AIProvider加上缓存，相同的请求直接返回缓存的响应
+type CachedProvider struct {
+	provider  AIProvider
+	cache     Cache
+	namespace string
+	ttl       time.Duration
+}
+
+// NewCachedProvider 创建CachedProvider
+// namespace用于区分不同的服务商和模型，相同请求在不同模型下的响应不共享
+func NewCachedProvider(provider AIProvider, cache Cache, namespace string, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		provider:  provider,
+		cache:     cache,
+		namespace: namespace,
+		ttl:       ttl,
+	}
+}
+
+// Chat 实现AIProvider接口
+func (p *CachedProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	key := p.cacheKey(req)
+	if content, ok := p.cache.Get(key); ok {
+		return &ChatResponse{Content: content, Cached: true}, nil
+	}
+	resp, err := p.provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.Set(key, resp.Content, p.ttl)
+	return resp, nil
+}
+
+// ChatStream 实现AIProvider接口
+// 命中缓存时一次性返回全部内容，未命中时边转发边收集，流正常结束以后写入缓存
+func (p *CachedProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *StreamChunk, error) {
+	key := p.cacheKey(req)
+	if content, ok := p.cache.Get(key); ok {
+		chunks := make(chan *StreamChunk, 2)
+		chunks <- &StreamChunk{Content: content}
+		chunks <- &StreamChunk{Done: true, Usage: &Usage{}}
+		close(chunks)
+		return chunks, nil
+	}
+	chunks, err := p.provider.ChatStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan *StreamChunk, 16)
+	go func() {
+		defer close(out)
+		var content strings.Builder
+		for chunk := range chunks {
+			content.WriteString(chunk.Content)
+			if chunk.Done {
+				p.cache.Set(key, content.String(), p.ttl)
+			}
+			if !sendChunk(ctx, out, chunk) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// cacheKey 使用请求内容的sha256作为缓存key
+func (p *CachedProvider) cacheKey(req *ChatRequest) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(append([]byte(p.namespace+"\n"), data...))
+	return aiCacheKeyPrefix + hex.EncodeToString(sum[:])
+}
@@ -1,50 +1,198 @@
 package ai_provider
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"strings"
 )
 
-// OpenAIProvider OpenAI服务商实现
+// OpenAIProvider OpenAI服务商实现，兼容OpenAI chat/completions协议的服务都可以使用
 // 只负责与OpenAI API交互
 type OpenAIProvider struct {
 	ApiKey  string
 	ApiBase string
 	Model   string
-	Timeout int
+	client  *http.Client
 }
 
-func NewOpenAIProvider(apiKey, apiBase, model string, timeout int) *OpenAIProvider {
+func NewOpenAIProvider(apiKey, apiBase, model string) *OpenAIProvider {
 	return &OpenAIProvider{
 		ApiKey:  apiKey,
-		ApiBase: apiBase,
+		ApiBase: strings.TrimSuffix(apiBase, "/"),
 		Model:   model,
-		Timeout: timeout,
+		// 超时由ctx控制
+		client: &http.Client{},
 	}
 }
 
+// openAIRequest chat/completions的请求体
+type openAIRequest struct {
+	Model         string               `json:"model"`
+	Messages      []*Message           `json:"messages"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	Temperature   *float32             `json:"temperature,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *openAIUsage) toUsage() *Usage {
+	if u == nil {
+		return nil
+	}
+	return &Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+// openAIResponse chat/completions的响应体，流式响应的每个片段也使用该结构
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 // Chat 实现AIProvider接口
-func (p *OpenAIProvider) Chat(ctx context.Context, prompt string) (string, error) {
-	body := fmt.Sprintf(`{"model": "%s", "messages": [{"role": "user", "content": "%s"}]}`,
-		p.Model, prompt)
-	client := &http.Client{}
-	req, err := http.NewRequestWithContext(context.Background(), "POST", p.ApiBase+"/chat/completions", bytes.NewBuffer([]byte(body)))
+func (p *OpenAIProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.do(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body openAIResponse
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode openai response fail: %w", err)
+	}
+	if len(body.Choices) == 0 {
+		return nil, errors.New("openai response has no choices")
+	}
+	answer := &ChatResponse{Content: body.Choices[0].Message.Content}
+	if usage := body.Usage.toUsage(); usage != nil {
+		answer.Usage = *usage
+	}
+	return answer, nil
+}
+
+// ChatStream 实现AIProvider接口，解析服务端推送的SSE事件
+func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *StreamChunk, error) {
+	resp, err := p.do(ctx, req, true)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	chunks := make(chan *StreamChunk, 16)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		var usage *Usage
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				break
+			}
+			var body openAIResponse
+			if err := json.Unmarshal([]byte(data), &body); err != nil {
+				sendChunk(ctx, chunks, &StreamChunk{Err: fmt.Errorf("decode openai stream fail: %w", err)})
+				return
+			}
+			if body.Error != nil {
+				sendChunk(ctx, chunks, &StreamChunk{Err: errors.New(body.Error.Message)})
+				return
+			}
+			if u := body.Usage.toUsage(); u != nil {
+				usage = u
+			}
+			if len(body.Choices) != 0 && body.Choices[0].Delta.Content != "" {
+				if !sendChunk(ctx, chunks, &StreamChunk{Content: body.Choices[0].Delta.Content}) {
+					return
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			sendChunk(ctx, chunks, &StreamChunk{Err: err})
+			return
+		}
+		sendChunk(ctx, chunks, &StreamChunk{Done: true, Usage: usage})
+	}()
+	return chunks, nil
+}
+
+// do 发送请求，返回状态码为200的响应
+func (p *OpenAIProvider) do(ctx context.Context, req *ChatRequest, stream bool) (*http.Response, error) {
+	body := openAIRequest{
+		Model:       p.Model,
+		Messages:    req.Messages,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		Stream:      stream,
+	}
+	if stream {
+		body.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
 	}
-	req.Header.Set("Authorization", "Bearer "+p.ApiKey)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
+	data, err := json.Marshal(body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer resp.Body.Close()
-	respBody, err := ioutil.ReadAll(resp.Body)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.ApiBase+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+p.ApiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	resp, err := p.client.Do(httpReq)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: string(message)}
+	}
+	return resp, nil
+}
+
+// sendChunk 发送流式片段，ctx取消时返回false
+func sendChunk(ctx context.Context, chunks chan<- *StreamChunk, chunk *StreamChunk) bool {
+	select {
+	case chunks <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
 	}
-	return string(respBody), nil
 }
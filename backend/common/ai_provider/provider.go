@@ -2,26 +2,125 @@ package ai_provider
 
 import (
 	"context"
+	"time"
+
 	"github.com/fansqz/fancode-backend/common/config"
 )
 
-// AIProvider 只定义最基础的AI调用方法
-// prompt为业务层拼接好的字符串，返回AI原始响应内容
-// 具体实现只负责与AI服务商交互
+// Role 对话消息的角色
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message 对话消息
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest 对话请求，消息由业务层组装，provider只负责与AI服务商交互
+type ChatRequest struct {
+	Messages []*Message `json:"messages"`
+	// MaxTokens 最大生成token数，0表示使用服务商的默认值
+	MaxTokens int `json:"maxTokens,omitempty"`
+	// Temperature 采样温度，nil表示使用服务商的默认值
+	Temperature *float32 `json:"temperature,omitempty"`
+}
+
+// NewChatRequest 根据系统提示词和用户输入创建对话请求，系统提示词为空时只发送用户输入
+func NewChatRequest(system string, user string) *ChatRequest {
+	req := &ChatRequest{}
+	if system != "" {
+		req.Messages = append(req.Messages, &Message{Role: RoleSystem, Content: system})
+	}
+	req.Messages = append(req.Messages, &Message{Role: RoleUser, Content: user})
+	return req
+}
+
+// Usage token使用情况
+type Usage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// ChatResponse 对话响应
+type ChatResponse struct {
+	Content string `json:"content"`
+	Usage   Usage  `json:"usage"`
+	// Cached 是否命中缓存，命中缓存时没有消耗token
+	Cached bool `json:"cached"`
+}
+
+// StreamChunk 流式响应的一个片段
+// 最后一个片段Done为true，并带有整个请求的Usage；出错时Err不为nil，随后channel关闭
+type StreamChunk struct {
+	Content string
+	Done    bool
+	Usage   *Usage
+	Err     error
+}
+
+// AIProvider AI服务商的抽象
+// 具体实现只负责与AI服务商交互，重试、超时和缓存由NewAIProvider组装
 // 如需支持多家AI，只需实现本接口
 type AIProvider interface {
-	Chat(ctx context.Context, prompt string) (string, error)
+	// Chat 阻塞式对话，返回完整的响应内容
+	Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error)
+	// ChatStream 流式对话，返回的channel在对话结束、出错或者ctx取消以后关闭
+	ChatStream(ctx context.Context, req *ChatRequest) (<-chan *StreamChunk, error)
 }
 
-// 根据ai配置生成不同的AIProvider
+const (
+	// defaultTimeout 没有配置超时时间时，单次请求的超时时间
+	defaultTimeout = 60 * time.Second
+	// defaultMaxRetries 没有配置重试次数时的重试次数
+	defaultMaxRetries = 2
+)
+
+// NewAIProviderIfConfigured 配置了ai服务的密钥时生成AIProvider，没有配置时返回nil
+func NewAIProviderIfConfigured(aiConfig *config.AIConfig) AIProvider {
+	if aiConfig == nil || (aiConfig.ApiKey == "" && aiConfig.AccessKeyID == "") {
+		return nil
+	}
+	return NewAIProvider(aiConfig)
+}
+
+// NewAIProvider 根据ai配置生成AIProvider，并加上超时、重试和缓存
 func NewAIProvider(aiConfig *config.AIConfig) AIProvider {
+	var provider AIProvider
 	switch aiConfig.Provider {
 	case "openai":
-		return NewOpenAIProvider(aiConfig.ApiKey, aiConfig.ApiBase, aiConfig.Model, aiConfig.Timeout)
+		provider = NewOpenAIProvider(aiConfig.ApiKey, aiConfig.ApiBase, aiConfig.Model)
 	case "volcengine":
-		return NewVolcengineProvider(aiConfig.ApiKey, aiConfig.ApiBase, aiConfig.Model, aiConfig.Timeout)
+		provider = NewVolcengineProvider(aiConfig.ApiKey, aiConfig.ApiBase, aiConfig.Model)
+	case "stub":
+		provider = NewStubProvider(nil)
 	default:
 		// 默认使用OpenAI
-		return NewOpenAIProvider(aiConfig.ApiKey, aiConfig.ApiBase, aiConfig.Model, aiConfig.Timeout)
+		provider = NewOpenAIProvider(aiConfig.ApiKey, aiConfig.ApiBase, aiConfig.Model)
+	}
+
+	timeout := time.Duration(aiConfig.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	maxRetries := aiConfig.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	} else if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	provider = NewRetryProvider(provider, maxRetries, timeout)
+
+	// 缓存放在最外层，命中缓存时不需要重试
+	if aiConfig.CacheTTL > 0 {
+		provider = NewCachedProvider(provider, NewRedisCache(), aiConfig.Provider+":"+aiConfig.Model,
+			time.Duration(aiConfig.CacheTTL)*time.Second)
 	}
+	return provider
 }
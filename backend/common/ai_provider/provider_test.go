@@ -0,0 +1,220 @@
+package ai_provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCache 测试使用的内存缓存
+type memoryCache struct {
+	mutex sync.Mutex
+	data  map[string]string
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{data: map[string]string{}}
+}
+
+func (c *memoryCache) Get(key string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	value, ok := c.data[key]
+	return value, ok
+}
+
+func (c *memoryCache) Set(key string, value string, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.data[key] = value
+}
+
+func collect(t *testing.T, chunks <-chan *StreamChunk) (string, *StreamChunk) {
+	var content strings.Builder
+	var last *StreamChunk
+	for chunk := range chunks {
+		assert.Nil(t, chunk.Err)
+		content.WriteString(chunk.Content)
+		last = chunk
+	}
+	return content.String(), last
+}
+
+func TestStubProvider(t *testing.T) {
+	stub := NewStubProvider(func(req *ChatRequest) (string, error) {
+		return "echo " + req.Messages[len(req.Messages)-1].Content, nil
+	})
+	resp, err := stub.Chat(context.Background(), NewChatRequest("system", "hello world"))
+	assert.Nil(t, err)
+	assert.Equal(t, "echo hello world", resp.Content)
+	assert.Equal(t, 17, resp.Usage.PromptTokens)
+
+	chunks, err := stub.ChatStream(context.Background(), NewChatRequest("", "hi"))
+	assert.Nil(t, err)
+	content, last := collect(t, chunks)
+	assert.Equal(t, "echo hi", content)
+	assert.True(t, last.Done)
+	assert.Equal(t, 2, last.Usage.PromptTokens)
+
+	_, err = NewStubProvider(nil).Chat(context.Background(), NewChatRequest("", "hi"))
+	assert.Equal(t, ErrStubNoReply, err)
+}
+
+func TestRetryProvider(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	failures := 2
+	stub := NewStubProvider(func(req *ChatRequest) (string, error) {
+		if failures > 0 {
+			failures--
+			return "", &APIError{StatusCode: http.StatusServiceUnavailable}
+		}
+		return "ok", nil
+	})
+	resp, err := NewRetryProvider(stub, 2, time.Second).Chat(context.Background(), NewChatRequest("", "hi"))
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", resp.Content)
+	assert.Equal(t, 3, stub.Calls())
+
+	// 4xx错误不重试
+	stub = NewStubProvider(func(req *ChatRequest) (string, error) {
+		return "", &APIError{StatusCode: http.StatusUnauthorized}
+	})
+	_, err = NewRetryProvider(stub, 2, time.Second).Chat(context.Background(), NewChatRequest("", "hi"))
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, stub.Calls())
+
+	// 429错误重试
+	stub = NewStubProvider(func(req *ChatRequest) (string, error) {
+		return "", &APIError{StatusCode: http.StatusTooManyRequests}
+	})
+	_, err = NewRetryProvider(stub, 1, time.Second).Chat(context.Background(), NewChatRequest("", "hi"))
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, stub.Calls())
+}
+
+func TestRetryProvider_Timeout(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	slow := &slowProvider{delay: 100 * time.Millisecond}
+	_, err := NewRetryProvider(slow, 1, 10*time.Millisecond).Chat(context.Background(), NewChatRequest("", "hi"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 2, slow.calls)
+}
+
+// slowProvider 响应很慢的provider，用于测试超时
+type slowProvider struct {
+	delay time.Duration
+	calls int
+}
+
+func (p *slowProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	p.calls++
+	select {
+	case <-time.After(p.delay):
+		return &ChatResponse{Content: "late"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *slowProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestCachedProvider(t *testing.T) {
+	stub := NewStubProvider(func(req *ChatRequest) (string, error) {
+		return "cached answer", nil
+	})
+	cache := newMemoryCache()
+	provider := NewCachedProvider(stub, cache, "stub:test", time.Minute)
+
+	resp, err := provider.Chat(context.Background(), NewChatRequest("system", "question"))
+	assert.Nil(t, err)
+	assert.False(t, resp.Cached)
+	resp, err = provider.Chat(context.Background(), NewChatRequest("system", "question"))
+	assert.Nil(t, err)
+	assert.True(t, resp.Cached)
+	assert.Equal(t, "cached answer", resp.Content)
+	assert.Equal(t, 1, stub.Calls())
+
+	// 流式请求写入缓存以后，再次请求直接返回
+	chunks, err := provider.ChatStream(context.Background(), NewChatRequest("system", "other question"))
+	assert.Nil(t, err)
+	content, _ := collect(t, chunks)
+	assert.Equal(t, "cached answer", content)
+	resp, err = provider.Chat(context.Background(), NewChatRequest("system", "other question"))
+	assert.Nil(t, err)
+	assert.True(t, resp.Cached)
+	assert.Equal(t, 2, stub.Calls())
+
+	// 不同的namespace不共享缓存
+	other := NewCachedProvider(stub, cache, "stub:other", time.Minute)
+	resp, err = other.Chat(context.Background(), NewChatRequest("system", "question"))
+	assert.Nil(t, err)
+	assert.False(t, resp.Cached)
+}
+
+func TestOpenAIProvider_Chat(t *testing.T) {
+	var received openAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+		_, _ = fmt.Fprint(w, `{"choices":[{"message":{"content":"answer"}}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("key", server.URL+"/", "model")
+	code := "printf(\"%d\\n\", a);\n"
+	resp, err := provider.Chat(context.Background(), NewChatRequest("system", code))
+	assert.Nil(t, err)
+	assert.Equal(t, "answer", resp.Content)
+	assert.Equal(t, Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4}, resp.Usage)
+	assert.Equal(t, "model", received.Model)
+	assert.Equal(t, code, received.Messages[1].Content)
+	assert.Equal(t, RoleSystem, received.Messages[0].Role)
+}
+
+func TestOpenAIProvider_ChatError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprint(w, `{"error":{"message":"invalid key"}}`)
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIProvider("key", server.URL, "model").Chat(context.Background(), NewChatRequest("", "hi"))
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestOpenAIProvider_ChatStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+		assert.True(t, req.StreamOptions.IncludeUsage)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n")
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":2,\"completion_tokens\":2,\"total_tokens\":4}}\n\n")
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	chunks, err := NewOpenAIProvider("key", server.URL, "model").ChatStream(context.Background(), NewChatRequest("", "hi"))
+	assert.Nil(t, err)
+	content, last := collect(t, chunks)
+	assert.Equal(t, "Hello", content)
+	assert.True(t, last.Done)
+	assert.Equal(t, 4, last.Usage.TotalTokens)
+}
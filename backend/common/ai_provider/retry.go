@@ -0,0 +1,144 @@
+package ai_provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// APIError AI服务商返回的错误
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ai api error, status = %d, message = %s", e.StatusCode, e.Message)
+}
+
+// retryBaseDelay 第一次重试前的等待时间，之后每次翻倍
+var retryBaseDelay = 500 * time.Millisecond
+
+// RetryProvider 为AIProvider加上超时和重试
+type RetryProvider struct {
+	provider   AIProvider
+	maxRetries int
+	timeout    time.Duration
+}
+
+// NewRetryProvider 创建RetryProvider
+// maxRetries为失败以后的重试次数，timeout为单次请求的超时时间，流式请求为整个流的超时时间
+func NewRetryProvider(provider AIProvider, maxRetries int, timeout time.Duration) *RetryProvider {
+	return &RetryProvider{
+		provider:   provider,
+		maxRetries: maxRetries,
+		timeout:    timeout,
+	}
+}
+
+// Chat 实现AIProvider接口
+func (p *RetryProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	var err error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			if waitErr := p.wait(ctx, attempt); waitErr != nil {
+				return nil, err
+			}
+		}
+		var resp *ChatResponse
+		resp, err = p.chatOnce(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if !p.retryable(ctx, err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (p *RetryProvider) chatOnce(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return p.provider.Chat(ctx, req)
+}
+
+// ChatStream 实现AIProvider接口
+// 只有建立连接失败时才重试，已经开始返回内容以后出错不再重试
+func (p *RetryProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *StreamChunk, error) {
+	var err error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			if waitErr := p.wait(ctx, attempt); waitErr != nil {
+				return nil, err
+			}
+		}
+		streamCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		var chunks <-chan *StreamChunk
+		chunks, err = p.provider.ChatStream(streamCtx, req)
+		if err == nil {
+			return p.forward(streamCtx, cancel, chunks), nil
+		}
+		cancel()
+		if !p.retryable(ctx, err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// forward 转发流式片段，结束以后释放超时ctx，超时时返回错误片段
+func (p *RetryProvider) forward(ctx context.Context, cancel context.CancelFunc, chunks <-chan *StreamChunk) <-chan *StreamChunk {
+	out := make(chan *StreamChunk, 16)
+	go func() {
+		defer close(out)
+		defer cancel()
+		for {
+			select {
+			case chunk, ok := <-chunks:
+				if !ok {
+					return
+				}
+				if !sendChunk(ctx, out, chunk) || chunk.Done || chunk.Err != nil {
+					return
+				}
+			case <-ctx.Done():
+				select {
+				case out <- &StreamChunk{Err: ctx.Err()}:
+				default:
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// retryable 判断错误是否可以重试，调用方取消以及4xx错误（429除外）不重试
+func (p *RetryProvider) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		return apiErr.StatusCode < 400 || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// wait 指数退避，ctx取消时返回错误
+func (p *RetryProvider) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(retryBaseDelay << (attempt - 1))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
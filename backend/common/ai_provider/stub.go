@@ -0,0 +1,87 @@
+package ai_provider
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrStubNoReply 本地桩没有配置回复，按请求错误处理，不会被重试
+var ErrStubNoReply error = &APIError{StatusCode: http.StatusBadRequest, Message: "stub provider has no reply"}
+
+// StubProvider 本地桩实现，不访问网络，用于测试和本地开发
+type StubProvider struct {
+	reply func(req *ChatRequest) (string, error)
+
+	mutex sync.Mutex
+	// Requests 收到的所有请求，便于测试断言
+	Requests []*ChatRequest
+}
+
+// NewStubProvider 创建本地桩，reply根据请求生成回复，为nil时所有请求都返回ErrStubNoReply
+func NewStubProvider(reply func(req *ChatRequest) (string, error)) *StubProvider {
+	return &StubProvider{reply: reply}
+}
+
+// Calls 返回收到的请求次数
+func (p *StubProvider) Calls() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.Requests)
+}
+
+// Chat 实现AIProvider接口
+func (p *StubProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	content, err := p.answer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatResponse{Content: content, Usage: stubUsage(req, content)}, nil
+}
+
+// ChatStream 实现AIProvider接口，按空白分隔逐段返回回复
+func (p *StubProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *StreamChunk, error) {
+	content, err := p.answer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan *StreamChunk, 16)
+	go func() {
+		defer close(chunks)
+		for _, part := range strings.SplitAfter(content, " ") {
+			if part == "" {
+				continue
+			}
+			if !sendChunk(ctx, chunks, &StreamChunk{Content: part}) {
+				return
+			}
+		}
+		usage := stubUsage(req, content)
+		sendChunk(ctx, chunks, &StreamChunk{Done: true, Usage: &usage})
+	}()
+	return chunks, nil
+}
+
+func (p *StubProvider) answer(ctx context.Context, req *ChatRequest) (string, error) {
+	p.mutex.Lock()
+	p.Requests = append(p.Requests, req)
+	p.mutex.Unlock()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if p.reply == nil {
+		return "", ErrStubNoReply
+	}
+	return p.reply(req)
+}
+
+// stubUsage 以字符数近似token数
+func stubUsage(req *ChatRequest, content string) Usage {
+	prompt := 0
+	for _, message := range req.Messages {
+		prompt += len([]rune(message.Content))
+	}
+	completion := len([]rune(content))
+	return Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
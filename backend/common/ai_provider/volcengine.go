@@ -2,7 +2,8 @@ package ai_provider
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"io"
 
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime"
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
@@ -12,44 +13,118 @@ import (
 // VolcengineProvider 火山引擎AI服务商实现
 // 使用官方Go SDK与火山引擎API交互
 type VolcengineProvider struct {
-	client  *arkruntime.Client
-	model   string
-	timeout int
+	client *arkruntime.Client
+	model  string
 }
 
 // NewVolcengineProvider 创建火山引擎AI服务商实例
-func NewVolcengineProvider(apiKey, apiBase, model string, timeout int) *VolcengineProvider {
-	// 创建客户端配置
+func NewVolcengineProvider(apiKey, apiBase, model string) *VolcengineProvider {
+	// 重试由RetryProvider统一处理，sdk不再重试
 	client := arkruntime.NewClientWithApiKey(
 		apiKey,
 		arkruntime.WithBaseUrl(apiBase),
+		arkruntime.WithRetryTimes(0),
 	)
 
 	return &VolcengineProvider{
-		client:  client,
-		model:   model,
-		timeout: timeout,
+		client: client,
+		model:  model,
 	}
 }
 
 // Chat 实现AIProvider接口
-func (p *VolcengineProvider) Chat(ctx context.Context, prompt string) (string, error) {
-	req := model.CreateChatCompletionRequest{
-		Model: p.model,
-		Messages: []*model.ChatCompletionMessage{
-			{
-				Role: model.ChatMessageRoleSystem,
-				Content: &model.ChatCompletionMessageContent{
-					StringValue: volcengine.String(prompt),
-				},
-			},
-		},
+func (p *VolcengineProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, p.buildRequest(req))
+	if err != nil {
+		return nil, p.convertError(err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == nil ||
+		resp.Choices[0].Message.Content.StringValue == nil {
+		return nil, errors.New("volcengine response has no content")
 	}
+	return &ChatResponse{
+		Content: *resp.Choices[0].Message.Content.StringValue,
+		Usage:   p.convertUsage(&resp.Usage),
+	}, nil
+}
 
-	resp, err := p.client.CreateChatCompletion(ctx, req)
+// ChatStream 实现AIProvider接口
+func (p *VolcengineProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan *StreamChunk, error) {
+	request := p.buildRequest(req)
+	request.StreamOptions = &model.StreamOptions{IncludeUsage: true}
+	stream, err := p.client.CreateChatCompletionStream(ctx, request)
 	if err != nil {
-		fmt.Printf("standard chat error: %v\n", err)
-		return "", err
+		return nil, p.convertError(err)
+	}
+	chunks := make(chan *StreamChunk, 16)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		var usage *Usage
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				sendChunk(ctx, chunks, &StreamChunk{Err: p.convertError(err)})
+				return
+			}
+			if resp.Usage != nil {
+				u := p.convertUsage(resp.Usage)
+				usage = &u
+			}
+			if len(resp.Choices) != 0 && resp.Choices[0].Delta.Content != "" {
+				if !sendChunk(ctx, chunks, &StreamChunk{Content: resp.Choices[0].Delta.Content}) {
+					return
+				}
+			}
+		}
+		sendChunk(ctx, chunks, &StreamChunk{Done: true, Usage: usage})
+	}()
+	return chunks, nil
+}
+
+// buildRequest 将消息转换为sdk的请求
+func (p *VolcengineProvider) buildRequest(req *ChatRequest) model.CreateChatCompletionRequest {
+	messages := make([]*model.ChatCompletionMessage, 0, len(req.Messages))
+	for _, message := range req.Messages {
+		messages = append(messages, &model.ChatCompletionMessage{
+			Role: string(message.Role),
+			Content: &model.ChatCompletionMessageContent{
+				StringValue: volcengine.String(message.Content),
+			},
+		})
+	}
+	request := model.CreateChatCompletionRequest{
+		Model:       p.model,
+		Messages:    messages,
+		Temperature: req.Temperature,
+	}
+	if req.MaxTokens > 0 {
+		request.MaxTokens = volcengine.Int(req.MaxTokens)
+	}
+	return request
+}
+
+func (p *VolcengineProvider) convertUsage(usage *model.Usage) Usage {
+	return Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+}
+
+// convertError 将sdk的错误转换为APIError，便于判断是否需要重试
+func (p *VolcengineProvider) convertError(err error) error {
+	var apiErr *model.APIError
+	if errors.As(err, &apiErr) {
+		return &APIError{StatusCode: apiErr.HTTPStatusCode, Message: apiErr.Message}
+	}
+	var requestErr *model.RequestError
+	if errors.As(err, &requestErr) {
+		return &APIError{StatusCode: requestErr.HTTPStatusCode, Message: requestErr.Error()}
 	}
-	return *resp.Choices[0].Message.Content.StringValue, nil
+	return err
 }
@@ -0,0 +1,48 @@
+package artifact_cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strings"
+
+	"github.com/fansqz/fancode-backend/constants"
+)
+
+const (
+	// Version 编译产物的版本，编译命令或打包方式变化时需要修改，使旧的缓存失效
+	Version = "v2"
+	// ArtifactFile 打包编译产物的文件
+	ArtifactFile = "artifact.tar"
+	// MaxSize 可以缓存的编译产物大小上限（以字节为单位）
+	MaxSize = 64 << 20
+)
+
+// 编译产物的使用场景，调试镜像和判题沙箱中的编译器以及打包的文件不同，编译产物不能混用
+const (
+	ScopeDebug = "debug"
+	ScopeJudge = "judge"
+)
+
+// Key 根据使用场景、语言、编译参数和代码计算编译产物的缓存key
+func Key(scope string, language constants.LanguageType, flags []string, code string) string {
+	content := strings.Join([]string{
+		Version,
+		scope,
+		string(language),
+		strings.Join(flags, " "),
+		code,
+	}, "\x00")
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// PackCommand 将工作目录中的文件打包为编译产物的命令，打包后的文件为dir下的ArtifactFile
+func PackCommand(dir string, files ...string) []string {
+	return append([]string{"tar", "-cf", path.Join(dir, ArtifactFile), "-C", dir}, files...)
+}
+
+// UnpackCommand 将dir下的ArtifactFile解压到工作目录的命令
+func UnpackCommand(dir string) []string {
+	return []string{"tar", "-xf", path.Join(dir, ArtifactFile), "-C", dir}
+}
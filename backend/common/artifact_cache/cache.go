@@ -0,0 +1,135 @@
+package artifact_cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common"
+	"github.com/fansqz/fancode-backend/common/config"
+	"github.com/fansqz/fancode-backend/common/logger"
+)
+
+// redisKeyPrefix 编译产物缓存的key前缀
+const redisKeyPrefix = "compile:artifact:"
+
+// Cache 编译产物缓存，key由调用方根据语言、编译参数和代码计算
+type Cache interface {
+	// Get 获取编译产物，不存在或已过期时返回false
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set 保存编译产物
+	Set(ctx context.Context, key string, artifact []byte)
+}
+
+// New 根据配置创建编译产物缓存
+func New(cacheConfig *config.CompileCacheConfig) Cache {
+	ttl := time.Duration(cacheConfig.TTL) * time.Second
+	switch cacheConfig.Store {
+	case config.CompileCacheVolume:
+		return NewVolumeCache(cacheConfig.Dir, ttl)
+	case config.CompileCacheNone:
+		return noneCache{}
+	default:
+		return NewRedisCache(ttl)
+	}
+}
+
+// redisCache 基于redis的缓存，redis未初始化时不缓存
+type redisCache struct {
+	ttl time.Duration
+}
+
+func NewRedisCache(ttl time.Duration) Cache {
+	return &redisCache{ttl: ttl}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	if common.Redis == nil {
+		return nil, false
+	}
+	value, err := common.Redis.Get(redisKeyPrefix + key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, artifact []byte) {
+	if common.Redis == nil {
+		return
+	}
+	common.Redis.Set(redisKeyPrefix+key, artifact, c.ttl)
+}
+
+// volumeCache 基于目录的缓存，多个实例挂载同一个共享卷时可以共享编译产物
+// 以文件的修改时间判断是否过期，命中时更新修改时间，常用的编译产物会一直保留
+type volumeCache struct {
+	dir string
+	ttl time.Duration
+}
+
+func NewVolumeCache(dir string, ttl time.Duration) Cache {
+	return &volumeCache{dir: dir, ttl: ttl}
+}
+
+func (c *volumeCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	file := c.path(key)
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	if now.Sub(info.ModTime()) > c.ttl {
+		_ = os.Remove(file)
+		return nil, false
+	}
+	artifact, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	_ = os.Chtimes(file, now, now)
+	return artifact, true
+}
+
+func (c *volumeCache) Set(ctx context.Context, key string, artifact []byte) {
+	file := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		logger.WithCtx(ctx).Errorf("[volumeCache] create dir fail, err = %v", err)
+		return
+	}
+	// 先写入临时文件再重命名，其他实例不会读到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[volumeCache] create temp file fail, err = %v", err)
+		return
+	}
+	_, err = tmp.Write(artifact)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[volumeCache] save artifact fail, err = %v", err)
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// path 使用key的前两个字符作为子目录，避免单个目录下文件过多
+func (c *volumeCache) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.dir, key)
+	}
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// noneCache 不进行缓存
+type noneCache struct{}
+
+func (noneCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	return nil, false
+}
+
+func (noneCache) Set(ctx context.Context, key string, artifact []byte) {}
@@ -0,0 +1,71 @@
+package artifact_cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cache := NewVolumeCache(dir, time.Hour)
+	key := "0123456789abcdef"
+
+	_, ok := cache.Get(ctx, key)
+	assert.False(t, ok)
+
+	cache.Set(ctx, key, []byte("artifact"))
+	artifact, ok := cache.Get(ctx, key)
+	assert.True(t, ok)
+	assert.Equal(t, "artifact", string(artifact))
+
+	// 其他实例挂载同一个目录时共享缓存
+	artifact, ok = NewVolumeCache(dir, time.Hour).Get(ctx, key)
+	assert.True(t, ok)
+	assert.Equal(t, "artifact", string(artifact))
+
+	// 临时文件已经重命名
+	files, err := os.ReadDir(filepath.Join(dir, key[:2]))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+}
+
+func TestVolumeCache_Expired(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cache := NewVolumeCache(dir, time.Hour)
+	key := "0123456789abcdef"
+	cache.Set(ctx, key, []byte("artifact"))
+
+	// 命中时重新计时
+	old := time.Now().Add(-30 * time.Minute)
+	file := filepath.Join(dir, key[:2], key)
+	assert.Nil(t, os.Chtimes(file, old, old))
+	_, ok := cache.Get(ctx, key)
+	assert.True(t, ok)
+	info, err := os.Stat(file)
+	assert.Nil(t, err)
+	assert.True(t, info.ModTime().After(old))
+
+	old = time.Now().Add(-2 * time.Hour)
+	assert.Nil(t, os.Chtimes(file, old, old))
+	_, ok = cache.Get(ctx, key)
+	assert.False(t, ok)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestKey(t *testing.T) {
+	key := Key(ScopeJudge, constants.LanguageC, []string{"-O2"}, "int main() {}")
+	assert.Equal(t, key, Key(ScopeJudge, constants.LanguageC, []string{"-O2"}, "int main() {}"))
+	assert.NotEqual(t, key, Key(ScopeDebug, constants.LanguageC, []string{"-O2"}, "int main() {}"))
+	assert.NotEqual(t, key, Key(ScopeJudge, constants.LanguageCPP, []string{"-O2"}, "int main() {}"))
+	assert.NotEqual(t, key, Key(ScopeJudge, constants.LanguageC, []string{"-O0"}, "int main() {}"))
+	assert.NotEqual(t, key, Key(ScopeJudge, constants.LanguageC, []string{"-O2"}, "int main() { }"))
+}
@@ -7,7 +7,11 @@ type AIConfig struct {
 	ApiKey   string `ini:"api_key"`
 	ApiBase  string `ini:"api_base"`
 	Model    string `ini:"model"`
-	Timeout  int    `ini:"timeout"`
+	Timeout  int    `ini:"timeout"` // 单次请求超时时间，单位秒
+	// MaxRetries 失败重试次数，0使用默认值，负数表示不重试
+	MaxRetries int `ini:"max_retries"`
+	// CacheTTL 相同请求的响应缓存时间，单位秒，0表示不缓存
+	CacheTTL int `ini:"cache_ttl"`
 	// Access Key ID
 	AccessKeyID string `ini:"access_key_id"`
 	// Access Key Secret
@@ -16,6 +16,10 @@ type AppConfig struct {
 	*FilePathConfig
 	*LoggerConfig
 	*AIConfig
+	*JudgeConfig
+	*CompileCacheConfig
+	*OAuthConfig
+	*JWTConfig
 }
 
 type ReleasePathConfig struct {
@@ -0,0 +1,35 @@
+package config
+
+import "gopkg.in/ini.v1"
+
+const (
+	// CompileCacheRedis 编译产物保存在redis中
+	CompileCacheRedis = "redis"
+	// CompileCacheVolume 编译产物保存在共享目录中，多个实例挂载同一个卷时可以共享
+	CompileCacheVolume = "volume"
+	// CompileCacheNone 不缓存编译产物
+	CompileCacheNone = "none"
+)
+
+// CompileCacheConfig
+// @Description: 编译产物缓存配置，判题运行和调试共用
+type CompileCacheConfig struct {
+	Store string `ini:"store"` // 缓存存储方式，redis、volume或none
+	Dir   string `ini:"dir"`   // store为volume时保存编译产物的目录
+	TTL   int    `ini:"ttl"`   // 缓存时间，单位秒，volume存储在命中时会重新计时
+}
+
+func NewCompileCacheConfig(cfg *ini.File) *CompileCacheConfig {
+	compileCacheConfig := &CompileCacheConfig{}
+	cfg.Section("compileCache").MapTo(compileCacheConfig)
+	if compileCacheConfig.Store != CompileCacheVolume && compileCacheConfig.Store != CompileCacheNone {
+		compileCacheConfig.Store = CompileCacheRedis
+	}
+	if compileCacheConfig.Dir == "" {
+		compileCacheConfig.Dir = "/var/fanCode/compileCache"
+	}
+	if compileCacheConfig.TTL <= 0 {
+		compileCacheConfig.TTL = 86400
+	}
+	return compileCacheConfig
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"github.com/fansqz/fancode-backend/constants"
+	"gopkg.in/ini.v1"
+)
+
+// JudgeConfig
+// @Description: 判题相关配置
+type JudgeConfig struct {
+	MaxConcurrent  int   `ini:"maxConcurrent"`  // 同时进行判题的最大数量
+	QueueSize      int   `ini:"queueSize"`      // 等待判题的提交记录数量上限，超过时拒绝提交
+	CompileTimeout int   `ini:"compileTimeout"` // 编译超时时间，单位秒
+	MemoryLimit    int64 `ini:"memoryLimit"`    // 判题容器的内存上限，单位MB，编译也在容器中进行
+	CPUQuota       int64 `ini:"cpuQuota"`       // 判题容器的cpu配额，100000表示一个cpu
+	OutputLimit    int64 `ini:"outputLimit"`    // 程序输出的大小上限，单位MB
+	// PidsLimit 用户程序的进程数上限，包括线程，沙箱支持cgroup时生效
+	PidsLimit int64 `ini:"pidsLimit"`
+	// ContainerPidsLimit 判题容器的进程数上限，编译器和java虚拟机需要较多线程
+	ContainerPidsLimit int64 `ini:"containerPidsLimit"`
+	// Sandbox 判题沙箱，docker或local，local在本机判题，不需要docker
+	Sandbox string `ini:"sandbox"`
+	// Isolate 是否在命名空间中编译和运行用户代码，并限制用户程序的系统调用，local沙箱总是开启
+	// 需要内核允许创建user namespace，docker默认的seccomp配置不允许在容器中创建
+	// 隔离编译时不共享go的编译缓存，每次编译go代码都需要重新编译标准库，需要适当增加compileTimeout
+	Isolate bool `ini:"isolate"`
+	// 使用自定义输入运行代码时的限制
+	RunTimeLimit   int64 `ini:"runTimeLimit"`   // cpu时间限制，单位毫秒
+	RunMemoryLimit int64 `ini:"runMemoryLimit"` // 内存限制，单位MB
+	RunInputLimit  int64 `ini:"runInputLimit"`  // 输入的大小上限，单位KB
+}
+
+func NewJudgeConfig(cfg *ini.File) *JudgeConfig {
+	judgeConfig := &JudgeConfig{}
+	cfg.Section("judge").MapTo(judgeConfig)
+	if judgeConfig.MaxConcurrent <= 0 {
+		judgeConfig.MaxConcurrent = 2
+	}
+	if judgeConfig.QueueSize <= 0 {
+		judgeConfig.QueueSize = 100
+	}
+	if judgeConfig.CompileTimeout <= 0 {
+		judgeConfig.CompileTimeout = 10
+	}
+	if judgeConfig.MemoryLimit <= 0 {
+		judgeConfig.MemoryLimit = 1024
+	}
+	if judgeConfig.CPUQuota <= 0 {
+		judgeConfig.CPUQuota = 100000
+	}
+	if judgeConfig.OutputLimit <= 0 {
+		judgeConfig.OutputLimit = 16
+	}
+	if judgeConfig.PidsLimit <= 0 {
+		judgeConfig.PidsLimit = 64
+	}
+	if judgeConfig.ContainerPidsLimit <= 0 {
+		judgeConfig.ContainerPidsLimit = 512
+	}
+	if judgeConfig.RunTimeLimit <= 0 {
+		judgeConfig.RunTimeLimit = 2000
+	}
+	if judgeConfig.RunMemoryLimit <= 0 {
+		judgeConfig.RunMemoryLimit = 256
+	}
+	if judgeConfig.RunInputLimit <= 0 {
+		judgeConfig.RunInputLimit = 1024
+	}
+	if judgeConfig.Sandbox != constants.SandboxLocal {
+		judgeConfig.Sandbox = constants.SandboxDocker
+	}
+	if judgeConfig.Sandbox == constants.SandboxLocal {
+		judgeConfig.Isolate = true
+	}
+	return judgeConfig
+}
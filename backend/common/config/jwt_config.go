@@ -0,0 +1,37 @@
+package config
+
+import "gopkg.in/ini.v1"
+
+// JWTConfig
+// @Description: token配置，签名密钥在[jwt.keys]小节中，每一行是一个kid和对应的密钥
+// 轮换密钥时先加入新的密钥并修改currentKid，等旧的refresh token都过期后再删除旧的密钥
+type JWTConfig struct {
+	CurrentKid      string            `ini:"currentKid"`      // 签发token使用的密钥
+	AccessTokenTTL  int               `ini:"accessTokenTTL"`  // access token有效时间，单位秒
+	RefreshTokenTTL int               `ini:"refreshTokenTTL"` // refresh token有效时间，单位秒，每次刷新后重新计时
+	Keys            map[string]string `ini:"-"`               // kid到密钥的映射，用于校验token
+}
+
+func NewJWTConfig(cfg *ini.File) *JWTConfig {
+	jwtConfig := &JWTConfig{}
+	cfg.Section("jwt").MapTo(jwtConfig)
+	jwtConfig.Keys = make(map[string]string)
+	for kid, key := range cfg.Section("jwt.keys").KeysHash() {
+		if kid != "" && key != "" {
+			jwtConfig.Keys[kid] = key
+		}
+	}
+	// 只有一个密钥时可以不配置currentKid
+	if jwtConfig.CurrentKid == "" && len(jwtConfig.Keys) == 1 {
+		for kid := range jwtConfig.Keys {
+			jwtConfig.CurrentKid = kid
+		}
+	}
+	if jwtConfig.AccessTokenTTL <= 0 {
+		jwtConfig.AccessTokenTTL = 30 * 60
+	}
+	if jwtConfig.RefreshTokenTTL <= 0 {
+		jwtConfig.RefreshTokenTTL = 7 * 24 * 60 * 60
+	}
+	return jwtConfig
+}
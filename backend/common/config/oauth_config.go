@@ -0,0 +1,76 @@
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+const (
+	// OAuthProviderGithub github登录
+	OAuthProviderGithub = "github"
+	// OAuthProviderOIDC 通用的OIDC登录，例如学校的统一身份认证
+	OAuthProviderOIDC = "oidc"
+)
+
+// OAuthConfig
+// @Description: 第三方登录配置，每个[oauth.xxx]小节是一个登录方式，xxx为登录方式的名称
+type OAuthConfig struct {
+	Providers []*OAuthProviderConfig
+}
+
+// OAuthProviderConfig
+// @Description: 单个第三方登录方式的配置
+type OAuthProviderConfig struct {
+	Name          string `ini:"-"`             // 登录方式的名称，取自小节名称
+	Type          string `ini:"type"`          // github或oidc
+	DisplayName   string `ini:"displayName"`   // 前端展示的名称
+	ClientID      string `ini:"clientID"`      // 客户端id
+	ClientSecret  string `ini:"clientSecret"`  // 客户端密钥
+	RedirectURL   string `ini:"redirectURL"`   // 授权后跳转的前端地址
+	Issuer        string `ini:"issuer"`        // oidc的issuer，用于自动发现各个端点
+	AuthURL       string `ini:"authURL"`       // 授权地址，为空时使用默认值或自动发现
+	TokenURL      string `ini:"tokenURL"`      // 获取token的地址
+	UserInfoURL   string `ini:"userInfoURL"`   // 获取用户信息的地址
+	EmailURL      string `ini:"emailURL"`      // github获取用户邮箱列表的地址
+	Scopes        string `ini:"scopes"`        // 申请的权限，空格分隔
+	DefaultRoleID uint   `ini:"defaultRoleID"` // 第一次登录时创建的用户的角色
+}
+
+func NewOAuthConfig(cfg *ini.File) *OAuthConfig {
+	oauthConfig := &OAuthConfig{
+		Providers: []*OAuthProviderConfig{},
+	}
+	for _, section := range cfg.Section("oauth").ChildSections() {
+		provider := &OAuthProviderConfig{}
+		if err := section.MapTo(provider); err != nil {
+			continue
+		}
+		provider.Name = strings.TrimPrefix(section.Name(), "oauth.")
+		if provider.Name == "" || provider.ClientID == "" {
+			continue
+		}
+		switch provider.Type {
+		case OAuthProviderGithub:
+			setOAuthDefault(&provider.AuthURL, "https://github.com/login/oauth/authorize")
+			setOAuthDefault(&provider.TokenURL, "https://github.com/login/oauth/access_token")
+			setOAuthDefault(&provider.UserInfoURL, "https://api.github.com/user")
+			setOAuthDefault(&provider.EmailURL, "https://api.github.com/user/emails")
+			setOAuthDefault(&provider.Scopes, "read:user user:email")
+			setOAuthDefault(&provider.DisplayName, "GitHub")
+		case OAuthProviderOIDC:
+			setOAuthDefault(&provider.Scopes, "openid email profile")
+			setOAuthDefault(&provider.DisplayName, provider.Name)
+		default:
+			continue
+		}
+		oauthConfig.Providers = append(oauthConfig.Providers, provider)
+	}
+	return oauthConfig
+}
+
+func setOAuthDefault(value *string, defaultValue string) {
+	if *value == "" {
+		*value = defaultValue
+	}
+}
@@ -50,6 +50,10 @@ func InitSettingWithPath(path string) (*AppConfig, error) {
 	config.FilePathConfig = NewFilePathConfig(cfg)
 	config.LoggerConfig = NewLoggerConfig(cfg)
 	config.AIConfig = NewAIConfig(cfg)
+	config.JudgeConfig = NewJudgeConfig(cfg)
+	config.CompileCacheConfig = NewCompileCacheConfig(cfg)
+	config.OAuthConfig = NewOAuthConfig(cfg)
+	config.JWTConfig = NewJWTConfig(cfg)
 	return config, nil
 }
 
@@ -62,5 +66,8 @@ func NewAIConfig(cfg *ini.File) *AIConfig {
 		ApiBase:  aiSection.Key("api_base").String(),
 		Model:    aiSection.Key("model").MustString("gpt-3.5-turbo"),
 		Timeout:  aiSection.Key("timeout").MustInt(30),
+
+		MaxRetries: aiSection.Key("max_retries").MustInt(0),
+		CacheTTL:   aiSection.Key("cache_ttl").MustInt(0),
 	}
 }
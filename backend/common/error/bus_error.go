@@ -14,6 +14,8 @@ const (
 	CodeUserUnknownError                             // 用户服务未知错误
 	CodeEmailFormatWrong                             // 邮箱格式有问题
 	CodeEmailNotRegister                             // 邮箱不存在
+	CodeRefreshTokenInvalid                          // refresh token无效
+	CodeSessionNotExist                              // 登录会话不存在
 )
 
 var (
@@ -26,6 +28,8 @@ var (
 	ErrLoginCodeWrong                = NewError(CodeLoginCodeWrong, "登录验证码错误", ErrTypeBus)
 	ErrEmailFormatWrong              = NewError(CodeEmailFormatWrong, "邮箱格式异常", ErrTypeBus)
 	ErrEmailNotRegister              = NewError(CodeEmailNotRegister, "邮箱未注册，请检查", ErrTypeBus)
+	ErrRefreshTokenInvalid           = NewError(CodeRefreshTokenInvalid, "登录已过期，请重新登录", ErrTypeAuth)
+	ErrSessionNotExist               = NewError(CodeSessionNotExist, "登录设备不存在或已退出", ErrTypeBus)
 )
 
 /************Question错误**************/
@@ -37,6 +41,9 @@ const (
 	CodeProblemUpdateFailed                   // 题目更新失败
 	CodeProblemListFailed                     // 获取题目列表失败
 	CodeProblemNotExist                       // 题目不存在
+	CodeProblemBankNotExist                   // 题库不存在
+	CodeProblemCaseFileInvalid                // 测试数据文件不合法
+	CodeProblemCheckerInvalid                 // 特判程序或交互程序不合法
 )
 
 var (
@@ -47,6 +54,9 @@ var (
 	ErrProblemUpdateFailed    = NewError(CodeProblemUpdateFailed, "The problem update failed", ErrTypeServer)
 	ErrProblemListFailed      = NewError(CodeProblemListFailed, "Failed to get the problem list", ErrTypeServer)
 	ErrProblemNotExist        = NewError(CodeProblemNotExist, "The problem does not exist", ErrTypeBus)
+	ErrProblemBankNotExist    = NewError(CodeProblemBankNotExist, "题库不存在", ErrTypeBus)
+	ErrProblemCaseFileInvalid = NewError(CodeProblemCaseFileInvalid, "测试数据文件不合法", ErrTypeBus)
+	ErrProblemCheckerInvalid  = NewError(CodeProblemCheckerInvalid, "特判程序或交互程序不合法，只支持c和cpp", ErrTypeBus)
 )
 
 /************judge错误**************/
@@ -58,6 +68,11 @@ const (
 	CodeDebuggerIsClosed
 	CodeProgramIsRunningOptionFail
 	CodeErrDebugIsFinish
+	CodeProgramNotStopped
+	CodeNoCompileError
+	CodeProblemHasNoCase
+	CodeRunInputTooLarge
+	CodeJudgeQueueFull
 )
 
 var (
@@ -67,4 +82,53 @@ var (
 	ErrLanguageNotSupported       = NewError(CodeLanguageNotSupported, "This language is not supported", ErrTypeBus)
 	ErrDebuggerIsClosed           = NewError(CodeDebuggerIsClosed, "debug is closed", ErrTypeBus)
 	ErrProgramIsRunningOptionFail = NewError(CodeProgramIsRunningOptionFail, "The program is running", ErrTypeBus)
+	ErrProgramNotStopped          = NewError(CodeProgramNotStopped, "程序没有暂停", ErrTypeBus)
+	ErrNoCompileError             = NewError(CodeNoCompileError, "没有编译错误", ErrTypeBus)
+	ErrProblemHasNoCase           = NewError(CodeProblemHasNoCase, "题目没有测试用例", ErrTypeBus)
+	ErrRunInputTooLarge           = NewError(CodeRunInputTooLarge, "输入内容过大", ErrTypeBadReq)
+	ErrJudgeQueueFull             = NewError(CodeJudgeQueueFull, "判题任务过多，请稍后重新提交", ErrTypeBus)
+)
+
+/************visual错误**************/
+const (
+	CodeVisualDescriptionInvalid         = 13000 + iota // 可视化描述不合法
+	CodeVisualDescriptionMismatch                       // 可视化描述与程序不匹配
+	CodeVisualDocumentBankArchiveInvalid                // 知识库导入文件不合法
+	CodeVisualDocumentBankExist                         // 知识库已存在
+	CodeVisualDocumentVersionConflict                   // 文档已被其他人修改
+	CodeExerciseInvalid                                 // 练习不合法
+	CodeExerciseTraceFailed                             // 调试器运行练习的代码失败
+)
+
+var (
+	ErrVisualDescriptionInvalid         = NewError(CodeVisualDescriptionInvalid, "可视化描述不合法", ErrTypeBadReq)
+	ErrVisualDescriptionMismatch        = NewError(CodeVisualDescriptionMismatch, "可视化描述与程序中的变量不匹配", ErrTypeBus)
+	ErrVisualDocumentBankArchiveInvalid = NewError(CodeVisualDocumentBankArchiveInvalid, "知识库导入文件不合法", ErrTypeBus)
+	ErrVisualDocumentBankExist          = NewError(CodeVisualDocumentBankExist, "同名知识库已存在", ErrTypeBus)
+	ErrVisualDocumentVersionConflict    = NewError(CodeVisualDocumentVersionConflict, "文档已被其他人修改，请刷新后重试", ErrTypeBus)
+	ErrExerciseTraceFailed              = NewError(CodeExerciseTraceFailed, "调试器运行练习的代码失败", ErrTypeBus)
+)
+
+/************savedCode错误**************/
+const (
+	CodeSavedCodeShareExpired   = 13500 + iota // 分享已过期
+	CodeCheckpointLimitExceeded                // 检查点数量已达上限
+)
+
+var (
+	ErrSavedCodeShareExpired   = NewError(CodeSavedCodeShareExpired, "分享链接已过期", ErrTypeBus)
+	ErrCheckpointLimitExceeded = NewError(CodeCheckpointLimitExceeded, "检查点数量已达上限，请先删除不需要的检查点", ErrTypeBus)
+)
+
+/************oauth错误**************/
+const (
+	CodeOAuthProviderNotExist = 14000 + iota // 登录方式不存在
+	CodeOAuthStateInvalid                    // 登录状态无效或已过期
+	CodeOAuthLoginFailed                     // 第三方登录失败
+)
+
+var (
+	ErrOAuthProviderNotExist = NewError(CodeOAuthProviderNotExist, "登录方式不存在", ErrTypeBadReq)
+	ErrOAuthStateInvalid     = NewError(CodeOAuthStateInvalid, "登录已过期，请重新登录", ErrTypeBus)
+	ErrOAuthLoginFailed      = NewError(CodeOAuthLoginFailed, "第三方登录失败，请稍后重试", ErrTypeBus)
 )
@@ -0,0 +1,17 @@
+package constants
+
+// 代码历史版本的来源
+const (
+	// CodeRevisionSourceSavedCode 用户保存的代码
+	CodeRevisionSourceSavedCode = "savedCode"
+	// CodeRevisionSourceUserCode 用户在题目中某个语言的代码
+	CodeRevisionSourceUserCode = "userCode"
+)
+
+// 代码历史版本的保留规则
+const (
+	// MaxCodeAutosaveRevisions 每份代码最多保留的自动保存版本数，超出时删除最旧的自动保存版本
+	MaxCodeAutosaveRevisions = 50
+	// MaxCodeCheckpoints 每份代码最多保存的检查点数，检查点不会被自动删除
+	MaxCodeCheckpoints = 20
+)
@@ -59,6 +59,10 @@ const (
 	BreakpointStopped StoppedReasonType = "breakpoint"
 	StepStopped       StoppedReasonType = "step"
 	ExitedNormally    StoppedReasonType = "exited-normally"
+	// ExceptionStopped 程序发生异常，比如段错误、除零
+	ExceptionStopped StoppedReasonType = "exception"
+	// SignalStopped 程序收到信号
+	SignalStopped StoppedReasonType = "signal"
 )
 
 // StepType 单步调试类型
@@ -91,3 +95,15 @@ const (
 	ScopeModule   ScopeName = "module"
 	ScopeRegister ScopeName = "register"
 )
+
+// AssistEventType AI调试助手通过sse返回的事件类型
+type AssistEventType string
+
+const (
+	// AssistContentEvent 一段讲解内容
+	AssistContentEvent AssistEventType = "content"
+	// AssistDoneEvent 讲解结束
+	AssistDoneEvent AssistEventType = "done"
+	// AssistErrorEvent 讲解出错
+	AssistErrorEvent AssistEventType = "error"
+)
@@ -11,4 +11,41 @@ const (
 	CompileError
 	// RuntimeError 运行出错
 	RuntimeError
+	// TimeLimitExceeded 运行超时
+	TimeLimitExceeded
+	// MemoryLimitExceeded 内存超出限制
+	MemoryLimitExceeded
+	// SystemError 判题系统出错
+	SystemError
+	// Pending 等待判题
+	Pending
+	// Judging 判题中
+	Judging
+	// PartiallyAccepted 部分正确，特判程序给出了部分分数
+	PartiallyAccepted
+	// SecurityViolation 违规操作，程序使用了沙箱禁止的系统调用
+	SecurityViolation
+)
+
+// JudgeStatusMessages 判题结果对应的说明
+var JudgeStatusMessages = map[int]string{
+	Accepted:            "答案正确",
+	RunSuccess:          "运行成功",
+	WrongAnswer:         "答案错误",
+	CompileError:        "编译错误",
+	RuntimeError:        "运行错误",
+	TimeLimitExceeded:   "运行超时",
+	MemoryLimitExceeded: "内存超出限制",
+	SystemError:         "系统错误",
+	Pending:             "等待判题",
+	Judging:             "判题中",
+	PartiallyAccepted:   "部分正确",
+	SecurityViolation:   "违规操作",
+}
+
+const (
+	// SandboxDocker 在docker容器中判题
+	SandboxDocker = "docker"
+	// SandboxLocal 在本机判题，用户程序通过命名空间和seccomp隔离，不需要docker
+	SandboxLocal = "local"
 )
@@ -0,0 +1,26 @@
+package constants
+
+// 题目难度
+const (
+	// DifficultyEasy 简单
+	DifficultyEasy = 1 + iota
+	// DifficultyMedium 中等
+	DifficultyMedium
+	// DifficultyHard 困难
+	DifficultyHard
+)
+
+// 判断输出是否正确的方式
+const (
+	// CheckerDefault 逐行比较，忽略行末空白和末尾空行
+	CheckerDefault = "default"
+	// CheckerIgnoreWhitespace 按空白切分后逐个比较，忽略所有空白的差异
+	CheckerIgnoreWhitespace = "ignore_whitespace"
+	// CheckerFloat 按空白切分后逐个比较，浮点数在误差范围内视为相同
+	CheckerFloat = "float"
+	// CheckerSpecial 使用题目的特判程序判断
+	CheckerSpecial = "special"
+)
+
+// DefaultFloatEpsilon 浮点数比较默认的误差
+const DefaultFloatEpsilon = 1e-6
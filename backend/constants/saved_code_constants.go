@@ -0,0 +1,9 @@
+package constants
+
+// 用户保存代码的整理规则
+const (
+	// MaxSavedCodeTags 每份代码最多的标签数
+	MaxSavedCodeTags = 10
+	// MaxSavedCodeTagLength 标签的最大长度，按字符计算
+	MaxSavedCodeTagLength = 32
+)
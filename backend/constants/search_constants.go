@@ -0,0 +1,11 @@
+package constants
+
+// 搜索结果的类型
+const (
+	// SearchTypeDocument 知识库文档的标题和内容
+	SearchTypeDocument = "document"
+	// SearchTypeDocumentCode 知识库文档中的代码
+	SearchTypeDocumentCode = "documentCode"
+	// SearchTypeSavedCode 用户自己保存的代码
+	SearchTypeSavedCode = "savedCode"
+)
@@ -23,3 +23,43 @@ var VisualTypeList = [5]VisualType{
 	GraphType,
 	LinkListType,
 }
+
+// 导入知识库时存在同名知识库的处理方式
+const (
+	// BankImportRename 创建新的知识库，名称后面添加序号
+	BankImportRename = "rename"
+	// BankImportMerge 导入到同名知识库，标题路径相同的文档覆盖内容，其余文档新增
+	BankImportMerge = "merge"
+	// BankImportFail 不导入，返回错误
+	BankImportFail = "fail"
+)
+
+// 用户阅读文档的进度
+const (
+	// DocumentProgressViewed 已阅读
+	DocumentProgressViewed = 1
+	// DocumentProgressCompleted 已完成，手动标记或者文档中的代码调试到程序结束
+	DocumentProgressCompleted = 2
+)
+
+// 用户在知识库中的角色
+const (
+	// BankRoleOwner 所有者，可以修改知识库信息、删除知识库和管理协作者
+	BankRoleOwner = "owner"
+	// BankRoleEditor 编辑者，可以编辑和发布知识库中的文档
+	BankRoleEditor = "editor"
+	// BankRoleViewer 查看者，只能在管理端查看知识库中的文档
+	BankRoleViewer = "viewer"
+)
+
+// 文档练习的类型
+const (
+	// ExerciseChoice 选择题，可以有多个正确选项
+	ExerciseChoice = "choice"
+	// ExerciseOutput 预测程序的输出
+	ExerciseOutput = "output"
+	// ExerciseTrace 程序第N次暂停时变量的值，答案由调试器运行程序得到
+	ExerciseTrace = "trace"
+	// ExerciseCode 补全代码，运行测试用例判断是否正确
+	ExerciseCode = "code"
+)
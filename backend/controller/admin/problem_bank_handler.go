@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/po"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/problem_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemBankManageController
+// @Description: 题库管理相关功能
+type ProblemBankManageController interface {
+	// InsertProblemBank 添加题库
+	InsertProblemBank(ctx *gin.Context)
+	// UpdateProblemBank 更新题库
+	UpdateProblemBank(ctx *gin.Context)
+	// DeleteProblemBank 删除题库
+	DeleteProblemBank(ctx *gin.Context)
+	// GetProblemBankByID 读取题库信息
+	GetProblemBankByID(ctx *gin.Context)
+	// GetAllProblemBank 获取所有题库
+	GetAllProblemBank(ctx *gin.Context)
+}
+
+type problemBankManageController struct {
+	problemBankService problem_service.ProblemBankService
+}
+
+func NewProblemBankManageController(bankService problem_service.ProblemBankService) ProblemBankManageController {
+	return &problemBankManageController{
+		problemBankService: bankService,
+	}
+}
+
+func (p *problemBankManageController) InsertProblemBank(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	bank := po.ProblemBank{}
+	if err := ctx.BindJSON(&bank); err != nil {
+		result.Error(err)
+		return
+	}
+	bankID, err := p.problemBankService.InsertProblemBank(ctx, &bank)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("题库添加成功", bankID)
+}
+
+func (p *problemBankManageController) UpdateProblemBank(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	bank := po.ProblemBank{}
+	if err := ctx.BindJSON(&bank); err != nil {
+		result.Error(err)
+		return
+	}
+	if err := p.problemBankService.UpdateProblemBank(ctx, &bank); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData("题库修改成功")
+}
+
+func (p *problemBankManageController) DeleteProblemBank(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	bankID := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	if err := p.problemBankService.DeleteProblemBank(ctx, bankID); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData("题库删除成功")
+}
+
+func (p *problemBankManageController) GetProblemBankByID(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	bankID := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	bank, err := p.problemBankService.GetProblemBankByID(ctx, bankID)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(bank)
+}
+
+func (p *problemBankManageController) GetAllProblemBank(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	banks, err := p.problemBankService.GetAllProblemBank(ctx, nil)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(banks)
+}
@@ -0,0 +1,204 @@
+package admin
+
+import (
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/problem_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxProblemCaseFileSize 上传的测试数据文件的大小限制
+const maxProblemCaseFileSize = 64 << 20
+
+// ProblemManageController
+// @Description: 题目及测试用例管理
+type ProblemManageController interface {
+	// InsertProblem 添加题目
+	InsertProblem(ctx *gin.Context)
+	// UpdateProblem 更新题目
+	UpdateProblem(ctx *gin.Context)
+	// DeleteProblem 删除题目
+	DeleteProblem(ctx *gin.Context)
+	// GetProblemByID 读取题目信息
+	GetProblemByID(ctx *gin.Context)
+	// GetProblemList 获取题目列表
+	GetProblemList(ctx *gin.Context)
+	// GetAllProblemTag 获取所有标签
+	GetAllProblemTag(ctx *gin.Context)
+	// InsertProblemCase 添加测试用例
+	InsertProblemCase(ctx *gin.Context)
+	// UpdateProblemCase 更新测试用例
+	UpdateProblemCase(ctx *gin.Context)
+	// DeleteProblemCase 删除测试用例
+	DeleteProblemCase(ctx *gin.Context)
+	// GetProblemCaseList 获取题目的所有测试用例
+	GetProblemCaseList(ctx *gin.Context)
+	// UploadProblemCases 上传zip格式的测试数据
+	UploadProblemCases(ctx *gin.Context)
+}
+
+type problemManageController struct {
+	problemService problem_service.ProblemService
+}
+
+func NewProblemManageController(problemService problem_service.ProblemService) ProblemManageController {
+	return &problemManageController{
+		problemService: problemService,
+	}
+}
+
+func (p *problemManageController) InsertProblem(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problem := dto.ProblemDtoForSave{}
+	if err := ctx.BindJSON(&problem); err != nil {
+		result.Error(err)
+		return
+	}
+	pID, err := p.problemService.InsertProblem(ctx, &problem)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("题目添加成功", pID)
+}
+
+func (p *problemManageController) UpdateProblem(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problem := dto.ProblemDtoForSave{}
+	if err := ctx.BindJSON(&problem); err != nil {
+		result.Error(err)
+		return
+	}
+	if err := p.problemService.UpdateProblem(ctx, &problem); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData("题目修改成功")
+}
+
+func (p *problemManageController) DeleteProblem(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemID := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	if err := p.problemService.DeleteProblem(ctx, problemID); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData("题目删除成功")
+}
+
+func (p *problemManageController) GetProblemByID(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemID := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	problem, err := p.problemService.GetProblemByID(ctx, problemID)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(problem)
+}
+
+func (p *problemManageController) GetProblemList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &dto.ProblemQuery{
+		Number:     ctx.Query("number"),
+		Name:       ctx.Query("name"),
+		BankID:     uint(utils.GetIntQueryOrDefault(ctx, "bankID", 0)),
+		Difficulty: utils.GetIntQueryOrDefault(ctx, "difficulty", 0),
+		Tag:        ctx.Query("tag"),
+	}
+	pageInfo, err := p.problemService.GetProblemList(ctx, pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (p *problemManageController) GetAllProblemTag(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	tags, err := p.problemService.GetAllProblemTag(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(tags)
+}
+
+func (p *problemManageController) InsertProblemCase(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemCase := po.ProblemCase{}
+	if err := ctx.BindJSON(&problemCase); err != nil {
+		result.Error(err)
+		return
+	}
+	caseID, err := p.problemService.InsertProblemCase(ctx, &problemCase)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("测试用例添加成功", caseID)
+}
+
+func (p *problemManageController) UpdateProblemCase(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemCase := po.ProblemCase{}
+	if err := ctx.BindJSON(&problemCase); err != nil {
+		result.Error(err)
+		return
+	}
+	if err := p.problemService.UpdateProblemCase(ctx, &problemCase); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData("测试用例修改成功")
+}
+
+func (p *problemManageController) DeleteProblemCase(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	caseID := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	if err := p.problemService.DeleteProblemCase(ctx, caseID); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData("测试用例删除成功")
+}
+
+func (p *problemManageController) GetProblemCaseList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemID := uint(utils.GetIntQueryOrDefault(ctx, "problemID", 0))
+	problemCases, err := p.problemService.GetProblemCaseList(ctx, problemID)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(problemCases)
+}
+
+func (p *problemManageController) UploadProblemCases(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemID := uint(utils.GetIntParamOrDefault(ctx, "problemID", 0))
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if file.Size > maxProblemCaseFileSize {
+		result.SimpleErrorMessage("测试数据文件大小不能超过64m")
+		return
+	}
+	count, err := p.problemService.UploadProblemCases(ctx, problemID, file)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("测试数据上传成功", count)
+}
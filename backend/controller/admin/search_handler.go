@@ -0,0 +1,34 @@
+package admin
+
+import (
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/search_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchManageController
+// @Description: 搜索索引管理
+type SearchManageController interface {
+	// RebuildIndex 重建搜索索引
+	RebuildIndex(ctx *gin.Context)
+}
+
+type searchManageController struct {
+	searchService search_service.SearchService
+}
+
+func NewSearchManageController(searchService search_service.SearchService) SearchManageController {
+	return &searchManageController{
+		searchService: searchService,
+	}
+}
+
+func (s *searchManageController) RebuildIndex(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	if err := s.searchService.RebuildIndex(ctx); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("索引重建成功")
+}
@@ -1,7 +1,12 @@
 package admin
 
 import (
+	"net/http"
+	"net/url"
+
+	e "github.com/fansqz/fancode-backend/common/error"
 	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/models/po"
 	r "github.com/fansqz/fancode-backend/models/vo"
 	"github.com/fansqz/fancode-backend/service/visual_document_service"
@@ -9,6 +14,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// maxBankArchiveSize 导入知识库的文件大小限制
+const maxBankArchiveSize = 64 << 20
+
 // VisualDocumentBankManageController
 // @Description: 知识库管理相关功能
 type VisualDocumentBankManageController interface {
@@ -22,6 +30,16 @@ type VisualDocumentBankManageController interface {
 	GetVisualDocumentBankByID(ctx *gin.Context)
 	// GetAllVisualDocumentBank 获取所有知识库
 	GetAllVisualDocumentBank(ctx *gin.Context)
+	// ExportVisualDocumentBank 导出知识库为zip文件
+	ExportVisualDocumentBank(ctx *gin.Context)
+	// ImportVisualDocumentBank 从zip文件导入知识库
+	ImportVisualDocumentBank(ctx *gin.Context)
+	// GetVisualDocumentBankMembers 获取知识库的协作者
+	GetVisualDocumentBankMembers(ctx *gin.Context)
+	// SaveVisualDocumentBankMember 添加协作者或修改协作者的角色
+	SaveVisualDocumentBankMember(ctx *gin.Context)
+	// DeleteVisualDocumentBankMember 移除协作者
+	DeleteVisualDocumentBankMember(ctx *gin.Context)
 }
 
 type visualDocumentBankManageController struct {
@@ -78,7 +96,7 @@ func (v *visualDocumentBankManageController) DeleteVisualDocumentBank(ctx *gin.C
 func (v *visualDocumentBankManageController) GetVisualDocumentBankByID(ctx *gin.Context) {
 	result := r.NewResult(ctx)
 	id := utils.GetIntParamOrDefault(ctx, "id", 0)
-	bank, err := v.visualDocumentBankService.GetVisualDocumentBankByID(ctx, uint(id))
+	bank, err := v.visualDocumentBankService.GetVisualDocumentBankByID(ctx, uint(id), nil)
 	if err != nil {
 		result.Error(err)
 		return
@@ -95,3 +113,72 @@ func (v *visualDocumentBankManageController) GetAllVisualDocumentBank(ctx *gin.C
 	}
 	result.SuccessData(banks)
 }
+
+func (v *visualDocumentBankManageController) ExportVisualDocumentBank(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	fileName, data, err := v.visualDocumentBankService.ExportVisualDocumentBank(ctx, id)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	ctx.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(fileName))
+	ctx.Data(http.StatusOK, "application/zip", data)
+}
+
+func (v *visualDocumentBankManageController) ImportVisualDocumentBank(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if file.Size > maxBankArchiveSize {
+		result.SimpleErrorMessage("知识库文件大小不能超过64m")
+		return
+	}
+	conflict := ctx.PostForm("conflict")
+	importResult, err := v.visualDocumentBankService.ImportVisualDocumentBank(ctx, file, conflict)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("知识库导入成功", importResult)
+}
+
+func (v *visualDocumentBankManageController) GetVisualDocumentBankMembers(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	members, err := v.visualDocumentBankService.GetVisualDocumentBankMembers(ctx, id)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(members)
+}
+
+func (v *visualDocumentBankManageController) SaveVisualDocumentBankMember(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	req := dto.SaveVisualDocumentBankMemberReq{}
+	if err := ctx.BindJSON(&req); err != nil {
+		result.Error(err)
+		return
+	}
+	if err := v.visualDocumentBankService.SaveVisualDocumentBankMember(ctx, id, &req); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("协作者保存成功")
+}
+
+func (v *visualDocumentBankManageController) DeleteVisualDocumentBankMember(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	userID := uint(utils.GetIntParamOrDefault(ctx, "userID", 0))
+	if err := v.visualDocumentBankService.DeleteVisualDocumentBankMember(ctx, id, userID); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("协作者移除成功")
+}
@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/visual_document_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VisualDocumentExerciseManageController
+// @Description: 文档练习管理
+type VisualDocumentExerciseManageController interface {
+	// GetExerciseList 获取文档的所有练习
+	GetExerciseList(ctx *gin.Context)
+	// InsertExercise 添加练习
+	InsertExercise(ctx *gin.Context)
+	// UpdateExercise 修改练习
+	UpdateExercise(ctx *gin.Context)
+	// DeleteExercise 删除练习
+	DeleteExercise(ctx *gin.Context)
+	// GetExerciseResults 获取所有用户的作答结果
+	GetExerciseResults(ctx *gin.Context)
+}
+
+func NewVisualDocumentExerciseManageController(es visual_document_service.VisualDocumentExerciseService) VisualDocumentExerciseManageController {
+	return &visualDocumentExerciseManageController{
+		exerciseService: es,
+	}
+}
+
+type visualDocumentExerciseManageController struct {
+	exerciseService visual_document_service.VisualDocumentExerciseService
+}
+
+func (v *visualDocumentExerciseManageController) GetExerciseList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	documentID := utils.GetIntParamOrDefault(ctx, "documentID", 0)
+	exercises, err := v.exerciseService.GetExerciseList(ctx, uint(documentID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(exercises)
+}
+
+func (v *visualDocumentExerciseManageController) InsertExercise(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	exercise := dto.VisualDocumentExerciseDto{}
+	if err := ctx.BindJSON(&exercise); err != nil {
+		result.Error(err)
+		return
+	}
+	id, err := v.exerciseService.InsertExercise(ctx, &exercise)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(id)
+}
+
+func (v *visualDocumentExerciseManageController) UpdateExercise(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	exercise := dto.VisualDocumentExerciseDto{}
+	if err := ctx.BindJSON(&exercise); err != nil {
+		result.Error(err)
+		return
+	}
+	if err := v.exerciseService.UpdateExercise(ctx, &exercise); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("更新成功")
+}
+
+func (v *visualDocumentExerciseManageController) DeleteExercise(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := v.exerciseService.DeleteExercise(ctx, uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+func (v *visualDocumentExerciseManageController) GetExerciseResults(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	results, err := v.exerciseService.GetExerciseResults(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(results)
+}
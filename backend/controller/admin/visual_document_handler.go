@@ -26,6 +26,17 @@ type VisualDocumentManageController interface {
 	UpdateVisualDocumentDirectory(ctx *gin.Context)
 	// DeleteVisualDocumentByID 删除可视化文档
 	DeleteVisualDocumentByID(ctx *gin.Context)
+
+	// GetVisualDocumentRevisionList 获取文档的所有版本
+	GetVisualDocumentRevisionList(ctx *gin.Context)
+	// GetVisualDocumentRevision 获取某个版本的内容
+	GetVisualDocumentRevision(ctx *gin.Context)
+	// DiffVisualDocumentRevision 比较两个版本
+	DiffVisualDocumentRevision(ctx *gin.Context)
+	// RestoreVisualDocumentRevision 将草稿恢复为某个版本
+	RestoreVisualDocumentRevision(ctx *gin.Context)
+	// PublishVisualDocument 发布文档
+	PublishVisualDocument(ctx *gin.Context)
 }
 
 func NewVisualDocumentManageController(vd visual_document_service.VisualDocumentService) VisualDocumentManageController {
@@ -85,11 +96,12 @@ func (v *visualDocumentManageController) UpdateVisualDocument(ctx *gin.Context)
 		result.Error(err)
 		return
 	}
-	if err := v.visualDocumentService.UpdateVisualDocument(ctx, &document); err != nil {
+	version, err := v.visualDocumentService.UpdateVisualDocument(ctx, &document)
+	if err != nil {
 		result.Error(err)
 		return
 	}
-	result.SuccessMessage("更新成功")
+	result.Success("更新成功", version)
 }
 
 func (v *visualDocumentManageController) UpdateVisualDocumentDirectory(ctx *gin.Context) {
@@ -115,3 +127,70 @@ func (v *visualDocumentManageController) DeleteVisualDocumentByID(ctx *gin.Conte
 	}
 	result.SuccessMessage("删除成功")
 }
+
+func (v *visualDocumentManageController) GetVisualDocumentRevisionList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	revisions, err := v.visualDocumentService.GetVisualDocumentRevisionList(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(revisions)
+}
+
+func (v *visualDocumentManageController) GetVisualDocumentRevision(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	revisionID := utils.GetIntParamOrDefault(ctx, "revisionID", 0)
+	revision, err := v.visualDocumentService.GetVisualDocumentRevision(ctx, uint(revisionID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(revision)
+}
+
+func (v *visualDocumentManageController) DiffVisualDocumentRevision(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	from := utils.GetIntQueryOrDefault(ctx, "from", 0)
+	to := utils.GetIntQueryOrDefault(ctx, "to", 0)
+	if from == 0 || to == 0 {
+		result.SimpleErrorMessage("参数错误: from和to不能为空")
+		return
+	}
+	diff, err := v.visualDocumentService.DiffVisualDocumentRevision(ctx, uint(from), uint(to))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(diff)
+}
+
+func (v *visualDocumentManageController) RestoreVisualDocumentRevision(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	revisionID := utils.GetIntParamOrDefault(ctx, "revisionID", 0)
+	revision, err := v.visualDocumentService.RestoreVisualDocumentRevision(ctx, uint(revisionID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("恢复成功", revision)
+}
+
+func (v *visualDocumentManageController) PublishVisualDocument(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	req := dto.PublishVisualDocumentReq{}
+	// 请求体为空时发布当前的草稿
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			result.SimpleErrorMessage("参数错误: " + err.Error())
+			return
+		}
+	}
+	if err := v.visualDocumentService.PublishVisualDocument(ctx, uint(id), req.RevisionID); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("发布成功")
+}
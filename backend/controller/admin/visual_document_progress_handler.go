@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"github.com/fansqz/fancode-backend/controller/utils"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/visual_document_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VisualDocumentProgressManageController
+// @Description: 学习进度统计
+type VisualDocumentProgressManageController interface {
+	// GetBankFunnel 获取知识库每篇文档的阅读和完成人数
+	GetBankFunnel(ctx *gin.Context)
+}
+
+func NewVisualDocumentProgressManageController(ps visual_document_service.VisualDocumentProgressService) VisualDocumentProgressManageController {
+	return &visualDocumentProgressManageController{
+		progressService: ps,
+	}
+}
+
+type visualDocumentProgressManageController struct {
+	progressService visual_document_service.VisualDocumentProgressService
+}
+
+func (v *visualDocumentProgressManageController) GetBankFunnel(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	bankID := utils.GetIntParamOrDefault(ctx, "bankID", 0)
+	funnel, err := v.progressService.GetBankFunnel(ctx, uint(bankID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(funnel)
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/controller/utils"
 	"github.com/fansqz/fancode-backend/models/po"
 	r "github.com/fansqz/fancode-backend/models/vo"
 	"github.com/fansqz/fancode-backend/service/common_service"
@@ -16,15 +17,27 @@ type AuthController interface {
 	SendAuthCode(ctx *gin.Context)
 	// UserRegister 用户注册
 	UserRegister(ctx *gin.Context)
+	// RefreshToken 使用refresh token获取新的token
+	RefreshToken(ctx *gin.Context)
+	// Logout 退出当前设备
+	Logout(ctx *gin.Context)
+	// LogoutAll 退出所有设备
+	LogoutAll(ctx *gin.Context)
+	// GetSessionList 获取已登录的设备
+	GetSessionList(ctx *gin.Context)
+	// RevokeSession 退出某个设备
+	RevokeSession(ctx *gin.Context)
 }
 
 type authController struct {
-	authService common_service.AuthService
+	authService    common_service.AuthService
+	sessionService common_service.SessionService
 }
 
-func NewAuthController(authService common_service.AuthService) AuthController {
+func NewAuthController(authService common_service.AuthService, sessionService common_service.SessionService) AuthController {
 	return &authController{
-		authService: authService,
+		authService:    authService,
+		sessionService: sessionService,
 	}
 }
 
@@ -77,7 +90,7 @@ func (a *authController) passwordLogin(ctx *gin.Context) {
 		result.Error(e.ErrBadRequest)
 		return
 	}
-	token, err := a.authService.PasswordLogin(ctx, account, password)
+	token, err := a.authService.PasswordLogin(ctx, account, password, utils.GetLoginDevice(ctx))
 	if err != nil {
 		result.Error(err)
 		return
@@ -95,10 +108,62 @@ func (a *authController) emailLogin(ctx *gin.Context) {
 		return
 	}
 	// 登录
-	token, err := a.authService.EmailLogin(ctx, email, code)
+	token, err := a.authService.EmailLogin(ctx, email, code, utils.GetLoginDevice(ctx))
 	if err != nil {
 		result.Error(err)
 		return
 	}
 	result.SuccessData(token)
 }
+
+func (a *authController) RefreshToken(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	refreshToken := ctx.PostForm("refreshToken")
+	if refreshToken == "" {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	token, err := a.sessionService.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(token)
+}
+
+func (a *authController) Logout(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	if err := a.sessionService.Logout(ctx); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("退出成功")
+}
+
+func (a *authController) LogoutAll(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	if err := a.sessionService.LogoutAll(ctx); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("已退出所有设备")
+}
+
+func (a *authController) GetSessionList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	sessions, err := a.sessionService.GetSessionList(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(sessions)
+}
+
+func (a *authController) RevokeSession(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	if err := a.sessionService.RevokeSession(ctx, ctx.Param("sessionID")); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("设备已退出")
+}
@@ -9,6 +9,7 @@ import (
 var ProviderSet = wire.NewSet(
 	NewAccountController,
 	NewAuthController,
+	NewOAuthController,
 	NewFileController,
 	user.NewUserSavedCodeHandler,
 	admin.NewSysApiController,
@@ -17,9 +18,23 @@ var ProviderSet = wire.NewSet(
 	admin.NewSysUserController,
 	admin.NewVisualDocumentManageController,
 	admin.NewVisualDocumentBankManageController,
+	admin.NewProblemManageController,
+	admin.NewProblemBankManageController,
 	user.NewDebugController,
+	user.NewDebugAssistController,
 	user.NewVisualController,
 	user.NewVisualDocumentController,
 	user.NewVisualDocumentBankController,
+	user.NewProblemController,
+	user.NewProblemBankController,
+	user.NewJudgeController,
+	user.NewSearchController,
+	admin.NewSearchManageController,
+	user.NewVisualDocumentProgressController,
+	admin.NewVisualDocumentProgressManageController,
+	user.NewVisualDocumentExerciseController,
+	admin.NewVisualDocumentExerciseManageController,
+	user.NewUserCodeController,
+	user.NewCodeRevisionController,
 	NewCommonController,
 )
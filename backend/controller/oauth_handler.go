@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/common_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oauthStateCookie 保存state的cookie，回调时只有发起登录的浏览器能通过state校验
+const oauthStateCookie = "oauth_state"
+
+type OAuthController interface {
+	// GetProviderList 获取可用的第三方登录方式
+	GetProviderList(ctx *gin.Context)
+	// GetAuthorizeURL 获取跳转到第三方授权页面的地址
+	GetAuthorizeURL(ctx *gin.Context)
+	// Login 第三方授权后使用授权码登录
+	Login(ctx *gin.Context)
+	// GetUserOAuthList 获取当前用户绑定的第三方账号
+	GetUserOAuthList(ctx *gin.Context)
+}
+
+type oauthController struct {
+	oauthService common_service.OAuthService
+}
+
+func NewOAuthController(oauthService common_service.OAuthService) OAuthController {
+	return &oauthController{
+		oauthService: oauthService,
+	}
+}
+
+func (o *oauthController) GetProviderList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	result.SuccessData(o.oauthService.GetProviderList(ctx))
+}
+
+func (o *oauthController) GetAuthorizeURL(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	authURL, state, err := o.oauthService.GetAuthorizeURL(ctx, ctx.Param("provider"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	o.setStateCookie(ctx, state, int(common_service.OAuthStateExpire.Seconds()))
+	result.SuccessData(authURL)
+}
+
+func (o *oauthController) Login(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.OAuthLoginRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	state, err := ctx.Cookie(oauthStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(state), []byte(req.State)) != 1 {
+		result.Error(e.ErrOAuthStateInvalid)
+		return
+	}
+	o.setStateCookie(ctx, "", -1)
+	token, err := o.oauthService.Login(ctx, ctx.Param("provider"), &req, utils.GetLoginDevice(ctx))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(token)
+}
+
+func (o *oauthController) GetUserOAuthList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	list, err := o.oauthService.GetUserOAuthList(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(list)
+}
+
+// setStateCookie 设置保存state的cookie，maxAge小于0时删除
+// 授权页面回调到前端后再由前端请求登录，使用Lax时同站点的请求可以带上cookie
+func (o *oauthController) setStateCookie(ctx *gin.Context, state string, maxAge int) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	secure := ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https"
+	ctx.SetCookie(oauthStateCookie, state, maxAge, "/", "", secure, true)
+}
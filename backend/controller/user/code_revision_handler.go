@@ -0,0 +1,114 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/code_revision_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodeRevisionController
+// @Description: 用户保存的代码和题目代码的历史版本
+type CodeRevisionController interface {
+	// GetRevisionList 获取代码的所有历史版本
+	GetRevisionList(ctx *gin.Context)
+	// GetRevision 获取历史版本详情
+	GetRevision(ctx *gin.Context)
+	// DiffRevision 比较两个历史版本
+	DiffRevision(ctx *gin.Context)
+	// CreateCheckpoint 创建检查点
+	CreateCheckpoint(ctx *gin.Context)
+	// DeleteCheckpoint 删除检查点
+	DeleteCheckpoint(ctx *gin.Context)
+	// RestoreRevision 恢复历史版本
+	RestoreRevision(ctx *gin.Context)
+}
+
+func NewCodeRevisionController(rs code_revision_service.CodeRevisionService) CodeRevisionController {
+	return &codeRevisionController{
+		revisionService: rs,
+	}
+}
+
+type codeRevisionController struct {
+	revisionService code_revision_service.CodeRevisionService
+}
+
+func (c *codeRevisionController) GetRevisionList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.CodeRevisionQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.WithCtx(ctx).Errorf("[GetRevisionList] bind query fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+	revisions, err := c.revisionService.GetRevisionList(ctx, &req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(revisions)
+}
+
+func (c *codeRevisionController) GetRevision(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	revision, err := c.revisionService.GetRevision(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(revision)
+}
+
+func (c *codeRevisionController) DiffRevision(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	from := utils.GetIntQueryOrDefault(ctx, "from", 0)
+	to := utils.GetIntQueryOrDefault(ctx, "to", 0)
+	diff, err := c.revisionService.DiffRevision(ctx, uint(from), uint(to))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(diff)
+}
+
+func (c *codeRevisionController) CreateCheckpoint(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.CreateCodeCheckpointRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateCheckpoint] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+	revision, err := c.revisionService.CreateCheckpoint(ctx, &req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(revision)
+}
+
+func (c *codeRevisionController) DeleteCheckpoint(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := c.revisionService.DeleteCheckpoint(ctx, uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+func (c *codeRevisionController) RestoreRevision(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	revision, err := c.revisionService.RestoreRevision(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("恢复成功", revision)
+}
@@ -0,0 +1,111 @@
+package user
+
+import (
+	"context"
+	json2 "encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fansqz/fancode-backend/common/ai_provider"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/models/dto"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugAssistController
+// AI调试助手，依赖于debugger，讲解内容通过sse流式返回
+type DebugAssistController interface {
+	// ExplainStep 讲解程序当前暂停的这一步
+	ExplainStep(ctx *gin.Context)
+	// Diagnose 分析程序崩溃或者卡住的原因
+	Diagnose(ctx *gin.Context)
+	// ExplainCompileError 讲解编译错误并给出修改建议
+	ExplainCompileError(ctx *gin.Context)
+}
+
+type debugAssistController struct {
+	debugAssistService visual_debug_servcie.DebugAssistService
+}
+
+func NewDebugAssistController(debugAssistService visual_debug_servcie.DebugAssistService) DebugAssistController {
+	return &debugAssistController{
+		debugAssistService: debugAssistService,
+	}
+}
+
+// ExplainStep 讲解程序当前暂停的这一步
+func (d *debugAssistController) ExplainStep(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	chunks, err := d.debugAssistService.ExplainStep(requestContext(ctx), ctx.Param("id"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	writeAssistEvents(ctx, chunks)
+}
+
+// Diagnose 分析程序崩溃或者卡住的原因
+func (d *debugAssistController) Diagnose(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	chunks, err := d.debugAssistService.Diagnose(requestContext(ctx), ctx.Param("id"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	writeAssistEvents(ctx, chunks)
+}
+
+// ExplainCompileError 讲解编译错误并给出修改建议
+func (d *debugAssistController) ExplainCompileError(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	chunks, err := d.debugAssistService.ExplainCompileError(requestContext(ctx), ctx.Param("id"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	writeAssistEvents(ctx, chunks)
+}
+
+// requestContext 获取客户端断开连接时会取消的context，用于取消AI服务的流式请求，保留日志id
+func requestContext(ctx *gin.Context) context.Context {
+	reqCtx := ctx.Request.Context()
+	if logID, ok := ctx.Value(logger.LOG_ID_KEY).(string); ok {
+		reqCtx = logger.SetLogID(reqCtx, logID)
+	}
+	return reqCtx
+}
+
+// writeAssistEvents 将讲解内容通过sse返回给前端，客户端断开连接或者写入失败时停止
+func writeAssistEvents(ctx *gin.Context, chunks <-chan *ai_provider.StreamChunk) {
+	w := ctx.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher, _ := w.(http.Flusher)
+	done := ctx.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		case chunk, ok := <-chunks:
+			if !ok {
+				return
+			}
+			json, err := json2.Marshal(dto.NewAssistEvent(chunk))
+			if err != nil {
+				continue
+			}
+			if _, err = fmt.Fprintf(w, "data: %s\n\n", string(json)); err != nil {
+				logger.WithCtx(ctx).Warnf("[writeAssistEvents] write event fail, err = %v", err)
+				return
+			}
+			// 刷新缓冲，确保立即发送到客户端
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
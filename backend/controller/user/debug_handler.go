@@ -13,6 +13,10 @@ type DebugController interface {
 	CreateDebugSession(ctx *gin.Context)
 	// Start 启动调试
 	Start(ctx *gin.Context)
+	// StartSnippet 启动调试文档中的代码示例
+	StartSnippet(ctx *gin.Context)
+	// StartShare 启动调试通过链接分享的代码
+	StartShare(ctx *gin.Context)
 	// CreateSseConnect 会创建一个sse链接，用于接受服务器响应
 	CreateSseConnect(ctx *gin.Context)
 	// SendToConsole 提交
@@ -72,6 +76,36 @@ func (d *debugController) Start(ctx *gin.Context) {
 	result.SuccessMessage("启动成功")
 }
 
+// StartSnippet 直接调试文档中的代码示例
+func (d *debugController) StartSnippet(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.StartSnippetDebugRequest
+	if err := ctx.BindJSON(&req); err != nil {
+		return
+	}
+	err := d.debugService.StartSnippet(ctx, req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("启动成功")
+}
+
+// StartShare 调试通过链接分享的代码
+func (d *debugController) StartShare(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.StartShareDebugRequest
+	if err := ctx.BindJSON(&req); err != nil {
+		return
+	}
+	err := d.debugService.StartShare(ctx, req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("启动成功")
+}
+
 // CreateSseConnect
 func (d *debugController) CreateSseConnect(ctx *gin.Context) {
 	id := ctx.Param("id")
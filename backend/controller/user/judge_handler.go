@@ -0,0 +1,93 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/user_coding_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JudgeController
+// @Description: 提交代码以及查看提交记录
+type JudgeController interface {
+	// Submit 提交代码，返回提交记录id，通过提交记录查看判题结果
+	Submit(ctx *gin.Context)
+	// GetSubmission 获取提交记录详情
+	GetSubmission(ctx *gin.Context)
+	// GetSubmissionList 获取提交记录列表
+	GetSubmissionList(ctx *gin.Context)
+	// Run 使用自定义输入运行代码，返回输出、退出码和资源使用情况
+	Run(ctx *gin.Context)
+}
+
+type judgeController struct {
+	judgeService user_coding_service.JudgeService
+}
+
+func NewJudgeController(judgeService user_coding_service.JudgeService) JudgeController {
+	return &judgeController{
+		judgeService: judgeService,
+	}
+}
+
+func (j *judgeController) Submit(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.SubmitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(ctx).Errorf("[Submit] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+	submissionID, err := j.judgeService.Submit(ctx, &req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("提交成功", submissionID)
+}
+
+func (j *judgeController) GetSubmission(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	submissionID := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	submission, err := j.judgeService.GetSubmission(ctx, submissionID)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(submission)
+}
+
+func (j *judgeController) GetSubmissionList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.SubmissionDtoForQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.WithCtx(ctx).Errorf("[GetSubmissionList] bind query fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+	pageInfo, err := j.judgeService.GetSubmissionList(ctx, &req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (j *judgeController) Run(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.RunRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(ctx).Errorf("[Run] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+	runResult, err := j.judgeService.Run(ctx, &req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(runResult)
+}
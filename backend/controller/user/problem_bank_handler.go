@@ -0,0 +1,55 @@
+package user
+
+import (
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/controller/utils"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/problem_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemBankController
+// @Description: 用户浏览题库，只能看到已启用的题库
+type ProblemBankController interface {
+	// GetAllProblemBank 获取所有启用的题库
+	GetAllProblemBank(ctx *gin.Context)
+	// GetProblemBankByID 读取题库信息
+	GetProblemBankByID(ctx *gin.Context)
+}
+
+type problemBankController struct {
+	problemBankService problem_service.ProblemBankService
+}
+
+func NewProblemBankController(bankService problem_service.ProblemBankService) ProblemBankController {
+	return &problemBankController{
+		problemBankService: bankService,
+	}
+}
+
+func (p *problemBankController) GetAllProblemBank(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	enable := true
+	banks, err := p.problemBankService.GetAllProblemBank(ctx, &enable)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(banks)
+}
+
+func (p *problemBankController) GetProblemBankByID(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	bankID := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	bank, err := p.problemBankService.GetProblemBankByID(ctx, bankID)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	if !bank.Enable {
+		result.Error(e.ErrProblemBankNotExist)
+		return
+	}
+	result.SuccessData(bank)
+}
@@ -0,0 +1,88 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/problem_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemController
+// @Description: 用户查看题目，只能看到已启用的题目
+type ProblemController interface {
+	// GetProblemList 获取题目列表
+	GetProblemList(ctx *gin.Context)
+	// GetProblem 获取题目详情
+	GetProblem(ctx *gin.Context)
+	// GetProblemTemplate 获取题目在某个语言下的代码模板
+	GetProblemTemplate(ctx *gin.Context)
+	// GetAllProblemTag 获取所有标签
+	GetAllProblemTag(ctx *gin.Context)
+}
+
+type problemController struct {
+	problemService problem_service.ProblemService
+}
+
+func NewProblemController(problemService problem_service.ProblemService) ProblemController {
+	return &problemController{
+		problemService: problemService,
+	}
+}
+
+func (p *problemController) GetProblemList(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &dto.ProblemQuery{
+		Number:     ctx.Query("number"),
+		Name:       ctx.Query("name"),
+		BankID:     uint(utils.GetIntQueryOrDefault(ctx, "bankID", 0)),
+		Difficulty: utils.GetIntQueryOrDefault(ctx, "difficulty", 0),
+		Tag:        ctx.Query("tag"),
+		Enable:     true,
+	}
+	pageInfo, err := p.problemService.GetProblemList(ctx, pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (p *problemController) GetProblem(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemID := uint(utils.GetIntParamOrDefault(ctx, "id", 0))
+	problem, err := p.problemService.GetEnabledProblem(ctx, problemID)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(problem)
+}
+
+func (p *problemController) GetProblemTemplate(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemID := uint(utils.GetIntQueryOrDefault(ctx, "problemID", 0))
+	code, err := p.problemService.GetProblemTemplate(ctx, problemID, ctx.Query("language"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(code)
+}
+
+func (p *problemController) GetAllProblemTag(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	tags, err := p.problemService.GetAllProblemTag(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(tags)
+}
@@ -0,0 +1,48 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/search_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchController
+// @Description: 搜索知识库文档和用户保存的代码
+type SearchController interface {
+	// Search 搜索，支持按类型、知识库和编程语言过滤
+	Search(ctx *gin.Context)
+}
+
+type searchController struct {
+	searchService search_service.SearchService
+}
+
+func NewSearchController(searchService search_service.SearchService) SearchController {
+	return &searchController{
+		searchService: searchService,
+	}
+}
+
+func (s *searchController) Search(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &dto.SearchQuery{
+		Keyword:  ctx.Query("keyword"),
+		Type:     ctx.Query("type"),
+		BankID:   uint(utils.GetIntQueryOrDefault(ctx, "bankID", 0)),
+		Language: ctx.Query("language"),
+	}
+	pageInfo, err := s.searchService.Search(ctx, pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
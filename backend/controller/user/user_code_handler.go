@@ -0,0 +1,64 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/user_coding_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserCodeController
+// @Description: 用户在题目中保存的代码
+type UserCodeController interface {
+	// GetUserCode 读取用户在题目中某个语言的代码
+	GetUserCode(ctx *gin.Context)
+	// SaveUserCode 保存用户在题目中的代码
+	SaveUserCode(ctx *gin.Context)
+}
+
+func NewUserCodeController(cs user_coding_service.UserCodeService) UserCodeController {
+	return &userCodeController{
+		codeService: cs,
+	}
+}
+
+type userCodeController struct {
+	codeService user_coding_service.UserCodeService
+}
+
+func (u *userCodeController) GetUserCode(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	problemID := utils.GetIntParamOrDefault(ctx, "problemID", 0)
+	language := constants.LanguageType(ctx.Query("language"))
+	code, err := u.codeService.GetUserCodeDetail(ctx, uint(problemID), language)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(code)
+}
+
+func (u *userCodeController) SaveUserCode(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	var req dto.SaveUserCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(ctx).Errorf("[SaveUserCode] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+	code := &po.UserCode{
+		ProblemID: req.ProblemID,
+		Language:  req.Language,
+		Code:      req.Code,
+	}
+	if err := u.codeService.SaveUserCode(ctx, code); err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("保存成功", dto.NewUserCodeDto(code))
+}
@@ -128,3 +128,242 @@ func (h *UserSavedCodeHandler) GetUserSavedCodeList(c *gin.Context) {
 
 	result.SuccessData(pageInfo)
 }
+
+// UpdateVisualDescription 更新用户保存代码的可视化描述
+func (h *UserSavedCodeHandler) UpdateVisualDescription(c *gin.Context) {
+	result := r.NewResult(c)
+	var req dto.UpdateSavedCodeVisualDescriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(c).Errorf("[UpdateVisualDescription] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+
+	if err := h.savedCodeService.UpdateVisualDescription(c, &req); err != nil {
+		logger.WithCtx(c).Errorf("[UpdateVisualDescription] update fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessMessage("更新成功")
+}
+
+// CreateShare 分享用户保存的代码
+func (h *UserSavedCodeHandler) CreateShare(c *gin.Context) {
+	result := r.NewResult(c)
+	var req dto.CreateSavedCodeShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(c).Errorf("[CreateShare] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+
+	data, err := h.savedCodeService.CreateShare(c, &req)
+	if err != nil {
+		logger.WithCtx(c).Errorf("[CreateShare] create fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessData(data)
+}
+
+// GetShareList 获取用户保存代码的所有分享
+func (h *UserSavedCodeHandler) GetShareList(c *gin.Context) {
+	result := r.NewResult(c)
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		logger.WithCtx(c).Errorf("[GetShareList] parse id fail, err = %v", err)
+		result.SimpleErrorMessage("无效的ID")
+		return
+	}
+
+	data, err := h.savedCodeService.GetShareList(c, uint(id))
+	if err != nil {
+		logger.WithCtx(c).Errorf("[GetShareList] get fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessData(data)
+}
+
+// DeleteShare 取消分享
+func (h *UserSavedCodeHandler) DeleteShare(c *gin.Context) {
+	result := r.NewResult(c)
+	if err := h.savedCodeService.DeleteShare(c, c.Param("shareID")); err != nil {
+		logger.WithCtx(c).Errorf("[DeleteShare] delete fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessMessage("取消分享成功")
+}
+
+// GetSharedCode 通过分享链接获取代码
+func (h *UserSavedCodeHandler) GetSharedCode(c *gin.Context) {
+	result := r.NewResult(c)
+	data, err := h.savedCodeService.GetSharedCode(c, c.Param("shareID"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+
+	result.SuccessData(data)
+}
+
+// ForkSharedCode 将分享的代码复制到自己保存的代码中
+func (h *UserSavedCodeHandler) ForkSharedCode(c *gin.Context) {
+	result := r.NewResult(c)
+	data, err := h.savedCodeService.ForkSharedCode(c, c.Param("shareID"))
+	if err != nil {
+		logger.WithCtx(c).Errorf("[ForkSharedCode] fork fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessData(data)
+}
+
+// UpdateMeta 修改代码的标题、文件夹和标签
+func (h *UserSavedCodeHandler) UpdateMeta(c *gin.Context) {
+	result := r.NewResult(c)
+	var req dto.UpdateSavedCodeMetaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(c).Errorf("[UpdateMeta] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+
+	if err := h.savedCodeService.UpdateMeta(c, &req); err != nil {
+		logger.WithCtx(c).Errorf("[UpdateMeta] update fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessMessage("更新成功")
+}
+
+// StarSavedCode 收藏或取消收藏代码
+func (h *UserSavedCodeHandler) StarSavedCode(c *gin.Context) {
+	result := r.NewResult(c)
+	var req dto.StarSavedCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(c).Errorf("[StarSavedCode] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+
+	if err := h.savedCodeService.StarSavedCode(c, &req); err != nil {
+		logger.WithCtx(c).Errorf("[StarSavedCode] star fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessMessage("更新成功")
+}
+
+// BatchMove 批量移动代码到文件夹
+func (h *UserSavedCodeHandler) BatchMove(c *gin.Context) {
+	result := r.NewResult(c)
+	var req dto.BatchMoveSavedCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(c).Errorf("[BatchMove] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+
+	if err := h.savedCodeService.BatchMove(c, &req); err != nil {
+		logger.WithCtx(c).Errorf("[BatchMove] move fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessMessage("移动成功")
+}
+
+// BatchDelete 批量删除代码
+func (h *UserSavedCodeHandler) BatchDelete(c *gin.Context) {
+	result := r.NewResult(c)
+	var req dto.BatchDeleteSavedCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(c).Errorf("[BatchDelete] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+
+	if err := h.savedCodeService.BatchDelete(c, &req); err != nil {
+		logger.WithCtx(c).Errorf("[BatchDelete] delete fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessMessage("删除成功")
+}
+
+// GetFolderList 获取用户的所有文件夹
+func (h *UserSavedCodeHandler) GetFolderList(c *gin.Context) {
+	result := r.NewResult(c)
+	data, err := h.savedCodeService.GetFolderList(c)
+	if err != nil {
+		logger.WithCtx(c).Errorf("[GetFolderList] get list fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessData(data)
+}
+
+// SaveFolder 创建或重命名文件夹
+func (h *UserSavedCodeHandler) SaveFolder(c *gin.Context) {
+	result := r.NewResult(c)
+	var req dto.SavedCodeFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithCtx(c).Errorf("[SaveFolder] bind json fail, err = %v", err)
+		result.SimpleErrorMessage("参数错误: " + err.Error())
+		return
+	}
+
+	data, err := h.savedCodeService.SaveFolder(c, &req)
+	if err != nil {
+		logger.WithCtx(c).Errorf("[SaveFolder] save fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessData(data)
+}
+
+// DeleteFolder 删除文件夹
+func (h *UserSavedCodeHandler) DeleteFolder(c *gin.Context) {
+	result := r.NewResult(c)
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		logger.WithCtx(c).Errorf("[DeleteFolder] parse id fail, err = %v", err)
+		result.SimpleErrorMessage("无效的ID")
+		return
+	}
+
+	if err = h.savedCodeService.DeleteFolder(c, uint(id)); err != nil {
+		logger.WithCtx(c).Errorf("[DeleteFolder] delete fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessMessage("删除成功")
+}
+
+// GetTagList 获取用户使用过的所有标签
+func (h *UserSavedCodeHandler) GetTagList(c *gin.Context) {
+	result := r.NewResult(c)
+	data, err := h.savedCodeService.GetTagList(c)
+	if err != nil {
+		logger.WithCtx(c).Errorf("[GetTagList] get list fail, err = %v", err)
+		result.Error(err)
+		return
+	}
+
+	result.SuccessData(data)
+}
@@ -41,7 +41,8 @@ func (v *visualDocumentBankController) GetAllVisualDocumentBank(ctx *gin.Context
 func (v *visualDocumentBankController) GetVisualDocumentBankByID(ctx *gin.Context) {
 	result := r.NewResult(ctx)
 	id := utils.GetIntParamOrDefault(ctx, "id", 0)
-	bank, err := v.visualDocumentBankService.GetVisualDocumentBankByID(ctx, uint(id))
+	enable := true
+	bank, err := v.visualDocumentBankService.GetVisualDocumentBankByID(ctx, uint(id), &enable)
 	if err != nil {
 		result.Error(err)
 		return
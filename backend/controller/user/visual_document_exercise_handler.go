@@ -0,0 +1,56 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/utils"
+	"github.com/fansqz/fancode-backend/models/dto"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/visual_document_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VisualDocumentExerciseController
+// @Description: 文档练习
+type VisualDocumentExerciseController interface {
+	// GetDocumentExercises 获取文档的练习和当前用户的作答结果
+	GetDocumentExercises(ctx *gin.Context)
+	// SubmitExercise 提交练习的答案
+	SubmitExercise(ctx *gin.Context)
+}
+
+func NewVisualDocumentExerciseController(es visual_document_service.VisualDocumentExerciseService) VisualDocumentExerciseController {
+	return &visualDocumentExerciseController{
+		exerciseService: es,
+	}
+}
+
+type visualDocumentExerciseController struct {
+	exerciseService visual_document_service.VisualDocumentExerciseService
+}
+
+func (v *visualDocumentExerciseController) GetDocumentExercises(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	documentID := utils.GetIntParamOrDefault(ctx, "documentID", 0)
+	exercises, err := v.exerciseService.GetDocumentExercises(ctx, uint(documentID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(exercises)
+}
+
+func (v *visualDocumentExerciseController) SubmitExercise(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	req := dto.SubmitExerciseReq{}
+	if err := ctx.BindJSON(&req); err != nil {
+		result.Error(err)
+		return
+	}
+	answer, err := v.exerciseService.SubmitExercise(ctx, uint(id), &req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(answer)
+}
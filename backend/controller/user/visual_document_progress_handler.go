@@ -0,0 +1,62 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/utils"
+	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/visual_document_service"
+	utils2 "github.com/fansqz/fancode-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VisualDocumentProgressController
+// @Description: 用户的学习进度
+type VisualDocumentProgressController interface {
+	// CompleteDocument 标记文档已完成
+	CompleteDocument(ctx *gin.Context)
+	// GetBankProgress 获取知识库的学习进度
+	GetBankProgress(ctx *gin.Context)
+	// GetContinueLearning 获取继续学习的文档
+	GetContinueLearning(ctx *gin.Context)
+}
+
+func NewVisualDocumentProgressController(ps visual_document_service.VisualDocumentProgressService) VisualDocumentProgressController {
+	return &visualDocumentProgressController{
+		progressService: ps,
+	}
+}
+
+type visualDocumentProgressController struct {
+	progressService visual_document_service.VisualDocumentProgressService
+}
+
+func (v *visualDocumentProgressController) CompleteDocument(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	documentID := utils.GetIntParamOrDefault(ctx, "documentID", 0)
+	if err := v.progressService.CompleteDocument(ctx, utils2.GetUserIDWithCtx(ctx), uint(documentID)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("已完成")
+}
+
+func (v *visualDocumentProgressController) GetBankProgress(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	bankID := utils.GetIntParamOrDefault(ctx, "bankID", 0)
+	progress, err := v.progressService.GetBankProgress(ctx, uint(bankID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(progress)
+}
+
+func (v *visualDocumentProgressController) GetContinueLearning(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	answer, err := v.progressService.GetContinueLearning(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(answer)
+}
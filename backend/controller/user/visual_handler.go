@@ -20,6 +20,8 @@ type VisualController interface {
 	Array2DVisual(ctx *gin.Context)
 	// GetVisualDescription 获取用户可视化描述解析结果
 	GetVisualDescription(ctx *gin.Context)
+	// UpdateVisualDescription 用户修改可视化描述
+	UpdateVisualDescription(ctx *gin.Context)
 }
 
 type visualController struct {
@@ -90,3 +92,19 @@ func (v *visualController) GetVisualDescription(ctx *gin.Context) {
 	}
 	result.SuccessData(visualDescription)
 }
+
+// UpdateVisualDescription 用户修改可视化描述
+func (v *visualController) UpdateVisualDescription(ctx *gin.Context) {
+	result := r.NewResult(ctx)
+	req := dto.UpdateVisualDescriptionRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	checkResult, err := v.visualService.UpdateVisualDescription(ctx, &req)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(checkResult)
+}
@@ -73,3 +73,11 @@ func GetPageQueryByQuery(ctx *gin.Context) (*dto.PageQuery, error) {
 	}
 	return answer, nil
 }
+
+// GetLoginDevice 获取登录设备的信息
+func GetLoginDevice(ctx *gin.Context) *dto.LoginDeviceDto {
+	return &dto.LoginDeviceDto{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	}
+}
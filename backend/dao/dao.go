@@ -3,12 +3,30 @@ package dao
 import "github.com/google/wire"
 
 var ProviderSet = wire.NewSet(
+	NewProblemDao,
+	NewProblemCaseDao,
+	NewProblemBankDao,
+	NewProblemTagDao,
+	NewProblemTemplateDao,
+	NewSubmissionDao,
 	NewSysApiDao,
 	NewSysMenuDao,
 	NewSysRoleDao,
 	NewSysUserDao,
+	NewSysUserOAuthDao,
+	NewSysUserSessionDao,
 	NewUserCodeDao,
 	NewUserSavedCodeDao,
+	NewUserSavedCodeShareDao,
+	NewUserSavedCodeFolderDao,
+	NewUserSavedCodeTagDao,
+	NewUserCodeRevisionDao,
 	NewVisualDocumentDao,
+	NewVisualDocumentRevisionDao,
+	NewVisualDocumentProgressDao,
+	NewVisualDocumentBankMemberDao,
+	NewVisualDocumentSnippetDao,
+	NewVisualDocumentExerciseDao,
+	NewSearchEntryDao,
 	NewVisualDocumentBankDao,
 )
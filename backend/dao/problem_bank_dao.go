@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type ProblemBankDao interface {
+	// InsertProblemBank 添加题库
+	InsertProblemBank(db *gorm.DB, bank *po.ProblemBank) error
+	// GetProblemBankByID 根据题库id获取题库
+	GetProblemBankByID(db *gorm.DB, bankID uint) (*po.ProblemBank, error)
+	// UpdateProblemBank 更新题库
+	UpdateProblemBank(db *gorm.DB, bank *po.ProblemBank) error
+	// DeleteProblemBankByID 删除题库
+	DeleteProblemBankByID(db *gorm.DB, id uint) error
+	// GetAllProblemBank 获取所有的题库
+	GetAllProblemBank(db *gorm.DB) ([]*po.ProblemBank, error)
+}
+
+type problemBankDao struct {
+}
+
+func NewProblemBankDao() ProblemBankDao {
+	return &problemBankDao{}
+}
+
+func (p *problemBankDao) InsertProblemBank(db *gorm.DB, bank *po.ProblemBank) error {
+	return db.Create(bank).Error
+}
+
+func (p *problemBankDao) GetProblemBankByID(db *gorm.DB, bankID uint) (*po.ProblemBank, error) {
+	bank := &po.ProblemBank{}
+	err := db.First(bank, bankID).Error
+	return bank, err
+}
+
+func (p *problemBankDao) UpdateProblemBank(db *gorm.DB, bank *po.ProblemBank) error {
+	return db.Model(&po.ProblemBank{}).Where("id = ?", bank.ID).Updates(map[string]interface{}{
+		"name":        bank.Name,
+		"description": bank.Description,
+		"enable":      bank.Enable,
+	}).Error
+}
+
+func (p *problemBankDao) DeleteProblemBankByID(db *gorm.DB, id uint) error {
+	return db.Delete(&po.ProblemBank{}, id).Error
+}
+
+func (p *problemBankDao) GetAllProblemBank(db *gorm.DB) ([]*po.ProblemBank, error) {
+	var banks []*po.ProblemBank
+	err := db.Order("id").Find(&banks).Error
+	return banks, err
+}
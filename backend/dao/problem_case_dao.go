@@ -0,0 +1,87 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type ProblemCaseDao interface {
+	// InsertProblemCase 添加测试用例
+	InsertProblemCase(db *gorm.DB, problemCase *po.ProblemCase) error
+	// GetProblemCaseByID 根据id获取测试用例
+	GetProblemCaseByID(db *gorm.DB, id uint) (*po.ProblemCase, error)
+	// UpdateProblemCase 更新测试用例
+	UpdateProblemCase(db *gorm.DB, problemCase *po.ProblemCase) error
+	// DeleteProblemCaseByID 删除测试用例
+	DeleteProblemCaseByID(db *gorm.DB, id uint) error
+	// DeleteProblemCasesByProblemID 删除题目的所有测试用例
+	DeleteProblemCasesByProblemID(db *gorm.DB, problemID uint) error
+	// InsertProblemCases 批量添加测试用例
+	InsertProblemCases(db *gorm.DB, problemCases []*po.ProblemCase) error
+	// GetSampleCasesByProblemID 获取题目的样例
+	GetSampleCasesByProblemID(db *gorm.DB, problemID uint) ([]*po.ProblemCase, error)
+	// DeleteProblemCasesByProblemIDs 删除多个题目的测试用例
+	DeleteProblemCasesByProblemIDs(db *gorm.DB, problemIDs []uint) error
+	// GetProblemCasesByProblemID 获取题目的所有测试用例
+	GetProblemCasesByProblemID(db *gorm.DB, problemID uint) ([]*po.ProblemCase, error)
+}
+
+type problemCaseDao struct {
+}
+
+func NewProblemCaseDao() ProblemCaseDao {
+	return &problemCaseDao{}
+}
+
+func (p *problemCaseDao) InsertProblemCase(db *gorm.DB, problemCase *po.ProblemCase) error {
+	return db.Create(problemCase).Error
+}
+
+func (p *problemCaseDao) GetProblemCaseByID(db *gorm.DB, id uint) (*po.ProblemCase, error) {
+	problemCase := &po.ProblemCase{}
+	err := db.First(problemCase, id).Error
+	return problemCase, err
+}
+
+func (p *problemCaseDao) UpdateProblemCase(db *gorm.DB, problemCase *po.ProblemCase) error {
+	return db.Model(&po.ProblemCase{}).Where("id = ?", problemCase.ID).Updates(map[string]interface{}{
+		"name":   problemCase.Name,
+		"input":  problemCase.Input,
+		"output": problemCase.Output,
+		"sample": problemCase.Sample,
+	}).Error
+}
+
+func (p *problemCaseDao) DeleteProblemCaseByID(db *gorm.DB, id uint) error {
+	return db.Delete(&po.ProblemCase{}, id).Error
+}
+
+func (p *problemCaseDao) DeleteProblemCasesByProblemID(db *gorm.DB, problemID uint) error {
+	return db.Where("problem_id = ?", problemID).Delete(&po.ProblemCase{}).Error
+}
+
+func (p *problemCaseDao) GetProblemCasesByProblemID(db *gorm.DB, problemID uint) ([]*po.ProblemCase, error) {
+	var problemCases []*po.ProblemCase
+	err := db.Where("problem_id = ?", problemID).Order("id").Find(&problemCases).Error
+	return problemCases, err
+}
+
+func (p *problemCaseDao) InsertProblemCases(db *gorm.DB, problemCases []*po.ProblemCase) error {
+	if len(problemCases) == 0 {
+		return nil
+	}
+	return db.Create(problemCases).Error
+}
+
+func (p *problemCaseDao) GetSampleCasesByProblemID(db *gorm.DB, problemID uint) ([]*po.ProblemCase, error) {
+	var problemCases []*po.ProblemCase
+	err := db.Where("problem_id = ? AND sample = ?", problemID, true).Order("id").Find(&problemCases).Error
+	return problemCases, err
+}
+
+func (p *problemCaseDao) DeleteProblemCasesByProblemIDs(db *gorm.DB, problemIDs []uint) error {
+	if len(problemIDs) == 0 {
+		return nil
+	}
+	return db.Where("problem_id IN ?", problemIDs).Delete(&po.ProblemCase{}).Error
+}
@@ -0,0 +1,166 @@
+package dao
+
+import (
+	"errors"
+
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type ProblemDao interface {
+	// InsertProblem 添加题目
+	InsertProblem(db *gorm.DB, problem *po.Problem) error
+	// GetProblemByID 根据题目id获取题目
+	GetProblemByID(db *gorm.DB, problemID uint) (*po.Problem, error)
+	// CheckProblemNumberExists 检查题目编号是否已经存在
+	CheckProblemNumberExists(db *gorm.DB, number string) (bool, error)
+	// UpdateProblem 更新题目
+	UpdateProblem(db *gorm.DB, problem *po.Problem) error
+	// DeleteProblemByID 删除题目
+	DeleteProblemByID(db *gorm.DB, id uint) error
+	// GetProblemList 获取题目列表，会同时读取题目的标签
+	GetProblemList(db *gorm.DB, pageQuery *dto.PageQuery) ([]*po.Problem, error)
+	// GetProblemCount 获取题目数量
+	GetProblemCount(db *gorm.DB, query *dto.ProblemQuery) (int64, error)
+	// GetProblemTags 获取题目的标签
+	GetProblemTags(db *gorm.DB, problem *po.Problem) ([]*po.ProblemTag, error)
+	// ReplaceProblemTags 替换题目的标签
+	ReplaceProblemTags(db *gorm.DB, problem *po.Problem, tags []*po.ProblemTag) error
+	// ClearProblemTags 清空题目的标签
+	ClearProblemTags(db *gorm.DB, problem *po.Problem) error
+	// DeleteProblemsByBankID 删除题库中的所有题目
+	DeleteProblemsByBankID(db *gorm.DB, bankID uint) error
+	// GetProblemIDsByBankID 获取题库中的所有题目id
+	GetProblemIDsByBankID(db *gorm.DB, bankID uint) ([]uint, error)
+}
+
+type problemDao struct {
+}
+
+func NewProblemDao() ProblemDao {
+	return &problemDao{}
+}
+
+func (p *problemDao) InsertProblem(db *gorm.DB, problem *po.Problem) error {
+	return db.Create(problem).Error
+}
+
+func (p *problemDao) GetProblemByID(db *gorm.DB, problemID uint) (*po.Problem, error) {
+	problem := &po.Problem{}
+	err := db.First(problem, problemID).Error
+	return problem, err
+}
+
+func (p *problemDao) CheckProblemNumberExists(db *gorm.DB, number string) (bool, error) {
+	problem := &po.Problem{}
+	err := db.Where("number = ?", number).First(problem).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (p *problemDao) UpdateProblem(db *gorm.DB, problem *po.Problem) error {
+	return db.Model(&po.Problem{}).Where("id = ?", problem.ID).Updates(map[string]interface{}{
+		"number":              problem.Number,
+		"name":                problem.Name,
+		"description":         problem.Description,
+		"bank_id":             problem.BankID,
+		"difficulty":          problem.Difficulty,
+		"checker":             problem.Checker,
+		"checker_epsilon":     problem.CheckerEpsilon,
+		"checker_language":    problem.CheckerLanguage,
+		"checker_code":        problem.CheckerCode,
+		"interactive":         problem.Interactive,
+		"interactor_language": problem.InteractorLanguage,
+		"interactor_code":     problem.InteractorCode,
+		"time_limit":          problem.TimeLimit,
+		"memory_limit":        problem.MemoryLimit,
+		"enable":              problem.Enable,
+	}).Error
+}
+
+func (p *problemDao) DeleteProblemByID(db *gorm.DB, id uint) error {
+	return db.Delete(&po.Problem{}, id).Error
+}
+
+func (p *problemDao) GetProblemList(db *gorm.DB, pageQuery *dto.PageQuery) ([]*po.Problem, error) {
+	var query *dto.ProblemQuery
+	if pageQuery.Query != nil {
+		query = pageQuery.Query.(*dto.ProblemQuery)
+	}
+	db = p.problemQuery(db, query)
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var problems []*po.Problem
+	err := db.Preload("Tags").Order("number").Limit(pageQuery.PageSize).Offset(offset).Find(&problems).Error
+	return problems, err
+}
+
+func (p *problemDao) GetProblemCount(db *gorm.DB, query *dto.ProblemQuery) (int64, error) {
+	var count int64
+	err := p.problemQuery(db, query).Model(&po.Problem{}).Count(&count).Error
+	return count, err
+}
+
+func (p *problemDao) GetProblemTags(db *gorm.DB, problem *po.Problem) ([]*po.ProblemTag, error) {
+	var tags []*po.ProblemTag
+	err := db.Model(problem).Association("Tags").Find(&tags)
+	return tags, err
+}
+
+func (p *problemDao) ReplaceProblemTags(db *gorm.DB, problem *po.Problem, tags []*po.ProblemTag) error {
+	if err := db.Model(problem).Association("Tags").Clear(); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return db.Model(problem).Association("Tags").Append(tags)
+}
+
+func (p *problemDao) ClearProblemTags(db *gorm.DB, problem *po.Problem) error {
+	return db.Model(problem).Association("Tags").Clear()
+}
+
+func (p *problemDao) DeleteProblemsByBankID(db *gorm.DB, bankID uint) error {
+	return db.Where("bank_id = ?", bankID).Delete(&po.Problem{}).Error
+}
+
+func (p *problemDao) GetProblemIDsByBankID(db *gorm.DB, bankID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&po.Problem{}).Where("bank_id = ?", bankID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// problemQuery 题目列表的查询条件
+func (p *problemDao) problemQuery(db *gorm.DB, query *dto.ProblemQuery) *gorm.DB {
+	if query == nil {
+		return db
+	}
+	if query.Number != "" {
+		db = db.Where("number LIKE ?", "%"+query.Number+"%")
+	}
+	if query.Name != "" {
+		db = db.Where("name LIKE ?", "%"+query.Name+"%")
+	}
+	if query.BankID != 0 {
+		db = db.Where("bank_id = ?", query.BankID)
+	}
+	if query.Difficulty != 0 {
+		db = db.Where("difficulty = ?", query.Difficulty)
+	}
+	if query.Tag != "" {
+		db = db.Where("id IN (?)", db.Session(&gorm.Session{NewDB: true}).Table("problem_tag_relations").
+			Select("problem_tag_relations.problem_id").
+			Joins("JOIN problem_tags ON problem_tags.id = problem_tag_relations.problem_tag_id").
+			Where("problem_tags.name = ? AND problem_tags.deleted_at IS NULL", query.Tag))
+	}
+	if query.Enable {
+		// 题目所在的题库也需要是启用的
+		db = db.Where("enable = ?", true).
+			Where("bank_id = 0 OR bank_id IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&po.ProblemBank{}).
+				Select("id").Where("enable = ?", true))
+	}
+	return db
+}
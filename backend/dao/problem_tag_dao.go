@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type ProblemTagDao interface {
+	// GetOrCreateProblemTags 根据名称获取标签，不存在的标签会被创建
+	GetOrCreateProblemTags(db *gorm.DB, names []string) ([]*po.ProblemTag, error)
+	// GetAllProblemTag 获取所有标签
+	GetAllProblemTag(db *gorm.DB) ([]*po.ProblemTag, error)
+}
+
+type problemTagDao struct {
+}
+
+func NewProblemTagDao() ProblemTagDao {
+	return &problemTagDao{}
+}
+
+func (p *problemTagDao) GetOrCreateProblemTags(db *gorm.DB, names []string) ([]*po.ProblemTag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var tags []*po.ProblemTag
+	if err := db.Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	exist := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		exist[tag.Name] = true
+	}
+	for _, name := range names {
+		if exist[name] {
+			continue
+		}
+		tag := &po.ProblemTag{Name: name}
+		if err := db.Create(tag).Error; err != nil {
+			return nil, err
+		}
+		exist[name] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (p *problemTagDao) GetAllProblemTag(db *gorm.DB) ([]*po.ProblemTag, error) {
+	var tags []*po.ProblemTag
+	err := db.Order("name").Find(&tags).Error
+	return tags, err
+}
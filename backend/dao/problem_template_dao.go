@@ -0,0 +1,57 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type ProblemTemplateDao interface {
+	// GetProblemTemplates 获取题目所有语言的代码模板
+	GetProblemTemplates(db *gorm.DB, problemID uint) ([]*po.ProblemTemplate, error)
+	// GetProblemTemplate 获取题目在某个语言下的代码模板
+	GetProblemTemplate(db *gorm.DB, problemID uint, language string) (*po.ProblemTemplate, error)
+	// ReplaceProblemTemplates 替换题目的代码模板
+	ReplaceProblemTemplates(db *gorm.DB, problemID uint, templates []*po.ProblemTemplate) error
+	// DeleteProblemTemplatesByProblemIDs 删除多个题目的代码模板
+	DeleteProblemTemplatesByProblemIDs(db *gorm.DB, problemIDs []uint) error
+}
+
+type problemTemplateDao struct {
+}
+
+func NewProblemTemplateDao() ProblemTemplateDao {
+	return &problemTemplateDao{}
+}
+
+func (p *problemTemplateDao) GetProblemTemplates(db *gorm.DB, problemID uint) ([]*po.ProblemTemplate, error) {
+	var templates []*po.ProblemTemplate
+	err := db.Where("problem_id = ?", problemID).Order("id").Find(&templates).Error
+	return templates, err
+}
+
+func (p *problemTemplateDao) GetProblemTemplate(db *gorm.DB, problemID uint, language string) (*po.ProblemTemplate, error) {
+	template := &po.ProblemTemplate{}
+	err := db.Where("problem_id = ? AND language = ?", problemID, language).First(template).Error
+	return template, err
+}
+
+func (p *problemTemplateDao) ReplaceProblemTemplates(db *gorm.DB, problemID uint, templates []*po.ProblemTemplate) error {
+	if err := db.Unscoped().Where("problem_id = ?", problemID).Delete(&po.ProblemTemplate{}).Error; err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+	for _, template := range templates {
+		template.ID = 0
+		template.ProblemID = problemID
+	}
+	return db.Create(templates).Error
+}
+
+func (p *problemTemplateDao) DeleteProblemTemplatesByProblemIDs(db *gorm.DB, problemIDs []uint) error {
+	if len(problemIDs) == 0 {
+		return nil
+	}
+	return db.Where("problem_id IN ?", problemIDs).Delete(&po.ProblemTemplate{}).Error
+}
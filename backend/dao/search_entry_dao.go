@@ -0,0 +1,127 @@
+package dao
+
+import (
+	"strings"
+
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+// ngramTokenSize mysql ngram分词的长度，短于该长度的词无法使用全文索引
+const ngramTokenSize = 2
+
+type SearchEntryDao interface {
+	// GetSearchEntryList 搜索，按相关度排序
+	GetSearchEntryList(db *gorm.DB, pageQuery *dto.PageQuery) ([]*po.SearchEntry, error)
+	// GetSearchEntryCount 获取搜索结果的数量
+	GetSearchEntryCount(db *gorm.DB, query *dto.SearchQuery) (int64, error)
+	// InsertSearchEntries 批量添加索引
+	InsertSearchEntries(db *gorm.DB, entries []*po.SearchEntry) error
+	// DeleteSearchEntriesByBankID 删除知识库中所有文档的索引
+	DeleteSearchEntriesByBankID(db *gorm.DB, bankID uint) error
+	// DeleteSearchEntriesByDocumentIDs 删除文档和文档代码的索引，不包括用户保存的代码
+	DeleteSearchEntriesByDocumentIDs(db *gorm.DB, documentIDs []uint) error
+	// DeleteSearchEntry 删除一条记录的所有索引
+	DeleteSearchEntry(db *gorm.DB, entryType string, sourceID uint) error
+	// DeleteAllSearchEntries 清空索引
+	DeleteAllSearchEntries(db *gorm.DB) error
+}
+
+type searchEntryDao struct {
+}
+
+func NewSearchEntryDao() SearchEntryDao {
+	return &searchEntryDao{}
+}
+
+func (s *searchEntryDao) GetSearchEntryList(db *gorm.DB, pageQuery *dto.PageQuery) ([]*po.SearchEntry, error) {
+	query := pageQuery.Query.(*dto.SearchQuery)
+	db = s.searchQuery(db, query)
+	if against := matchAgainst(query.Terms); against != "" {
+		db = db.Order(gorm.Expr("MATCH(title, content) AGAINST(? IN BOOLEAN MODE) DESC", against))
+	}
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var entries []*po.SearchEntry
+	err := db.Order("id DESC").Limit(pageQuery.PageSize).Offset(offset).Find(&entries).Error
+	return entries, err
+}
+
+func (s *searchEntryDao) GetSearchEntryCount(db *gorm.DB, query *dto.SearchQuery) (int64, error) {
+	var count int64
+	err := s.searchQuery(db, query).Model(&po.SearchEntry{}).Count(&count).Error
+	return count, err
+}
+
+func (s *searchEntryDao) InsertSearchEntries(db *gorm.DB, entries []*po.SearchEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(entries, 100).Error
+}
+
+func (s *searchEntryDao) DeleteSearchEntriesByBankID(db *gorm.DB, bankID uint) error {
+	return db.Where("bank_id = ? AND user_id = 0", bankID).Delete(&po.SearchEntry{}).Error
+}
+
+func (s *searchEntryDao) DeleteSearchEntriesByDocumentIDs(db *gorm.DB, documentIDs []uint) error {
+	if len(documentIDs) == 0 {
+		return nil
+	}
+	return db.Where("document_id in ? AND user_id = 0", documentIDs).Delete(&po.SearchEntry{}).Error
+}
+
+func (s *searchEntryDao) DeleteSearchEntry(db *gorm.DB, entryType string, sourceID uint) error {
+	return db.Where("type = ? AND source_id = ?", entryType, sourceID).Delete(&po.SearchEntry{}).Error
+}
+
+func (s *searchEntryDao) DeleteAllSearchEntries(db *gorm.DB) error {
+	return db.Where("1 = 1").Delete(&po.SearchEntry{}).Error
+}
+
+// searchQuery 搜索的查询条件，公开的内容和用户自己的代码可以被搜索到
+func (s *searchEntryDao) searchQuery(db *gorm.DB, query *dto.SearchQuery) *gorm.DB {
+	db = db.Where("user_id = 0 OR user_id = ?", query.UserID)
+	if against := matchAgainst(query.Terms); against != "" {
+		db = db.Where("MATCH(title, content) AGAINST(? IN BOOLEAN MODE)", against)
+	}
+	// 过短的词使用like匹配
+	for _, term := range query.Terms {
+		if len([]rune(term)) < ngramTokenSize {
+			like := "%" + escapeLike(term) + "%"
+			db = db.Where("title LIKE ? OR content LIKE ?", like, like)
+		}
+	}
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.BankID != 0 {
+		db = db.Where("bank_id = ?", query.BankID)
+	}
+	if query.Language != "" {
+		db = db.Where("language = ?", query.Language)
+	}
+	return db
+}
+
+// matchAgainst 生成boolean mode的全文检索表达式，每个词作为短语并且必须出现
+func matchAgainst(terms []string) string {
+	var builder strings.Builder
+	for _, term := range terms {
+		if len([]rune(term)) < ngramTokenSize {
+			continue
+		}
+		if builder.Len() != 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(`+"`)
+		builder.WriteString(strings.ReplaceAll(term, `"`, ""))
+		builder.WriteString(`"`)
+	}
+	return builder.String()
+}
+
+// escapeLike 转义like中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
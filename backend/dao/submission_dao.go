@@ -0,0 +1,106 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type SubmissionDao interface {
+	// InsertSubmission 添加提交记录
+	InsertSubmission(db *gorm.DB, submission *po.Submission) error
+	// UpdateSubmissionStatus 更新提交记录的判题状态
+	UpdateSubmissionStatus(db *gorm.DB, id uint, status int) error
+	// CompareAndUpdateSubmissionStatus 判题状态为oldStatus时更新为newStatus，返回更新的行数
+	// 多个实例处理同一个提交记录时，只有一个能更新成功
+	CompareAndUpdateSubmissionStatus(db *gorm.DB, id uint, oldStatus, newStatus int, errorMessage string) (int64, error)
+	// GetSubmissionsByStatus 获取某个判题状态下，在before之前更新的提交记录，按id升序
+	GetSubmissionsByStatus(db *gorm.DB, status int, before time.Time, limit int) ([]*po.Submission, error)
+	// UpdateSubmissionResult 更新提交记录的判题结果
+	UpdateSubmissionResult(db *gorm.DB, submission *po.Submission) error
+	// GetSubmissionByID 根据id获取用户的提交记录
+	GetSubmissionByID(db *gorm.DB, id uint, userID uint) (*po.Submission, error)
+	// GetSubmissionList 获取用户的提交记录列表，problemID为0时查询所有题目
+	GetSubmissionList(db *gorm.DB, userID uint, problemID uint, page, pageSize int) ([]*po.Submission, int64, error)
+	// InsertSubmissionCases 批量添加用例的判题结果
+	InsertSubmissionCases(db *gorm.DB, cases []*po.SubmissionCase) error
+	// GetSubmissionCases 获取提交记录中所有用例的判题结果
+	GetSubmissionCases(db *gorm.DB, submissionID uint) ([]*po.SubmissionCase, error)
+}
+
+type submissionDao struct {
+}
+
+func NewSubmissionDao() SubmissionDao {
+	return &submissionDao{}
+}
+
+func (s *submissionDao) InsertSubmission(db *gorm.DB, submission *po.Submission) error {
+	return db.Create(submission).Error
+}
+
+func (s *submissionDao) UpdateSubmissionStatus(db *gorm.DB, id uint, status int) error {
+	return db.Model(&po.Submission{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (s *submissionDao) CompareAndUpdateSubmissionStatus(db *gorm.DB, id uint, oldStatus, newStatus int, errorMessage string) (int64, error) {
+	result := db.Model(&po.Submission{}).Where("id = ? AND status = ?", id, oldStatus).Updates(map[string]interface{}{
+		"status":        newStatus,
+		"error_message": errorMessage,
+	})
+	return result.RowsAffected, result.Error
+}
+
+func (s *submissionDao) GetSubmissionsByStatus(db *gorm.DB, status int, before time.Time, limit int) ([]*po.Submission, error) {
+	var submissions []*po.Submission
+	err := db.Where("status = ? AND updated_at < ?", status, before).Order("id").Limit(limit).Find(&submissions).Error
+	return submissions, err
+}
+
+func (s *submissionDao) UpdateSubmissionResult(db *gorm.DB, submission *po.Submission) error {
+	return db.Model(&po.Submission{}).Where("id = ?", submission.ID).Updates(map[string]interface{}{
+		"status":        submission.Status,
+		"error_message": submission.ErrorMessage,
+		"time_used":     submission.TimeUsed,
+		"memory_used":   submission.MemoryUsed,
+		"passed_count":  submission.PassedCount,
+		"case_count":    submission.CaseCount,
+		"score":         submission.Score,
+	}).Error
+}
+
+func (s *submissionDao) GetSubmissionByID(db *gorm.DB, id uint, userID uint) (*po.Submission, error) {
+	submission := &po.Submission{}
+	err := db.Where("id = ? AND user_id = ?", id, userID).First(submission).Error
+	return submission, err
+}
+
+func (s *submissionDao) GetSubmissionList(db *gorm.DB, userID uint, problemID uint, page, pageSize int) ([]*po.Submission, int64, error) {
+	var submissions []*po.Submission
+	var total int64
+	query := db.Model(&po.Submission{}).Where("user_id = ?", userID)
+	if problemID != 0 {
+		query = query.Where("problem_id = ?", problemID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	// 列表中不返回代码
+	offset := (page - 1) * pageSize
+	err := query.Omit("code").Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&submissions).Error
+	return submissions, total, err
+}
+
+func (s *submissionDao) InsertSubmissionCases(db *gorm.DB, cases []*po.SubmissionCase) error {
+	if len(cases) == 0 {
+		return nil
+	}
+	return db.Create(cases).Error
+}
+
+func (s *submissionDao) GetSubmissionCases(db *gorm.DB, submissionID uint) ([]*po.SubmissionCase, error) {
+	var cases []*po.SubmissionCase
+	err := db.Where("submission_id = ?", submissionID).Order("id").Find(&cases).Error
+	return cases, err
+}
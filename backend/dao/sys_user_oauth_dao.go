@@ -0,0 +1,41 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type SysUserOAuthDao interface {
+	// InsertUserOAuth 绑定第三方账号
+	InsertUserOAuth(db *gorm.DB, userOAuth *po.SysUserOAuth) error
+	// GetUserOAuth 根据登录方式和第三方账号的唯一标识获取绑定关系
+	GetUserOAuth(db *gorm.DB, provider string, subject string) (*po.SysUserOAuth, error)
+	// GetUserOAuthListByUserID 获取用户绑定的所有第三方账号
+	GetUserOAuthListByUserID(db *gorm.DB, userID uint) ([]*po.SysUserOAuth, error)
+}
+
+type sysUserOAuthDao struct {
+}
+
+func NewSysUserOAuthDao() SysUserOAuthDao {
+	return &sysUserOAuthDao{}
+}
+
+func (s *sysUserOAuthDao) InsertUserOAuth(db *gorm.DB, userOAuth *po.SysUserOAuth) error {
+	return db.Create(userOAuth).Error
+}
+
+func (s *sysUserOAuthDao) GetUserOAuth(db *gorm.DB, provider string, subject string) (*po.SysUserOAuth, error) {
+	var userOAuth po.SysUserOAuth
+	err := db.Where("provider = ? AND subject = ?", provider, subject).First(&userOAuth).Error
+	if err != nil {
+		return nil, err
+	}
+	return &userOAuth, nil
+}
+
+func (s *sysUserOAuthDao) GetUserOAuthListByUserID(db *gorm.DB, userID uint) ([]*po.SysUserOAuth, error) {
+	var list []*po.SysUserOAuth
+	err := db.Where("user_id = ?", userID).Order("created_at").Find(&list).Error
+	return list, err
+}
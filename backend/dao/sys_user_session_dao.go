@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type SysUserSessionDao interface {
+	// InsertSession 创建登录会话
+	InsertSession(db *gorm.DB, session *po.SysUserSession) error
+	// GetSessionBySessionID 根据会话id获取会话
+	GetSessionBySessionID(db *gorm.DB, sessionID string) (*po.SysUserSession, error)
+	// GetActiveSessionList 获取用户所有没有退出且没有过期的会话
+	GetActiveSessionList(db *gorm.DB, userID uint, now time.Time) ([]*po.SysUserSession, error)
+	// RotateRefreshJTI 轮换会话的refresh token，只有当前jti为oldJTI时才会更新，返回更新的行数
+	RotateRefreshJTI(db *gorm.DB, id uint, oldJTI string, newJTI string, now time.Time, expireAt time.Time) (int64, error)
+	// RevokeSession 用户退出某个会话，返回更新的行数
+	RevokeSession(db *gorm.DB, sessionID string, userID uint, now time.Time) (int64, error)
+	// RevokeSessionsByUserID 用户退出所有会话
+	RevokeSessionsByUserID(db *gorm.DB, userID uint, now time.Time) error
+}
+
+type sysUserSessionDao struct {
+}
+
+func NewSysUserSessionDao() SysUserSessionDao {
+	return &sysUserSessionDao{}
+}
+
+func (s *sysUserSessionDao) InsertSession(db *gorm.DB, session *po.SysUserSession) error {
+	return db.Create(session).Error
+}
+
+func (s *sysUserSessionDao) GetSessionBySessionID(db *gorm.DB, sessionID string) (*po.SysUserSession, error) {
+	var session po.SysUserSession
+	err := db.Where("session_id = ?", sessionID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *sysUserSessionDao) GetActiveSessionList(db *gorm.DB, userID uint, now time.Time) ([]*po.SysUserSession, error) {
+	var sessions []*po.SysUserSession
+	err := db.Where("user_id = ? AND revoked_at IS NULL AND expire_at > ?", userID, now).
+		Order("last_active_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+func (s *sysUserSessionDao) RotateRefreshJTI(db *gorm.DB, id uint, oldJTI string, newJTI string, now time.Time, expireAt time.Time) (int64, error) {
+	result := db.Model(&po.SysUserSession{}).
+		Where("id = ? AND refresh_jti = ? AND revoked_at IS NULL", id, oldJTI).
+		Updates(map[string]interface{}{
+			"refresh_jti":    newJTI,
+			"last_active_at": now,
+			"expire_at":      expireAt,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (s *sysUserSessionDao) RevokeSession(db *gorm.DB, sessionID string, userID uint, now time.Time) (int64, error) {
+	result := db.Model(&po.SysUserSession{}).
+		Where("session_id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", now)
+	return result.RowsAffected, result.Error
+}
+
+func (s *sysUserSessionDao) RevokeSessionsByUserID(db *gorm.DB, userID uint, now time.Time) error {
+	return db.Model(&po.SysUserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
@@ -13,6 +13,8 @@ type UserCodeDao interface {
 	InsertUserCode(db *gorm.DB, code *po.UserCode) error
 	UpdateUserCode(db *gorm.DB, code *po.UserCode) error
 	GetUserCodeListByProblemID(db *gorm.DB, userId uint, problemId uint) ([]*po.UserCode, error)
+	// GetUserCodeByID 根据id获取用户的代码
+	GetUserCodeByID(db *gorm.DB, id uint, userID uint) (*po.UserCode, error)
 }
 
 type userCodeDao struct {
@@ -62,3 +64,9 @@ func (u *userCodeDao) GetUserCodeListByProblemID(db *gorm.DB, userId uint, probl
 	err := db.Where("user_id = ? and problem_id = ?", userId, problemId).Order("updated_at desc").Find(&answer).Error
 	return answer, err
 }
+
+func (u *userCodeDao) GetUserCodeByID(db *gorm.DB, id uint, userID uint) (*po.UserCode, error) {
+	userCode := po.UserCode{}
+	err := db.Where("id = ? and user_id = ?", id, userID).First(&userCode).Error
+	return &userCode, err
+}
@@ -0,0 +1,96 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type UserCodeRevisionDao interface {
+	// InsertRevision 添加历史版本
+	InsertRevision(db *gorm.DB, revision *po.UserCodeRevision) error
+	// GetRevisionByID 获取用户的历史版本
+	GetRevisionByID(db *gorm.DB, id uint, userID uint) (*po.UserCodeRevision, error)
+	// GetLatestRevision 获取代码最新的历史版本
+	GetLatestRevision(db *gorm.DB, sourceType string, sourceID uint) (*po.UserCodeRevision, error)
+	// GetRevisionList 获取代码的所有历史版本，不包含代码内容，按版本号倒序
+	GetRevisionList(db *gorm.DB, sourceType string, sourceID uint, userID uint) ([]*po.UserCodeRevision, error)
+	// CountCheckpoint 统计代码的检查点数量
+	CountCheckpoint(db *gorm.DB, sourceType string, sourceID uint) (int64, error)
+	// DeleteOldAutosaveRevisions 只保留最新的keep个自动保存版本，删除更旧的自动保存版本
+	DeleteOldAutosaveRevisions(db *gorm.DB, sourceType string, sourceID uint, keep int) error
+	// DeleteRevisionByID 删除历史版本
+	DeleteRevisionByID(db *gorm.DB, id uint) error
+	// DeleteRevisionBySource 删除代码的所有历史版本
+	DeleteRevisionBySource(db *gorm.DB, sourceType string, sourceID uint) error
+}
+
+type userCodeRevisionDao struct {
+}
+
+func NewUserCodeRevisionDao() UserCodeRevisionDao {
+	return &userCodeRevisionDao{}
+}
+
+func (u *userCodeRevisionDao) InsertRevision(db *gorm.DB, revision *po.UserCodeRevision) error {
+	return db.Create(revision).Error
+}
+
+func (u *userCodeRevisionDao) GetRevisionByID(db *gorm.DB, id uint, userID uint) (*po.UserCodeRevision, error) {
+	var revision po.UserCodeRevision
+	err := db.Where("id = ? AND user_id = ?", id, userID).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (u *userCodeRevisionDao) GetLatestRevision(db *gorm.DB, sourceType string, sourceID uint) (*po.UserCodeRevision, error) {
+	var revision po.UserCodeRevision
+	err := db.Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+		Order("version DESC").First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (u *userCodeRevisionDao) GetRevisionList(db *gorm.DB, sourceType string, sourceID uint, userID uint) ([]*po.UserCodeRevision, error) {
+	var revisions []*po.UserCodeRevision
+	err := db.Omit("code").Where("source_type = ? AND source_id = ? AND user_id = ?", sourceType, sourceID, userID).
+		Order("version DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (u *userCodeRevisionDao) CountCheckpoint(db *gorm.DB, sourceType string, sourceID uint) (int64, error) {
+	var count int64
+	err := db.Model(&po.UserCodeRevision{}).
+		Where("source_type = ? AND source_id = ? AND checkpoint = ?", sourceType, sourceID, true).
+		Count(&count).Error
+	return count, err
+}
+
+func (u *userCodeRevisionDao) DeleteOldAutosaveRevisions(db *gorm.DB, sourceType string, sourceID uint, keep int) error {
+	// mysql不支持单独的OFFSET，也不支持IN子查询中使用LIMIT，先查询需要保留的版本再删除其他版本
+	var keepIDs []uint
+	if keep > 0 {
+		err := db.Model(&po.UserCodeRevision{}).
+			Where("source_type = ? AND source_id = ? AND checkpoint = ?", sourceType, sourceID, false).
+			Order("version DESC").Limit(keep).Pluck("id", &keepIDs).Error
+		if err != nil {
+			return err
+		}
+	}
+	query := db.Where("source_type = ? AND source_id = ? AND checkpoint = ?", sourceType, sourceID, false)
+	if len(keepIDs) != 0 {
+		query = query.Where("id NOT IN ?", keepIDs)
+	}
+	return query.Delete(&po.UserCodeRevision{}).Error
+}
+
+func (u *userCodeRevisionDao) DeleteRevisionByID(db *gorm.DB, id uint) error {
+	return db.Delete(&po.UserCodeRevision{}, id).Error
+}
+
+func (u *userCodeRevisionDao) DeleteRevisionBySource(db *gorm.DB, sourceType string, sourceID uint) error {
+	return db.Where("source_type = ? AND source_id = ?", sourceType, sourceID).Delete(&po.UserCodeRevision{}).Error
+}
@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newMockMysql 使用mysql方言的gorm，执行的sql由sqlmock校验
+func newMockMysql(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		SkipInitializeWithVersion: true,
+		Conn:                      db,
+	}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	return gormDB, mock
+}
+
+func TestUserCodeRevisionDao_DeleteOldAutosaveRevisions(t *testing.T) {
+	db, mock := newMockMysql(t)
+	// mysql中OFFSET必须与LIMIT一起使用，检查实际生成的sql
+	mock.ExpectQuery("SELECT `id` FROM `user_code_revisions` WHERE source_type = ? AND source_id = ? AND checkpoint = ? "+
+		"ORDER BY version DESC LIMIT ?").
+		WithArgs("savedCode", 1, false, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9).AddRow(8))
+	mock.ExpectExec("DELETE FROM `user_code_revisions` WHERE (source_type = ? AND source_id = ? AND checkpoint = ?) "+
+		"AND id NOT IN (?,?)").
+		WithArgs("savedCode", 1, false, 9, 8).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	err := NewUserCodeRevisionDao().DeleteOldAutosaveRevisions(db, "savedCode", 1, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserCodeRevisionDao_DeleteOldAutosaveRevisions_KeepNone(t *testing.T) {
+	db, mock := newMockMysql(t)
+	mock.ExpectExec("DELETE FROM `user_code_revisions` WHERE source_type = ? AND source_id = ? AND checkpoint = ?").
+		WithArgs("savedCode", 1, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := NewUserCodeRevisionDao().DeleteOldAutosaveRevisions(db, "savedCode", 1, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/models/po"
 	"gorm.io/gorm"
 )
@@ -15,9 +16,23 @@ type UserSavedCodeDao interface {
 	// GetUserSavedCodeByID 根据ID获取用户保存的代码
 	GetUserSavedCodeByID(db *gorm.DB, id uint, userID uint) (*po.UserSavedCode, error)
 	// GetUserSavedCodeList 获取用户保存的代码列表
-	GetUserSavedCodeList(db *gorm.DB, userID uint, documentID *uint, language string, page, pageSize int) ([]*po.UserSavedCode, int64, error)
+	GetUserSavedCodeList(db *gorm.DB, userID uint, query *dto.UserSavedCodeDtoForQuery) ([]*po.UserSavedCode, int64, error)
+	// GetUserSavedCodesByIDs 获取用户保存的多个代码，不属于用户的代码会被忽略
+	GetUserSavedCodesByIDs(db *gorm.DB, ids []uint, userID uint) ([]*po.UserSavedCode, error)
+	// UpdateUserSavedCodeMeta 更新用户保存代码的标题和文件夹
+	UpdateUserSavedCodeMeta(db *gorm.DB, savedCode *po.UserSavedCode) error
+	// UpdateUserSavedCodeStarred 收藏或取消收藏用户保存的代码
+	UpdateUserSavedCodeStarred(db *gorm.DB, id uint, userID uint, starred bool) (int64, error)
+	// MoveUserSavedCodes 将用户保存的代码移动到文件夹，文件夹为空时移动到根目录
+	MoveUserSavedCodes(db *gorm.DB, ids []uint, userID uint, folderID *uint) (int64, error)
+	// CountUserSavedCodeByFolder 统计用户每个文件夹中代码的数量
+	CountUserSavedCodeByFolder(db *gorm.DB, userID uint) (map[uint]int64, error)
 	// CheckUserSavedCodeExists 检查用户保存的代码是否存在
 	CheckUserSavedCodeExists(db *gorm.DB, id uint, userID uint) (bool, error)
+	// UpdateUserSavedCodeVisualDescription 更新用户保存代码的可视化描述
+	UpdateUserSavedCodeVisualDescription(db *gorm.DB, id uint, userID uint, visualDescription string) error
+	// GetUserSavedCodesAfterID 按id顺序获取所有用户保存的代码，用于分批遍历
+	GetUserSavedCodesAfterID(db *gorm.DB, id uint, limit int) ([]*po.UserSavedCode, error)
 }
 
 type userSavedCodeDao struct {
@@ -34,11 +49,16 @@ func (u *userSavedCodeDao) CreateUserSavedCode(db *gorm.DB, savedCode *po.UserSa
 
 // UpdateUserSavedCode 更新用户保存的代码
 func (u *userSavedCodeDao) UpdateUserSavedCode(db *gorm.DB, savedCode *po.UserSavedCode) error {
-	return db.Model(&po.UserSavedCode{}).Where("id = ? AND user_id = ?", savedCode.ID, savedCode.UserID).Updates(map[string]interface{}{
+	updates := map[string]interface{}{
 		"language": savedCode.Language,
 		"code":     savedCode.Code,
 		"remark":   savedCode.Remark,
-	}).Error
+	}
+	// 可视化描述为空时不做修改，清除描述使用UpdateUserSavedCodeVisualDescription
+	if savedCode.VisualDescription != "" {
+		updates["visual_description"] = savedCode.VisualDescription
+	}
+	return db.Model(&po.UserSavedCode{}).Where("id = ? AND user_id = ?", savedCode.ID, savedCode.UserID).Updates(updates).Error
 }
 
 // DeleteUserSavedCode 删除用户保存的代码
@@ -57,23 +77,41 @@ func (u *userSavedCodeDao) GetUserSavedCodeByID(db *gorm.DB, id uint, userID uin
 }
 
 // GetUserSavedCodeList 获取用户保存的代码列表
-func (u *userSavedCodeDao) GetUserSavedCodeList(db *gorm.DB, userID uint, documentID *uint, language string, page, pageSize int) ([]*po.UserSavedCode, int64, error) {
+func (u *userSavedCodeDao) GetUserSavedCodeList(db *gorm.DB, userID uint, req *dto.UserSavedCodeDtoForQuery) ([]*po.UserSavedCode, int64, error) {
 	var savedCodes []*po.UserSavedCode
 	var total int64
 
 	query := db.Model(&po.UserSavedCode{}).Where("user_id = ?", userID)
 
 	// 如果指定了文档ID，则按文档ID过滤
-	if documentID != nil {
-		query = query.Where("document_id = ?", *documentID)
+	if req.DocumentID != nil {
+		query = query.Where("document_id = ?", *req.DocumentID)
 	} else {
 		// 如果没有指定文档ID，则只查询没有文档ID的记录（非知识库页面保存的代码）
 		query = query.Where("document_id IS NULL")
 	}
 
 	// 如果指定了编程语言，则按语言过滤
-	if language != "" {
-		query = query.Where("language = ?", language)
+	if req.Language != "" {
+		query = query.Where("language = ?", req.Language)
+	}
+
+	// 按文件夹过滤，0表示根目录
+	if req.FolderID != nil && *req.FolderID == 0 {
+		query = query.Where("folder_id IS NULL")
+	} else if req.FolderID != nil {
+		query = query.Where("folder_id = ?", *req.FolderID)
+	}
+	if req.Starred != nil {
+		query = query.Where("starred = ?", *req.Starred)
+	}
+	if req.Tag != "" {
+		query = query.Where("id IN (?)", db.Model(&po.UserSavedCodeTag{}).Select("saved_code_id").
+			Where("user_id = ? AND name = ?", userID, req.Tag))
+	}
+	if req.Keyword != "" {
+		keyword := "%" + req.Keyword + "%"
+		query = query.Where("title LIKE ? OR remark LIKE ?", keyword, keyword)
 	}
 
 	// 获取总数
@@ -83,8 +121,8 @@ func (u *userSavedCodeDao) GetUserSavedCodeList(db *gorm.DB, userID uint, docume
 	}
 
 	// 分页查询
-	offset := (page - 1) * pageSize
-	err = query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&savedCodes).Error
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Order(savedCodeListOrder(req.Sort, req.Order)).Offset(offset).Limit(req.PageSize).Find(&savedCodes).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -98,3 +136,80 @@ func (u *userSavedCodeDao) CheckUserSavedCodeExists(db *gorm.DB, id uint, userID
 	err := db.Model(&po.UserSavedCode{}).Where("id = ? AND user_id = ?", id, userID).Count(&count).Error
 	return count > 0, err
 }
+
+// UpdateUserSavedCodeVisualDescription 更新用户保存代码的可视化描述
+func (u *userSavedCodeDao) UpdateUserSavedCodeVisualDescription(db *gorm.DB, id uint, userID uint, visualDescription string) error {
+	return db.Model(&po.UserSavedCode{}).Where("id = ? AND user_id = ?", id, userID).
+		Update("visual_description", visualDescription).Error
+}
+
+// GetUserSavedCodesAfterID 按id顺序获取所有用户保存的代码，用于分批遍历
+func (u *userSavedCodeDao) GetUserSavedCodesAfterID(db *gorm.DB, id uint, limit int) ([]*po.UserSavedCode, error) {
+	var savedCodes []*po.UserSavedCode
+	err := db.Where("id > ?", id).Order("id").Limit(limit).Find(&savedCodes).Error
+	return savedCodes, err
+}
+
+// GetUserSavedCodesByIDs 获取用户保存的多个代码，不属于用户的代码会被忽略
+func (u *userSavedCodeDao) GetUserSavedCodesByIDs(db *gorm.DB, ids []uint, userID uint) ([]*po.UserSavedCode, error) {
+	var savedCodes []*po.UserSavedCode
+	err := db.Where("id IN ? AND user_id = ?", ids, userID).Find(&savedCodes).Error
+	return savedCodes, err
+}
+
+// UpdateUserSavedCodeMeta 更新用户保存代码的标题和文件夹
+func (u *userSavedCodeDao) UpdateUserSavedCodeMeta(db *gorm.DB, savedCode *po.UserSavedCode) error {
+	return db.Model(&po.UserSavedCode{}).Where("id = ? AND user_id = ?", savedCode.ID, savedCode.UserID).
+		Updates(map[string]interface{}{
+			"title":     savedCode.Title,
+			"folder_id": savedCode.FolderID,
+		}).Error
+}
+
+// UpdateUserSavedCodeStarred 收藏或取消收藏用户保存的代码
+func (u *userSavedCodeDao) UpdateUserSavedCodeStarred(db *gorm.DB, id uint, userID uint, starred bool) (int64, error) {
+	result := db.Model(&po.UserSavedCode{}).Where("id = ? AND user_id = ?", id, userID).
+		UpdateColumn("starred", starred)
+	return result.RowsAffected, result.Error
+}
+
+// MoveUserSavedCodes 将用户保存的代码移动到文件夹，文件夹为空时移动到根目录
+func (u *userSavedCodeDao) MoveUserSavedCodes(db *gorm.DB, ids []uint, userID uint, folderID *uint) (int64, error) {
+	result := db.Model(&po.UserSavedCode{}).Where("id IN ? AND user_id = ?", ids, userID).
+		Update("folder_id", folderID)
+	return result.RowsAffected, result.Error
+}
+
+// CountUserSavedCodeByFolder 统计用户每个文件夹中代码的数量
+func (u *userSavedCodeDao) CountUserSavedCodeByFolder(db *gorm.DB, userID uint) (map[uint]int64, error) {
+	var rows []struct {
+		FolderID uint
+		Count    int64
+	}
+	err := db.Model(&po.UserSavedCode{}).Select("folder_id, COUNT(*) AS count").
+		Where("user_id = ? AND folder_id IS NOT NULL", userID).Group("folder_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	answer := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		answer[row.FolderID] = row.Count
+	}
+	return answer, nil
+}
+
+// savedCodeListOrder 生成列表的排序条件，排序字段相同时按id排序保证分页稳定
+func savedCodeListOrder(sort string, order string) string {
+	column := "created_at"
+	switch sort {
+	case "updatedAt":
+		column = "updated_at"
+	case "title":
+		column = "title"
+	}
+	direction := "DESC"
+	if order == "asc" {
+		direction = "ASC"
+	}
+	return column + " " + direction + ", id " + direction
+}
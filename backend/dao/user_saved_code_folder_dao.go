@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type UserSavedCodeFolderDao interface {
+	// InsertFolder 创建文件夹
+	InsertFolder(db *gorm.DB, folder *po.UserSavedCodeFolder) error
+	// UpdateFolderName 重命名文件夹
+	UpdateFolderName(db *gorm.DB, id uint, userID uint, name string) error
+	// DeleteFolder 删除用户的文件夹
+	DeleteFolder(db *gorm.DB, id uint, userID uint) error
+	// GetFolderByID 获取用户的文件夹
+	GetFolderByID(db *gorm.DB, id uint, userID uint) (*po.UserSavedCodeFolder, error)
+	// GetFolderByName 根据名称获取用户的文件夹
+	GetFolderByName(db *gorm.DB, userID uint, name string) (*po.UserSavedCodeFolder, error)
+	// GetFolderList 获取用户的所有文件夹，按名称排序
+	GetFolderList(db *gorm.DB, userID uint) ([]*po.UserSavedCodeFolder, error)
+	// ClearFolder 将文件夹中的代码移动到根目录
+	ClearFolder(db *gorm.DB, id uint, userID uint) error
+}
+
+type userSavedCodeFolderDao struct {
+}
+
+func NewUserSavedCodeFolderDao() UserSavedCodeFolderDao {
+	return &userSavedCodeFolderDao{}
+}
+
+func (u *userSavedCodeFolderDao) InsertFolder(db *gorm.DB, folder *po.UserSavedCodeFolder) error {
+	return db.Create(folder).Error
+}
+
+func (u *userSavedCodeFolderDao) UpdateFolderName(db *gorm.DB, id uint, userID uint, name string) error {
+	return db.Model(&po.UserSavedCodeFolder{}).Where("id = ? AND user_id = ?", id, userID).
+		Update("name", name).Error
+}
+
+func (u *userSavedCodeFolderDao) DeleteFolder(db *gorm.DB, id uint, userID uint) error {
+	return db.Where("id = ? AND user_id = ?", id, userID).Delete(&po.UserSavedCodeFolder{}).Error
+}
+
+func (u *userSavedCodeFolderDao) GetFolderByID(db *gorm.DB, id uint, userID uint) (*po.UserSavedCodeFolder, error) {
+	var folder po.UserSavedCodeFolder
+	err := db.Where("id = ? AND user_id = ?", id, userID).First(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (u *userSavedCodeFolderDao) GetFolderByName(db *gorm.DB, userID uint, name string) (*po.UserSavedCodeFolder, error) {
+	var folder po.UserSavedCodeFolder
+	err := db.Where("user_id = ? AND name = ?", userID, name).First(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (u *userSavedCodeFolderDao) GetFolderList(db *gorm.DB, userID uint) ([]*po.UserSavedCodeFolder, error) {
+	var folders []*po.UserSavedCodeFolder
+	err := db.Where("user_id = ?", userID).Order("name").Find(&folders).Error
+	return folders, err
+}
+
+func (u *userSavedCodeFolderDao) ClearFolder(db *gorm.DB, id uint, userID uint) error {
+	return db.Model(&po.UserSavedCode{}).Where("folder_id = ? AND user_id = ?", id, userID).
+		Update("folder_id", nil).Error
+}
@@ -0,0 +1,62 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type UserSavedCodeShareDao interface {
+	// CreateShare 创建分享
+	CreateShare(db *gorm.DB, share *po.UserSavedCodeShare) error
+	// GetShareByShareID 根据分享id获取分享
+	GetShareByShareID(db *gorm.DB, shareID string) (*po.UserSavedCodeShare, error)
+	// GetShareListBySavedCodeID 获取用户某个代码的所有分享
+	GetShareListBySavedCodeID(db *gorm.DB, savedCodeID uint, userID uint) ([]*po.UserSavedCodeShare, error)
+	// IncreaseForkCount 分享被复制次数加一
+	IncreaseForkCount(db *gorm.DB, id uint) error
+	// DeleteShare 删除用户的分享
+	DeleteShare(db *gorm.DB, shareID string, userID uint) (int64, error)
+	// DeleteShareBySavedCodeID 删除代码的所有分享
+	DeleteShareBySavedCodeID(db *gorm.DB, savedCodeID uint, userID uint) error
+}
+
+type userSavedCodeShareDao struct {
+}
+
+func NewUserSavedCodeShareDao() UserSavedCodeShareDao {
+	return &userSavedCodeShareDao{}
+}
+
+func (u *userSavedCodeShareDao) CreateShare(db *gorm.DB, share *po.UserSavedCodeShare) error {
+	return db.Create(share).Error
+}
+
+func (u *userSavedCodeShareDao) GetShareByShareID(db *gorm.DB, shareID string) (*po.UserSavedCodeShare, error) {
+	var share po.UserSavedCodeShare
+	err := db.Where("share_id = ?", shareID).First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (u *userSavedCodeShareDao) GetShareListBySavedCodeID(db *gorm.DB, savedCodeID uint, userID uint) ([]*po.UserSavedCodeShare, error) {
+	var shares []*po.UserSavedCodeShare
+	err := db.Where("saved_code_id = ? AND user_id = ?", savedCodeID, userID).
+		Order("created_at DESC").Find(&shares).Error
+	return shares, err
+}
+
+func (u *userSavedCodeShareDao) IncreaseForkCount(db *gorm.DB, id uint) error {
+	return db.Model(&po.UserSavedCodeShare{}).Where("id = ?", id).
+		UpdateColumn("fork_count", gorm.Expr("fork_count + 1")).Error
+}
+
+func (u *userSavedCodeShareDao) DeleteShare(db *gorm.DB, shareID string, userID uint) (int64, error) {
+	result := db.Where("share_id = ? AND user_id = ?", shareID, userID).Delete(&po.UserSavedCodeShare{})
+	return result.RowsAffected, result.Error
+}
+
+func (u *userSavedCodeShareDao) DeleteShareBySavedCodeID(db *gorm.DB, savedCodeID uint, userID uint) error {
+	return db.Where("saved_code_id = ? AND user_id = ?", savedCodeID, userID).Delete(&po.UserSavedCodeShare{}).Error
+}
@@ -0,0 +1,70 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type UserSavedCodeTagDao interface {
+	// ReplaceTags 使用新的标签替换代码原有的标签
+	ReplaceTags(db *gorm.DB, userID uint, savedCodeID uint, names []string) error
+	// GetTagsBySavedCodeIDs 获取多个代码的标签，key为代码id
+	GetTagsBySavedCodeIDs(db *gorm.DB, savedCodeIDs []uint) (map[uint][]string, error)
+	// GetTagCountList 获取用户使用过的所有标签以及使用次数，按名称排序
+	GetTagCountList(db *gorm.DB, userID uint) ([]*TagCount, error)
+	// DeleteTagsBySavedCodeIDs 删除代码的所有标签
+	DeleteTagsBySavedCodeIDs(db *gorm.DB, savedCodeIDs []uint) error
+}
+
+// TagCount 标签的使用次数
+type TagCount struct {
+	Name  string
+	Count int64
+}
+
+type userSavedCodeTagDao struct {
+}
+
+func NewUserSavedCodeTagDao() UserSavedCodeTagDao {
+	return &userSavedCodeTagDao{}
+}
+
+func (u *userSavedCodeTagDao) ReplaceTags(db *gorm.DB, userID uint, savedCodeID uint, names []string) error {
+	if err := db.Where("saved_code_id = ?", savedCodeID).Delete(&po.UserSavedCodeTag{}).Error; err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	tags := make([]*po.UserSavedCodeTag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, &po.UserSavedCodeTag{UserID: userID, SavedCodeID: savedCodeID, Name: name})
+	}
+	return db.Create(&tags).Error
+}
+
+func (u *userSavedCodeTagDao) GetTagsBySavedCodeIDs(db *gorm.DB, savedCodeIDs []uint) (map[uint][]string, error) {
+	answer := make(map[uint][]string, len(savedCodeIDs))
+	if len(savedCodeIDs) == 0 {
+		return answer, nil
+	}
+	var tags []*po.UserSavedCodeTag
+	if err := db.Where("saved_code_id IN ?", savedCodeIDs).Order("id").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		answer[tag.SavedCodeID] = append(answer[tag.SavedCodeID], tag.Name)
+	}
+	return answer, nil
+}
+
+func (u *userSavedCodeTagDao) GetTagCountList(db *gorm.DB, userID uint) ([]*TagCount, error) {
+	var answer []*TagCount
+	err := db.Model(&po.UserSavedCodeTag{}).Select("name, COUNT(*) AS count").
+		Where("user_id = ?", userID).Group("name").Order("name").Scan(&answer).Error
+	return answer, err
+}
+
+func (u *userSavedCodeTagDao) DeleteTagsBySavedCodeIDs(db *gorm.DB, savedCodeIDs []uint) error {
+	return db.Where("saved_code_id IN ?", savedCodeIDs).Delete(&po.UserSavedCodeTag{}).Error
+}
@@ -10,6 +10,8 @@ type VisualDocumentBankDao interface {
 	InsertVisualDocumentBank(db *gorm.DB, bank *po.VisualDocumentBank) error
 	// GetVisualDocumentBankByID 根据题库id获取题库
 	GetVisualDocumentBankByID(db *gorm.DB, bankID uint) (*po.VisualDocumentBank, error)
+	// GetVisualDocumentBankByName 根据名称获取题库
+	GetVisualDocumentBankByName(db *gorm.DB, name string) (*po.VisualDocumentBank, error)
 	// UpdateVisualDocumentBank 更新题库
 	UpdateVisualDocumentBank(db *gorm.DB, bank *po.VisualDocumentBank) error
 	// DeleteVisualDocumentBankByID 删除题库
@@ -35,6 +37,12 @@ func (v *visualDocumentBankDao) GetVisualDocumentBankByID(db *gorm.DB, bankID ui
 	return bank, err
 }
 
+func (v *visualDocumentBankDao) GetVisualDocumentBankByName(db *gorm.DB, name string) (*po.VisualDocumentBank, error) {
+	bank := &po.VisualDocumentBank{}
+	err := db.Where("name = ?", name).First(&bank).Error
+	return bank, err
+}
+
 func (v *visualDocumentBankDao) UpdateVisualDocumentBank(db *gorm.DB, bank *po.VisualDocumentBank) error {
 	return db.Model(bank).Updates(bank).Error
 }
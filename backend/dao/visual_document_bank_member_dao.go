@@ -0,0 +1,61 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VisualDocumentBankMemberDao interface {
+	// SaveMember 添加协作者，已存在时修改角色
+	SaveMember(db *gorm.DB, member *po.VisualDocumentBankMember) error
+	// GetMember 获取用户在知识库中的协作者信息
+	GetMember(db *gorm.DB, bankID uint, userID uint) (*po.VisualDocumentBankMember, error)
+	// GetMemberListByBankID 获取知识库的所有协作者
+	GetMemberListByBankID(db *gorm.DB, bankID uint) ([]*po.VisualDocumentBankMember, error)
+	// GetMemberListByUserID 获取用户参与的所有知识库
+	GetMemberListByUserID(db *gorm.DB, userID uint) ([]*po.VisualDocumentBankMember, error)
+	// DeleteMember 删除协作者
+	DeleteMember(db *gorm.DB, bankID uint, userID uint) error
+	// DeleteMemberByBankID 删除知识库的所有协作者
+	DeleteMemberByBankID(db *gorm.DB, bankID uint) error
+}
+
+type visualDocumentBankMemberDao struct {
+}
+
+func NewVisualDocumentBankMemberDao() VisualDocumentBankMemberDao {
+	return &visualDocumentBankMemberDao{}
+}
+
+func (v *visualDocumentBankMemberDao) SaveMember(db *gorm.DB, member *po.VisualDocumentBankMember) error {
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(member).Error
+}
+
+func (v *visualDocumentBankMemberDao) GetMember(db *gorm.DB, bankID uint, userID uint) (*po.VisualDocumentBankMember, error) {
+	member := &po.VisualDocumentBankMember{}
+	err := db.Where("bank_id = ? AND user_id = ?", bankID, userID).First(member).Error
+	return member, err
+}
+
+func (v *visualDocumentBankMemberDao) GetMemberListByBankID(db *gorm.DB, bankID uint) ([]*po.VisualDocumentBankMember, error) {
+	var members []*po.VisualDocumentBankMember
+	err := db.Where("bank_id = ?", bankID).Order("id").Find(&members).Error
+	return members, err
+}
+
+func (v *visualDocumentBankMemberDao) GetMemberListByUserID(db *gorm.DB, userID uint) ([]*po.VisualDocumentBankMember, error) {
+	var members []*po.VisualDocumentBankMember
+	err := db.Where("user_id = ?", userID).Find(&members).Error
+	return members, err
+}
+
+func (v *visualDocumentBankMemberDao) DeleteMember(db *gorm.DB, bankID uint, userID uint) error {
+	return db.Where("bank_id = ? AND user_id = ?", bankID, userID).Delete(&po.VisualDocumentBankMember{}).Error
+}
+
+func (v *visualDocumentBankMemberDao) DeleteMemberByBankID(db *gorm.DB, bankID uint) error {
+	return db.Where("bank_id = ?", bankID).Delete(&po.VisualDocumentBankMember{}).Error
+}
@@ -3,6 +3,7 @@ package dao
 import (
 	"github.com/fansqz/fancode-backend/models/po"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type VisualDocumentDao interface {
@@ -10,6 +11,12 @@ type VisualDocumentDao interface {
 	GetSimpleDocumentByParentID(db *gorm.DB, parentID uint) ([]*po.VisualDocument, error)
 	GetVisualDocumentByID(db *gorm.DB, id uint) (*po.VisualDocument, error)
 	GetCodeListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentCode, error)
+	// LockVisualDocument 在事务中锁定文档所在的行，直到事务结束
+	LockVisualDocument(db *gorm.DB, id uint) error
+	// GetVisualDocumentListByBankID 获取知识库中的所有文档，包括文档内容
+	GetVisualDocumentListByBankID(db *gorm.DB, bankID uint) ([]*po.VisualDocument, error)
+	// GetCodeListByDocumentIDs 批量获取多篇文档的代码
+	GetCodeListByDocumentIDs(db *gorm.DB, documentIDs []uint) ([]*po.VisualDocumentCode, error)
 
 	InsertVisualDocument(db *gorm.DB, code *po.VisualDocument) error
 	InsertVisualDocumentCode(db *gorm.DB, code *po.VisualDocumentCode) error
@@ -17,8 +24,14 @@ type VisualDocumentDao interface {
 	UpdateVisualDocumentTitle(db *gorm.DB, id uint, title string) error
 	UpdateVisualDocumentEnable(db *gorm.DB, id uint, enable bool) error
 	UpdateVisualDocument(db *gorm.DB, code *po.VisualDocument) error
+	// UpdateVisualDocumentWithVersion 文档的版本与document.Version一致时才更新，返回是否更新成功
+	// 不更新文档的位置，移动文档通过UpdateVisualDocumentParentAndOrder
+	UpdateVisualDocumentWithVersion(db *gorm.DB, document *po.VisualDocument) (bool, error)
+	UpdateVisualDocumentContent(db *gorm.DB, id uint, content string) error
 	UpdateVisualDocumentParentAndOrder(db *gorm.DB, id uint, parentID uint, order uint) error
 	UpdateVisualDocumentCode(db *gorm.DB, code *po.VisualDocumentCode) error
+	// PublishVisualDocument 发布文档的指定版本，并启用文档
+	PublishVisualDocument(db *gorm.DB, id uint, revisionID uint) error
 
 	DeleteVisualDocumentByID(db *gorm.DB, documentID uint) error
 	DeleteVisualDocumentCodeByDocumentID(db *gorm.DB, documentID uint) error
@@ -39,7 +52,7 @@ type visualDocumentDao struct {
 
 func (v *visualDocumentDao) GetAllSimpleDocument(db *gorm.DB, bankID uint) ([]*po.VisualDocument, error) {
 	documents := []*po.VisualDocument{}
-	err := db.Select("id", "parent_id", "title", "creator_id", "order", "enable").Where("bank_id = ?", bankID).Find(&documents).Error
+	err := db.Select("id", "parent_id", "title", "creator_id", "order", "enable", "published_revision_id").Where("bank_id = ?", bankID).Find(&documents).Error
 	return documents, err
 }
 
@@ -61,6 +74,26 @@ func (v *visualDocumentDao) GetCodeListByDocumentID(db *gorm.DB, documentID uint
 	return codeList, err
 }
 
+func (v *visualDocumentDao) LockVisualDocument(db *gorm.DB, id uint) error {
+	document := &po.VisualDocument{}
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(document, id).Error
+}
+
+func (v *visualDocumentDao) GetVisualDocumentListByBankID(db *gorm.DB, bankID uint) ([]*po.VisualDocument, error) {
+	documents := []*po.VisualDocument{}
+	err := db.Where("bank_id = ?", bankID).Find(&documents).Error
+	return documents, err
+}
+
+func (v *visualDocumentDao) GetCodeListByDocumentIDs(db *gorm.DB, documentIDs []uint) ([]*po.VisualDocumentCode, error) {
+	codeList := []*po.VisualDocumentCode{}
+	if len(documentIDs) == 0 {
+		return codeList, nil
+	}
+	err := db.Where("document_id in ?", documentIDs).Order("id").Find(&codeList).Error
+	return codeList, err
+}
+
 func (v *visualDocumentDao) InsertVisualDocument(db *gorm.DB, document *po.VisualDocument) error {
 	return db.Create(document).Error
 }
@@ -87,6 +120,24 @@ func (v *visualDocumentDao) UpdateVisualDocument(db *gorm.DB, document *po.Visua
 		"title":     document.Title,
 		"content":   document.Content,
 		"enable":    document.Enable,
+		"version":   gorm.Expr("version + 1"),
+	}).Error
+}
+
+func (v *visualDocumentDao) UpdateVisualDocumentWithVersion(db *gorm.DB, document *po.VisualDocument) (bool, error) {
+	tx := db.Model(&po.VisualDocument{}).Where("id = ? AND version = ?", document.ID, document.Version).Updates(map[string]interface{}{
+		"title":   document.Title,
+		"content": document.Content,
+		"enable":  document.Enable,
+		"version": gorm.Expr("version + 1"),
+	})
+	return tx.RowsAffected != 0, tx.Error
+}
+
+func (v *visualDocumentDao) UpdateVisualDocumentContent(db *gorm.DB, id uint, content string) error {
+	return db.Model(&po.VisualDocument{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"content": content,
+		"version": gorm.Expr("version + 1"),
 	}).Error
 }
 
@@ -104,6 +155,13 @@ func (v *visualDocumentDao) UpdateVisualDocumentCode(db *gorm.DB, code *po.Visua
 	}).Error
 }
 
+func (v *visualDocumentDao) PublishVisualDocument(db *gorm.DB, id uint, revisionID uint) error {
+	return db.Model(&po.VisualDocument{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"published_revision_id": revisionID,
+		"enable":                true,
+	}).Error
+}
+
 func (v *visualDocumentDao) DeleteVisualDocumentByID(db *gorm.DB, documentID uint) error {
 	return db.Delete(&po.VisualDocument{}, documentID).Error
 }
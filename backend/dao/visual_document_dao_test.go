@@ -0,0 +1,21 @@
+package dao
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisualDocumentDao_LockVisualDocument(t *testing.T) {
+	db, mock := newMockMysql(t)
+	// 保存版本前锁定文档，并发的保存在这里等待
+	mock.ExpectQuery("SELECT `id` FROM `visual_documents` WHERE `visual_documents`.`id` = ? AND "+
+		"`visual_documents`.`deleted_at` IS NULL ORDER BY `visual_documents`.`id` LIMIT ? FOR UPDATE").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	err := NewVisualDocumentDao().LockVisualDocument(db, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
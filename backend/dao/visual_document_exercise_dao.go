@@ -0,0 +1,119 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VisualDocumentExerciseDao interface {
+	GetExerciseListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentExercise, error)
+	GetExerciseByID(db *gorm.DB, id uint) (*po.VisualDocumentExercise, error)
+	InsertExercise(db *gorm.DB, exercise *po.VisualDocumentExercise) error
+	UpdateExercise(db *gorm.DB, exercise *po.VisualDocumentExercise) error
+	DeleteExerciseByID(db *gorm.DB, id uint) error
+	DeleteExerciseByDocumentID(db *gorm.DB, documentID uint) error
+
+	// SaveResult 保存用户的作答结果，作答次数加一，答对过的练习保持已通过
+	SaveResult(db *gorm.DB, result *po.VisualDocumentExerciseResult) error
+	GetResult(db *gorm.DB, userID uint, exerciseID uint) (*po.VisualDocumentExerciseResult, error)
+	// GetResultListByDocumentID 获取用户在文档中所有练习的作答结果
+	GetResultListByDocumentID(db *gorm.DB, userID uint, documentID uint) ([]*po.VisualDocumentExerciseResult, error)
+	// GetResultListByExerciseID 获取所有用户在练习上的作答结果
+	GetResultListByExerciseID(db *gorm.DB, exerciseID uint) ([]*po.VisualDocumentExerciseResult, error)
+	DeleteResultByExerciseID(db *gorm.DB, exerciseID uint) error
+	DeleteResultByDocumentID(db *gorm.DB, documentID uint) error
+}
+
+type visualDocumentExerciseDao struct {
+}
+
+func NewVisualDocumentExerciseDao() VisualDocumentExerciseDao {
+	return &visualDocumentExerciseDao{}
+}
+
+func (v *visualDocumentExerciseDao) GetExerciseListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentExercise, error) {
+	exercises := []*po.VisualDocumentExercise{}
+	err := db.Where("document_id = ?", documentID).Order("`order`, id").Find(&exercises).Error
+	return exercises, err
+}
+
+func (v *visualDocumentExerciseDao) GetExerciseByID(db *gorm.DB, id uint) (*po.VisualDocumentExercise, error) {
+	exercise := &po.VisualDocumentExercise{}
+	err := db.First(exercise, id).Error
+	return exercise, err
+}
+
+func (v *visualDocumentExerciseDao) InsertExercise(db *gorm.DB, exercise *po.VisualDocumentExercise) error {
+	return db.Create(exercise).Error
+}
+
+func (v *visualDocumentExerciseDao) UpdateExercise(db *gorm.DB, exercise *po.VisualDocumentExercise) error {
+	return db.Model(&po.VisualDocumentExercise{}).Where("id = ?", exercise.ID).Updates(map[string]interface{}{
+		"type":        exercise.Type,
+		"title":       exercise.Title,
+		"question":    exercise.Question,
+		"options":     exercise.Options,
+		"answer":      exercise.Answer,
+		"language":    exercise.Language,
+		"code":        exercise.Code,
+		"stdin":       exercise.Stdin,
+		"breakpoints": exercise.Breakpoints,
+		"step":        exercise.Step,
+		"variable":    exercise.Variable,
+		"cases":       exercise.Cases,
+		"explanation": exercise.Explanation,
+		"order":       exercise.Order,
+	}).Error
+}
+
+func (v *visualDocumentExerciseDao) DeleteExerciseByID(db *gorm.DB, id uint) error {
+	return db.Delete(&po.VisualDocumentExercise{}, id).Error
+}
+
+func (v *visualDocumentExerciseDao) DeleteExerciseByDocumentID(db *gorm.DB, documentID uint) error {
+	return db.Where("document_id = ?", documentID).Delete(&po.VisualDocumentExercise{}).Error
+}
+
+func (v *visualDocumentExerciseDao) SaveResult(db *gorm.DB, result *po.VisualDocumentExerciseResult) error {
+	result.Attempts = 1
+	result.Passed = result.Correct
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"answer":     result.Answer,
+			"correct":    result.Correct,
+			"passed":     gorm.Expr("passed OR ?", result.Correct),
+			"attempts":   gorm.Expr("attempts + 1"),
+			"message":    result.Message,
+			"updated_at": time.Now(),
+		}),
+	}).Create(result).Error
+}
+
+func (v *visualDocumentExerciseDao) GetResult(db *gorm.DB, userID uint, exerciseID uint) (*po.VisualDocumentExerciseResult, error) {
+	result := &po.VisualDocumentExerciseResult{}
+	err := db.Where("user_id = ? AND exercise_id = ?", userID, exerciseID).First(result).Error
+	return result, err
+}
+
+func (v *visualDocumentExerciseDao) GetResultListByDocumentID(db *gorm.DB, userID uint, documentID uint) ([]*po.VisualDocumentExerciseResult, error) {
+	results := []*po.VisualDocumentExerciseResult{}
+	err := db.Where("user_id = ? AND document_id = ?", userID, documentID).Find(&results).Error
+	return results, err
+}
+
+func (v *visualDocumentExerciseDao) GetResultListByExerciseID(db *gorm.DB, exerciseID uint) ([]*po.VisualDocumentExerciseResult, error) {
+	results := []*po.VisualDocumentExerciseResult{}
+	err := db.Where("exercise_id = ?", exerciseID).Order("updated_at desc").Find(&results).Error
+	return results, err
+}
+
+func (v *visualDocumentExerciseDao) DeleteResultByExerciseID(db *gorm.DB, exerciseID uint) error {
+	return db.Where("exercise_id = ?", exerciseID).Delete(&po.VisualDocumentExerciseResult{}).Error
+}
+
+func (v *visualDocumentExerciseDao) DeleteResultByDocumentID(db *gorm.DB, documentID uint) error {
+	return db.Where("document_id = ?", documentID).Delete(&po.VisualDocumentExerciseResult{}).Error
+}
@@ -0,0 +1,110 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DocumentProgressStat 一篇文档的学习人数统计
+type DocumentProgressStat struct {
+	DocumentID uint  `gorm:"column:document_id"`
+	Viewed     int64 `gorm:"column:viewed"`
+	Completed  int64 `gorm:"column:completed"`
+}
+
+type VisualDocumentProgressDao interface {
+	// ViewDocument 记录用户阅读文档，已完成的文档只更新阅读时间
+	ViewDocument(db *gorm.DB, userID uint, documentID uint, bankID uint, viewedAt time.Time) error
+	// CompleteDocument 标记用户完成文档，已完成的文档不修改完成时间
+	CompleteDocument(db *gorm.DB, userID uint, documentID uint, bankID uint, completedAt time.Time) error
+	// GetProgressListByBankID 获取用户在知识库中的所有进度
+	GetProgressListByBankID(db *gorm.DB, userID uint, bankID uint) ([]*po.VisualDocumentProgress, error)
+	// GetLastViewedProgress 获取用户最后阅读的文档的进度
+	GetLastViewedProgress(db *gorm.DB, userID uint) (*po.VisualDocumentProgress, error)
+	// GetDocumentProgressStats 统计知识库中每篇文档的阅读和完成人数
+	GetDocumentProgressStats(db *gorm.DB, bankID uint) ([]*DocumentProgressStat, error)
+	// GetBankLearnerCount 统计在知识库中阅读过文档的人数
+	GetBankLearnerCount(db *gorm.DB, bankID uint) (int64, error)
+	// DeleteProgressByDocumentID 删除文档的所有进度
+	DeleteProgressByDocumentID(db *gorm.DB, documentID uint) error
+	// DeleteProgressByBankID 删除知识库的所有进度
+	DeleteProgressByBankID(db *gorm.DB, bankID uint) error
+}
+
+type visualDocumentProgressDao struct {
+}
+
+func NewVisualDocumentProgressDao() VisualDocumentProgressDao {
+	return &visualDocumentProgressDao{}
+}
+
+func (v *visualDocumentProgressDao) ViewDocument(db *gorm.DB, userID uint, documentID uint, bankID uint, viewedAt time.Time) error {
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_viewed_at": viewedAt,
+			"updated_at":     viewedAt,
+		}),
+	}).Create(&po.VisualDocumentProgress{
+		UserID:       userID,
+		DocumentID:   documentID,
+		BankID:       bankID,
+		Status:       constants.DocumentProgressViewed,
+		LastViewedAt: viewedAt,
+	}).Error
+}
+
+func (v *visualDocumentProgressDao) CompleteDocument(db *gorm.DB, userID uint, documentID uint, bankID uint, completedAt time.Time) error {
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":       constants.DocumentProgressCompleted,
+			"completed_at": gorm.Expr("COALESCE(completed_at, ?)", completedAt),
+			"updated_at":   completedAt,
+		}),
+	}).Create(&po.VisualDocumentProgress{
+		UserID:       userID,
+		DocumentID:   documentID,
+		BankID:       bankID,
+		Status:       constants.DocumentProgressCompleted,
+		LastViewedAt: completedAt,
+		CompletedAt:  &completedAt,
+	}).Error
+}
+
+func (v *visualDocumentProgressDao) GetProgressListByBankID(db *gorm.DB, userID uint, bankID uint) ([]*po.VisualDocumentProgress, error) {
+	var progressList []*po.VisualDocumentProgress
+	err := db.Where("user_id = ? AND bank_id = ?", userID, bankID).Find(&progressList).Error
+	return progressList, err
+}
+
+func (v *visualDocumentProgressDao) GetLastViewedProgress(db *gorm.DB, userID uint) (*po.VisualDocumentProgress, error) {
+	progress := &po.VisualDocumentProgress{}
+	err := db.Where("user_id = ?", userID).Order("last_viewed_at DESC").First(progress).Error
+	return progress, err
+}
+
+func (v *visualDocumentProgressDao) GetDocumentProgressStats(db *gorm.DB, bankID uint) ([]*DocumentProgressStat, error) {
+	var stats []*DocumentProgressStat
+	err := db.Model(&po.VisualDocumentProgress{}).
+		Select("document_id, COUNT(*) AS viewed, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS completed",
+			constants.DocumentProgressCompleted).
+		Where("bank_id = ?", bankID).Group("document_id").Scan(&stats).Error
+	return stats, err
+}
+
+func (v *visualDocumentProgressDao) GetBankLearnerCount(db *gorm.DB, bankID uint) (int64, error) {
+	var count int64
+	err := db.Model(&po.VisualDocumentProgress{}).Where("bank_id = ?", bankID).Distinct("user_id").Count(&count).Error
+	return count, err
+}
+
+func (v *visualDocumentProgressDao) DeleteProgressByDocumentID(db *gorm.DB, documentID uint) error {
+	return db.Where("document_id = ?", documentID).Delete(&po.VisualDocumentProgress{}).Error
+}
+
+func (v *visualDocumentProgressDao) DeleteProgressByBankID(db *gorm.DB, bankID uint) error {
+	return db.Where("bank_id = ?", bankID).Delete(&po.VisualDocumentProgress{}).Error
+}
@@ -0,0 +1,62 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+// VisualDocumentRevisionDao 可视化文档的版本
+type VisualDocumentRevisionDao interface {
+	// GetRevisionListByDocumentID 获取文档的所有版本，不包括内容，按版本号从新到旧排序
+	GetRevisionListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentRevision, error)
+	GetRevisionByID(db *gorm.DB, id uint) (*po.VisualDocumentRevision, error)
+	// GetRevisionListByIDs 批量获取版本，包括内容
+	GetRevisionListByIDs(db *gorm.DB, ids []uint) ([]*po.VisualDocumentRevision, error)
+	// GetLatestRevision 获取文档最新的版本
+	GetLatestRevision(db *gorm.DB, documentID uint) (*po.VisualDocumentRevision, error)
+	InsertRevision(db *gorm.DB, revision *po.VisualDocumentRevision) error
+	DeleteRevisionByDocumentID(db *gorm.DB, documentID uint) error
+}
+
+func NewVisualDocumentRevisionDao() VisualDocumentRevisionDao {
+	return &visualDocumentRevisionDao{}
+}
+
+type visualDocumentRevisionDao struct {
+}
+
+func (v *visualDocumentRevisionDao) GetRevisionListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentRevision, error) {
+	revisions := []*po.VisualDocumentRevision{}
+	err := db.Select("id", "document_id", "version", "author_id", "created_at").
+		Where("document_id = ?", documentID).Order("version desc").Find(&revisions).Error
+	return revisions, err
+}
+
+func (v *visualDocumentRevisionDao) GetRevisionByID(db *gorm.DB, id uint) (*po.VisualDocumentRevision, error) {
+	revision := &po.VisualDocumentRevision{}
+	err := db.First(revision, id).Error
+	return revision, err
+}
+
+func (v *visualDocumentRevisionDao) GetRevisionListByIDs(db *gorm.DB, ids []uint) ([]*po.VisualDocumentRevision, error) {
+	revisions := []*po.VisualDocumentRevision{}
+	if len(ids) == 0 {
+		return revisions, nil
+	}
+	err := db.Where("id in ?", ids).Find(&revisions).Error
+	return revisions, err
+}
+
+func (v *visualDocumentRevisionDao) GetLatestRevision(db *gorm.DB, documentID uint) (*po.VisualDocumentRevision, error) {
+	revision := &po.VisualDocumentRevision{}
+	err := db.Where("document_id = ?", documentID).Order("version desc").First(revision).Error
+	return revision, err
+}
+
+func (v *visualDocumentRevisionDao) InsertRevision(db *gorm.DB, revision *po.VisualDocumentRevision) error {
+	return db.Create(revision).Error
+}
+
+func (v *visualDocumentRevisionDao) DeleteRevisionByDocumentID(db *gorm.DB, documentID uint) error {
+	return db.Where("document_id = ?", documentID).Delete(&po.VisualDocumentRevision{}).Error
+}
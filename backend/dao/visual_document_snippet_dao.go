@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+type VisualDocumentSnippetDao interface {
+	// GetSnippetListByDocumentID 获取文档草稿中的代码示例
+	GetSnippetListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentSnippet, error)
+	// GetSnippetListByDocumentIDs 批量获取多篇文档草稿中的代码示例
+	GetSnippetListByDocumentIDs(db *gorm.DB, documentIDs []uint) ([]*po.VisualDocumentSnippet, error)
+	// GetAllSnippetListByDocumentID 获取文档所有的代码示例，包括已删除的示例
+	GetAllSnippetListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentSnippet, error)
+	// GetSnippetByID 获取代码示例，包括已删除的示例
+	GetSnippetByID(db *gorm.DB, id uint) (*po.VisualDocumentSnippet, error)
+	InsertSnippet(db *gorm.DB, snippet *po.VisualDocumentSnippet) error
+	// UpdateSnippet 更新代码示例，已删除的示例会被恢复
+	UpdateSnippet(db *gorm.DB, snippet *po.VisualDocumentSnippet) error
+	DeleteSnippetByID(db *gorm.DB, id uint) error
+	DeleteSnippetByDocumentID(db *gorm.DB, documentID uint) error
+}
+
+type visualDocumentSnippetDao struct {
+}
+
+func NewVisualDocumentSnippetDao() VisualDocumentSnippetDao {
+	return &visualDocumentSnippetDao{}
+}
+
+func (v *visualDocumentSnippetDao) GetSnippetListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentSnippet, error) {
+	snippets := []*po.VisualDocumentSnippet{}
+	err := db.Where("document_id = ?", documentID).Order("id").Find(&snippets).Error
+	return snippets, err
+}
+
+func (v *visualDocumentSnippetDao) GetSnippetListByDocumentIDs(db *gorm.DB, documentIDs []uint) ([]*po.VisualDocumentSnippet, error) {
+	snippets := []*po.VisualDocumentSnippet{}
+	if len(documentIDs) == 0 {
+		return snippets, nil
+	}
+	err := db.Where("document_id in ?", documentIDs).Order("id").Find(&snippets).Error
+	return snippets, err
+}
+
+func (v *visualDocumentSnippetDao) GetAllSnippetListByDocumentID(db *gorm.DB, documentID uint) ([]*po.VisualDocumentSnippet, error) {
+	snippets := []*po.VisualDocumentSnippet{}
+	err := db.Unscoped().Where("document_id = ?", documentID).Order("id").Find(&snippets).Error
+	return snippets, err
+}
+
+func (v *visualDocumentSnippetDao) GetSnippetByID(db *gorm.DB, id uint) (*po.VisualDocumentSnippet, error) {
+	snippet := &po.VisualDocumentSnippet{}
+	err := db.Unscoped().First(snippet, id).Error
+	return snippet, err
+}
+
+func (v *visualDocumentSnippetDao) InsertSnippet(db *gorm.DB, snippet *po.VisualDocumentSnippet) error {
+	return db.Create(snippet).Error
+}
+
+func (v *visualDocumentSnippetDao) UpdateSnippet(db *gorm.DB, snippet *po.VisualDocumentSnippet) error {
+	return db.Unscoped().Model(&po.VisualDocumentSnippet{}).Where("id = ?", snippet.ID).Updates(map[string]interface{}{
+		"title":      snippet.Title,
+		"code_list":  snippet.CodeList,
+		"deleted_at": nil,
+	}).Error
+}
+
+func (v *visualDocumentSnippetDao) DeleteSnippetByID(db *gorm.DB, id uint) error {
+	return db.Delete(&po.VisualDocumentSnippet{}, id).Error
+}
+
+func (v *visualDocumentSnippetDao) DeleteSnippetByDocumentID(db *gorm.DB, documentID uint) error {
+	return db.Where("document_id = ?", documentID).Delete(&po.VisualDocumentSnippet{}).Error
+}
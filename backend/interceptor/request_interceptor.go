@@ -5,6 +5,7 @@ import (
 	"github.com/fansqz/fancode-backend/common/logger"
 	"github.com/fansqz/fancode-backend/constants"
 	r "github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/common_service"
 	"github.com/fansqz/fancode-backend/service/system_service"
 	"github.com/fansqz/fancode-backend/utils"
 	"github.com/gin-gonic/gin"
@@ -12,14 +13,17 @@ import (
 )
 
 type RequestInterceptor struct {
-	roleService system_service.SysRoleService
-	userService system_service.SysUserService
+	roleService    system_service.SysRoleService
+	userService    system_service.SysUserService
+	sessionService common_service.SessionService
 }
 
-func NewRequestInterceptor(roleService system_service.SysRoleService, userService system_service.SysUserService) *RequestInterceptor {
+func NewRequestInterceptor(roleService system_service.SysRoleService, userService system_service.SysUserService,
+	sessionService common_service.SessionService) *RequestInterceptor {
 	return &RequestInterceptor{
-		roleService: roleService,
-		userService: userService,
+		roleService:    roleService,
+		userService:    userService,
+		sessionService: sessionService,
 	}
 }
 
@@ -42,7 +46,6 @@ func (i *RequestInterceptor) TokenAuthorize() gin.HandlerFunc {
 		// 检验路径是否在游客路径中
 		allow, _ := i.checkIsAllowTouristReq(c)
 		if allow {
-			i.checkAndUpdateToken(c, userID)
 			c.Next()
 			return
 		}
@@ -63,7 +66,6 @@ func (i *RequestInterceptor) TokenAuthorize() gin.HandlerFunc {
 			return
 		}
 		if allow {
-			i.checkAndUpdateToken(c, userID)
 			c.Next()
 			return
 		}
@@ -71,24 +73,6 @@ func (i *RequestInterceptor) TokenAuthorize() gin.HandlerFunc {
 	}
 }
 
-// checkAndUpdateToken 校验token时间，如果要过期则更新token
-func (i *RequestInterceptor) checkAndUpdateToken(ctx *gin.Context, userID uint) {
-	if userID == 0 {
-		return
-	}
-	token := ctx.Request.Header.Get("token")
-	ok, err := utils.ShouldUpdateToken(token)
-	if err == nil && ok {
-		// 续签token
-		newToken, err := utils.GenerateToken(utils.Claims{
-			ID: userID,
-		})
-		if err == nil {
-			ctx.Header(constants.TokenHeader, newToken)
-		}
-	}
-}
-
 // checkIsRoleAllowReq 校验是否是用户角色
 func (i *RequestInterceptor) checkIsRoleAllowReq(ctx *gin.Context, userID uint) (bool, error) {
 	rules, err := i.userService.GetRoleIDsByUserID(ctx, userID)
@@ -135,21 +119,31 @@ func (i *RequestInterceptor) checkIsAllowTouristReq(ctx *gin.Context) (bool, err
 	return false, nil
 }
 
-// getUserAndSaveInfo 从token中读取用户信息并保存到ctx中
+// getUserAndSaveInfo 从access token中读取用户信息并保存到ctx中，已退出的会话中的token无效
 func (i *RequestInterceptor) getAndSaveUserID(ctx *gin.Context, token string) uint {
 	claims, err := utils.ParseToken(token)
-	var userID uint
 	if err != nil {
 		logger.WithCtx(ctx).Warnf("[TokenAuthorize] token authorize err, err = %v", err)
-	} else {
-		userID = claims.ID
-		if ctx.Keys == nil {
-			ctx.Keys = make(map[string]interface{}, 1)
-		}
-		ctx.Set(utils.CtxUserIDKey, userID)
-		// 记录 用户id
-		ctx.Set(logger.USER_ID_KEY, userID)
+		return 0
+	}
+	if claims.TokenType != utils.TokenTypeAccess {
+		logger.WithCtx(ctx).Warnf("[TokenAuthorize] token type is %s", claims.TokenType)
+		return 0
+	}
+	// 检查失败时按未登录处理
+	revoked, err := i.sessionService.IsSessionRevoked(ctx, claims.SessionID)
+	if err != nil || revoked {
+		logger.WithCtx(ctx).Warnf("[TokenAuthorize] session revoked or check fail, sessionID = %s", claims.SessionID)
+		return 0
+	}
+	userID := claims.ID
+	if ctx.Keys == nil {
+		ctx.Keys = make(map[string]interface{}, 1)
 	}
+	ctx.Set(utils.CtxUserIDKey, userID)
+	ctx.Set(utils.CtxSessionID, claims.SessionID)
+	// 记录 用户id
+	ctx.Set(logger.USER_ID_KEY, userID)
 	return userID
 }
 
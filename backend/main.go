@@ -10,6 +10,8 @@ import (
 	"github.com/fansqz/fancode-backend/common/logger"
 	ratelimiter "github.com/fansqz/fancode-backend/common/rate_limiter"
 	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/service/user_coding_service/judger/runner"
+	"github.com/fansqz/fancode-backend/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,11 +24,23 @@ func newApp(engine *gin.Engine, config *config2.AppConfig) *http.Server {
 }
 
 func main() {
+	// 本机沙箱以judge-runner参数启动当前程序作为判题运行器，运行结束后直接退出
+	runner.Init()
+
 	//加载配置
 	conf := config2.InitSetting()
 	// 初始化日志
 	logger.InitLogger(context.Background(), conf.LoggerConfig)
 
+	// 设置token签名密钥，正式版本中没有配置密钥时无法启动
+	useDefaultKey, err := utils.InitJWTKeys(conf.JWTConfig.Keys, conf.JWTConfig.CurrentKid)
+	if err != nil {
+		panic(err)
+	}
+	if useDefaultKey {
+		logger.WithCtx(context.Background()).Warnf("jwt keys not configured, use default key of dev build")
+	}
+
 	//连接数据库
 	common.InitMysql(conf.MySqlConfig)
 
@@ -39,16 +53,36 @@ func main() {
 	ratelimiter.InitSentinel()
 
 	// 模型绑定
-	err := common.Mysql.AutoMigrate(
+	err = common.Mysql.AutoMigrate(
 		&po.SysApi{},
 		&po.SysMenu{},
 		&po.SysRole{},
 		&po.SysUser{},
+		&po.SysUserOAuth{},
+		&po.SysUserSession{},
 		&po.UserCode{},
 		&po.VisualDocument{},
 		&po.VisualDocumentCode{},
+		&po.VisualDocumentRevision{},
+		&po.VisualDocumentProgress{},
+		&po.VisualDocumentBankMember{},
+		&po.VisualDocumentSnippet{},
+		&po.VisualDocumentExercise{},
+		&po.VisualDocumentExerciseResult{},
 		&po.VisualDocumentBank{},
 		&po.UserSavedCode{},
+		&po.UserSavedCodeShare{},
+		&po.UserSavedCodeFolder{},
+		&po.UserSavedCodeTag{},
+		&po.UserCodeRevision{},
+		&po.Problem{},
+		&po.ProblemCase{},
+		&po.ProblemTag{},
+		&po.ProblemTemplate{},
+		&po.ProblemBank{},
+		&po.Submission{},
+		&po.SubmissionCase{},
+		&po.SearchEntry{},
 	)
 	if err != nil {
 		panic(err)
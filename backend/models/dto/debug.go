@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/fansqz/fancode-backend/constants"
+import (
+	"github.com/fansqz/fancode-backend/common/ai_provider"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
+)
 
 // =======================以下是request=============================
 
@@ -17,6 +21,28 @@ type StartDebugRequest struct {
 	Language constants.LanguageType `json:"language"`
 	// 初始断点
 	Breakpoints []int `json:"breakpoints"`
+	// VisualDescription 用户或者文档指定的可视化描述，指定以后不再分析代码
+	VisualDescription *VisualDescription `json:"visualDescription"`
+	// Stdin 程序启动后发送到标准输入的内容
+	Stdin string `json:"stdin"`
+}
+
+// StartSnippetDebugRequest 直接调试文档中的代码示例
+type StartSnippetDebugRequest struct {
+	ID        string `json:"id"`
+	SnippetID uint   `json:"snippetID"`
+	// Language 调试的语言，需要是示例中存在的语言
+	Language constants.LanguageType `json:"language"`
+	// Breakpoints 初始断点，为空时使用示例中的断点
+	Breakpoints []int `json:"breakpoints"`
+}
+
+// StartShareDebugRequest 调试通过链接分享的代码
+type StartShareDebugRequest struct {
+	ID      string `json:"id"`
+	ShareID string `json:"shareID"`
+	// Breakpoints 初始断点
+	Breakpoints []int `json:"breakpoints"`
 }
 
 type BaseDebugRequest struct {
@@ -49,13 +75,19 @@ type CompileEvent struct {
 	Event   constants.DebugEventType `json:"event"`
 	Success bool                     `json:"success"`
 	Message string                   `json:"message"` // 编译产生的信息
+	// Diagnostics 结构化的编译错误和警告，前端可以据此在编辑器中标记
+	Diagnostics []*debug_core.CompileDiagnostic `json:"diagnostics"`
 }
 
-func NewCompileEvent(success bool, message string) *CompileEvent {
+func NewCompileEvent(success bool, message string, diagnostics []*debug_core.CompileDiagnostic) *CompileEvent {
+	if diagnostics == nil {
+		diagnostics = []*debug_core.CompileDiagnostic{}
+	}
 	return &CompileEvent{
-		Event:   constants.CompileEvent,
-		Success: success,
-		Message: message,
+		Event:       constants.CompileEvent,
+		Success:     success,
+		Message:     message,
+		Diagnostics: diagnostics,
 	}
 }
 
@@ -113,3 +145,24 @@ func NewTerminatedEvent() *TerminatedEvent {
 		Event: constants.TerminatedEvent,
 	}
 }
+
+// AssistEvent
+// AI调试助手通过sse返回的事件
+type AssistEvent struct {
+	Event   constants.AssistEventType `json:"event"`
+	Content string                    `json:"content,omitempty"`
+	// Usage 讲解结束时返回本次讲解消耗的token
+	Usage   *ai_provider.Usage `json:"usage,omitempty"`
+	Message string             `json:"message,omitempty"`
+}
+
+// NewAssistEvent 根据AI返回的流式片段生成事件
+func NewAssistEvent(chunk *ai_provider.StreamChunk) *AssistEvent {
+	if chunk.Err != nil {
+		return &AssistEvent{Event: constants.AssistErrorEvent, Message: "AI讲解失败，请稍后重试"}
+	}
+	if chunk.Done {
+		return &AssistEvent{Event: constants.AssistDoneEvent, Usage: chunk.Usage}
+	}
+	return &AssistEvent{Event: constants.AssistContentEvent, Content: chunk.Content}
+}
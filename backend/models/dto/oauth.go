@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/fansqz/fancode-backend/models/po"
+)
+
+// OAuthProviderDto 可用的第三方登录方式
+type OAuthProviderDto struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	DisplayName string `json:"displayName"`
+}
+
+// OAuthLoginRequest 第三方授权后的回调参数
+type OAuthLoginRequest struct {
+	Code  string `json:"code" form:"code" binding:"required"`
+	State string `json:"state" form:"state" binding:"required"`
+}
+
+// UserOAuthDto 用户绑定的第三方账号
+type UserOAuthDto struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewUserOAuthDto(userOAuth *po.SysUserOAuth) *UserOAuthDto {
+	return &UserOAuthDto{
+		Provider:  userOAuth.Provider,
+		Email:     userOAuth.Email,
+		CreatedAt: userOAuth.CreatedAt,
+	}
+}
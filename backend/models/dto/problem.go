@@ -0,0 +1,190 @@
+package dto
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+)
+
+// ProblemQuery 题目列表的查询条件
+type ProblemQuery struct {
+	Number     string
+	Name       string
+	BankID     uint
+	Difficulty int
+	Tag        string // 标签名称
+	Enable     bool   // 为true时只查询启用的题目
+}
+
+// ProblemDtoForSave 添加或修改题目时的请求
+type ProblemDtoForSave struct {
+	ID          uint                  `json:"id"`
+	BankID      uint                  `json:"bankID"`
+	Number      string                `json:"number"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"` // 题目描述，markdown格式，为空时使用默认模板
+	Difficulty  int                   `json:"difficulty"`
+	TimeLimit   int64                 `json:"timeLimit"`
+	MemoryLimit int64                 `json:"memoryLimit"`
+	Enable      bool                  `json:"enable"`
+	Tags        []string              `json:"tags"`
+	Templates   []*ProblemTemplateDto `json:"templates"` // 各个语言的代码模板
+	ProblemJudgeDto
+}
+
+// ProblemJudgeDto 题目的判题方式，包括特判程序和交互程序
+type ProblemJudgeDto struct {
+	Checker            string  `json:"checker"`        // 判断输出是否正确的方式
+	CheckerEpsilon     float64 `json:"checkerEpsilon"` // 浮点数比较允许的误差
+	CheckerLanguage    string  `json:"checkerLanguage"`
+	CheckerCode        string  `json:"checkerCode"`
+	Interactive        bool    `json:"interactive"` // 是否是交互题
+	InteractorLanguage string  `json:"interactorLanguage"`
+	InteractorCode     string  `json:"interactorCode"`
+}
+
+// ProblemTemplateDto 题目在某个语言下的代码模板
+type ProblemTemplateDto struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+// ProblemDtoForList 题目列表的DTO
+type ProblemDtoForList struct {
+	ID          uint       `json:"id"`
+	BankID      uint       `json:"bankID"`
+	Number      string     `json:"number"`      // 题目编号
+	Name        string     `json:"name"`        // 题目名称
+	Difficulty  int        `json:"difficulty"`  // 难度
+	Tags        []string   `json:"tags"`        // 标签
+	TimeLimit   int64      `json:"timeLimit"`   // 时间限制，单位毫秒
+	MemoryLimit int64      `json:"memoryLimit"` // 内存限制，单位MB
+	Enable      bool       `json:"enable"`      // 是否启用
+	CreatedAt   utils.Time `json:"createdAt"`
+	UpdatedAt   utils.Time `json:"updatedAt"`
+}
+
+// ProblemDtoForManage 管理员查看的题目详情，包括代码模板
+type ProblemDtoForManage struct {
+	ProblemDtoForList
+	Description string                `json:"description"`
+	Templates   []*ProblemTemplateDto `json:"templates"`
+	ProblemJudgeDto
+}
+
+// ProblemDtoForDetail 用户查看的题目详情
+type ProblemDtoForDetail struct {
+	ID          uint              `json:"id"`
+	BankID      uint              `json:"bankID"`
+	Number      string            `json:"number"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Difficulty  int               `json:"difficulty"`
+	Tags        []string          `json:"tags"`
+	TimeLimit   int64             `json:"timeLimit"`
+	MemoryLimit int64             `json:"memoryLimit"`
+	Interactive bool              `json:"interactive"` // 是否是交互题
+	Samples     []*ProblemCaseDto `json:"samples"`     // 样例，隐藏的测试用例不会返回
+}
+
+// ProblemCaseDto 展示给用户的样例
+type ProblemCaseDto struct {
+	Name   string `json:"name"`
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// ProblemBankDto 题库
+type ProblemBankDto struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedAt   utils.Time `json:"createdAt"`
+	UpdatedAt   utils.Time `json:"updatedAt"`
+	CreatorID   uint       `json:"creatorID"`
+	CreatorName string     `json:"creatorName"`
+	Enable      bool       `json:"enable"`
+}
+
+func NewProblemDtoForList(problem *po.Problem) *ProblemDtoForList {
+	return &ProblemDtoForList{
+		ID:          problem.ID,
+		BankID:      problem.BankID,
+		Number:      problem.Number,
+		Name:        problem.Name,
+		Difficulty:  problem.Difficulty,
+		Tags:        problemTagNames(problem.Tags),
+		TimeLimit:   problem.TimeLimit,
+		MemoryLimit: problem.MemoryLimit,
+		Enable:      problem.Enable,
+		CreatedAt:   utils.Time(problem.CreatedAt),
+		UpdatedAt:   utils.Time(problem.UpdatedAt),
+	}
+}
+
+func NewProblemDtoForManage(problem *po.Problem, templates []*po.ProblemTemplate) *ProblemDtoForManage {
+	response := &ProblemDtoForManage{
+		ProblemDtoForList: *NewProblemDtoForList(problem),
+		Description:       problem.Description,
+		Templates:         make([]*ProblemTemplateDto, 0, len(templates)),
+		ProblemJudgeDto: ProblemJudgeDto{
+			Checker:            problem.Checker,
+			CheckerEpsilon:     problem.CheckerEpsilon,
+			CheckerLanguage:    problem.CheckerLanguage,
+			CheckerCode:        problem.CheckerCode,
+			Interactive:        problem.Interactive,
+			InteractorLanguage: problem.InteractorLanguage,
+			InteractorCode:     problem.InteractorCode,
+		},
+	}
+	for _, template := range templates {
+		response.Templates = append(response.Templates, &ProblemTemplateDto{
+			Language: template.Language,
+			Code:     template.Code,
+		})
+	}
+	return response
+}
+
+func NewProblemDtoForDetail(problem *po.Problem, samples []*po.ProblemCase) *ProblemDtoForDetail {
+	response := &ProblemDtoForDetail{
+		ID:          problem.ID,
+		BankID:      problem.BankID,
+		Number:      problem.Number,
+		Name:        problem.Name,
+		Description: problem.Description,
+		Difficulty:  problem.Difficulty,
+		Tags:        problemTagNames(problem.Tags),
+		TimeLimit:   problem.TimeLimit,
+		MemoryLimit: problem.MemoryLimit,
+		Interactive: problem.Interactive,
+		Samples:     make([]*ProblemCaseDto, 0, len(samples)),
+	}
+	for _, sample := range samples {
+		response.Samples = append(response.Samples, &ProblemCaseDto{
+			Name:   sample.Name,
+			Input:  sample.Input,
+			Output: sample.Output,
+		})
+	}
+	return response
+}
+
+func NewProblemBankDto(bank *po.ProblemBank) *ProblemBankDto {
+	return &ProblemBankDto{
+		ID:          bank.ID,
+		Name:        bank.Name,
+		Description: bank.Description,
+		CreatedAt:   utils.Time(bank.CreatedAt),
+		UpdatedAt:   utils.Time(bank.UpdatedAt),
+		CreatorID:   bank.CreatorID,
+		Enable:      bank.Enable,
+	}
+}
+
+func problemTagNames(tags []*po.ProblemTag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
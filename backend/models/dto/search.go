@@ -0,0 +1,38 @@
+package dto
+
+import "github.com/fansqz/fancode-backend/models/po"
+
+// SearchQuery 搜索的查询条件
+type SearchQuery struct {
+	Keyword string
+	// Terms 从关键词中拆分出的搜索词，所有的词都需要匹配
+	Terms    []string
+	Type     string
+	BankID   uint
+	Language string
+	// UserID 当前用户，只能搜索到自己保存的代码
+	UserID uint
+}
+
+// SearchResultDto 一条搜索结果，Title和Snippet中的关键词使用<em>标记
+type SearchResultDto struct {
+	Type       string `json:"type"`
+	SourceID   uint   `json:"sourceID"`
+	BankID     uint   `json:"bankID"`
+	DocumentID uint   `json:"documentID"`
+	Language   string `json:"language"`
+	Title      string `json:"title"`
+	Snippet    string `json:"snippet"`
+	// Line 第一个关键词在内容中的行号，从1开始，没有在内容中匹配时为0
+	Line int `json:"line"`
+}
+
+func NewSearchResultDto(entry *po.SearchEntry) *SearchResultDto {
+	return &SearchResultDto{
+		Type:       entry.Type,
+		SourceID:   entry.SourceID,
+		BankID:     entry.BankID,
+		DocumentID: entry.DocumentID,
+		Language:   entry.Language,
+	}
+}
@@ -0,0 +1,114 @@
+package dto
+
+import (
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+)
+
+// SubmitRequest 提交代码的请求
+type SubmitRequest struct {
+	ProblemID uint   `json:"problemID" binding:"required"` // 题目ID
+	Language  string `json:"language" binding:"required"`  // 编程语言
+	Code      string `json:"code" binding:"required"`      // 代码内容
+}
+
+// SubmissionDtoForQuery 查询提交记录的DTO
+type SubmissionDtoForQuery struct {
+	ProblemID uint `json:"problemID" form:"problemID"` // 题目ID，可选
+	Page      int  `json:"page" form:"page"`           // 页码
+	PageSize  int  `json:"pageSize" form:"pageSize"`   // 每页大小
+}
+
+// SubmissionDtoForList 提交记录列表的DTO
+type SubmissionDtoForList struct {
+	ID            uint       `json:"id"`
+	ProblemID     uint       `json:"problemID"`
+	Language      string     `json:"language"`
+	Status        int        `json:"status"`        // 判题结果
+	StatusMessage string     `json:"statusMessage"` // 判题结果说明
+	TimeUsed      int64      `json:"timeUsed"`      // 运行时间，单位毫秒
+	MemoryUsed    int64      `json:"memoryUsed"`    // 内存使用量，单位KB
+	PassedCount   int        `json:"passedCount"`   // 通过的用例数量
+	CaseCount     int        `json:"caseCount"`     // 用例总数
+	Score         float64    `json:"score"`         // 得分，0到100之间
+	CreatedAt     utils.Time `json:"createdAt"`
+}
+
+// SubmissionCaseDto 单个测试用例的判题结果
+type SubmissionCaseDto struct {
+	CaseID        uint    `json:"caseID"`
+	CaseName      string  `json:"caseName"`
+	Status        int     `json:"status"`
+	StatusMessage string  `json:"statusMessage"`
+	ErrorMessage  string  `json:"errorMessage"`
+	TimeUsed      int64   `json:"timeUsed"`
+	MemoryUsed    int64   `json:"memoryUsed"`
+	Score         float64 `json:"score"`
+}
+
+// SubmissionDtoForDetail 提交记录详情的DTO
+type SubmissionDtoForDetail struct {
+	SubmissionDtoForList
+	Code         string               `json:"code"`
+	ErrorMessage string               `json:"errorMessage"` // 编译错误或第一个未通过用例的异常信息
+	Cases        []*SubmissionCaseDto `json:"cases"`
+}
+
+func NewSubmissionDtoForList(submission *po.Submission) *SubmissionDtoForList {
+	return &SubmissionDtoForList{
+		ID:            submission.ID,
+		ProblemID:     submission.ProblemID,
+		Language:      submission.Language,
+		Status:        submission.Status,
+		StatusMessage: constants.JudgeStatusMessages[submission.Status],
+		TimeUsed:      submission.TimeUsed,
+		MemoryUsed:    submission.MemoryUsed,
+		PassedCount:   submission.PassedCount,
+		CaseCount:     submission.CaseCount,
+		Score:         submission.Score,
+		CreatedAt:     utils.Time(submission.CreatedAt),
+	}
+}
+
+func NewSubmissionDtoForDetail(submission *po.Submission, cases []*po.SubmissionCase) *SubmissionDtoForDetail {
+	detail := &SubmissionDtoForDetail{
+		SubmissionDtoForList: *NewSubmissionDtoForList(submission),
+		Code:                 submission.Code,
+		ErrorMessage:         submission.ErrorMessage,
+		Cases:                make([]*SubmissionCaseDto, 0, len(cases)),
+	}
+	for _, c := range cases {
+		detail.Cases = append(detail.Cases, &SubmissionCaseDto{
+			CaseID:        c.CaseID,
+			CaseName:      c.CaseName,
+			Status:        c.Status,
+			StatusMessage: constants.JudgeStatusMessages[c.Status],
+			ErrorMessage:  c.ErrorMessage,
+			TimeUsed:      c.TimeUsed,
+			MemoryUsed:    c.MemoryUsed,
+			Score:         c.Score,
+		})
+	}
+	return detail
+}
+
+// RunRequest 使用自定义输入运行代码的请求，不需要题目
+type RunRequest struct {
+	Language string `json:"language" binding:"required"` // 编程语言
+	Code     string `json:"code" binding:"required"`     // 代码内容
+	Input    string `json:"input"`                       // 标准输入
+}
+
+// RunResult 运行结果
+type RunResult struct {
+	Status        int    `json:"status"`        // 运行结果
+	StatusMessage string `json:"statusMessage"` // 运行结果说明
+	ErrorMessage  string `json:"errorMessage"`  // 编译错误或运行异常信息
+	Stdout        string `json:"stdout"`
+	Stderr        string `json:"stderr"`
+	ExitCode      int    `json:"exitCode"`
+	TimeUsed      int64  `json:"timeUsed"`   // cpu时间，单位毫秒
+	MemoryUsed    int64  `json:"memoryUsed"` // 内存使用峰值，单位KB
+	Cached        bool   `json:"cached"`     // 是否复用了已有的编译结果
+}
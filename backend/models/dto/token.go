@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/fansqz/fancode-backend/models/po"
+)
+
+// TokenDto 登录或刷新后返回的token
+type TokenDto struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"` // access token有效时间，单位秒
+}
+
+// LoginDeviceDto 登录设备的信息
+type LoginDeviceDto struct {
+	UserAgent string
+	IP        string
+}
+
+// UserSessionDto 用户已登录的设备
+type UserSessionDto struct {
+	SessionID    string    `json:"sessionID"`
+	UserAgent    string    `json:"userAgent"`
+	IP           string    `json:"ip"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastActiveAt time.Time `json:"lastActiveAt"`
+	Current      bool      `json:"current"` // 是否是当前设备
+}
+
+func NewUserSessionDto(session *po.SysUserSession, currentSessionID string) *UserSessionDto {
+	return &UserSessionDto{
+		SessionID:    session.SessionID,
+		UserAgent:    session.UserAgent,
+		IP:           session.IP,
+		CreatedAt:    session.CreatedAt,
+		LastActiveAt: session.LastActiveAt,
+		Current:      session.SessionID == currentSessionID,
+	}
+}
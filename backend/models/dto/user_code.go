@@ -0,0 +1,27 @@
+package dto
+
+import "github.com/fansqz/fancode-backend/models/po"
+
+// UserCodeDto 用户在题目中某个语言的代码，用户没有保存过代码时返回题目模板，id为0
+type UserCodeDto struct {
+	ID        uint   `json:"id"`
+	ProblemID uint   `json:"problemID"`
+	Language  string `json:"language"`
+	Code      string `json:"code"`
+}
+
+// SaveUserCodeRequest 保存用户在题目中的代码
+type SaveUserCodeRequest struct {
+	ProblemID uint   `json:"problemID" binding:"required"`
+	Language  string `json:"language" binding:"required"`
+	Code      string `json:"code"`
+}
+
+func NewUserCodeDto(code *po.UserCode) *UserCodeDto {
+	return &UserCodeDto{
+		ID:        code.ID,
+		ProblemID: code.ProblemID,
+		Language:  code.Language,
+		Code:      code.Code,
+	}
+}
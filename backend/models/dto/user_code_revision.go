@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+)
+
+// CodeRevisionQuery 查询代码的历史版本
+type CodeRevisionQuery struct {
+	SourceType string `form:"sourceType" binding:"required,oneof=savedCode userCode"`
+	SourceID   uint   `form:"sourceID" binding:"required"`
+}
+
+// CreateCodeCheckpointRequest 将代码当前的内容保存为命名的检查点
+type CreateCodeCheckpointRequest struct {
+	SourceType string `json:"sourceType" binding:"required,oneof=savedCode userCode"`
+	SourceID   uint   `json:"sourceID" binding:"required"`
+	Name       string `json:"name" binding:"required,max=64"`
+}
+
+// CodeRevisionDto 代码历史版本，不包含代码内容
+type CodeRevisionDto struct {
+	ID           uint       `json:"id"`
+	SourceType   string     `json:"sourceType"`
+	SourceID     uint       `json:"sourceID"`
+	Version      uint       `json:"version"`
+	Language     string     `json:"language"`
+	Checkpoint   bool       `json:"checkpoint"`
+	Name         string     `json:"name"`
+	RestoredFrom uint       `json:"restoredFrom"` // 恢复历史版本时被恢复的版本id
+	CreatedAt    utils.Time `json:"createdAt"`
+}
+
+// CodeRevisionDetailDto 代码历史版本的详情
+type CodeRevisionDetailDto struct {
+	CodeRevisionDto
+	Code string `json:"code"`
+}
+
+// CodeRevisionDiffDto 两个代码历史版本之间的差异
+type CodeRevisionDiffDto struct {
+	FromVersion  uint   `json:"fromVersion"`
+	ToVersion    uint   `json:"toVersion"`
+	FromLanguage string `json:"fromLanguage"`
+	ToLanguage   string `json:"toLanguage"`
+	// Code unified diff格式的代码差异，没有差异时为空
+	Code string `json:"code"`
+}
+
+func NewCodeRevisionDto(revision *po.UserCodeRevision) *CodeRevisionDto {
+	return &CodeRevisionDto{
+		ID:           revision.ID,
+		SourceType:   revision.SourceType,
+		SourceID:     revision.SourceID,
+		Version:      revision.Version,
+		Language:     revision.Language,
+		Checkpoint:   revision.Checkpoint,
+		Name:         revision.Name,
+		RestoredFrom: revision.RestoredFrom,
+		CreatedAt:    utils.Time(revision.CreatedAt),
+	}
+}
@@ -11,6 +11,12 @@ type UserSavedCodeDtoForCreate struct {
 	Language   string `json:"language" binding:"required"`    // 编程语言
 	Code       string `json:"code" binding:"required"`        // 代码内容
 	Remark     string `json:"remark"`                         // 备注
+	// VisualDescription 可视化描述，可选
+	VisualDescription *VisualDescription `json:"visualDescription"`
+	Title             string             `json:"title" binding:"max=128"` // 标题
+	FolderID          *uint              `json:"folderID"`                // 所在文件夹，为空时在根目录
+	Tags              []string           `json:"tags"`                    // 标签
+	Starred           bool               `json:"starred"`                 // 是否收藏
 }
 
 // UserSavedCodeDtoForUpdate 更新用户保存代码的DTO
@@ -19,6 +25,60 @@ type UserSavedCodeDtoForUpdate struct {
 	Language string `json:"language" binding:"required"` // 编程语言
 	Code     string `json:"code" binding:"required"`     // 代码内容
 	Remark   string `json:"remark"`                      // 备注
+	// VisualDescription 可视化描述，为空时不修改已保存的描述，清除描述使用UpdateSavedCodeVisualDescriptionRequest
+	VisualDescription *VisualDescription `json:"visualDescription"`
+}
+
+// UpdateSavedCodeVisualDescriptionRequest 只更新保存代码的可视化描述
+type UpdateSavedCodeVisualDescriptionRequest struct {
+	ID uint `json:"id" binding:"required"` // 代码ID
+	// VisualDescription 可视化描述，为空时清除已保存的描述
+	VisualDescription *VisualDescription `json:"visualDescription"`
+}
+
+// UpdateSavedCodeMetaRequest 修改保存代码的标题、文件夹和标签，不修改代码内容
+type UpdateSavedCodeMetaRequest struct {
+	ID       uint     `json:"id" binding:"required"`   // 代码ID
+	Title    string   `json:"title" binding:"max=128"` // 标题
+	FolderID *uint    `json:"folderID"`                // 所在文件夹，为空时移动到根目录
+	Tags     []string `json:"tags"`                    // 标签，会替换原有的标签
+}
+
+// StarSavedCodeRequest 收藏或取消收藏保存的代码
+type StarSavedCodeRequest struct {
+	ID      uint `json:"id" binding:"required"`
+	Starred bool `json:"starred"`
+}
+
+// BatchMoveSavedCodeRequest 批量移动保存的代码到文件夹
+type BatchMoveSavedCodeRequest struct {
+	IDs      []uint `json:"ids" binding:"required,min=1,max=100"`
+	FolderID *uint  `json:"folderID"` // 目标文件夹，为空时移动到根目录
+}
+
+// BatchDeleteSavedCodeRequest 批量删除保存的代码
+type BatchDeleteSavedCodeRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=100"`
+}
+
+// SavedCodeFolderRequest 创建或重命名文件夹，id为0时创建
+type SavedCodeFolderRequest struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+// SavedCodeFolderDto 文件夹
+type SavedCodeFolderDto struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	CodeCount int64      `json:"codeCount"` // 文件夹中代码的数量
+	CreatedAt utils.Time `json:"createdAt"`
+}
+
+// SavedCodeTagDto 用户使用过的标签
+type SavedCodeTagDto struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"` // 使用该标签的代码数量
 }
 
 // UserSavedCodeDtoForList 用户保存代码列表的DTO
@@ -29,6 +89,10 @@ type UserSavedCodeDtoForList struct {
 	Remark     string     `json:"remark"`     // 备注
 	CreatedAt  utils.Time `json:"createdAt"`  // 创建时间
 	UpdatedAt  utils.Time `json:"updatedAt"`  // 更新时间
+	Title      string     `json:"title"`      // 标题
+	FolderID   *uint      `json:"folderID"`   // 所在文件夹
+	Starred    bool       `json:"starred"`    // 是否收藏
+	Tags       []string   `json:"tags"`       // 标签
 }
 
 // UserSavedCodeDtoForDetail 用户保存代码详情的DTO
@@ -40,6 +104,12 @@ type UserSavedCodeDtoForDetail struct {
 	Remark     string     `json:"remark"`     // 备注
 	CreatedAt  utils.Time `json:"createdAt"`  // 创建时间
 	UpdatedAt  utils.Time `json:"updatedAt"`  // 更新时间
+	Title      string     `json:"title"`      // 标题
+	FolderID   *uint      `json:"folderID"`   // 所在文件夹
+	Starred    bool       `json:"starred"`    // 是否收藏
+	Tags       []string   `json:"tags"`       // 标签
+	// VisualDescription 用户指定的可视化描述，未指定时为null
+	VisualDescription *VisualDescription `json:"visualDescription"`
 }
 
 // UserSavedCodeDtoForQuery 查询用户保存代码的DTO
@@ -48,6 +118,14 @@ type UserSavedCodeDtoForQuery struct {
 	Language   string `json:"language" form:"language"`     // 编程语言，可选
 	Page       int    `json:"page" form:"page"`             // 页码
 	PageSize   int    `json:"pageSize" form:"pageSize"`     // 每页大小
+	// FolderID 文件夹，为空时不按文件夹过滤，为0时只查询根目录中的代码
+	FolderID *uint  `json:"folderID" form:"folderID"`
+	Tag      string `json:"tag" form:"tag"`         // 标签，可选
+	Starred  *bool  `json:"starred" form:"starred"` // 只查询收藏或未收藏的代码，可选
+	Keyword  string `json:"keyword" form:"keyword"` // 按标题和备注搜索，可选
+	// Sort 排序字段，createdAt、updatedAt或title，默认createdAt
+	Sort  string `json:"sort" form:"sort" binding:"omitempty,oneof=createdAt updatedAt title"`
+	Order string `json:"order" form:"order" binding:"omitempty,oneof=asc desc"` // 排序方向，默认desc
 }
 
 // NewUserSavedCodeDtoForList 创建列表DTO
@@ -59,6 +137,10 @@ func NewUserSavedCodeDtoForList(savedCode *po.UserSavedCode) *UserSavedCodeDtoFo
 		Remark:     savedCode.Remark,
 		CreatedAt:  utils.Time(savedCode.CreatedAt),
 		UpdatedAt:  utils.Time(savedCode.UpdatedAt),
+		Title:      savedCode.Title,
+		FolderID:   savedCode.FolderID,
+		Starred:    savedCode.Starred,
+		Tags:       []string{},
 	}
 }
 
@@ -72,5 +154,11 @@ func NewUserSavedCodeDtoForDetail(savedCode *po.UserSavedCode) *UserSavedCodeDto
 		Remark:     savedCode.Remark,
 		CreatedAt:  utils.Time(savedCode.CreatedAt),
 		UpdatedAt:  utils.Time(savedCode.UpdatedAt),
+		Title:      savedCode.Title,
+		FolderID:   savedCode.FolderID,
+		Starred:    savedCode.Starred,
+		Tags:       []string{},
+
+		VisualDescription: ParseVisualDescription(savedCode.VisualDescription),
 	}
 }
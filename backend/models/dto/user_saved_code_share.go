@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+)
+
+// CreateSavedCodeShareRequest 分享用户保存的代码
+type CreateSavedCodeShareRequest struct {
+	SavedCodeID uint `json:"savedCodeID" binding:"required"` // 代码ID
+	// ExpireDays 有效天数，为0时永久有效
+	ExpireDays int `json:"expireDays" binding:"min=0,max=365"`
+}
+
+// SavedCodeShareDto 分享的信息，分享者查看
+type SavedCodeShareDto struct {
+	ShareID     string      `json:"shareID"`
+	SavedCodeID uint        `json:"savedCodeID"`
+	ForkCount   int         `json:"forkCount"`
+	ExpireAt    *utils.Time `json:"expireAt"` // 过期时间，为空时永久有效
+	CreatedAt   utils.Time  `json:"createdAt"`
+}
+
+// SharedCodeDto 通过分享链接查看的代码
+type SharedCodeDto struct {
+	ShareID   string      `json:"shareID"`
+	Language  string      `json:"language"`
+	Code      string      `json:"code"`
+	Remark    string      `json:"remark"`
+	Author    string      `json:"author"` // 分享者的名称
+	ExpireAt  *utils.Time `json:"expireAt"`
+	CreatedAt utils.Time  `json:"createdAt"`
+	// VisualDescription 分享时代码的可视化描述，未指定时为null
+	VisualDescription *VisualDescription `json:"visualDescription"`
+}
+
+func NewSavedCodeShareDto(share *po.UserSavedCodeShare) *SavedCodeShareDto {
+	return &SavedCodeShareDto{
+		ShareID:     share.ShareID,
+		SavedCodeID: share.SavedCodeID,
+		ForkCount:   share.ForkCount,
+		ExpireAt:    formatExpireAt(share),
+		CreatedAt:   utils.Time(share.CreatedAt),
+	}
+}
+
+func NewSharedCodeDto(share *po.UserSavedCodeShare, author string) *SharedCodeDto {
+	return &SharedCodeDto{
+		ShareID:           share.ShareID,
+		Language:          share.Language,
+		Code:              share.Code,
+		Remark:            share.Remark,
+		Author:            author,
+		ExpireAt:          formatExpireAt(share),
+		CreatedAt:         utils.Time(share.CreatedAt),
+		VisualDescription: ParseVisualDescription(share.VisualDescription),
+	}
+}
+
+func formatExpireAt(share *po.UserSavedCodeShare) *utils.Time {
+	if share.ExpireAt == nil {
+		return nil
+	}
+	expireAt := utils.Time(*share.ExpireAt)
+	return &expireAt
+}
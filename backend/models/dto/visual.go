@@ -1,6 +1,10 @@
 package dto
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
 	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
 )
@@ -25,12 +29,195 @@ type Array2DVisualRequest struct {
 	Query   debug_core.Array2DVisualQuery `json:"query"`
 }
 
+// UpdateVisualDescriptionRequest 用户修改调试会话的可视化描述
+type UpdateVisualDescriptionRequest struct {
+	DebugID           string             `json:"debugID"`
+	VisualDescription *VisualDescription `json:"visualDescription"`
+	// SavedCodeID 不为0时，同时保存到用户保存的代码中
+	SavedCodeID uint `json:"savedCodeID"`
+	// Force 为true时跳过与程序变量的校验，比如变量还未进入作用域
+	Force bool `json:"force"`
+}
+
 // VisualDescription 可视化描述
 type VisualDescription struct {
 	VisualType  constants.VisualType `json:"visualType"`
 	Description interface{}          `json:"description"`
 }
 
+// 变量名、字段名以及结构体名称的格式，结构体名称允许带命名空间
+var (
+	visualNameRegexp   = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	visualStructRegexp = regexp.MustCompile(`^[A-Za-z_][\w:]*$`)
+)
+
+// UnmarshalJSON 根据visualType将description解析为对应的描述结构体
+func (v *VisualDescription) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		VisualType  constants.VisualType `json:"visualType"`
+		Description json.RawMessage      `json:"description"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	v.VisualType = raw.VisualType
+	if len(raw.Description) == 0 || string(raw.Description) == "null" {
+		raw.Description = []byte("{}")
+	}
+	var err error
+	switch raw.VisualType {
+	case constants.ArrayType:
+		desc := ArrayDescription{}
+		err = json.Unmarshal(raw.Description, &desc)
+		v.Description = desc
+	case constants.Array2DType:
+		desc := Array2DDescription{}
+		err = json.Unmarshal(raw.Description, &desc)
+		v.Description = desc
+	case constants.BinaryTreeType:
+		desc := BinaryTreeDescription{}
+		err = json.Unmarshal(raw.Description, &desc)
+		v.Description = desc
+	case constants.GraphType:
+		desc := GraphDescription{}
+		err = json.Unmarshal(raw.Description, &desc)
+		v.Description = desc
+	case constants.LinkListType:
+		desc := LinkListDescription{}
+		err = json.Unmarshal(raw.Description, &desc)
+		v.Description = desc
+	default:
+		return fmt.Errorf("unknown visual type: %s", raw.VisualType)
+	}
+	return err
+}
+
+// Validate 校验可视化描述的格式，不涉及程序中的变量
+func (v *VisualDescription) Validate() error {
+	checkNames := func(field string, names ...string) error {
+		for _, name := range names {
+			if !visualNameRegexp.MatchString(name) {
+				return fmt.Errorf("%s is invalid: %q", field, name)
+			}
+		}
+		return nil
+	}
+	checkStruct := func(field string, name string) error {
+		if !visualStructRegexp.MatchString(name) {
+			return fmt.Errorf("%s is invalid: %q", field, name)
+		}
+		return nil
+	}
+	checkOptional := func(field string, name string) error {
+		if name == "" {
+			return nil
+		}
+		return checkNames(field, name)
+	}
+
+	switch desc := v.Description.(type) {
+	case ArrayDescription:
+		if desc.DisplayType != "" && desc.DisplayType != "array" && desc.DisplayType != "array-bar" {
+			return fmt.Errorf("displayType is invalid: %q", desc.DisplayType)
+		}
+		if err := checkNames("arrayName", desc.ArrayName); err != nil {
+			return err
+		}
+		return checkNames("pointNames", desc.PointNames...)
+	case Array2DDescription:
+		if err := checkNames("arrayName", desc.ArrayName); err != nil {
+			return err
+		}
+		if err := checkNames("rowPointNames", desc.RowPointNames...); err != nil {
+			return err
+		}
+		return checkNames("colPointNames", desc.ColPointNames...)
+	case BinaryTreeDescription:
+		if err := checkStruct("treeNode", desc.TreeNode); err != nil {
+			return err
+		}
+		if err := checkOptional("data", desc.Data); err != nil {
+			return err
+		}
+		return checkNames("left/right", desc.Left, desc.Right)
+	case GraphDescription:
+		if err := checkStruct("graphNode", desc.GraphNode); err != nil {
+			return err
+		}
+		if err := checkOptional("data", desc.Data); err != nil {
+			return err
+		}
+		if len(desc.Nexts) == 0 {
+			return fmt.Errorf("nexts is required")
+		}
+		return checkNames("nexts", desc.Nexts...)
+	case LinkListDescription:
+		if err := checkStruct("linkNode", desc.LinkNode); err != nil {
+			return err
+		}
+		if err := checkOptional("data", desc.Data); err != nil {
+			return err
+		}
+		if err := checkOptional("prev", desc.Prev); err != nil {
+			return err
+		}
+		return checkNames("next", desc.Next)
+	default:
+		return fmt.Errorf("unknown visual type: %s", v.VisualType)
+	}
+}
+
+// NewVisualCheckQuery 根据可视化描述生成调试器的校验查询
+// 数组只检查数组名称，下标变量可能还没有进入作用域
+func (v *VisualDescription) NewVisualCheckQuery() *debug_core.VisualCheckQuery {
+	nonEmpty := func(names ...string) []string {
+		answer := make([]string, 0, len(names))
+		for _, name := range names {
+			if name != "" {
+				answer = append(answer, name)
+			}
+		}
+		return answer
+	}
+	switch desc := v.Description.(type) {
+	case ArrayDescription:
+		return &debug_core.VisualCheckQuery{Variables: []string{desc.ArrayName}}
+	case Array2DDescription:
+		return &debug_core.VisualCheckQuery{Variables: []string{desc.ArrayName}}
+	case BinaryTreeDescription:
+		return &debug_core.VisualCheckQuery{Struct: desc.TreeNode, Fields: nonEmpty(desc.Data, desc.Left, desc.Right)}
+	case GraphDescription:
+		return &debug_core.VisualCheckQuery{Struct: desc.GraphNode, Fields: nonEmpty(append([]string{desc.Data}, desc.Nexts...)...)}
+	case LinkListDescription:
+		return &debug_core.VisualCheckQuery{Struct: desc.LinkNode, Fields: nonEmpty(desc.Data, desc.Next, desc.Prev)}
+	}
+	return &debug_core.VisualCheckQuery{}
+}
+
+// ParseVisualDescription 解析数据库中保存的可视化描述，为空或者格式错误时返回nil
+func ParseVisualDescription(data string) *VisualDescription {
+	if data == "" {
+		return nil
+	}
+	answer := &VisualDescription{}
+	if err := json.Unmarshal([]byte(data), answer); err != nil {
+		return nil
+	}
+	return answer
+}
+
+// FormatVisualDescription 将可视化描述转换为数据库中保存的格式，nil转换为空字符串
+func FormatVisualDescription(v *VisualDescription) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 // ArrayDescription 数组可视化描述
 type ArrayDescription struct {
 	ArrayName  string   `json:"arrayName"`
@@ -3,6 +3,7 @@ package dto
 import (
 	"encoding/json"
 	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
 	"log"
 )
 
@@ -14,16 +15,24 @@ type VisualDocumentDto struct {
 	Content  string                   `json:"content"`
 	Enable   bool                     `json:"enable"`
 	CodeList []*VisualDocumentCodeDto `json:"codeList"`
+	// Snippets 文档中的代码示例
+	Snippets []*VisualDocumentSnippetDto `json:"snippets"`
+	// PublishedRevisionID 已发布的版本，为0时表示还没有发布过
+	PublishedRevisionID uint `json:"publishedRevisionID"`
+	// Version 文档的版本，保存时需要传入读取时的版本
+	Version uint `json:"version"`
 }
 
 func NewVisualDocumentDto(document *po.VisualDocument) *VisualDocumentDto {
 	return &VisualDocumentDto{
-		BankID:   document.BankID,
-		ID:       document.ID,
-		ParentID: document.ParentID,
-		Title:    document.Title,
-		Content:  document.Content,
-		Enable:   document.Enable,
+		BankID:              document.BankID,
+		ID:                  document.ID,
+		ParentID:            document.ParentID,
+		Title:               document.Title,
+		Content:             document.Content,
+		Enable:              document.Enable,
+		PublishedRevisionID: document.PublishedRevisionID,
+		Version:             document.Version,
 	}
 }
 
@@ -32,6 +41,8 @@ type VisualDocumentCodeDto struct {
 	Language string `gorm:"column:language" json:"language"`
 	// Breakpoints 初始断点
 	Breakpoints []int `gorm:"column:breakpoints" json:"breakpoints"`
+	// VisualDescription 作者指定的可视化描述，为空时由系统分析
+	VisualDescription *VisualDescription `json:"visualDescription"`
 }
 
 func NewVisualDocumentCodeDto(code *po.VisualDocumentCode) *VisualDocumentCodeDto {
@@ -43,9 +54,28 @@ func NewVisualDocumentCodeDto(code *po.VisualDocumentCode) *VisualDocumentCodeDt
 		Code:        code.Code,
 		Language:    code.Language,
 		Breakpoints: bps,
+		// 数据库中的描述格式错误时当做未指定处理
+		VisualDescription: ParseVisualDescription(code.VisualDescription),
 	}
 }
 
+// VisualDocumentSnippetDto 文档中的代码示例
+type VisualDocumentSnippetDto struct {
+	ID         uint `json:"id"`
+	DocumentID uint `json:"documentID"`
+	// Key 示例在文档中的标识，markdown中通过<!-- snippet:key -->引用
+	Key      string                          `json:"key"`
+	Title    string                          `json:"title"`
+	CodeList []*VisualDocumentSnippetCodeDto `json:"codeList"`
+}
+
+// VisualDocumentSnippetCodeDto 代码示例某个语言的代码
+type VisualDocumentSnippetCodeDto struct {
+	VisualDocumentCodeDto
+	// Stdin 运行示例时预设的标准输入
+	Stdin string `json:"stdin"`
+}
+
 type VisualDocumentDirectoryDto struct {
 	ID       uint                          `json:"id"`
 	ParentID uint                          `json:"parentID"`
@@ -73,3 +103,57 @@ type UpdateVisualDocumentReq struct {
 	DraggingDocumentID uint   `json:"draggingDocumentID"`
 	DragDocumentID     uint   `json:"dragDocumentID"`
 }
+
+// VisualDocumentRevisionDto 可视化文档的版本
+type VisualDocumentRevisionDto struct {
+	ID         uint       `json:"id"`
+	DocumentID uint       `json:"documentID"`
+	Version    uint       `json:"version"`
+	AuthorID   uint       `json:"authorID"`
+	AuthorName string     `json:"authorName"`
+	Published  bool       `json:"published"` // 是否是当前发布的版本
+	CreatedAt  utils.Time `json:"createdAt"`
+}
+
+func NewVisualDocumentRevisionDto(revision *po.VisualDocumentRevision) *VisualDocumentRevisionDto {
+	return &VisualDocumentRevisionDto{
+		ID:         revision.ID,
+		DocumentID: revision.DocumentID,
+		Version:    revision.Version,
+		AuthorID:   revision.AuthorID,
+		CreatedAt:  utils.Time(revision.CreatedAt),
+	}
+}
+
+// VisualDocumentRevisionDetailDto 可视化文档版本的内容
+type VisualDocumentRevisionDetailDto struct {
+	VisualDocumentRevisionDto
+	Content  string                      `json:"content"`
+	CodeList []*VisualDocumentCodeDto    `json:"codeList"`
+	Snippets []*VisualDocumentSnippetDto `json:"snippets"`
+}
+
+// VisualDocumentRevisionDiffDto 两个版本之间的差异，使用unified diff格式
+type VisualDocumentRevisionDiffDto struct {
+	FromVersion uint                         `json:"fromVersion"`
+	ToVersion   uint                         `json:"toVersion"`
+	Content     string                       `json:"content"`  // 文档内容的差异，没有差异时为空
+	CodeList    []*VisualDocumentCodeDiffDto `json:"codeList"` // 有差异的代码
+	Snippets    []string                     `json:"snippets"` // 新增、删除或修改的代码示例的key
+}
+
+// VisualDocumentCodeDiffDto 同一语言的代码在两个版本之间的差异
+type VisualDocumentCodeDiffDto struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+	// Breakpoints 初始断点是否变化
+	BreakpointsChanged bool `json:"breakpointsChanged"`
+	// VisualDescriptionChanged 可视化描述是否变化
+	VisualDescriptionChanged bool `json:"visualDescriptionChanged"`
+}
+
+// PublishVisualDocumentReq 发布可视化文档的请求
+type PublishVisualDocumentReq struct {
+	// RevisionID 需要发布的版本，为0时发布最新的版本
+	RevisionID uint `json:"revisionID"`
+}
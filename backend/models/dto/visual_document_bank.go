@@ -15,6 +15,8 @@ type VisualDocumentBankDto struct {
 	CreatorName string                   `json:"creatorName"`
 	Enable      bool                     `json:"enable"`
 	CodeList    []*VisualDocumentCodeDto `json:"codeList"`
+	// Role 当前用户在知识库中的角色，只在管理端返回
+	Role string `json:"role,omitempty"`
 }
 
 func NewVisualDocumentBankDto(bank *po.VisualDocumentBank) *VisualDocumentBankDto {
@@ -29,3 +31,34 @@ func NewVisualDocumentBankDto(bank *po.VisualDocumentBank) *VisualDocumentBankDt
 	}
 	return response
 }
+
+// ImportVisualDocumentBankResult 导入知识库的结果
+type ImportVisualDocumentBankResult struct {
+	BankID uint `json:"bankID"`
+	// Created 新增的文档数量
+	Created int `json:"created"`
+	// Updated 合并时覆盖的文档数量
+	Updated int `json:"updated"`
+}
+
+// VisualDocumentBankMemberDto 知识库的协作者
+type VisualDocumentBankMemberDto struct {
+	UserID    uint       `json:"userID"`
+	Username  string     `json:"username"`
+	Role      string     `json:"role"`
+	CreatedAt utils.Time `json:"createdAt"`
+}
+
+func NewVisualDocumentBankMemberDto(member *po.VisualDocumentBankMember) *VisualDocumentBankMemberDto {
+	return &VisualDocumentBankMemberDto{
+		UserID:    member.UserID,
+		Role:      member.Role,
+		CreatedAt: utils.Time(member.CreatedAt),
+	}
+}
+
+// SaveVisualDocumentBankMemberReq 添加协作者或修改协作者的角色
+type SaveVisualDocumentBankMemberReq struct {
+	UserID uint   `json:"userID"`
+	Role   string `json:"role"`
+}
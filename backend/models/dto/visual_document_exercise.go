@@ -0,0 +1,120 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+)
+
+// VisualDocumentExerciseDto 文档中的练习，返回给用户时不包含答案和测试用例
+type VisualDocumentExerciseDto struct {
+	ID         uint   `json:"id"`
+	DocumentID uint   `json:"documentID"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Question   string `json:"question"`
+	// Options 选择题的选项
+	Options []string `json:"options"`
+	// Choices 选择题正确选项的下标
+	Choices []int `json:"choices,omitempty"`
+	// Answer 预测输出和变量追踪的正确答案，预测输出为空时运行代码得到，变量追踪总是由调试器得到
+	Answer   string `json:"answer,omitempty"`
+	Language string `json:"language"`
+	// Code 练习展示的代码，补全代码时为代码模板
+	Code  string `json:"code"`
+	Stdin string `json:"stdin"`
+	// Breakpoints 变量追踪时程序会在断点处暂停，之后每一步单步执行
+	Breakpoints []int `json:"breakpoints"`
+	// Step 变量追踪时读取程序第几次暂停时的变量，从1开始
+	Step     int    `json:"step"`
+	Variable string `json:"variable"`
+	// Cases 补全代码的测试用例
+	Cases       []*ExerciseCaseDto `json:"cases,omitempty"`
+	Explanation string             `json:"explanation,omitempty"`
+	Order       uint               `json:"order"`
+	// Result 当前用户的作答结果，没有作答时为空
+	Result *ExerciseResultDto `json:"result,omitempty"`
+}
+
+// ExerciseCaseDto 补全代码的测试用例
+type ExerciseCaseDto struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+func NewVisualDocumentExerciseDto(exercise *po.VisualDocumentExercise) *VisualDocumentExerciseDto {
+	answer := &VisualDocumentExerciseDto{
+		ID:          exercise.ID,
+		DocumentID:  exercise.DocumentID,
+		Type:        exercise.Type,
+		Title:       exercise.Title,
+		Question:    exercise.Question,
+		Options:     []string{},
+		Answer:      exercise.Answer,
+		Language:    exercise.Language,
+		Code:        exercise.Code,
+		Stdin:       exercise.Stdin,
+		Breakpoints: []int{},
+		Step:        exercise.Step,
+		Variable:    exercise.Variable,
+		Cases:       []*ExerciseCaseDto{},
+		Explanation: exercise.Explanation,
+		Order:       exercise.Order,
+	}
+	// 格式错误的字段当做空处理
+	if exercise.Options != "" {
+		_ = json.Unmarshal([]byte(exercise.Options), &answer.Options)
+	}
+	if exercise.Breakpoints != "" {
+		_ = json.Unmarshal([]byte(exercise.Breakpoints), &answer.Breakpoints)
+	}
+	if exercise.Cases != "" {
+		_ = json.Unmarshal([]byte(exercise.Cases), &answer.Cases)
+	}
+	// 选择题的答案是正确选项下标的json
+	if exercise.Type == constants.ExerciseChoice {
+		answer.Answer = ""
+		_ = json.Unmarshal([]byte(exercise.Answer), &answer.Choices)
+	}
+	return answer
+}
+
+// SubmitExerciseReq 提交练习的答案
+type SubmitExerciseReq struct {
+	// Choices 选择题选择的选项下标
+	Choices []int `json:"choices"`
+	// Answer 预测输出和变量追踪的答案
+	Answer string `json:"answer"`
+	// Code 补全代码提交的代码
+	Code string `json:"code"`
+}
+
+// ExerciseResultDto 用户的作答结果
+type ExerciseResultDto struct {
+	ExerciseID uint   `json:"exerciseID"`
+	UserID     uint   `json:"userID,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Answer     string `json:"answer"`
+	Correct    bool   `json:"correct"`
+	// Passed 是否曾经答对过
+	Passed   bool   `json:"passed"`
+	Attempts int    `json:"attempts"`
+	Message  string `json:"message"`
+	// Explanation 练习的解析，作答以后才返回
+	Explanation string     `json:"explanation,omitempty"`
+	UpdatedAt   utils.Time `json:"updatedAt"`
+}
+
+func NewExerciseResultDto(result *po.VisualDocumentExerciseResult) *ExerciseResultDto {
+	return &ExerciseResultDto{
+		ExerciseID: result.ExerciseID,
+		Answer:     result.Answer,
+		Correct:    result.Correct,
+		Passed:     result.Passed,
+		Attempts:   result.Attempts,
+		Message:    result.Message,
+		UpdatedAt:  utils.Time(result.UpdatedAt),
+	}
+}
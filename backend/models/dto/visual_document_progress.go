@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+)
+
+// DocumentProgressDto 用户在一篇文档上的学习进度
+type DocumentProgressDto struct {
+	DocumentID   uint        `json:"documentID"`
+	Status       int         `json:"status"`
+	LastViewedAt utils.Time  `json:"lastViewedAt"`
+	CompletedAt  *utils.Time `json:"completedAt"`
+}
+
+func NewDocumentProgressDto(progress *po.VisualDocumentProgress) *DocumentProgressDto {
+	answer := &DocumentProgressDto{
+		DocumentID:   progress.DocumentID,
+		Status:       progress.Status,
+		LastViewedAt: utils.Time(progress.LastViewedAt),
+	}
+	if progress.CompletedAt != nil {
+		completedAt := utils.Time(*progress.CompletedAt)
+		answer.CompletedAt = &completedAt
+	}
+	return answer
+}
+
+// BankProgressDto 用户在知识库中的学习进度，只统计用户可以看到的文档
+type BankProgressDto struct {
+	BankID    uint `json:"bankID"`
+	Total     int  `json:"total"`
+	Viewed    int  `json:"viewed"`
+	Completed int  `json:"completed"`
+	// Percent 完成的百分比
+	Percent   int                    `json:"percent"`
+	Documents []*DocumentProgressDto `json:"documents"`
+}
+
+// ContinueLearningDto 继续学习的位置
+type ContinueLearningDto struct {
+	BankID     uint   `json:"bankID"`
+	DocumentID uint   `json:"documentID"`
+	Title      string `json:"title"`
+	// Status 该文档的学习状态，没有阅读过时为0
+	Status int `json:"status"`
+	// BankCompleted 知识库中的文档已经全部完成
+	BankCompleted bool `json:"bankCompleted"`
+}
+
+// BankFunnelDto 知识库的学习漏斗，文档按目录顺序排列
+type BankFunnelDto struct {
+	BankID uint `json:"bankID"`
+	// Learners 在知识库中阅读过文档的人数
+	Learners  int64                `json:"learners"`
+	Documents []*DocumentFunnelDto `json:"documents"`
+}
+
+// DocumentFunnelDto 一篇文档的阅读和完成人数，比例相对于知识库的学习人数
+type DocumentFunnelDto struct {
+	DocumentID    uint    `json:"documentID"`
+	ParentID      uint    `json:"parentID"`
+	Title         string  `json:"title"`
+	Viewed        int64   `json:"viewed"`
+	Completed     int64   `json:"completed"`
+	ViewedRate    float64 `json:"viewedRate"`
+	CompletedRate float64 `json:"completedRate"`
+}
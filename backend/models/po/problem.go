@@ -0,0 +1,70 @@
+package po
+
+import "gorm.io/gorm"
+
+// Problem 题目
+type Problem struct {
+	gorm.Model
+	// BankID 所属的题库
+	BankID uint   `gorm:"column:bank_id;index" json:"bankID"`
+	Number string `gorm:"column:number;size:50;index" json:"number"` // 题目编号
+	Name   string `gorm:"column:name" json:"name"`
+	// Description 题目描述，markdown格式
+	Description string `gorm:"column:description;type:longtext" json:"description"`
+	// Difficulty 难度，取值见constants中的题目难度
+	Difficulty int `gorm:"column:difficulty" json:"difficulty"`
+	// TimeLimit 每个用例的时间限制，单位毫秒
+	TimeLimit int64 `gorm:"column:time_limit" json:"timeLimit"`
+	// MemoryLimit 内存限制，单位MB
+	MemoryLimit int64 `gorm:"column:memory_limit" json:"memoryLimit"`
+	CreatorID   uint  `gorm:"column:creator_id" json:"creatorID"`
+	// Checker 判断输出是否正确的方式，取值见constants中的判断方式
+	Checker string `gorm:"column:checker;size:50" json:"checker"`
+	// CheckerEpsilon 使用浮点数比较时允许的误差
+	CheckerEpsilon float64 `gorm:"column:checker_epsilon" json:"checkerEpsilon"`
+	// CheckerLanguage、CheckerCode 特判程序的语言和代码，支持c和cpp，退出码与testlib一致
+	CheckerLanguage string `gorm:"column:checker_language;size:50" json:"checkerLanguage"`
+	CheckerCode     string `gorm:"column:checker_code;type:longtext" json:"checkerCode"`
+	// Interactive 是否是交互题，交互题由交互程序与用户程序交互并给出结果
+	Interactive        bool   `gorm:"column:interactive" json:"interactive"`
+	InteractorLanguage string `gorm:"column:interactor_language;size:50" json:"interactorLanguage"`
+	InteractorCode     string `gorm:"column:interactor_code;type:longtext" json:"interactorCode"`
+	// Enable 是否启用，启用以后用户才能看到并提交
+	Enable bool          `gorm:"column:enable" json:"enable"`
+	Tags   []*ProblemTag `gorm:"many2many:problem_tag_relations;" json:"tags"`
+}
+
+// ProblemCase 题目的测试用例
+type ProblemCase struct {
+	gorm.Model
+	ProblemID uint   `gorm:"column:problem_id;index" json:"problemID"`
+	Name      string `gorm:"column:name" json:"name"`
+	Input     string `gorm:"column:input;type:longtext" json:"input"`
+	Output    string `gorm:"column:output;type:longtext" json:"output"`
+	// Sample 是否是样例，样例会展示给学生，其他用例是隐藏的
+	Sample bool `gorm:"column:sample" json:"sample"`
+}
+
+// ProblemTag 题目标签
+type ProblemTag struct {
+	gorm.Model
+	Name string `gorm:"column:name;size:50;index" json:"name"`
+}
+
+// ProblemTemplate 题目在某个语言下的代码模板
+type ProblemTemplate struct {
+	gorm.Model
+	ProblemID uint   `gorm:"column:problem_id;index" json:"problemID"`
+	Language  string `gorm:"column:language;size:50" json:"language"`
+	Code      string `gorm:"column:code;type:longtext" json:"code"`
+}
+
+// ProblemBank 题库
+type ProblemBank struct {
+	gorm.Model
+	Name        string `gorm:"column:name" json:"name"`
+	Description string `gorm:"column:description;type:text" json:"description"`
+	CreatorID   uint   `gorm:"column:creator_id" json:"creatorID"`
+	// Enable 是否启用
+	Enable bool `gorm:"column:enable" json:"enable"`
+}
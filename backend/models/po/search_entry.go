@@ -0,0 +1,22 @@
+package po
+
+import "time"
+
+// SearchEntry 搜索索引中的一条记录，由文档和用户保存的代码生成
+// 文档只索引用户可以看到的内容，即启用的知识库中启用的文档的已发布版本
+type SearchEntry struct {
+	ID        uint `gorm:"primarykey"`
+	UpdatedAt time.Time
+	// Type 记录的类型，文档、文档代码或用户保存的代码
+	Type string `gorm:"column:type;size:20;uniqueIndex:idx_search_source" json:"type"`
+	// SourceID 文档id或者用户保存的代码id
+	SourceID uint   `gorm:"column:source_id;uniqueIndex:idx_search_source" json:"sourceID"`
+	Language string `gorm:"column:language;size:20;uniqueIndex:idx_search_source" json:"language"`
+	BankID   uint   `gorm:"column:bank_id;index" json:"bankID"`
+	// DocumentID 记录所属的文档，不属于文档的保存代码为0
+	DocumentID uint `gorm:"column:document_id" json:"documentID"`
+	// UserID 用户保存的代码只有自己可以搜索到，公开的内容为0
+	UserID  uint   `gorm:"column:user_id;index" json:"userID"`
+	Title   string `gorm:"column:title;size:255;index:idx_search_text,class:FULLTEXT,option:WITH PARSER ngram" json:"title"`
+	Content string `gorm:"column:content;type:longtext;index:idx_search_text" json:"content"`
+}
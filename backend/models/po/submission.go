@@ -0,0 +1,37 @@
+package po
+
+import "gorm.io/gorm"
+
+// Submission 用户的提交记录
+type Submission struct {
+	gorm.Model
+	UserID    uint   `gorm:"column:user_id;index" json:"userID"`
+	ProblemID uint   `gorm:"column:problem_id;index" json:"problemID"`
+	Language  string `gorm:"column:language;size:50" json:"language"`
+	Code      string `gorm:"column:code;type:longtext" json:"code"`
+	// Status 判题结果，取值见constants中的判题结果
+	Status       int    `gorm:"column:status" json:"status"`
+	ErrorMessage string `gorm:"column:error_message;type:text" json:"errorMessage"`
+	// TimeUsed 所有用例中最长的运行时间，单位毫秒
+	TimeUsed int64 `gorm:"column:time_used" json:"timeUsed"`
+	// MemoryUsed 所有用例中最大的内存使用量，单位KB
+	MemoryUsed  int64 `gorm:"column:memory_used" json:"memoryUsed"`
+	PassedCount int   `gorm:"column:passed_count" json:"passedCount"`
+	CaseCount   int   `gorm:"column:case_count" json:"caseCount"`
+	// Score 得分，所有用例得分的平均值，0到100之间
+	Score float64 `gorm:"column:score" json:"score"`
+}
+
+// SubmissionCase 提交记录中单个测试用例的判题结果
+type SubmissionCase struct {
+	gorm.Model
+	SubmissionID uint   `gorm:"column:submission_id;index" json:"submissionID"`
+	CaseID       uint   `gorm:"column:case_id" json:"caseID"`
+	CaseName     string `gorm:"column:case_name" json:"caseName"`
+	Status       int    `gorm:"column:status" json:"status"`
+	ErrorMessage string `gorm:"column:error_message;type:text" json:"errorMessage"`
+	TimeUsed     int64  `gorm:"column:time_used" json:"timeUsed"`     // 单位毫秒
+	MemoryUsed   int64  `gorm:"column:memory_used" json:"memoryUsed"` // 单位KB
+	// Score 得分，0到100之间
+	Score float64 `gorm:"column:score" json:"score"`
+}
@@ -0,0 +1,17 @@
+package po
+
+import "gorm.io/gorm"
+
+// SysUserOAuth 用户绑定的第三方账号，同一个登录方式下的第三方账号只能绑定一个用户
+type SysUserOAuth struct {
+	gorm.Model
+	UserID   uint   `gorm:"column:user_id;not null;index" json:"userID"`                                    // 绑定的用户ID
+	Provider string `gorm:"column:provider;size:64;not null;uniqueIndex:idx_oauth_subject" json:"provider"` // 登录方式的名称
+	Subject  string `gorm:"column:subject;size:191;not null;uniqueIndex:idx_oauth_subject" json:"subject"`  // 第三方账号的唯一标识
+	Email    string `gorm:"column:email;size:255" json:"email"`                                             // 绑定时第三方账号的邮箱
+}
+
+// TableName 指定表名
+func (SysUserOAuth) TableName() string {
+	return "sys_user_oauths"
+}
@@ -0,0 +1,25 @@
+package po
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SysUserSession 用户的登录会话，每次登录创建一个会话，对应一个登录设备
+type SysUserSession struct {
+	gorm.Model
+	SessionID    string     `gorm:"column:session_id;size:32;not null;uniqueIndex" json:"sessionID"` // token中的会话id
+	UserID       uint       `gorm:"column:user_id;not null;index" json:"userID"`                     // 用户ID
+	RefreshJTI   string     `gorm:"column:refresh_jti;size:32;not null" json:"-"`                    // 当前有效的refresh token的jti，每次刷新后轮换
+	UserAgent    string     `gorm:"column:user_agent;size:512" json:"userAgent"`                     // 登录设备的user agent
+	IP           string     `gorm:"column:ip;size:64" json:"ip"`                                     // 登录时的ip
+	LastActiveAt time.Time  `gorm:"column:last_active_at" json:"lastActiveAt"`                       // 最后一次刷新token的时间
+	ExpireAt     time.Time  `gorm:"column:expire_at" json:"expireAt"`                                // refresh token的过期时间
+	RevokedAt    *time.Time `gorm:"column:revoked_at" json:"revokedAt"`                              // 退出登录的时间，为空时会话有效
+}
+
+// TableName 指定表名
+func (SysUserSession) TableName() string {
+	return "sys_user_sessions"
+}
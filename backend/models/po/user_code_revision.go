@@ -0,0 +1,27 @@
+package po
+
+import "time"
+
+// UserCodeRevision 用户代码的历史版本，每次保存代码时记录一个自动保存版本，用户也可以手动创建命名的检查点
+type UserCodeRevision struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UserID    uint      `gorm:"column:user_id;not null" json:"userID"`
+	// SourceType 代码来源，savedCode或userCode
+	SourceType string `gorm:"column:source_type;size:16;not null;index:idx_code_revision_source" json:"sourceType"`
+	// SourceID 用户保存的代码id或者题目代码的id
+	SourceID uint   `gorm:"column:source_id;not null;index:idx_code_revision_source" json:"sourceID"`
+	Version  uint   `gorm:"column:version;not null" json:"version"`
+	Language string `gorm:"column:language;size:50;not null" json:"language"`
+	Code     string `gorm:"column:code;type:longtext" json:"code"`
+	// Checkpoint 是否是用户创建的检查点
+	Checkpoint bool   `gorm:"column:checkpoint;not null;default:false" json:"checkpoint"`
+	Name       string `gorm:"column:name;size:64" json:"name"` // 检查点名称
+	// RestoredFrom 恢复历史版本时记录被恢复的版本id
+	RestoredFrom uint `gorm:"column:restored_from" json:"restoredFrom"`
+}
+
+// TableName 指定表名
+func (UserCodeRevision) TableName() string {
+	return "user_code_revisions"
+}
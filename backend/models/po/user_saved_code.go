@@ -12,6 +12,13 @@ type UserSavedCode struct {
 	Language   string `gorm:"column:language;not null;size:50" json:"language"` // 编程语言
 	Code       string `gorm:"column:code;type:longtext" json:"code"`            // 代码内容
 	Remark     string `gorm:"column:remark;type:text" json:"remark"`            // 备注
+	Title      string `gorm:"column:title;size:128" json:"title"`               // 标题
+	// FolderID 所在文件夹，为空时在根目录
+	FolderID *uint `gorm:"column:folder_id;index" json:"folderID"`
+	// Starred 是否收藏
+	Starred bool `gorm:"column:starred;not null;default:false" json:"starred"`
+	// VisualDescription 用户指定的可视化描述，json格式，为空时由系统分析
+	VisualDescription string `gorm:"column:visual_description;type:text" json:"visualDescription"`
 }
 
 // TableName 指定表名
@@ -0,0 +1,28 @@
+package po
+
+import "gorm.io/gorm"
+
+// UserSavedCodeFolder 用户创建的用于整理保存代码的文件夹
+type UserSavedCodeFolder struct {
+	gorm.Model
+	UserID uint   `gorm:"column:user_id;not null;index" json:"userID"`
+	Name   string `gorm:"column:name;size:64;not null" json:"name"`
+}
+
+// TableName 指定表名
+func (UserSavedCodeFolder) TableName() string {
+	return "user_saved_code_folders"
+}
+
+// UserSavedCodeTag 用户保存代码的标签
+type UserSavedCodeTag struct {
+	ID          uint   `gorm:"primarykey" json:"id"`
+	UserID      uint   `gorm:"column:user_id;not null;index:idx_user_tag" json:"userID"`
+	SavedCodeID uint   `gorm:"column:saved_code_id;not null;uniqueIndex:idx_saved_code_tag" json:"savedCodeID"`
+	Name        string `gorm:"column:name;size:32;not null;index:idx_user_tag;uniqueIndex:idx_saved_code_tag" json:"name"`
+}
+
+// TableName 指定表名
+func (UserSavedCodeTag) TableName() string {
+	return "user_saved_code_tags"
+}
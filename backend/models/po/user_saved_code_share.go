@@ -0,0 +1,29 @@
+package po
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserSavedCodeShare 用户保存代码的分享，保存分享时代码的快照，之后修改代码不影响分享的内容
+type UserSavedCodeShare struct {
+	gorm.Model
+	ShareID     string `gorm:"column:share_id;size:32;not null;uniqueIndex" json:"shareID"` // 分享链接中的随机id
+	SavedCodeID uint   `gorm:"column:saved_code_id;not null;index" json:"savedCodeID"`      // 被分享的代码ID
+	UserID      uint   `gorm:"column:user_id;not null" json:"userID"`                       // 分享者ID
+	Language    string `gorm:"column:language;not null;size:50" json:"language"`            // 编程语言
+	Code        string `gorm:"column:code;type:longtext" json:"code"`                       // 代码内容
+	Remark      string `gorm:"column:remark;type:text" json:"remark"`                       // 备注
+	// VisualDescription 分享时代码的可视化描述，json格式
+	VisualDescription string `gorm:"column:visual_description;type:text" json:"visualDescription"`
+	// ExpireAt 过期时间，为空时永久有效
+	ExpireAt *time.Time `gorm:"column:expire_at" json:"expireAt"`
+	// ForkCount 被复制到其他用户代码中的次数
+	ForkCount int `gorm:"column:fork_count;not null;default:0" json:"forkCount"`
+}
+
+// TableName 指定表名
+func (UserSavedCodeShare) TableName() string {
+	return "user_saved_code_shares"
+}
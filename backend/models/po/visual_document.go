@@ -15,6 +15,26 @@ type VisualDocument struct {
 	CreatorID uint   `gorm:"column:creator_id" json:"creatorID"`
 	// Enable 是否启用
 	Enable bool `gorm:"column:enable" json:"enable"`
+	// PublishedRevisionID 已发布的版本，用户看到的是该版本的内容
+	// 文档本身的内容是作者正在编辑的草稿，为0时表示还没有发布过版本，用户看到的是文档本身的内容
+	PublishedRevisionID uint `gorm:"column:published_revision_id" json:"publishedRevisionID"`
+	// Version 乐观锁，每次修改草稿时加1，保存时版本不一致说明文档已被其他人修改
+	Version uint `gorm:"column:version;not null;default:0" json:"version"`
+}
+
+// VisualDocumentRevision 可视化文档的一个版本，每次保存文档时生成
+// 标题和目录结构直接生效，不区分版本
+type VisualDocumentRevision struct {
+	gorm.Model
+	DocumentID uint `gorm:"column:document_id;uniqueIndex:idx_document_version" json:"documentID"`
+	// Version 版本号，同一篇文档从1开始递增
+	Version uint   `gorm:"column:version;uniqueIndex:idx_document_version" json:"version"`
+	Content string `gorm:"column:content" json:"content"`
+	// CodeList 文档的代码列表，json格式
+	CodeList string `gorm:"column:code_list" json:"codeList"`
+	// Snippets 文档中的代码示例，json格式
+	Snippets string `gorm:"column:snippets;type:mediumtext" json:"snippets"`
+	AuthorID uint   `gorm:"column:author_id" json:"authorID"`
 }
 
 // VisualDocumentCode 存储可视化文章的代码
@@ -25,4 +45,18 @@ type VisualDocumentCode struct {
 	Language   string `gorm:"column:language" json:"language"`
 	// Breakpoints 初始断点
 	Breakpoints string `gorm:"column:breakpoints" json:"breakpoints"`
+	// VisualDescription 作者指定的可视化描述，json格式，为空时由系统分析
+	VisualDescription string `gorm:"column:visual_description;type:text" json:"visualDescription"`
+}
+
+// VisualDocumentSnippet 文档中可以单独运行和调试的代码示例，在markdown中通过<!-- snippet:key -->引用
+// 从草稿中删除的示例只做软删除，同一个key再次添加时恢复原来的记录，保证已发布版本中的示例id一直有效
+type VisualDocumentSnippet struct {
+	gorm.Model
+	DocumentID uint `gorm:"column:document_id;uniqueIndex:idx_document_snippet" json:"documentID"`
+	// Key 示例在文档中的标识
+	Key   string `gorm:"column:snippet_key;size:64;uniqueIndex:idx_document_snippet" json:"key"`
+	Title string `gorm:"column:title" json:"title"`
+	// CodeList 示例各语言的代码，json格式
+	CodeList string `gorm:"column:code_list;type:mediumtext" json:"codeList"`
 }
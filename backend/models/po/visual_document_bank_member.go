@@ -0,0 +1,14 @@
+package po
+
+import "time"
+
+// VisualDocumentBankMember 知识库的协作者
+type VisualDocumentBankMember struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	BankID    uint `gorm:"column:bank_id;uniqueIndex:idx_bank_user" json:"bankID"`
+	UserID    uint `gorm:"column:user_id;uniqueIndex:idx_bank_user;index" json:"userID"`
+	// Role 协作者的角色，owner、editor或viewer
+	Role string `gorm:"column:role" json:"role"`
+}
@@ -0,0 +1,52 @@
+package po
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VisualDocumentExercise 文档中的练习
+type VisualDocumentExercise struct {
+	gorm.Model
+	DocumentID uint `gorm:"column:document_id;index" json:"documentID"`
+	// Type 练习的类型，选择题、预测输出、变量追踪或者补全代码
+	Type     string `gorm:"column:type;size:16" json:"type"`
+	Title    string `gorm:"column:title" json:"title"`
+	Question string `gorm:"column:question;type:mediumtext" json:"question"`
+	// Options 选择题的选项，json格式
+	Options string `gorm:"column:options;type:text" json:"options"`
+	// Answer 正确答案，选择题为正确选项下标的json，预测输出为程序的输出，变量追踪为调试器读取的变量值
+	Answer string `gorm:"column:answer;type:mediumtext" json:"answer"`
+	// Language 练习代码的语言
+	Language string `gorm:"column:language" json:"language"`
+	// Code 练习展示的代码，补全代码时为代码模板
+	Code  string `gorm:"column:code;type:mediumtext" json:"code"`
+	Stdin string `gorm:"column:stdin;type:text" json:"stdin"`
+	// Breakpoints 变量追踪的断点，json格式
+	Breakpoints string `gorm:"column:breakpoints" json:"breakpoints"`
+	// Step 变量追踪时读取程序第几次暂停时的变量
+	Step     int    `gorm:"column:step" json:"step"`
+	Variable string `gorm:"column:variable" json:"variable"`
+	// Cases 补全代码的测试用例，json格式
+	Cases       string `gorm:"column:cases;type:mediumtext" json:"cases"`
+	Explanation string `gorm:"column:explanation;type:text" json:"explanation"`
+	Order       uint   `gorm:"column:order" json:"order"`
+}
+
+// VisualDocumentExerciseResult 用户在一道练习上的作答结果，只保留最后一次作答
+type VisualDocumentExerciseResult struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uint `gorm:"column:user_id;uniqueIndex:idx_user_exercise;index:idx_user_document" json:"userID"`
+	ExerciseID uint `gorm:"column:exercise_id;uniqueIndex:idx_user_exercise;index" json:"exerciseID"`
+	DocumentID uint `gorm:"column:document_id;index:idx_user_document" json:"documentID"`
+	// Answer 最后一次作答的内容
+	Answer  string `gorm:"column:answer;type:mediumtext" json:"answer"`
+	Correct bool   `gorm:"column:correct" json:"correct"`
+	// Passed 是否曾经答对过
+	Passed   bool   `gorm:"column:passed" json:"passed"`
+	Attempts int    `gorm:"column:attempts" json:"attempts"`
+	Message  string `gorm:"column:message;type:text" json:"message"`
+}
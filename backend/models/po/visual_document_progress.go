@@ -0,0 +1,18 @@
+package po
+
+import "time"
+
+// VisualDocumentProgress 用户在一篇文档上的学习进度
+type VisualDocumentProgress struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uint `gorm:"column:user_id;uniqueIndex:idx_user_document;index:idx_user_viewed" json:"userID"`
+	DocumentID uint `gorm:"column:document_id;uniqueIndex:idx_user_document;index" json:"documentID"`
+	BankID     uint `gorm:"column:bank_id;index" json:"bankID"`
+	// Status 学习状态，已阅读或已完成
+	Status int `gorm:"column:status" json:"status"`
+	// LastViewedAt 最后一次阅读的时间，用于继续学习
+	LastViewedAt time.Time  `gorm:"column:last_viewed_at;index:idx_user_viewed" json:"lastViewedAt"`
+	CompletedAt  *time.Time `gorm:"column:completed_at" json:"completedAt"`
+}
@@ -0,0 +1,18 @@
+package admin
+
+import (
+	"github.com/fansqz/fancode-backend/controller/admin"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupProblemBankRoutes(r *gin.Engine, p admin.ProblemBankManageController) {
+	// 题库相关路由
+	bank := r.Group("/manage/problem/bank")
+	{
+		bank.POST("", p.InsertProblemBank)
+		bank.PUT("", p.UpdateProblemBank)
+		bank.DELETE("/:id", p.DeleteProblemBank)
+		bank.GET("/all", p.GetAllProblemBank)
+		bank.GET("/:id", p.GetProblemBankByID)
+	}
+}
@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"github.com/fansqz/fancode-backend/controller/admin"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupProblemRoutes(r *gin.Engine, p admin.ProblemManageController) {
+	// 题目相关路由
+	problem := r.Group("/manage/problem")
+	{
+		problem.POST("", p.InsertProblem)
+		problem.PUT("", p.UpdateProblem)
+		problem.DELETE("/:id", p.DeleteProblem)
+		problem.GET("/list", p.GetProblemList)
+		problem.GET("/tag/all", p.GetAllProblemTag)
+		problem.GET("/:id", p.GetProblemByID)
+	}
+	// 测试用例相关路由
+	problemCase := r.Group("/manage/problem/case")
+	{
+		problemCase.POST("", p.InsertProblemCase)
+		problemCase.PUT("", p.UpdateProblemCase)
+		problemCase.DELETE("/:id", p.DeleteProblemCase)
+		problemCase.GET("/list", p.GetProblemCaseList)
+		problemCase.POST("/upload/:problemID", p.UploadProblemCases)
+	}
+}
@@ -0,0 +1,14 @@
+package admin
+
+import (
+	"github.com/fansqz/fancode-backend/controller/admin"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSearchRoutes(r *gin.Engine, s admin.SearchManageController) {
+	// 搜索索引管理相关路由
+	search := r.Group("/manage/search")
+	{
+		search.POST("/rebuild", s.RebuildIndex)
+	}
+}
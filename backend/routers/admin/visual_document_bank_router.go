@@ -14,5 +14,11 @@ func SetupVisualDocumentBankRoutes(r *gin.Engine, v admin.VisualDocumentBankMana
 		document.DELETE("/:id", v.DeleteVisualDocumentBank)
 		document.GET("/all", v.GetAllVisualDocumentBank)
 		document.GET("/:id", v.GetVisualDocumentBankByID)
+		document.GET("/:id/export", v.ExportVisualDocumentBank)
+		document.POST("/import", v.ImportVisualDocumentBank)
+		// 协作者管理
+		document.GET("/:id/members", v.GetVisualDocumentBankMembers)
+		document.PUT("/:id/member", v.SaveVisualDocumentBankMember)
+		document.DELETE("/:id/member/:userID", v.DeleteVisualDocumentBankMember)
 	}
 }
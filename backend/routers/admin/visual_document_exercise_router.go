@@ -0,0 +1,18 @@
+package admin
+
+import (
+	"github.com/fansqz/fancode-backend/controller/admin"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupVisualDocumentExerciseRoutes(r *gin.Engine, controller admin.VisualDocumentExerciseManageController) {
+	// 文档练习管理相关路由
+	exercise := r.Group("/manage/visual/exercise")
+	{
+		exercise.GET("/document/:documentID", controller.GetExerciseList)
+		exercise.POST("", controller.InsertExercise)
+		exercise.PUT("", controller.UpdateExercise)
+		exercise.DELETE("/:id", controller.DeleteExercise)
+		exercise.GET("/:id/results", controller.GetExerciseResults)
+	}
+}
@@ -0,0 +1,14 @@
+package admin
+
+import (
+	"github.com/fansqz/fancode-backend/controller/admin"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupVisualDocumentProgressRoutes(r *gin.Engine, controller admin.VisualDocumentProgressManageController) {
+	// 学习进度统计相关路由
+	progress := r.Group("/manage/visual/progress")
+	{
+		progress.GET("/bank/:bankID/funnel", controller.GetBankFunnel)
+	}
+}
@@ -15,5 +15,11 @@ func SetupVisualDocumentRoutes(r *gin.Engine, visualDocumentController admin.Vis
 		document.POST("", visualDocumentController.InsertVisualDocument)
 		document.PUT("", visualDocumentController.UpdateVisualDocument)
 		document.DELETE("/:id", visualDocumentController.DeleteVisualDocumentByID)
+		// 版本管理
+		document.GET("/:id/revisions", visualDocumentController.GetVisualDocumentRevisionList)
+		document.POST("/:id/publish", visualDocumentController.PublishVisualDocument)
+		document.GET("/revision/diff", visualDocumentController.DiffVisualDocumentRevision)
+		document.GET("/revision/:revisionID", visualDocumentController.GetVisualDocumentRevision)
+		document.POST("/revision/:revisionID/restore", visualDocumentController.RestoreVisualDocumentRevision)
 	}
 }
@@ -5,12 +5,25 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupAuthRoutes(r *gin.Engine, authController controller.AuthController) {
+func SetupAuthRoutes(r *gin.Engine, authController controller.AuthController, oauthController controller.OAuthController) {
 	//用户相关
 	auth := r.Group("/auth")
 	{
 		auth.POST("/login", authController.Login)
 		auth.POST("/register", authController.UserRegister)
 		auth.POST("/code/send", authController.SendAuthCode)
+		auth.POST("/token/refresh", authController.RefreshToken)
+		auth.POST("/logout", authController.Logout)
+		auth.POST("/logout/all", authController.LogoutAll)
+		auth.GET("/sessions", authController.GetSessionList)
+		auth.DELETE("/sessions/:sessionID", authController.RevokeSession)
+	}
+	// 第三方登录
+	oauth := r.Group("/auth/oauth")
+	{
+		oauth.GET("/providers", oauthController.GetProviderList)
+		oauth.GET("/bindings", oauthController.GetUserOAuthList)
+		oauth.GET("/:provider/authorize", oauthController.GetAuthorizeURL)
+		oauth.POST("/:provider/login", oauthController.Login)
 	}
 }
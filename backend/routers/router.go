@@ -20,6 +20,7 @@ import (
 //	@Description: 启动路由
 func SetupRouter(
 	authController c.AuthController,
+	oauthController c.OAuthController,
 	accountController c.AccountController,
 	commonController c.CommonController,
 	userSavedCodeHandler *user.UserSavedCodeHandler,
@@ -30,9 +31,23 @@ func SetupRouter(
 	visualDocumentManageController admin.VisualDocumentManageController,
 	visualDocumentBankManageController admin.VisualDocumentBankManageController,
 	debugController user.DebugController,
+	debugAssistController user.DebugAssistController,
 	visualController user.VisualController,
 	visualDocumentController user.VisualDocumentController,
 	visualDocumentBankController user.VisualDocumentBankController,
+	problemManageController admin.ProblemManageController,
+	problemController user.ProblemController,
+	problemBankManageController admin.ProblemBankManageController,
+	problemBankController user.ProblemBankController,
+	judgeController user.JudgeController,
+	searchController user.SearchController,
+	searchManageController admin.SearchManageController,
+	visualDocumentProgressController user.VisualDocumentProgressController,
+	visualDocumentProgressManageController admin.VisualDocumentProgressManageController,
+	visualDocumentExerciseController user.VisualDocumentExerciseController,
+	visualDocumentExerciseManageController admin.VisualDocumentExerciseManageController,
+	userCodeController user.UserCodeController,
+	codeRevisionController user.CodeRevisionController,
 	config *conf.AppConfig,
 	panicInterceptor *interceptor.RecoverPanicInterceptor,
 	corsInterceptor *interceptor.CorsInterceptor,
@@ -62,7 +77,7 @@ func SetupRouter(
 	//ping
 	r.GET("/ping", c.Ping)
 
-	SetupAuthRoutes(r, authController)
+	SetupAuthRoutes(r, authController, oauthController)
 	SetupAccountRoutes(r, accountController)
 	SetupCommonRoutes(r, commonController)
 	userRouter.SetupUserSavedCodeRoutes(r, userSavedCodeHandler)
@@ -73,8 +88,21 @@ func SetupRouter(
 	adminRouter.SetupVisualDocumentRoutes(r, visualDocumentManageController)
 	adminRouter.SetupVisualDocumentBankRoutes(r, visualDocumentBankManageController)
 	userRouter.SetupDebugRoutes(r, debugController)
+	userRouter.SetupDebugAssistRoutes(r, debugAssistController)
 	userRouter.SetupVisualRoutes(r, visualController)
 	userRouter.SetupVisualDocumentRoutes(r, visualDocumentController)
 	userRouter.SetupVisualDocumentBankRoutes(r, visualDocumentBankController)
+	adminRouter.SetupProblemRoutes(r, problemManageController)
+	userRouter.SetupProblemRoutes(r, problemController)
+	adminRouter.SetupProblemBankRoutes(r, problemBankManageController)
+	userRouter.SetupProblemBankRoutes(r, problemBankController)
+	userRouter.SetupJudgeRoutes(r, judgeController)
+	userRouter.SetupSearchRoutes(r, searchController)
+	adminRouter.SetupSearchRoutes(r, searchManageController)
+	userRouter.SetupVisualDocumentProgressRoutes(r, visualDocumentProgressController)
+	adminRouter.SetupVisualDocumentProgressRoutes(r, visualDocumentProgressManageController)
+	userRouter.SetupVisualDocumentExerciseRoutes(r, visualDocumentExerciseController)
+	adminRouter.SetupVisualDocumentExerciseRoutes(r, visualDocumentExerciseManageController)
+	userRouter.SetupUserCodeRoutes(r, userCodeController, codeRevisionController)
 	return r
 }
@@ -0,0 +1,16 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/user"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupDebugAssistRoutes(r *gin.Engine, debugAssistController user.DebugAssistController) {
+	// AI调试助手相关
+	assist := r.Group("/debug/assist")
+	{
+		assist.GET("/explain/:id", debugAssistController.ExplainStep)
+		assist.GET("/diagnose/:id", debugAssistController.Diagnose)
+		assist.GET("/compile/:id", debugAssistController.ExplainCompileError)
+	}
+}
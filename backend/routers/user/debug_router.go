@@ -12,6 +12,8 @@ func SetupDebugRoutes(r *gin.Engine, debugController user.DebugController) {
 		judge.POST("/session/create", debugController.CreateDebugSession)
 		judge.GET("/sse/:id", debugController.CreateSseConnect)
 		judge.POST("/start", debugController.Start)
+		judge.POST("/start/snippet", debugController.StartSnippet)
+		judge.POST("/start/share", debugController.StartShare)
 		judge.POST("/step/in", debugController.StepIn)
 		judge.POST("/step/out", debugController.StepOut)
 		judge.POST("/step/over", debugController.StepOver)
@@ -0,0 +1,21 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/user"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupJudgeRoutes(r *gin.Engine, j user.JudgeController) {
+	// 判题相关路由
+	judge := r.Group("/judge")
+	{
+		// 提交代码
+		judge.POST("/submit", j.Submit)
+		// 使用自定义输入运行代码
+		judge.POST("/run", j.Run)
+		// 提交记录列表
+		judge.GET("/submission/list", j.GetSubmissionList)
+		// 提交记录详情
+		judge.GET("/submission/:id", j.GetSubmission)
+	}
+}
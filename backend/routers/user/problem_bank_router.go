@@ -0,0 +1,15 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/user"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupProblemBankRoutes(r *gin.Engine, p user.ProblemBankController) {
+	// 题库相关路由
+	bank := r.Group("/problem/bank")
+	{
+		bank.GET("/all", p.GetAllProblemBank)
+		bank.GET("/:id", p.GetProblemBankByID)
+	}
+}
@@ -0,0 +1,17 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/user"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupProblemRoutes(r *gin.Engine, p user.ProblemController) {
+	// 题目相关路由
+	problem := r.Group("/problem")
+	{
+		problem.GET("/list", p.GetProblemList)
+		problem.GET("/template", p.GetProblemTemplate)
+		problem.GET("/tag/all", p.GetAllProblemTag)
+		problem.GET("/:id", p.GetProblem)
+	}
+}
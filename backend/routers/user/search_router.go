@@ -0,0 +1,11 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/user"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSearchRoutes(r *gin.Engine, s user.SearchController) {
+	// 搜索相关路由
+	r.GET("/search", s.Search)
+}
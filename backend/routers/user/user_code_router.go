@@ -0,0 +1,26 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/user"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupUserCodeRoutes(r *gin.Engine, userCodeController user.UserCodeController, revisionController user.CodeRevisionController) {
+	// 用户在题目中保存的代码
+	code := r.Group("/user/code")
+	{
+		// 读取代码，language通过query传入
+		code.GET("/:problemID", userCodeController.GetUserCode)
+		code.PUT("", userCodeController.SaveUserCode)
+	}
+	// 用户保存的代码和题目代码的历史版本
+	revision := r.Group("/user/code/revision")
+	{
+		revision.GET("/list", revisionController.GetRevisionList)
+		revision.GET("/diff", revisionController.DiffRevision)
+		revision.GET("/:id", revisionController.GetRevision)
+		revision.POST("/checkpoint", revisionController.CreateCheckpoint)
+		revision.DELETE("/checkpoint/:id", revisionController.DeleteCheckpoint)
+		revision.POST("/:id/restore", revisionController.RestoreRevision)
+	}
+}
@@ -14,11 +14,42 @@ func SetupUserSavedCodeRoutes(r *gin.Engine, handler *c.UserSavedCodeHandler) {
 		savedCodeGroup.POST("", handler.CreateUserSavedCode)
 		// 更新用户保存的代码
 		savedCodeGroup.PUT("", handler.UpdateUserSavedCode)
+		// 更新用户保存代码的可视化描述
+		savedCodeGroup.PUT("visualDescription", handler.UpdateVisualDescription)
 		// 获取用户保存的代码列表
 		savedCodeGroup.GET("list", handler.GetUserSavedCodeList)
 		// 根据ID获取用户保存的代码详情
 		savedCodeGroup.GET("/:id", handler.GetUserSavedCodeByID)
 		// 删除用户保存的代码
 		savedCodeGroup.DELETE("/:id", handler.DeleteUserSavedCode)
+		// 分享用户保存的代码
+		savedCodeGroup.POST("share", handler.CreateShare)
+		// 获取代码的所有分享
+		savedCodeGroup.GET("/:id/shares", handler.GetShareList)
+		// 取消分享
+		savedCodeGroup.DELETE("share/:shareID", handler.DeleteShare)
+		// 修改代码的标题、文件夹和标签
+		savedCodeGroup.PUT("meta", handler.UpdateMeta)
+		// 收藏或取消收藏代码
+		savedCodeGroup.PUT("star", handler.StarSavedCode)
+		// 批量移动代码到文件夹
+		savedCodeGroup.POST("batch/move", handler.BatchMove)
+		// 批量删除代码
+		savedCodeGroup.POST("batch/delete", handler.BatchDelete)
+		// 文件夹
+		savedCodeGroup.GET("folder/list", handler.GetFolderList)
+		savedCodeGroup.POST("folder", handler.SaveFolder)
+		savedCodeGroup.DELETE("folder/:id", handler.DeleteFolder)
+		// 获取用户使用过的所有标签
+		savedCodeGroup.GET("tag/list", handler.GetTagList)
+	}
+
+	// 分享链接相关路由，查看分享的代码不需要登录
+	shareGroup := r.Group("/share/code")
+	{
+		// 查看分享的代码
+		shareGroup.GET("/:shareID", handler.GetSharedCode)
+		// 复制分享的代码到自己保存的代码中
+		shareGroup.POST("/:shareID/fork", handler.ForkSharedCode)
 	}
 }
@@ -0,0 +1,15 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/user"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupVisualDocumentExerciseRoutes(r *gin.Engine, controller user.VisualDocumentExerciseController) {
+	// 文档练习相关路由
+	exercise := r.Group("/learn/visual/exercise")
+	{
+		exercise.GET("/document/:documentID", controller.GetDocumentExercises)
+		exercise.POST("/:id/submit", controller.SubmitExercise)
+	}
+}
@@ -0,0 +1,16 @@
+package user
+
+import (
+	"github.com/fansqz/fancode-backend/controller/user"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupVisualDocumentProgressRoutes(r *gin.Engine, controller user.VisualDocumentProgressController) {
+	// 学习进度相关路由
+	progress := r.Group("/learn/visual/progress")
+	{
+		progress.POST("/document/:documentID/complete", controller.CompleteDocument)
+		progress.GET("/bank/:bankID", controller.GetBankProgress)
+		progress.GET("/continue", controller.GetContinueLearning)
+	}
+}
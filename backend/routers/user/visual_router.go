@@ -14,5 +14,6 @@ func SetupVisualRoutes(r *gin.Engine, visualController user.VisualController) {
 		visual.POST("/debug/array", visualController.ArrayVisual)
 		visual.POST("/debug/array2d", visualController.Array2DVisual)
 		visual.GET("/debug/description/:id", visualController.GetVisualDescription)
+		visual.PUT("/debug/description", visualController.UpdateVisualDescription)
 	}
 }
@@ -0,0 +1,286 @@
+package code_revision_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/service/search_service"
+	"github.com/fansqz/fancode-backend/utils"
+	"github.com/pmezard/go-difflib/difflib"
+	"gorm.io/gorm"
+)
+
+// CodeRevisionService 用户保存的代码和题目代码的历史版本
+type CodeRevisionService interface {
+	// GetRevisionList 获取代码的所有历史版本
+	GetRevisionList(ctx context.Context, query *dto.CodeRevisionQuery) ([]*dto.CodeRevisionDto, error)
+	// GetRevision 获取历史版本的详情
+	GetRevision(ctx context.Context, id uint) (*dto.CodeRevisionDetailDto, error)
+	// DiffRevision 比较同一份代码的两个历史版本
+	DiffRevision(ctx context.Context, fromID uint, toID uint) (*dto.CodeRevisionDiffDto, error)
+	// CreateCheckpoint 将代码当前的内容保存为命名的检查点
+	CreateCheckpoint(ctx context.Context, req *dto.CreateCodeCheckpointRequest) (*dto.CodeRevisionDto, error)
+	// DeleteCheckpoint 删除检查点，自动保存的版本不能删除
+	DeleteCheckpoint(ctx context.Context, id uint) error
+	// RestoreRevision 将代码恢复为历史版本的内容，恢复后会记录一个新的版本
+	RestoreRevision(ctx context.Context, id uint) (*dto.CodeRevisionDto, error)
+}
+
+type codeRevisionService struct {
+	revisionDao   dao.UserCodeRevisionDao
+	savedCodeDao  dao.UserSavedCodeDao
+	userCodeDao   dao.UserCodeDao
+	searchService search_service.SearchService
+}
+
+func NewCodeRevisionService(revisionDao dao.UserCodeRevisionDao, savedCodeDao dao.UserSavedCodeDao, userCodeDao dao.UserCodeDao,
+	searchService search_service.SearchService) CodeRevisionService {
+	return &codeRevisionService{
+		revisionDao:   revisionDao,
+		savedCodeDao:  savedCodeDao,
+		userCodeDao:   userCodeDao,
+		searchService: searchService,
+	}
+}
+
+func (c *codeRevisionService) GetRevisionList(ctx context.Context, query *dto.CodeRevisionQuery) ([]*dto.CodeRevisionDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	revisions, err := c.revisionDao.GetRevisionList(common.Mysql, query.SourceType, query.SourceID, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetRevisionList] GetRevisionList fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.CodeRevisionDto, 0, len(revisions))
+	for _, revision := range revisions {
+		answer = append(answer, dto.NewCodeRevisionDto(revision))
+	}
+	return answer, nil
+}
+
+func (c *codeRevisionService) GetRevision(ctx context.Context, id uint) (*dto.CodeRevisionDetailDto, error) {
+	revision, err := c.getRevision(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.CodeRevisionDetailDto{
+		CodeRevisionDto: *dto.NewCodeRevisionDto(revision),
+		Code:            revision.Code,
+	}, nil
+}
+
+func (c *codeRevisionService) DiffRevision(ctx context.Context, fromID uint, toID uint) (*dto.CodeRevisionDiffDto, error) {
+	from, err := c.getRevision(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := c.getRevision(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	if from.SourceType != to.SourceType || from.SourceID != to.SourceID {
+		return nil, e.NewParamErr("只能比较同一份代码的历史版本")
+	}
+	return diffRevision(from, to), nil
+}
+
+func (c *codeRevisionService) CreateCheckpoint(ctx context.Context, req *dto.CreateCodeCheckpointRequest) (*dto.CodeRevisionDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	language, code, err := c.getSource(ctx, req.SourceType, req.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	count, err := c.revisionDao.CountCheckpoint(common.Mysql, req.SourceType, req.SourceID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateCheckpoint] CountCheckpoint fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	if count >= constants.MaxCodeCheckpoints {
+		return nil, e.ErrCheckpointLimitExceeded
+	}
+	revision := &po.UserCodeRevision{
+		UserID:     userID,
+		SourceType: req.SourceType,
+		SourceID:   req.SourceID,
+		Language:   language,
+		Code:       code,
+		Checkpoint: true,
+		Name:       req.Name,
+	}
+	if err = SaveCodeRevision(common.Mysql, c.revisionDao, revision); err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateCheckpoint] SaveCodeRevision fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return dto.NewCodeRevisionDto(revision), nil
+}
+
+func (c *codeRevisionService) DeleteCheckpoint(ctx context.Context, id uint) error {
+	revision, err := c.getRevision(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revision.Checkpoint {
+		return e.NewParamErr("自动保存的版本不能删除")
+	}
+	if err = c.revisionDao.DeleteRevisionByID(common.Mysql, id); err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteCheckpoint] DeleteRevisionByID fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (c *codeRevisionService) RestoreRevision(ctx context.Context, id uint) (*dto.CodeRevisionDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	revision, err := c.getRevision(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	language, code, err := c.getSource(ctx, revision.SourceType, revision.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	restored := &po.UserCodeRevision{
+		UserID:       userID,
+		SourceType:   revision.SourceType,
+		SourceID:     revision.SourceID,
+		Language:     revision.Language,
+		Code:         revision.Code,
+		RestoredFrom: revision.ID,
+	}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		// 功能上线之前保存的代码没有历史版本，先记录当前内容，保证恢复以后还能找回
+		current := &po.UserCodeRevision{
+			UserID:     userID,
+			SourceType: revision.SourceType,
+			SourceID:   revision.SourceID,
+			Language:   language,
+			Code:       code,
+		}
+		if err := SaveCodeRevision(tx, c.revisionDao, current); err != nil {
+			return err
+		}
+		if err := c.writeSource(tx, revision); err != nil {
+			return err
+		}
+		return SaveCodeRevision(tx, c.revisionDao, restored)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[RestoreRevision] restore fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	if revision.SourceType == constants.CodeRevisionSourceSavedCode {
+		if savedCode, err := c.savedCodeDao.GetUserSavedCodeByID(common.Mysql, revision.SourceID, userID); err == nil {
+			c.searchService.IndexSavedCode(ctx, savedCode)
+		}
+	}
+	return dto.NewCodeRevisionDto(restored), nil
+}
+
+func (c *codeRevisionService) getRevision(ctx context.Context, id uint) (*po.UserCodeRevision, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	revision, err := c.revisionDao.GetRevisionByID(common.Mysql, id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("revision not found")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getRevision] GetRevisionByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return revision, nil
+}
+
+// getSource 读取用户代码当前的语言和内容
+func (c *codeRevisionService) getSource(ctx context.Context, sourceType string, sourceID uint) (string, string, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	var err error
+	var language, code string
+	switch sourceType {
+	case constants.CodeRevisionSourceSavedCode:
+		var savedCode *po.UserSavedCode
+		if savedCode, err = c.savedCodeDao.GetUserSavedCodeByID(common.Mysql, sourceID, userID); err == nil {
+			language, code = savedCode.Language, savedCode.Code
+		}
+	case constants.CodeRevisionSourceUserCode:
+		var userCode *po.UserCode
+		if userCode, err = c.userCodeDao.GetUserCodeByID(common.Mysql, sourceID, userID); err == nil {
+			language, code = userCode.Language, userCode.Code
+		}
+	default:
+		return "", "", e.NewParamErr(fmt.Sprintf("不支持的代码来源: %s", sourceType))
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", e.NewRecordNotFoundErr("code not found")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getSource] get code fail, err = %v", err)
+		return "", "", e.ErrMysql
+	}
+	return language, code, nil
+}
+
+// writeSource 将历史版本的内容写回用户代码
+func (c *codeRevisionService) writeSource(tx *gorm.DB, revision *po.UserCodeRevision) error {
+	if revision.SourceType == constants.CodeRevisionSourceUserCode {
+		return c.userCodeDao.UpdateUserCode(tx, &po.UserCode{
+			Model: gorm.Model{ID: revision.SourceID},
+			Code:  revision.Code,
+		})
+	}
+	savedCode, err := c.savedCodeDao.GetUserSavedCodeByID(tx, revision.SourceID, revision.UserID)
+	if err != nil {
+		return err
+	}
+	savedCode.Language = revision.Language
+	savedCode.Code = revision.Code
+	// 可视化描述为空时不修改
+	savedCode.VisualDescription = ""
+	return c.savedCodeDao.UpdateUserSavedCode(tx, savedCode)
+}
+
+// SaveCodeRevision 记录代码的历史版本，保存代码的地方都需要调用
+// 自动保存的内容和最新版本相同时不记录，超出保留数量时删除最旧的自动保存版本
+func SaveCodeRevision(tx *gorm.DB, revisionDao dao.UserCodeRevisionDao, revision *po.UserCodeRevision) error {
+	var version uint = 1
+	latest, err := revisionDao.GetLatestRevision(tx, revision.SourceType, revision.SourceID)
+	if err == nil {
+		if !revision.Checkpoint && latest.Code == revision.Code && latest.Language == revision.Language {
+			*revision = *latest
+			return nil
+		}
+		version = latest.Version + 1
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	revision.Version = version
+	if err = revisionDao.InsertRevision(tx, revision); err != nil {
+		return err
+	}
+	if revision.Checkpoint {
+		return nil
+	}
+	return revisionDao.DeleteOldAutosaveRevisions(tx, revision.SourceType, revision.SourceID, constants.MaxCodeAutosaveRevisions)
+}
+
+func diffRevision(from *po.UserCodeRevision, to *po.UserCodeRevision) *dto.CodeRevisionDiffDto {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from.Code),
+		B:        difflib.SplitLines(to.Code),
+		FromFile: fmt.Sprintf("v%d", from.Version),
+		ToFile:   fmt.Sprintf("v%d", to.Version),
+		Context:  3,
+	})
+	return &dto.CodeRevisionDiffDto{
+		FromVersion:  from.Version,
+		ToVersion:    to.Version,
+		FromLanguage: from.Language,
+		ToLanguage:   to.Language,
+		Code:         diff,
+	}
+}
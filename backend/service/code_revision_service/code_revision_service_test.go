@@ -0,0 +1,163 @@
+package code_revision_service
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// memoryRevisionDao 保存在内存中的历史版本，用于测试版本的记录和保留规则
+type memoryRevisionDao struct {
+	revisions []*po.UserCodeRevision
+}
+
+func (m *memoryRevisionDao) InsertRevision(db *gorm.DB, revision *po.UserCodeRevision) error {
+	revision.ID = uint(len(m.revisions) + 1)
+	m.revisions = append(m.revisions, revision)
+	return nil
+}
+
+func (m *memoryRevisionDao) GetRevisionByID(db *gorm.DB, id uint, userID uint) (*po.UserCodeRevision, error) {
+	for _, revision := range m.revisions {
+		if revision.ID == id && revision.UserID == userID {
+			return revision, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryRevisionDao) GetLatestRevision(db *gorm.DB, sourceType string, sourceID uint) (*po.UserCodeRevision, error) {
+	list := m.list(sourceType, sourceID)
+	if len(list) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	latest := *list[0]
+	return &latest, nil
+}
+
+func (m *memoryRevisionDao) GetRevisionList(db *gorm.DB, sourceType string, sourceID uint, userID uint) ([]*po.UserCodeRevision, error) {
+	return m.list(sourceType, sourceID), nil
+}
+
+func (m *memoryRevisionDao) CountCheckpoint(db *gorm.DB, sourceType string, sourceID uint) (int64, error) {
+	var count int64
+	for _, revision := range m.list(sourceType, sourceID) {
+		if revision.Checkpoint {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memoryRevisionDao) DeleteOldAutosaveRevisions(db *gorm.DB, sourceType string, sourceID uint, keep int) error {
+	kept := 0
+	deleted := map[uint]bool{}
+	for _, revision := range m.list(sourceType, sourceID) {
+		if revision.Checkpoint {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		deleted[revision.ID] = true
+	}
+	m.remove(func(revision *po.UserCodeRevision) bool { return deleted[revision.ID] })
+	return nil
+}
+
+func (m *memoryRevisionDao) DeleteRevisionByID(db *gorm.DB, id uint) error {
+	m.remove(func(revision *po.UserCodeRevision) bool { return revision.ID == id })
+	return nil
+}
+
+func (m *memoryRevisionDao) DeleteRevisionBySource(db *gorm.DB, sourceType string, sourceID uint) error {
+	m.remove(func(revision *po.UserCodeRevision) bool {
+		return revision.SourceType == sourceType && revision.SourceID == sourceID
+	})
+	return nil
+}
+
+func (m *memoryRevisionDao) list(sourceType string, sourceID uint) []*po.UserCodeRevision {
+	var answer []*po.UserCodeRevision
+	for _, revision := range m.revisions {
+		if revision.SourceType == sourceType && revision.SourceID == sourceID {
+			answer = append(answer, revision)
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool { return answer[i].Version > answer[j].Version })
+	return answer
+}
+
+func (m *memoryRevisionDao) remove(match func(revision *po.UserCodeRevision) bool) {
+	revisions := m.revisions[:0]
+	for _, revision := range m.revisions {
+		if !match(revision) {
+			revisions = append(revisions, revision)
+		}
+	}
+	m.revisions = revisions
+}
+
+func newRevisionForTest(code string, checkpoint bool) *po.UserCodeRevision {
+	return &po.UserCodeRevision{
+		UserID:     1,
+		SourceType: constants.CodeRevisionSourceSavedCode,
+		SourceID:   1,
+		Language:   "c",
+		Code:       code,
+		Checkpoint: checkpoint,
+	}
+}
+
+func TestSaveCodeRevision(t *testing.T) {
+	revisionDao := &memoryRevisionDao{}
+	first := newRevisionForTest("int a;", false)
+	assert.Nil(t, SaveCodeRevision(nil, revisionDao, first))
+	assert.Equal(t, uint(1), first.Version)
+
+	// 内容没有变化时不记录新的版本
+	same := newRevisionForTest("int a;", false)
+	assert.Nil(t, SaveCodeRevision(nil, revisionDao, same))
+	assert.Equal(t, first.ID, same.ID)
+	assert.Len(t, revisionDao.revisions, 1)
+
+	// 检查点即使内容相同也会记录
+	checkpoint := newRevisionForTest("int a;", true)
+	assert.Nil(t, SaveCodeRevision(nil, revisionDao, checkpoint))
+	assert.Equal(t, uint(2), checkpoint.Version)
+
+	// 切换语言也是一个新的版本
+	other := newRevisionForTest("int a;", false)
+	other.Language = "cpp"
+	assert.Nil(t, SaveCodeRevision(nil, revisionDao, other))
+	assert.Equal(t, uint(3), other.Version)
+}
+
+func TestSaveCodeRevision_Retention(t *testing.T) {
+	revisionDao := &memoryRevisionDao{}
+	assert.Nil(t, SaveCodeRevision(nil, revisionDao, newRevisionForTest("checkpoint", true)))
+	for i := 0; i < constants.MaxCodeAutosaveRevisions+10; i++ {
+		assert.Nil(t, SaveCodeRevision(nil, revisionDao, newRevisionForTest(fmt.Sprintf("int a = %d;", i), false)))
+	}
+	// 超出保留数量时只删除最旧的自动保存版本，检查点不会被删除
+	revisions := revisionDao.list(constants.CodeRevisionSourceSavedCode, 1)
+	assert.Len(t, revisions, constants.MaxCodeAutosaveRevisions+1)
+	assert.Equal(t, uint(constants.MaxCodeAutosaveRevisions+11), revisions[0].Version)
+	assert.Equal(t, "checkpoint", revisions[len(revisions)-1].Code)
+	assert.Equal(t, uint(12), revisions[len(revisions)-2].Version)
+}
+
+func TestDiffRevision(t *testing.T) {
+	from := &po.UserCodeRevision{Version: 1, Language: "c", Code: "int a;\nint b;\n"}
+	to := &po.UserCodeRevision{Version: 2, Language: "c", Code: "int a;\nint c;\n"}
+	diff := diffRevision(from, to)
+	assert.Contains(t, diff.Code, "-int b;")
+	assert.Contains(t, diff.Code, "+int c;")
+	assert.Equal(t, "", diffRevision(from, from).Code)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/fansqz/fancode-backend/common/logger"
 	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/models/po"
 	"github.com/fansqz/fancode-backend/utils"
 	"gorm.io/gorm"
@@ -26,9 +27,9 @@ const (
 type AuthService interface {
 
 	// PasswordLogin 密码登录 account可能是邮箱可能是用户id
-	PasswordLogin(ctx context.Context, account string, password string) (string, error)
+	PasswordLogin(ctx context.Context, account string, password string, device *dto.LoginDeviceDto) (*dto.TokenDto, error)
 	// EmailLogin 邮箱验证登录
-	EmailLogin(ctx context.Context, email string, code string) (string, error)
+	EmailLogin(ctx context.Context, email string, code string, device *dto.LoginDeviceDto) (*dto.TokenDto, error)
 	// SendAuthCode 获取邮件的验证码
 	SendAuthCode(ctx context.Context, email string, kind string) error
 	// UserRegister 用户注册
@@ -40,18 +41,22 @@ type authService struct {
 	sysUserDao dao.SysUserDao
 	sysMenuDao dao.SysMenuDao
 	sysRoleDao dao.SysRoleDao
+
+	sessionService SessionService
 }
 
-func NewAuthService(config *conf.AppConfig, userDao dao.SysUserDao, menuDao dao.SysMenuDao, roleDao dao.SysRoleDao) AuthService {
+func NewAuthService(config *conf.AppConfig, userDao dao.SysUserDao, menuDao dao.SysMenuDao, roleDao dao.SysRoleDao,
+	sessionService SessionService) AuthService {
 	return &authService{
-		config:     config,
-		sysUserDao: userDao,
-		sysMenuDao: menuDao,
-		sysRoleDao: roleDao,
+		config:         config,
+		sysUserDao:     userDao,
+		sysMenuDao:     menuDao,
+		sysRoleDao:     roleDao,
+		sessionService: sessionService,
 	}
 }
 
-func (u *authService) PasswordLogin(ctx context.Context, account string, password string) (string, error) {
+func (u *authService) PasswordLogin(ctx context.Context, account string, password string, device *dto.LoginDeviceDto) (*dto.TokenDto, error) {
 	var user *po.SysUser
 	var err error
 	if verifyEmailFormat(account) {
@@ -62,56 +67,42 @@ func (u *authService) PasswordLogin(ctx context.Context, account string, passwor
 	}
 	if user == nil || err == gorm.ErrRecordNotFound {
 		logger.WithCtx(ctx).Infof("[PasswordLogin] user not exist")
-		return "", e.ErrUserNotExist
+		return nil, e.ErrUserNotExist
 	}
 	if err != nil {
 		logger.WithCtx(ctx).Errorf("[PasswordLogin] get user error, err = %v", err)
-		return "", e.ErrUserUnknownError
+		return nil, e.ErrUserUnknownError
 	}
 	// 比较密码
 	if !utils.ComparePwd(user.Password, password) {
-		return "", e.ErrUserNameOrPasswordWrong
-	}
-	token, err := utils.GenerateToken(utils.Claims{
-		ID: user.ID,
-	})
-	if err != nil {
-		logger.WithCtx(ctx).Errorf("[PasswordLogin] generate token error, err = %v", err)
-		return "", e.ErrUnknown
+		return nil, e.ErrUserNameOrPasswordWrong
 	}
-	return token, nil
+	return u.sessionService.CreateSession(ctx, user.ID, device)
 }
 
-func (u *authService) EmailLogin(ctx context.Context, email string, code string) (string, error) {
+func (u *authService) EmailLogin(ctx context.Context, email string, code string, device *dto.LoginDeviceDto) (*dto.TokenDto, error) {
 	email = strings.ToLower(email)
 	// 获取用户
 	user, err := u.sysUserDao.GetUserByEmail(common.Mysql, email)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		logger.WithCtx(ctx).Infof("[EmailLogin] user not exist")
-		return "", e.ErrEmailNotRegister
+		return nil, e.ErrEmailNotRegister
 	}
 	if err != nil {
 		logger.WithCtx(ctx).Errorf("[EmailLogin] get user error, err = %v", err)
-		return "", e.ErrUnknown
+		return nil, e.ErrUnknown
 	}
 	// 检测验证码
 	key := LoginEmailProKey + email
 	result, err := common.Redis.Get(key).Result()
 	if err != nil {
 		logger.WithCtx(ctx).Errorf("[EmailLogin] get code from redis fail, err = %v", err)
-		return "", e.ErrUnknown
+		return nil, e.ErrUnknown
 	}
 	if result != code {
-		return "", e.ErrLoginCodeWrong
-	}
-	token, err := utils.GenerateToken(utils.Claims{
-		ID: user.ID,
-	})
-	if err != nil {
-		logger.WithCtx(ctx).Errorf("[EmailLogin] generate token error, err = %v", err)
-		return "", e.ErrUserUnknownError
+		return nil, e.ErrLoginCodeWrong
 	}
-	return token, nil
+	return u.sessionService.CreateSession(ctx, user.ID, device)
 }
 
 // verifyEmailFormat email verify
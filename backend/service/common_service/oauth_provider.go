@@ -0,0 +1,295 @@
+package common_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	conf "github.com/fansqz/fancode-backend/common/config"
+)
+
+// oauthHTTPTimeout 请求第三方接口的超时时间
+const oauthHTTPTimeout = 10 * time.Second
+
+// oauthUserInfo 第三方账号的信息
+type oauthUserInfo struct {
+	Subject       string // 第三方账号的唯一标识
+	Email         string
+	EmailVerified bool // 只有验证过的邮箱才能用来关联已有用户
+	Username      string
+	Avatar        string
+}
+
+// oauthProvider 第三方登录方式，使用授权码模式获取用户信息
+type oauthProvider interface {
+	// AuthCodeURL 获取跳转到第三方授权页面的地址
+	AuthCodeURL(ctx context.Context, state string, codeVerifier string) (string, error)
+	// Exchange 使用授权码获取第三方账号的信息
+	Exchange(ctx context.Context, code string, codeVerifier string) (*oauthUserInfo, error)
+}
+
+// newOAuthProvider 根据配置创建登录方式
+func newOAuthProvider(config *conf.OAuthProviderConfig, client *http.Client) oauthProvider {
+	switch config.Type {
+	case conf.OAuthProviderGithub:
+		return &githubProvider{config: config, client: client}
+	case conf.OAuthProviderOIDC:
+		return &oidcProvider{config: config, client: client}
+	}
+	return nil
+}
+
+// githubProvider github登录，github不支持oidc，通过api获取用户信息和验证过的邮箱
+type githubProvider struct {
+	config *conf.OAuthProviderConfig
+	client *http.Client
+}
+
+func (g *githubProvider) AuthCodeURL(ctx context.Context, state string, codeVerifier string) (string, error) {
+	return buildAuthCodeURL(g.config, g.config.AuthURL, state, codeVerifier)
+}
+
+func (g *githubProvider) Exchange(ctx context.Context, code string, codeVerifier string) (*oauthUserInfo, error) {
+	accessToken, err := exchangeAccessToken(ctx, g.client, g.config, g.config.TokenURL, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err = getOAuthJSON(ctx, g.client, g.config.UserInfoURL, accessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("github user id is empty")
+	}
+	info := &oauthUserInfo{
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Username: user.Name,
+		Avatar:   user.AvatarURL,
+	}
+	if info.Username == "" {
+		info.Username = user.Login
+	}
+	// 用户信息中的邮箱不一定验证过，需要从邮箱列表中获取验证过的主邮箱
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err = getOAuthJSON(ctx, g.client, g.config.EmailURL, accessToken, &emails); err != nil {
+		return nil, err
+	}
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			info.Email = email.Email
+			info.EmailVerified = true
+			break
+		}
+	}
+	return info, nil
+}
+
+// oidcProvider 通用的oidc登录，没有配置端点时通过issuer自动发现
+type oidcProvider struct {
+	config *conf.OAuthProviderConfig
+	client *http.Client
+
+	mutex     sync.Mutex
+	discovery *oidcDiscovery
+}
+
+// oidcDiscovery oidc自动发现的配置
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+func (o *oidcProvider) AuthCodeURL(ctx context.Context, state string, codeVerifier string) (string, error) {
+	endpoints, err := o.getEndpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+	return buildAuthCodeURL(o.config, endpoints.AuthorizationEndpoint, state, codeVerifier)
+}
+
+func (o *oidcProvider) Exchange(ctx context.Context, code string, codeVerifier string) (*oauthUserInfo, error) {
+	endpoints, err := o.getEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := exchangeAccessToken(ctx, o.client, o.config, endpoints.TokenEndpoint, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	var claims struct {
+		Subject           string      `json:"sub"`
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"`
+		Name              string      `json:"name"`
+		PreferredUsername string      `json:"preferred_username"`
+		Picture           string      `json:"picture"`
+	}
+	if err = getOAuthJSON(ctx, o.client, endpoints.UserInfoEndpoint, accessToken, &claims); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("userinfo sub is empty")
+	}
+	info := &oauthUserInfo{
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		Username: claims.Name,
+		Avatar:   claims.Picture,
+	}
+	if info.Username == "" {
+		info.Username = claims.PreferredUsername
+	}
+	// 部分身份提供者返回的email_verified是字符串
+	switch verified := claims.EmailVerified.(type) {
+	case bool:
+		info.EmailVerified = verified
+	case string:
+		info.EmailVerified = verified == "true"
+	}
+	return info, nil
+}
+
+// getEndpoints 获取oidc的各个端点，配置文件中的端点优先，其他的通过issuer自动发现并缓存
+func (o *oidcProvider) getEndpoints(ctx context.Context) (*oidcDiscovery, error) {
+	configured := &oidcDiscovery{
+		Issuer:                o.config.Issuer,
+		AuthorizationEndpoint: o.config.AuthURL,
+		TokenEndpoint:         o.config.TokenURL,
+		UserInfoEndpoint:      o.config.UserInfoURL,
+	}
+	if configured.AuthorizationEndpoint != "" && configured.TokenEndpoint != "" && configured.UserInfoEndpoint != "" {
+		return configured, nil
+	}
+	if o.config.Issuer == "" {
+		return nil, errors.New("oidc issuer is empty")
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.discovery == nil {
+		var discovery oidcDiscovery
+		discoveryURL := strings.TrimSuffix(o.config.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := getOAuthJSON(ctx, o.client, discoveryURL, "", &discovery); err != nil {
+			return nil, err
+		}
+		if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(o.config.Issuer, "/") {
+			return nil, fmt.Errorf("oidc issuer mismatch, expect %s, got %s", o.config.Issuer, discovery.Issuer)
+		}
+		o.discovery = &discovery
+	}
+	setOAuthEndpoint(&configured.AuthorizationEndpoint, o.discovery.AuthorizationEndpoint)
+	setOAuthEndpoint(&configured.TokenEndpoint, o.discovery.TokenEndpoint)
+	setOAuthEndpoint(&configured.UserInfoEndpoint, o.discovery.UserInfoEndpoint)
+	if configured.AuthorizationEndpoint == "" || configured.TokenEndpoint == "" || configured.UserInfoEndpoint == "" {
+		return nil, errors.New("oidc discovery endpoints incomplete")
+	}
+	return configured, nil
+}
+
+func setOAuthEndpoint(endpoint *string, discovered string) {
+	if *endpoint == "" {
+		*endpoint = discovered
+	}
+}
+
+// buildAuthCodeURL 生成授权地址，使用pkce防止授权码被截获后使用
+func buildAuthCodeURL(config *conf.OAuthProviderConfig, authURL string, state string, codeVerifier string) (string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", config.ClientID)
+	query.Set("redirect_uri", config.RedirectURL)
+	query.Set("scope", config.Scopes)
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// exchangeAccessToken 使用授权码获取access token
+func exchangeAccessToken(ctx context.Context, client *http.Client, config *conf.OAuthProviderConfig,
+	tokenURL string, code string, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", config.RedirectURL)
+	form.Set("client_id", config.ClientID)
+	form.Set("client_secret", config.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = doOAuthRequest(client, req, &token); err != nil {
+		return "", err
+	}
+	// github授权码错误时返回200，错误信息在响应中
+	if token.Error != "" {
+		return "", fmt.Errorf("exchange token fail, %s: %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("access token is empty")
+	}
+	return token.AccessToken, nil
+}
+
+// getOAuthJSON 请求第三方接口并解析json，accessToken不为空时携带token
+func getOAuthJSON(ctx context.Context, client *http.Client, rawURL string, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doOAuthRequest(client, req, v)
+}
+
+func doOAuthRequest(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request %s fail, status = %d, body = %s", req.URL.Path, resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,168 @@
+package common_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	conf "github.com/fansqz/fancode-backend/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	mockClientID     = "fancode"
+	mockClientSecret = "secret"
+	mockCode         = "auth-code"
+	mockAccessToken  = "access-token"
+	mockVerifier     = "code-verifier"
+)
+
+// newMockOAuthServer 本地的oidc和github模拟服务器，校验授权码、客户端密钥和pkce
+func newMockOAuthServer(t *testing.T, emailVerified interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"userinfo_endpoint":      server.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != mockCode || r.PostForm.Get("client_secret") != mockClientSecret ||
+			r.PostForm.Get("code_verifier") != mockVerifier {
+			writeJSON(w, map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		writeJSON(w, map[string]string{"access_token": mockAccessToken, "token_type": "bearer"})
+	})
+	checkToken := func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Authorization") != "Bearer "+mockAccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if checkToken(w, r) {
+			writeJSON(w, map[string]interface{}{
+				"sub":                "s2024001",
+				"email":              "Student@School.edu",
+				"email_verified":     emailVerified,
+				"preferred_username": "student",
+			})
+		}
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if checkToken(w, r) {
+			writeJSON(w, map[string]interface{}{"id": 12345, "login": "octocat", "avatar_url": "https://avatar"})
+		}
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		if checkToken(w, r) {
+			writeJSON(w, []map[string]interface{}{
+				{"email": "unverified@example.com", "primary": false, "verified": false},
+				{"email": "octocat@example.com", "primary": true, "verified": true},
+			})
+		}
+	})
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOIDCProvider(t *testing.T) {
+	server := newMockOAuthServer(t, "true")
+	provider := newOAuthProvider(&conf.OAuthProviderConfig{
+		Name:         "school",
+		Type:         conf.OAuthProviderOIDC,
+		ClientID:     mockClientID,
+		ClientSecret: mockClientSecret,
+		RedirectURL:  "http://localhost/oauth/callback",
+		Issuer:       server.URL,
+		Scopes:       "openid email profile",
+	}, server.Client())
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", mockVerifier)
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", u.Path)
+	assert.Equal(t, mockClientID, u.Query().Get("client_id"))
+	assert.Equal(t, "state", u.Query().Get("state"))
+	challenge := sha256.Sum256([]byte(mockVerifier))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(challenge[:]), u.Query().Get("code_challenge"))
+
+	info, err := provider.Exchange(context.Background(), mockCode, mockVerifier)
+	require.NoError(t, err)
+	assert.Equal(t, "s2024001", info.Subject)
+	assert.Equal(t, "Student@School.edu", info.Email)
+	assert.True(t, info.EmailVerified)
+	assert.Equal(t, "student", info.Username)
+
+	_, err = provider.Exchange(context.Background(), "wrong-code", mockVerifier)
+	assert.Error(t, err)
+	_, err = provider.Exchange(context.Background(), mockCode, "wrong-verifier")
+	assert.Error(t, err)
+}
+
+func TestOIDCProvider_EmailNotVerified(t *testing.T) {
+	server := newMockOAuthServer(t, false)
+	provider := newOAuthProvider(&conf.OAuthProviderConfig{
+		Name:         "school",
+		Type:         conf.OAuthProviderOIDC,
+		ClientID:     mockClientID,
+		ClientSecret: mockClientSecret,
+		Issuer:       server.URL,
+	}, server.Client())
+	info, err := provider.Exchange(context.Background(), mockCode, mockVerifier)
+	require.NoError(t, err)
+	assert.False(t, info.EmailVerified)
+}
+
+func TestOIDCProvider_IssuerMismatch(t *testing.T) {
+	server := newMockOAuthServer(t, true)
+	provider := newOAuthProvider(&conf.OAuthProviderConfig{
+		Name:     "school",
+		Type:     conf.OAuthProviderOIDC,
+		ClientID: mockClientID,
+		Issuer:   server.URL + "/other",
+	}, server.Client())
+	_, err := provider.AuthCodeURL(context.Background(), "state", mockVerifier)
+	assert.Error(t, err)
+}
+
+func TestGithubProvider(t *testing.T) {
+	server := newMockOAuthServer(t, true)
+	provider := newOAuthProvider(&conf.OAuthProviderConfig{
+		Name:         "github",
+		Type:         conf.OAuthProviderGithub,
+		ClientID:     mockClientID,
+		ClientSecret: mockClientSecret,
+		AuthURL:      server.URL + "/login/oauth/authorize",
+		TokenURL:     server.URL + "/token",
+		UserInfoURL:  server.URL + "/user",
+		EmailURL:     server.URL + "/user/emails",
+	}, server.Client())
+	info, err := provider.Exchange(context.Background(), mockCode, mockVerifier)
+	require.NoError(t, err)
+	assert.Equal(t, "12345", info.Subject)
+	assert.Equal(t, "octocat", info.Username)
+	assert.Equal(t, "octocat@example.com", info.Email)
+	assert.True(t, info.EmailVerified)
+
+	// github授权码错误时返回200，需要识别响应中的错误
+	_, err = provider.Exchange(context.Background(), "wrong-code", mockVerifier)
+	assert.Error(t, err)
+}
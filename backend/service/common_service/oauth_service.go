@@ -0,0 +1,281 @@
+package common_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common"
+	conf "github.com/fansqz/fancode-backend/common/config"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+)
+
+const (
+	// OAuthStateProKey 第三方登录的state，保存登录方式和pkce的code verifier
+	OAuthStateProKey = "oauth-state-"
+	// OAuthStateExpire 从跳转授权页面到回调的最长时间
+	OAuthStateExpire = 10 * time.Minute
+)
+
+// takeStateScript 读取并删除state，并发的回调只有一个能读到
+var takeStateScript = redis.NewScript(`
+local value = redis.call('GET', KEYS[1])
+if value then
+	redis.call('DEL', KEYS[1])
+end
+return value
+`)
+
+type OAuthService interface {
+	// GetProviderList 获取可用的第三方登录方式
+	GetProviderList(ctx context.Context) []*dto.OAuthProviderDto
+	// GetAuthorizeURL 获取跳转到第三方授权页面的地址和state，state需要保存在浏览器中，回调时与参数中的state比较
+	GetAuthorizeURL(ctx context.Context, provider string) (string, string, error)
+	// Login 第三方授权后使用授权码登录，返回token
+	// 已绑定的账号直接登录，否则通过验证过的邮箱关联已有用户，都没有时创建新用户
+	Login(ctx context.Context, provider string, req *dto.OAuthLoginRequest, device *dto.LoginDeviceDto) (*dto.TokenDto, error)
+	// GetUserOAuthList 获取当前用户绑定的第三方账号
+	GetUserOAuthList(ctx context.Context) ([]*dto.UserOAuthDto, error)
+}
+
+type oauthService struct {
+	config          *conf.AppConfig
+	sysUserDao      dao.SysUserDao
+	sysUserOAuthDao dao.SysUserOAuthDao
+	sessionService  SessionService
+	providers       map[string]oauthProvider
+}
+
+// oauthState 保存在redis中的登录状态
+type oauthState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"codeVerifier"`
+}
+
+func NewOAuthService(config *conf.AppConfig, userDao dao.SysUserDao, userOAuthDao dao.SysUserOAuthDao,
+	sessionService SessionService) OAuthService {
+	client := &http.Client{Timeout: oauthHTTPTimeout}
+	providers := make(map[string]oauthProvider)
+	if config.OAuthConfig != nil {
+		for _, providerConfig := range config.OAuthConfig.Providers {
+			providers[providerConfig.Name] = newOAuthProvider(providerConfig, client)
+		}
+	}
+	return &oauthService{
+		config:          config,
+		sysUserDao:      userDao,
+		sysUserOAuthDao: userOAuthDao,
+		sessionService:  sessionService,
+		providers:       providers,
+	}
+}
+
+func (o *oauthService) GetProviderList(ctx context.Context) []*dto.OAuthProviderDto {
+	answer := []*dto.OAuthProviderDto{}
+	if o.config.OAuthConfig == nil {
+		return answer
+	}
+	for _, provider := range o.config.OAuthConfig.Providers {
+		answer = append(answer, &dto.OAuthProviderDto{
+			Name:        provider.Name,
+			Type:        provider.Type,
+			DisplayName: provider.DisplayName,
+		})
+	}
+	return answer
+}
+
+func (o *oauthService) GetAuthorizeURL(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := o.providers[providerName]
+	if !ok {
+		return "", "", e.ErrOAuthProviderNotExist
+	}
+	state, err := utils.GetSecureRandomHex(16)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetAuthorizeURL] generate state fail, err = %v", err)
+		return "", "", e.ErrUnknown
+	}
+	codeVerifier, err := utils.GetSecureRandomHex(32)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetAuthorizeURL] generate code verifier fail, err = %v", err)
+		return "", "", e.ErrUnknown
+	}
+	authURL, err := provider.AuthCodeURL(ctx, state, codeVerifier)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetAuthorizeURL] get auth code url fail, provider = %s, err = %v", providerName, err)
+		return "", "", e.ErrOAuthLoginFailed
+	}
+	data, _ := json.Marshal(&oauthState{Provider: providerName, CodeVerifier: codeVerifier})
+	if err = common.Redis.Set(OAuthStateProKey+state, string(data), OAuthStateExpire).Err(); err != nil {
+		logger.WithCtx(ctx).Errorf("[GetAuthorizeURL] set redis error, err = %v", err)
+		return "", "", e.ErrRedis
+	}
+	return authURL, state, nil
+}
+
+func (o *oauthService) Login(ctx context.Context, providerName string, req *dto.OAuthLoginRequest,
+	device *dto.LoginDeviceDto) (*dto.TokenDto, error) {
+	provider, ok := o.providers[providerName]
+	if !ok {
+		return nil, e.ErrOAuthProviderNotExist
+	}
+	// state只能使用一次，读取和删除需要是原子的
+	data, err := takeStateScript.Run(common.Redis, []string{OAuthStateProKey + req.State}).String()
+	if err == redis.Nil {
+		return nil, e.ErrOAuthStateInvalid
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[OAuthLogin] take state from redis fail, err = %v", err)
+		return nil, e.ErrRedis
+	}
+	var state oauthState
+	if err = json.Unmarshal([]byte(data), &state); err != nil || state.Provider != providerName {
+		return nil, e.ErrOAuthStateInvalid
+	}
+	info, err := provider.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		logger.WithCtx(ctx).Warnf("[OAuthLogin] exchange fail, provider = %s, err = %v", providerName, err)
+		return nil, e.ErrOAuthLoginFailed
+	}
+	userID, err := o.getOrCreateUser(ctx, o.getProviderConfig(providerName), info)
+	if err != nil {
+		return nil, err
+	}
+	return o.sessionService.CreateSession(ctx, userID, device)
+}
+
+func (o *oauthService) GetUserOAuthList(ctx context.Context) ([]*dto.UserOAuthDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if userID == 0 {
+		return nil, e.ErrSessionExpire
+	}
+	list, err := o.sysUserOAuthDao.GetUserOAuthListByUserID(common.Mysql, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetUserOAuthList] get user oauth list fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.UserOAuthDto, 0, len(list))
+	for _, userOAuth := range list {
+		answer = append(answer, dto.NewUserOAuthDto(userOAuth))
+	}
+	return answer, nil
+}
+
+// getOrCreateUser 获取第三方账号对应的用户
+// 已绑定时返回绑定的用户，邮箱验证过且已注册时绑定到该用户，否则创建新用户并绑定
+func (o *oauthService) getOrCreateUser(ctx context.Context, providerConfig *conf.OAuthProviderConfig, info *oauthUserInfo) (uint, error) {
+	var userID uint
+	err := common.Mysql.Transaction(func(tx *gorm.DB) error {
+		userOAuth, err := o.sysUserOAuthDao.GetUserOAuth(tx, providerConfig.Name, info.Subject)
+		if err == nil {
+			userID = userOAuth.UserID
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		email := ""
+		if info.EmailVerified && verifyEmailFormat(info.Email) {
+			email = strings.ToLower(info.Email)
+		}
+		var user *po.SysUser
+		if email != "" {
+			user, err = o.sysUserDao.GetUserByEmail(tx, email)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		if user == nil {
+			if user, err = o.createUser(tx, providerConfig, info, email); err != nil {
+				return err
+			}
+		}
+		userID = user.ID
+		return o.sysUserOAuthDao.InsertUserOAuth(tx, &po.SysUserOAuth{
+			UserID:   user.ID,
+			Provider: providerConfig.Name,
+			Subject:  info.Subject,
+			Email:    email,
+		})
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[OAuthLogin] get or create user fail, provider = %s, err = %v", providerConfig.Name, err)
+		return 0, e.ErrMysql
+	}
+	return userID, nil
+}
+
+// createUser 第一次登录时创建用户，密码随机生成，用户可以通过邮箱验证码登录后修改
+func (o *oauthService) createUser(tx *gorm.DB, providerConfig *conf.OAuthProviderConfig, info *oauthUserInfo, email string) (*po.SysUser, error) {
+	randomPassword, err := utils.GetSecureRandomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	password, err := utils.GetPwd(randomPassword)
+	if err != nil {
+		return nil, err
+	}
+	loginName, err := o.newLoginName(tx)
+	if err != nil {
+		return nil, err
+	}
+	user := &po.SysUser{
+		Avatar:    info.Avatar,
+		Username:  info.Username,
+		LoginName: loginName,
+		Password:  string(password),
+		Email:     email,
+		BirthDay:  time.Now(),
+		Sex:       1,
+	}
+	if user.Username == "" {
+		user.Username = "fancoder"
+	}
+	if err = o.sysUserDao.InsertUser(tx, user); err != nil {
+		return nil, err
+	}
+	roleID := providerConfig.DefaultRoleID
+	if roleID == 0 {
+		roleID = constants.UserID
+	}
+	if err = o.sysUserDao.InsertRolesToUser(tx, user.ID, []uint{roleID}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// newLoginName 生成唯一的登录名
+func (o *oauthService) newLoginName(tx *gorm.DB) (string, error) {
+	loginName := utils.GetRandomNumber(11) + utils.GetRandomNumber(3)
+	for i := 0; i < 5; i++ {
+		exist, err := o.sysUserDao.CheckLoginName(tx, loginName)
+		if err != nil {
+			return "", err
+		}
+		if !exist {
+			break
+		}
+		loginName = loginName + utils.GetRandomNumber(1)
+	}
+	return loginName, nil
+}
+
+func (o *oauthService) getProviderConfig(providerName string) *conf.OAuthProviderConfig {
+	for _, provider := range o.config.OAuthConfig.Providers {
+		if provider.Name == providerName {
+			return provider
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package common_service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common"
+	conf "github.com/fansqz/fancode-backend/common/config"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+// RevokedSessionProKey 已退出的会话，会话中还没过期的access token都会被拒绝
+// 过期时间与access token的有效时间相同，之后会话中不会再有有效的access token
+const RevokedSessionProKey = "session-revoked-"
+
+type SessionService interface {
+	// CreateSession 用户登录时创建会话，返回access token和refresh token
+	CreateSession(ctx context.Context, userID uint, device *dto.LoginDeviceDto) (*dto.TokenDto, error)
+	// RefreshToken 使用refresh token获取新的token，旧的refresh token失效
+	// 已经失效的refresh token再次使用时认为token被盗用，会话直接退出
+	RefreshToken(ctx context.Context, refreshToken string) (*dto.TokenDto, error)
+	// Logout 退出当前会话
+	Logout(ctx context.Context) error
+	// LogoutAll 退出所有设备
+	LogoutAll(ctx context.Context) error
+	// GetSessionList 获取当前用户已登录的设备
+	GetSessionList(ctx context.Context) ([]*dto.UserSessionDto, error)
+	// RevokeSession 退出某个设备
+	RevokeSession(ctx context.Context, sessionID string) error
+	// IsSessionRevoked 检查会话是否已经退出
+	IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+type sessionService struct {
+	config     *conf.AppConfig
+	sessionDao dao.SysUserSessionDao
+}
+
+func NewSessionService(config *conf.AppConfig, sessionDao dao.SysUserSessionDao) SessionService {
+	return &sessionService{
+		config:     config,
+		sessionDao: sessionDao,
+	}
+}
+
+func (s *sessionService) CreateSession(ctx context.Context, userID uint, device *dto.LoginDeviceDto) (*dto.TokenDto, error) {
+	sessionID, err := utils.GetSecureRandomHex(16)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateSession] generate session id fail, err = %v", err)
+		return nil, e.ErrUnknown
+	}
+	refreshJTI, err := utils.GetSecureRandomHex(16)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateSession] generate jti fail, err = %v", err)
+		return nil, e.ErrUnknown
+	}
+	now := time.Now()
+	session := &po.SysUserSession{
+		SessionID:    sessionID,
+		UserID:       userID,
+		RefreshJTI:   refreshJTI,
+		LastActiveAt: now,
+		ExpireAt:     now.Add(s.refreshTokenTTL()),
+	}
+	if device != nil {
+		session.UserAgent = truncateString(device.UserAgent, 512)
+		session.IP = truncateString(device.IP, 64)
+	}
+	if err = s.sessionDao.InsertSession(common.Mysql, session); err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateSession] insert session fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	token, err := s.generateTokens(userID, sessionID, refreshJTI)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateSession] generate token fail, err = %v", err)
+		return nil, e.ErrUnknown
+	}
+	return token, nil
+}
+
+func (s *sessionService) RefreshToken(ctx context.Context, refreshToken string) (*dto.TokenDto, error) {
+	claims, err := utils.ParseToken(refreshToken)
+	if err != nil || claims.TokenType != utils.TokenTypeRefresh {
+		return nil, e.ErrRefreshTokenInvalid
+	}
+	session, err := s.sessionDao.GetSessionBySessionID(common.Mysql, claims.SessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[RefreshToken] get session fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || session.UserID != claims.ID || !session.ExpireAt.After(now) {
+		return nil, e.ErrRefreshTokenInvalid
+	}
+	// refresh token已经被使用过，可能被盗用，退出整个会话
+	if session.RefreshJTI != claims.Id {
+		logger.WithCtx(ctx).Warnf("[RefreshToken] refresh token reused, userID = %d, sessionID = %s", session.UserID, session.SessionID)
+		if err = s.revokeSessions(ctx, session.UserID, []string{session.SessionID}); err != nil {
+			return nil, err
+		}
+		return nil, e.ErrRefreshTokenInvalid
+	}
+	refreshJTI, err := utils.GetSecureRandomHex(16)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[RefreshToken] generate jti fail, err = %v", err)
+		return nil, e.ErrUnknown
+	}
+	rows, err := s.sessionDao.RotateRefreshJTI(common.Mysql, session.ID, claims.Id, refreshJTI, now, now.Add(s.refreshTokenTTL()))
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[RefreshToken] rotate refresh token fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	// 并发刷新时只有一个请求成功
+	if rows == 0 {
+		return nil, e.ErrRefreshTokenInvalid
+	}
+	token, err := s.generateTokens(session.UserID, session.SessionID, refreshJTI)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[RefreshToken] generate token fail, err = %v", err)
+		return nil, e.ErrUnknown
+	}
+	return token, nil
+}
+
+func (s *sessionService) Logout(ctx context.Context) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	sessionID := utils.GetSessionIDWithCtx(ctx)
+	if userID == 0 || sessionID == "" {
+		return e.ErrSessionExpire
+	}
+	return s.revokeSessions(ctx, userID, []string{sessionID})
+}
+
+func (s *sessionService) LogoutAll(ctx context.Context) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if userID == 0 {
+		return e.ErrSessionExpire
+	}
+	sessions, err := s.sessionDao.GetActiveSessionList(common.Mysql, userID, time.Now())
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[LogoutAll] get session list fail, err = %v", err)
+		return e.ErrMysql
+	}
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.SessionID)
+	}
+	if err = s.revokeSessions(ctx, userID, sessionIDs); err != nil {
+		return err
+	}
+	// 过期的会话也一起标记为退出
+	if err = s.sessionDao.RevokeSessionsByUserID(common.Mysql, userID, time.Now()); err != nil {
+		logger.WithCtx(ctx).Errorf("[LogoutAll] revoke sessions fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (s *sessionService) GetSessionList(ctx context.Context) ([]*dto.UserSessionDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if userID == 0 {
+		return nil, e.ErrSessionExpire
+	}
+	sessions, err := s.sessionDao.GetActiveSessionList(common.Mysql, userID, time.Now())
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetSessionList] get session list fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	currentSessionID := utils.GetSessionIDWithCtx(ctx)
+	answer := make([]*dto.UserSessionDto, 0, len(sessions))
+	for _, session := range sessions {
+		answer = append(answer, dto.NewUserSessionDto(session, currentSessionID))
+	}
+	return answer, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, sessionID string) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if userID == 0 {
+		return e.ErrSessionExpire
+	}
+	rows, err := s.sessionDao.RevokeSession(common.Mysql, sessionID, userID, time.Now())
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[RevokeSession] revoke session fail, err = %v", err)
+		return e.ErrMysql
+	}
+	if rows == 0 {
+		return e.ErrSessionNotExist
+	}
+	return s.addRevokedSessions(ctx, []string{sessionID})
+}
+
+func (s *sessionService) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	count, err := common.Redis.Exists(RevokedSessionProKey + sessionID).Result()
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[IsSessionRevoked] check redis fail, err = %v", err)
+		return false, e.ErrRedis
+	}
+	return count > 0, nil
+}
+
+// revokeSessions 退出用户的会话，并加入redis中的退出列表
+func (s *sessionService) revokeSessions(ctx context.Context, userID uint, sessionIDs []string) error {
+	now := time.Now()
+	for _, sessionID := range sessionIDs {
+		if _, err := s.sessionDao.RevokeSession(common.Mysql, sessionID, userID, now); err != nil {
+			logger.WithCtx(ctx).Errorf("[revokeSessions] revoke session fail, err = %v", err)
+			return e.ErrMysql
+		}
+	}
+	return s.addRevokedSessions(ctx, sessionIDs)
+}
+
+// addRevokedSessions 会话加入退出列表，会话中的access token立即失效
+func (s *sessionService) addRevokedSessions(ctx context.Context, sessionIDs []string) error {
+	for _, sessionID := range sessionIDs {
+		if err := common.Redis.Set(RevokedSessionProKey+sessionID, "1", s.accessTokenTTL()).Err(); err != nil {
+			logger.WithCtx(ctx).Errorf("[addRevokedSessions] set redis fail, err = %v", err)
+			return e.ErrRedis
+		}
+	}
+	return nil
+}
+
+// generateTokens 生成会话的access token和refresh token
+func (s *sessionService) generateTokens(userID uint, sessionID string, refreshJTI string) (*dto.TokenDto, error) {
+	accessToken, err := utils.GenerateToken(utils.Claims{
+		ID:        userID,
+		SessionID: sessionID,
+		TokenType: utils.TokenTypeAccess,
+	}, s.accessTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+	claims := utils.Claims{
+		ID:        userID,
+		SessionID: sessionID,
+		TokenType: utils.TokenTypeRefresh,
+	}
+	claims.Id = refreshJTI
+	refreshToken, err := utils.GenerateToken(claims, s.refreshTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TokenDto{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTokenTTL().Seconds()),
+	}, nil
+}
+
+func (s *sessionService) accessTokenTTL() time.Duration {
+	if s.config.JWTConfig == nil || s.config.JWTConfig.AccessTokenTTL <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(s.config.JWTConfig.AccessTokenTTL) * time.Second
+}
+
+func (s *sessionService) refreshTokenTTL() time.Duration {
+	if s.config.JWTConfig == nil || s.config.JWTConfig.RefreshTokenTTL <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(s.config.JWTConfig.RefreshTokenTTL) * time.Second
+}
+
+// truncateString 截断过长的字符串，避免超过数据库字段长度
+func truncateString(str string, length int) string {
+	runes := []rune(str)
+	if len(runes) <= length {
+		return str
+	}
+	return string(runes[:length])
+}
@@ -0,0 +1,40 @@
+package problem_service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fansqz/fancode-backend/constants"
+)
+
+const (
+	AcmCCodeFilePath    = "/conf/acm_template/c"
+	AcmCPPCodeFilePath  = "/conf/acm_template/cpp"
+	AcmGoCodeFilePath   = "/conf/acm_template/go"
+	AcmJavaCodeFilePath = "/conf/acm_template/java"
+)
+
+// GetAcmCodeTemplate 读取默认的acm模板，题目没有设置代码模板时使用
+func GetAcmCodeTemplate(language constants.LanguageType) (string, error) {
+	// 规定配置读取位置在执行文件所在目录下的/conf目录下
+	// 读取当前位置
+	dir, _ := os.Getwd()
+	dir = strings.ReplaceAll(dir, "\\", "/")
+	var filePath string
+	switch language {
+	case constants.LanguageC:
+		filePath = filepath.Join(dir, AcmCCodeFilePath)
+	case constants.LanguageCPP:
+		filePath = filepath.Join(dir, AcmCPPCodeFilePath)
+	case constants.LanguageGo:
+		filePath = filepath.Join(dir, AcmGoCodeFilePath)
+	case constants.LanguageJava:
+		filePath = filepath.Join(dir, AcmJavaCodeFilePath)
+	default:
+		return "", fmt.Errorf("no acm template for language %s", language)
+	}
+	code, err := os.ReadFile(filePath)
+	return string(code), err
+}
@@ -0,0 +1,135 @@
+package problem_service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+// ProblemBankService 题库管理
+type ProblemBankService interface {
+	// InsertProblemBank 添加题库
+	InsertProblemBank(ctx context.Context, bank *po.ProblemBank) (uint, error)
+	// UpdateProblemBank 更新题库
+	UpdateProblemBank(ctx context.Context, bank *po.ProblemBank) error
+	// DeleteProblemBank 删除题库以及题库中的所有题目
+	DeleteProblemBank(ctx context.Context, id uint) error
+	// GetAllProblemBank 获取所有题库，enable不为空时根据是否启用过滤
+	GetAllProblemBank(ctx context.Context, enable *bool) ([]*dto.ProblemBankDto, error)
+	// GetProblemBankByID 获取题库信息
+	GetProblemBankByID(ctx context.Context, id uint) (*dto.ProblemBankDto, error)
+}
+
+type problemBankService struct {
+	problemBankDao     dao.ProblemBankDao
+	problemDao         dao.ProblemDao
+	problemCaseDao     dao.ProblemCaseDao
+	problemTemplateDao dao.ProblemTemplateDao
+	sysUserDao         dao.SysUserDao
+}
+
+func NewProblemBankService(problemBankDao dao.ProblemBankDao, problemDao dao.ProblemDao, problemCaseDao dao.ProblemCaseDao,
+	problemTemplateDao dao.ProblemTemplateDao, sysUserDao dao.SysUserDao) ProblemBankService {
+	return &problemBankService{
+		problemBankDao:     problemBankDao,
+		problemDao:         problemDao,
+		problemCaseDao:     problemCaseDao,
+		problemTemplateDao: problemTemplateDao,
+		sysUserDao:         sysUserDao,
+	}
+}
+
+func (p *problemBankService) InsertProblemBank(ctx context.Context, bank *po.ProblemBank) (uint, error) {
+	bank.ID = 0
+	bank.CreatorID = utils.GetUserIDWithCtx(ctx)
+	if err := p.problemBankDao.InsertProblemBank(common.Mysql, bank); err != nil {
+		logger.WithCtx(ctx).Errorf("[InsertProblemBank] InsertProblemBank fail, err = %v", err)
+		return 0, e.ErrMysql
+	}
+	return bank.ID, nil
+}
+
+func (p *problemBankService) UpdateProblemBank(ctx context.Context, bank *po.ProblemBank) error {
+	if _, err := p.getProblemBank(ctx, bank.ID); err != nil {
+		return err
+	}
+	if err := p.problemBankDao.UpdateProblemBank(common.Mysql, bank); err != nil {
+		logger.WithCtx(ctx).Errorf("[UpdateProblemBank] UpdateProblemBank fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (p *problemBankService) DeleteProblemBank(ctx context.Context, id uint) error {
+	err := common.Mysql.Transaction(func(tx *gorm.DB) error {
+		problemIDs, err := p.problemDao.GetProblemIDsByBankID(tx, id)
+		if err != nil {
+			return err
+		}
+		if err = p.problemCaseDao.DeleteProblemCasesByProblemIDs(tx, problemIDs); err != nil {
+			return err
+		}
+		if err = p.problemTemplateDao.DeleteProblemTemplatesByProblemIDs(tx, problemIDs); err != nil {
+			return err
+		}
+		if err = p.problemDao.DeleteProblemsByBankID(tx, id); err != nil {
+			return err
+		}
+		return p.problemBankDao.DeleteProblemBankByID(tx, id)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteProblemBank] delete problem bank fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (p *problemBankService) GetAllProblemBank(ctx context.Context, enable *bool) ([]*dto.ProblemBankDto, error) {
+	banks, err := p.problemBankDao.GetAllProblemBank(common.Mysql)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetAllProblemBank] GetAllProblemBank fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.ProblemBankDto, 0, len(banks))
+	for _, bank := range banks {
+		// 根据enable过滤
+		if enable != nil && bank.Enable != *enable {
+			continue
+		}
+		bankDto := dto.NewProblemBankDto(bank)
+		bankDto.CreatorName, _ = p.sysUserDao.GetUserNameByID(common.Mysql, bank.CreatorID)
+		answer = append(answer, bankDto)
+	}
+	return answer, nil
+}
+
+func (p *problemBankService) GetProblemBankByID(ctx context.Context, id uint) (*dto.ProblemBankDto, error) {
+	bank, err := p.getProblemBank(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	answer := dto.NewProblemBankDto(bank)
+	answer.CreatorName, _ = p.sysUserDao.GetUserNameByID(common.Mysql, bank.CreatorID)
+	return answer, nil
+}
+
+// getProblemBank 读取题库，题库不存在时返回ErrProblemBankNotExist
+func (p *problemBankService) getProblemBank(ctx context.Context, id uint) (*po.ProblemBank, error) {
+	bank, err := p.problemBankDao.GetProblemBankByID(common.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrProblemBankNotExist
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getProblemBank] GetProblemBankByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return bank, nil
+}
@@ -0,0 +1,162 @@
+package problem_service
+
+import (
+	"context"
+	"mime/multipart"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+)
+
+const (
+	// caseInputExt 测试数据中输入文件的后缀
+	caseInputExt = ".in"
+	// sampleCasePrefix 文件名以sample开头或者在sample目录下的用例为样例
+	sampleCasePrefix = "sample"
+)
+
+// caseOutputExts 测试数据中输出文件的后缀，按顺序查找
+var caseOutputExts = []string{".out", ".ans"}
+
+// extractProblemCases 解压上传的zip文件，并读取其中的测试用例
+func (p *problemService) extractProblemCases(ctx context.Context, file *multipart.FileHeader) ([]*po.ProblemCase, error) {
+	if strings.ToLower(path.Ext(file.Filename)) != ".zip" {
+		return nil, e.ErrProblemCaseFileInvalid
+	}
+	tempDir := path.Join(p.config.TempDir, "problem_case", utils.GetUUID())
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			logger.WithCtx(ctx).Errorf("[extractProblemCases] remove temp dir fail, err = %v", err)
+		}
+	}()
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		logger.WithCtx(ctx).Errorf("[extractProblemCases] create temp dir fail, err = %v", err)
+		return nil, e.ErrServer
+	}
+	archiveFile := path.Join(tempDir, "cases.zip")
+	if err := utils.SaveUploadedFile(file, archiveFile); err != nil {
+		logger.WithCtx(ctx).Errorf("[extractProblemCases] save file fail, err = %v", err)
+		return nil, e.ErrServer
+	}
+	dataDir := path.Join(tempDir, "data")
+	if err := utils.Extract(archiveFile, dataDir); err != nil {
+		logger.WithCtx(ctx).Warnf("[extractProblemCases] extract file fail, err = %v", err)
+		return nil, e.ErrProblemCaseFileInvalid
+	}
+	problemCases, err := readProblemCases(dataDir)
+	if err != nil {
+		logger.WithCtx(ctx).Warnf("[extractProblemCases] read problem cases fail, err = %v", err)
+		return nil, e.ErrProblemCaseFileInvalid
+	}
+	if len(problemCases) == 0 {
+		return nil, e.ErrProblemCaseFileInvalid
+	}
+	return problemCases, nil
+}
+
+// readProblemCases 读取目录下的测试用例，每个.in文件需要有同名的.out或者.ans文件
+func readProblemCases(dir string) ([]*po.ProblemCase, error) {
+	var problemCases []*po.ProblemCase
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(filePath) != caseInputExt {
+			return nil
+		}
+		input, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		basePath := strings.TrimSuffix(filePath, caseInputExt)
+		output, err := readCaseOutput(basePath)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, basePath)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		problemCases = append(problemCases, &po.ProblemCase{
+			Name:   name,
+			Input:  string(input),
+			Output: string(output),
+			Sample: isSampleCase(name),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 样例排在前面，同类用例按名称排序
+	sort.SliceStable(problemCases, func(i, j int) bool {
+		if problemCases[i].Sample != problemCases[j].Sample {
+			return problemCases[i].Sample
+		}
+		return naturalLess(problemCases[i].Name, problemCases[j].Name)
+	})
+	return problemCases, nil
+}
+
+// readCaseOutput 读取输入文件对应的输出文件
+func readCaseOutput(basePath string) ([]byte, error) {
+	var err error
+	for _, ext := range caseOutputExts {
+		var output []byte
+		output, err = os.ReadFile(basePath + ext)
+		if err == nil {
+			return output, nil
+		}
+	}
+	return nil, err
+}
+
+// isSampleCase 判断用例是否为样例
+func isSampleCase(name string) bool {
+	for _, part := range strings.Split(strings.ToLower(name), "/") {
+		if strings.HasPrefix(part, sampleCasePrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// naturalLess 按自然顺序比较用例名称，保证2排在10的前面
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		na, restA := splitNumberPrefix(a)
+		nb, restB := splitNumberPrefix(b)
+		if na != "" && nb != "" {
+			x, _ := strconv.Atoi(na)
+			y, _ := strconv.Atoi(nb)
+			if x != y {
+				return x < y
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// splitNumberPrefix 拆分出字符串开头的数字
+func splitNumberPrefix(s string) (string, string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,76 @@
+package problem_service
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fansqz/fancode-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeZip(t *testing.T, zipPath string, files map[string]string) {
+	file, err := os.Create(zipPath)
+	assert.Nil(t, err)
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		assert.Nil(t, err)
+		_, err = w.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, writer.Close())
+}
+
+func TestReadProblemCases(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "cases.zip")
+	writeZip(t, zipPath, map[string]string{
+		"10.in":          "10",
+		"10.out":         "100",
+		"2.in":           "2",
+		"2.ans":          "4",
+		"sample1.in":     "1",
+		"sample1.out":    "1",
+		"sample/a.in":    "3",
+		"sample/a.out":   "9",
+		"readme.txt":     "ignored",
+		"group/3.in":     "3",
+		"group/3.out":    "9",
+		"group/3.out.md": "ignored",
+	})
+	dataDir := filepath.Join(dir, "data")
+	assert.Nil(t, utils.Extract(zipPath, dataDir))
+
+	problemCases, err := readProblemCases(dataDir)
+	assert.Nil(t, err)
+	names := make([]string, 0, len(problemCases))
+	for _, problemCase := range problemCases {
+		names = append(names, problemCase.Name)
+	}
+	assert.Equal(t, []string{"sample/a", "sample1", "2", "10", "group/3"}, names)
+	assert.True(t, problemCases[0].Sample)
+	assert.True(t, problemCases[1].Sample)
+	assert.False(t, problemCases[2].Sample)
+	assert.Equal(t, "2", problemCases[2].Input)
+	assert.Equal(t, "4", problemCases[2].Output)
+	assert.Equal(t, "100", problemCases[3].Output)
+}
+
+func TestReadProblemCases_MissingOutput(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "1.in"), []byte("1"), 0644))
+	_, err := readProblemCases(dir)
+	assert.NotNil(t, err)
+}
+
+func TestExtract_IllegalPath(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "cases.zip")
+	writeZip(t, zipPath, map[string]string{"../evil.in": "1"})
+	assert.NotNil(t, utils.Extract(zipPath, filepath.Join(dir, "data")))
+	_, err := os.Stat(filepath.Join(dir, "evil.in"))
+	assert.True(t, os.IsNotExist(err))
+}
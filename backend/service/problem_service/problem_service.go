@@ -0,0 +1,530 @@
+package problem_service
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"os"
+	"strings"
+
+	"github.com/fansqz/fancode-backend/common"
+	conf "github.com/fansqz/fancode-backend/common/config"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTimeLimit 默认时间限制，单位毫秒
+	DefaultTimeLimit = 1000
+	// DefaultMemoryLimit 默认内存限制，单位MB
+	DefaultMemoryLimit = 256
+)
+
+// ProblemService 题目及测试用例管理
+type ProblemService interface {
+	// InsertProblem 添加题目
+	InsertProblem(ctx context.Context, problem *dto.ProblemDtoForSave) (uint, error)
+	// UpdateProblem 更新题目
+	UpdateProblem(ctx context.Context, problem *dto.ProblemDtoForSave) error
+	// DeleteProblem 删除题目以及题目的测试用例
+	DeleteProblem(ctx context.Context, id uint) error
+	// GetProblemByID 获取题目，包括代码模板
+	GetProblemByID(ctx context.Context, id uint) (*dto.ProblemDtoForManage, error)
+	// GetProblemList 获取题目列表
+	GetProblemList(ctx context.Context, pageQuery *dto.PageQuery) (*dto.PageInfo, error)
+	// GetEnabledProblem 用户获取已启用的题目，只返回样例
+	GetEnabledProblem(ctx context.Context, id uint) (*dto.ProblemDtoForDetail, error)
+	// GetProblemTemplate 获取题目在某个语言下的代码模板，题目没有设置模板时使用默认的acm模板
+	GetProblemTemplate(ctx context.Context, problemID uint, language string) (string, error)
+	// GetAllProblemTag 获取所有标签
+	GetAllProblemTag(ctx context.Context) ([]string, error)
+
+	// InsertProblemCase 添加测试用例
+	InsertProblemCase(ctx context.Context, problemCase *po.ProblemCase) (uint, error)
+	// UpdateProblemCase 更新测试用例
+	UpdateProblemCase(ctx context.Context, problemCase *po.ProblemCase) error
+	// DeleteProblemCase 删除测试用例
+	DeleteProblemCase(ctx context.Context, id uint) error
+	// GetProblemCaseList 获取题目的所有测试用例
+	GetProblemCaseList(ctx context.Context, problemID uint) ([]*po.ProblemCase, error)
+	// UploadProblemCases 上传zip格式的测试数据，替换题目原有的测试用例，返回用例数量
+	UploadProblemCases(ctx context.Context, problemID uint, file *multipart.FileHeader) (int, error)
+}
+
+type problemService struct {
+	config             *conf.AppConfig
+	problemDao         dao.ProblemDao
+	problemCaseDao     dao.ProblemCaseDao
+	problemBankDao     dao.ProblemBankDao
+	problemTagDao      dao.ProblemTagDao
+	problemTemplateDao dao.ProblemTemplateDao
+}
+
+func NewProblemService(config *conf.AppConfig, problemDao dao.ProblemDao, problemCaseDao dao.ProblemCaseDao,
+	problemBankDao dao.ProblemBankDao, problemTagDao dao.ProblemTagDao, problemTemplateDao dao.ProblemTemplateDao) ProblemService {
+	return &problemService{
+		config:             config,
+		problemDao:         problemDao,
+		problemCaseDao:     problemCaseDao,
+		problemBankDao:     problemBankDao,
+		problemTagDao:      problemTagDao,
+		problemTemplateDao: problemTemplateDao,
+	}
+}
+
+func (p *problemService) InsertProblem(ctx context.Context, problemDto *dto.ProblemDtoForSave) (uint, error) {
+	if err := p.checkProblem(ctx, problemDto); err != nil {
+		return 0, err
+	}
+	exist, err := p.problemDao.CheckProblemNumberExists(common.Mysql, problemDto.Number)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[InsertProblem] CheckProblemNumberExists fail, err = %v", err)
+		return 0, e.ErrProblemCodeCheckFailed
+	}
+	if exist {
+		return 0, e.ErrProblemCodeIsExist
+	}
+	problem := newProblem(problemDto)
+	problem.ID = 0
+	problem.CreatorID = utils.GetUserIDWithCtx(ctx)
+	if problem.Description == "" {
+		problem.Description = p.getDescriptionTemplate(ctx)
+	}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := p.problemDao.InsertProblem(tx, problem); err != nil {
+			return err
+		}
+		return p.saveTagsAndTemplates(tx, problem, problemDto)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[InsertProblem] InsertProblem fail, err = %v", err)
+		return 0, e.ErrProblemInsertFailed
+	}
+	return problem.ID, nil
+}
+
+func (p *problemService) UpdateProblem(ctx context.Context, problemDto *dto.ProblemDtoForSave) error {
+	oldProblem, err := p.getProblem(ctx, problemDto.ID)
+	if err != nil {
+		return err
+	}
+	if err = p.checkProblem(ctx, problemDto); err != nil {
+		return err
+	}
+	// 修改了编号时检查编号是否重复
+	if problemDto.Number != oldProblem.Number {
+		exist, err := p.problemDao.CheckProblemNumberExists(common.Mysql, problemDto.Number)
+		if err != nil {
+			logger.WithCtx(ctx).Errorf("[UpdateProblem] CheckProblemNumberExists fail, err = %v", err)
+			return e.ErrProblemCodeCheckFailed
+		}
+		if exist {
+			return e.ErrProblemCodeIsExist
+		}
+	}
+	problem := newProblem(problemDto)
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := p.problemDao.UpdateProblem(tx, problem); err != nil {
+			return err
+		}
+		return p.saveTagsAndTemplates(tx, problem, problemDto)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[UpdateProblem] UpdateProblem fail, err = %v", err)
+		return e.ErrProblemUpdateFailed
+	}
+	return nil
+}
+
+func (p *problemService) DeleteProblem(ctx context.Context, id uint) error {
+	err := common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := p.problemCaseDao.DeleteProblemCasesByProblemID(tx, id); err != nil {
+			return err
+		}
+		if err := p.problemTemplateDao.DeleteProblemTemplatesByProblemIDs(tx, []uint{id}); err != nil {
+			return err
+		}
+		problem := &po.Problem{}
+		problem.ID = id
+		if err := p.problemDao.ClearProblemTags(tx, problem); err != nil {
+			return err
+		}
+		return p.problemDao.DeleteProblemByID(tx, id)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteProblem] delete problem fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (p *problemService) GetProblemByID(ctx context.Context, id uint) (*dto.ProblemDtoForManage, error) {
+	problem, err := p.getProblemWithTags(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := p.problemTemplateDao.GetProblemTemplates(common.Mysql, id)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetProblemByID] GetProblemTemplates fail, err = %v", err)
+		return nil, e.ErrProblemGetFailed
+	}
+	return dto.NewProblemDtoForManage(problem, templates), nil
+}
+
+func (p *problemService) GetProblemList(ctx context.Context, pageQuery *dto.PageQuery) (*dto.PageInfo, error) {
+	var problemQuery *dto.ProblemQuery
+	if pageQuery.Query != nil {
+		problemQuery = pageQuery.Query.(*dto.ProblemQuery)
+	}
+	problems, err := p.problemDao.GetProblemList(common.Mysql, pageQuery)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetProblemList] GetProblemList fail, err = %v", err)
+		return nil, e.ErrProblemListFailed
+	}
+	count, err := p.problemDao.GetProblemCount(common.Mysql, problemQuery)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetProblemList] GetProblemCount fail, err = %v", err)
+		return nil, e.ErrProblemListFailed
+	}
+	list := make([]*dto.ProblemDtoForList, 0, len(problems))
+	for _, problem := range problems {
+		list = append(list, dto.NewProblemDtoForList(problem))
+	}
+	return &dto.PageInfo{
+		Total: count,
+		Size:  int64(len(list)),
+		List:  list,
+	}, nil
+}
+
+func (p *problemService) GetEnabledProblem(ctx context.Context, id uint) (*dto.ProblemDtoForDetail, error) {
+	problem, err := p.getEnabledProblem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	problem.Tags, err = p.problemDao.GetProblemTags(common.Mysql, problem)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetEnabledProblem] GetProblemTags fail, err = %v", err)
+		return nil, e.ErrProblemGetFailed
+	}
+	samples, err := p.problemCaseDao.GetSampleCasesByProblemID(common.Mysql, id)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetEnabledProblem] GetSampleCasesByProblemID fail, err = %v", err)
+		return nil, e.ErrProblemGetFailed
+	}
+	return dto.NewProblemDtoForDetail(problem, samples), nil
+}
+
+func (p *problemService) GetProblemTemplate(ctx context.Context, problemID uint, language string) (string, error) {
+	if !isSupportLanguage(language) {
+		return "", e.ErrLanguageNotSupported
+	}
+	if _, err := p.getEnabledProblem(ctx, problemID); err != nil {
+		return "", err
+	}
+	template, err := p.problemTemplateDao.GetProblemTemplate(common.Mysql, problemID, language)
+	if err == nil {
+		return template.Code, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.WithCtx(ctx).Errorf("[GetProblemTemplate] GetProblemTemplate fail, err = %v", err)
+		return "", e.ErrProblemGetFailed
+	}
+	// 题目没有设置该语言的模板，使用默认的acm模板
+	code, err := GetAcmCodeTemplate(constants.LanguageType(language))
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetProblemTemplate] GetAcmCodeTemplate fail, err = %v", err)
+		return "", e.ErrProblemGetFailed
+	}
+	return code, nil
+}
+
+func (p *problemService) GetAllProblemTag(ctx context.Context) ([]string, error) {
+	tags, err := p.problemTagDao.GetAllProblemTag(common.Mysql)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetAllProblemTag] GetAllProblemTag fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names, nil
+}
+
+func (p *problemService) InsertProblemCase(ctx context.Context, problemCase *po.ProblemCase) (uint, error) {
+	if _, err := p.getProblem(ctx, problemCase.ProblemID); err != nil {
+		return 0, err
+	}
+	problemCase.ID = 0
+	if err := p.problemCaseDao.InsertProblemCase(common.Mysql, problemCase); err != nil {
+		logger.WithCtx(ctx).Errorf("[InsertProblemCase] InsertProblemCase fail, err = %v", err)
+		return 0, e.ErrMysql
+	}
+	return problemCase.ID, nil
+}
+
+func (p *problemService) UpdateProblemCase(ctx context.Context, problemCase *po.ProblemCase) error {
+	if _, err := p.problemCaseDao.GetProblemCaseByID(common.Mysql, problemCase.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.NewRecordNotFoundErr("problem case not found")
+		}
+		logger.WithCtx(ctx).Errorf("[UpdateProblemCase] GetProblemCaseByID fail, err = %v", err)
+		return e.ErrMysql
+	}
+	if err := p.problemCaseDao.UpdateProblemCase(common.Mysql, problemCase); err != nil {
+		logger.WithCtx(ctx).Errorf("[UpdateProblemCase] UpdateProblemCase fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (p *problemService) DeleteProblemCase(ctx context.Context, id uint) error {
+	if err := p.problemCaseDao.DeleteProblemCaseByID(common.Mysql, id); err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteProblemCase] DeleteProblemCaseByID fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (p *problemService) GetProblemCaseList(ctx context.Context, problemID uint) ([]*po.ProblemCase, error) {
+	problemCases, err := p.problemCaseDao.GetProblemCasesByProblemID(common.Mysql, problemID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetProblemCaseList] GetProblemCasesByProblemID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return problemCases, nil
+}
+
+func (p *problemService) UploadProblemCases(ctx context.Context, problemID uint, file *multipart.FileHeader) (int, error) {
+	if _, err := p.getProblem(ctx, problemID); err != nil {
+		return 0, err
+	}
+	problemCases, err := p.extractProblemCases(ctx, file)
+	if err != nil {
+		return 0, err
+	}
+	for _, problemCase := range problemCases {
+		problemCase.ProblemID = problemID
+	}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := p.problemCaseDao.DeleteProblemCasesByProblemID(tx, problemID); err != nil {
+			return err
+		}
+		return p.problemCaseDao.InsertProblemCases(tx, problemCases)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[UploadProblemCases] save problem cases fail, err = %v", err)
+		return 0, e.ErrMysql
+	}
+	return len(problemCases), nil
+}
+
+// getProblem 读取题目，题目不存在时返回ErrProblemNotExist
+func (p *problemService) getProblem(ctx context.Context, id uint) (*po.Problem, error) {
+	problem, err := p.problemDao.GetProblemByID(common.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrProblemNotExist
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getProblem] GetProblemByID fail, err = %v", err)
+		return nil, e.ErrProblemGetFailed
+	}
+	return problem, nil
+}
+
+// getProblemWithTags 读取题目以及题目的标签
+func (p *problemService) getProblemWithTags(ctx context.Context, id uint) (*po.Problem, error) {
+	problem, err := p.getProblem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	problem.Tags, err = p.problemDao.GetProblemTags(common.Mysql, problem)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getProblemWithTags] GetProblemTags fail, err = %v", err)
+		return nil, e.ErrProblemGetFailed
+	}
+	return problem, nil
+}
+
+// getEnabledProblem 读取用户可见的题目，题目或者题目所在的题库未启用时视为不存在
+func (p *problemService) getEnabledProblem(ctx context.Context, id uint) (*po.Problem, error) {
+	problem, err := p.getProblem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !problem.Enable {
+		return nil, e.ErrProblemNotExist
+	}
+	if problem.BankID == 0 {
+		return problem, nil
+	}
+	bank, err := p.problemBankDao.GetProblemBankByID(common.Mysql, problem.BankID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !bank.Enable) {
+		return nil, e.ErrProblemNotExist
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getEnabledProblem] GetProblemBankByID fail, err = %v", err)
+		return nil, e.ErrProblemGetFailed
+	}
+	return problem, nil
+}
+
+// checkProblem 检查添加或修改的题目是否合法
+func (p *problemService) checkProblem(ctx context.Context, problemDto *dto.ProblemDtoForSave) error {
+	if problemDto.Number == "" {
+		return e.ErrBadRequest
+	}
+	if problemDto.Difficulty == 0 {
+		problemDto.Difficulty = constants.DifficultyEasy
+	}
+	if problemDto.Difficulty < constants.DifficultyEasy || problemDto.Difficulty > constants.DifficultyHard {
+		return e.ErrBadRequest
+	}
+	for _, template := range problemDto.Templates {
+		if !isSupportLanguage(template.Language) {
+			return e.ErrLanguageNotSupported
+		}
+	}
+	if err := checkProblemJudge(&problemDto.ProblemJudgeDto); err != nil {
+		return err
+	}
+	if problemDto.BankID == 0 {
+		return nil
+	}
+	_, err := p.problemBankDao.GetProblemBankByID(common.Mysql, problemDto.BankID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrProblemBankNotExist
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[checkProblem] GetProblemBankByID fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+// saveTagsAndTemplates 保存题目的标签和代码模板
+func (p *problemService) saveTagsAndTemplates(tx *gorm.DB, problem *po.Problem, problemDto *dto.ProblemDtoForSave) error {
+	tags, err := p.problemTagDao.GetOrCreateProblemTags(tx, tagNames(problemDto.Tags))
+	if err != nil {
+		return err
+	}
+	if err = p.problemDao.ReplaceProblemTags(tx, problem, tags); err != nil {
+		return err
+	}
+	templates := make([]*po.ProblemTemplate, 0, len(problemDto.Templates))
+	for _, template := range problemDto.Templates {
+		templates = append(templates, &po.ProblemTemplate{
+			Language: template.Language,
+			Code:     template.Code,
+		})
+	}
+	return p.problemTemplateDao.ReplaceProblemTemplates(tx, problem.ID, templates)
+}
+
+// getDescriptionTemplate 读取默认的题目描述模板，读取失败时返回空字符串
+func (p *problemService) getDescriptionTemplate(ctx context.Context) string {
+	if p.config.FilePathConfig == nil || p.config.ProblemDescriptionTemplate == "" {
+		return ""
+	}
+	content, err := os.ReadFile(p.config.ProblemDescriptionTemplate)
+	if err != nil {
+		logger.WithCtx(ctx).Warnf("[getDescriptionTemplate] read description template fail, err = %v", err)
+		return ""
+	}
+	return string(content)
+}
+
+// newProblem 根据请求创建题目
+func newProblem(problemDto *dto.ProblemDtoForSave) *po.Problem {
+	problem := &po.Problem{
+		BankID:      problemDto.BankID,
+		Number:      problemDto.Number,
+		Name:        problemDto.Name,
+		Description: problemDto.Description,
+		Difficulty:  problemDto.Difficulty,
+		TimeLimit:   problemDto.TimeLimit,
+		MemoryLimit: problemDto.MemoryLimit,
+		Enable:      problemDto.Enable,
+
+		Checker:            problemDto.Checker,
+		CheckerEpsilon:     problemDto.CheckerEpsilon,
+		CheckerLanguage:    problemDto.CheckerLanguage,
+		CheckerCode:        problemDto.CheckerCode,
+		Interactive:        problemDto.Interactive,
+		InteractorLanguage: problemDto.InteractorLanguage,
+		InteractorCode:     problemDto.InteractorCode,
+	}
+	problem.ID = problemDto.ID
+	setDefaultLimit(problem)
+	return problem
+}
+
+// checkProblemJudge 检查题目的判题方式，特判程序和交互程序只支持c和cpp
+func checkProblemJudge(judgeDto *dto.ProblemJudgeDto) error {
+	switch judgeDto.Checker {
+	case "":
+		judgeDto.Checker = constants.CheckerDefault
+	case constants.CheckerDefault, constants.CheckerIgnoreWhitespace:
+	case constants.CheckerFloat:
+		if judgeDto.CheckerEpsilon < 0 {
+			return e.ErrBadRequest
+		}
+	case constants.CheckerSpecial:
+		if judgeDto.CheckerCode == "" || !isNativeLanguage(judgeDto.CheckerLanguage) {
+			return e.ErrProblemCheckerInvalid
+		}
+	default:
+		return e.ErrBadRequest
+	}
+	if judgeDto.Interactive && (judgeDto.InteractorCode == "" || !isNativeLanguage(judgeDto.InteractorLanguage)) {
+		return e.ErrProblemCheckerInvalid
+	}
+	return nil
+}
+
+// isNativeLanguage 特判程序和交互程序支持的语言
+func isNativeLanguage(language string) bool {
+	return language == string(constants.LanguageC) || language == string(constants.LanguageCPP)
+}
+
+// tagNames 去掉标签名称两端的空白，并去除空的和重复的标签
+func tagNames(tags []string) []string {
+	names := make([]string, 0, len(tags))
+	exist := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || exist[tag] {
+			continue
+		}
+		exist[tag] = true
+		names = append(names, tag)
+	}
+	return names
+}
+
+// setDefaultLimit 未设置资源限制时使用默认值
+func setDefaultLimit(problem *po.Problem) {
+	if problem.TimeLimit <= 0 {
+		problem.TimeLimit = DefaultTimeLimit
+	}
+	if problem.MemoryLimit <= 0 {
+		problem.MemoryLimit = DefaultMemoryLimit
+	}
+}
+
+// isSupportLanguage 判断是否支持该语言
+func isSupportLanguage(language string) bool {
+	for _, l := range constants.SupportLanguages {
+		if string(l) == language {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package search_service
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightPrefix = "<em>"
+	highlightSuffix = "</em>"
+	ellipsis        = "..."
+)
+
+// highlight 转义html并使用<em>标记文本中的搜索词，返回结果和第一个搜索词所在的行号
+// limit大于0时只截取第一个搜索词附近limit个字符，没有匹配时截取开头
+func highlight(text string, terms []string, limit int) (string, int) {
+	runes := []rune(text)
+	matches := findMatches(runes, terms)
+	start, end := 0, len(runes)
+	line := 0
+	if len(matches) != 0 {
+		line = strings.Count(string(runes[:matches[0][0]]), "\n") + 1
+	}
+	if limit > 0 && len(runes) > limit {
+		if len(matches) != 0 {
+			// 搜索词前面保留四分之一的长度作为上下文
+			start = matches[0][0] - limit/4
+			if start < 0 {
+				start = 0
+			}
+		}
+		end = start + limit
+		if end > len(runes) {
+			end = len(runes)
+			start = end - limit
+		}
+	}
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString(ellipsis)
+	}
+	pos := start
+	for _, m := range matches {
+		// 跳过窗口外的匹配，窗口边缘的匹配只标记窗口内的部分
+		if m[1] <= start || m[0] >= end {
+			continue
+		}
+		mStart, mEnd := max(m[0], start), min(m[1], end)
+		builder.WriteString(html.EscapeString(string(runes[pos:mStart])))
+		builder.WriteString(highlightPrefix)
+		builder.WriteString(html.EscapeString(string(runes[mStart:mEnd])))
+		builder.WriteString(highlightSuffix)
+		pos = mEnd
+	}
+	builder.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		builder.WriteString(ellipsis)
+	}
+	return builder.String(), line
+}
+
+// findMatches 查找所有搜索词出现的位置，忽略大小写，返回按位置排序且不重叠的区间
+func findMatches(runes []rune, terms []string) [][2]int {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	termRunes := make([][]rune, 0, len(terms))
+	for _, term := range terms {
+		if t := []rune(strings.ToLower(term)); len(t) != 0 {
+			termRunes = append(termRunes, t)
+		}
+	}
+	// 长的词优先匹配
+	sort.SliceStable(termRunes, func(i, j int) bool {
+		return len(termRunes[i]) > len(termRunes[j])
+	})
+	var matches [][2]int
+	for i := 0; i < len(lower); {
+		matched := 0
+		for _, term := range termRunes {
+			if hasPrefix(lower[i:], term) {
+				matched = len(term)
+				break
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		matches = append(matches, [2]int{i, i + matched})
+		i += matched
+	}
+	return matches
+}
+
+func hasPrefix(runes []rune, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if runes[i] != r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,396 @@
+package search_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxSearchTerms 关键词最多拆分的词数
+	maxSearchTerms = 10
+	// snippetLength 搜索结果中内容摘要的长度
+	snippetLength = 160
+	// rebuildBatchSize 重建索引时每次读取的保存代码数量
+	rebuildBatchSize = 500
+	// maxPageSize 每页搜索结果的最大数量
+	maxPageSize = 50
+)
+
+// SearchService 搜索知识库文档、文档中的代码和用户自己保存的代码
+// 搜索使用单独的索引表，文档或代码变化后需要调用Index方法更新索引，更新失败只记录日志
+type SearchService interface {
+	// Search 搜索当前用户可以看到的内容
+	Search(ctx context.Context, pageQuery *dto.PageQuery) (*dto.PageInfo, error)
+	// RebuildIndex 清空并重建所有的索引
+	RebuildIndex(ctx context.Context) error
+	// IndexBank 重建知识库中所有文档的索引，知识库不存在或者未启用时删除索引
+	IndexBank(ctx context.Context, bankID uint)
+	// IndexDocument 文档新增、修改、移动或删除后更新文档及其子文档的索引，文档的启用状态和位置会影响子文档是否可见
+	IndexDocument(ctx context.Context, bankID uint, documentID uint)
+	// IndexSavedCode 更新用户保存的代码的索引
+	IndexSavedCode(ctx context.Context, savedCode *po.UserSavedCode)
+	// RemoveSavedCode 删除用户保存的代码的索引
+	RemoveSavedCode(ctx context.Context, id uint)
+}
+
+type searchService struct {
+	searchEntryDao        dao.SearchEntryDao
+	visualDocumentBankDao dao.VisualDocumentBankDao
+	visualDocumentDao     dao.VisualDocumentDao
+	revisionDao           dao.VisualDocumentRevisionDao
+	savedCodeDao          dao.UserSavedCodeDao
+}
+
+func NewSearchService(searchEntryDao dao.SearchEntryDao, bankDao dao.VisualDocumentBankDao, documentDao dao.VisualDocumentDao,
+	revisionDao dao.VisualDocumentRevisionDao, savedCodeDao dao.UserSavedCodeDao) SearchService {
+	return &searchService{
+		searchEntryDao:        searchEntryDao,
+		visualDocumentBankDao: bankDao,
+		visualDocumentDao:     documentDao,
+		revisionDao:           revisionDao,
+		savedCodeDao:          savedCodeDao,
+	}
+}
+
+func (s *searchService) Search(ctx context.Context, pageQuery *dto.PageQuery) (*dto.PageInfo, error) {
+	query := pageQuery.Query.(*dto.SearchQuery)
+	if query.Type != "" && query.Type != constants.SearchTypeDocument &&
+		query.Type != constants.SearchTypeDocumentCode && query.Type != constants.SearchTypeSavedCode {
+		return nil, e.NewParamErr("不支持的搜索类型")
+	}
+	query.Terms = splitSearchTerms(query.Keyword)
+	if len(query.Terms) == 0 {
+		return nil, e.NewParamErr("搜索关键词不能为空")
+	}
+	query.UserID = utils.GetUserIDWithCtx(ctx)
+	if pageQuery.Page <= 0 {
+		pageQuery.Page = 1
+	}
+	if pageQuery.PageSize <= 0 {
+		pageQuery.PageSize = 10
+	}
+	if pageQuery.PageSize > maxPageSize {
+		pageQuery.PageSize = maxPageSize
+	}
+	entries, err := s.searchEntryDao.GetSearchEntryList(common.Mysql, pageQuery)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[Search] GetSearchEntryList fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	count, err := s.searchEntryDao.GetSearchEntryCount(common.Mysql, query)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[Search] GetSearchEntryCount fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	list := make([]*dto.SearchResultDto, 0, len(entries))
+	for _, entry := range entries {
+		result := dto.NewSearchResultDto(entry)
+		result.Title, _ = highlight(entry.Title, query.Terms, 0)
+		result.Snippet, result.Line = highlight(entry.Content, query.Terms, snippetLength)
+		list = append(list, result)
+	}
+	return &dto.PageInfo{
+		Total: count,
+		Size:  int64(len(list)),
+		List:  list,
+	}, nil
+}
+
+func (s *searchService) RebuildIndex(ctx context.Context) error {
+	if err := s.searchEntryDao.DeleteAllSearchEntries(common.Mysql); err != nil {
+		logger.WithCtx(ctx).Errorf("[RebuildIndex] DeleteAllSearchEntries fail, err = %v", err)
+		return e.ErrMysql
+	}
+	banks, err := s.visualDocumentBankDao.GetAllVisualDocumentBank(common.Mysql)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[RebuildIndex] GetAllVisualDocumentBank fail, err = %v", err)
+		return e.ErrMysql
+	}
+	for _, bank := range banks {
+		if err = s.indexBank(bank.ID); err != nil {
+			logger.WithCtx(ctx).Errorf("[RebuildIndex] index bank %d fail, err = %v", bank.ID, err)
+			return e.ErrMysql
+		}
+	}
+	var lastID uint
+	for {
+		savedCodes, err := s.savedCodeDao.GetUserSavedCodesAfterID(common.Mysql, lastID, rebuildBatchSize)
+		if err != nil {
+			logger.WithCtx(ctx).Errorf("[RebuildIndex] GetUserSavedCodesAfterID fail, err = %v", err)
+			return e.ErrMysql
+		}
+		if len(savedCodes) == 0 {
+			return nil
+		}
+		entries := make([]*po.SearchEntry, 0, len(savedCodes))
+		for _, savedCode := range savedCodes {
+			entry, err := s.savedCodeEntry(savedCode)
+			if err != nil {
+				logger.WithCtx(ctx).Errorf("[RebuildIndex] savedCodeEntry fail, err = %v", err)
+				return e.ErrMysql
+			}
+			entries = append(entries, entry)
+			lastID = savedCode.ID
+		}
+		if err = s.searchEntryDao.InsertSearchEntries(common.Mysql, entries); err != nil {
+			logger.WithCtx(ctx).Errorf("[RebuildIndex] InsertSearchEntries fail, err = %v", err)
+			return e.ErrMysql
+		}
+	}
+}
+
+func (s *searchService) IndexBank(ctx context.Context, bankID uint) {
+	if err := s.indexBank(bankID); err != nil {
+		logger.WithCtx(ctx).Errorf("[IndexBank] index bank %d fail, err = %v", bankID, err)
+	}
+}
+
+func (s *searchService) IndexDocument(ctx context.Context, bankID uint, documentID uint) {
+	if err := s.indexDocument(bankID, documentID); err != nil {
+		logger.WithCtx(ctx).Errorf("[IndexDocument] index document %d fail, err = %v", documentID, err)
+	}
+}
+
+func (s *searchService) IndexSavedCode(ctx context.Context, savedCode *po.UserSavedCode) {
+	entry, err := s.savedCodeEntry(savedCode)
+	if err == nil {
+		err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+			if err := s.searchEntryDao.DeleteSearchEntry(tx, constants.SearchTypeSavedCode, savedCode.ID); err != nil {
+				return err
+			}
+			return s.searchEntryDao.InsertSearchEntries(tx, []*po.SearchEntry{entry})
+		})
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[IndexSavedCode] index saved code %d fail, err = %v", savedCode.ID, err)
+	}
+}
+
+func (s *searchService) RemoveSavedCode(ctx context.Context, id uint) {
+	if err := s.searchEntryDao.DeleteSearchEntry(common.Mysql, constants.SearchTypeSavedCode, id); err != nil {
+		logger.WithCtx(ctx).Errorf("[RemoveSavedCode] remove saved code %d fail, err = %v", id, err)
+	}
+}
+
+// indexBank 生成知识库中用户可见文档的索引，替换原有的索引
+func (s *searchService) indexBank(bankID uint) error {
+	entries := []*po.SearchEntry{}
+	bank, err := s.visualDocumentBankDao.GetVisualDocumentBankByID(common.Mysql, bankID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && bank.Enable {
+		documents, err := s.visualDocumentDao.GetAllSimpleDocument(common.Mysql, bankID)
+		if err != nil {
+			return err
+		}
+		for _, document := range visibleDocuments(documents) {
+			documentEntries, err := s.documentEntries(bankID, document)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, documentEntries...)
+		}
+	}
+	return common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := s.searchEntryDao.DeleteSearchEntriesByBankID(tx, bankID); err != nil {
+			return err
+		}
+		return s.searchEntryDao.InsertSearchEntries(tx, entries)
+	})
+}
+
+// indexDocument 生成文档及其子文档中用户可见文档的索引，替换这些文档原有的索引
+// 文档已经被删除时按父文档找到留下的子文档，删除它们的索引
+func (s *searchService) indexDocument(bankID uint, documentID uint) error {
+	bank, err := s.visualDocumentBankDao.GetVisualDocumentBankByID(common.Mysql, bankID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	enable := err == nil && bank.Enable
+	documents, err := s.visualDocumentDao.GetAllSimpleDocument(common.Mysql, bankID)
+	if err != nil {
+		return err
+	}
+	subtree := subtreeDocumentIDs(documents, documentID)
+	entries := []*po.SearchEntry{}
+	if enable {
+		for _, document := range visibleDocuments(documents) {
+			if !subtree[document.ID] {
+				continue
+			}
+			documentEntries, err := s.documentEntries(bankID, document)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, documentEntries...)
+		}
+	}
+	documentIDs := make([]uint, 0, len(subtree))
+	for id := range subtree {
+		documentIDs = append(documentIDs, id)
+	}
+	return common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := s.searchEntryDao.DeleteSearchEntriesByDocumentIDs(tx, documentIDs); err != nil {
+			return err
+		}
+		return s.searchEntryDao.InsertSearchEntries(tx, entries)
+	})
+}
+
+// documentEntries 生成文档和文档代码的索引，已发布的文档使用发布的版本
+func (s *searchService) documentEntries(bankID uint, document *po.VisualDocument) ([]*po.SearchEntry, error) {
+	var content string
+	var codeList []*dto.VisualDocumentCodeDto
+	if document.PublishedRevisionID != 0 {
+		revision, err := s.revisionDao.GetRevisionByID(common.Mysql, document.PublishedRevisionID)
+		if err != nil {
+			return nil, err
+		}
+		content = revision.Content
+		if err = json.Unmarshal([]byte(revision.CodeList), &codeList); err != nil {
+			codeList = nil
+		}
+	} else {
+		detail, err := s.visualDocumentDao.GetVisualDocumentByID(common.Mysql, document.ID)
+		if err != nil {
+			return nil, err
+		}
+		content = detail.Content
+		codes, err := s.visualDocumentDao.GetCodeListByDocumentID(common.Mysql, document.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range codes {
+			codeList = append(codeList, dto.NewVisualDocumentCodeDto(code))
+		}
+	}
+	entries := []*po.SearchEntry{{
+		Type:       constants.SearchTypeDocument,
+		SourceID:   document.ID,
+		BankID:     bankID,
+		DocumentID: document.ID,
+		Title:      document.Title,
+		Content:    content,
+	}}
+	for _, code := range codeList {
+		entries = append(entries, &po.SearchEntry{
+			Type:       constants.SearchTypeDocumentCode,
+			SourceID:   document.ID,
+			Language:   code.Language,
+			BankID:     bankID,
+			DocumentID: document.ID,
+			Title:      document.Title,
+			Content:    code.Code,
+		})
+	}
+	return entries, nil
+}
+
+// savedCodeEntry 生成用户保存代码的索引，属于文档的代码记录文档所在的知识库，用于按知识库过滤
+func (s *searchService) savedCodeEntry(savedCode *po.UserSavedCode) (*po.SearchEntry, error) {
+	entry := &po.SearchEntry{
+		Type:     constants.SearchTypeSavedCode,
+		SourceID: savedCode.ID,
+		Language: savedCode.Language,
+		UserID:   savedCode.UserID,
+		Title:    savedCode.Remark,
+		Content:  savedCode.Code,
+	}
+	// 设置了标题时使用标题，否则使用备注
+	if savedCode.Title != "" {
+		entry.Title = savedCode.Title
+	}
+	if savedCode.DocumentID != nil {
+		document, err := s.visualDocumentDao.GetVisualDocumentByID(common.Mysql, *savedCode.DocumentID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			entry.BankID = document.BankID
+			entry.DocumentID = document.ID
+		}
+	}
+	return entry, nil
+}
+
+// visibleDocuments 获取用户可以看到的文档，文档和所有的父文档都需要启用
+func visibleDocuments(documents []*po.VisualDocument) []*po.VisualDocument {
+	documentMap := make(map[uint]*po.VisualDocument, len(documents))
+	for _, document := range documents {
+		documentMap[document.ID] = document
+	}
+	visible := make(map[uint]bool, len(documents))
+	var isVisible func(document *po.VisualDocument, depth int) bool
+	isVisible = func(document *po.VisualDocument, depth int) bool {
+		if v, ok := visible[document.ID]; ok {
+			return v
+		}
+		// 目录中存在环时当做不可见
+		v := document.Enable && depth <= len(documents)
+		if v && document.ParentID != 0 {
+			parent, ok := documentMap[document.ParentID]
+			v = ok && isVisible(parent, depth+1)
+		}
+		visible[document.ID] = v
+		return v
+	}
+	answer := make([]*po.VisualDocument, 0, len(documents))
+	for _, document := range documents {
+		if isVisible(document, 0) {
+			answer = append(answer, document)
+		}
+	}
+	return answer
+}
+
+// subtreeDocumentIDs 获取文档及其所有子文档的id，目录中存在环时每篇文档只访问一次
+func subtreeDocumentIDs(documents []*po.VisualDocument, documentID uint) map[uint]bool {
+	children := make(map[uint][]uint, len(documents))
+	for _, document := range documents {
+		children[document.ParentID] = append(children[document.ParentID], document.ID)
+	}
+	subtree := map[uint]bool{documentID: true}
+	queue := []uint{documentID}
+	for len(queue) != 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range children[id] {
+			if !subtree[child] {
+				subtree[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return subtree
+}
+
+// splitSearchTerms 将关键词按空白拆分为搜索词，去掉重复的词
+func splitSearchTerms(keyword string) []string {
+	terms := make([]string, 0, maxSearchTerms)
+	exists := map[string]bool{}
+	for _, term := range strings.Fields(strings.ToLower(keyword)) {
+		term = strings.ReplaceAll(term, `"`, "")
+		if term == "" || exists[term] {
+			continue
+		}
+		exists[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
@@ -0,0 +1,94 @@
+package search_service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestHighlight(t *testing.T) {
+	text, line := highlight("双向链表<List>\nLinkedList 链表", []string{"链表", "list"}, 0)
+	assert.Equal(t, "双向<em>链表</em>&lt;<em>List</em>&gt;\nLinked<em>List</em> <em>链表</em>", text)
+	assert.Equal(t, 1, line)
+
+	// 没有匹配时不做标记
+	text, line = highlight("int main()", []string{"链表"}, 0)
+	assert.Equal(t, "int main()", text)
+	assert.Equal(t, 0, line)
+
+	// 长的词优先匹配
+	text, _ = highlight("linklist", []string{"link", "linklist"}, 0)
+	assert.Equal(t, "<em>linklist</em>", text)
+}
+
+func TestHighlight_Snippet(t *testing.T) {
+	content := "line1\nline2\n" + strings.Repeat("x", 100) + "target" + strings.Repeat("x", 100)
+	text, line := highlight(content, []string{"target"}, 40)
+	assert.Equal(t, 3, line)
+	assert.Contains(t, text, "<em>target</em>")
+	assert.True(t, len(text) < len(content))
+	assert.Equal(t, "...", text[:3])
+	assert.Equal(t, "...", text[len(text)-3:])
+
+	// 没有匹配时截取开头
+	text, _ = highlight("abcdefghij", []string{"z"}, 4)
+	assert.Equal(t, "abcd...", text)
+	// 匹配在末尾时截取最后的内容
+	text, _ = highlight("abcdefghij", []string{"j"}, 4)
+	assert.Equal(t, "...ghi<em>j</em>", text)
+	// 窗口边缘的匹配只标记窗口内的部分
+	text, _ = highlight("abcdefghij", []string{"cdefgh"}, 4)
+	assert.Equal(t, "...b<em>cde</em>...", text)
+}
+
+func TestSplitSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"链表", "insert"}, splitSearchTerms(` 链表  Insert "链表" `))
+	assert.Equal(t, []string{}, splitSearchTerms(`  "" `))
+	assert.Equal(t, maxSearchTerms, len(splitSearchTerms("a b c d e f g h i j k l")))
+}
+
+func TestVisibleDocuments(t *testing.T) {
+	document := func(id uint, parentID uint, enable bool) *po.VisualDocument {
+		return &po.VisualDocument{Model: gorm.Model{ID: id}, ParentID: parentID, Enable: enable}
+	}
+	documents := []*po.VisualDocument{
+		document(3, 1, true),
+		document(1, 0, true),
+		document(2, 0, false),
+		// 父文档未启用
+		document(4, 2, true),
+		document(5, 4, true),
+		// 父文档不存在
+		document(6, 100, true),
+		// 目录中存在环
+		document(7, 8, true),
+		document(8, 7, true),
+	}
+	var ids []uint
+	for _, d := range visibleDocuments(documents) {
+		ids = append(ids, d.ID)
+	}
+	assert.Equal(t, []uint{3, 1}, ids)
+}
+
+func TestSubtreeDocumentIDs(t *testing.T) {
+	document := func(id uint, parentID uint) *po.VisualDocument {
+		return &po.VisualDocument{Model: gorm.Model{ID: id}, ParentID: parentID}
+	}
+	documents := []*po.VisualDocument{
+		document(1, 0),
+		document(2, 1),
+		document(3, 2),
+		document(4, 0),
+		// 目录中存在环
+		document(5, 6),
+		document(6, 5),
+	}
+	assert.Equal(t, map[uint]bool{1: true, 2: true, 3: true}, subtreeDocumentIDs(documents, 1))
+	assert.Equal(t, map[uint]bool{5: true, 6: true}, subtreeDocumentIDs(documents, 5))
+	// 文档已经被删除时只包括留下的子文档
+	assert.Equal(t, map[uint]bool{1: true, 2: true, 3: true}, subtreeDocumentIDs(documents[1:], 1))
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"github.com/fansqz/fancode-backend/service/code_revision_service"
 	"github.com/fansqz/fancode-backend/service/common_service"
+	"github.com/fansqz/fancode-backend/service/problem_service"
+	"github.com/fansqz/fancode-backend/service/search_service"
 	"github.com/fansqz/fancode-backend/service/system_service"
 	"github.com/fansqz/fancode-backend/service/user_coding_service"
 	"github.com/fansqz/fancode-backend/service/user_saved_code_service"
@@ -13,15 +16,25 @@ import (
 var ProviderSet = wire.NewSet(
 	common_service.NewAccountService,
 	common_service.NewAuthService,
+	common_service.NewOAuthService,
+	common_service.NewSessionService,
 	common_service.NewCommonService,
+	problem_service.NewProblemService,
+	problem_service.NewProblemBankService,
 	system_service.NewSysApiService,
 	system_service.NewSysMenuService,
 	system_service.NewSysRoleService,
 	system_service.NewSysUserService,
 	user_coding_service.NewUserCodeService,
+	user_coding_service.NewJudgeService,
 	user_saved_code_service.NewUserSavedCodeService,
 	visual_debug_servcie.NewDebugService,
+	visual_debug_servcie.NewDebugAssistService,
 	visual_debug_servcie.NewVisualService,
 	visual_document_service.NewVisualDocumentService,
 	visual_document_service.NewVisualDocumentBankService,
+	visual_document_service.NewVisualDocumentProgressService,
+	visual_document_service.NewVisualDocumentExerciseService,
+	search_service.NewSearchService,
+	code_revision_service.NewCodeRevisionService,
 )
@@ -0,0 +1,466 @@
+package user_coding_service
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common"
+	"github.com/fansqz/fancode-backend/common/artifact_cache"
+	conf "github.com/fansqz/fancode-backend/common/config"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/service/user_coding_service/judger"
+	"github.com/fansqz/fancode-backend/utils"
+	"github.com/fansqz/fancode-backend/utils/gosync"
+	"gorm.io/gorm"
+)
+
+// JudgeService 提交代码并判题
+type JudgeService interface {
+	// Submit 提交代码，判题异步进行，返回提交记录的id
+	Submit(ctx context.Context, req *dto.SubmitRequest) (uint, error)
+	// GetSubmission 获取提交记录详情，包括每个用例的判题结果
+	GetSubmission(ctx context.Context, id uint) (*dto.SubmissionDtoForDetail, error)
+	// GetSubmissionList 获取用户的提交记录列表
+	GetSubmissionList(ctx context.Context, req *dto.SubmissionDtoForQuery) (*dto.PageInfo, error)
+	// Run 使用自定义输入运行代码，同步返回运行结果，相同代码的编译结果会被缓存
+	Run(ctx context.Context, req *dto.RunRequest) (*dto.RunResult, error)
+}
+
+const (
+	// judgeRecoverInterval 检查等待判题和判题中的提交记录的间隔
+	judgeRecoverInterval = time.Minute
+	// pendingRequeueDelay 等待判题超过这个时间的提交记录重新加入判题队列，例如服务重启前没有判题的提交
+	pendingRequeueDelay = time.Minute
+	// judgingStaleTimeout 判题中超过这个时间的提交记录认为判题进程已经退出，标记为系统错误
+	judgingStaleTimeout = 30 * time.Minute
+)
+
+type judgeService struct {
+	config         *conf.AppConfig
+	problemDao     dao.ProblemDao
+	problemCaseDao dao.ProblemCaseDao
+	submissionDao  dao.SubmissionDao
+	// judgeLimiter 限制同时进行判题的数量，运行代码与判题共用
+	judgeLimiter chan struct{}
+	// judgeQueue 等待判题的提交记录，由固定数量的协程处理
+	judgeQueue chan *po.Submission
+	// queued 已经在判题队列中的提交记录id，避免重复加入队列
+	queued        sync.Map
+	artifactCache artifact_cache.Cache
+}
+
+func NewJudgeService(config *conf.AppConfig, problemDao dao.ProblemDao, problemCaseDao dao.ProblemCaseDao,
+	submissionDao dao.SubmissionDao) JudgeService {
+	j := &judgeService{
+		config:         config,
+		problemDao:     problemDao,
+		problemCaseDao: problemCaseDao,
+		submissionDao:  submissionDao,
+		judgeLimiter:   make(chan struct{}, config.JudgeConfig.MaxConcurrent),
+		judgeQueue:     make(chan *po.Submission, config.JudgeConfig.QueueSize),
+		artifactCache:  artifact_cache.New(config.CompileCacheConfig),
+	}
+	for i := 0; i < config.JudgeConfig.MaxConcurrent; i++ {
+		gosync.Go(context.Background(), j.judgeWorker)
+	}
+	gosync.Go(context.Background(), j.recoverSubmissions)
+	return j
+}
+
+func (j *judgeService) Submit(ctx context.Context, req *dto.SubmitRequest) (uint, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if !isSupportLanguage(constants.LanguageType(req.Language)) {
+		return 0, e.ErrLanguageNotSupported
+	}
+	problem, err := j.problemDao.GetProblemByID(common.Mysql, req.ProblemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !problem.Enable) {
+		return 0, e.ErrProblemNotExist
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[Submit] GetProblemByID fail, err = %v", err)
+		return 0, e.ErrProblemGetFailed
+	}
+	cases, err := j.problemCaseDao.GetProblemCasesByProblemID(common.Mysql, problem.ID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[Submit] GetProblemCasesByProblemID fail, err = %v", err)
+		return 0, e.ErrSubmitFailed
+	}
+	if len(cases) == 0 {
+		return 0, e.ErrProblemHasNoCase
+	}
+
+	submission := &po.Submission{
+		UserID:    userID,
+		ProblemID: problem.ID,
+		Language:  req.Language,
+		Code:      req.Code,
+		Status:    constants.Pending,
+		CaseCount: len(cases),
+	}
+	if err = j.submissionDao.InsertSubmission(common.Mysql, submission); err != nil {
+		logger.WithCtx(ctx).Errorf("[Submit] InsertSubmission fail, err = %v", err)
+		return 0, e.ErrSubmitFailed
+	}
+
+	if !j.enqueue(submission) {
+		logger.WithCtx(ctx).Warnf("[Submit] judge queue is full, submissionID = %d", submission.ID)
+		if _, err = j.submissionDao.CompareAndUpdateSubmissionStatus(common.Mysql, submission.ID,
+			constants.Pending, constants.SystemError, e.ErrJudgeQueueFull.Message); err != nil {
+			logger.WithCtx(ctx).Errorf("[Submit] CompareAndUpdateSubmissionStatus fail, err = %v", err)
+		}
+		return 0, e.ErrJudgeQueueFull
+	}
+	return submission.ID, nil
+}
+
+func (j *judgeService) GetSubmission(ctx context.Context, id uint) (*dto.SubmissionDtoForDetail, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	submission, err := j.submissionDao.GetSubmissionByID(common.Mysql, id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("submission not found")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetSubmission] GetSubmissionByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	cases, err := j.submissionDao.GetSubmissionCases(common.Mysql, submission.ID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetSubmission] GetSubmissionCases fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return dto.NewSubmissionDtoForDetail(submission, cases), nil
+}
+
+func (j *judgeService) GetSubmissionList(ctx context.Context, req *dto.SubmissionDtoForQuery) (*dto.PageInfo, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	// 设置默认分页参数
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+	submissions, total, err := j.submissionDao.GetSubmissionList(common.Mysql, userID, req.ProblemID, req.Page, req.PageSize)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetSubmissionList] GetSubmissionList fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	list := make([]*dto.SubmissionDtoForList, 0, len(submissions))
+	for _, submission := range submissions {
+		list = append(list, dto.NewSubmissionDtoForList(submission))
+	}
+	return &dto.PageInfo{
+		Total: total,
+		Size:  int64(len(list)),
+		List:  list,
+	}, nil
+}
+
+// enqueue 提交记录加入判题队列，队列已满时返回false
+func (j *judgeService) enqueue(submission *po.Submission) bool {
+	if _, loaded := j.queued.LoadOrStore(submission.ID, struct{}{}); loaded {
+		return true
+	}
+	select {
+	case j.judgeQueue <- submission:
+		return true
+	default:
+		j.queued.Delete(submission.ID)
+		return false
+	}
+}
+
+// judgeWorker 从判题队列中取出提交记录进行判题
+func (j *judgeService) judgeWorker(ctx context.Context) {
+	for submission := range j.judgeQueue {
+		j.judgeSubmission(submission)
+	}
+}
+
+// judgeSubmission 读取题目和用例并判题，判题出现panic时不影响后续的提交
+func (j *judgeService) judgeSubmission(submission *po.Submission) {
+	// 请求结束以后ctx会被回收，判题使用新的ctx
+	ctx := logger.SetUserID(context.Background(), strconv.Itoa(int(submission.UserID)))
+	defer func() {
+		j.queued.Delete(submission.ID)
+		if err := recover(); err != nil {
+			logger.WithCtx(ctx).Errorf("[judgeSubmission] judge panic, submissionID = %d, err = %v", submission.ID, err)
+		}
+	}()
+	j.judgeLimiter <- struct{}{}
+	defer func() {
+		<-j.judgeLimiter
+	}()
+	// 其他实例可能已经处理了这个提交记录
+	rows, err := j.submissionDao.CompareAndUpdateSubmissionStatus(common.Mysql, submission.ID,
+		constants.Pending, constants.Judging, "")
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[judgeSubmission] CompareAndUpdateSubmissionStatus fail, submissionID = %d, err = %v", submission.ID, err)
+		return
+	}
+	if rows == 0 {
+		return
+	}
+	problem, err := j.problemDao.GetProblemByID(common.Mysql, submission.ProblemID)
+	var cases []*po.ProblemCase
+	if err == nil {
+		cases, err = j.problemCaseDao.GetProblemCasesByProblemID(common.Mysql, problem.ID)
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[judgeSubmission] get problem fail, submissionID = %d, err = %v", submission.ID, err)
+		j.saveSystemError(ctx, submission)
+		return
+	}
+	j.judge(ctx, submission, problem, cases)
+}
+
+// recoverSubmissions 定时检查提交记录，服务重启前等待判题的重新加入队列，判题中断的标记为系统错误
+func (j *judgeService) recoverSubmissions(ctx context.Context) {
+	if common.Mysql == nil {
+		return
+	}
+	ticker := time.NewTicker(judgeRecoverInterval)
+	defer ticker.Stop()
+	for {
+		j.recoverJudgingSubmissions(ctx)
+		j.requeuePendingSubmissions(ctx)
+		<-ticker.C
+	}
+}
+
+func (j *judgeService) recoverJudgingSubmissions(ctx context.Context) {
+	submissions, err := j.submissionDao.GetSubmissionsByStatus(common.Mysql, constants.Judging,
+		time.Now().Add(-judgingStaleTimeout), j.config.JudgeConfig.QueueSize)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[recoverJudgingSubmissions] GetSubmissionsByStatus fail, err = %v", err)
+		return
+	}
+	for _, submission := range submissions {
+		logger.WithCtx(ctx).Warnf("[recoverJudgingSubmissions] judge interrupted, submissionID = %d", submission.ID)
+		if _, err = j.submissionDao.CompareAndUpdateSubmissionStatus(common.Mysql, submission.ID,
+			constants.Judging, constants.SystemError, "判题系统出错，请稍后重试"); err != nil {
+			logger.WithCtx(ctx).Errorf("[recoverJudgingSubmissions] CompareAndUpdateSubmissionStatus fail, err = %v", err)
+		}
+	}
+}
+
+func (j *judgeService) requeuePendingSubmissions(ctx context.Context) {
+	submissions, err := j.submissionDao.GetSubmissionsByStatus(common.Mysql, constants.Pending,
+		time.Now().Add(-pendingRequeueDelay), j.config.JudgeConfig.QueueSize)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[requeuePendingSubmissions] GetSubmissionsByStatus fail, err = %v", err)
+		return
+	}
+	for _, submission := range submissions {
+		// 队列已满时等待下次检查
+		if !j.enqueue(submission) {
+			return
+		}
+	}
+}
+
+// saveSystemError 判题出错时保存为系统错误
+func (j *judgeService) saveSystemError(ctx context.Context, submission *po.Submission) {
+	submission.Status = constants.SystemError
+	submission.ErrorMessage = "判题系统出错，请稍后重试"
+	if err := j.submissionDao.UpdateSubmissionResult(common.Mysql, submission); err != nil {
+		logger.WithCtx(ctx).Errorf("[saveSystemError] UpdateSubmissionResult fail, submissionID = %d, err = %v", submission.ID, err)
+	}
+}
+
+// judge 进行判题并保存判题结果
+func (j *judgeService) judge(ctx context.Context, submission *po.Submission, problem *po.Problem, cases []*po.ProblemCase) {
+	result, err := j.runJudge(ctx, submission, problem, cases)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[judge] judge fail, submissionID = %d, err = %v", submission.ID, err)
+		j.saveSystemError(ctx, submission)
+		return
+	}
+
+	submission.Status = result.Status
+	submission.ErrorMessage = result.ErrorMessage
+	submission.TimeUsed = result.UsedTime / int64(time.Millisecond)
+	submission.MemoryUsed = result.UsedMemory / 1024
+	submission.PassedCount = result.PassedCount
+	submission.Score = result.Score * 100
+	caseNames := make(map[uint]string, len(cases))
+	for _, c := range cases {
+		caseNames[c.ID] = c.Name
+	}
+	submissionCases := make([]*po.SubmissionCase, 0, len(result.Cases))
+	for _, caseResult := range result.Cases {
+		submissionCases = append(submissionCases, &po.SubmissionCase{
+			SubmissionID: submission.ID,
+			CaseID:       caseResult.CaseID,
+			CaseName:     caseNames[caseResult.CaseID],
+			Status:       caseResult.Status,
+			ErrorMessage: caseResult.ErrorMessage,
+			TimeUsed:     caseResult.UsedTime / int64(time.Millisecond),
+			MemoryUsed:   caseResult.UsedMemory / 1024,
+			Score:        caseResult.Score * 100,
+		})
+	}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := j.submissionDao.InsertSubmissionCases(tx, submissionCases); err != nil {
+			return err
+		}
+		return j.submissionDao.UpdateSubmissionResult(tx, submission)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[judge] save judge result fail, submissionID = %d, err = %v", submission.ID, err)
+	}
+}
+
+// runJudge 创建沙箱，编译并运行所有用例
+func (j *judgeService) runJudge(ctx context.Context, submission *po.Submission, problem *po.Problem,
+	cases []*po.ProblemCase) (*judger.JudgeResult, error) {
+	judgeConfig := j.config.JudgeConfig
+	memoryLimit := problem.MemoryLimit << 20
+	// 容器内存需要大于题目的内存限制，超出限制时才能通过实际使用的内存判断
+	containerMemory := judgeConfig.MemoryLimit << 20
+	if containerMemory < memoryLimit*2 {
+		containerMemory = memoryLimit * 2
+	}
+	workPath := path.Join(j.config.TempDir, "judge", utils.GetUUID())
+	sandbox, err := j.newSandbox(ctx, workPath, containerMemory)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := sandbox.Close(ctx); err != nil {
+			logger.WithCtx(ctx).Errorf("[runJudge] close sandbox fail, err = %v", err)
+		}
+	}()
+
+	judgeCases := make([]*judger.JudgeCase, 0, len(cases))
+	for _, c := range cases {
+		judgeCases = append(judgeCases, &judger.JudgeCase{
+			ID:     c.ID,
+			Input:  []byte(c.Input),
+			Output: []byte(c.Output),
+		})
+	}
+	judgeCore := judger.NewJudgeCore(sandbox, workPath)
+	req := &judger.JudgeRequest{
+		Language:         constants.LanguageType(submission.Language),
+		Code:             submission.Code,
+		Cases:            judgeCases,
+		LimitTime:        problem.TimeLimit * int64(time.Millisecond),
+		MemoryLimit:      memoryLimit,
+		OutputLimit:      judgeConfig.OutputLimit << 20,
+		PidsLimit:        judgeConfig.PidsLimit,
+		Isolate:          judgeConfig.Isolate,
+		CompileLimitTime: int64(judgeConfig.CompileTimeout) * int64(time.Second),
+		Checker:          problem.Checker,
+		Epsilon:          problem.CheckerEpsilon,
+	}
+	if problem.Checker == constants.CheckerSpecial {
+		req.CheckerProgram = &judger.Program{
+			Language: constants.LanguageType(problem.CheckerLanguage),
+			Code:     problem.CheckerCode,
+		}
+	}
+	if problem.Interactive {
+		req.Interactor = &judger.Program{
+			Language: constants.LanguageType(problem.InteractorLanguage),
+			Code:     problem.InteractorCode,
+		}
+	}
+	return judgeCore.Judge(ctx, req)
+}
+
+func (j *judgeService) Run(ctx context.Context, req *dto.RunRequest) (*dto.RunResult, error) {
+	judgeConfig := j.config.JudgeConfig
+	if !isSupportLanguage(constants.LanguageType(req.Language)) {
+		return nil, e.ErrLanguageNotSupported
+	}
+	if int64(len(req.Input)) > judgeConfig.RunInputLimit<<10 {
+		return nil, e.ErrRunInputTooLarge
+	}
+	select {
+	case j.judgeLimiter <- struct{}{}:
+	case <-ctx.Done():
+		return nil, e.ErrExecuteFailed
+	}
+	defer func() {
+		<-j.judgeLimiter
+	}()
+
+	memoryLimit := judgeConfig.RunMemoryLimit << 20
+	containerMemory := judgeConfig.MemoryLimit << 20
+	if containerMemory < memoryLimit*2 {
+		containerMemory = memoryLimit * 2
+	}
+	workPath := path.Join(j.config.TempDir, "judge", utils.GetUUID())
+	sandbox, err := j.newSandbox(ctx, workPath, containerMemory)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[Run] create sandbox fail, err = %v", err)
+		return nil, e.ErrExecuteFailed
+	}
+	defer func() {
+		if err := sandbox.Close(ctx); err != nil {
+			logger.WithCtx(ctx).Errorf("[Run] close sandbox fail, err = %v", err)
+		}
+	}()
+
+	judgeCore := judger.NewJudgeCore(sandbox, workPath)
+	result, err := judgeCore.Run(ctx, &judger.RunRequest{
+		Language:         constants.LanguageType(req.Language),
+		Code:             req.Code,
+		Input:            []byte(req.Input),
+		LimitTime:        judgeConfig.RunTimeLimit * int64(time.Millisecond),
+		MemoryLimit:      memoryLimit,
+		OutputLimit:      judgeConfig.OutputLimit << 20,
+		PidsLimit:        judgeConfig.PidsLimit,
+		Isolate:          judgeConfig.Isolate,
+		CompileLimitTime: int64(judgeConfig.CompileTimeout) * int64(time.Second),
+	}, j.artifactCache)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[Run] run fail, err = %v", err)
+		return nil, e.ErrExecuteFailed
+	}
+	return &dto.RunResult{
+		Status:        result.Status,
+		StatusMessage: constants.JudgeStatusMessages[result.Status],
+		ErrorMessage:  result.ErrorMessage,
+		Stdout:        string(result.Stdout),
+		Stderr:        string(result.Stderr),
+		ExitCode:      result.ExitCode,
+		TimeUsed:      result.UsedTime / int64(time.Millisecond),
+		MemoryUsed:    result.UsedMemory / 1024,
+		Cached:        result.Cached,
+	}, nil
+}
+
+// newSandbox 根据配置创建判题沙箱，containerMemory为docker容器的内存上限
+func (j *judgeService) newSandbox(ctx context.Context, workPath string, containerMemory int64) (judger.Sandbox, error) {
+	judgeConfig := j.config.JudgeConfig
+	if judgeConfig.Sandbox == constants.SandboxLocal {
+		return judger.NewLocalSandbox(workPath), nil
+	}
+	return judger.NewDockerSandbox(ctx, &judger.SandboxOptions{
+		Image:       j.config.DebuggerImage,
+		MemoryLimit: containerMemory,
+		CPUQuota:    judgeConfig.CPUQuota,
+		PidsLimit:   judgeConfig.ContainerPidsLimit,
+	})
+}
+
+// isSupportLanguage 判断是否支持该语言
+func isSupportLanguage(language constants.LanguageType) bool {
+	for _, l := range constants.SupportLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package user_coding_service
+
+import (
+	"testing"
+
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestJudgeService_Enqueue(t *testing.T) {
+	j := &judgeService{judgeQueue: make(chan *po.Submission, 1)}
+	first := &po.Submission{Model: gorm.Model{ID: 1}}
+	assert.True(t, j.enqueue(first))
+	// 已经在队列中的提交记录不会重复加入
+	assert.True(t, j.enqueue(first))
+	assert.Len(t, j.judgeQueue, 1)
+	// 队列已满时拒绝
+	assert.False(t, j.enqueue(&po.Submission{Model: gorm.Model{ID: 2}}))
+
+	<-j.judgeQueue
+	j.queued.Delete(first.ID)
+	assert.True(t, j.enqueue(&po.Submission{Model: gorm.Model{ID: 2}}))
+}
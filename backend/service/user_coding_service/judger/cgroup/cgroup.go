@@ -1,79 +1,272 @@
 package cgroup
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 const (
-	cgCPUPathPrefix    = "/sys/fs/cgroup/cpu/"
-	cgMemoryPathPrefix = "/sys/fs/cgroup/memory/"
+	// DefaultRoot cgroup文件系统的挂载位置
+	DefaultRoot = "/sys/fs/cgroup"
+	// cpuPeriod cpu配额的周期，单位微秒，与cgroup v1的默认值一致
+	cpuPeriod = 100000
 )
 
+// Version cgroup的版本
+type Version int
+
+const (
+	// V1 每种资源一个层级，例如/sys/fs/cgroup/memory/
+	V1 Version = 1
+	// V2 统一层级，通过cgroup.controllers判断
+	V2 Version = 2
+)
+
+// v1Controllers cgroup v1中需要用到的控制器
+var v1Controllers = []string{"cpu", "cpuacct", "memory", "pids"}
+
+// v2Controllers cgroup v2中子cgroup需要父cgroup开启的控制器
+var v2Controllers = []string{"cpu", "memory", "pids"}
+
+// CGroup 对用户代码的内存、cpu和进程数做限制，并统计资源使用情况
 type CGroup struct {
-	containerID string
+	root    string
+	name    string
+	version Version
+}
+
+// DetectVersion 判断root下挂载的cgroup版本，统一层级的根目录下存在cgroup.controllers
+func DetectVersion(root string) Version {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return V2
+	}
+	return V1
 }
 
 /**
- * Cgroup 对用户代码的内存和cpu做限制
+ * NewCGroup 在默认位置创建cgroup，自动判断cgroup版本
  */
-func NewCGroup(containerID string) (*CGroup, error) {
-	preFixs := []string{cgCPUPathPrefix, cgMemoryPathPrefix}
-	for _, prefix := range preFixs {
-		cgroupDir := filepath.Join(prefix, containerID)
-		if err := os.MkdirAll(cgroupDir, os.ModePerm); err != nil {
+func NewCGroup(name string) (*CGroup, error) {
+	return NewCGroupWithRoot(DefaultRoot, name)
+}
+
+// NewCGroupWithRoot 在指定的cgroup挂载位置创建cgroup
+func NewCGroupWithRoot(root string, name string) (*CGroup, error) {
+	c := &CGroup{
+		root:    root,
+		name:    name,
+		version: DetectVersion(root),
+	}
+	if c.version == V2 {
+		// 统一层级中子cgroup需要父cgroup开启对应的控制器才能使用
+		if err := enableControllers(root, v2Controllers); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range c.dirs() {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			c.Release()
 			return nil, err
 		}
 	}
-	return &CGroup{
-		containerID: containerID,
-	}, nil
+	return c, nil
+}
+
+// Version 获取cgroup的版本
+func (c *CGroup) Version() Version {
+	return c.version
 }
 
 // AddPID 添加进程到cgroup组
 func (c *CGroup) AddPID(pid int) error {
-	preFixs := []string{cgCPUPathPrefix, cgMemoryPathPrefix}
-	for _, prefix := range preFixs {
-		cgroupDir := filepath.Join(prefix, c.containerID)
-		path := filepath.Join(cgroupDir, "tasks")
-		if err := os.WriteFile(path, []byte(fmt.Sprintf("%d", pid)), 0644); err != nil {
-			c.Release()
-			fmt.Println(err)
+	for _, dir := range c.dirs() {
+		path := filepath.Join(dir, "cgroup.procs")
+		if err := os.WriteFile(path, []byte(strconv.Itoa(pid)), 0644); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// SetCPUQuota 设置CPU配额
+// SetCPUQuota 设置CPU配额，100000表示一个cpu
 func (c *CGroup) SetCPUQuota(quota int64) error {
-	cgroupDir := filepath.Join(cgCPUPathPrefix, c.containerID)
-	cpuQuotaFile := filepath.Join(cgroupDir, "cpu.cfs_quota_us")
-	if err := os.WriteFile(cpuQuotaFile, []byte(fmt.Sprintf("%d", quota)), 0644); err != nil {
+	if c.version == V2 {
+		return c.write("cpu", "cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod))
+	}
+	if err := c.write("cpu", "cpu.cfs_period_us", strconv.Itoa(cpuPeriod)); err != nil {
 		return err
 	}
-	return nil
+	return c.write("cpu", "cpu.cfs_quota_us", strconv.FormatInt(quota, 10))
 }
 
-// SetMemoryLimit 设置内存限制
+// SetMemoryLimit 设置内存限制，同时禁止使用swap
 func (c *CGroup) SetMemoryLimit(limit int64) error {
-	cgroupDir := filepath.Join(cgMemoryPathPrefix, c.containerID)
-	memoryLimitFile := filepath.Join(cgroupDir, "memory.limit_in_bytes")
-	if err := os.WriteFile(memoryLimitFile, []byte(fmt.Sprintf("%d", limit)), 0644); err != nil {
+	value := strconv.FormatInt(limit, 10)
+	if c.version == V2 {
+		if err := c.write("memory", "memory.max", value); err != nil {
+			return err
+		}
+		// 未开启swap时不存在该文件
+		if err := c.write("memory", "memory.swap.max", "0"); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := c.write("memory", "memory.limit_in_bytes", value); err != nil {
+		return err
+	}
+	if err := c.write("memory", "memory.memsw.limit_in_bytes", value); err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
 	return nil
 }
 
-func (c *CGroup) Release() error {
-	preFixs := []string{cgCPUPathPrefix, cgMemoryPathPrefix}
-	for _, prefix := range preFixs {
-		cgroupDir := filepath.Join(prefix, c.containerID)
-		err := os.Remove(cgroupDir)
+// SetPidsLimit 设置进程数限制，防止fork炸弹
+func (c *CGroup) SetPidsLimit(limit int64) error {
+	return c.write("pids", "pids.max", strconv.FormatInt(limit, 10))
+}
+
+// MemoryPeak 获取cgroup中进程的内存使用峰值，单位字节
+func (c *CGroup) MemoryPeak() (int64, error) {
+	if c.version == V2 {
+		return c.readInt("memory", "memory.peak")
+	}
+	return c.readInt("memory", "memory.max_usage_in_bytes")
+}
+
+// CPUUsage 获取cgroup中进程使用的cpu时间，单位纳秒
+func (c *CGroup) CPUUsage() (int64, error) {
+	if c.version == V1 {
+		return c.readInt("cpuacct", "cpuacct.usage")
+	}
+	content, err := c.read("cpu", "cpu.stat")
+	if err != nil {
+		return 0, err
+	}
+	usage, err := statValue(content, "usage_usec")
+	return usage * 1000, err
+}
+
+// OOMKilled 判断cgroup中是否有进程因为内存超出限制被杀死
+func (c *CGroup) OOMKilled() (bool, error) {
+	if c.version == V1 {
+		content, err := c.read("memory", "memory.oom_control")
 		if err != nil {
+			return false, err
+		}
+		count, err := statValue(content, "oom_kill")
+		return count > 0, err
+	}
+	content, err := c.read("memory", "memory.events")
+	if err != nil {
+		return false, err
+	}
+	count, err := statValue(content, "oom_kill")
+	return count > 0, err
+}
+
+// KillAll 杀死cgroup中剩余的进程，进程可能在杀死的过程中继续创建子进程，所以需要多次检查
+func (c *CGroup) KillAll() error {
+	for i := 0; i < 100; i++ {
+		content, err := c.read("pids", "cgroup.procs")
+		if err != nil {
+			return err
+		}
+		pids := strings.Fields(content)
+		if len(pids) == 0 {
+			return nil
+		}
+		for _, p := range pids {
+			if pid, err := strconv.Atoi(p); err == nil {
+				_ = syscall.Kill(pid, syscall.SIGKILL)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("kill processes in cgroup %s timeout", c.name)
+}
+
+// Release 删除cgroup，cgroup中的进程需要已经退出
+func (c *CGroup) Release() error {
+	var err error
+	for _, dir := range c.dirs() {
+		if e := os.Remove(dir); e != nil && !errors.Is(e, os.ErrNotExist) {
+			err = e
+		}
+	}
+	return err
+}
+
+// dirs cgroup对应的目录，v1每个控制器一个目录
+func (c *CGroup) dirs() []string {
+	if c.version == V2 {
+		return []string{filepath.Join(c.root, c.name)}
+	}
+	dirs := make([]string, 0, len(v1Controllers))
+	for _, controller := range v1Controllers {
+		dirs = append(dirs, filepath.Join(c.root, controller, c.name))
+	}
+	return dirs
+}
+
+// file 控制器中的文件路径
+func (c *CGroup) file(controller string, filename string) string {
+	if c.version == V2 {
+		return filepath.Join(c.root, c.name, filename)
+	}
+	return filepath.Join(c.root, controller, c.name, filename)
+}
+
+func (c *CGroup) write(controller string, filename string, value string) error {
+	return os.WriteFile(c.file(controller, filename), []byte(value), 0644)
+}
+
+func (c *CGroup) read(controller string, filename string) (string, error) {
+	content, err := os.ReadFile(c.file(controller, filename))
+	return string(content), err
+}
+
+func (c *CGroup) readInt(controller string, filename string) (int64, error) {
+	content, err := c.read(controller, filename)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(content), 10, 64)
+}
+
+// enableControllers 在root的cgroup.subtree_control中开启控制器，已经开启的控制器不会重复写入
+func enableControllers(root string, controllers []string) error {
+	path := filepath.Join(root, "cgroup.subtree_control")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	enabled := strings.Fields(string(content))
+	for _, controller := range controllers {
+		if slices.Contains(enabled, controller) {
+			continue
+		}
+		if err = os.WriteFile(path, []byte("+"+controller), 0644); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// statValue 读取"key value"格式的统计文件中key对应的值
+func statValue(content string, key string) (int64, error) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			return strconv.ParseInt(fields[1], 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("%s not found", key)
+}
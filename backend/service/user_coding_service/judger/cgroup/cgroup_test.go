@@ -0,0 +1,107 @@
+package cgroup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	return string(content)
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestCGroupV2(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "cgroup.controllers"), "cpu memory pids")
+	writeFile(t, filepath.Join(root, "cgroup.subtree_control"), "cpu memory\n")
+	c, err := NewCGroupWithRoot(root, "judge")
+	assert.Nil(t, err)
+	assert.Equal(t, V2, c.Version())
+	// 已经开启的控制器不会重复写入
+	assert.Equal(t, "+pids", readFile(t, filepath.Join(root, "cgroup.subtree_control")))
+
+	dir := filepath.Join(root, "judge")
+	assert.Nil(t, c.SetCPUQuota(50000))
+	assert.Nil(t, c.SetMemoryLimit(64<<20))
+	assert.Nil(t, c.SetPidsLimit(32))
+	assert.Nil(t, c.AddPID(100))
+	assert.Equal(t, "50000 100000", readFile(t, filepath.Join(dir, "cpu.max")))
+	assert.Equal(t, "67108864", readFile(t, filepath.Join(dir, "memory.max")))
+	assert.Equal(t, "32", readFile(t, filepath.Join(dir, "pids.max")))
+	assert.Equal(t, "100", readFile(t, filepath.Join(dir, "cgroup.procs")))
+
+	writeFile(t, filepath.Join(dir, "memory.peak"), "1048576\n")
+	writeFile(t, filepath.Join(dir, "cpu.stat"), "usage_usec 1500\nuser_usec 1000\nsystem_usec 500\n")
+	writeFile(t, filepath.Join(dir, "memory.events"), "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n")
+	peak, err := c.MemoryPeak()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1048576), peak)
+	usage, err := c.CPUUsage()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1500000), usage)
+	killed, err := c.OOMKilled()
+	assert.Nil(t, err)
+	assert.True(t, killed)
+}
+
+func TestCGroupV1(t *testing.T) {
+	root := t.TempDir()
+	c, err := NewCGroupWithRoot(root, "judge")
+	assert.Nil(t, err)
+	assert.Equal(t, V1, c.Version())
+	for _, controller := range v1Controllers {
+		assert.DirExists(t, filepath.Join(root, controller, "judge"))
+	}
+
+	assert.Nil(t, c.SetCPUQuota(50000))
+	assert.Nil(t, c.SetMemoryLimit(64<<20))
+	assert.Nil(t, c.SetPidsLimit(32))
+	assert.Equal(t, "50000", readFile(t, filepath.Join(root, "cpu", "judge", "cpu.cfs_quota_us")))
+	assert.Equal(t, "67108864", readFile(t, filepath.Join(root, "memory", "judge", "memory.limit_in_bytes")))
+	assert.Equal(t, "32", readFile(t, filepath.Join(root, "pids", "judge", "pids.max")))
+
+	writeFile(t, filepath.Join(root, "memory", "judge", "memory.max_usage_in_bytes"), "2048\n")
+	writeFile(t, filepath.Join(root, "cpuacct", "judge", "cpuacct.usage"), "123456\n")
+	writeFile(t, filepath.Join(root, "memory", "judge", "memory.oom_control"), "oom_kill_disable 0\nunder_oom 0\noom_kill 0\n")
+	peak, err := c.MemoryPeak()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2048), peak)
+	usage, err := c.CPUUsage()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(123456), usage)
+	killed, err := c.OOMKilled()
+	assert.Nil(t, err)
+	assert.False(t, killed)
+}
+
+func TestCGroup_KillAll(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "cgroup.controllers"), "cpu memory pids")
+	writeFile(t, filepath.Join(root, "cgroup.subtree_control"), "cpu memory pids")
+	c, err := NewCGroupWithRoot(root, "judge")
+	assert.Nil(t, err)
+	procs := filepath.Join(root, "judge", "cgroup.procs")
+	writeFile(t, procs, "")
+	assert.Nil(t, c.KillAll())
+
+	// 普通文件中的进程号不会被内核移除，杀死进程后会因为等待超时返回错误
+	cmd := exec.Command("sleep", "10")
+	assert.Nil(t, cmd.Start())
+	writeFile(t, procs, strconv.Itoa(cmd.Process.Pid)+"\n")
+	assert.NotNil(t, c.KillAll())
+	err = cmd.Wait()
+	exitErr, ok := err.(*exec.ExitError)
+	assert.True(t, ok)
+	assert.Equal(t, syscall.SIGKILL, exitErr.Sys().(syscall.WaitStatus).Signal())
+}
@@ -0,0 +1,177 @@
+package judger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fansqz/fancode-backend/constants"
+)
+
+const (
+	checkerDir          = "checker"
+	interactorDir       = "interactor"
+	answerFile          = "answer"
+	checkerOutputFile   = "checker_output"
+	checkerErrorFile    = "checker_error"
+	interactorErrorFile = "interactor_error"
+	// checkerLimitTime 特判程序的时间限制
+	checkerLimitTime = 10 * time.Second
+	// checkerMemoryLimit 特判程序的内存限制
+	checkerMemoryLimit = 1 << 30
+)
+
+// testlib约定的退出码
+const (
+	testlibOK            = 0
+	testlibWrongAnswer   = 1
+	testlibPresentation  = 2
+	testlibFail          = 3
+	testlibPartialPoints = 7
+)
+
+// compileProgram 编译特判程序或交互程序，返回编译产物的路径
+func (j *JudgeCore) compileProgram(ctx context.Context, dir string, program *Program, limitTime int64) (string, error) {
+	if program.Language != constants.LanguageC && program.Language != constants.LanguageCPP {
+		return "", fmt.Errorf("unsupported %s language: %s", dir, program.Language)
+	}
+	programPath := path.Join(j.workPath, dir)
+	output, exitCode, err := j.sandbox.Exec(ctx, []string{"mkdir", "-p", programPath})
+	if err != nil {
+		return "", err
+	}
+	if exitCode != 0 {
+		return "", fmt.Errorf("create %s path fail, output = %s", dir, output)
+	}
+	filename, _ := mainFileName(program.Language)
+	if err = j.sandbox.WriteFile(ctx, programPath, filename, []byte(program.Code)); err != nil {
+		return "", err
+	}
+	compileResult, err := j.Compile(ctx, []string{path.Join(programPath, filename)}, path.Join(programPath, dir), &CompileOptions{
+		Language:  program.Language,
+		LimitTime: limitTime,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !compileResult.Compiled {
+		return "", fmt.Errorf("compile %s fail: %s", dir, compileResult.ErrorMessage)
+	}
+	return compileResult.CompiledFilePath, nil
+}
+
+// check 判断用户程序的输出是否正确，特判程序需要用例的输入文件和用户输出文件已经在工作目录中
+func (j *JudgeCore) check(ctx context.Context, req *JudgeRequest, checkerFile string, judgeCase *JudgeCase,
+	output []byte) (*Verdict, error) {
+	var same bool
+	var position int
+	switch req.Checker {
+	case constants.CheckerSpecial:
+		return j.runChecker(ctx, checkerFile, judgeCase.Output)
+	case constants.CheckerIgnoreWhitespace:
+		same, position = CompareTokens(judgeCase.Output, output)
+	case constants.CheckerFloat:
+		epsilon := req.Epsilon
+		if epsilon <= 0 {
+			epsilon = constants.DefaultFloatEpsilon
+		}
+		same, position = CompareFloat(judgeCase.Output, output, epsilon)
+	default:
+		if same, position = CompareOutput(judgeCase.Output, output); !same {
+			return &Verdict{
+				Status:       constants.WrongAnswer,
+				ErrorMessage: fmt.Sprintf("第%d行输出与预期输出不一致", position),
+			}, nil
+		}
+	}
+	if !same {
+		return &Verdict{
+			Status:       constants.WrongAnswer,
+			ErrorMessage: fmt.Sprintf("第%d个输出与预期输出不一致", position),
+		}, nil
+	}
+	return &Verdict{Status: constants.Accepted, Score: 1}, nil
+}
+
+// runChecker 运行特判程序
+func (j *JudgeCore) runChecker(ctx context.Context, checkerFile string, answer []byte) (*Verdict, error) {
+	if err := j.sandbox.WriteFile(ctx, j.workPath, answerFile, answer); err != nil {
+		return nil, err
+	}
+	inputPath := path.Join(j.workPath, inputFile)
+	cmd := j.runnerCommand(
+		strconv.FormatInt(int64(checkerLimitTime/time.Millisecond), 10),
+		strconv.FormatInt(checkerMemoryLimit, 10),
+		"0",
+		inputPath,
+		path.Join(j.workPath, checkerOutputFile),
+		path.Join(j.workPath, checkerErrorFile),
+		checkerFile, inputPath, path.Join(j.workPath, outputFile), path.Join(j.workPath, answerFile),
+	)
+	output, exitCode, err := j.sandbox.Exec(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	usage := &runnerResult{}
+	if exitCode != 0 || json.Unmarshal([]byte(lastLine(output)), usage) != nil {
+		return nil, fmt.Errorf("runner fail, exitCode = %d, output = %s", exitCode, output)
+	}
+	if usage.Killed || usage.Signal != 0 {
+		return &Verdict{Status: constants.SystemError, ErrorMessage: "特判程序运行出错"}, nil
+	}
+	message, err := j.sandbox.ReadFile(ctx, path.Join(j.workPath, checkerErrorFile))
+	if err != nil {
+		return nil, err
+	}
+	return parseVerdict(usage.ExitCode, string(message)), nil
+}
+
+// interactVerdict 根据交互程序的运行结果给出判题结果
+func interactVerdict(usage *runnerResult, message string) *Verdict {
+	// 用户程序提前退出时交互程序写入管道会收到SIGPIPE
+	if usage.InteractorSignal == int(syscall.SIGPIPE) {
+		return &Verdict{Status: constants.WrongAnswer, ErrorMessage: "程序提前结束了交互"}
+	}
+	if usage.InteractorSignal != 0 {
+		return &Verdict{Status: constants.SystemError, ErrorMessage: "交互程序运行出错"}
+	}
+	return parseVerdict(usage.InteractorExitCode, message)
+}
+
+// parseVerdict 根据testlib约定的退出码解析特判程序的判题结果
+// 部分得分时错误输出以"points <得分>"开头，得分在0到1之间
+func parseVerdict(exitCode int, message string) *Verdict {
+	message = strings.TrimSpace(message)
+	if len(message) > errorMessageLimit {
+		message = message[:errorMessageLimit] + "..."
+	}
+	switch exitCode {
+	case testlibOK:
+		return &Verdict{Status: constants.Accepted, Score: 1, ErrorMessage: message}
+	case testlibWrongAnswer, testlibPresentation:
+		return &Verdict{Status: constants.WrongAnswer, ErrorMessage: message}
+	case testlibPartialPoints:
+		fields := strings.Fields(message)
+		if len(fields) >= 2 && fields[0] == "points" {
+			score, err := strconv.ParseFloat(fields[1], 64)
+			if err == nil && score >= 0 && score <= 1 {
+				verdict := &Verdict{Status: constants.PartiallyAccepted, Score: score, ErrorMessage: message}
+				if score == 1 {
+					verdict.Status = constants.Accepted
+				} else if score == 0 {
+					verdict.Status = constants.WrongAnswer
+				}
+				return verdict
+			}
+		}
+		return &Verdict{Status: constants.SystemError, ErrorMessage: "特判程序给出的得分不合法"}
+	default:
+		// testlibFail以及其他退出码都表示特判程序自身出错
+		return &Verdict{Status: constants.SystemError, ErrorMessage: "特判程序运行出错"}
+	}
+}
@@ -0,0 +1,33 @@
+package judger
+
+import (
+	"testing"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		exitCode int
+		message  string
+		status   int
+		score    float64
+	}{
+		{0, "ok answer is correct", constants.Accepted, 1},
+		{1, "wrong answer expected 3, found 4", constants.WrongAnswer, 0},
+		{2, "presentation error", constants.WrongAnswer, 0},
+		{3, "fail", constants.SystemError, 0},
+		{7, "points 0.5 half of the answers are correct", constants.PartiallyAccepted, 0.5},
+		{7, "points 1", constants.Accepted, 1},
+		{7, "points 0", constants.WrongAnswer, 0},
+		{7, "points 2", constants.SystemError, 0},
+		{7, "half", constants.SystemError, 0},
+		{-1, "", constants.SystemError, 0},
+	}
+	for _, test := range tests {
+		verdict := parseVerdict(test.exitCode, test.message)
+		assert.Equal(t, test.status, verdict.Status, test.message)
+		assert.Equal(t, test.score, verdict.Score, test.message)
+	}
+}
@@ -0,0 +1,73 @@
+package judger
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+)
+
+// CompareOutput 比较程序输出与预期输出，忽略行末空白和末尾空行
+// 不一致时返回第一个不一致的行号，从1开始
+func CompareOutput(expected []byte, actual []byte) (bool, int) {
+	expectedLines := splitLines(expected)
+	actualLines := splitLines(actual)
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		if i >= len(expectedLines) || i >= len(actualLines) {
+			return false, i + 1
+		}
+		if !bytes.Equal(expectedLines[i], actualLines[i]) {
+			return false, i + 1
+		}
+	}
+	return true, 0
+}
+
+// CompareTokens 按空白切分后逐个比较，忽略所有空白的差异
+// 不一致时返回第一个不一致的输出项序号，从1开始
+func CompareTokens(expected []byte, actual []byte) (bool, int) {
+	return compareTokens(expected, actual, bytes.Equal)
+}
+
+// CompareFloat 按空白切分后逐个比较，两项都是数字时绝对误差或相对误差不超过epsilon即视为相同
+// 不一致时返回第一个不一致的输出项序号，从1开始
+func CompareFloat(expected []byte, actual []byte, epsilon float64) (bool, int) {
+	return compareTokens(expected, actual, func(e []byte, a []byte) bool {
+		if bytes.Equal(e, a) {
+			return true
+		}
+		x, err1 := strconv.ParseFloat(string(e), 64)
+		y, err2 := strconv.ParseFloat(string(a), 64)
+		if err1 != nil || err2 != nil || math.IsNaN(x) || math.IsNaN(y) {
+			return false
+		}
+		diff := math.Abs(x - y)
+		return diff <= epsilon || diff <= epsilon*math.Abs(x)
+	})
+}
+
+func compareTokens(expected []byte, actual []byte, equal func([]byte, []byte) bool) (bool, int) {
+	expectedTokens := bytes.Fields(expected)
+	actualTokens := bytes.Fields(actual)
+	for i := 0; i < len(expectedTokens) || i < len(actualTokens); i++ {
+		if i >= len(expectedTokens) || i >= len(actualTokens) {
+			return false, i + 1
+		}
+		if !equal(expectedTokens[i], actualTokens[i]) {
+			return false, i + 1
+		}
+	}
+	return true, 0
+}
+
+// splitLines 按行切分，去除每行末尾的空白以及末尾的空行
+func splitLines(content []byte) [][]byte {
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimRight(line, " \t\r")
+	}
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,69 @@
+package judger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareOutput(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		same     bool
+		line     int
+	}{
+		{"3\n", "3", true, 0},
+		{"1 2\n3 4\n", "1 2  \r\n3 4\n\n\n", true, 0},
+		{"1 2\n3 4\n", "1 2\n3 5\n", false, 2},
+		{"1\n2\n", "1\n", false, 2},
+		{"1\n", "1\n2\n", false, 2},
+		{"", "\n\n", true, 0},
+		{" 1", "1", false, 1},
+	}
+	for _, test := range tests {
+		same, line := CompareOutput([]byte(test.expected), []byte(test.actual))
+		assert.Equal(t, test.same, same, test.expected)
+		assert.Equal(t, test.line, line, test.expected)
+	}
+}
+
+func TestCompareTokens(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		same     bool
+		position int
+	}{
+		{"1 2\n3\n", "1\n2   3", true, 0},
+		{"1 2 3", " 1 2 3 \n\n", true, 0},
+		{"1 2 3", "1 2 4", false, 3},
+		{"1 2", "1 2 3", false, 3},
+	}
+	for _, test := range tests {
+		same, position := CompareTokens([]byte(test.expected), []byte(test.actual))
+		assert.Equal(t, test.same, same, test.expected)
+		assert.Equal(t, test.position, position, test.expected)
+	}
+}
+
+func TestCompareFloat(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		same     bool
+		position int
+	}{
+		{"0.3333333", "0.33333334", true, 0},
+		{"1000000.0", "1000000.5", true, 0},
+		{"3.14159", "3.1416", false, 1},
+		{"yes 1.5", "yes 1.5000000001", true, 0},
+		{"yes 1.5", "no 1.5", false, 1},
+		{"1.5", "nan", false, 1},
+	}
+	for _, test := range tests {
+		same, position := CompareFloat([]byte(test.expected), []byte(test.actual), 1e-6)
+		assert.Equal(t, test.same, same, test.expected)
+		assert.Equal(t, test.position, position, test.expected)
+	}
+}
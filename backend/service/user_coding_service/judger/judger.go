@@ -1,64 +1,257 @@
 package judger
 
 import (
-	"bytes"
 	"context"
-	"errors"
-	"os/exec"
+	"encoding/json"
+	"fmt"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/service/user_coding_service/judger/cgroup"
-	"github.com/fansqz/fancode-backend/utils"
 )
 
+const (
+	inputFile  = "input"
+	outputFile = "output"
+	errorFile  = "error"
+	// 隔离编译时编译器的标准输出和标准错误
+	compileOutputFile = "compile_output"
+	compileErrorFile  = "compile_error"
+	// errorMessageLimit 异常信息中保留的程序错误输出长度
+	errorMessageLimit = 1024
+)
+
+// 运行器的隔离配置，与runner包中的profiles对应
+const (
+	isolateC       = "c"
+	isolateGo      = "go"
+	isolateJava    = "java"
+	isolateCompile = "compile"
+)
+
+// compileFlags 各语言编译时使用的参数，同时作为编译产物缓存key的一部分
+var compileFlags = map[constants.LanguageType][]string{
+	constants.LanguageC:    {"-O2", "-std=gnu11"},
+	constants.LanguageCPP:  {"-O2", "-std=gnu++17"},
+	constants.LanguageJava: {"-encoding", "UTF-8"},
+}
+
+// runnerResult 运行器输出的运行结果
+type runnerResult struct {
+	ExitCode int   `json:"exitCode"`
+	Signal   int   `json:"signal"`
+	CPUTime  int64 `json:"cpuTime"`  // 单位毫秒
+	WallTime int64 `json:"wallTime"` // 单位毫秒
+	Memory   int64 `json:"memory"`   // 单位字节，使用cgroup时为内存峰值
+	Killed   bool  `json:"killed"`   // 是否因为墙上时间超时被杀死
+	// OOMKilled 是否因为超出cgroup的内存上限被杀死
+	OOMKilled bool `json:"oomKilled"`
+	// CGroup 统计资源使用情况的cgroup版本，为0时使用rusage统计
+	CGroup int `json:"cgroup"`
+	// 交互程序的退出码和信号，只在交互模式下有效
+	InteractorExitCode int `json:"interactorExitCode"`
+	InteractorSignal   int `json:"interactorSignal"`
+}
+
+// JudgeCore 判题核心，编译和运行都在沙箱中进行
 type JudgeCore struct {
+	sandbox  Sandbox
+	workPath string // 沙箱中的工作目录
+}
+
+func NewJudgeCore(sandbox Sandbox, workPath string) *JudgeCore {
+	return &JudgeCore{
+		sandbox:  sandbox,
+		workPath: workPath,
+	}
+}
+
+// Prepare 创建工作目录
+func (j *JudgeCore) Prepare(ctx context.Context) error {
+	output, exitCode, err := j.sandbox.Exec(ctx, []string{"mkdir", "-p", j.workPath})
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("create work path fail, output = %s", output)
+	}
+	return nil
 }
 
-func NewJudgeCore() *JudgeCore {
-	return &JudgeCore{}
+// runnerCommand 在沙箱中启动运行器的命令
+func (j *JudgeCore) runnerCommand(args ...string) []string {
+	return append(j.sandbox.RunnerCommand(), args...)
 }
 
-// Compile 编译，编译时在容器外进行编译的
-// compileFiles第个文件是main文件
-func (j *JudgeCore) Compile(compileFiles []string, outFilePath string, options *CompileOptions) (*CompileResult, error) {
+// Judge 编译用户代码，并依次运行所有测试用例
+func (j *JudgeCore) Judge(ctx context.Context, req *JudgeRequest) (*JudgeResult, error) {
+	if err := j.Prepare(ctx); err != nil {
+		return nil, err
+	}
+	filename, ok := mainFileName(req.Language)
+	if !ok {
+		return &JudgeResult{Status: constants.CompileError, ErrorMessage: "不支持该语言\n"}, nil
+	}
+	if err := j.sandbox.WriteFile(ctx, j.workPath, filename, []byte(req.Code)); err != nil {
+		return nil, err
+	}
+
+	// 编译
+	excludedPaths := []string{j.workPath + "/"}
+	compileResult, err := j.Compile(ctx, []string{path.Join(j.workPath, filename)}, path.Join(j.workPath, "main"), &CompileOptions{
+		Language:      req.Language,
+		LimitTime:     req.CompileLimitTime,
+		Isolate:       req.Isolate,
+		ExcludedPaths: excludedPaths,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !compileResult.Compiled {
+		return &JudgeResult{Status: constants.CompileError, ErrorMessage: compileResult.ErrorMessage}, nil
+	}
+
+	// 编译特判程序和交互程序，编译失败属于题目配置错误
+	var checkerFile, interactorFile string
+	if req.Checker == constants.CheckerSpecial {
+		if req.CheckerProgram == nil {
+			return nil, fmt.Errorf("checker program is required")
+		}
+		if checkerFile, err = j.compileProgram(ctx, checkerDir, req.CheckerProgram, req.CompileLimitTime); err != nil {
+			return nil, err
+		}
+	}
+	if req.Interactor != nil {
+		if interactorFile, err = j.compileProgram(ctx, interactorDir, req.Interactor, req.CompileLimitTime); err != nil {
+			return nil, err
+		}
+	}
+
+	// 运行所有用例，未通过的用例不会中断判题
+	result := &JudgeResult{Status: constants.Accepted, Cases: make([]*CaseResult, 0, len(req.Cases))}
+	var totalScore float64
+	for _, judgeCase := range req.Cases {
+		executeResult, err := j.Execute(ctx, compileResult.CompiledFilePath, judgeCase.Input, &ExecuteOptions{
+			Language:      req.Language,
+			LimitTime:     req.LimitTime,
+			MemoryLimit:   req.MemoryLimit,
+			OutputLimit:   req.OutputLimit,
+			PidsLimit:     req.PidsLimit,
+			ExcludedPaths: excludedPaths,
+			Interactor:    interactorFile,
+			Isolate:       req.Isolate,
+		})
+		if err != nil {
+			return nil, err
+		}
+		caseResult := &CaseResult{
+			CaseID:       judgeCase.ID,
+			Status:       executeResult.Status,
+			ErrorMessage: executeResult.ErrorMessage,
+			UsedTime:     executeResult.UsedCpuTime,
+			UsedMemory:   executeResult.UsedMemory,
+		}
+		if executeResult.Status == constants.RunSuccess {
+			verdict := executeResult.Verdict
+			// 交互题由交互程序判断，交互通过并且有特判程序时再由特判程序检查交互程序的输出
+			if verdict == nil || (verdict.Status == constants.Accepted && req.Checker == constants.CheckerSpecial) {
+				if verdict, err = j.check(ctx, req, checkerFile, judgeCase, executeResult.Output); err != nil {
+					return nil, err
+				}
+			}
+			caseResult.Status = verdict.Status
+			caseResult.Score = verdict.Score
+			caseResult.ErrorMessage = verdict.ErrorMessage
+		}
+
+		result.Cases = append(result.Cases, caseResult)
+		totalScore += caseResult.Score
+		if caseResult.Status == constants.Accepted {
+			result.PassedCount++
+		} else if result.Status == constants.Accepted {
+			result.Status = caseResult.Status
+			result.ErrorMessage = caseResult.ErrorMessage
+		}
+		if caseResult.UsedTime > result.UsedTime {
+			result.UsedTime = caseResult.UsedTime
+		}
+		if caseResult.UsedMemory > result.UsedMemory {
+			result.UsedMemory = caseResult.UsedMemory
+		}
+	}
+	if len(result.Cases) != 0 {
+		result.Score = totalScore / float64(len(result.Cases))
+	}
+	return result, nil
+}
+
+// Compile 编译，在沙箱中进行
+// compileFiles第一个文件是main文件，java语言输出的是class文件所在的目录
+func (j *JudgeCore) Compile(ctx context.Context, compileFiles []string, outFilePath string, options *CompileOptions) (*CompileResult, error) {
 	result := &CompileResult{
 		Compiled:         false,
 		ErrorMessage:     "",
 		CompiledFilePath: "",
 	}
 
-	// 进行编译
-	var cmd *exec.Cmd
-	var ctx context.Context
+	var cmds [][]string
 	language := j.getLanguage(options)
 	switch language {
+	case constants.LanguageC:
+		cmd := append(append([]string{"gcc"}, compileFlags[language]...), "-o", outFilePath)
+		cmds = [][]string{append(append(cmd, compileFiles...), "-lm")}
+	case constants.LanguageCPP:
+		cmd := append(append([]string{"g++"}, compileFlags[language]...), "-o", outFilePath)
+		cmds = [][]string{append(cmd, compileFiles...)}
 	case constants.LanguageGo:
-		compileFiles = append([]string{"build", "-gcflags", "-l -N", "-o", outFilePath}, compileFiles...)
-		var cancel context.CancelFunc
-		if options != nil && options.LimitTime != 0 {
-			ctx, cancel = context.WithTimeout(context.Background(), time.Duration(options.LimitTime))
-			defer cancel()
-		} else {
-			ctx = context.Background()
+		cmd := append(append([]string{"go", "build"}, compileFlags[language]...), "-o", outFilePath)
+		cmds = [][]string{append(cmd, compileFiles...)}
+	case constants.LanguageJava:
+		cmd := append(append([]string{"javac"}, compileFlags[language]...), "-d", outFilePath)
+		cmds = [][]string{
+			{"mkdir", "-p", outFilePath},
+			append(cmd, compileFiles...),
 		}
-		cmd = exec.CommandContext(ctx, "go", compileFiles...)
 	default:
 		result.ErrorMessage = "不支持该语言\n"
 		return result, nil
 	}
-	cmd.Stdout = &bytes.Buffer{}
-	cmd.Stderr = &bytes.Buffer{}
 
-	var err error
-	if err = cmd.Start(); err == nil {
-		err = cmd.Wait()
-	}
-	if err != nil {
-		return j.setErrMessageForCompileResult(ctx, cmd, err, result, options)
+	for _, cmd := range cmds {
+		var output string
+		var exitCode int
+		var err error
+		if options != nil && options.Isolate {
+			output, exitCode, err = j.compileIsolated(ctx, cmd, options.LimitTime)
+		} else {
+			// 使用timeout限制编译时间
+			if options != nil && options.LimitTime != 0 {
+				seconds := (time.Duration(options.LimitTime) + time.Second - 1) / time.Second
+				cmd = append([]string{"timeout", "-s", "KILL", strconv.Itoa(int(seconds))}, cmd...)
+			}
+			output, exitCode, err = j.sandbox.Exec(ctx, cmd)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if exitCode == 0 {
+			continue
+		}
+		if options != nil && len(options.ExcludedPaths) != 0 {
+			output = j.maskPath(output, options.ExcludedPaths, options.ReplacementPath)
+		}
+		// timeout发送SIGKILL时退出码为137
+		if exitCode == 128+int(syscall.SIGKILL) {
+			output = "编译超时\n" + output
+		}
+		result.ErrorMessage = output
+		return result, nil
 	}
 
 	result.Compiled = true
@@ -66,32 +259,225 @@ func (j *JudgeCore) Compile(compileFiles []string, outFilePath string, options *
 	return result, nil
 }
 
-func (j *JudgeCore) getLanguage(options *CompileOptions) constants.LanguageType {
-	language := constants.LanguageC
-	if options != nil && options.Language != "" {
-		language = options.Language
+// compileIsolated 通过运行器在命名空间中执行编译命令，返回编译器的输出和与timeout一致的退出码
+func (j *JudgeCore) compileIsolated(ctx context.Context, cmd []string, limitTime int64) (string, int, error) {
+	runnerCmd := j.runnerCommand(
+		"--isolate", isolateCompile, j.workPath,
+		strconv.FormatInt(limitTime/int64(time.Millisecond), 10), "0", "0",
+		"/dev/null",
+		path.Join(j.workPath, compileOutputFile),
+		path.Join(j.workPath, compileErrorFile),
+	)
+	output, exitCode, err := j.sandbox.Exec(ctx, append(runnerCmd, cmd...))
+	if err != nil {
+		return "", 0, err
 	}
-	return language
+	usage := &runnerResult{}
+	if exitCode != 0 || json.Unmarshal([]byte(lastLine(output)), usage) != nil {
+		return "", 0, fmt.Errorf("runner fail, exitCode = %d, output = %s", exitCode, output)
+	}
+	if usage.ExitCode == 0 && usage.Signal == 0 {
+		return "", 0, nil
+	}
+	stdout, err := j.sandbox.ReadFile(ctx, path.Join(j.workPath, compileOutputFile))
+	if err != nil {
+		return "", 0, err
+	}
+	stderr, err := j.sandbox.ReadFile(ctx, path.Join(j.workPath, compileErrorFile))
+	if err != nil {
+		return "", 0, err
+	}
+	switch {
+	case usage.Killed || usage.Signal == int(syscall.SIGXCPU):
+		exitCode = 128 + int(syscall.SIGKILL)
+	case usage.Signal != 0:
+		exitCode = 128 + usage.Signal
+	default:
+		exitCode = usage.ExitCode
+	}
+	return string(stdout) + string(stderr), exitCode, nil
 }
 
-func (j *JudgeCore) setErrMessageForCompileResult(ctx context.Context, cmd *exec.Cmd, err error, result *CompileResult, options *CompileOptions) (*CompileResult, error) {
+// Execute 运行，每次运行一个输入，资源使用情况由沙箱中的运行器统计
+func (j *JudgeCore) Execute(ctx context.Context, execFile string, input []byte, options *ExecuteOptions) (*ExecuteResult, error) {
+	if options == nil {
+		options = &ExecuteOptions{}
+	}
+	runCmd, err := runCommand(options.Language, execFile, options.MemoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	if err = j.sandbox.WriteFile(ctx, j.workPath, inputFile, input); err != nil {
+		return nil, err
+	}
+
+	cmd := j.runnerCommand()
+	if options.Interactor != "" {
+		cmd = append(cmd, "--interactor", options.Interactor, path.Join(j.workPath, interactorErrorFile))
+	}
+	// 运行器会自动判断cgroup版本，无法创建cgroup时使用rusage统计资源
+	cmd = append(cmd, "--cgroup", cgroup.DefaultRoot)
+	if options.PidsLimit != 0 {
+		cmd = append(cmd, "--pids", strconv.FormatInt(options.PidsLimit, 10))
+	}
+	if options.Isolate {
+		cmd = append(cmd, "--isolate", isolateProfile(options.Language), execFile)
+	}
+	cmd = append(cmd,
+		strconv.FormatInt(options.LimitTime/int64(time.Millisecond), 10),
+		strconv.FormatInt(options.MemoryLimit, 10),
+		strconv.FormatInt(options.OutputLimit, 10),
+		path.Join(j.workPath, inputFile),
+		path.Join(j.workPath, outputFile),
+		path.Join(j.workPath, errorFile),
+	)
+	output, exitCode, err := j.sandbox.Exec(ctx, append(cmd, runCmd...))
 	if err != nil {
-		errBytes := cmd.Stderr.(*bytes.Buffer).Bytes()
-		errMessage := string(errBytes)
+		return nil, err
+	}
+	usage := &runnerResult{}
+	if exitCode != 0 || json.Unmarshal([]byte(lastLine(output)), usage) != nil {
+		return nil, fmt.Errorf("runner fail, exitCode = %d, output = %s", exitCode, output)
+	}
+
+	result := &ExecuteResult{
+		ExitCode:    usage.ExitCode,
+		UsedTime:    usage.WallTime * int64(time.Millisecond),
+		UsedMemory:  usage.Memory,
+		UsedCpuTime: usage.CPUTime * int64(time.Millisecond),
+	}
+	result.Status, result.ErrorMessage = checkStatus(usage, options)
+	// 交互程序结束交互后用户程序写入管道会收到SIGPIPE，此时以交互程序的结果为准
+	if options.Interactor != "" && !usage.Killed && usage.Signal == int(syscall.SIGPIPE) {
+		result.Status, result.ErrorMessage = constants.RunSuccess, ""
+	}
+	// 需要保留输出时，无论运行结果如何都读取标准输出和标准错误
+	if options.CaptureOutput {
+		if result.Output, err = j.sandbox.ReadFile(ctx, path.Join(j.workPath, outputFile)); err != nil {
+			return nil, err
+		}
+		if result.Stderr, err = j.sandbox.ReadFile(ctx, path.Join(j.workPath, errorFile)); err != nil {
+			return nil, err
+		}
+	}
+	if result.Status == constants.RunSuccess {
+		result.Executed = true
+		if !options.CaptureOutput {
+			if result.Output, err = j.sandbox.ReadFile(ctx, path.Join(j.workPath, outputFile)); err != nil {
+				return nil, err
+			}
+		}
+		if options.Interactor != "" {
+			message, err := j.sandbox.ReadFile(ctx, path.Join(j.workPath, interactorErrorFile))
+			if err != nil {
+				return nil, err
+			}
+			result.Verdict = interactVerdict(usage, string(message))
+		}
+		return result, nil
+	}
+
+	// 运行出错时附带程序的错误输出
+	if result.Status == constants.RuntimeError {
+		errOutput, err := j.sandbox.ReadFile(ctx, path.Join(j.workPath, errorFile))
+		if err != nil {
+			return nil, err
+		}
+		errMessage := string(errOutput)
+		if len(errMessage) > errorMessageLimit {
+			errMessage = errMessage[:errorMessageLimit] + "..."
+		}
 		if len(options.ExcludedPaths) != 0 {
-			errMessage = j.maskPath(string(errBytes), options.ExcludedPaths, options.ReplacementPath)
+			errMessage = j.maskPath(errMessage, options.ExcludedPaths, options.ReplacementPath)
 		}
-		// 如果是由于超时导致的错误，则返回自定义错误
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			result.ErrorMessage = "编译超时\n" + errMessage
-			return result, nil
+		if errMessage != "" {
+			result.ErrorMessage += "\n" + errMessage
 		}
-		if len(errBytes) != 0 {
-			result.ErrorMessage = errMessage
-			return result, nil
+	}
+	return result, nil
+}
+
+// checkStatus 根据运行器的结果判断运行状态
+func checkStatus(usage *runnerResult, options *ExecuteOptions) (int, string) {
+	// seccomp拦截系统调用时会以SIGSYS杀死进程
+	if usage.Signal == int(syscall.SIGSYS) {
+		return constants.SecurityViolation, "程序使用了被禁止的系统调用"
+	}
+	if usage.Killed || usage.Signal == int(syscall.SIGXCPU) ||
+		(options.LimitTime != 0 && usage.CPUTime*int64(time.Millisecond) > options.LimitTime) {
+		return constants.TimeLimitExceeded, "运行超时"
+	}
+	if usage.OOMKilled || (options.MemoryLimit != 0 && usage.Memory > options.MemoryLimit) {
+		return constants.MemoryLimitExceeded, "内存超出限制"
+	}
+	if usage.Signal == int(syscall.SIGXFSZ) {
+		return constants.RuntimeError, "输出超出限制"
+	}
+	if usage.Signal != 0 {
+		return constants.RuntimeError, fmt.Sprintf("程序异常终止：%s", syscall.Signal(usage.Signal).String())
+	}
+	if usage.ExitCode != 0 {
+		return constants.RuntimeError, fmt.Sprintf("程序退出码为%d", usage.ExitCode)
+	}
+	return constants.RunSuccess, ""
+}
+
+// runCommand 运行编译产物的命令
+func runCommand(language constants.LanguageType, execFile string, memoryLimit int64) ([]string, error) {
+	switch language {
+	case constants.LanguageC, constants.LanguageCPP, constants.LanguageGo:
+		return []string{execFile}, nil
+	case constants.LanguageJava:
+		cmd := []string{"java", "-Xss64m"}
+		if memoryLimit != 0 {
+			cmd = append(cmd, fmt.Sprintf("-Xmx%dm", (memoryLimit+(1<<20)-1)>>20))
 		}
+		return append(cmd, "-cp", execFile, "Main"), nil
+	default:
+		return nil, fmt.Errorf("不支持该语言")
+	}
+}
+
+// isolateProfile 运行器中隔离用户程序使用的系统调用白名单
+func isolateProfile(language constants.LanguageType) string {
+	switch language {
+	case constants.LanguageGo:
+		return isolateGo
+	case constants.LanguageJava:
+		return isolateJava
+	default:
+		return isolateC
+	}
+}
+
+// mainFileName 用户代码的文件名
+func mainFileName(language constants.LanguageType) (string, bool) {
+	switch language {
+	case constants.LanguageC:
+		return "main.c", true
+	case constants.LanguageCPP:
+		return "main.cpp", true
+	case constants.LanguageGo:
+		return "main.go", true
+	case constants.LanguageJava:
+		return "Main.java", true
+	default:
+		return "", false
+	}
+}
+
+// lastLine 读取输出的最后一个非空行
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func (j *JudgeCore) getLanguage(options *CompileOptions) constants.LanguageType {
+	language := constants.LanguageC
+	if options != nil && options.Language != "" {
+		language = options.Language
 	}
-	return nil, err
+	return language
 }
 
 // maskPath 函数用于屏蔽错误消息中的路径信息
@@ -116,138 +502,3 @@ func (j *JudgeCore) maskPath(errorMessage string, excludedPaths []string, replac
 
 	return errorMessage
 }
-
-// Execute 运行
-func (j *JudgeCore) Execute(execFile string, inputCh <-chan []byte, outputCh chan<- ExecuteResult, exitCh <-chan string, options *ExecuteOptions) error {
-	//language := constants.LanguageC
-	//if options != nil && options.Language != "" {
-	//	language = options.Language
-	//}
-	//// 根据扩展名设置执行命令
-	//cmdName := ""
-	//cmdArg := []string{}
-	//switch language {
-	//case constants.LanguageC:
-	//	cmdName = execFile
-	//case constants.LanguageJava:
-	//	cmdName = "java"
-	//	cmdArg = []string{"-jar", execFile}
-	//case constants.LanguageGo:
-	//	cmdName = execFile
-	//default:
-	//	return fmt.Errorf("不支持该语言")
-	//}
-	//
-	//// 创建cgroup限制资源
-	//cgroup, err := j.initCGroup(options)
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//go func() {
-	//	defer func() {
-	//		if err := recover(); err != nil {
-	//			fmt.Println("defer", err)
-	//		}
-	//	}()
-	//	for {
-	//		select {
-	//		case inputItem := <-inputCh:
-	//
-	//			// 设置超时上下文
-	//			var ctx context.Context
-	//			var cancel context.CancelFunc
-	//			if options != nil && options.LimitTime != 0 {
-	//				ctx, cancel = context.WithTimeout(context.Background(), time.Duration(options.LimitTime)+100)
-	//				defer cancel()
-	//			} else {
-	//				ctx = context.Background()
-	//			}
-	//
-	//			// 创建子进程，并将其加入cgroup
-	//			cmd2 := exec.CommandContext(ctx, cmdName, cmdArg...)
-	//			result := ExecuteResult{}
-	//
-	//			cmd2.Stdin = bytes.NewReader(inputItem)
-	//			cmd2.Stdout = &bytes.Buffer{}
-	//			cmd2.Stderr = &bytes.Buffer{}
-	//			cmd2.SysProcAttr = &syscall.SysProcAttr{
-	//				Setpgid: true,
-	//			}
-	//
-	//			beginTime := time.Now()
-	//			if err = cmd2.Start(); err != nil {
-	//				result.Executed = false
-	//				result.ErrorMessage = err.Error() + "\n"
-	//				outputCh <- result
-	//				break
-	//			}
-	//
-	//			// 将进程写入cgroup组
-	//			if err = cgroup.AddPID(cmd2.Process.Pid); err != nil {
-	//				result.Executed = false
-	//				result.ErrorMessage = err.Error() + "\n"
-	//				outputCh <- result
-	//				break
-	//			}
-	//
-	//			// 等待程序执行
-	//			cmd2.Wait()
-	//			// 读取使用cpu和内存，以及执行时间
-	//			rusage := cmd2.ProcessState.SysUsage().(*syscall.Rusage)
-	//			result.UsedCpuTime = rusage.Utime.Sec*1000 + rusage.Utime.Usec/1000
-	//			result.UsedMemory = rusage.Maxrss * 1024
-	//			result.UsedTime = int64(time.Now().Sub(beginTime))
-	//
-	//			// 输出的错误信息
-	//			errMessage := string(cmd2.Stderr.(*bytes.Buffer).Bytes())
-	//			if options != nil && len(options.ExcludedPaths) != 0 {
-	//				errMessage = j.maskPath(errMessage, options.ExcludedPaths, options.ReplacementPath)
-	//			}
-	//			outMessage := cmd2.Stdout.(*bytes.Buffer).Bytes()
-	//			// 检测内存占用，cpu占用，以及执行时间
-	//			if options != nil && options.LimitTime < result.UsedTime {
-	//				result.Executed = false
-	//				result.ErrorMessage = "运行超时\n"
-	//			} else if options != nil && options.MemoryLimit < result.UsedMemory {
-	//				result.Executed = false
-	//				result.ErrorMessage = "内存超出限制\n"
-	//			} else if options != nil && options.CPUQuota < result.UsedCpuTime {
-	//				result.Executed = false
-	//				result.ErrorMessage = "cpu超出限制\n"
-	//			} else if len(errMessage) != 0 {
-	//				result.Executed = false
-	//				result.ErrorMessage = errMessage
-	//			} else {
-	//				result.Executed = true
-	//				result.Output = outMessage
-	//			}
-	//			outputCh <- result
-	//		case <-exitCh:
-	//			cgroup.Release()
-	//			return
-	//		}
-	//	}
-	//}()
-
-	return nil
-}
-
-func (j *JudgeCore) initCGroup(options *ExecuteOptions) (*cgroup.CGroup, error) {
-	// 创建cgroup限制资源
-	cgroup, err := cgroup.NewCGroup(utils.GetUUID())
-	if err != nil {
-		return nil, err
-	}
-	if options != nil && options.MemoryLimit != 0 {
-		if err = cgroup.SetMemoryLimit(options.MemoryLimit); err != nil {
-			return nil, err
-		}
-	}
-	if options != nil && options.CPUQuota != 0 {
-		if err = cgroup.SetCPUQuota(options.CPUQuota); err != nil {
-			return nil, err
-		}
-	}
-	return cgroup, nil
-}
@@ -1,142 +1,427 @@
 package judger
 
-//func TestJudgeCore_Execute(t *testing.T) {
-//	execute(constants.LanguageC, t)
-//	execute(constants.LanguageJava, t)
-//}
-
-//func execute(language constants.LanguageType, t *testing.T) {
-//	judgeCore := NewJudgeCore()
-//	// 程序进行编译
-//	input := make(chan []byte)
-//	output := make(chan ExecuteResult)
-//	exitCh := make(chan string)
-//
-//	// 编译
-//	var compileFiles []string
-//	switch language {
-//	case constants.LanguageC:
-//		compileFiles = []string{"./test_file/test_execute.c"}
-//	case constants.LanguageJava:
-//		compileFiles = []string{"./test_file/test_execute.java"}
-//	}
-//	_, err := judgeCore.Compile(compileFiles, "./test_file/test_execute", &CompileOptions{
-//		Language:  language,
-//		LimitTime: int64(1000 * time.Second),
-//	})
-//	if err != nil {
-//		assert.NilError(t, err)
-//		return
-//	}
-//	defer os.Remove("./test_file/test_execute")
-//
-//	// 运行
-//	executeOption := &ExecuteOptions{
-//		Language:    language,
-//		LimitTime:   int64(10 * time.Second),
-//		MemoryLimit: 100 * 1024 * 1024 * 1024,
-//		CPUQuota:    100000,
-//	}
-//
-//	err = judgeCore.Execute("./test_file/test_execute", input, output, exitCh, executeOption)
-//	if err != nil {
-//		assert.NilError(t, err)
-//		return
-//	}
-//
-//	// 输入数据
-//	defer func() {
-//		exitCh <- "exit"
-//	}()
-//	for i := 0; i < 10; i++ {
-//		a := rand.Int() % 100
-//		b := rand.Int() % 100
-//		input <- []byte(strconv.Itoa(a) + " " + strconv.Itoa(b))
-//		select {
-//		case result := <-output:
-//			assert.Equal(t, true, result.Executed)
-//			assert.Equal(t, strconv.Itoa(a+b)+"\n", string(result.Output))
-//		}
-//	}
-//}
-
-//func TestJudgeCore_Timeout(t *testing.T) {
-//	judgeCore := NewJudgeCore()
-//	// 程序进行编译
-//	input := make(chan []byte)
-//	output := make(chan ExecuteResult)
-//	exitCh := make(chan string)
-//
-//	// 编译
-//	_, err := judgeCore.Compile([]string{"./test_file/test_timeout.c"}, "./test_file/test_timeout",
-//		&CompileOptions{LimitTime: int64(2 * time.Second)})
-//	assert.NilError(t, err)
-//	defer func() {
-//		// 删除文件
-//		err = os.Remove("./test_file/test_timeout")
-//		assert.NilError(t, err)
-//		exitCh <- "exit"
-//	}()
-//
-//	executeOption := &ExecuteOptions{
-//		Language:    constants.LanguageC,
-//		LimitTime:   int64(1 * time.Second),
-//		MemoryLimit: 1 * 1024 * 1024, //限制1m
-//		CPUQuota:    10000,           //限制cpu
-//	}
-//	err = judgeCore.Execute("./test_file/test_timeout", input, output, exitCh, executeOption)
-//	assert.NilError(t, err)
-//	input <- []byte("1 2")
-//	select {
-//	case result := <-output:
-//		assert.Equal(t, false, result.Executed)
-//		assert.Equal(t, "运行超时\n", result.ErrorMessage)
-//	}
-//}
-
-//func TestJudgeCore_MemoryOut(t *testing.T) {
-//	judgeCore := NewJudgeCore()
-//	// 程序进行编译
-//	input := make(chan []byte)
-//	output := make(chan ExecuteResult)
-//	exitCh := make(chan string)
-//
-//	// 编译
-//	_, err := judgeCore.Compile([]string{"./test_file/test_memory_limit.c"}, "./test_file/test_memory_limit",
-//		&CompileOptions{LimitTime: int64(2 * time.Second)})
-//	assert.NilError(t, err)
-//	defer func() {
-//		// 删除文件
-//		err = os.Remove("./test_file/test_memory_limit")
-//		assert.NilError(t, err)
-//		exitCh <- "exit"
-//	}()
-//
-//	executeOption := &ExecuteOptions{
-//		Language:    constants.LanguageC,
-//		LimitTime:   int64(1 * time.Second),
-//		MemoryLimit: 1 * 1024 * 1024, //限制1m
-//		CPUQuota:    10000,           //限制cpu
-//	}
-//	err = judgeCore.Execute("./test_file/test_memory_limit", input, output, exitCh, executeOption)
-//	assert.NilError(t, err)
-//	input <- []byte("1 2")
-//	select {
-//	case result := <-output:
-//		assert.Equal(t, false, result.Executed)
-//		assert.Equal(t, "内存超出限制\n", result.ErrorMessage)
-//	}
-//}
-//
-//func TestJudgeCore_Compile(t *testing.T) {
-//	judgeCore := NewJudgeCore()
-//	// 编译
-//	compileResult, err := judgeCore.Compile([]string{"./test_file/test_compile_err.c"}, "./test_file/test_compile_err",
-//		&CompileOptions{
-//			LimitTime:     int64(2 * time.Second),
-//			ExcludedPaths: []string{"./test_file"},
-//		})
-//	assert.NilError(t, err)
-//	assert.Equal(t, false, compileResult.Compiled)
-//	return
-//}
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/service/user_coding_service/judger/runner"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMain 本机沙箱以测试程序作为运行器
+func TestMain(m *testing.M) {
+	runner.Init()
+	os.Exit(m.Run())
+}
+
+func newTestJudgeCore(t *testing.T) *JudgeCore {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc not found")
+	}
+	return NewJudgeCore(NewLocalSandbox(""), t.TempDir())
+}
+
+func readTestFile(t *testing.T, name string) string {
+	content, err := os.ReadFile(filepath.Join("test_file", name))
+	assert.Nil(t, err)
+	return string(content)
+}
+
+func judgeC(t *testing.T, code string, limitTime time.Duration, memoryLimit int64, cases ...*JudgeCase) *JudgeResult {
+	judgeCore := newTestJudgeCore(t)
+	result, err := judgeCore.Judge(context.Background(), &JudgeRequest{
+		Language:         constants.LanguageC,
+		Code:             code,
+		Cases:            cases,
+		LimitTime:        int64(limitTime),
+		MemoryLimit:      memoryLimit,
+		OutputLimit:      1 << 20,
+		CompileLimitTime: int64(10 * time.Second),
+	})
+	assert.Nil(t, err)
+	return result
+}
+
+func TestJudgeCore_Judge(t *testing.T) {
+	result := judgeC(t, readTestFile(t, "test_execute.c"), time.Second, 64<<20,
+		&JudgeCase{ID: 1, Input: []byte("1 2"), Output: []byte("3\n")},
+		&JudgeCase{ID: 2, Input: []byte("2 2"), Output: []byte("5")},
+		&JudgeCase{ID: 3, Input: []byte("10 20\n"), Output: []byte("30  \n\n")},
+	)
+	assert.Equal(t, constants.WrongAnswer, result.Status)
+	assert.Equal(t, 2, result.PassedCount)
+	assert.Equal(t, "第1行输出与预期输出不一致", result.ErrorMessage)
+	assert.Equal(t, 3, len(result.Cases))
+	assert.Equal(t, constants.Accepted, result.Cases[0].Status)
+	assert.Equal(t, constants.WrongAnswer, result.Cases[1].Status)
+	assert.Equal(t, constants.Accepted, result.Cases[2].Status)
+	assert.True(t, result.UsedMemory > 0)
+}
+
+func TestJudgeCore_Timeout(t *testing.T) {
+	result := judgeC(t, readTestFile(t, "test_timeout.c"), 500*time.Millisecond, 64<<20,
+		&JudgeCase{ID: 1, Input: []byte("1 2"), Output: []byte("3")})
+	assert.Equal(t, constants.TimeLimitExceeded, result.Status)
+	assert.Equal(t, "运行超时", result.ErrorMessage)
+
+	busyLoop := `int main() { volatile long i = 0; for (;;) { i++; } return 0; }`
+	result = judgeC(t, busyLoop, 500*time.Millisecond, 64<<20, &JudgeCase{ID: 1})
+	assert.Equal(t, constants.TimeLimitExceeded, result.Status)
+}
+
+func TestJudgeCore_MemoryOut(t *testing.T) {
+	code := `#include <stdio.h>
+#include <stdlib.h>
+int main() {
+	size_t size = 128 * 1024 * 1024;
+	volatile char *memory = malloc(size);
+	for (size_t i = 0; i < size; i += 4096) {
+		memory[i] = 1;
+	}
+	printf("%d\n", memory[size / 2]);
+	return 0;
+}`
+	result := judgeC(t, code, time.Second, 64<<20, &JudgeCase{ID: 1})
+	assert.Equal(t, constants.MemoryLimitExceeded, result.Status)
+	assert.Equal(t, "内存超出限制", result.ErrorMessage)
+}
+
+func TestJudgeCore_RuntimeError(t *testing.T) {
+	code := `#include <stdio.h>
+int main() {
+	int *p = NULL;
+	fprintf(stderr, "before crash\n");
+	fflush(stderr);
+	return *p;
+}`
+	result := judgeC(t, code, time.Second, 64<<20, &JudgeCase{ID: 1})
+	assert.Equal(t, constants.RuntimeError, result.Status)
+	assert.Equal(t, "程序异常终止：segmentation fault\nbefore crash\n", result.ErrorMessage)
+
+	result = judgeC(t, `int main() { return 3; }`, time.Second, 64<<20, &JudgeCase{ID: 1})
+	assert.Equal(t, constants.RuntimeError, result.Status)
+	assert.Equal(t, "程序退出码为3", result.ErrorMessage)
+}
+
+func TestJudgeCore_Compile(t *testing.T) {
+	judgeCore := newTestJudgeCore(t)
+	result, err := judgeCore.Judge(context.Background(), &JudgeRequest{
+		Language:         constants.LanguageC,
+		Code:             readTestFile(t, "test_compile_err.c"),
+		Cases:            []*JudgeCase{{ID: 1}},
+		CompileLimitTime: int64(10 * time.Second),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, constants.CompileError, result.Status)
+	assert.Contains(t, result.ErrorMessage, "main.c:8:23: error")
+	assert.NotContains(t, result.ErrorMessage, judgeCore.workPath)
+	assert.Nil(t, result.Cases)
+}
+
+// sumCode 读取两个整数并输出它们的和
+const sumCode = `#include <stdio.h>
+int main() {
+	int a, b;
+	scanf("%d %d", &a, &b);
+	printf("%d\n", a + b);
+	return 0;
+}`
+
+func judgeRequest(t *testing.T, req *JudgeRequest) *JudgeResult {
+	judgeCore := newTestJudgeCore(t)
+	req.LimitTime = int64(time.Second)
+	req.MemoryLimit = 64 << 20
+	req.OutputLimit = 1 << 20
+	req.CompileLimitTime = int64(10 * time.Second)
+	result, err := judgeCore.Judge(context.Background(), req)
+	assert.Nil(t, err)
+	return result
+}
+
+func TestJudgeCore_SpecialChecker(t *testing.T) {
+	// 输出与答案相差不超过1即为正确，相差不超过10时得一半分数
+	checker := `#include <stdio.h>
+#include <stdlib.h>
+int main(int argc, char *argv[]) {
+	FILE *out = fopen(argv[2], "r");
+	FILE *ans = fopen(argv[3], "r");
+	int x, y;
+	if (fscanf(out, "%d", &x) != 1) {
+		fprintf(stderr, "wrong answer output is empty");
+		return 1;
+	}
+	fscanf(ans, "%d", &y);
+	int diff = abs(x - y);
+	if (diff <= 1) {
+		return 0;
+	}
+	if (diff <= 10) {
+		fprintf(stderr, "points 0.5 too far");
+		return 7;
+	}
+	fprintf(stderr, "wrong answer expected %d, found %d", y, x);
+	return 1;
+}`
+	result := judgeRequest(t, &JudgeRequest{
+		Language:       constants.LanguageC,
+		Code:           sumCode,
+		Checker:        constants.CheckerSpecial,
+		CheckerProgram: &Program{Language: constants.LanguageC, Code: checker},
+		Cases: []*JudgeCase{
+			{ID: 1, Input: []byte("1 2"), Output: []byte("4")},
+			{ID: 2, Input: []byte("1 2"), Output: []byte("10")},
+			{ID: 3, Input: []byte("1 2"), Output: []byte("100")},
+		},
+	})
+	assert.Equal(t, constants.PartiallyAccepted, result.Status)
+	assert.Equal(t, 1, result.PassedCount)
+	assert.Equal(t, constants.Accepted, result.Cases[0].Status)
+	assert.Equal(t, constants.PartiallyAccepted, result.Cases[1].Status)
+	assert.Equal(t, 0.5, result.Cases[1].Score)
+	assert.Equal(t, constants.WrongAnswer, result.Cases[2].Status)
+	assert.Equal(t, "wrong answer expected 100, found 3", result.Cases[2].ErrorMessage)
+	assert.Equal(t, 0.5, result.Score)
+}
+
+func TestJudgeCore_BuiltinChecker(t *testing.T) {
+	code := `#include <stdio.h>
+int main() {
+	printf("%.8f   %.8f\n\n", 1.0 / 3, 2.0 / 3);
+	return 0;
+}`
+	result := judgeRequest(t, &JudgeRequest{
+		Language: constants.LanguageC,
+		Code:     code,
+		Checker:  constants.CheckerFloat,
+		Cases:    []*JudgeCase{{ID: 1, Output: []byte("0.333333\n0.666667")}},
+	})
+	assert.Equal(t, constants.Accepted, result.Status)
+
+	result = judgeRequest(t, &JudgeRequest{
+		Language: constants.LanguageC,
+		Code:     code,
+		Checker:  constants.CheckerIgnoreWhitespace,
+		Cases:    []*JudgeCase{{ID: 1, Output: []byte("0.333333\n0.666667")}},
+	})
+	assert.Equal(t, constants.WrongAnswer, result.Status)
+	assert.Equal(t, "第1个输出与预期输出不一致", result.ErrorMessage)
+}
+
+func TestJudgeCore_Interactor(t *testing.T) {
+	// 交互程序从输入文件读取答案，用户每次猜一个数，交互程序回复大了、小了或者正确
+	interactor := `#include <stdio.h>
+int main(int argc, char *argv[]) {
+	FILE *in = fopen(argv[1], "r");
+	FILE *out = fopen(argv[2], "w");
+	int answer, guess, count = 0;
+	fscanf(in, "%d", &answer);
+	while (scanf("%d", &guess) == 1) {
+		count++;
+		if (count > 20) {
+			fprintf(stderr, "wrong answer too many guesses");
+			return 1;
+		}
+		if (guess == answer) {
+			printf("correct\n");
+			fflush(stdout);
+			fprintf(out, "%d\n", count);
+			return 0;
+		}
+		printf(guess < answer ? "bigger\n" : "smaller\n");
+		fflush(stdout);
+	}
+	fprintf(stderr, "wrong answer unexpected eof");
+	return 1;
+}`
+	binarySearch := `#include <stdio.h>
+#include <string.h>
+int main() {
+	int low = 1, high = 1000000;
+	char reply[16];
+	while (low <= high) {
+		int mid = (low + high) / 2;
+		printf("%d\n", mid);
+		fflush(stdout);
+		scanf("%15s", reply);
+		if (strcmp(reply, "correct") == 0) {
+			return 0;
+		}
+		if (strcmp(reply, "bigger") == 0) {
+			low = mid + 1;
+		} else {
+			high = mid - 1;
+		}
+	}
+	return 0;
+}`
+	cases := []*JudgeCase{{ID: 1, Input: []byte("1")}, {ID: 2, Input: []byte("777777")}}
+	result := judgeRequest(t, &JudgeRequest{
+		Language:   constants.LanguageC,
+		Code:       binarySearch,
+		Interactor: &Program{Language: constants.LanguageC, Code: interactor},
+		Cases:      cases,
+	})
+	assert.Equal(t, constants.Accepted, result.Status)
+	assert.Equal(t, 2, result.PassedCount)
+
+	linearSearch := `#include <stdio.h>
+int main() {
+	char reply[16];
+	for (int i = 1; ; i++) {
+		printf("%d\n", i);
+		fflush(stdout);
+		scanf("%15s", reply);
+		if (reply[0] == 'c') {
+			return 0;
+		}
+	}
+}`
+	result = judgeRequest(t, &JudgeRequest{
+		Language:   constants.LanguageC,
+		Code:       linearSearch,
+		Interactor: &Program{Language: constants.LanguageC, Code: interactor},
+		Cases:      cases,
+	})
+	assert.Equal(t, constants.WrongAnswer, result.Status)
+	assert.Equal(t, 1, result.PassedCount)
+	assert.Equal(t, "wrong answer too many guesses", result.Cases[1].ErrorMessage)
+
+	result = judgeRequest(t, &JudgeRequest{
+		Language:   constants.LanguageC,
+		Code:       `int main() { return 0; }`,
+		Interactor: &Program{Language: constants.LanguageC, Code: interactor},
+		Cases:      cases[:1],
+	})
+	assert.Equal(t, constants.WrongAnswer, result.Status)
+	assert.Equal(t, "wrong answer unexpected eof", result.ErrorMessage)
+}
+
+func TestJudgeCore_ForkBomb(t *testing.T) {
+	code := `#include <stdio.h>
+#include <unistd.h>
+int main() {
+	for (int i = 0; i < 10000; i++) {
+		fork();
+	}
+	return 0;
+}`
+	judgeCore := newTestJudgeCore(t)
+	start := time.Now()
+	result, err := judgeCore.Judge(context.Background(), &JudgeRequest{
+		Language:         constants.LanguageC,
+		Code:             code,
+		Cases:            []*JudgeCase{{ID: 1}},
+		LimitTime:        int64(500 * time.Millisecond),
+		MemoryLimit:      64 << 20,
+		OutputLimit:      1 << 20,
+		PidsLimit:        16,
+		CompileLimitTime: int64(10 * time.Second),
+	})
+	assert.Nil(t, err)
+	assert.NotEqual(t, constants.Accepted, result.Status)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+// judgeIsolated 在命名空间中编译和运行，系统不允许创建user namespace时跳过
+func judgeIsolated(t *testing.T, language constants.LanguageType, code string, cases ...*JudgeCase) *JudgeResult {
+	if err := exec.Command("unshare", "--user", "--map-root-user", "true").Run(); err != nil {
+		t.Skipf("user namespace not supported: %v", err)
+	}
+	judgeCore := newTestJudgeCore(t)
+	result, err := judgeCore.Judge(context.Background(), &JudgeRequest{
+		Language:         language,
+		Code:             code,
+		Cases:            cases,
+		LimitTime:        int64(time.Second),
+		MemoryLimit:      64 << 20,
+		OutputLimit:      1 << 20,
+		PidsLimit:        16,
+		Isolate:          true,
+		CompileLimitTime: int64(30 * time.Second),
+	})
+	assert.Nil(t, err)
+	return result
+}
+
+func TestJudgeCore_Isolate(t *testing.T) {
+	result := judgeIsolated(t, constants.LanguageC, sumCode,
+		&JudgeCase{ID: 1, Input: []byte("1 2"), Output: []byte("3")})
+	assert.Equal(t, constants.Accepted, result.Status)
+
+	// 根目录只读，不包含宿主机的/etc，只有/tmp可写
+	fileCode := `#include <stdio.h>
+int main() {
+	printf("%d %d %d\n", fopen("/etc/passwd", "r") != NULL, fopen("/usr/judge", "w") != NULL,
+		fopen("/tmp/judge", "w") != NULL);
+	return 0;
+}`
+	result = judgeIsolated(t, constants.LanguageC, fileCode, &JudgeCase{ID: 1, Output: []byte("0 0 1")})
+	assert.Equal(t, constants.Accepted, result.Status)
+
+	busyLoop := `int main() { volatile long i = 0; for (;;) { i++; } return 0; }`
+	result = judgeIsolated(t, constants.LanguageC, busyLoop, &JudgeCase{ID: 1})
+	assert.Equal(t, constants.TimeLimitExceeded, result.Status)
+}
+
+func TestJudgeCore_IsolateViolation(t *testing.T) {
+	codes := map[string]string{
+		"fork": `#include <unistd.h>
+int main() { fork(); return 0; }`,
+		"socket": `#include <sys/socket.h>
+int main() { return socket(AF_INET, SOCK_STREAM, 0) < 0; }`,
+		"exec": `#include <unistd.h>
+int main() { execl("/bin/sh", "sh", "-c", "true", (char *)NULL); return 0; }`,
+		// 再次执行自身时参数指针与第一次不同也要被拦截
+		"reexec": `#include <unistd.h>
+int main(int argc, char *argv[]) { if (argc < 3) { char *args[] = {argv[0], "a", "b", NULL}; execv(argv[0], args); } return 0; }`,
+	}
+	for name, code := range codes {
+		t.Run(name, func(t *testing.T) {
+			result := judgeIsolated(t, constants.LanguageC, code, &JudgeCase{ID: 1})
+			assert.Equal(t, constants.SecurityViolation, result.Status)
+			assert.Equal(t, "程序使用了被禁止的系统调用", result.ErrorMessage)
+		})
+	}
+}
+
+func TestJudgeCore_IsolateCompile(t *testing.T) {
+	result := judgeIsolated(t, constants.LanguageC, "#include \"/etc/passwd\"\nint main() { return 0; }",
+		&JudgeCase{ID: 1})
+	assert.Equal(t, constants.CompileError, result.Status)
+	assert.Contains(t, result.ErrorMessage, "No such file or directory")
+}
+
+func TestJudgeCore_IsolateGo(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	code := `package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+func main() {
+	var a, b int
+	fmt.Scan(&a, &b)
+	var wg sync.WaitGroup
+	sum := make([]int, 4)
+	for i := range sum {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sum[i] = a + b
+		}()
+	}
+	wg.Wait()
+	fmt.Println(sum[3])
+}`
+	result := judgeIsolated(t, constants.LanguageGo, code, &JudgeCase{ID: 1, Input: []byte("1 2"), Output: []byte("3")})
+	assert.Equal(t, constants.Accepted, result.Status, result.ErrorMessage)
+}
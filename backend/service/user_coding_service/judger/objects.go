@@ -5,9 +5,13 @@ import "github.com/fansqz/fancode-backend/constants"
 // ExecuteOptions 执行文件可选操作
 type ExecuteOptions struct {
 	Language        constants.LanguageType
-	LimitTime       int64 // 资源限制
-	MemoryLimit     int64
-	CPUQuota        int64
+	LimitTime       int64    // cpu时间限制（以纳秒为单位）
+	MemoryLimit     int64    // 内存限制（以字节为单位）
+	OutputLimit     int64    // 输出大小限制（以字节为单位），0表示不限制
+	PidsLimit       int64    // 进程数限制，由运行器通过cgroup限制，0表示不限制
+	Interactor      string   // 交互程序的路径，不为空时用户程序的输入输出与交互程序相连
+	Isolate         bool     // 是否在命名空间中运行，并限制可以使用的系统调用
+	CaptureOutput   bool     // 是否总是读取标准输出和标准错误，运行失败时也保留
 	ExcludedPaths   []string // 屏蔽的敏感路径
 	ReplacementPath string   // 取代敏感路径的路径
 }
@@ -15,17 +19,36 @@ type ExecuteOptions struct {
 // ExecuteResult 程序执行结果
 type ExecuteResult struct {
 	Executed     bool   // 判题是否执行成功
+	Status       int    // 运行状态，RunSuccess、TimeLimitExceeded、MemoryLimitExceeded、RuntimeError或SecurityViolation
 	ErrorMessage string // 异常信息
 	Output       []byte // 输出结果（正常输出结果，如果有）
+	Stderr       []byte // 标准错误，只在CaptureOutput为true时读取
+	ExitCode     int    // 程序退出码
 	UsedTime     int64  // 执行时间（以纳秒为单位）
-	UsedMemory   int64  // 内存使用量（以字节为单位）
-	UsedCpuTime  int64  // cpu使用
+	UsedMemory   int64  // 内存使用峰值（以字节为单位），沙箱支持cgroup时包括所有子进程
+	UsedCpuTime  int64  // cpu使用时间（以纳秒为单位），沙箱支持cgroup时包括所有子进程
+	// Verdict 交互程序给出的判题结果，只在交互模式下有效
+	Verdict *Verdict
+}
+
+// Verdict 特判程序或交互程序给出的判题结果
+type Verdict struct {
+	Status       int     // Accepted、PartiallyAccepted、WrongAnswer或SystemError
+	Score        float64 // 得分，0到1之间
+	ErrorMessage string
+}
+
+// Program 特判程序或者交互程序
+type Program struct {
+	Language constants.LanguageType
+	Code     string
 }
 
 // CompileOptions 编译文件可选参数
 type CompileOptions struct {
 	Language        constants.LanguageType
 	LimitTime       int64
+	Isolate         bool     // 是否在命名空间中编译，编译器只能访问系统目录和工作目录
 	ExcludedPaths   []string // 屏蔽的敏感路径
 	ReplacementPath string   // 取代敏感路径的路径
 }
@@ -36,3 +59,57 @@ type CompileResult struct {
 	ErrorMessage     string // 异常信息
 	CompiledFilePath string // 输出文件路径
 }
+
+// JudgeCase 判题的测试用例
+type JudgeCase struct {
+	ID     uint
+	Input  []byte
+	Output []byte
+}
+
+// JudgeRequest 判题请求
+type JudgeRequest struct {
+	Language    constants.LanguageType
+	Code        string
+	Cases       []*JudgeCase
+	LimitTime   int64 // 每个用例的cpu时间限制（以纳秒为单位）
+	MemoryLimit int64 // 内存限制（以字节为单位）
+	OutputLimit int64 // 输出大小限制（以字节为单位）
+	PidsLimit   int64 // 进程数限制，0表示不限制
+	// Isolate 是否在命名空间中编译和运行用户代码，运行时按语言限制可以使用的系统调用
+	// 特判程序和交互程序由出题人提供，不进行隔离
+	Isolate bool
+	// CompileLimitTime 编译时间限制（以纳秒为单位）
+	CompileLimitTime int64
+	// Checker 判断输出是否正确的方式，取值见constants中的判断方式，为空时逐行比较
+	Checker string
+	// Epsilon 使用浮点数比较时允许的误差
+	Epsilon float64
+	// CheckerProgram 特判程序，Checker为CheckerSpecial时使用
+	// 以"checker <输入文件> <用户输出文件> <答案文件>"的方式运行，退出码与testlib一致
+	CheckerProgram *Program
+	// Interactor 交互程序，不为空时为交互题
+	// 以"interactor <输入文件> <输出文件>"的方式运行，标准输入输出与用户程序相连
+	Interactor *Program
+}
+
+// CaseResult 单个测试用例的判题结果
+type CaseResult struct {
+	CaseID       uint
+	Status       int     // 判题结果
+	ErrorMessage string  // 异常信息
+	Score        float64 // 得分，0到1之间
+	UsedTime     int64   // cpu使用时间（以纳秒为单位）
+	UsedMemory   int64   // 内存使用量（以字节为单位）
+}
+
+// JudgeResult 判题结果
+type JudgeResult struct {
+	Status       int    // 判题结果，所有用例通过时为Accepted，否则为第一个未通过用例的结果
+	ErrorMessage string // 编译错误信息或第一个未通过用例的异常信息
+	Cases        []*CaseResult
+	PassedCount  int     // 通过的用例数量
+	Score        float64 // 所有用例得分的平均值，0到1之间
+	UsedTime     int64   // 所有用例中最长的cpu使用时间（以纳秒为单位）
+	UsedMemory   int64   // 所有用例中最大的内存使用量（以字节为单位）
+}
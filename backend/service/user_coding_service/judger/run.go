@@ -0,0 +1,135 @@
+package judger
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/fansqz/fancode-backend/common/artifact_cache"
+	"github.com/fansqz/fancode-backend/constants"
+)
+
+// RunRequest 使用自定义输入运行代码的请求
+type RunRequest struct {
+	Language    constants.LanguageType
+	Code        string
+	Input       []byte
+	LimitTime   int64 // cpu时间限制（以纳秒为单位）
+	MemoryLimit int64 // 内存限制（以字节为单位）
+	OutputLimit int64 // 输出大小限制（以字节为单位）
+	PidsLimit   int64 // 进程数限制，0表示不限制
+	Isolate     bool  // 是否在命名空间中编译和运行
+	// CompileLimitTime 编译时间限制（以纳秒为单位）
+	CompileLimitTime int64
+}
+
+// RunResult 运行结果
+type RunResult struct {
+	Status       int    // RunSuccess、CompileError、TimeLimitExceeded、MemoryLimitExceeded、RuntimeError或SecurityViolation
+	ErrorMessage string // 编译错误信息或运行异常信息
+	Stdout       []byte
+	Stderr       []byte
+	ExitCode     int
+	UsedTime     int64 // cpu使用时间（以纳秒为单位）
+	UsedWallTime int64 // 墙上时间（以纳秒为单位）
+	UsedMemory   int64 // 内存使用峰值（以字节为单位）
+	Cached       bool  // 是否使用了缓存的编译产物
+}
+
+// ArtifactKey 编译产物的缓存key，由语言、编译参数和代码计算
+func ArtifactKey(language constants.LanguageType, code string) string {
+	return artifact_cache.Key(artifact_cache.ScopeJudge, language, compileFlags[language], code)
+}
+
+// Run 编译并使用自定义输入运行代码，cache不为空时复用相同代码的编译产物
+func (j *JudgeCore) Run(ctx context.Context, req *RunRequest, cache artifact_cache.Cache) (*RunResult, error) {
+	if err := j.Prepare(ctx); err != nil {
+		return nil, err
+	}
+	filename, ok := mainFileName(req.Language)
+	if !ok {
+		return &RunResult{Status: constants.CompileError, ErrorMessage: "不支持该语言\n"}, nil
+	}
+
+	result := &RunResult{}
+	execFile := path.Join(j.workPath, "main")
+	key := ArtifactKey(req.Language, req.Code)
+	if cache != nil {
+		if artifact, ok := cache.Get(ctx, key); ok {
+			restored, err := j.restoreArtifact(ctx, artifact)
+			if err != nil {
+				return nil, err
+			}
+			result.Cached = restored
+		}
+	}
+	if !result.Cached {
+		if err := j.sandbox.WriteFile(ctx, j.workPath, filename, []byte(req.Code)); err != nil {
+			return nil, err
+		}
+		compileResult, err := j.Compile(ctx, []string{path.Join(j.workPath, filename)}, execFile, &CompileOptions{
+			Language:      req.Language,
+			LimitTime:     req.CompileLimitTime,
+			Isolate:       req.Isolate,
+			ExcludedPaths: []string{j.workPath + "/"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !compileResult.Compiled {
+			return &RunResult{Status: constants.CompileError, ErrorMessage: compileResult.ErrorMessage}, nil
+		}
+		if cache != nil {
+			if artifact, err := j.packArtifact(ctx); err == nil && len(artifact) <= artifact_cache.MaxSize {
+				cache.Set(ctx, key, artifact)
+			}
+		}
+	}
+
+	executeResult, err := j.Execute(ctx, execFile, req.Input, &ExecuteOptions{
+		Language:      req.Language,
+		LimitTime:     req.LimitTime,
+		MemoryLimit:   req.MemoryLimit,
+		OutputLimit:   req.OutputLimit,
+		PidsLimit:     req.PidsLimit,
+		Isolate:       req.Isolate,
+		CaptureOutput: true,
+		ExcludedPaths: []string{j.workPath + "/"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Status = executeResult.Status
+	result.ErrorMessage = executeResult.ErrorMessage
+	result.Stdout = executeResult.Output
+	result.Stderr = executeResult.Stderr
+	result.ExitCode = executeResult.ExitCode
+	result.UsedTime = executeResult.UsedCpuTime
+	result.UsedWallTime = executeResult.UsedTime
+	result.UsedMemory = executeResult.UsedMemory
+	return result, nil
+}
+
+// packArtifact 将工作目录中的编译产物打包
+func (j *JudgeCore) packArtifact(ctx context.Context) ([]byte, error) {
+	output, exitCode, err := j.sandbox.Exec(ctx, artifact_cache.PackCommand(j.workPath, "main"))
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("pack artifact fail, output = %s", output)
+	}
+	return j.sandbox.ReadFile(ctx, path.Join(j.workPath, artifact_cache.ArtifactFile))
+}
+
+// restoreArtifact 将缓存的编译产物解压到工作目录，缓存损坏时返回false，需要重新编译
+func (j *JudgeCore) restoreArtifact(ctx context.Context, artifact []byte) (bool, error) {
+	if err := j.sandbox.WriteFile(ctx, j.workPath, artifact_cache.ArtifactFile, artifact); err != nil {
+		return false, err
+	}
+	_, exitCode, err := j.sandbox.Exec(ctx, artifact_cache.UnpackCommand(j.workPath))
+	if err != nil {
+		return false, err
+	}
+	return exitCode == 0, nil
+}
@@ -0,0 +1,81 @@
+package judger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common/artifact_cache"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryArtifactCache map[string][]byte
+
+func (m memoryArtifactCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	artifact, ok := m[key]
+	return artifact, ok
+}
+
+func (m memoryArtifactCache) Set(ctx context.Context, key string, artifact []byte) {
+	m[key] = artifact
+}
+
+func runC(t *testing.T, code string, input string, cache artifact_cache.Cache) *RunResult {
+	judgeCore := newTestJudgeCore(t)
+	result, err := judgeCore.Run(context.Background(), &RunRequest{
+		Language:         constants.LanguageC,
+		Code:             code,
+		Input:            []byte(input),
+		LimitTime:        int64(time.Second),
+		MemoryLimit:      64 << 20,
+		OutputLimit:      1 << 20,
+		CompileLimitTime: int64(10 * time.Second),
+	}, cache)
+	assert.Nil(t, err)
+	return result
+}
+
+func TestJudgeCore_Run(t *testing.T) {
+	code := `#include <stdio.h>
+int main() {
+	int a, b;
+	scanf("%d %d", &a, &b);
+	printf("%d\n", a + b);
+	fprintf(stderr, "debug %d\n", a);
+	return a;
+}`
+	cache := memoryArtifactCache{}
+	result := runC(t, code, "3 4", cache)
+	assert.Equal(t, constants.RuntimeError, result.Status)
+	assert.Equal(t, "7\n", string(result.Stdout))
+	assert.Equal(t, "debug 3\n", string(result.Stderr))
+	assert.Equal(t, 3, result.ExitCode)
+	assert.False(t, result.Cached)
+	assert.Equal(t, 1, len(cache))
+
+	// 相同代码复用编译产物
+	result = runC(t, code, "0 5", cache)
+	assert.Equal(t, constants.RunSuccess, result.Status)
+	assert.Equal(t, "5\n", string(result.Stdout))
+	assert.True(t, result.Cached)
+
+	// 缓存损坏时重新编译
+	for key := range cache {
+		cache[key] = []byte("broken")
+	}
+	result = runC(t, code, "1 1", cache)
+	assert.Equal(t, "2\n", string(result.Stdout))
+	assert.False(t, result.Cached)
+
+	result = runC(t, readTestFile(t, "test_compile_err.c"), "", cache)
+	assert.Equal(t, constants.CompileError, result.Status)
+	assert.NotEmpty(t, result.ErrorMessage)
+}
+
+func TestArtifactKey(t *testing.T) {
+	key := ArtifactKey(constants.LanguageC, "int main() {}")
+	assert.Equal(t, key, ArtifactKey(constants.LanguageC, "int main() {}"))
+	assert.NotEqual(t, key, ArtifactKey(constants.LanguageCPP, "int main() {}"))
+	assert.NotEqual(t, key, ArtifactKey(constants.LanguageC, "int main() { }"))
+}
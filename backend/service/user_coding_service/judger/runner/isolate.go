@@ -0,0 +1,401 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// profileCompile 编译使用的隔离配置
+const profileCompile = "compile"
+
+// 根目录中只读挂载的系统目录和文件，不包括/etc，避免泄露宿主机的配置
+var (
+	systemDirs  = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32"}
+	systemFiles = []string{"/etc/ld.so.cache", "/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+	sandboxEnv  = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=/tmp", "LANG=C.UTF-8"}
+)
+
+// isolation 在新的命名空间中运行用户程序
+// 运行器创建新的user/mount/net等命名空间中的进程并写入uid映射，该进程再创建pid命名空间中的1号进程，
+// 1号进程准备好根目录后创建用户进程，用户进程切换根目录、设置资源限制和seccomp后执行用户程序
+type isolation struct {
+	profile string
+	program string
+	root    string
+	errorR  *os.File // 命名空间中的进程出错时写入错误信息
+	errorW  *os.File
+	statusR *os.File // 1号进程写入用户程序的等待状态
+	statusW *os.File
+}
+
+func newIsolation(profile string, program string) (*isolation, error) {
+	if _, ok := profiles[profile]; !ok {
+		return nil, fmt.Errorf("unknown isolate profile %s", profile)
+	}
+	if syscallNumbers == nil {
+		return nil, errors.New("isolate is not supported on this arch")
+	}
+	program, err := filepath.Abs(program)
+	if err != nil {
+		return nil, err
+	}
+	if program, err = filepath.EvalSymlinks(program); err != nil {
+		return nil, err
+	}
+	root, err := os.MkdirTemp("", "judge-root-")
+	if err != nil {
+		return nil, err
+	}
+	iso := &isolation{profile: profile, program: program, root: root}
+	// 运行用户程序时命名空间中的root映射为nobody，需要能够访问挂载点
+	// 编译时需要写入编译目录，映射为运行器的用户，根目录中只有编译目录可写
+	if err = os.Chmod(root, 0755); err == nil {
+		if iso.errorR, iso.errorW, err = os.Pipe(); err == nil {
+			iso.statusR, iso.statusW, err = os.Pipe()
+		}
+	}
+	if err != nil {
+		iso.release()
+		return nil, err
+	}
+	return iso, nil
+}
+
+// prepare 设置在新的命名空间中启动进程的参数
+func (iso *isolation) prepare(config *stageConfig, attr *syscall.SysProcAttr) {
+	uid, gid := os.Geteuid(), os.Getegid()
+	if uid == 0 && iso.profile != profileCompile {
+		uid, gid = 65534, 65534
+	}
+	attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET |
+		syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: uid, Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: gid, Size: 1}}
+	// 非root用户只能映射自己，并且需要禁止setgroups
+	attr.GidMappingsEnableSetgroups = os.Geteuid() == 0
+	// 子进程需要以原来的用户打开用户程序，此时不是命名空间中的root，exec后会失去能力，
+	// 通过ambient能力保留创建pid命名空间和切换用户需要的能力
+	attr.AmbientCaps = []uintptr{unix.CAP_SYS_ADMIN, unix.CAP_SETUID, unix.CAP_SETGID}
+	config.Profile = iso.profile
+	config.Program = iso.program
+	config.Root = iso.root
+	config.SetGroups = attr.GidMappingsEnableSetgroups
+}
+
+// closeWriters 子进程启动后关闭运行器中的写端，子进程全部退出后读端才能读到EOF
+func (iso *isolation) closeWriters() {
+	iso.errorW.Close()
+	iso.statusW.Close()
+}
+
+// userStatus 用户程序的等待状态，1号进程没有写入时返回false
+func (iso *isolation) userStatus() (syscall.WaitStatus, bool) {
+	return readStatus(iso.statusR)
+}
+
+// errorMessage 命名空间中的进程出错时的错误信息，需要在子进程结束后调用
+func (iso *isolation) errorMessage() string {
+	message, _ := io.ReadAll(iso.errorR)
+	return string(message)
+}
+
+func (iso *isolation) release() {
+	for _, file := range []*os.File{iso.errorR, iso.errorW, iso.statusR, iso.statusW} {
+		if file != nil {
+			file.Close()
+		}
+	}
+	_ = os.Remove(iso.root)
+}
+
+// runNamespace 在新的命名空间中执行，创建pid命名空间中的1号进程并等待其退出
+func runNamespace(config *stageConfig, errorW *os.File, statusW *os.File) error {
+	// 映射后的用户可能无法访问程序所在的目录，切换用户前打开程序，通过文件描述符挂载
+	// 挂载来源需要在新的mount命名空间中，所以在创建命名空间后打开
+	fd, err := unix.Open(config.Program, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", config.Program, err)
+	}
+	program := os.NewFile(uintptr(fd), config.Program)
+	defer program.Close()
+	cmd, err := stageCommand(stageInit, config, []*os.File{os.Stdin, os.Stdout, os.Stderr},
+		[]*os.File{errorW, statusW, program})
+	if err != nil {
+		return err
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWPID,
+		// 切换为映射后的用户，创建命名空间不会改变进程原来的用户
+		Credential: &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: !config.SetGroups},
+		Pdeathsig:  syscall.SIGKILL,
+	}
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("start init process: %w", err)
+	}
+	_ = cmd.Wait()
+	return nil
+}
+
+// runInit 命名空间中的1号进程，1号进程会忽略没有处理函数的信号，所以用户程序需要在子进程中运行
+// 用户程序使用系统调用白名单时，由1号进程处理execve的通知，只允许执行一次
+func runInit(config *stageConfig, errorW *os.File, statusW *os.File, program *os.File) error {
+	path, err := resolvePath(config.Cmd[0])
+	if err != nil {
+		return err
+	}
+	config.Path = path
+	if err = setupRoot(config, program); err != nil {
+		return err
+	}
+	socket, userSocket, err := socketPair()
+	if err != nil {
+		return err
+	}
+	defer socket.Close()
+	cmd, err := stageCommand(stageExec, config, []*os.File{os.Stdin, os.Stdout, os.Stderr},
+		[]*os.File{errorW, userSocket})
+	if err != nil {
+		userSocket.Close()
+		return err
+	}
+	err = cmd.Start()
+	userSocket.Close()
+	if err != nil {
+		return fmt.Errorf("start user process: %w", err)
+	}
+	var violated atomic.Bool
+	if profiles[config.Profile].syscalls != nil {
+		go superviseExec(socket, cmd.Process, &violated)
+	}
+	_ = cmd.Wait()
+	status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if violated.Load() {
+		// 与seccomp直接杀死进程的结果一致
+		status = syscall.WaitStatus(syscall.SIGSYS)
+	}
+	return writeStatus(statusW, status)
+}
+
+// resolvePath 解析用户程序的实际路径，新的根目录中没有/etc，系统目录中指向/etc/alternatives的链接需要提前解析
+func resolvePath(name string) (string, error) {
+	path := name
+	if !strings.Contains(name, "/") {
+		var err error
+		if path, err = exec.LookPath(name); err != nil {
+			return "", err
+		}
+	}
+	// 映射后的用户可能无法访问程序所在的目录，此时使用原来的路径
+	if realPath, err := filepath.EvalSymlinks(path); err == nil {
+		path = realPath
+	}
+	return filepath.Abs(path)
+}
+
+// setupRoot 新的根目录为只读的tmpfs，挂载系统目录、用户程序和可写的/tmp，切换根目录由用户进程完成
+func setupRoot(config *stageConfig, program *os.File) error {
+	root := config.Root
+	if err := mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return err
+	}
+	if err := mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=16m,mode=755"); err != nil {
+		return err
+	}
+	if err := os.Mkdir(root+"/tmp", 0755); err != nil {
+		return err
+	}
+	tmpData := fmt.Sprintf("size=%s,mode=1777", profiles[config.Profile].tmpSize)
+	if err := mount("tmpfs", root+"/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, tmpData); err != nil {
+		return err
+	}
+	if err := os.Mkdir(root+"/proc", 0755); err != nil {
+		return err
+	}
+	// 系统目录中的程序不需要单独挂载，否则会在系统目录中创建挂载点
+	if !inSystemDirs(config.Program) {
+		if err := bind(root, config.Program, "/proc/self/fd/"+strconv.Itoa(int(program.Fd()))); err != nil {
+			return err
+		}
+	}
+	for _, dir := range systemDirs {
+		info, err := os.Lstat(dir)
+		if err != nil {
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(dir)
+			if err != nil {
+				return err
+			}
+			if err = os.Symlink(target, root+dir); err != nil {
+				return err
+			}
+		} else if info.IsDir() {
+			if err = bind(root, dir, dir); err != nil {
+				return err
+			}
+		}
+	}
+	for _, file := range systemFiles {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		if err := bind(root, file, file); err != nil {
+			return err
+		}
+	}
+
+	writable := []string{root + "/tmp"}
+	if config.Profile == profileCompile {
+		writable = append(writable, root+config.Program)
+	}
+	points, err := mountPointsUnder(root)
+	if err != nil {
+		return err
+	}
+	for _, point := range points {
+		if slices.Contains(writable, point) || point == root+"/proc" || strings.HasPrefix(point, root+"/proc/") {
+			continue
+		}
+		// 用户命名空间中重新挂载时需要保留原来的nosuid等选项
+		var stat unix.Statfs_t
+		if err = unix.Statfs(point, &stat); err != nil {
+			return fmt.Errorf("statfs %s: %w", point, err)
+		}
+		if err = mount("", point, "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY|lockedFlags(stat.Flags), ""); err != nil {
+			return err
+		}
+	}
+	// 容器中/proc的部分路径被遮盖时无法挂载新的proc
+	_ = mount("proc", root+"/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+	return mount("", root, "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, "")
+}
+
+// pivotRoot 切换到新的根目录，并卸载原来的根目录
+func pivotRoot(root string) error {
+	if err := os.Chdir(root); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := unix.Unmount(".", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("umount old root: %w", err)
+	}
+	return os.Chdir("/tmp")
+}
+
+// execIsolated 在新的根目录中设置资源限制和seccomp后执行用户程序，成功时不会返回
+func execIsolated(config *stageConfig, socket *os.File) error {
+	profile := profiles[config.Profile]
+	if err := pivotRoot(config.Root); err != nil {
+		return err
+	}
+	setLimits(config)
+	setLimit(unix.RLIMIT_NOFILE, profile.nofile)
+	setLimit(unix.RLIMIT_CORE, 0)
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("prctl no_new_privs: %w", err)
+	}
+	if profile.syscalls != nil {
+		if err := loadSeccomp(profile, socket); err != nil {
+			return err
+		}
+	}
+	socket.Close()
+	return syscall.Exec(config.Path, config.Cmd, sandboxEnv)
+}
+
+// bind 将path挂载到新的根目录中相同的位置，source为实际挂载的来源
+func bind(root string, path string, source string) error {
+	target := root + path
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if err = os.MkdirAll(target, 0755); err != nil {
+			return err
+		}
+	} else {
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		file.Close()
+	}
+	return mount(source, target, "", unix.MS_BIND|unix.MS_REC, "")
+}
+
+func mount(source string, target string, fstype string, flags uintptr, data string) error {
+	if err := unix.Mount(source, target, fstype, flags, data); err != nil {
+		return fmt.Errorf("mount %s: %w", target, err)
+	}
+	return nil
+}
+
+// lockedFlags statfs中需要在重新挂载时保留的挂载选项
+func lockedFlags(statFlags int64) uintptr {
+	var flags uintptr
+	for statFlag, mountFlag := range map[int64]uintptr{
+		unix.ST_NOSUID:     unix.MS_NOSUID,
+		unix.ST_NODEV:      unix.MS_NODEV,
+		unix.ST_NOEXEC:     unix.MS_NOEXEC,
+		unix.ST_NOATIME:    unix.MS_NOATIME,
+		unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+		unix.ST_RELATIME:   unix.MS_RELATIME,
+	} {
+		if statFlags&statFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	return flags
+}
+
+// mountPointsUnder root下的所有挂载点
+func mountPointsUnder(root string) ([]string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	// 挂载点中的空格等字符以八进制转义
+	replacer := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+	var points []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		point := replacer.Replace(fields[4])
+		if strings.HasPrefix(point, root+"/") {
+			points = append(points, point)
+		}
+	}
+	return points, scanner.Err()
+}
+
+func inSystemDirs(path string) bool {
+	for _, dir := range systemDirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,557 @@
+// Package runner 判题运行器，在沙箱中运行用户程序并统计资源使用情况
+// 用法：judge-runner [选项] <时间限制ms> <内存限制byte> <输出限制byte> <输入文件> <输出文件> <错误输出文件> 命令...
+// 选项：
+//
+//	--interactor <交互程序> <交互程序错误输出文件>  用户程序的标准输入输出通过管道与交互程序相连，交互程序以"<交互程序> <输入文件> <输出文件>"的方式启动
+//	--cgroup <cgroup挂载位置>  为用户程序创建cgroup，统计内存峰值和cpu时间，自动判断cgroup v1和v2，无法创建时使用rusage统计
+//	--pids <进程数限制>  通过cgroup限制用户程序的进程数，防止fork炸弹
+//	--isolate <配置> <程序路径>  在user/pid/mount/net等命名空间中运行用户程序，根目录只读，工作目录为tmpfs，
+//	                            并按配置（c、go、java）限制可以使用的系统调用，程序路径会以只读方式挂载到新的根目录中，
+//	                            配置为compile时用于编译，不限制系统调用，程序路径为可写的编译目录
+//
+// 运行结束后以json格式输出运行结果
+package runner
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fansqz/fancode-backend/service/user_coding_service/judger/cgroup"
+)
+
+const (
+	// Command 以运行器的方式启动程序时的第一个参数，本机沙箱通过该参数把后端程序作为运行器使用
+	Command = "judge-runner"
+	// 运行器通过/proc/self/exe重新执行自身进入不同的阶段，第一个参数为阶段名，第二个参数为json格式的配置
+	stageExec      = Command + ":exec"
+	stageNamespace = Command + ":namespace"
+	stageInit      = Command + ":init"
+	selfExe        = "/proc/self/exe"
+)
+
+// result 运行结果，字段与judger中的runnerResult对应
+type result struct {
+	ExitCode           int   `json:"exitCode"`
+	Signal             int   `json:"signal"`
+	CPUTime            int64 `json:"cpuTime"`  // 单位毫秒
+	WallTime           int64 `json:"wallTime"` // 单位毫秒
+	Memory             int64 `json:"memory"`   // 单位字节
+	Killed             bool  `json:"killed"`
+	OOMKilled          bool  `json:"oomKilled"`
+	CGroup             int   `json:"cgroup"`
+	InteractorExitCode int   `json:"interactorExitCode"`
+	InteractorSignal   int   `json:"interactorSignal"`
+}
+
+// options 运行器的参数
+type options struct {
+	interactor      string
+	interactorError string
+	cgroupRoot      string
+	pidsLimit       int64
+	profile         string
+	program         string
+	timeLimit       int64 // 单位毫秒
+	memoryLimit     int64
+	outputLimit     int64
+	files           []string // 输入文件、输出文件和错误输出文件
+	cmd             []string
+}
+
+// stageConfig 传递给阶段进程的配置
+type stageConfig struct {
+	TimeLimit   int64    `json:"timeLimit"`
+	MemoryLimit int64    `json:"memoryLimit"`
+	OutputLimit int64    `json:"outputLimit"`
+	Profile     string   `json:"profile"`   // 隔离配置，为空时不隔离
+	Program     string   `json:"program"`   // 挂载到新的根目录中的程序路径
+	Root        string   `json:"root"`      // 新的根目录
+	SetGroups   bool     `json:"setGroups"` // 命名空间中是否可以清空附加组
+	Path        string   `json:"path"`      // 用户程序的实际路径，由命名空间中的1号进程解析
+	Cmd         []string `json:"cmd"`
+}
+
+// Init 当前进程是运行器启动的阶段进程，或者以Command参数启动时，执行运行器后退出，否则直接返回
+// 需要在main函数的开始调用，测试中在TestMain中调用
+func Init() {
+	if len(os.Args) < 2 {
+		return
+	}
+	switch os.Args[1] {
+	case Command:
+		os.Exit(Main(os.Args[2:]))
+	case stageExec, stageNamespace, stageInit:
+		// prctl和seccomp只对当前线程生效，之后的execve也需要在同一个线程中执行
+		runtime.LockOSThread()
+		config := &stageConfig{}
+		if len(os.Args) < 3 || json.Unmarshal([]byte(os.Args[2]), config) != nil {
+			os.Exit(127)
+		}
+		os.Exit(runStage(os.Args[1], config))
+	}
+}
+
+// Main 运行器的入口，args为运行器的参数，返回进程的退出码
+func Main(args []string) int {
+	opts, err := parseOptions(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	res, err := run(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	output, err := json.Marshal(res)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(output))
+	return 0
+}
+
+func parseOptions(args []string) (*options, error) {
+	opts := &options{}
+	for len(args) != 0 && strings.HasPrefix(args[0], "--") {
+		var n int
+		switch args[0] {
+		case "--interactor", "--isolate":
+			n = 3
+		case "--cgroup", "--pids":
+			n = 2
+		default:
+			return nil, fmt.Errorf("unknown option %s", args[0])
+		}
+		if len(args) < n {
+			return nil, fmt.Errorf("option %s requires %d arguments", args[0], n-1)
+		}
+		switch args[0] {
+		case "--interactor":
+			opts.interactor, opts.interactorError = args[1], args[2]
+		case "--isolate":
+			opts.profile, opts.program = args[1], args[2]
+		case "--cgroup":
+			opts.cgroupRoot = args[1]
+		case "--pids":
+			limit, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pids limit %s", args[1])
+			}
+			opts.pidsLimit = limit
+		}
+		args = args[n:]
+	}
+	if len(args) < 7 {
+		return nil, errors.New("usage: judge-runner [options] <time> <memory> <output> <input file> <output file> <error file> cmd...")
+	}
+	limits := make([]int64, 3)
+	for i := range limits {
+		limit, err := strconv.ParseInt(args[i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit %s", args[i])
+		}
+		limits[i] = limit
+	}
+	opts.timeLimit, opts.memoryLimit, opts.outputLimit = limits[0], limits[1], limits[2]
+	opts.files = args[3:6]
+	opts.cmd = args[6:]
+	return opts, nil
+}
+
+func run(opts *options) (*result, error) {
+	cg := createCGroup(opts.cgroupRoot, opts.memoryLimit, opts.pidsLimit)
+	if cg != nil {
+		defer func() {
+			_ = cg.KillAll()
+			_ = cg.Release()
+		}()
+	}
+	config := &stageConfig{
+		TimeLimit:   opts.timeLimit,
+		MemoryLimit: opts.memoryLimit,
+		OutputLimit: opts.outputLimit,
+		Cmd:         opts.cmd,
+	}
+	var iso *isolation
+	if opts.profile != "" {
+		var err error
+		if iso, err = newIsolation(opts.profile, opts.program); err != nil {
+			return nil, err
+		}
+		defer iso.release()
+	}
+
+	start := time.Now()
+	var user, interactor *exec.Cmd
+	var err error
+	if opts.interactor == "" {
+		var files []*os.File
+		if files, err = openFiles(opts.files); err != nil {
+			return nil, err
+		}
+		user, err = startUser(config, files, cg, iso)
+		closeFiles(files)
+	} else {
+		user, interactor, err = startInteractive(opts, config, cg, iso)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pids := []int{user.Process.Pid}
+	if interactor != nil {
+		pids = append(pids, interactor.Process.Pid)
+	}
+
+	// 程序阻塞时cpu时间不会增加，使用墙上时间兜底
+	var killed atomic.Bool
+	var timer *time.Timer
+	if opts.timeLimit > 0 {
+		timer = time.AfterFunc(time.Duration(opts.timeLimit*2+500)*time.Millisecond, func() {
+			killed.Store(true)
+			killGroups(pids)
+		})
+	}
+	_ = user.Wait()
+	if interactor != nil {
+		_ = interactor.Wait()
+	}
+	wallTime := time.Since(start)
+	if timer != nil {
+		timer.Stop()
+	}
+	// 程序退出后仍在运行的子进程
+	killGroups(pids)
+
+	status := user.ProcessState.Sys().(syscall.WaitStatus)
+	if iso != nil {
+		// 命名空间中的用户程序由1号进程等待，运行器等待的进程在用户程序结束后退出
+		if userStatus, ok := iso.userStatus(); ok {
+			status = userStatus
+		}
+	}
+	usage := user.ProcessState.SysUsage().(*syscall.Rusage)
+	res := &result{
+		CPUTime:  (syscall.TimevalToNsec(usage.Utime) + syscall.TimevalToNsec(usage.Stime)) / int64(time.Millisecond),
+		WallTime: wallTime.Milliseconds(),
+		Memory:   usage.Maxrss * 1024,
+		Killed:   killed.Load(),
+	}
+	res.ExitCode, res.Signal = statusOf(status)
+	if interactor != nil {
+		res.InteractorExitCode, res.InteractorSignal = statusOf(interactor.ProcessState.Sys().(syscall.WaitStatus))
+	}
+	if cg != nil {
+		// cgroup统计的是用户程序及其所有子进程的资源使用情况
+		if cpuUsage, err := cg.CPUUsage(); err == nil {
+			res.CPUTime = max(res.CPUTime, cpuUsage/int64(time.Millisecond))
+		}
+		if peak, err := cg.MemoryPeak(); err == nil {
+			res.Memory = max(res.Memory, peak)
+		}
+		if oomKilled, err := cg.OOMKilled(); err == nil {
+			res.OOMKilled = oomKilled
+		}
+		res.CGroup = int(cg.Version())
+	}
+	if iso != nil {
+		// 创建命名空间或者准备根目录失败属于判题系统的错误
+		if message := iso.errorMessage(); message != "" {
+			return nil, fmt.Errorf("isolate fail: %s", message)
+		}
+	}
+	return res, nil
+}
+
+// startInteractive 启动用户程序和交互程序，两者的标准输入输出通过管道相连
+func startInteractive(opts *options, config *stageConfig, cg *cgroup.CGroup, iso *isolation) (*exec.Cmd, *exec.Cmd, error) {
+	// toUser：交互程序写，用户程序读；toInteractor：用户程序写，交互程序读
+	toUserR, toUserW, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer toUserR.Close()
+	defer toUserW.Close()
+	toInteractorR, toInteractorW, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	// 父进程需要关闭管道，否则一方退出后另一方读不到EOF
+	defer toInteractorR.Close()
+	defer toInteractorW.Close()
+
+	userError, err := createFile(opts.files[2])
+	if err != nil {
+		return nil, nil, err
+	}
+	defer userError.Close()
+	interactorError, err := createFile(opts.interactorError)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer interactorError.Close()
+
+	user, err := startUser(config, []*os.File{toUserR, toInteractorW, userError}, cg, iso)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 交互程序不限制cpu时间，由墙上时间兜底
+	interactor := exec.Command(opts.interactor, opts.files[0], opts.files[1])
+	interactor.Stdin, interactor.Stdout, interactor.Stderr = toInteractorR, toUserW, interactorError
+	interactor.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err = interactor.Start(); err != nil {
+		killGroups([]int{user.Process.Pid})
+		_ = user.Wait()
+		return nil, nil, err
+	}
+	return user, interactor, nil
+}
+
+// openFiles 打开输入文件，创建输出文件和错误输出文件
+func openFiles(paths []string) ([]*os.File, error) {
+	input, err := os.Open(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	files := []*os.File{input}
+	for _, path := range paths[1:] {
+		file, err := createFile(path)
+		if err != nil {
+			closeFiles(files)
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func createFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+}
+
+func closeFiles(files []*os.File) {
+	for _, file := range files {
+		file.Close()
+	}
+}
+
+// startUser 启动用户程序，进程加入cgroup后才会继续执行，用户程序在单独的进程组中，结束时可以杀死程序创建的所有进程
+func startUser(config *stageConfig, stdio []*os.File, cg *cgroup.CGroup, iso *isolation) (*exec.Cmd, error) {
+	goR, goW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer goW.Close()
+	stage := stageExec
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	extraFiles := []*os.File{goR}
+	if iso != nil {
+		stage = stageNamespace
+		iso.prepare(config, attr)
+		extraFiles = append(extraFiles, iso.errorW, iso.statusW)
+	}
+	cmd, err := stageCommand(stage, config, stdio, extraFiles)
+	if err != nil {
+		goR.Close()
+		return nil, err
+	}
+	cmd.SysProcAttr = attr
+	err = cmd.Start()
+	goR.Close()
+	if iso != nil {
+		iso.closeWriters()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cg != nil {
+		if err = cg.AddPID(cmd.Process.Pid); err != nil {
+			// 关闭管道后子进程会直接退出
+			goW.Close()
+			_ = cmd.Wait()
+			return nil, err
+		}
+	}
+	if _, err = goW.Write([]byte{1}); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// stageCommand 重新执行运行器进入指定的阶段，extraFiles在子进程中从3开始依次编号
+func stageCommand(stage string, config *stageConfig, stdio []*os.File, extraFiles []*os.File) (*exec.Cmd, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(selfExe, stage, string(data))
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdio[0], stdio[1], stdio[2]
+	cmd.ExtraFiles = extraFiles
+	return cmd, nil
+}
+
+// runStage 在阶段进程中执行，返回进程的退出码
+func runStage(stage string, config *stageConfig) int {
+	// 继承的文件描述符只在当前阶段使用，不能被之后执行的程序继承
+	files := inheritedFiles(stage, config)
+	for _, file := range files {
+		syscall.CloseOnExec(int(file.Fd()))
+	}
+	switch stage {
+	case stageNamespace:
+		if !waitGo(files[0]) {
+			return 127
+		}
+		if err := runNamespace(config, files[1], files[2]); err != nil {
+			reportError(files[1], err)
+			return 127
+		}
+		return 0
+	case stageInit:
+		if err := runInit(config, files[0], files[1], files[2]); err != nil {
+			reportError(files[0], err)
+		}
+		return 0
+	default:
+		if config.Profile != "" {
+			reportError(files[0], execIsolated(config, files[1]))
+			return 127
+		}
+		if !waitGo(files[0]) {
+			return 127
+		}
+		// 非隔离运行时错误信息写入用户程序的错误输出
+		fmt.Fprintln(os.Stderr, execProgram(config))
+		return 127
+	}
+}
+
+// inheritedFiles 各阶段从父进程继承的文件，顺序与启动时的extraFiles一致
+func inheritedFiles(stage string, config *stageConfig) []*os.File {
+	names := map[string][]string{
+		stageNamespace: {"go", "error", "status"},
+		stageInit:      {"error", "status", "program"},
+		stageExec:      {"go"},
+	}[stage]
+	if stage == stageExec && config.Profile != "" {
+		names = []string{"error", "seccomp"}
+	}
+	files := make([]*os.File, 0, len(names))
+	for i, name := range names {
+		files = append(files, os.NewFile(uintptr(3+i), name))
+	}
+	return files
+}
+
+// waitGo 等待运行器把进程加入cgroup
+func waitGo(file *os.File) bool {
+	buf := make([]byte, 1)
+	n, _ := file.Read(buf)
+	file.Close()
+	return n == 1 && buf[0] == 1
+}
+
+// execProgram 设置资源限制后执行用户程序，成功时不会返回
+func execProgram(config *stageConfig) error {
+	setLimits(config)
+	path, err := exec.LookPath(config.Cmd[0])
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, config.Cmd, os.Environ())
+}
+
+func setLimits(config *stageConfig) {
+	// cpu时间超出限制时进程会收到SIGXCPU
+	if config.TimeLimit > 0 {
+		setLimit(syscall.RLIMIT_CPU, uint64((config.TimeLimit+999)/1000+1))
+	}
+	if config.OutputLimit > 0 {
+		setLimit(syscall.RLIMIT_FSIZE, uint64(config.OutputLimit))
+	}
+	// 不限制地址空间，否则申请内存失败会表现为运行错误，内存是否超出限制由最大常驻内存判断
+	if config.MemoryLimit > 0 {
+		setLimit(syscall.RLIMIT_STACK, uint64(config.MemoryLimit))
+	}
+}
+
+func setLimit(resource int, value uint64) {
+	_ = syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value})
+}
+
+// createCGroup 为用户程序创建cgroup，沙箱中cgroup文件系统只读或者没有对应的控制器时返回nil，使用rusage统计
+func createCGroup(root string, memoryLimit int64, pidsLimit int64) *cgroup.CGroup {
+	if root == "" {
+		return nil
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil
+	}
+	cg, err := cgroup.NewCGroupWithRoot(root, "judge-"+hex.EncodeToString(id))
+	if err != nil {
+		return nil
+	}
+	// 内存上限比限制大，超出限制的程序可以通过峰值判断，而不是直接被杀死
+	if memoryLimit > 0 {
+		err = cg.SetMemoryLimit(memoryLimit * 2)
+	}
+	if err == nil && pidsLimit > 0 {
+		err = cg.SetPidsLimit(pidsLimit)
+	}
+	if err != nil {
+		_ = cg.Release()
+		return nil
+	}
+	return cg
+}
+
+func killGroups(pids []int) {
+	for _, pid := range pids {
+		_ = syscall.Kill(-pid, syscall.SIGKILL)
+	}
+}
+
+func statusOf(status syscall.WaitStatus) (int, int) {
+	exitCode, sig := -1, 0
+	if status.Exited() {
+		exitCode = status.ExitStatus()
+	}
+	if status.Signaled() {
+		sig = int(status.Signal())
+	}
+	return exitCode, sig
+}
+
+func reportError(file *os.File, err error) {
+	_, _ = file.WriteString(err.Error())
+}
+
+// readStatus 读取1号进程写入的用户程序的等待状态
+func readStatus(reader io.Reader) (syscall.WaitStatus, bool) {
+	var status uint32
+	if err := binary.Read(reader, binary.NativeEndian, &status); err != nil {
+		return 0, false
+	}
+	return syscall.WaitStatus(status), true
+}
+
+func writeStatus(writer io.Writer, status syscall.WaitStatus) error {
+	return binary.Write(writer, binary.NativeEndian, uint32(status))
+}
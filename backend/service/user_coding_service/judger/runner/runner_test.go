@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMain 运行器会以测试程序重新执行自身进入各个阶段
+func TestMain(m *testing.M) {
+	Init()
+	os.Exit(m.Run())
+}
+
+func TestParseOptions(t *testing.T) {
+	opts, err := parseOptions([]string{
+		"--interactor", "interactor", "interactor_error", "--cgroup", "/sys/fs/cgroup", "--pids", "16",
+		"--isolate", "c", "main", "1000", "1024", "0", "input", "output", "error", "main", "arg",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "interactor", opts.interactor)
+	assert.Equal(t, "interactor_error", opts.interactorError)
+	assert.Equal(t, "/sys/fs/cgroup", opts.cgroupRoot)
+	assert.Equal(t, int64(16), opts.pidsLimit)
+	assert.Equal(t, "c", opts.profile)
+	assert.Equal(t, "main", opts.program)
+	assert.Equal(t, int64(1000), opts.timeLimit)
+	assert.Equal(t, int64(1024), opts.memoryLimit)
+	assert.Equal(t, int64(0), opts.outputLimit)
+	assert.Equal(t, []string{"input", "output", "error"}, opts.files)
+	assert.Equal(t, []string{"main", "arg"}, opts.cmd)
+
+	_, err = parseOptions([]string{"--unknown", "1000", "1024", "0", "input", "output", "error", "main"})
+	assert.NotNil(t, err)
+	_, err = parseOptions([]string{"1000", "1024", "0", "input", "output", "error"})
+	assert.NotNil(t, err)
+	_, err = parseOptions([]string{"1s", "1024", "0", "input", "output", "error", "main"})
+	assert.NotNil(t, err)
+}
+
+// runMain 以Main运行命令，返回解析后的运行结果
+func runMain(t *testing.T, args ...string) *result {
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	assert.Nil(t, err)
+	os.Stdout = w
+	exitCode := Main(args)
+	os.Stdout = stdout
+	w.Close()
+	output, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, exitCode)
+	res := &result{}
+	assert.Nil(t, json.Unmarshal(bytes.TrimSpace(output), res))
+	return res
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	input, output, errOutput := filepath.Join(dir, "input"), filepath.Join(dir, "output"), filepath.Join(dir, "error")
+	assert.Nil(t, os.WriteFile(input, []byte("hello"), 0644))
+
+	res := runMain(t, "1000", "0", "0", input, output, errOutput, "sh", "-c", "cat; echo fail >&2; exit 3")
+	assert.Equal(t, 3, res.ExitCode)
+	assert.Equal(t, 0, res.Signal)
+	assert.False(t, res.Killed)
+	content, err := os.ReadFile(output)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(content))
+	content, err = os.ReadFile(errOutput)
+	assert.Nil(t, err)
+	assert.Equal(t, "fail\n", string(content))
+
+	// 输出超出限制时收到SIGXFSZ
+	res = runMain(t, "1000", "0", "4", input, output, errOutput, "sh", "-c", "exec cat /dev/zero")
+	assert.Equal(t, int(syscall.SIGXFSZ), res.Signal)
+
+	// 阻塞的程序由墙上时间兜底杀死
+	res = runMain(t, "100", "0", "0", input, output, errOutput, "sleep", "10")
+	assert.True(t, res.Killed)
+	assert.Equal(t, int(syscall.SIGKILL), res.Signal)
+}
+
+func TestRunner_Interactor(t *testing.T) {
+	dir := t.TempDir()
+	input, output, errOutput := filepath.Join(dir, "input"), filepath.Join(dir, "output"), filepath.Join(dir, "error")
+	interactor := filepath.Join(dir, "interactor.sh")
+	assert.Nil(t, os.WriteFile(input, []byte("ping"), 0644))
+	// 交互程序把输入文件发送给用户程序，并把用户程序的回复写入输出文件
+	assert.Nil(t, os.WriteFile(interactor, []byte("#!/bin/sh\ncat \"$1\"; echo; read line; echo \"$line\" > \"$2\"\n"), 0755))
+
+	res := runMain(t, "--interactor", interactor, filepath.Join(dir, "interactor_error"),
+		"1000", "0", "0", input, output, errOutput, "sh", "-c", "read line; echo \"$line pong\"")
+	assert.Equal(t, 0, res.ExitCode)
+	assert.Equal(t, 0, res.InteractorExitCode)
+	content, err := os.ReadFile(output)
+	assert.Nil(t, err)
+	assert.Equal(t, "ping pong\n", string(content))
+}
@@ -0,0 +1,235 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// profile 隔离配置
+type profile struct {
+	syscalls    []string // 系统调用白名单，为nil时不限制系统调用
+	allowThread bool     // 是否允许创建线程
+	nofile      uint64   // 打开文件数限制
+	tmpSize     string   // tmpfs工作目录的大小，写入工作目录的文件同样计入cgroup的内存
+}
+
+// cSyscalls 单线程程序可以使用的系统调用，不允许创建进程和网络连接
+var cSyscalls = []string{
+	"read", "write", "readv", "writev", "pread64", "pwrite64", "lseek", "close", "fstat", "stat",
+	"lstat", "newfstatat", "statx", "open", "openat", "access", "faccessat", "faccessat2",
+	"readlink", "readlinkat", "mmap", "munmap", "mprotect", "mremap", "brk", "madvise",
+	"rt_sigaction", "rt_sigprocmask", "rt_sigreturn", "sigaltstack", "exit", "exit_group",
+	"arch_prctl", "set_tid_address", "set_robust_list", "rseq", "prlimit64", "getrlimit",
+	"getrandom", "futex", "clock_gettime", "clock_getres", "clock_nanosleep", "nanosleep",
+	"gettimeofday", "time", "uname", "getpid", "gettid", "getuid", "geteuid", "getgid", "getegid",
+	"getppid", "ioctl", "fcntl", "dup", "dup2", "dup3", "poll", "ppoll", "select", "pselect6",
+	"sched_yield", "restart_syscall", "kill", "tgkill", "getcwd", "getrusage", "sysinfo",
+}
+
+// goSyscalls go运行时需要创建线程、使用epoll和定时器
+var goSyscalls = append(slices.Clone(cSyscalls),
+	"sched_getaffinity", "epoll_create1", "epoll_ctl", "epoll_wait", "epoll_pwait", "pipe2",
+	"eventfd2", "setrlimit", "mincore", "prctl", "timer_create", "timer_settime", "timer_delete",
+)
+
+// javaSyscalls java虚拟机还需要读取目录，并在/tmp中写入性能数据
+var javaSyscalls = append(slices.Clone(goSyscalls),
+	"sched_setaffinity", "getdents", "getdents64", "mkdir", "mkdirat", "unlink",
+	"unlinkat", "ftruncate", "fsync", "msync", "membarrier", "memfd_create",
+)
+
+// profiles 隔离配置，编译go代码时需要在工作目录中缓存标准库
+var profiles = map[string]*profile{
+	"c":            {syscalls: cSyscalls, nofile: 64, tmpSize: "64m"},
+	"go":           {syscalls: goSyscalls, allowThread: true, nofile: 256, tmpSize: "64m"},
+	"java":         {syscalls: javaSyscalls, allowThread: true, nofile: 1024, tmpSize: "64m"},
+	profileCompile: {allowThread: true, nofile: 1024, tmpSize: "512m"},
+}
+
+// seccomp_data中的字段偏移
+const (
+	offsetNr   = 0
+	offsetArch = 4
+	offsetArg0 = 16
+)
+
+func bpfStmt(code uint16, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: code, K: k}
+}
+
+func bpfJump(code uint16, k uint32, jt uint8, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
+
+const (
+	ldAbs = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+	jeq   = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+	jge   = unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K
+	jset  = unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K
+	ret   = unix.BPF_RET | unix.BPF_K
+)
+
+// execFilter 生成把execve通知给1号进程的bpf程序，其他系统调用交给白名单处理
+// bpf程序无法记录execve是否执行过，也不能可靠地检查路径参数，所以由1号进程计数
+func execFilter() []unix.SockFilter {
+	return []unix.SockFilter{
+		bpfStmt(ldAbs, offsetNr),
+		bpfJump(jeq, syscallNumbers["execve"], 0, 1), bpfStmt(ret, unix.SECCOMP_RET_USER_NOTIF),
+		bpfStmt(ret, unix.SECCOMP_RET_ALLOW),
+	}
+}
+
+// seccompFilter 生成系统调用白名单的bpf程序
+// 多个过滤器同时生效时内核取最严格的结果，execve在这里放行后仍然需要经过execFilter的通知
+func seccompFilter(p *profile) []unix.SockFilter {
+	kill := bpfStmt(ret, unix.SECCOMP_RET_KILL_PROCESS)
+	allow := bpfStmt(ret, unix.SECCOMP_RET_ALLOW)
+	prog := []unix.SockFilter{bpfStmt(ldAbs, offsetArch), bpfJump(jeq, auditArch, 1, 0), kill, bpfStmt(ldAbs, offsetNr)}
+	if x32SyscallBit != 0 {
+		// 禁止x32调用
+		prog = append(prog, bpfJump(jge, x32SyscallBit, 0, 1), kill)
+	}
+	names := slices.Clone(p.syscalls)
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		if nr, ok := syscallNumbers[name]; ok {
+			prog = append(prog, bpfJump(jeq, nr, 0, 1), allow)
+		}
+	}
+	if p.allowThread {
+		// 只允许通过clone创建线程，clone3的参数无法检查，返回ENOSYS让libc回退到clone
+		prog = append(prog,
+			bpfJump(jeq, syscallNumbers["clone"], 0, 4), bpfStmt(ldAbs, offsetArg0),
+			bpfJump(jset, unix.CLONE_THREAD, 0, 1), allow, kill,
+			bpfJump(jeq, syscallNumbers["clone3"], 0, 1), bpfStmt(ret, unix.SECCOMP_RET_ERRNO|uint32(unix.ENOSYS)))
+	}
+	prog = append(prog,
+		bpfJump(jeq, syscallNumbers["execve"], 0, 1), allow, kill)
+	return prog
+}
+
+// loadSeccomp 为当前线程设置系统调用白名单，并把execve通知的监听发送给1号进程
+// 白名单不允许发送文件描述符，所以先设置execve的通知并发送监听，再叠加白名单
+// 监听不能被用户程序持有，否则用户程序的其他线程可以自己允许execve
+// 需要5.5以上的内核支持SECCOMP_USER_NOTIF_FLAG_CONTINUE
+func loadSeccomp(p *profile, socket *os.File) error {
+	listener, err := setFilter(execFilter(), unix.SECCOMP_FILTER_FLAG_NEW_LISTENER)
+	if err != nil {
+		return err
+	}
+	err = unix.Sendmsg(int(socket.Fd()), []byte{0}, unix.UnixRights(listener), nil, 0)
+	unix.Close(listener)
+	if err != nil {
+		return fmt.Errorf("send seccomp listener: %w", err)
+	}
+	_, err = setFilter(seccompFilter(p), 0)
+	return err
+}
+
+// setFilter 为当前线程设置seccomp过滤器，flags包含SECCOMP_FILTER_FLAG_NEW_LISTENER时返回监听
+func setFilter(filter []unix.SockFilter, flags uintptr) (int, error) {
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	fd, _, errno := unix.RawSyscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, flags, uintptr(unsafe.Pointer(&prog)))
+	if errno != 0 {
+		return -1, fmt.Errorf("seccomp: %w", errno)
+	}
+	return int(fd), nil
+}
+
+// seccompNotif 与内核的struct seccomp_notif一致
+type seccompNotif struct {
+	id    uint64
+	pid   uint32
+	flags uint32
+	nr    int32
+	arch  uint32
+	ip    uint64
+	args  [6]uint64
+}
+
+// seccompNotifResp 与内核的struct seccomp_notif_resp一致
+type seccompNotifResp struct {
+	id    uint64
+	val   int64
+	error int32
+	flags uint32
+}
+
+// superviseExec 在1号进程中处理用户进程的execve通知，第一次execve是用户进程执行用户程序，之后的execve都是违规
+// 违规时杀死用户进程并记录，用户进程退出后监听不会再收到通知
+func superviseExec(socket *os.File, process *os.Process, violated *atomic.Bool) {
+	listener, err := receiveFd(socket)
+	if err != nil {
+		return
+	}
+	defer unix.Close(listener)
+	executed := false
+	for {
+		notif := seccompNotif{}
+		if err = ioctl(listener, unix.SECCOMP_IOCTL_NOTIF_RECV, unsafe.Pointer(&notif)); err != nil {
+			// 用户进程在通知被读取前退出时返回ENOENT
+			if errors.Is(err, unix.EINTR) || errors.Is(err, unix.ENOENT) {
+				continue
+			}
+			return
+		}
+		resp := seccompNotifResp{id: notif.id}
+		if !executed && uint32(notif.nr) == syscallNumbers["execve"] {
+			executed = true
+			resp.flags = unix.SECCOMP_USER_NOTIF_FLAG_CONTINUE
+		} else {
+			violated.Store(true)
+			_ = process.Kill()
+			resp.error = -int32(unix.EPERM)
+		}
+		// 用户进程已经被杀死时返回ENOENT
+		_ = ioctl(listener, unix.SECCOMP_IOCTL_NOTIF_SEND, unsafe.Pointer(&resp))
+	}
+}
+
+// receiveFd 接收通过unix socket发送的文件描述符
+func receiveFd(socket *os.File) (int, error) {
+	buf := make([]byte, 1)
+	oob := make([]byte, unix.CmsgSpace(4))
+	_, oobn, _, _, err := unix.Recvmsg(int(socket.Fd()), buf, oob, 0)
+	if err != nil {
+		return -1, err
+	}
+	messages, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return -1, err
+	}
+	if len(messages) != 1 {
+		return -1, errors.New("seccomp listener not received")
+	}
+	fds, err := unix.ParseUnixRights(&messages[0])
+	if err != nil {
+		return -1, err
+	}
+	if len(fds) != 1 {
+		return -1, errors.New("seccomp listener not received")
+	}
+	return fds[0], nil
+}
+
+// socketPair 创建传递seccomp监听的unix socket
+func socketPair() (*os.File, *os.File, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return os.NewFile(uintptr(fds[0]), "seccomp"), os.NewFile(uintptr(fds[1]), "seccomp"), nil
+}
+
+func ioctl(fd int, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package runner
+
+import "golang.org/x/sys/unix"
+
+const (
+	auditArch = unix.AUDIT_ARCH_X86_64
+	// x32SyscallBit x32调用的系统调用号带有该标志，为0时没有x32调用
+	x32SyscallBit = 0x40000000
+)
+
+// syscallNumbers 白名单中用到的系统调用号
+var syscallNumbers = map[string]uint32{
+	"read": 0, "write": 1, "open": 2, "close": 3, "stat": 4, "fstat": 5, "lstat": 6, "poll": 7,
+	"lseek": 8, "mmap": 9, "mprotect": 10, "munmap": 11, "brk": 12, "rt_sigaction": 13,
+	"rt_sigprocmask": 14, "rt_sigreturn": 15, "ioctl": 16, "pread64": 17, "pwrite64": 18, "readv": 19,
+	"writev": 20, "access": 21, "select": 23, "sched_yield": 24, "mremap": 25, "msync": 26,
+	"mincore": 27, "madvise": 28, "dup": 32, "dup2": 33, "nanosleep": 35, "getpid": 39, "clone": 56,
+	"execve": 59, "exit": 60, "kill": 62, "uname": 63, "fcntl": 72, "fsync": 74, "ftruncate": 77,
+	"getdents": 78, "getcwd": 79, "mkdir": 83, "unlink": 87, "readlink": 89, "gettimeofday": 96,
+	"getrlimit": 97, "getrusage": 98, "sysinfo": 99, "getuid": 102, "getgid": 104, "geteuid": 107,
+	"getegid": 108, "getppid": 110, "sigaltstack": 131, "prctl": 157, "arch_prctl": 158,
+	"setrlimit": 160, "gettid": 186, "time": 201, "futex": 202, "sched_setaffinity": 203,
+	"sched_getaffinity": 204, "getdents64": 217, "set_tid_address": 218, "restart_syscall": 219,
+	"timer_create": 222, "timer_settime": 223, "timer_delete": 226, "clock_gettime": 228,
+	"clock_getres": 229, "clock_nanosleep": 230, "exit_group": 231, "epoll_wait": 232,
+	"epoll_ctl": 233, "tgkill": 234, "openat": 257, "mkdirat": 258, "newfstatat": 262,
+	"unlinkat": 263, "readlinkat": 267, "faccessat": 269, "pselect6": 270, "ppoll": 271,
+	"set_robust_list": 273, "epoll_pwait": 281, "eventfd2": 290, "epoll_create1": 291, "dup3": 292,
+	"pipe2": 293, "prlimit64": 302, "getrandom": 318, "memfd_create": 319, "membarrier": 324,
+	"statx": 332, "rseq": 334, "clone3": 435, "faccessat2": 439,
+}
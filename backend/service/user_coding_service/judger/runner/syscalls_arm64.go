@@ -0,0 +1,29 @@
+package runner
+
+import "golang.org/x/sys/unix"
+
+const (
+	auditArch = unix.AUDIT_ARCH_AARCH64
+	// x32SyscallBit x32调用的系统调用号带有该标志，为0时没有x32调用
+	x32SyscallBit = 0
+)
+
+// syscallNumbers 白名单中用到的系统调用号
+var syscallNumbers = map[string]uint32{
+	"getcwd": 17, "eventfd2": 19, "epoll_create1": 20, "epoll_ctl": 21, "epoll_pwait": 22, "dup": 23,
+	"dup3": 24, "fcntl": 25, "ioctl": 29, "mkdirat": 34, "unlinkat": 35, "ftruncate": 46,
+	"faccessat": 48, "openat": 56, "close": 57, "pipe2": 59, "getdents64": 61, "lseek": 62,
+	"read": 63, "write": 64, "readv": 65, "writev": 66, "pread64": 67, "pwrite64": 68, "pselect6": 72,
+	"ppoll": 73, "readlinkat": 78, "newfstatat": 79, "fstat": 80, "fsync": 82, "exit": 93,
+	"exit_group": 94, "set_tid_address": 96, "futex": 98, "set_robust_list": 99, "nanosleep": 101,
+	"timer_create": 107, "timer_settime": 110, "timer_delete": 111, "clock_gettime": 113,
+	"clock_getres": 114, "clock_nanosleep": 115, "sched_setaffinity": 122, "sched_getaffinity": 123,
+	"sched_yield": 124, "restart_syscall": 128, "kill": 129, "tgkill": 131, "sigaltstack": 132,
+	"rt_sigaction": 134, "rt_sigprocmask": 135, "rt_sigreturn": 139, "uname": 160, "getrlimit": 163,
+	"setrlimit": 164, "getrusage": 165, "prctl": 167, "gettimeofday": 169, "getpid": 172,
+	"getppid": 173, "getuid": 174, "geteuid": 175, "getgid": 176, "getegid": 177, "gettid": 178,
+	"sysinfo": 179, "brk": 214, "munmap": 215, "mremap": 216, "clone": 220, "execve": 221,
+	"mmap": 222, "mprotect": 226, "msync": 227, "mincore": 232, "madvise": 233, "prlimit64": 261,
+	"getrandom": 278, "memfd_create": 279, "membarrier": 283, "statx": 291, "rseq": 293,
+	"clone3": 435, "faccessat2": 439,
+}
@@ -0,0 +1,11 @@
+//go:build !amd64 && !arm64
+
+package runner
+
+// 其他架构不支持隔离运行
+const (
+	auditArch     = 0
+	x32SyscallBit = 0
+)
+
+var syscallNumbers map[string]uint32
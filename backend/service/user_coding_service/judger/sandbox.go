@@ -0,0 +1,115 @@
+package judger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/fansqz/fancode-backend/service/user_coding_service/judger/runner"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core/utils"
+)
+
+// Sandbox 判题沙箱，用户代码的编译和运行都在沙箱中进行
+type Sandbox interface {
+	// WriteFile 将文件写入沙箱的指定目录
+	WriteFile(ctx context.Context, dir string, filename string, content []byte) error
+	// ReadFile 读取沙箱中的文件
+	ReadFile(ctx context.Context, filePath string) ([]byte, error)
+	// Exec 在沙箱中执行命令，返回标准输出和标准错误合并后的内容
+	Exec(ctx context.Context, cmd []string) (output string, exitCode int, err error)
+	// RunnerCommand 在沙箱中启动运行器的命令，运行器的参数追加在命令之后
+	RunnerCommand() []string
+	// Close 销毁沙箱
+	Close(ctx context.Context) error
+}
+
+// SandboxOptions 沙箱配置
+type SandboxOptions struct {
+	Image       string // 使用的镜像，与调试器使用同一个镜像
+	MemoryLimit int64  // 容器内存上限（以字节为单位）
+	CPUQuota    int64  // 容器cpu配额
+	PidsLimit   int64  // 容器进程数限制，防止fork炸弹，编译也在容器中进行
+}
+
+// dockerSandbox 基于docker容器的沙箱，容器禁用网络
+type dockerSandbox struct {
+	docker utils.DockerClient
+}
+
+// NewDockerSandbox 创建docker沙箱
+func NewDockerSandbox(ctx context.Context, options *SandboxOptions) (Sandbox, error) {
+	docker, err := utils.NewDockerClient(ctx, &utils.Config{
+		ImageName:       options.Image,
+		Memory:          options.MemoryLimit,
+		CPUQuota:        options.CPUQuota,
+		PidsLimit:       options.PidsLimit,
+		NetworkDisabled: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dockerSandbox{docker: docker}, nil
+}
+
+func (d *dockerSandbox) WriteFile(ctx context.Context, dir string, filename string, content []byte) error {
+	return d.docker.CopyToContainer(ctx, content, dir, filename)
+}
+
+func (d *dockerSandbox) ReadFile(ctx context.Context, filePath string) ([]byte, error) {
+	return d.docker.CopyFromContainer(ctx, filePath)
+}
+
+func (d *dockerSandbox) Exec(ctx context.Context, cmd []string) (string, int, error) {
+	output, err, exitCode := d.docker.Exec(ctx, cmd)
+	return output, exitCode, err
+}
+
+// RunnerCommand 调试器镜像中安装了运行器
+func (d *dockerSandbox) RunnerCommand() []string {
+	return []string{runner.Command}
+}
+
+func (d *dockerSandbox) Close(ctx context.Context) error {
+	return d.docker.RemoveContainer(ctx)
+}
+
+// localSandbox 直接在本机执行命令的沙箱，需要配合运行器的命名空间隔离运行不可信的代码
+type localSandbox struct {
+	dir string // 沙箱使用的目录，销毁沙箱时删除
+}
+
+// NewLocalSandbox 创建本机沙箱，dir为空时销毁沙箱不会删除任何目录
+func NewLocalSandbox(dir string) Sandbox {
+	return &localSandbox{dir: dir}
+}
+
+func (l *localSandbox) WriteFile(ctx context.Context, dir string, filename string, content []byte) error {
+	return os.WriteFile(filepath.Join(dir, filename), content, 0644)
+}
+
+func (l *localSandbox) ReadFile(ctx context.Context, filePath string) ([]byte, error) {
+	return os.ReadFile(filePath)
+}
+
+func (l *localSandbox) Exec(ctx context.Context, cmd []string) (string, int, error) {
+	output, err := exec.CommandContext(ctx, cmd[0], cmd[1:]...).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(output), exitErr.ExitCode(), nil
+	}
+	return string(output), 0, err
+}
+
+// RunnerCommand 本机沙箱中运行器就是当前程序，通过Command参数启动
+func (l *localSandbox) RunnerCommand() []string {
+	return []string{"/proc/self/exe", runner.Command}
+}
+
+func (l *localSandbox) Close(ctx context.Context) error {
+	if l.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(l.dir)
+}
@@ -2,18 +2,20 @@ package user_coding_service
 
 import (
 	"context"
+	"errors"
 	"github.com/fansqz/fancode-backend/common"
 	conf "github.com/fansqz/fancode-backend/common/config"
 	e "github.com/fansqz/fancode-backend/common/error"
 	"github.com/fansqz/fancode-backend/common/logger"
 	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/service/code_revision_service"
+	"github.com/fansqz/fancode-backend/service/problem_service"
 	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
 	"log"
-	"os"
-	"path/filepath"
-	"strings"
 )
 
 /**
@@ -21,10 +23,7 @@ import (
  */
 
 const (
-	AcmCCodeFilePath    = "/conf/acm_template/c"
-	AcmGoCodeFilePath   = "/conf/acm_template/go"
-	AcmJavaCodeFilePath = "/conf/acm_template/java"
-	VisualDocumentPath  = "/conf/document/visual_document.md"
+	VisualDocumentPath = "/conf/document/visual_document.md"
 )
 
 type UserCodeService interface {
@@ -34,17 +33,24 @@ type UserCodeService interface {
 	GetUserCode(ctx context.Context, problemID uint, language constants.LanguageType) (string, error)
 	// GetUserCodeByProblemID 根据题目id获取用户代码，无语言类型
 	GetUserCodeByProblemID(ctx context.Context, problemID uint) (*po.UserCode, error)
+	// GetUserCodeDetail 读取用户代码，包含代码id，代码id用于查询历史版本
+	GetUserCodeDetail(ctx context.Context, problemID uint, language constants.LanguageType) (*dto.UserCodeDto, error)
 	// GetProblemTemplateCode 获取题目的模板代码
-	GetProblemTemplateCode(ctx context.Context, language string) (string, error)
+	GetProblemTemplateCode(ctx context.Context, problemID uint, language string) (string, error)
 }
 
 type userCodeService struct {
-	codeDao dao.UserCodeDao
+	codeDao            dao.UserCodeDao
+	problemTemplateDao dao.ProblemTemplateDao
+	revisionDao        dao.UserCodeRevisionDao
 }
 
-func NewUserCodeService(config *conf.AppConfig, userCodeDao dao.UserCodeDao) UserCodeService {
+func NewUserCodeService(config *conf.AppConfig, userCodeDao dao.UserCodeDao,
+	problemTemplateDao dao.ProblemTemplateDao, revisionDao dao.UserCodeRevisionDao) UserCodeService {
 	return &userCodeService{
-		codeDao: userCodeDao,
+		codeDao:            userCodeDao,
+		problemTemplateDao: problemTemplateDao,
+		revisionDao:        revisionDao,
 	}
 }
 
@@ -59,7 +65,13 @@ func (u *userCodeService) SaveUserCode(ctx context.Context, userCode *po.UserCod
 	}
 	// 添加
 	if !exist {
-		if err = u.codeDao.InsertUserCode(common.Mysql, userCode); err != nil {
+		err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+			if err := u.codeDao.InsertUserCode(tx, userCode); err != nil {
+				return err
+			}
+			return u.saveRevision(tx, userCode)
+		})
+		if err != nil {
 			logger.WithCtx(ctx).Errorf("[SaveUserCode] InsertUserCode fail, err = %v", err)
 			return err
 		}
@@ -73,13 +85,54 @@ func (u *userCodeService) SaveUserCode(ctx context.Context, userCode *po.UserCod
 		return e.ErrUnknown
 	}
 	code.Code = userCode.Code
-	if err = u.codeDao.UpdateUserCode(common.Mysql, code); err != nil {
+	// 每次保存都记录历史版本，避免误操作覆盖代码以后无法找回
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := u.codeDao.UpdateUserCode(tx, code); err != nil {
+			return err
+		}
+		return u.saveRevision(tx, code)
+	})
+	if err != nil {
 		logger.WithCtx(ctx).Errorf("[SaveUserCode] GetUserCode fail, err = %v", err)
 		return e.ErrUnknown
 	}
+	userCode.ID = code.ID
 	return nil
 }
 
+// GetUserCodeDetail 读取用户代码，用户没有保存过代码时返回题目模板
+func (u *userCodeService) GetUserCodeDetail(ctx context.Context, problemID uint, language constants.LanguageType) (*dto.UserCodeDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	code, err := u.codeDao.GetUserCode(common.Mysql, userID, problemID, language)
+	if err == nil {
+		return dto.NewUserCodeDto(code), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.WithCtx(ctx).Errorf("[GetUserCodeDetail] GetUserCode fail, err = %v", err)
+		return nil, e.ErrUnknown
+	}
+	template, err := u.getTemplateCode(problemID, language)
+	if err != nil {
+		return nil, e.ErrProblemGetFailed
+	}
+	return &dto.UserCodeDto{
+		ProblemID: problemID,
+		Language:  string(language),
+		Code:      template,
+	}, nil
+}
+
+// saveRevision 记录题目代码的历史版本
+func (u *userCodeService) saveRevision(tx *gorm.DB, code *po.UserCode) error {
+	return code_revision_service.SaveCodeRevision(tx, u.revisionDao, &po.UserCodeRevision{
+		UserID:     code.UserID,
+		SourceType: constants.CodeRevisionSourceUserCode,
+		SourceID:   code.ID,
+		Language:   code.Language,
+		Code:       code.Code,
+	})
+}
+
 // GetUserCode 读取用户代码
 func (u *userCodeService) GetUserCode(ctx context.Context, problemId uint, language constants.LanguageType) (string, error) {
 	userID := utils.GetUserIDWithCtx(ctx)
@@ -90,8 +143,8 @@ func (u *userCodeService) GetUserCode(ctx context.Context, problemId uint, langu
 	}
 	// 如果用户代码不存在，那么读取模板
 	if !exist {
-		// 读取acm模板
-		code, err := getAcmCodeTemplate(language)
+		// 读取题目的代码模板
+		code, err := u.getTemplateCode(problemId, language)
 		if err != nil {
 			return "", e.ErrProblemGetFailed
 		}
@@ -120,8 +173,8 @@ func (u *userCodeService) GetUserCodeByProblemID(ctx context.Context, problemId
 	}
 	// 如果用户代码不存在，那么读取模板
 	language := constants.LanguageGo
-	// 读取acm模板
-	code, err := getAcmCodeTemplate(language)
+	// 读取题目的代码模板
+	code, err := u.getTemplateCode(problemId, language)
 	if err != nil {
 		logger.WithCtx(ctx).Printf("get template fail, err = %v", err)
 		return nil, e.ErrProblemGetFailed
@@ -132,30 +185,22 @@ func (u *userCodeService) GetUserCodeByProblemID(ctx context.Context, problemId
 	}, nil
 }
 
-func (u *userCodeService) GetProblemTemplateCode(ctx context.Context, language string) (string, error) {
-	// 读取acm模板
-	code, err := getAcmCodeTemplate(constants.LanguageType(language))
+func (u *userCodeService) GetProblemTemplateCode(ctx context.Context, problemID uint, language string) (string, error) {
+	code, err := u.getTemplateCode(problemID, constants.LanguageType(language))
 	if err != nil {
 		return "", e.ErrProblemGetFailed
 	}
 	return code, nil
 }
 
-func getAcmCodeTemplate(language constants.LanguageType) (string, error) {
-	// 规定配置读取位置在执行文件所在目录下的/conf目录下
-	// 读取当前位置
-	dir, _ := os.Getwd()
-	dir = strings.ReplaceAll(dir, "\\", "/")
-	// 通过环境变量控制读取哪个配置文件
-	var filePath string
-	switch language {
-	case constants.LanguageC:
-		filePath = filepath.Join(dir, AcmCCodeFilePath)
-	case constants.LanguageGo:
-		filePath = filepath.Join(dir, AcmGoCodeFilePath)
-	case constants.LanguageJava:
-		filePath = filepath.Join(dir, AcmJavaCodeFilePath)
-	}
-	code, err := os.ReadFile(filePath)
-	return string(code), err
+// getTemplateCode 读取题目在某个语言下的代码模板，题目没有设置模板时读取默认的acm模板
+func (u *userCodeService) getTemplateCode(problemID uint, language constants.LanguageType) (string, error) {
+	template, err := u.problemTemplateDao.GetProblemTemplate(common.Mysql, problemID, string(language))
+	if err == nil {
+		return template.Code, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	return problem_service.GetAcmCodeTemplate(language)
 }
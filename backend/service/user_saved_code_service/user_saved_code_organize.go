@@ -0,0 +1,274 @@
+package user_saved_code_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+// UpdateMeta 修改代码的标题、文件夹和标签，不会记录历史版本
+func (u *userSavedCodeService) UpdateMeta(ctx context.Context, req *dto.UpdateSavedCodeMetaRequest) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+	folderID, err := u.checkFolder(ctx, req.FolderID)
+	if err != nil {
+		return err
+	}
+	savedCode, err := u.savedCodeDao.GetUserSavedCodeByID(common.Mysql, req.ID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.NewRecordNotFoundErr("saved code not found")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[UpdateMeta] GetUserSavedCodeByID fail, err = %v", err)
+		return e.ErrMysql
+	}
+	savedCode.Title = req.Title
+	savedCode.FolderID = folderID
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := u.savedCodeDao.UpdateUserSavedCodeMeta(tx, savedCode); err != nil {
+			return err
+		}
+		return u.tagDao.ReplaceTags(tx, userID, savedCode.ID, tags)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[UpdateMeta] update fail, err = %v", err)
+		return e.ErrMysql
+	}
+	u.searchService.IndexSavedCode(ctx, savedCode)
+	return nil
+}
+
+// StarSavedCode 收藏或取消收藏代码
+func (u *userSavedCodeService) StarSavedCode(ctx context.Context, req *dto.StarSavedCodeRequest) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	exists, err := u.savedCodeDao.CheckUserSavedCodeExists(common.Mysql, req.ID, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[StarSavedCode] CheckUserSavedCodeExists fail, err = %v", err)
+		return e.ErrMysql
+	}
+	if !exists {
+		return e.NewRecordNotFoundErr("saved code not found")
+	}
+	if _, err = u.savedCodeDao.UpdateUserSavedCodeStarred(common.Mysql, req.ID, userID, req.Starred); err != nil {
+		logger.WithCtx(ctx).Errorf("[StarSavedCode] UpdateUserSavedCodeStarred fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+// BatchMove 批量移动代码到文件夹，不属于用户的代码会被忽略
+func (u *userSavedCodeService) BatchMove(ctx context.Context, req *dto.BatchMoveSavedCodeRequest) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	folderID, err := u.checkFolder(ctx, req.FolderID)
+	if err != nil {
+		return err
+	}
+	if _, err = u.savedCodeDao.MoveUserSavedCodes(common.Mysql, req.IDs, userID, folderID); err != nil {
+		logger.WithCtx(ctx).Errorf("[BatchMove] MoveUserSavedCodes fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+// BatchDelete 批量删除代码，不属于用户的代码会被忽略
+func (u *userSavedCodeService) BatchDelete(ctx context.Context, req *dto.BatchDeleteSavedCodeRequest) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	savedCodes, err := u.savedCodeDao.GetUserSavedCodesByIDs(common.Mysql, req.IDs, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[BatchDelete] GetUserSavedCodesByIDs fail, err = %v", err)
+		return e.ErrMysql
+	}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		for _, savedCode := range savedCodes {
+			if err := u.deleteSavedCode(tx, savedCode.ID, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[BatchDelete] delete fail, err = %v", err)
+		return e.ErrMysql
+	}
+	for _, savedCode := range savedCodes {
+		u.searchService.RemoveSavedCode(ctx, savedCode.ID)
+	}
+	return nil
+}
+
+// GetFolderList 获取用户的所有文件夹以及文件夹中代码的数量
+func (u *userSavedCodeService) GetFolderList(ctx context.Context) ([]*dto.SavedCodeFolderDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	folders, err := u.folderDao.GetFolderList(common.Mysql, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetFolderList] GetFolderList fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	counts, err := u.savedCodeDao.CountUserSavedCodeByFolder(common.Mysql, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetFolderList] CountUserSavedCodeByFolder fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.SavedCodeFolderDto, 0, len(folders))
+	for _, folder := range folders {
+		answer = append(answer, &dto.SavedCodeFolderDto{
+			ID:        folder.ID,
+			Name:      folder.Name,
+			CodeCount: counts[folder.ID],
+			CreatedAt: utils.Time(folder.CreatedAt),
+		})
+	}
+	return answer, nil
+}
+
+// SaveFolder 创建或重命名文件夹，同一个用户的文件夹不能重名
+func (u *userSavedCodeService) SaveFolder(ctx context.Context, req *dto.SavedCodeFolderRequest) (*dto.SavedCodeFolderDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, e.NewParamErr("文件夹名称不能为空")
+	}
+	existing, err := u.folderDao.GetFolderByName(common.Mysql, userID, name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.WithCtx(ctx).Errorf("[SaveFolder] GetFolderByName fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	if err == nil && existing.ID != req.ID {
+		return nil, e.NewParamErr(fmt.Sprintf("文件夹已存在: %s", name))
+	}
+	if req.ID == 0 {
+		folder := &po.UserSavedCodeFolder{UserID: userID, Name: name}
+		if err = u.folderDao.InsertFolder(common.Mysql, folder); err != nil {
+			logger.WithCtx(ctx).Errorf("[SaveFolder] InsertFolder fail, err = %v", err)
+			return nil, e.ErrMysql
+		}
+		return &dto.SavedCodeFolderDto{ID: folder.ID, Name: folder.Name, CreatedAt: utils.Time(folder.CreatedAt)}, nil
+	}
+	folder, err := u.folderDao.GetFolderByID(common.Mysql, req.ID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("folder not found")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[SaveFolder] GetFolderByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	if err = u.folderDao.UpdateFolderName(common.Mysql, folder.ID, userID, name); err != nil {
+		logger.WithCtx(ctx).Errorf("[SaveFolder] UpdateFolderName fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return &dto.SavedCodeFolderDto{ID: folder.ID, Name: name, CreatedAt: utils.Time(folder.CreatedAt)}, nil
+}
+
+// DeleteFolder 删除文件夹，文件夹中的代码移动到根目录
+func (u *userSavedCodeService) DeleteFolder(ctx context.Context, id uint) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if _, err := u.checkFolder(ctx, &id); err != nil {
+		return err
+	}
+	err := common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := u.folderDao.ClearFolder(tx, id, userID); err != nil {
+			return err
+		}
+		return u.folderDao.DeleteFolder(tx, id, userID)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteFolder] delete fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+// GetTagList 获取用户使用过的所有标签以及使用次数
+func (u *userSavedCodeService) GetTagList(ctx context.Context) ([]*dto.SavedCodeTagDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	tags, err := u.tagDao.GetTagCountList(common.Mysql, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetTagList] GetTagCountList fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.SavedCodeTagDto, 0, len(tags))
+	for _, tag := range tags {
+		answer = append(answer, &dto.SavedCodeTagDto{Name: tag.Name, Count: tag.Count})
+	}
+	return answer, nil
+}
+
+// checkFolder 校验文件夹属于当前用户，文件夹为空或者为0时表示根目录，返回nil
+func (u *userSavedCodeService) checkFolder(ctx context.Context, folderID *uint) (*uint, error) {
+	if folderID == nil || *folderID == 0 {
+		return nil, nil
+	}
+	userID := utils.GetUserIDWithCtx(ctx)
+	_, err := u.folderDao.GetFolderByID(common.Mysql, *folderID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("folder not found")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[checkFolder] GetFolderByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return folderID, nil
+}
+
+// newDetailDto 生成代码详情，包含代码的标签
+func (u *userSavedCodeService) newDetailDto(ctx context.Context, savedCode *po.UserSavedCode) *dto.UserSavedCodeDtoForDetail {
+	answer := dto.NewUserSavedCodeDtoForDetail(savedCode)
+	tags, err := u.tagDao.GetTagsBySavedCodeIDs(common.Mysql, []uint{savedCode.ID})
+	if err != nil {
+		logger.WithCtx(ctx).Warnf("[newDetailDto] GetTagsBySavedCodeIDs fail, err = %v", err)
+		return answer
+	}
+	if codeTags, ok := tags[savedCode.ID]; ok {
+		answer.Tags = codeTags
+	}
+	return answer
+}
+
+// deleteSavedCode 删除代码，同时取消代码的所有分享，删除代码的历史版本和标签
+func (u *userSavedCodeService) deleteSavedCode(tx *gorm.DB, id uint, userID uint) error {
+	if err := u.savedCodeDao.DeleteUserSavedCode(tx, id, userID); err != nil {
+		return err
+	}
+	if err := u.shareDao.DeleteShareBySavedCodeID(tx, id, userID); err != nil {
+		return err
+	}
+	if err := u.tagDao.DeleteTagsBySavedCodeIDs(tx, []uint{id}); err != nil {
+		return err
+	}
+	return u.revisionDao.DeleteRevisionBySource(tx, constants.CodeRevisionSourceSavedCode, id)
+}
+
+// normalizeTags 去掉标签两端的空白并去重，保持原有的顺序
+func normalizeTags(tags []string) ([]string, error) {
+	answer := make([]string, 0, len(tags))
+	exists := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || exists[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > constants.MaxSavedCodeTagLength {
+			return nil, e.NewParamErr(fmt.Sprintf("标签过长: %s", tag))
+		}
+		exists[tag] = true
+		answer = append(answer, tag)
+	}
+	if len(answer) > constants.MaxSavedCodeTags {
+		return nil, e.NewParamErr(fmt.Sprintf("标签不能超过%d个", constants.MaxSavedCodeTags))
+	}
+	return answer, nil
+}
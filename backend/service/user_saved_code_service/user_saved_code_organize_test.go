@@ -0,0 +1,32 @@
+package user_saved_code_service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{" 排序 ", "链表", "排序", "", "  "})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"排序", "链表"}, tags)
+
+	tags, err = normalizeTags(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, tags)
+
+	// 长度按字符计算
+	_, err = normalizeTags([]string{strings.Repeat("树", constants.MaxSavedCodeTagLength)})
+	assert.Nil(t, err)
+	_, err = normalizeTags([]string{strings.Repeat("树", constants.MaxSavedCodeTagLength+1)})
+	assert.NotNil(t, err)
+
+	tooMany := make([]string, 0, constants.MaxSavedCodeTags+1)
+	for i := 0; i <= constants.MaxSavedCodeTags; i++ {
+		tooMany = append(tooMany, strings.Repeat("a", i+1))
+	}
+	_, err = normalizeTags(tooMany)
+	assert.NotNil(t, err)
+}
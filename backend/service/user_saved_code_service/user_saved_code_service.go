@@ -7,9 +7,12 @@ import (
 	conf "github.com/fansqz/fancode-backend/common/config"
 	e "github.com/fansqz/fancode-backend/common/error"
 	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/dao"
 	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/service/code_revision_service"
+	"github.com/fansqz/fancode-backend/service/search_service"
 	"github.com/fansqz/fancode-backend/utils"
 	"gorm.io/gorm"
 )
@@ -25,42 +28,114 @@ type UserSavedCodeService interface {
 	GetUserSavedCodeByID(ctx context.Context, id uint) (*dto.UserSavedCodeDtoForDetail, error)
 	// GetUserSavedCodeList 获取用户保存的代码列表
 	GetUserSavedCodeList(ctx context.Context, req *dto.UserSavedCodeDtoForQuery) (*dto.PageInfo, error)
+	// UpdateVisualDescription 更新用户保存代码的可视化描述，描述为空时清除
+	UpdateVisualDescription(ctx context.Context, req *dto.UpdateSavedCodeVisualDescriptionRequest) error
+	// CreateShare 为用户保存的代码创建分享
+	CreateShare(ctx context.Context, req *dto.CreateSavedCodeShareRequest) (*dto.SavedCodeShareDto, error)
+	// GetShareList 获取用户某个代码的所有分享
+	GetShareList(ctx context.Context, savedCodeID uint) ([]*dto.SavedCodeShareDto, error)
+	// DeleteShare 取消分享
+	DeleteShare(ctx context.Context, shareID string) error
+	// GetSharedCode 通过分享链接获取代码，不需要登录
+	GetSharedCode(ctx context.Context, shareID string) (*dto.SharedCodeDto, error)
+	// ForkSharedCode 将分享的代码复制到当前用户保存的代码中
+	ForkSharedCode(ctx context.Context, shareID string) (*dto.UserSavedCodeDtoForDetail, error)
+	// UpdateMeta 修改代码的标题、文件夹和标签
+	UpdateMeta(ctx context.Context, req *dto.UpdateSavedCodeMetaRequest) error
+	// StarSavedCode 收藏或取消收藏代码
+	StarSavedCode(ctx context.Context, req *dto.StarSavedCodeRequest) error
+	// BatchMove 批量移动代码到文件夹
+	BatchMove(ctx context.Context, req *dto.BatchMoveSavedCodeRequest) error
+	// BatchDelete 批量删除代码
+	BatchDelete(ctx context.Context, req *dto.BatchDeleteSavedCodeRequest) error
+	// GetFolderList 获取用户的所有文件夹
+	GetFolderList(ctx context.Context) ([]*dto.SavedCodeFolderDto, error)
+	// SaveFolder 创建或重命名文件夹
+	SaveFolder(ctx context.Context, req *dto.SavedCodeFolderRequest) (*dto.SavedCodeFolderDto, error)
+	// DeleteFolder 删除文件夹，文件夹中的代码移动到根目录
+	DeleteFolder(ctx context.Context, id uint) error
+	// GetTagList 获取用户使用过的所有标签
+	GetTagList(ctx context.Context) ([]*dto.SavedCodeTagDto, error)
 }
 
 type userSavedCodeService struct {
-	savedCodeDao dao.UserSavedCodeDao
+	savedCodeDao  dao.UserSavedCodeDao
+	shareDao      dao.UserSavedCodeShareDao
+	revisionDao   dao.UserCodeRevisionDao
+	folderDao     dao.UserSavedCodeFolderDao
+	tagDao        dao.UserSavedCodeTagDao
+	sysUserDao    dao.SysUserDao
+	searchService search_service.SearchService
 }
 
-func NewUserSavedCodeService(config *conf.AppConfig, savedCodeDao dao.UserSavedCodeDao) UserSavedCodeService {
+func NewUserSavedCodeService(config *conf.AppConfig, savedCodeDao dao.UserSavedCodeDao, shareDao dao.UserSavedCodeShareDao,
+	revisionDao dao.UserCodeRevisionDao, folderDao dao.UserSavedCodeFolderDao, tagDao dao.UserSavedCodeTagDao,
+	sysUserDao dao.SysUserDao, searchService search_service.SearchService) UserSavedCodeService {
 	return &userSavedCodeService{
-		savedCodeDao: savedCodeDao,
+		savedCodeDao:  savedCodeDao,
+		shareDao:      shareDao,
+		revisionDao:   revisionDao,
+		folderDao:     folderDao,
+		tagDao:        tagDao,
+		sysUserDao:    sysUserDao,
+		searchService: searchService,
 	}
 }
 
 // CreateUserSavedCode 创建用户保存的代码
 func (u *userSavedCodeService) CreateUserSavedCode(ctx context.Context, req *dto.UserSavedCodeDtoForCreate) (*dto.UserSavedCodeDtoForDetail, error) {
 	userID := utils.GetUserIDWithCtx(ctx)
+	if err := validateVisualDescription(req.VisualDescription); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	folderID, err := u.checkFolder(ctx, req.FolderID)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建新的保存代码记录
 	savedCode := &po.UserSavedCode{
-		UserID:     userID,
-		DocumentID: req.DocumentID,
-		Language:   req.Language,
-		Code:       req.Code,
-		Remark:     req.Remark,
+		UserID:            userID,
+		DocumentID:        req.DocumentID,
+		Language:          req.Language,
+		Code:              req.Code,
+		Remark:            req.Remark,
+		Title:             req.Title,
+		FolderID:          folderID,
+		Starred:           req.Starred,
+		VisualDescription: dto.FormatVisualDescription(req.VisualDescription),
 	}
 
-	if err := u.savedCodeDao.CreateUserSavedCode(common.Mysql, savedCode); err != nil {
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := u.savedCodeDao.CreateUserSavedCode(tx, savedCode); err != nil {
+			return err
+		}
+		if err := u.tagDao.ReplaceTags(tx, userID, savedCode.ID, tags); err != nil {
+			return err
+		}
+		return u.saveRevision(tx, savedCode)
+	})
+	if err != nil {
 		logger.WithCtx(ctx).Errorf("[CreateUserSavedCode] CreateUserSavedCode fail, err = %v", err)
 		return nil, e.ErrUnknown
 	}
+	u.searchService.IndexSavedCode(ctx, savedCode)
 
-	return dto.NewUserSavedCodeDtoForDetail(savedCode), nil
+	answer := dto.NewUserSavedCodeDtoForDetail(savedCode)
+	answer.Tags = tags
+	return answer, nil
 }
 
 // UpdateUserSavedCode 更新用户保存的代码
 func (u *userSavedCodeService) UpdateUserSavedCode(ctx context.Context, req *dto.UserSavedCodeDtoForUpdate) (*dto.UserSavedCodeDtoForDetail, error) {
 	userID := utils.GetUserIDWithCtx(ctx)
+	if err := validateVisualDescription(req.VisualDescription); err != nil {
+		return nil, err
+	}
 
 	// 检查代码是否存在
 	exists, err := u.savedCodeDao.CheckUserSavedCodeExists(common.Mysql, req.ID, userID)
@@ -74,14 +149,22 @@ func (u *userSavedCodeService) UpdateUserSavedCode(ctx context.Context, req *dto
 
 	// 更新保存代码记录
 	savedCode := &po.UserSavedCode{
-		Model:    gorm.Model{ID: req.ID},
-		UserID:   userID,
-		Language: req.Language,
-		Code:     req.Code,
-		Remark:   req.Remark,
+		Model:             gorm.Model{ID: req.ID},
+		UserID:            userID,
+		Language:          req.Language,
+		Code:              req.Code,
+		Remark:            req.Remark,
+		VisualDescription: dto.FormatVisualDescription(req.VisualDescription),
 	}
 
-	if err = u.savedCodeDao.UpdateUserSavedCode(common.Mysql, savedCode); err != nil {
+	// 每次保存都记录历史版本，避免误操作覆盖代码以后无法找回
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := u.savedCodeDao.UpdateUserSavedCode(tx, savedCode); err != nil {
+			return err
+		}
+		return u.saveRevision(tx, savedCode)
+	})
+	if err != nil {
 		logger.WithCtx(ctx).Errorf("[UpdateUserSavedCode] UpdateUserSavedCode fail, err = %v", err)
 		return nil, e.ErrUnknown
 	}
@@ -92,8 +175,9 @@ func (u *userSavedCodeService) UpdateUserSavedCode(ctx context.Context, req *dto
 		logger.WithCtx(ctx).Errorf("[UpdateUserSavedCode] GetUserSavedCodeByID fail, err = %v", err)
 		return nil, e.ErrUnknown
 	}
+	u.searchService.IndexSavedCode(ctx, updatedCode)
 
-	return dto.NewUserSavedCodeDtoForDetail(updatedCode), nil
+	return u.newDetailDto(ctx, updatedCode), nil
 }
 
 // DeleteUserSavedCode 删除用户保存的代码
@@ -110,10 +194,14 @@ func (u *userSavedCodeService) DeleteUserSavedCode(ctx context.Context, id uint)
 		return e.NewRecordNotFoundErr("saved code not found")
 	}
 
-	if err = u.savedCodeDao.DeleteUserSavedCode(common.Mysql, id, userID); err != nil {
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		return u.deleteSavedCode(tx, id, userID)
+	})
+	if err != nil {
 		logger.WithCtx(ctx).Errorf("[DeleteUserSavedCode] DeleteUserSavedCode fail, err = %v", err)
 		return e.ErrUnknown
 	}
+	u.searchService.RemoveSavedCode(ctx, id)
 
 	return nil
 }
@@ -128,7 +216,7 @@ func (u *userSavedCodeService) GetUserSavedCodeByID(ctx context.Context, id uint
 		return nil, e.NewRecordNotFoundErr("saved code not found")
 	}
 
-	return dto.NewUserSavedCodeDtoForDetail(savedCode), nil
+	return u.newDetailDto(ctx, savedCode), nil
 }
 
 // GetUserSavedCodeList 获取用户保存的代码列表
@@ -143,16 +231,29 @@ func (u *userSavedCodeService) GetUserSavedCodeList(ctx context.Context, req *dt
 		req.PageSize = 10
 	}
 
-	savedCodes, total, err := u.savedCodeDao.GetUserSavedCodeList(common.Mysql, userID, req.DocumentID, req.Language, req.Page, req.PageSize)
+	savedCodes, total, err := u.savedCodeDao.GetUserSavedCodeList(common.Mysql, userID, req)
 	if err != nil {
 		logger.WithCtx(ctx).Errorf("[GetUserSavedCodeList] GetUserSavedCodeList fail, err = %v", err)
 		return nil, e.ErrUnknown
 	}
+	ids := make([]uint, 0, len(savedCodes))
+	for _, savedCode := range savedCodes {
+		ids = append(ids, savedCode.ID)
+	}
+	tags, err := u.tagDao.GetTagsBySavedCodeIDs(common.Mysql, ids)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetUserSavedCodeList] GetTagsBySavedCodeIDs fail, err = %v", err)
+		return nil, e.ErrUnknown
+	}
 
 	// 转换为DTO
 	result := make([]*dto.UserSavedCodeDtoForList, 0, len(savedCodes))
 	for _, savedCode := range savedCodes {
-		result = append(result, dto.NewUserSavedCodeDtoForList(savedCode))
+		item := dto.NewUserSavedCodeDtoForList(savedCode)
+		if codeTags, ok := tags[savedCode.ID]; ok {
+			item.Tags = codeTags
+		}
+		result = append(result, item)
 	}
 
 	return &dto.PageInfo{
@@ -161,3 +262,49 @@ func (u *userSavedCodeService) GetUserSavedCodeList(ctx context.Context, req *dt
 		List:  result,
 	}, nil
 }
+
+// UpdateVisualDescription 更新用户保存代码的可视化描述，描述为空时清除
+func (u *userSavedCodeService) UpdateVisualDescription(ctx context.Context, req *dto.UpdateSavedCodeVisualDescriptionRequest) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if err := validateVisualDescription(req.VisualDescription); err != nil {
+		return err
+	}
+
+	exists, err := u.savedCodeDao.CheckUserSavedCodeExists(common.Mysql, req.ID, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[UpdateVisualDescription] CheckUserSavedCodeExists fail, err = %v", err)
+		return e.ErrUnknown
+	}
+	if !exists {
+		return e.NewRecordNotFoundErr("saved code not found")
+	}
+
+	if err = u.savedCodeDao.UpdateUserSavedCodeVisualDescription(common.Mysql, req.ID, userID,
+		dto.FormatVisualDescription(req.VisualDescription)); err != nil {
+		logger.WithCtx(ctx).Errorf("[UpdateVisualDescription] UpdateUserSavedCodeVisualDescription fail, err = %v", err)
+		return e.ErrUnknown
+	}
+	return nil
+}
+
+// saveRevision 记录用户保存的代码的历史版本
+func (u *userSavedCodeService) saveRevision(tx *gorm.DB, savedCode *po.UserSavedCode) error {
+	return code_revision_service.SaveCodeRevision(tx, u.revisionDao, &po.UserCodeRevision{
+		UserID:     savedCode.UserID,
+		SourceType: constants.CodeRevisionSourceSavedCode,
+		SourceID:   savedCode.ID,
+		Language:   savedCode.Language,
+		Code:       savedCode.Code,
+	})
+}
+
+// validateVisualDescription 校验用户指定的可视化描述，未指定时不做校验
+func validateVisualDescription(visualDescription *dto.VisualDescription) error {
+	if visualDescription == nil {
+		return nil
+	}
+	if err := visualDescription.Validate(); err != nil {
+		return e.NewError(e.CodeVisualDescriptionInvalid, err.Error(), e.ErrTypeBadReq)
+	}
+	return nil
+}
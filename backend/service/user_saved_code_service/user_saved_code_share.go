@@ -0,0 +1,156 @@
+package user_saved_code_service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+// shareIDBytes 分享id的随机字节数，生成的分享id长度为32
+const shareIDBytes = 16
+
+// CreateShare 为用户保存的代码创建分享，分享保存的是当前代码的快照
+func (u *userSavedCodeService) CreateShare(ctx context.Context, req *dto.CreateSavedCodeShareRequest) (*dto.SavedCodeShareDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	savedCode, err := u.savedCodeDao.GetUserSavedCodeByID(common.Mysql, req.SavedCodeID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("saved code not found")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateShare] GetUserSavedCodeByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	shareID, err := utils.GetSecureRandomHex(shareIDBytes)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateShare] generate share id fail, err = %v", err)
+		return nil, e.ErrUnknown
+	}
+	share := &po.UserSavedCodeShare{
+		ShareID:           shareID,
+		SavedCodeID:       savedCode.ID,
+		UserID:            userID,
+		Language:          savedCode.Language,
+		Code:              savedCode.Code,
+		Remark:            savedCode.Remark,
+		VisualDescription: savedCode.VisualDescription,
+		ExpireAt:          newShareExpireAt(time.Now(), req.ExpireDays),
+	}
+	if err = u.shareDao.CreateShare(common.Mysql, share); err != nil {
+		logger.WithCtx(ctx).Errorf("[CreateShare] CreateShare fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return dto.NewSavedCodeShareDto(share), nil
+}
+
+// GetShareList 获取用户某个代码的所有分享
+func (u *userSavedCodeService) GetShareList(ctx context.Context, savedCodeID uint) ([]*dto.SavedCodeShareDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	shares, err := u.shareDao.GetShareListBySavedCodeID(common.Mysql, savedCodeID, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetShareList] GetShareListBySavedCodeID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.SavedCodeShareDto, 0, len(shares))
+	for _, share := range shares {
+		answer = append(answer, dto.NewSavedCodeShareDto(share))
+	}
+	return answer, nil
+}
+
+// DeleteShare 取消分享，之后分享链接不能再访问
+func (u *userSavedCodeService) DeleteShare(ctx context.Context, shareID string) error {
+	userID := utils.GetUserIDWithCtx(ctx)
+	rows, err := u.shareDao.DeleteShare(common.Mysql, shareID, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteShare] DeleteShare fail, err = %v", err)
+		return e.ErrMysql
+	}
+	if rows == 0 {
+		return e.NewRecordNotFoundErr("share not found")
+	}
+	return nil
+}
+
+// GetSharedCode 通过分享链接获取代码，不需要登录
+func (u *userSavedCodeService) GetSharedCode(ctx context.Context, shareID string) (*dto.SharedCodeDto, error) {
+	share, err := u.getShare(ctx, shareID)
+	if err != nil {
+		return nil, err
+	}
+	author, err := u.sysUserDao.GetUserNameByID(common.Mysql, share.UserID)
+	if err != nil {
+		logger.WithCtx(ctx).Warnf("[GetSharedCode] GetUserNameByID fail, err = %v", err)
+	}
+	return dto.NewSharedCodeDto(share, author), nil
+}
+
+// ForkSharedCode 将分享的代码复制到当前用户保存的代码中
+func (u *userSavedCodeService) ForkSharedCode(ctx context.Context, shareID string) (*dto.UserSavedCodeDtoForDetail, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if userID == 0 {
+		return nil, e.ErrSessionExpire
+	}
+	share, err := u.getShare(ctx, shareID)
+	if err != nil {
+		return nil, err
+	}
+	savedCode := &po.UserSavedCode{
+		UserID:            userID,
+		Language:          share.Language,
+		Code:              share.Code,
+		Remark:            share.Remark,
+		VisualDescription: share.VisualDescription,
+	}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := u.savedCodeDao.CreateUserSavedCode(tx, savedCode); err != nil {
+			return err
+		}
+		if err := u.saveRevision(tx, savedCode); err != nil {
+			return err
+		}
+		return u.shareDao.IncreaseForkCount(tx, share.ID)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[ForkSharedCode] fork fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	u.searchService.IndexSavedCode(ctx, savedCode)
+	return dto.NewUserSavedCodeDtoForDetail(savedCode), nil
+}
+
+// getShare 获取有效的分享，分享不存在或者已过期时返回错误
+func (u *userSavedCodeService) getShare(ctx context.Context, shareID string) (*po.UserSavedCodeShare, error) {
+	share, err := u.shareDao.GetShareByShareID(common.Mysql, shareID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("share not found")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getShare] GetShareByShareID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	if isShareExpired(share, time.Now()) {
+		return nil, e.ErrSavedCodeShareExpired
+	}
+	return share, nil
+}
+
+// newShareExpireAt 计算分享的过期时间，有效天数为0时永久有效
+func newShareExpireAt(now time.Time, expireDays int) *time.Time {
+	if expireDays <= 0 {
+		return nil
+	}
+	expireAt := now.AddDate(0, 0, expireDays)
+	return &expireAt
+}
+
+func isShareExpired(share *po.UserSavedCodeShare, now time.Time) bool {
+	return share.ExpireAt != nil && !now.Before(*share.ExpireAt)
+}
@@ -0,0 +1,32 @@
+package user_saved_code_service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareExpire(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.Local)
+	// 有效天数为0时永久有效
+	share := &po.UserSavedCodeShare{ExpireAt: newShareExpireAt(now, 0)}
+	assert.Nil(t, share.ExpireAt)
+	assert.False(t, isShareExpired(share, now.AddDate(10, 0, 0)))
+
+	share = &po.UserSavedCodeShare{ExpireAt: newShareExpireAt(now, 7)}
+	assert.Equal(t, now.AddDate(0, 0, 7), *share.ExpireAt)
+	assert.False(t, isShareExpired(share, now.AddDate(0, 0, 6)))
+	assert.True(t, isShareExpired(share, now.AddDate(0, 0, 7)))
+}
+
+func TestShareID(t *testing.T) {
+	first, err := utils.GetSecureRandomHex(shareIDBytes)
+	assert.Nil(t, err)
+	assert.Len(t, first, 32)
+	second, err := utils.GetSecureRandomHex(shareIDBytes)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,439 @@
+package ai_analyze_core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common/ai_provider"
+	"github.com/fansqz/fancode-backend/common/config"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
+)
+
+const (
+	// maxPromptVariables 提示词中最多携带的变量个数
+	maxPromptVariables = 30
+	// maxPromptValueLength 提示词中变量值的最大长度
+	maxPromptValueLength = 200
+	// maxPromptOutputLength 提示词中程序输出的最大长度，只保留末尾
+	maxPromptOutputLength = 2000
+)
+
+// VariableChange 单步执行前后变量的变化
+type VariableChange struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+	// Added 变量在上一步中不存在，比如刚进入作用域
+	Added bool `json:"added"`
+}
+
+// StepContext 讲解当前步骤需要的程序状态
+type StepContext struct {
+	Language constants.LanguageType
+	Code     string
+	// StoppedReason 程序暂停的原因
+	StoppedReason constants.StoppedReasonType
+	// Stack 当前栈帧，第一个为栈顶
+	Stack []*debug_core.StackFrame
+	// Variables 栈顶的变量
+	Variables []*debug_core.Variable
+	// ChangedVariables 与上一次暂停相比发生变化的变量
+	ChangedVariables []*VariableChange
+	// VisualDescription 可视化描述，可能为nil
+	VisualDescription *dto.VisualDescription
+	// VisualSnapshot 当前可视化数据，可能为nil
+	VisualSnapshot interface{}
+}
+
+// ProgramStatus 程序的状态，用于诊断程序崩溃或者卡住的原因
+type ProgramStatus string
+
+const (
+	// ProgramCrashed 程序因为异常暂停
+	ProgramCrashed ProgramStatus = "crashed"
+	// ProgramExited 程序已经退出
+	ProgramExited ProgramStatus = "exited"
+	// ProgramRunning 程序还在运行，用户认为程序卡住了
+	ProgramRunning ProgramStatus = "running"
+	// ProgramStopped 程序正常暂停
+	ProgramStopped ProgramStatus = "stopped"
+)
+
+// DiagnoseContext 诊断程序崩溃或者卡住需要的程序状态
+type DiagnoseContext struct {
+	Language      constants.LanguageType
+	Code          string
+	Status        ProgramStatus
+	StoppedReason constants.StoppedReasonType
+	ExitCode      int
+	ExitMessage   string
+	// Stack 最后一次暂停时的栈帧
+	Stack []*debug_core.StackFrame
+	// Variables 最后一次暂停时栈顶的变量
+	Variables []*debug_core.Variable
+	// Output 程序输出的末尾部分
+	Output string
+	// RunningFor 程序在没有暂停的情况下已经运行的时间
+	RunningFor time.Duration
+}
+
+// CompileErrorContext 讲解编译错误需要的信息
+type CompileErrorContext struct {
+	Language    constants.LanguageType
+	Code        string
+	Message     string
+	Diagnostics []*debug_core.CompileDiagnostic
+}
+
+// DebugAssistant AI调试助手，讲解单步执行的效果、编译错误，诊断程序崩溃或者卡住的原因
+// 没有配置AI服务或者AI服务失败时，根据程序状态生成简单的说明
+type DebugAssistant struct {
+	// aiProvider 为nil表示没有配置AI服务
+	aiProvider ai_provider.AIProvider
+}
+
+// NewDebugAssistant 创建AI调试助手
+func NewDebugAssistant(aiConfig *config.AIConfig) *DebugAssistant {
+	return &DebugAssistant{aiProvider: ai_provider.NewAIProviderIfConfigured(aiConfig)}
+}
+
+// NewDebugAssistantWithProvider 使用指定的AI服务创建调试助手，provider为nil时只生成简单说明
+func NewDebugAssistantWithProvider(provider ai_provider.AIProvider) *DebugAssistant {
+	return &DebugAssistant{aiProvider: provider}
+}
+
+// ExplainStep 讲解当前步骤，流式返回讲解内容
+func (a *DebugAssistant) ExplainStep(ctx context.Context, step *StepContext) (<-chan *ai_provider.StreamChunk, error) {
+	req := ai_provider.NewChatRequest(explainSystemPrompt, buildExplainPrompt(step))
+	return a.stream(ctx, req, func() string { return fallbackExplanation(step) })
+}
+
+// Diagnose 诊断程序崩溃或者卡住的原因，流式返回诊断内容
+func (a *DebugAssistant) Diagnose(ctx context.Context, diagnose *DiagnoseContext) (<-chan *ai_provider.StreamChunk, error) {
+	req := ai_provider.NewChatRequest(diagnoseSystemPrompt, buildDiagnosePrompt(diagnose))
+	return a.stream(ctx, req, func() string { return fallbackDiagnosis(diagnose) })
+}
+
+// ExplainCompileError 用通俗的语言讲解编译错误并给出修改建议，流式返回讲解内容
+func (a *DebugAssistant) ExplainCompileError(ctx context.Context, compileError *CompileErrorContext) (<-chan *ai_provider.StreamChunk, error) {
+	req := ai_provider.NewChatRequest(compileSystemPrompt, buildCompilePrompt(compileError))
+	return a.stream(ctx, req, func() string { return fallbackCompileExplanation(compileError) })
+}
+
+// stream 调用AI服务，没有配置AI服务或者建立连接失败时返回简单说明
+func (a *DebugAssistant) stream(ctx context.Context, req *ai_provider.ChatRequest, fallback func() string) (<-chan *ai_provider.StreamChunk, error) {
+	if a.aiProvider != nil {
+		chunks, err := a.aiProvider.ChatStream(ctx, req)
+		if err == nil {
+			return chunks, nil
+		}
+		logger.WithCtx(ctx).Warnf("[DebugAssistant] AI stream failed, use fallback: %v", err)
+	}
+	chunks := make(chan *ai_provider.StreamChunk, 2)
+	chunks <- &ai_provider.StreamChunk{Content: fallback()}
+	chunks <- &ai_provider.StreamChunk{Done: true, Usage: &ai_provider.Usage{}}
+	close(chunks)
+	return chunks, nil
+}
+
+// DiffVariables 比较前后两次暂停时的变量，返回新增和值发生变化的变量
+func DiffVariables(previous []*debug_core.Variable, current []*debug_core.Variable) []*VariableChange {
+	old := make(map[string]*debug_core.Variable, len(previous))
+	for _, variable := range previous {
+		old[variable.Name] = variable
+	}
+	var answer []*VariableChange
+	for _, variable := range current {
+		before, ok := old[variable.Name]
+		if !ok {
+			answer = append(answer, &VariableChange{
+				Name:     variable.Name,
+				Type:     variable.Type,
+				NewValue: variable.Value,
+				Added:    true,
+			})
+			continue
+		}
+		if before.Value != variable.Value {
+			answer = append(answer, &VariableChange{
+				Name:     variable.Name,
+				Type:     variable.Type,
+				OldValue: before.Value,
+				NewValue: variable.Value,
+			})
+		}
+	}
+	return answer
+}
+
+const explainSystemPrompt = "你是一名耐心的编程助教，正在帮助初学者单步调试程序。" +
+	"请根据给出的程序状态，用简洁的中文讲解刚刚执行的这一步做了什么、变量为什么这样变化，以及接下来会发生什么。" +
+	"不要直接给出完整的修改后代码，讲解控制在200字以内。"
+
+const diagnoseSystemPrompt = "你是一名耐心的编程助教，正在帮助初学者分析程序崩溃或者卡住的原因。" +
+	"请根据给出的程序状态，用简洁的中文指出最可能的原因、出问题的代码行，并给出排查思路。" +
+	"不要直接给出完整的修改后代码，回答控制在300字以内。"
+
+const compileSystemPrompt = "你是一名耐心的编程助教，正在帮助初学者理解编译错误。" +
+	"请针对每个编译错误，用通俗的中文说明错误的含义、出错的原因，并给出修改建议。" +
+	"只给出需要修改的代码行，不要给出完整的修改后代码，回答控制在300字以内。"
+
+// buildExplainPrompt 构建单步讲解的提示词
+func buildExplainPrompt(step *StepContext) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "以下为%s代码：\n%s\n\n", step.Language, numberedCode(step.Code))
+	line := currentLine(step.Stack)
+	fmt.Fprintf(&builder, "程序暂停原因：%s\n", step.StoppedReason)
+	if line > 0 {
+		fmt.Fprintf(&builder, "当前暂停在第%d行：%s\n", line, codeLine(step.Code, line))
+	}
+	fmt.Fprintf(&builder, "\n调用栈：\n%s\n", formatStack(step.Stack))
+	fmt.Fprintf(&builder, "\n当前函数中的变量：\n%s\n", formatVariables(step.Variables))
+	if len(step.ChangedVariables) != 0 {
+		builder.WriteString("\n与上一步相比发生变化的变量：\n")
+		for _, change := range step.ChangedVariables {
+			if change.Added {
+				fmt.Fprintf(&builder, "- %s（新出现）= %s\n", change.Name, truncate(change.NewValue, maxPromptValueLength))
+			} else {
+				fmt.Fprintf(&builder, "- %s：%s -> %s\n", change.Name,
+					truncate(change.OldValue, maxPromptValueLength), truncate(change.NewValue, maxPromptValueLength))
+			}
+		}
+	} else {
+		builder.WriteString("\n与上一步相比没有变量发生变化。\n")
+	}
+	if step.VisualDescription != nil {
+		fmt.Fprintf(&builder, "\n可视化描述：%s\n", dto.FormatVisualDescription(step.VisualDescription))
+	}
+	if step.VisualSnapshot != nil {
+		if data, err := json.Marshal(step.VisualSnapshot); err == nil {
+			fmt.Fprintf(&builder, "当前可视化数据：%s\n", truncate(string(data), maxPromptOutputLength))
+		}
+	}
+	return builder.String()
+}
+
+// buildDiagnosePrompt 构建诊断的提示词
+func buildDiagnosePrompt(diagnose *DiagnoseContext) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "以下为%s代码：\n%s\n\n", diagnose.Language, numberedCode(diagnose.Code))
+	switch diagnose.Status {
+	case ProgramCrashed:
+		fmt.Fprintf(&builder, "程序因为异常暂停，原因：%s\n", diagnose.StoppedReason)
+	case ProgramExited:
+		fmt.Fprintf(&builder, "程序已经退出，退出码：%d，信息：%s\n", diagnose.ExitCode, diagnose.ExitMessage)
+	case ProgramRunning:
+		fmt.Fprintf(&builder, "程序已经连续运行%d秒没有暂停，用户认为程序卡住了\n", int(diagnose.RunningFor.Seconds()))
+	default:
+		fmt.Fprintf(&builder, "程序处于暂停状态，原因：%s\n", diagnose.StoppedReason)
+	}
+	if line := currentLine(diagnose.Stack); line > 0 {
+		fmt.Fprintf(&builder, "最后一次暂停在第%d行：%s\n", line, codeLine(diagnose.Code, line))
+	}
+	fmt.Fprintf(&builder, "\n最后一次暂停时的调用栈：\n%s\n", formatStack(diagnose.Stack))
+	fmt.Fprintf(&builder, "\n最后一次暂停时的变量：\n%s\n", formatVariables(diagnose.Variables))
+	if diagnose.Output != "" {
+		fmt.Fprintf(&builder, "\n程序输出的末尾部分：\n%s\n", tail(diagnose.Output, maxPromptOutputLength))
+	}
+	return builder.String()
+}
+
+// buildCompilePrompt 构建编译错误讲解的提示词
+func buildCompilePrompt(compileError *CompileErrorContext) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "以下为%s代码：\n%s\n\n", compileError.Language, numberedCode(compileError.Code))
+	if len(compileError.Diagnostics) != 0 {
+		builder.WriteString("编译器报告的问题：\n")
+		for _, diagnostic := range compileError.Diagnostics {
+			fmt.Fprintf(&builder, "- [%s] 第%d行第%d列：%s\n", diagnostic.Severity, diagnostic.Line, diagnostic.Column, diagnostic.Message)
+			if diagnostic.Line > 0 {
+				fmt.Fprintf(&builder, "  代码：%s\n", strings.TrimSpace(codeLine(compileError.Code, diagnostic.Line)))
+			}
+		}
+	} else {
+		fmt.Fprintf(&builder, "编译器输出：\n%s\n", tail(compileError.Message, maxPromptOutputLength))
+	}
+	return builder.String()
+}
+
+// compileErrorHints 常见编译错误的关键字和对应的提示
+var compileErrorHints = []struct {
+	keywords []string
+	hint     string
+}{
+	{[]string{"expected ';'", "';' expected", "需要';'"}, "语句末尾缺少分号，检查这一行或者上一行的末尾。"},
+	{[]string{"undeclared", "was not declared", "undefined:", "cannot find symbol", "找不到符号"}, "使用了没有声明的变量或函数，检查名称是否拼写正确、是否在使用之前声明。"},
+	{[]string{"declared and not used", "imported and not used"}, "Go语言不允许存在未使用的变量或者导入，删除它或者使用它。"},
+	{[]string{"undefined reference"}, "函数只有声明没有定义，或者函数名拼写错误，检查函数是否实现。"},
+	{[]string{"incompatible", "cannot use", "cannot convert", "不兼容的类型"}, "类型不匹配，检查赋值或者传参两边的类型。"},
+	{[]string{"expected '}'", "expected declaration", "reached end of file", "unexpected EOF", "进行语法解析时已到达文件结尾"}, "括号没有配对，检查每个左括号是否都有对应的右括号。"},
+	{[]string{"too few arguments", "too many arguments", "not enough arguments"}, "函数调用的参数个数与定义不一致。"},
+	{[]string{"missing return", "缺少返回语句"}, "函数声明了返回值，但是有分支没有返回。"},
+}
+
+// fallbackCompileExplanation 没有AI服务时，根据常见编译错误给出提示
+func fallbackCompileExplanation(compileError *CompileErrorContext) string {
+	var builder strings.Builder
+	for _, diagnostic := range compileError.Diagnostics {
+		if diagnostic.Severity != debug_core.SeverityError {
+			continue
+		}
+		if diagnostic.Line > 0 {
+			fmt.Fprintf(&builder, "第%d行：%s\n", diagnostic.Line, diagnostic.Message)
+		} else {
+			fmt.Fprintf(&builder, "%s\n", diagnostic.Message)
+		}
+		if hint := compileErrorHint(diagnostic.Message); hint != "" {
+			fmt.Fprintf(&builder, "提示：%s\n", hint)
+		}
+	}
+	if builder.Len() == 0 {
+		if hint := compileErrorHint(compileError.Message); hint != "" {
+			return "提示：" + hint
+		}
+		return "请根据编译器的输出，从第一个错误开始逐个修改，后面的错误可能是由第一个错误引起的。"
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func compileErrorHint(message string) string {
+	for _, item := range compileErrorHints {
+		for _, keyword := range item.keywords {
+			if strings.Contains(message, keyword) {
+				return item.hint
+			}
+		}
+	}
+	return ""
+}
+
+// fallbackExplanation 没有AI服务时，根据变量变化生成讲解
+func fallbackExplanation(step *StepContext) string {
+	var builder strings.Builder
+	if line := currentLine(step.Stack); line > 0 {
+		fmt.Fprintf(&builder, "程序暂停在第%d行：%s\n", line, strings.TrimSpace(codeLine(step.Code, line)))
+	}
+	if len(step.Stack) > 1 {
+		fmt.Fprintf(&builder, "当前位于函数%s中，它由函数%s调用。\n", step.Stack[0].Name, step.Stack[1].Name)
+	} else if len(step.Stack) == 1 {
+		fmt.Fprintf(&builder, "当前位于函数%s中。\n", step.Stack[0].Name)
+	}
+	if len(step.ChangedVariables) == 0 {
+		builder.WriteString("这一步没有变量发生变化。")
+		return builder.String()
+	}
+	for _, change := range step.ChangedVariables {
+		if change.Added {
+			fmt.Fprintf(&builder, "变量%s进入作用域，值为%s。\n", change.Name, truncate(change.NewValue, maxPromptValueLength))
+		} else {
+			fmt.Fprintf(&builder, "变量%s的值由%s变为%s。\n", change.Name,
+				truncate(change.OldValue, maxPromptValueLength), truncate(change.NewValue, maxPromptValueLength))
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// fallbackDiagnosis 没有AI服务时，根据程序状态给出常见原因
+func fallbackDiagnosis(diagnose *DiagnoseContext) string {
+	var builder strings.Builder
+	message := strings.ToUpper(diagnose.ExitMessage + " " + diagnose.Output)
+	switch {
+	case diagnose.Status == ProgramRunning:
+		fmt.Fprintf(&builder, "程序已经运行%d秒没有暂停，可能存在死循环，或者程序在等待控制台输入。"+
+			"请检查循环条件是否会变为假、循环变量是否被正确更新。\n", int(diagnose.RunningFor.Seconds()))
+	case strings.Contains(message, "SIGSEGV") || strings.Contains(message, "SEGMENTATION"):
+		builder.WriteString("程序访问了非法内存（段错误），常见原因：使用了空指针或者已经释放的指针、数组下标越界、递归过深导致栈溢出。\n")
+	case strings.Contains(message, "SIGFPE"):
+		builder.WriteString("程序发生了算术异常，常见原因：整数除以0或者对0取模。\n")
+	case strings.Contains(message, "SIGABRT") || strings.Contains(message, "ABORT"):
+		builder.WriteString("程序被中止，常见原因：断言失败、重复释放内存、标准库容器越界检查失败。\n")
+	case strings.Contains(diagnose.ExitMessage, "超时"):
+		builder.WriteString("调试超时，程序长时间没有响应，可能存在死循环或者在等待控制台输入。\n")
+	case diagnose.Status == ProgramCrashed:
+		fmt.Fprintf(&builder, "程序因为%s暂停，请检查暂停位置附近的指针、数组下标和除数。\n", diagnose.StoppedReason)
+	case diagnose.Status == ProgramExited && diagnose.ExitCode != 0:
+		fmt.Fprintf(&builder, "程序以退出码%d结束，请检查main函数的返回值以及是否调用了exit。\n", diagnose.ExitCode)
+	default:
+		builder.WriteString("没有发现程序崩溃或者卡住的迹象。\n")
+	}
+	if line := currentLine(diagnose.Stack); line > 0 {
+		fmt.Fprintf(&builder, "最后一次暂停在函数%s的第%d行：%s", diagnose.Stack[0].Name, line,
+			strings.TrimSpace(codeLine(diagnose.Code, line)))
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func currentLine(stack []*debug_core.StackFrame) int {
+	if len(stack) == 0 {
+		return 0
+	}
+	return stack[0].Line
+}
+
+// codeLine 获取代码的第line行，行号从1开始
+func codeLine(code string, line int) string {
+	lines := strings.Split(code, "\n")
+	if line <= 0 || line > len(lines) {
+		return ""
+	}
+	return lines[line-1]
+}
+
+// numberedCode 为代码加上行号，方便AI定位
+func numberedCode(code string) string {
+	lines := strings.Split(code, "\n")
+	var builder strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&builder, "%4d | %s\n", i+1, line)
+	}
+	return builder.String()
+}
+
+func formatStack(stack []*debug_core.StackFrame) string {
+	if len(stack) == 0 {
+		return "无"
+	}
+	var builder strings.Builder
+	for _, frame := range stack {
+		fmt.Fprintf(&builder, "- %s（第%d行）\n", frame.Name, frame.Line)
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func formatVariables(variables []*debug_core.Variable) string {
+	if len(variables) == 0 {
+		return "无"
+	}
+	var builder strings.Builder
+	for i, variable := range variables {
+		if i == maxPromptVariables {
+			fmt.Fprintf(&builder, "- ...（省略%d个变量）\n", len(variables)-maxPromptVariables)
+			break
+		}
+		fmt.Fprintf(&builder, "- %s %s = %s\n", variable.Type, variable.Name, truncate(variable.Value, maxPromptValueLength))
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func truncate(str string, length int) string {
+	runes := []rune(str)
+	if len(runes) <= length {
+		return str
+	}
+	return string(runes[:length]) + "..."
+}
+
+func tail(str string, length int) string {
+	runes := []rune(str)
+	if len(runes) <= length {
+		return str
+	}
+	return "..." + string(runes[len(runes)-length:])
+}
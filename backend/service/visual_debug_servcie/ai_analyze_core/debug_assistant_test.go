@@ -0,0 +1,124 @@
+package ai_analyze_core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common/ai_provider"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
+	"github.com/stretchr/testify/assert"
+)
+
+const assistantTestCode = `#include <stdio.h>
+int main() {
+	int a[3] = {3, 1, 2};
+	int i = 0;
+	i++;
+	return a[i];
+}`
+
+func readAll(t *testing.T, chunks <-chan *ai_provider.StreamChunk) string {
+	var builder strings.Builder
+	for chunk := range chunks {
+		assert.Nil(t, chunk.Err)
+		builder.WriteString(chunk.Content)
+	}
+	return builder.String()
+}
+
+func TestDiffVariables(t *testing.T) {
+	previous := []*debug_core.Variable{
+		{Name: "i", Type: "int", Value: "0"},
+		{Name: "a", Type: "int [3]", Value: "{3, 1, 2}"},
+	}
+	current := []*debug_core.Variable{
+		{Name: "i", Type: "int", Value: "1"},
+		{Name: "a", Type: "int [3]", Value: "{3, 1, 2}"},
+		{Name: "j", Type: "int", Value: "5"},
+	}
+	changes := DiffVariables(previous, current)
+	assert.Equal(t, []*VariableChange{
+		{Name: "i", Type: "int", OldValue: "0", NewValue: "1"},
+		{Name: "j", Type: "int", NewValue: "5", Added: true},
+	}, changes)
+}
+
+func TestDebugAssistant_ExplainStepWithAI(t *testing.T) {
+	stub := ai_provider.NewStubProvider(func(req *ai_provider.ChatRequest) (string, error) {
+		return "i 自增 为 1", nil
+	})
+	assistant := NewDebugAssistantWithProvider(stub)
+	step := &StepContext{
+		Language:         constants.LanguageC,
+		Code:             assistantTestCode,
+		StoppedReason:    constants.StepStopped,
+		Stack:            []*debug_core.StackFrame{{Name: "main", Line: 6}},
+		Variables:        []*debug_core.Variable{{Name: "i", Type: "int", Value: "1"}},
+		ChangedVariables: []*VariableChange{{Name: "i", Type: "int", OldValue: "0", NewValue: "1"}},
+	}
+	chunks, err := assistant.ExplainStep(context.Background(), step)
+	assert.Nil(t, err)
+	assert.Equal(t, "i 自增 为 1", readAll(t, chunks))
+
+	// 提示词中携带当前行和变量变化
+	prompt := stub.Requests[0].Messages[1].Content
+	assert.Equal(t, ai_provider.RoleSystem, stub.Requests[0].Messages[0].Role)
+	assert.Contains(t, prompt, "当前暂停在第6行：\treturn a[i];")
+	assert.Contains(t, prompt, "- i：0 -> 1")
+}
+
+func TestDebugAssistant_ExplainStepFallback(t *testing.T) {
+	// AI服务失败时使用简单说明
+	assistant := NewDebugAssistantWithProvider(ai_provider.NewStubProvider(nil))
+	step := &StepContext{
+		Language: constants.LanguageC,
+		Code:     assistantTestCode,
+		Stack:    []*debug_core.StackFrame{{Name: "main", Line: 5}},
+		ChangedVariables: []*VariableChange{
+			{Name: "i", OldValue: "0", NewValue: "1"},
+		},
+	}
+	chunks, err := assistant.ExplainStep(context.Background(), step)
+	assert.Nil(t, err)
+	assert.Equal(t, "程序暂停在第5行：i++;\n当前位于函数main中。\n变量i的值由0变为1。", readAll(t, chunks))
+}
+
+func TestDebugAssistant_DiagnoseFallback(t *testing.T) {
+	assistant := NewDebugAssistant(nil)
+	crashed := &DiagnoseContext{
+		Language:    constants.LanguageC,
+		Code:        assistantTestCode,
+		Status:      ProgramExited,
+		ExitCode:    139,
+		ExitMessage: "Program received signal SIGSEGV, Segmentation fault.",
+		Stack:       []*debug_core.StackFrame{{Name: "main", Line: 6}},
+	}
+	chunks, err := assistant.Diagnose(context.Background(), crashed)
+	assert.Nil(t, err)
+	answer := readAll(t, chunks)
+	assert.Contains(t, answer, "段错误")
+	assert.Contains(t, answer, "最后一次暂停在函数main的第6行：return a[i];")
+
+	hang := &DiagnoseContext{Status: ProgramRunning, RunningFor: 30 * time.Second}
+	chunks, err = assistant.Diagnose(context.Background(), hang)
+	assert.Nil(t, err)
+	assert.Contains(t, readAll(t, chunks), "死循环")
+}
+
+func TestDebugAssistant_ExplainCompileErrorFallback(t *testing.T) {
+	assistant := NewDebugAssistant(nil)
+	compileError := &CompileErrorContext{
+		Language: constants.LanguageC,
+		Code:     assistantTestCode,
+		Diagnostics: []*debug_core.CompileDiagnostic{
+			{File: "main.c", Line: 4, Column: 10, Severity: debug_core.SeverityError, Message: "expected ';' before 'i'"},
+			{File: "main.c", Line: 5, Column: 2, Severity: debug_core.SeverityWarning, Message: "unused variable 'j'"},
+		},
+	}
+	chunks, err := assistant.ExplainCompileError(context.Background(), compileError)
+	assert.Nil(t, err)
+	assert.Equal(t, "第4行：expected ';' before 'i'\n提示：语句末尾缺少分号，检查这一行或者上一行的末尾。", readAll(t, chunks))
+}
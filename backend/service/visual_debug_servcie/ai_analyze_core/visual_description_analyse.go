@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"regexp"
 	"strings"
 	"sync"
 
@@ -13,24 +12,29 @@ import (
 	"github.com/fansqz/fancode-backend/common/logger"
 	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/static_analyze_core"
 )
 
 // VisualDescriptionAnalyzer AI分析代码中数据结构的分析器
 // 先触发StartAnalyzeCode，然后通过GetVisualDescription获取结果
 // 只有startAnalyzeCode返回成功以后，GetVisualDescription才能返回结果，否则被阻塞
+// 没有配置AI服务时使用静态分析，AI分析失败时也会降级为静态分析
 type VisualDescriptionAnalyzer struct {
+	// aiProvider 为nil表示没有配置AI服务
 	aiProvider        ai_provider.AIProvider
 	visualDescription *dto.VisualDescription
 	mutex             sync.Mutex
 	cond              *sync.Cond
 	isAnalyzing       bool
 	analysisError     error
+	// generation 每次开始分析或者用户指定描述时递增，用于丢弃过期的分析结果
+	generation int
 }
 
 // NewVisualDescriptionAnalyzer 创建新的可视化描述分析器
 func NewVisualDescriptionAnalyzer(aiConfig *config.AIConfig) *VisualDescriptionAnalyzer {
 	analyzer := &VisualDescriptionAnalyzer{
-		aiProvider: ai_provider.NewAIProvider(aiConfig),
+		aiProvider: ai_provider.NewAIProviderIfConfigured(aiConfig),
 	}
 	analyzer.cond = sync.NewCond(&analyzer.mutex)
 	return analyzer
@@ -72,6 +76,8 @@ func (a *VisualDescriptionAnalyzer) StartAnalyzeCode(ctx context.Context, code s
 	a.isAnalyzing = true
 	a.analysisError = nil
 	a.visualDescription = nil
+	a.generation++
+	generation := a.generation
 
 	go func() {
 		visualDescription, err := a.analyzeCode(ctx, code, language)
@@ -79,6 +85,11 @@ func (a *VisualDescriptionAnalyzer) StartAnalyzeCode(ctx context.Context, code s
 		a.mutex.Lock()
 		defer a.mutex.Unlock()
 
+		// 分析期间用户已经指定了描述，丢弃分析结果
+		if generation != a.generation {
+			return
+		}
+
 		if err != nil {
 			a.analysisError = err
 			logger.WithCtx(ctx).Errorf("[VisualDescriptionAnalyzer] Failed to analyze code: %v", err)
@@ -93,6 +104,19 @@ func (a *VisualDescriptionAnalyzer) StartAnalyzeCode(ctx context.Context, code s
 	return nil
 }
 
+// SetVisualDescription 使用用户指定的可视化描述，覆盖分析结果
+// 如果分析还在进行中，分析结果会被丢弃，等待中的GetVisualDescription直接返回用户指定的描述
+func (a *VisualDescriptionAnalyzer) SetVisualDescription(visualDescription *dto.VisualDescription) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.generation++
+	a.visualDescription = visualDescription
+	a.analysisError = nil
+	a.isAnalyzing = false
+	a.cond.Broadcast()
+}
+
 // IsAnalyzing 检查是否正在解析中
 func (a *VisualDescriptionAnalyzer) IsAnalyzing() bool {
 	a.mutex.Lock()
@@ -107,22 +131,38 @@ func (a *VisualDescriptionAnalyzer) GetAnalysisStatus() (bool, error) {
 	return a.isAnalyzing, a.analysisError
 }
 
-// AnalyzeCode 分析用户代码中的数据结构
+// analyzeCode 分析用户代码中的数据结构，优先使用AI分析，AI不可用时降级为静态分析
 func (a *VisualDescriptionAnalyzer) analyzeCode(ctx context.Context, code string, language constants.LanguageType) (*dto.VisualDescription, error) {
+	if a.aiProvider == nil {
+		logger.WithCtx(ctx).Infof("[VisualDescriptionAnalyzer] AI provider not configured, use static analysis")
+		return static_analyze_core.Analyze(code, language), nil
+	}
+	visualDescription, err := a.analyzeCodeWithAI(ctx, code, language)
+	if err != nil {
+		logger.WithCtx(ctx).Warnf("[VisualDescriptionAnalyzer] AI analysis failed, falling back to static analysis: %v", err)
+		return static_analyze_core.Analyze(code, language), nil
+	}
+	return visualDescription, nil
+}
+
+// analyzeCodeWithAI 使用AI分析用户代码中的数据结构
+func (a *VisualDescriptionAnalyzer) analyzeCodeWithAI(ctx context.Context, code string, language constants.LanguageType) (*dto.VisualDescription, error) {
 	logger.WithCtx(ctx).Infof("[VisualDescriptionAnalyzer] Analyzing code for language: %s", language)
 
 	// 构建AI提示词
-	prompt := a.buildAnalysisPrompt(code, language)
+	req := ai_provider.NewChatRequest(analysisSystemPrompt, a.buildAnalysisPrompt(code, language))
 
 	// 调用AI服务
-	response, err := a.aiProvider.Chat(ctx, prompt)
+	response, err := a.aiProvider.Chat(ctx, req)
 	if err != nil {
 		logger.WithCtx(ctx).Errorf("[VisualDescriptionAnalyzer] AI analysis failed: %v", err)
 		return nil, fmt.Errorf("AI analysis failed: %w", err)
 	}
+	logger.WithCtx(ctx).Infof("[VisualDescriptionAnalyzer] AI usage: prompt = %d, completion = %d, cached = %v",
+		response.Usage.PromptTokens, response.Usage.CompletionTokens, response.Cached)
 
 	// 解析AI响应
-	visualDescription, err := a.parseAIResponse(response)
+	visualDescription, err := a.parseAIResponse(response.Content)
 	if err != nil {
 		logger.WithCtx(ctx).Errorf("[VisualDescriptionAnalyzer] Failed to parse AI response: %v", err)
 		return nil, fmt.Errorf("failed to parse AI response: %w", err)
@@ -132,6 +172,9 @@ func (a *VisualDescriptionAnalyzer) analyzeCode(ctx context.Context, code string
 	return visualDescription, nil
 }
 
+// analysisSystemPrompt 代码分析的系统提示词
+const analysisSystemPrompt = "你是一名数据结构可视化助手，负责识别代码中的数据结构，只返回指定格式的JSON数据。"
+
 // buildAnalysisPrompt 构建AI分析提示词
 func (a *VisualDescriptionAnalyzer) buildAnalysisPrompt(code string, language constants.LanguageType) string {
 	return fmt.Sprintf(`请分析以下代码中的数据结构，并通过如下步骤处理代码分析任务：
@@ -330,119 +373,3 @@ func (a *VisualDescriptionAnalyzer) buildDescriptionObject(visualType constants.
 		}, nil
 	}
 }
-
-// AnalyzeCodeWithFallback 带降级策略的代码分析
-func (a *VisualDescriptionAnalyzer) AnalyzeCodeWithFallback(ctx context.Context, code string, language constants.LanguageType) (*dto.VisualDescription, error) {
-	// 首先尝试AI分析
-	visualDescription, err := a.analyzeCode(ctx, code, language)
-	if err == nil {
-		return visualDescription, nil
-	}
-
-	logger.WithCtx(ctx).Warnf("[VisualDescriptionAnalyzer] AI analysis failed, falling back to rule-based analysis: %v", err)
-
-	// AI分析失败时，使用基于规则的分析作为降级策略
-	return a.ruleBasedAnalysis(code, language), nil
-}
-
-// ruleBasedAnalysis 基于规则的代码分析（降级策略）
-func (a *VisualDescriptionAnalyzer) ruleBasedAnalysis(code string, language constants.LanguageType) *dto.VisualDescription {
-	// 简单的规则匹配
-	if a.containsLinkedListPattern(code) {
-		return &dto.VisualDescription{
-			VisualType: constants.LinkListType,
-			Description: dto.LinkListDescription{
-				LinkNode: "Node",
-				Data:     "data",
-				Next:     "next",
-			},
-		}
-	}
-
-	if a.containsBinaryTreePattern(code) {
-		return &dto.VisualDescription{
-			VisualType: constants.BinaryTreeType,
-			Description: dto.BinaryTreeDescription{
-				TreeNode: "TreeNode",
-				Data:     "val",
-				Left:     "left",
-				Right:    "right",
-			},
-		}
-	}
-
-	if a.containsGraphPattern(code) {
-		return &dto.VisualDescription{
-			VisualType: constants.GraphType,
-			Description: dto.GraphDescription{
-				GraphNode: "Node",
-				Data:      "val",
-				Nexts:     []string{"neighbors"},
-			},
-		}
-	}
-
-	// 默认返回数组
-	return &dto.VisualDescription{
-		VisualType: constants.ArrayType,
-		Description: dto.ArrayDescription{
-			ArrayName:   "array",
-			PointNames:  []string{},
-			DisplayType: "array",
-		},
-	}
-}
-
-// containsLinkedListPattern 检查是否包含链表模式
-func (a *VisualDescriptionAnalyzer) containsLinkedListPattern(code string) bool {
-	patterns := []string{
-		`type.*struct.*\{.*\w+\s+\w+.*\w+\s+\*.*\}`,
-		`\.next\s*=`,
-		`\.prev\s*=`,
-		`ListNode`,
-		`Node.*next`,
-	}
-
-	for _, pattern := range patterns {
-		if matched, _ := regexp.MatchString(pattern, code); matched {
-			return true
-		}
-	}
-	return false
-}
-
-// containsBinaryTreePattern 检查是否包含二叉树模式
-func (a *VisualDescriptionAnalyzer) containsBinaryTreePattern(code string) bool {
-	patterns := []string{
-		`type.*struct.*\{.*\w+\s+\w+.*\w+\s+\*.*\w+\s+\*.*\}`,
-		`\.left\s*=`,
-		`\.right\s*=`,
-		`TreeNode`,
-		`left.*right`,
-	}
-
-	for _, pattern := range patterns {
-		if matched, _ := regexp.MatchString(pattern, code); matched {
-			return true
-		}
-	}
-	return false
-}
-
-// containsGraphPattern 检查是否包含图模式
-func (a *VisualDescriptionAnalyzer) containsGraphPattern(code string) bool {
-	patterns := []string{
-		`neighbors`,
-		`adjacency`,
-		`graph`,
-		`\[.*\]\*.*\w+`,
-		`map.*\[\]`,
-	}
-
-	for _, pattern := range patterns {
-		if matched, _ := regexp.MatchString(pattern, code); matched {
-			return true
-		}
-	}
-	return false
-}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/fansqz/fancode-backend/common/config"
 	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
 	"testing"
 )
 
@@ -50,3 +51,37 @@ func TestVisualDescriptionAnalyzer_AnalyzeCode(t *testing.T) {
 
 	t.Logf("VisualDescription: %v", visualDescription)
 }
+
+func TestVisualDescriptionAnalyzer_SetVisualDescription(t *testing.T) {
+	// 未配置AI服务时使用静态分析
+	visualDescriptionAnalyzer := NewVisualDescriptionAnalyzer(&config.AIConfig{})
+	code := `package main
+
+func main() {
+	arr := []int{3, 1, 2}
+	for i := 0; i < len(arr); i++ {
+		_ = arr[i]
+	}
+}
+`
+	if err := visualDescriptionAnalyzer.StartAnalyzeCode(context.Background(), code, constants.LanguageGo); err != nil {
+		t.Fatalf("StartAnalyzeCode failed: %v", err)
+	}
+	override := &dto.VisualDescription{
+		VisualType: constants.ArrayType,
+		Description: dto.ArrayDescription{
+			ArrayName:   "nums",
+			PointNames:  []string{"left", "right"},
+			DisplayType: "array",
+		},
+	}
+	visualDescriptionAnalyzer.SetVisualDescription(override)
+
+	visualDescription, err := visualDescriptionAnalyzer.GetVisualDescription(context.Background())
+	if err != nil {
+		t.Fatalf("GetVisualDescription failed: %v", err)
+	}
+	if visualDescription != override {
+		t.Fatalf("expected user visual description, got %v", visualDescription)
+	}
+}
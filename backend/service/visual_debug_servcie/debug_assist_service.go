@@ -0,0 +1,137 @@
+package visual_debug_servcie
+
+import (
+	"context"
+
+	"github.com/fansqz/fancode-backend/common/ai_provider"
+	"github.com/fansqz/fancode-backend/common/config"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/ai_analyze_core"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
+)
+
+// DebugAssistService
+// AI调试助手，依赖于调试会话
+type DebugAssistService interface {
+	// ExplainStep 讲解程序当前暂停的这一步，返回流式的讲解内容
+	ExplainStep(ctx context.Context, debugID string) (<-chan *ai_provider.StreamChunk, error)
+	// Diagnose 分析程序崩溃或者卡住的原因，返回流式的分析内容
+	Diagnose(ctx context.Context, debugID string) (<-chan *ai_provider.StreamChunk, error)
+	// ExplainCompileError 讲解编译错误并给出修改建议，返回流式的讲解内容
+	ExplainCompileError(ctx context.Context, debugID string) (<-chan *ai_provider.StreamChunk, error)
+}
+
+type debugAssistService struct {
+	assistant *ai_analyze_core.DebugAssistant
+}
+
+func NewDebugAssistService(cf *config.AppConfig) DebugAssistService {
+	return &debugAssistService{
+		assistant: ai_analyze_core.NewDebugAssistant(cf.AIConfig),
+	}
+}
+
+// ExplainStep 讲解程序当前暂停的这一步
+func (d *debugAssistService) ExplainStep(ctx context.Context, debugID string) (<-chan *ai_provider.StreamChunk, error) {
+	ctx = logger.SetDebugID(ctx, debugID)
+	session, ok := DebugSessionManage.GetDebugSession(ctx, debugID)
+	if !ok {
+		return nil, e.ErrDebuggerIsClosed
+	}
+	if err := session.Recorder.Capture(ctx, session.Debugger); err != nil {
+		logger.WithCtx(ctx).Errorf("[ExplainStep] capture debug state fail, err = %v", err)
+		return nil, e.ErrProgramNotStopped
+	}
+	step, ok := session.Recorder.StepContext()
+	if !ok {
+		return nil, e.ErrProgramNotStopped
+	}
+
+	// 代码分析还没有完成时不等待，不携带可视化数据
+	if !session.VisualDescriptionAnalyzer.IsAnalyzing() {
+		if visualDescription, err := session.VisualDescriptionAnalyzer.GetVisualDescription(ctx); err == nil {
+			step.VisualDescription = visualDescription
+			step.VisualSnapshot = visualSnapshot(ctx, session.Debugger, visualDescription)
+		}
+	}
+	return d.assistant.ExplainStep(ctx, step)
+}
+
+// Diagnose 分析程序崩溃或者卡住的原因，调试会话结束以后的一段时间内仍然可以分析
+func (d *debugAssistService) Diagnose(ctx context.Context, debugID string) (<-chan *ai_provider.StreamChunk, error) {
+	ctx = logger.SetDebugID(ctx, debugID)
+	recorder, ok := DebugSessionManage.GetDebugRecorder(ctx, debugID)
+	if !ok {
+		return nil, e.ErrDebuggerIsClosed
+	}
+	if session, ok := DebugSessionManage.GetDebugSession(ctx, debugID); ok {
+		if err := recorder.Capture(ctx, session.Debugger); err != nil {
+			logger.WithCtx(ctx).Warnf("[Diagnose] capture debug state fail, err = %v", err)
+		}
+	}
+	return d.assistant.Diagnose(ctx, recorder.DiagnoseContext())
+}
+
+// ExplainCompileError 讲解编译错误，编译失败以后调试会话会被销毁，所以从调试记录中读取编译结果
+func (d *debugAssistService) ExplainCompileError(ctx context.Context, debugID string) (<-chan *ai_provider.StreamChunk, error) {
+	ctx = logger.SetDebugID(ctx, debugID)
+	recorder, ok := DebugSessionManage.GetDebugRecorder(ctx, debugID)
+	if !ok {
+		return nil, e.ErrDebuggerIsClosed
+	}
+	compileError, ok := recorder.CompileErrorContext()
+	if !ok {
+		return nil, e.ErrNoCompileError
+	}
+	return d.assistant.ExplainCompileError(ctx, compileError)
+}
+
+// visualSnapshot 根据可视化描述读取当前的可视化数据，读取失败时返回nil
+func visualSnapshot(ctx context.Context, debugger debug_core.Debugger, visualDescription *dto.VisualDescription) interface{} {
+	var data interface{}
+	var err error
+	switch desc := visualDescription.Description.(type) {
+	case dto.ArrayDescription:
+		data, err = debugger.ArrayVisual(ctx, &debug_core.ArrayVisualQuery{
+			ArrayName:  desc.ArrayName,
+			PointNames: desc.PointNames,
+		})
+	case dto.Array2DDescription:
+		data, err = debugger.Array2DVisual(ctx, &debug_core.Array2DVisualQuery{
+			ArrayName:     desc.ArrayName,
+			RowPointNames: desc.RowPointNames,
+			ColPointNames: desc.ColPointNames,
+		})
+	case dto.BinaryTreeDescription:
+		data, err = debugger.StructVisual(ctx, &debug_core.StructVisualQuery{
+			Struct: desc.TreeNode,
+			Values: []string{desc.Data},
+			Points: []string{desc.Left, desc.Right},
+		})
+	case dto.GraphDescription:
+		data, err = debugger.StructVisual(ctx, &debug_core.StructVisualQuery{
+			Struct: desc.GraphNode,
+			Values: []string{desc.Data},
+			Points: desc.Nexts,
+		})
+	case dto.LinkListDescription:
+		points := []string{desc.Next}
+		if desc.Prev != "" {
+			points = append(points, desc.Prev)
+		}
+		data, err = debugger.StructVisual(ctx, &debug_core.StructVisualQuery{
+			Struct: desc.LinkNode,
+			Values: []string{desc.Data},
+			Points: points,
+		})
+	default:
+		return nil
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Warnf("[visualSnapshot] get visual data fail, err = %v", err)
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,81 @@
+package debug_core
+
+import (
+	"context"
+	"path"
+
+	"github.com/fansqz/fancode-backend/common/artifact_cache"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+)
+
+// compileOutputFile 编译器的输出，命中缓存时用于恢复编译警告
+const compileOutputFile = "compile_output"
+
+// compileFlags 各语言调试编译时使用的参数，同时作为缓存key的一部分
+var compileFlags = map[constants.LanguageType][]string{
+	constants.LanguageC:    {"-g"},
+	constants.LanguageCPP:  {"-g", "-O0"},
+	constants.LanguageGo:   {"-gcflags", "all=-N -l"},
+	constants.LanguageJava: {"-encoding", "UTF-8"},
+}
+
+// compileCacheKey 根据语言、编译参数和代码计算编译产物的缓存key
+func compileCacheKey(language constants.LanguageType, code string) string {
+	return artifact_cache.Key(artifact_cache.ScopeDebug, language, compileFlags[language], code)
+}
+
+// restoreArtifact 从缓存中恢复编译产物，未命中或恢复失败时返回false，需要重新编译
+func (d *debugger) restoreArtifact(ctx context.Context) (*CompileEvent, bool) {
+	if d.option.CompileCache == nil {
+		return nil, false
+	}
+	artifact, ok := d.option.CompileCache.Get(ctx, d.cacheKey)
+	if !ok {
+		return nil, false
+	}
+	if err := d.docker.CopyToContainer(ctx, artifact, d.workPath, artifact_cache.ArtifactFile); err != nil {
+		logger.WithCtx(ctx).Errorf("[restoreArtifact] copy artifact fail, err = %v", err)
+		return nil, false
+	}
+	output, err, exitCode := d.docker.Exec(ctx, artifact_cache.UnpackCommand(d.workPath))
+	if err != nil || exitCode != 0 {
+		logger.WithCtx(ctx).Errorf("[restoreArtifact] extract artifact fail, output = %s, err = %v", output, err)
+		return nil, false
+	}
+	compileOutput, err := d.docker.CopyFromContainer(ctx, path.Join(d.workPath, compileOutputFile))
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[restoreArtifact] read compile output fail, err = %v", err)
+		return nil, false
+	}
+	d.execFile = path.Join(d.workPath, "main")
+	diagnostics := ParseCompileDiagnostics(d.option.Language, string(compileOutput), d.workPath)
+	event := NewCompileEvent(true, "编译成功").WithDiagnostics(diagnostics)
+	event.Cached = true
+	return event, true
+}
+
+// saveArtifact 将编译产物和编译器输出打包保存到缓存，失败时只记录日志
+func (d *debugger) saveArtifact(ctx context.Context, compileOutput string) {
+	if d.option.CompileCache == nil {
+		return
+	}
+	if err := d.docker.CopyToContainer(ctx, []byte(compileOutput), d.workPath, compileOutputFile); err != nil {
+		logger.WithCtx(ctx).Errorf("[saveArtifact] save compile output fail, err = %v", err)
+		return
+	}
+	output, err, exitCode := d.docker.Exec(ctx, artifact_cache.PackCommand(d.workPath, "main", compileOutputFile))
+	if err != nil || exitCode != 0 {
+		logger.WithCtx(ctx).Errorf("[saveArtifact] pack artifact fail, output = %s, err = %v", output, err)
+		return
+	}
+	artifact, err := d.docker.CopyFromContainer(ctx, path.Join(d.workPath, artifact_cache.ArtifactFile))
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[saveArtifact] read artifact fail, err = %v", err)
+		return
+	}
+	if len(artifact) > artifact_cache.MaxSize {
+		return
+	}
+	d.option.CompileCache.Set(ctx, d.cacheKey, artifact)
+}
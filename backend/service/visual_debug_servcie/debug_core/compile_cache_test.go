@@ -0,0 +1,108 @@
+package debug_core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+// localDockerClient 在本机执行命令的DockerClient，用于测试编译流程
+type localDockerClient struct {
+	commands [][]string
+}
+
+func (l *localDockerClient) Exec(ctx context.Context, cmd []string) (string, error, int) {
+	l.commands = append(l.commands, cmd)
+	output, err := exec.CommandContext(ctx, cmd[0], cmd[1:]...).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(output), nil, exitErr.ExitCode()
+	}
+	return string(output), err, 0
+}
+
+func (l *localDockerClient) CopyToContainer(ctx context.Context, content []byte, destPath string, filename string) error {
+	return os.WriteFile(filepath.Join(destPath, filename), content, 0644)
+}
+
+func (l *localDockerClient) CopyFromContainer(ctx context.Context, filePath string) ([]byte, error) {
+	return os.ReadFile(filePath)
+}
+
+func (l *localDockerClient) RemoveContainer(ctx context.Context) error    { return nil }
+func (l *localDockerClient) Interrupt() error                             { return nil }
+func (l *localDockerClient) GetContainerID() string                       { return "" }
+func (l *localDockerClient) GetDebugAttach() types.HijackedResponse       { return types.HijackedResponse{} }
+func (l *localDockerClient) SetDebugAttach(attach types.HijackedResponse) {}
+func (l *localDockerClient) GetClient() *client.Client                    { return nil }
+
+type memoryCompileCache map[string][]byte
+
+func (m memoryCompileCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	artifact, ok := m[key]
+	return artifact, ok
+}
+
+func (m memoryCompileCache) Set(ctx context.Context, key string, artifact []byte) {
+	m[key] = artifact
+}
+
+// compileWithCache 在新的工作目录中保存代码并编译，模拟一次新的调试
+func compileWithCache(t *testing.T, code string, cache memoryCompileCache) (*debugger, *CompileEvent) {
+	d := NewDebugger().(*debugger)
+	d.docker = &localDockerClient{}
+	d.option = &Option{
+		Language:     constants.LanguageC,
+		Code:         code,
+		TempDir:      t.TempDir(),
+		CompileCache: cache,
+	}
+	ctx := context.Background()
+	assert.Nil(t, d.saveCode(ctx))
+	return d, d.compile(ctx, []string{d.compileFile})
+}
+
+func TestDebugger_CompileCache(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc not found")
+	}
+	code := "#include <stdio.h>\nint main() { int unused; printf(\"hello\\n\"); return 0; }\n"
+	cache := memoryCompileCache{}
+	d, event := compileWithCache(t, code, cache)
+	assert.True(t, event.Success, event.Message)
+	assert.False(t, event.Cached)
+	assert.Equal(t, 1, len(cache))
+
+	d, event = compileWithCache(t, code, cache)
+	assert.True(t, event.Success, event.Message)
+	assert.True(t, event.Cached)
+	// 命中缓存时不执行编译器
+	for _, cmd := range d.docker.(*localDockerClient).commands {
+		assert.NotEqual(t, "gcc", cmd[0])
+	}
+	output, err := exec.Command(d.execFile).Output()
+	assert.Nil(t, err)
+	assert.Equal(t, "hello\n", string(output))
+
+	// 编译失败不缓存
+	_, event = compileWithCache(t, "int main() { return }", cache)
+	assert.False(t, event.Success)
+	assert.Equal(t, 1, len(cache))
+}
+
+func TestCompileCacheKey(t *testing.T) {
+	key := compileCacheKey(constants.LanguageC, "int main() {}")
+	assert.Equal(t, key, compileCacheKey(constants.LanguageC, "int main() {}"))
+	assert.NotEqual(t, key, compileCacheKey(constants.LanguageCPP, "int main() {}"))
+	assert.NotEqual(t, key, compileCacheKey(constants.LanguageC, "int main() { }"))
+	// 相同代码使用相同的工作目录，调试信息中的源文件路径保持一致
+	assert.Equal(t, getExecutePath("/tmp", key), getExecutePath("/tmp", compileCacheKey(constants.LanguageC, "int main() {}")))
+}
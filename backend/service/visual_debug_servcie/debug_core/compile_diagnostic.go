@@ -0,0 +1,161 @@
+package debug_core
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fansqz/fancode-backend/constants"
+)
+
+// DiagnosticSeverity 编译诊断的级别
+type DiagnosticSeverity string
+
+const (
+	SeverityError   DiagnosticSeverity = "error"
+	SeverityWarning DiagnosticSeverity = "warning"
+	SeverityNote    DiagnosticSeverity = "note"
+)
+
+// CompileDiagnostic 编译器输出的一条诊断信息，行号和列号从1开始，为0表示未知
+type CompileDiagnostic struct {
+	File     string             `json:"file"`
+	Line     int                `json:"line"`
+	Column   int                `json:"column"`
+	Severity DiagnosticSeverity `json:"severity"`
+	Message  string             `json:"message"`
+}
+
+var (
+	// gcc/g++: main.c:3:5: error: 'x' undeclared
+	gccDiagnosticRegexp = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?: (fatal error|error|warning|note): (.*)$`)
+	// ld: main.c:(.text+0x1e): undefined reference to `foo'
+	ldDiagnosticRegexp = regexp.MustCompile(`^(?:.*: )?(\S+?\.(?:c|cpp|cc|o)):\([^)]*\): (.*)$`)
+	// go: ./main.go:5:2: declared and not used: x
+	goDiagnosticRegexp = regexp.MustCompile(`^(.+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+	// javac: /tmp/xxx/Main.java:3: error: ';' expected
+	javaDiagnosticRegexp = regexp.MustCompile(`^(.+?\.java):(\d+): (error|warning|错误|警告): ?(.*)$`)
+)
+
+// ParseCompileDiagnostics 将编译器的输出解析为结构化的诊断信息
+// workPath为编译时的工作目录，诊断中的文件路径会被转换为相对于工作目录的路径
+func ParseCompileDiagnostics(language constants.LanguageType, output string, workPath string) []*CompileDiagnostic {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	var diagnostics []*CompileDiagnostic
+	switch language {
+	case constants.LanguageC, constants.LanguageCPP:
+		diagnostics = parseGccDiagnostics(lines)
+	case constants.LanguageGo:
+		diagnostics = parseGoDiagnostics(lines)
+	case constants.LanguageJava:
+		diagnostics = parseJavaDiagnostics(lines)
+	}
+	for _, diagnostic := range diagnostics {
+		diagnostic.File = relativeFile(diagnostic.File, workPath)
+	}
+	return diagnostics
+}
+
+func parseGccDiagnostics(lines []string) []*CompileDiagnostic {
+	var diagnostics []*CompileDiagnostic
+	for _, line := range lines {
+		if match := gccDiagnosticRegexp.FindStringSubmatch(line); match != nil {
+			severity := DiagnosticSeverity(match[4])
+			if severity == "fatal error" {
+				severity = SeverityError
+			}
+			diagnostics = append(diagnostics, &CompileDiagnostic{
+				File:     match[1],
+				Line:     atoi(match[2]),
+				Column:   atoi(match[3]),
+				Severity: severity,
+				Message:  match[5],
+			})
+			continue
+		}
+		// 链接错误没有行号
+		if match := ldDiagnosticRegexp.FindStringSubmatch(line); match != nil {
+			diagnostics = append(diagnostics, &CompileDiagnostic{
+				File:     match[1],
+				Severity: SeverityError,
+				Message:  match[2],
+			})
+		}
+	}
+	return diagnostics
+}
+
+func parseGoDiagnostics(lines []string) []*CompileDiagnostic {
+	var diagnostics []*CompileDiagnostic
+	for _, line := range lines {
+		if match := goDiagnosticRegexp.FindStringSubmatch(line); match != nil {
+			diagnostics = append(diagnostics, &CompileDiagnostic{
+				File:     match[1],
+				Line:     atoi(match[2]),
+				Column:   atoi(match[3]),
+				Severity: SeverityError,
+				Message:  match[4],
+			})
+			continue
+		}
+		// 以tab开头的行是上一条诊断的补充说明
+		if strings.HasPrefix(line, "\t") && len(diagnostics) != 0 {
+			last := diagnostics[len(diagnostics)-1]
+			last.Message += "\n" + strings.TrimSpace(line)
+		}
+	}
+	return diagnostics
+}
+
+// parseJavaDiagnostics javac的诊断后面跟着源码行和指向出错列的^
+func parseJavaDiagnostics(lines []string) []*CompileDiagnostic {
+	var diagnostics []*CompileDiagnostic
+	var last *CompileDiagnostic
+	for _, line := range lines {
+		if match := javaDiagnosticRegexp.FindStringSubmatch(line); match != nil {
+			severity := SeverityError
+			if match[3] == "warning" || match[3] == "警告" {
+				severity = SeverityWarning
+			}
+			last = &CompileDiagnostic{
+				File:     match[1],
+				Line:     atoi(match[2]),
+				Severity: severity,
+				Message:  match[4],
+			}
+			diagnostics = append(diagnostics, last)
+			continue
+		}
+		if last == nil {
+			continue
+		}
+		if strings.TrimSpace(line) == "^" && last.Column == 0 {
+			last.Column = strings.Index(line, "^") + 1
+			continue
+		}
+		// 符号、位置等补充说明
+		trimmed := strings.TrimSpace(line)
+		if last.Column != 0 && trimmed != "" && (strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")) {
+			last.Message += "\n" + trimmed
+		}
+	}
+	return diagnostics
+}
+
+// relativeFile 将文件路径转换为相对于工作目录的路径
+func relativeFile(file string, workPath string) string {
+	file = strings.TrimPrefix(file, "./")
+	if workPath != "" && strings.HasPrefix(file, workPath) {
+		return strings.TrimPrefix(strings.TrimPrefix(file, workPath), "/")
+	}
+	if strings.HasPrefix(file, "/") {
+		return path.Base(file)
+	}
+	return file
+}
+
+func atoi(str string) int {
+	answer, _ := strconv.Atoi(str)
+	return answer
+}
@@ -0,0 +1,74 @@
+package debug_core
+
+import (
+	"testing"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCompileDiagnostics_Gcc(t *testing.T) {
+	output := `/tmp/debug/abc/main.c: In function 'main':
+/tmp/debug/abc/main.c:4:5: error: 'x' undeclared (first use in this function)
+    4 |     x = 1;
+      |     ^
+/tmp/debug/abc/main.c:4:5: note: each undeclared identifier is reported only once for each function it appears in
+/tmp/debug/abc/main.c:5:12: warning: unused variable 'y' [-Wunused-variable]
+/usr/bin/ld: /tmp/ccX1.o: in function ` + "`main':" + `
+main.c:(.text+0x1e): undefined reference to ` + "`foo'" + `
+collect2: error: ld returned 1 exit status
+`
+	diagnostics := ParseCompileDiagnostics(constants.LanguageC, output, "/tmp/debug/abc")
+	assert.Equal(t, []*CompileDiagnostic{
+		{File: "main.c", Line: 4, Column: 5, Severity: SeverityError, Message: "'x' undeclared (first use in this function)"},
+		{File: "main.c", Line: 4, Column: 5, Severity: SeverityNote, Message: "each undeclared identifier is reported only once for each function it appears in"},
+		{File: "main.c", Line: 5, Column: 12, Severity: SeverityWarning, Message: "unused variable 'y' [-Wunused-variable]"},
+		{File: "main.c", Severity: SeverityError, Message: "undefined reference to `foo'"},
+	}, diagnostics)
+}
+
+func TestParseCompileDiagnostics_Cpp(t *testing.T) {
+	output := `/tmp/debug/abc/main.cpp:3:10: fatal error: vectr: No such file or directory
+    3 | #include <vectr>
+      |          ^~~~~~~
+compilation terminated.
+`
+	diagnostics := ParseCompileDiagnostics(constants.LanguageCPP, output, "/tmp/debug/abc")
+	assert.Equal(t, []*CompileDiagnostic{
+		{File: "main.cpp", Line: 3, Column: 10, Severity: SeverityError, Message: "vectr: No such file or directory"},
+	}, diagnostics)
+}
+
+func TestParseCompileDiagnostics_Go(t *testing.T) {
+	output := `# command-line-arguments
+./main.go:5:2: declared and not used: x
+./main.go:8:9: cannot use "a" (untyped string constant) as int value in return statement
+	have (string)
+	want (int)
+`
+	diagnostics := ParseCompileDiagnostics(constants.LanguageGo, output, "/tmp/debug/abc")
+	assert.Equal(t, []*CompileDiagnostic{
+		{File: "main.go", Line: 5, Column: 2, Severity: SeverityError, Message: "declared and not used: x"},
+		{File: "main.go", Line: 8, Column: 9, Severity: SeverityError,
+			Message: "cannot use \"a\" (untyped string constant) as int value in return statement\nhave (string)\nwant (int)"},
+	}, diagnostics)
+}
+
+func TestParseCompileDiagnostics_Java(t *testing.T) {
+	output := `/tmp/debug/abc/Main.java:3: error: ';' expected
+        int a = 1
+                 ^
+/tmp/debug/abc/Main.java:4: error: cannot find symbol
+        b = 2;
+        ^
+  symbol:   variable b
+  location: class Main
+2 errors
+`
+	diagnostics := ParseCompileDiagnostics(constants.LanguageJava, output, "/tmp/debug/abc")
+	assert.Equal(t, []*CompileDiagnostic{
+		{File: "Main.java", Line: 3, Column: 18, Severity: SeverityError, Message: "';' expected"},
+		{File: "Main.java", Line: 4, Column: 9, Severity: SeverityError,
+			Message: "cannot find symbol\nsymbol:   variable b\nlocation: class Main"},
+	}, diagnostics)
+}
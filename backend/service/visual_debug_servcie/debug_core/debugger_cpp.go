@@ -0,0 +1,367 @@
+package debug_core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fansqz/fancode-backend/common/logger"
+)
+
+// cppContainerKind C++标准库容器的种类
+type cppContainerKind string
+
+const (
+	cppNotContainer cppContainerKind = ""
+	// cppSequence vector、list、deque、set、array等顺序展示的容器
+	cppSequence cppContainerKind = "sequence"
+	// cppString std::string
+	cppString cppContainerKind = "string"
+	// cppMap map、unordered_map等键值对容器
+	cppMap cppContainerKind = "map"
+	// cppAdaptor priority_queue、stack、queue等容器适配器，底层容器存放在成员c中
+	cppAdaptor cppContainerKind = "adaptor"
+)
+
+// cppMaxContainerElements 没有pretty-printer时，通过evaluate逐个读取元素的上限
+const cppMaxContainerElements = 512
+
+var (
+	// gdb中stl的内部命名空间，比如std::__cxx11::list、std::__1::vector
+	cppInlineNamespaceRegexp = regexp.MustCompile(`std::__\w+::`)
+	// pretty-printer展开的子元素名称，比如[0]、["key"]
+	cppPrettyChildRegexp = regexp.MustCompile(`^\[.*\]$`)
+	// 字符串字面量，比如 0x4052a0 "hello"
+	cppStringLiteralRegexp = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	// pretty-printer值中的元素个数，比如 std::map with 2 elements
+	cppContainerSizeRegexp = regexp.MustCompile(`with (\d+) elements?`)
+)
+
+var cppContainerPrefixes = []struct {
+	prefix string
+	kind   cppContainerKind
+}{
+	{"std::string", cppString},
+	{"std::basic_string<", cppString},
+	{"std::vector<", cppSequence},
+	{"std::list<", cppSequence},
+	{"std::forward_list<", cppSequence},
+	{"std::deque<", cppSequence},
+	{"std::array<", cppSequence},
+	{"std::set<", cppSequence},
+	{"std::multiset<", cppSequence},
+	{"std::unordered_set<", cppSequence},
+	{"std::map<", cppMap},
+	{"std::multimap<", cppMap},
+	{"std::unordered_map<", cppMap},
+	{"std::priority_queue<", cppAdaptor},
+	{"std::stack<", cppAdaptor},
+	{"std::queue<", cppAdaptor},
+}
+
+// normalizeCppType 去掉类型中的const、引用以及stl内部命名空间，便于判断容器类型
+func normalizeCppType(typeName string) string {
+	typeName = strings.TrimSpace(typeName)
+	typeName = strings.TrimPrefix(typeName, "const ")
+	typeName = strings.TrimSuffix(typeName, "&")
+	typeName = cppInlineNamespaceRegexp.ReplaceAllString(typeName, "std::")
+	return strings.TrimSpace(typeName)
+}
+
+// getCppContainerKind 根据gdb返回的类型名称判断是否是stl容器
+func getCppContainerKind(typeName string) cppContainerKind {
+	typeName = normalizeCppType(typeName)
+	// 容器的指针不当做容器处理
+	if strings.HasSuffix(typeName, "*") {
+		return cppNotContainer
+	}
+	for _, p := range cppContainerPrefixes {
+		if strings.HasPrefix(typeName, p.prefix) {
+			return p.kind
+		}
+	}
+	return cppNotContainer
+}
+
+// isCppContainer 判断变量是否是stl容器
+func isCppContainer(variable *Variable) bool {
+	return variable != nil && getCppContainerKind(variable.Type) != cppNotContainer
+}
+
+// isCppVector 判断变量是否是std::vector
+func isCppVector(variable *Variable) bool {
+	return variable != nil && strings.HasPrefix(normalizeCppType(variable.Type), "std::vector<")
+}
+
+// isCppPrettyChildren 子元素是否已经被pretty-printer展开
+func isCppPrettyChildren(children []*Variable) bool {
+	if len(children) == 0 {
+		return false
+	}
+	for _, child := range children {
+		if !cppPrettyChildRegexp.MatchString(child.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// extractCppStringLiteral 从gdb的值中读取字符串内容，比如 0x4052a0 "hello" 读取为 hello
+func extractCppStringLiteral(value string) (string, bool) {
+	match := cppStringLiteralRegexp.FindStringSubmatch(value)
+	if len(match) < 2 {
+		return "", false
+	}
+	return match[1], true
+}
+
+// mergeCppMapPairs pretty-printer以map方式展示时，key和value可能是交替出现的两个子元素，需要合并成一个元素
+// 通过容器的元素个数判断，子元素个数是元素个数的两倍时才合并，读取不到元素个数时不做处理
+// 不能通过子元素名称判断，比如std::map<int, int>{0: 10, 1: 20}展开后的名称也是[0]、[1]
+func mergeCppMapPairs(variable *Variable, children []*Variable) []*Variable {
+	size, ok := parseCppContainerSize(variable.Value)
+	if !ok || size == 0 || len(children) != 2*size {
+		return children
+	}
+	answer := make([]*Variable, 0, size)
+	for i := 0; i+1 < len(children); i += 2 {
+		key, value := children[i], children[i+1]
+		answer = append(answer, &Variable{
+			Name:             fmt.Sprintf("[%s]", key.Value),
+			Type:             value.Type,
+			Value:            value.Value,
+			Reference:        value.Reference,
+			NamedVariables:   value.NamedVariables,
+			IndexedVariables: value.IndexedVariables,
+		})
+	}
+	return answer
+}
+
+// parseCppContainerSize 从pretty-printer的值中读取容器的元素个数，比如 std::map with 2 elements
+func parseCppContainerSize(value string) (int, bool) {
+	match := cppContainerSizeRegexp.FindStringSubmatch(value)
+	if len(match) < 2 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+// findChildByName 在结构体及其基类中查找指定名称的成员
+// 未开启pretty-printer时，stl容器的成员被包在多层基类中，比如std::_Vector_base -> _M_impl -> _M_start
+func (d *debugger) findChildByName(ctx context.Context, children []*Variable, name string, depth int) *Variable {
+	for _, child := range children {
+		if child.Name == name {
+			return child
+		}
+	}
+	if depth <= 0 {
+		return nil
+	}
+	for _, child := range children {
+		if child.Reference == 0 {
+			continue
+		}
+		grandChildren, err := d.GetVariables(ctx, child.Reference)
+		if err != nil {
+			continue
+		}
+		if v := d.findChildByName(ctx, grandChildren, name, depth-1); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// decodeCppContainer 将stl容器解码为元素列表
+// expr为容器在当前栈帧中的表达式，在没有pretty-printer时用于通过evaluate读取元素，为空时不做evaluate
+func (d *debugger) decodeCppContainer(ctx context.Context, expr string, variable *Variable) ([]*Variable, error) {
+	if variable == nil {
+		return []*Variable{}, nil
+	}
+	kind := getCppContainerKind(variable.Type)
+	if kind == cppString {
+		return d.decodeCppString(ctx, variable)
+	}
+
+	var children []*Variable
+	if variable.Reference != 0 {
+		var err error
+		if children, err = d.GetVariables(ctx, variable.Reference); err != nil {
+			return nil, err
+		}
+	}
+	if isCppPrettyChildren(children) {
+		if kind == cppMap {
+			return mergeCppMapPairs(variable, children), nil
+		}
+		return children, nil
+	}
+
+	switch kind {
+	case cppAdaptor:
+		// 容器适配器读取底层容器c
+		c := d.findChildByName(ctx, children, "c", 0)
+		if c == nil {
+			return children, nil
+		}
+		if expr != "" {
+			expr = fmt.Sprintf("(%s).c", expr)
+		}
+		return d.decodeCppContainer(ctx, expr, c)
+	case cppSequence:
+		if expr != "" && isCppVector(variable) {
+			return d.decodeRawCppVector(ctx, expr)
+		}
+		if elems := d.findChildByName(ctx, children, "_M_elems", 0); elems != nil && elems.Reference != 0 {
+			// std::array
+			return d.GetVariables(ctx, elems.Reference)
+		}
+	}
+	logger.WithCtx(ctx).Warnf("[decodeCppContainer] container %s can not be decoded without pretty-printer", variable.Type)
+	return children, nil
+}
+
+// decodeCppString 将std::string拆分为字符列表
+func (d *debugger) decodeCppString(ctx context.Context, variable *Variable) ([]*Variable, error) {
+	str, ok := extractCppStringLiteral(variable.Value)
+	if !ok && variable.Reference != 0 {
+		// 未开启pretty-printer时，字符串内容存放在_M_dataplus._M_p中
+		children, err := d.GetVariables(ctx, variable.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if p := d.findChildByName(ctx, children, "_M_p", 2); p != nil {
+			str, ok = extractCppStringLiteral(p.Value)
+		}
+	}
+	if !ok {
+		return []*Variable{}, nil
+	}
+	nodes := splitStringToVisualNodes(str, "char")
+	answer := make([]*Variable, len(nodes))
+	for i, node := range nodes {
+		answer[i] = &Variable{Name: node.Name, Type: node.Type, Value: node.Value}
+	}
+	return answer, nil
+}
+
+// decodeRawCppVector 未开启pretty-printer时，通过_M_start和_M_finish指针读取vector的元素
+func (d *debugger) decodeRawCppVector(ctx context.Context, expr string) ([]*Variable, error) {
+	stackTrace, err := d.GetStackTrace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(stackTrace) == 0 {
+		return []*Variable{}, nil
+	}
+	frameID := stackTrace[0].ID
+	resp, err := d.evaluate(fmt.Sprintf("(%s)._M_impl._M_finish - (%s)._M_impl._M_start", expr, expr), frameID, "watch")
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(resp.Body.Result))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected vector length %s", resp.Body.Result)
+	}
+	if length > cppMaxContainerElements {
+		length = cppMaxContainerElements
+	}
+	answer := make([]*Variable, 0, length)
+	for i := 0; i < length; i++ {
+		elem, err := d.evaluate(fmt.Sprintf("(%s)._M_impl._M_start[%d]", expr, i), frameID, "watch")
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, &Variable{
+			Name:      fmt.Sprintf("[%d]", i),
+			Type:      elem.Body.Type,
+			Value:     elem.Body.Result,
+			Reference: elem.Body.VariablesReference,
+		})
+	}
+	return answer, nil
+}
+
+// getArrayNodesForCPP 读取c++数组或stl容器中的元素
+func (d *debugger) getArrayNodesForCPP(ctx context.Context, expr string, structVariable *Variable) []*VisualVariable {
+	if structVariable == nil {
+		return []*VisualVariable{}
+	}
+	if !isCppContainer(structVariable) {
+		return d.getArrayNodesForC(ctx, structVariable)
+	}
+	elements, err := d.decodeCppContainer(ctx, expr, structVariable)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getArrayNodesForCPP] decodeCppContainer fail, err = %v", err)
+		return []*VisualVariable{}
+	}
+	return d.toCharTrimmedVisualVariables(elements)
+}
+
+// getArray2DNodesForCPP 读取c++二维数组，比如vector<vector<int>>或int[3][3]
+func (d *debugger) getArray2DNodesForCPP(ctx context.Context, expr string, structVariable *Variable) ([][]*VisualVariable, error) {
+	var rows []*Variable
+	var err error
+	if isCppContainer(structVariable) {
+		rows, err = d.decodeCppContainer(ctx, expr, structVariable)
+	} else {
+		rows, err = d.GetVariables(ctx, structVariable.Reference)
+	}
+	if err != nil {
+		return nil, err
+	}
+	array2d := make([][]*VisualVariable, 0, len(rows))
+	for i, row := range rows {
+		rowExpr := fmt.Sprintf("(%s)[%d]", expr, i)
+		if isCppVector(structVariable) {
+			rowExpr = fmt.Sprintf("(%s)._M_impl._M_start[%d]", expr, i)
+		}
+		array2d = append(array2d, d.getArrayNodesForCPP(ctx, rowExpr, row))
+	}
+	return array2d, nil
+}
+
+// expandCppPointField 指针域如果是stl容器，比如vector<Node*> children，那么容器中所有元素都作为指针
+func (d *debugger) expandCppPointField(ctx context.Context, field *Variable) []*Variable {
+	if !isCppContainer(field) {
+		return []*Variable{field}
+	}
+	// 成员变量没有完整的表达式，只能依赖pretty-printer展开
+	elements, err := d.decodeCppContainer(ctx, "", field)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[expandCppPointField] decodeCppContainer fail, err = %v", err)
+		return []*Variable{}
+	}
+	answer := make([]*Variable, 0, len(elements))
+	for _, e := range elements {
+		answer = append(answer, &Variable{
+			Name:             field.Name + e.Name,
+			Type:             e.Type,
+			Value:            e.Value,
+			Reference:        e.Reference,
+			NamedVariables:   e.NamedVariables,
+			IndexedVariables: e.IndexedVariables,
+		})
+	}
+	return answer
+}
+
+// toCharTrimmedVisualVariables 转换为可视化变量，并去除char类型值前面的数字
+func (d *debugger) toCharTrimmedVisualVariables(vars []*Variable) []*VisualVariable {
+	re := regexp.MustCompile(`^\d+\s*`)
+	answer := make([]*VisualVariable, len(vars))
+	for i, v := range vars {
+		answer[i] = NewVisualVariable(v)
+		if v.Type == "char" {
+			answer[i].Value = re.ReplaceAllString(v.Value, "")
+		}
+	}
+	return answer
+}
@@ -0,0 +1,98 @@
+package debug_core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCppContainerKind(t *testing.T) {
+	tests := []struct {
+		typeName string
+		kind     cppContainerKind
+	}{
+		{"std::vector<int, std::allocator<int> >", cppSequence},
+		{"const std::vector<int> &", cppSequence},
+		{"std::__cxx11::string", cppString},
+		{"std::__cxx11::basic_string<char, std::char_traits<char>, std::allocator<char> >", cppString},
+		{"std::__cxx11::list<int>", cppSequence},
+		{"std::map<int, int>", cppMap},
+		{"std::unordered_map<std::string, int>", cppMap},
+		{"std::stack<int, std::deque<int> >", cppAdaptor},
+		{"std::vector<int> *", cppNotContainer},
+		{"Node *", cppNotContainer},
+		{"int [10]", cppNotContainer},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.kind, getCppContainerKind(tt.typeName), tt.typeName)
+	}
+}
+
+func TestExtractCppStringLiteral(t *testing.T) {
+	str, ok := extractCppStringLiteral(`0x4052a0 "hello"`)
+	assert.True(t, ok)
+	assert.Equal(t, "hello", str)
+
+	str, ok = extractCppStringLiteral(`"a\"b"`)
+	assert.True(t, ok)
+	assert.Equal(t, `a\"b`, str)
+
+	_, ok = extractCppStringLiteral("0x4052a0")
+	assert.False(t, ok)
+}
+
+func TestIsCppPrettyChildren(t *testing.T) {
+	assert.True(t, isCppPrettyChildren([]*Variable{{Name: "[0]"}, {Name: "[\"key\"]"}}))
+	assert.False(t, isCppPrettyChildren([]*Variable{{Name: "[0]"}, {Name: "_M_impl"}}))
+	assert.False(t, isCppPrettyChildren(nil))
+}
+
+func TestMergeCppMapPairs(t *testing.T) {
+	mapVariable := &Variable{Type: "std::map<int, int>", Value: "std::map with 2 elements"}
+	children := []*Variable{
+		{Name: "[0]", Type: "int", Value: "1"},
+		{Name: "[1]", Type: "int", Value: "10"},
+		{Name: "[2]", Type: "int", Value: "2"},
+		{Name: "[3]", Type: "int", Value: "20"},
+	}
+	merged := mergeCppMapPairs(mapVariable, children)
+	assert.Equal(t, 2, len(merged))
+	assert.Equal(t, "[1]", merged[0].Name)
+	assert.Equal(t, "10", merged[0].Value)
+	assert.Equal(t, "[2]", merged[1].Name)
+	assert.Equal(t, "20", merged[1].Value)
+
+	// 已经是[key] = value形式的不做处理
+	keyed := []*Variable{{Name: "[1]", Value: "10"}, {Name: "[2]", Value: "20"}}
+	assert.Equal(t, keyed, mergeCppMapPairs(mapVariable, keyed))
+
+	// key为0到n-1的map，名称和交替展示的子元素相同，也不能合并
+	intKeyed := []*Variable{
+		{Name: "[0]", Type: "int", Value: "10"},
+		{Name: "[1]", Type: "int", Value: "20"},
+	}
+	assert.Equal(t, intKeyed, mergeCppMapPairs(mapVariable, intKeyed))
+
+	// 读取不到元素个数时不做处理
+	assert.Equal(t, children, mergeCppMapPairs(&Variable{Type: "std::map<int, int>"}, children))
+}
+
+func TestParseCppContainerSize(t *testing.T) {
+	size, ok := parseCppContainerSize("std::map with 2 elements")
+	assert.True(t, ok)
+	assert.Equal(t, 2, size)
+	size, ok = parseCppContainerSize("std::unordered_map with 1 element")
+	assert.True(t, ok)
+	assert.Equal(t, 1, size)
+	_, ok = parseCppContainerSize("{...}")
+	assert.False(t, ok)
+}
+
+func TestSplitStringToVisualNodes(t *testing.T) {
+	nodes := splitStringToVisualNodes(`a\nb`, "char")
+	assert.Equal(t, 3, len(nodes))
+	assert.Equal(t, "'a'", nodes[0].Value)
+	assert.Equal(t, `'\n'`, nodes[1].Value)
+	assert.Equal(t, "[2]", nodes[2].Name)
+	assert.Equal(t, "char", nodes[2].Type)
+}
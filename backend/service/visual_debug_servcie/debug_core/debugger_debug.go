@@ -43,6 +43,8 @@ type debugger struct {
 	compileFile string
 	// 调试文件
 	execFile string
+	// 编译产物的缓存key
+	cacheKey string
 
 	// 事件产生时，触发该回调
 	callback NotificationCallback
@@ -149,7 +151,8 @@ func (d *debugger) allocatePort() error {
 
 func (d *debugger) saveCode(ctx context.Context) error {
 	// 生成容器内的工作目录路径
-	d.workPath = getExecutePath(d.option.TempDir)
+	d.cacheKey = compileCacheKey(d.option.Language, d.option.Code)
+	d.workPath = getExecutePath(d.option.TempDir, d.cacheKey)
 
 	// 在容器内创建工作目录
 	if _, err, _ := d.docker.Exec(ctx, []string{"mkdir", "-p", d.workPath}); err != nil {
@@ -220,6 +223,11 @@ func (d *debugger) compile(ctx context.Context, compileFiles []string) *CompileE
 	var err error
 	var exitCode int
 
+	// 相同代码已经编译过时直接使用缓存的编译产物
+	if event, ok := d.restoreArtifact(ctx); ok {
+		return event
+	}
+
 	switch d.option.Language {
 	case constants.LanguageJava:
 		// java语言编译麻烦，特殊处理
@@ -242,20 +250,26 @@ func (d *debugger) compile(ctx context.Context, compileFiles []string) *CompileE
 			logger.WithCtx(ctx).Errorf("[compile] exec fail, err = %s", err)
 			break
 		}
-		output, err, exitCode = d.docker.Exec(ctx, []string{"go", "-C", d.workPath, "build", "-gcflags", "all=-N -l", "-o", execFile})
+		cmd := append(append([]string{"go", "-C", d.workPath, "build"}, compileFlags[constants.LanguageGo]...), "-o", execFile)
+		output, err, exitCode = d.docker.Exec(ctx, cmd)
 	case constants.LanguageC:
-		output, err, exitCode = d.docker.Exec(ctx, append([]string{"gcc", "-g", "-o", execFile}, compileFiles...))
+		cmd := append(append([]string{"gcc"}, compileFlags[constants.LanguageC]...), "-o", execFile)
+		output, err, exitCode = d.docker.Exec(ctx, append(cmd, compileFiles...))
 	case constants.LanguageCPP:
-		output, err, exitCode = d.docker.Exec(ctx, append([]string{"g++", "-g", "-O0", "-o", execFile}, compileFiles...))
+		cmd := append(append([]string{"g++"}, compileFlags[constants.LanguageCPP]...), "-o", execFile)
+		output, err, exitCode = d.docker.Exec(ctx, append(cmd, compileFiles...))
 	}
 
 	// 在容器中执行编译命令
 	if err != nil {
 		return NewCompileEvent(false, err.Error())
 	}
+	diagnostics := ParseCompileDiagnostics(d.option.Language, output, d.workPath)
 	if exitCode == 0 {
 		d.execFile = execFile
-		return NewCompileEvent(true, "编译成功")
+		d.saveArtifact(ctx, output)
+		// 编译成功时可能也有警告
+		return NewCompileEvent(true, "编译成功").WithDiagnostics(diagnostics)
 	}
 
 	// 正则处理路径
@@ -267,7 +281,7 @@ func (d *debugger) compile(ctx context.Context, compileFiles []string) *CompileE
 	re := regexp.MustCompile(`(?:\.\./|/).*?/(main\.go:\d+:\d+):`)
 	// 进行替换
 	compileOutput = re.ReplaceAllString(compileOutput, "$1:")
-	return NewCompileEvent(false, strings.Replace(compileOutput, d.workPath, "", -1))
+	return NewCompileEvent(false, strings.Replace(compileOutput, d.workPath, "", -1)).WithDiagnostics(diagnostics)
 }
 
 func (d *debugger) compileJava(ctx context.Context, compileFiles []string) *CompileEvent {
@@ -283,13 +297,16 @@ func (d *debugger) compileJava(ctx context.Context, compileFiles []string) *Comp
 	}
 
 	// 编译为class文件
-	output, err, exitCode := d.docker.Exec(ctx, append([]string{"javac", "-encoding", "UTF-8", "-d", classPath}, compileFiles...))
+	cmd := append(append([]string{"javac"}, compileFlags[constants.LanguageJava]...), "-d", classPath)
+	output, err, exitCode := d.docker.Exec(ctx, append(cmd, compileFiles...))
 	if err != nil {
 		return NewCompileEvent(false, err.Error())
 	}
+	compileOutput := output
+	diagnostics := ParseCompileDiagnostics(constants.LanguageJava, output, d.workPath)
 	if exitCode != 0 {
 		d.execFile = execFile
-		return NewCompileEvent(false, output)
+		return NewCompileEvent(false, strings.Replace(output, d.workPath+"/", "", -1)).WithDiagnostics(diagnostics)
 	}
 
 	// 将 MANIFEST.MF 复制到容器内
@@ -308,7 +325,8 @@ func (d *debugger) compileJava(ctx context.Context, compileFiles []string) *Comp
 		return NewCompileEvent(false, output)
 	}
 	d.execFile = execFile
-	return NewCompileEvent(true, "编译成功")
+	d.saveArtifact(ctx, compileOutput)
+	return NewCompileEvent(true, "编译成功").WithDiagnostics(diagnostics)
 }
 
 // startDebugger 启动调试器
@@ -400,6 +418,9 @@ func (d *debugger) dapEventCallback(message dap.EventMessage) {
 		}
 		d.mutex.Unlock()
 		d.callback(NewContinuedEvent())
+	case *dap.ExitedEvent:
+		event := message.(*dap.ExitedEvent)
+		d.callback(NewExitedEvent(event.Body.ExitCode, ""))
 	case *dap.TerminatedEvent:
 		d.callback(NewTerminalEvent())
 	default:
@@ -617,9 +638,10 @@ func (d *debugger) Terminate(ctx context.Context) error {
 }
 
 // getExecutePath 给用户的此次运行生成一个临时目录
-func getExecutePath(tempPath string) string {
-	uuid := utils.GetUUID()
-	executePath := path.Join(tempPath, uuid)
+// 调试信息中记录了源文件的绝对路径，相同代码使用相同的目录，缓存的编译产物才能在其他容器中正确调试
+// 每次调试使用独立的容器，目录相同不会冲突
+func getExecutePath(tempPath string, cacheKey string) string {
+	executePath := path.Join(tempPath, cacheKey[:32])
 	return executePath
 }
 
@@ -85,11 +85,17 @@ func (d *debugger) StructVisual(ctx context.Context, query *StructVisualQuery) (
 					visualNode.Values = append(visualNode.Values, NewVisualVariable(v))
 				}
 				if pointQuerySet.Contains(v.Name) {
-					// 处理指针域
-					visualVariable := NewVisualVariable(v)
-					visualVariable.Value = v.Value
-					visualNode.Points = append(visualNode.Points, visualVariable)
-					newVariables = append(newVariables, v)
+					// 处理指针域，c++中指针域可能是存放指针的容器，比如vector<Node*>
+					points := []*Variable{v}
+					if d.option.Language == constants.LanguageCPP {
+						points = d.expandCppPointField(ctx, v)
+					}
+					for _, p := range points {
+						visualVariable := NewVisualVariable(p)
+						visualVariable.Value = p.Value
+						visualNode.Points = append(visualNode.Points, visualVariable)
+						newVariables = append(newVariables, p)
+					}
 				}
 			}
 			visualNodeSet[visualNode.ID] = visualNode
@@ -113,6 +119,11 @@ func (d *debugger) isTargetStruct(structType string, targetType string) bool {
 		regexpStr = fmt.Sprintf(`^\*.*\.%s.*$`, targetType)
 	case constants.LanguageC:
 		regexpStr = fmt.Sprintf(`\b(%s)\b`, targetType)
+	case constants.LanguageCPP:
+		// c++中类和结构体可以不带struct关键字，同时要排除vector<Node*>这类容器
+		regexpStr = fmt.Sprintf(`^(const\s+)?((struct|class)\s+)?(\w+::)*%s\s*[*&]?\s*(const)?$`, regexp.QuoteMeta(targetType))
+	default:
+		return false
 	}
 	re := regexp.MustCompile(regexpStr)
 	return re.MatchString(structType)
@@ -149,8 +160,9 @@ func (d *debugger) ArrayVisual(ctx context.Context, query *ArrayVisualQuery) (*A
 		}
 	}
 
-	// c语言做特殊处理
-	if d.option.Language == constants.LanguageC {
+	// c语言做特殊处理，c++中的普通数组同理
+	if d.option.Language == constants.LanguageC ||
+		(d.option.Language == constants.LanguageCPP && !isCppContainer(structVariable)) {
 		structVariable, err = d.getStructVariableForC(ctx, stackTrace, structVariable)
 		if err != nil {
 			logger.WithCtx(ctx).Errorf("[VariableVisual] getStructVariableForC fail, err=%v", err)
@@ -165,6 +177,8 @@ func (d *debugger) ArrayVisual(ctx context.Context, query *ArrayVisualQuery) (*A
 		structs = d.getArrayNodesForGo(ctx, structVariable)
 	case constants.LanguageC:
 		structs = d.getArrayNodesForC(ctx, structVariable)
+	case constants.LanguageCPP:
+		structs = d.getArrayNodesForCPP(ctx, query.ArrayName, structVariable)
 	default:
 		structs = d.getArrayNodesNormal(ctx, structVariable)
 	}
@@ -184,27 +198,32 @@ func (d *debugger) getArrayNodesForGo(ctx context.Context, structVariable *Varia
 		return d.getArrayNodesNormal(ctx, structVariable)
 	} else {
 		trimmedStr := strings.Trim(structVariable.Value, "\"")
-		vars := []*VisualVariable{}
-		index := 0
-		for i := 0; i < len(trimmedStr); i++ {
-			char := trimmedStr[i]
-			value := fmt.Sprintf("'%c'", char)
-			// 特殊处理转义字符
-			if char == '\\' && i < len(trimmedStr)-1 {
-				value = fmt.Sprintf("'%c%c'", trimmedStr[i], trimmedStr[i+1])
-				i++
-			}
-			vars = append(vars, &VisualVariable{
-				Name:  fmt.Sprintf("[%d]", index),
-				Type:  "byte",
-				Value: value,
-			})
-			index++
-		}
-		return vars
+		return splitStringToVisualNodes(trimmedStr, "byte")
 	}
 }
 
+// splitStringToVisualNodes 将字符串拆分为字符节点，转义字符作为一个节点
+func splitStringToVisualNodes(str string, charType string) []*VisualVariable {
+	vars := []*VisualVariable{}
+	index := 0
+	for i := 0; i < len(str); i++ {
+		char := str[i]
+		value := fmt.Sprintf("'%c'", char)
+		// 特殊处理转义字符
+		if char == '\\' && i < len(str)-1 {
+			value = fmt.Sprintf("'%c%c'", str[i], str[i+1])
+			i++
+		}
+		vars = append(vars, &VisualVariable{
+			Name:  fmt.Sprintf("[%d]", index),
+			Type:  charType,
+			Value: value,
+		})
+		index++
+	}
+	return vars
+}
+
 // getStringVisualNodesForC 将收集到的目标遍历转换为可视化节点 C语言字符串要特殊处理
 func (d *debugger) getArrayNodesForC(ctx context.Context, structVariable *Variable) []*VisualVariable {
 	if structVariable == nil {
@@ -258,6 +277,57 @@ func (d *debugger) getStructVariableForC(ctx context.Context, stackTrace []*Stac
 	return structVariable, nil
 }
 
+// VisualCheck 检查所有栈帧中是否存在可视化描述引用的变量，以及目标结构体是否包含引用的字段
+func (d *debugger) VisualCheck(ctx context.Context, query *VisualCheckQuery) (*VisualCheckResult, error) {
+	logger.WithCtx(ctx).Infof("[GoDebugger] VisualCheck")
+	allVariables, err := d.getAllFrameVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	answer := &VisualCheckResult{
+		MissingVariables: []string{},
+		MissingFields:    []string{},
+	}
+	nameSet := mapset.NewSet()
+	for _, v := range allVariables {
+		nameSet.Add(v.Name)
+	}
+	for _, name := range query.Variables {
+		if !nameSet.Contains(name) {
+			answer.MissingVariables = append(answer.MissingVariables, name)
+		}
+	}
+	if query.Struct == "" {
+		return answer, nil
+	}
+
+	// 找到一个非空的目标结构体，读取结构体的字段
+	for _, v := range allVariables {
+		if !d.isTargetStruct(v.Type, query.Struct) || v.Reference == 0 {
+			continue
+		}
+		fields, err := d.GetVariables(ctx, v.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		answer.StructFound = true
+		fieldSet := mapset.NewSet()
+		for _, field := range fields {
+			fieldSet.Add(field.Name)
+		}
+		for _, field := range query.Fields {
+			if !fieldSet.Contains(field) {
+				answer.MissingFields = append(answer.MissingFields, field)
+			}
+		}
+		break
+	}
+	return answer, nil
+}
+
 // 读取所有栈帧中的变量列表
 func (d *debugger) getAllFrameVariables(ctx context.Context) ([]*Variable, error) {
 	variables := make([]*Variable, 0, 10)
@@ -317,8 +387,9 @@ func (d *debugger) Array2DVisual(ctx context.Context, query *Array2DVisualQuery)
 		}
 	}
 
-	// c语言做特殊处理
-	if d.option.Language == constants.LanguageC {
+	// c语言做特殊处理，c++中的普通数组同理
+	if d.option.Language == constants.LanguageC ||
+		(d.option.Language == constants.LanguageCPP && !isCppContainer(structVariable)) {
 		structVariable, err = d.getStructVariableForC(ctx, stackTrace, structVariable)
 		if err != nil {
 			logger.WithCtx(ctx).Errorf("[VariableVisual] getStructVariableForC fail, err=%v", err)
@@ -333,6 +404,19 @@ func (d *debugger) Array2DVisual(ctx context.Context, query *Array2DVisualQuery)
 			Array:     [][]*VisualVariable{},
 		}, nil
 	}
+	// c++的vector<vector<int>>等容器需要解码
+	if d.option.Language == constants.LanguageCPP {
+		array2d, err := d.getArray2DNodesForCPP(ctx, query.ArrayName, structVariable)
+		if err != nil {
+			logger.WithCtx(ctx).Errorf("[Array2DVisual] getArray2DNodesForCPP fail, err=%v", err)
+			return nil, err
+		}
+		return &Array2DVisualData{
+			RowPoints: rowPointVariables,
+			ColPoints: columnPointVariables,
+			Array:     array2d,
+		}, nil
+	}
 	arrases, err := d.GetVariables(ctx, structVariable.Reference)
 	var array2d [][]*VisualVariable
 	for _, array := range arrases {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/fansqz/fancode-backend/common/artifact_cache"
 	"github.com/fansqz/fancode-backend/constants"
 )
 
@@ -45,6 +46,8 @@ type Debugger interface {
 	ArrayVisual(ctx context.Context, query *ArrayVisualQuery) (*ArrayVisualData, error)
 	// Array2DVisual 二维数组可视化
 	Array2DVisual(ctx context.Context, query *Array2DVisualQuery) (*Array2DVisualData, error)
+	// VisualCheck 检查可视化需要的变量和结构体在当前程序中是否存在
+	VisualCheck(ctx context.Context, query *VisualCheckQuery) (*VisualCheckResult, error)
 }
 
 // Option 启动调试的参数
@@ -77,4 +80,7 @@ type Option struct {
 
 	// TempDir 临时文件目录
 	TempDir string
+
+	// CompileCache 编译产物缓存，不为空时相同语言和代码的程序再次调试不需要重新编译
+	CompileCache artifact_cache.Cache
 }
@@ -60,6 +60,26 @@ type Array2DVisualData struct {
 	ColPoints []*VisualVariable   `json:"colPoints"`
 }
 
+// VisualCheckQuery 校验可视化描述的查询，检查变量和结构体在程序中是否存在
+type VisualCheckQuery struct {
+	// Variables 需要存在的变量名称
+	Variables []string `json:"variables"`
+	// Struct 需要存在的结构体名称，为空时不检查结构体
+	Struct string `json:"struct"`
+	// Fields 结构体中需要存在的字段
+	Fields []string `json:"fields"`
+}
+
+// VisualCheckResult 可视化描述的校验结果
+type VisualCheckResult struct {
+	// MissingVariables 所有栈帧中都找不到的变量
+	MissingVariables []string `json:"missingVariables"`
+	// StructFound 是否找到了目标结构体类型的变量
+	StructFound bool `json:"structFound"`
+	// MissingFields 结构体中找不到的字段，只有StructFound为true时才有意义
+	MissingFields []string `json:"missingFields"`
+}
+
 type VisualVariable struct {
 	// 变量名称
 	Name string `json:"name"`
@@ -172,6 +192,10 @@ func NewTerminalEvent() *TerminalEvent {
 type CompileEvent struct {
 	Success bool
 	Message string // 编译产生的信息
+	// Diagnostics 从编译输出中解析出的错误和警告
+	Diagnostics []*CompileDiagnostic
+	// Cached 是否使用了缓存的编译产物
+	Cached bool
 }
 
 func NewCompileEvent(success bool, message string) *CompileEvent {
@@ -180,3 +204,9 @@ func NewCompileEvent(success bool, message string) *CompileEvent {
 		Message: message,
 	}
 }
+
+// WithDiagnostics 设置编译诊断信息
+func (c *CompileEvent) WithDiagnostics(diagnostics []*CompileDiagnostic) *CompileEvent {
+	c.Diagnostics = diagnostics
+	return c
+}
@@ -25,6 +25,10 @@ type Config struct {
 	CPUQuota      int64
 	Binds         []string
 	PortMapping   [][]string
+	// NetworkDisabled 禁用容器网络
+	NetworkDisabled bool
+	// PidsLimit 容器进程数限制，0表示不限制
+	PidsLimit int64
 }
 
 // DockerClient Docker 客户端接口
@@ -33,6 +37,8 @@ type DockerClient interface {
 	Exec(ctx context.Context, cmd []string) (output string, err error, exitCode int)
 	// CopyToContainer 将文件内容复制到容器指定目录
 	CopyToContainer(ctx context.Context, content []byte, destPath string, filename string) error
+	// CopyFromContainer 读取容器中指定文件的内容
+	CopyFromContainer(ctx context.Context, filePath string) ([]byte, error)
 	// RemoveContainer 强制关停并删除容器
 	RemoveContainer(ctx context.Context) error
 	// Interrupt 中断信号处理
@@ -75,6 +81,9 @@ func NewDockerClient(ctx context.Context, config *Config) (DockerClient, error)
 		PortBindings: portMappings,
 		Binds:        config.Binds,
 	}
+	if config.PidsLimit > 0 {
+		hostConfig.Resources.PidsLimit = &config.PidsLimit
+	}
 
 	cli, err := client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
@@ -82,8 +91,9 @@ func NewDockerClient(ctx context.Context, config *Config) (DockerClient, error)
 	}
 
 	resp, err := cli.ContainerCreate(ctx, &container.Config{
-		Image: config.ImageName,
-		Tty:   true,
+		Image:           config.ImageName,
+		Tty:             true,
+		NetworkDisabled: config.NetworkDisabled,
 	}, hostConfig, nil, nil, config.ContainerName)
 	if err != nil {
 		return nil, err
@@ -149,6 +159,22 @@ func (d *dockerClient) CopyToContainer(ctx context.Context, content []byte, dest
 	})
 }
 
+// CopyFromContainer 读取容器中指定文件的内容
+func (d *dockerClient) CopyFromContainer(ctx context.Context, filePath string) ([]byte, error) {
+	reader, _, err := d.client.CopyFromContainer(ctx, d.containerID, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	// 返回的是只包含单个文件的tar归档
+	tr := tar.NewReader(reader)
+	if _, err = tr.Next(); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(tr)
+}
+
 // RemoveContainer 强制关停并删除容器
 func (d *dockerClient) RemoveContainer(ctx context.Context) error {
 	if err := d.client.ContainerKill(ctx, d.containerID, "SIGKILL"); err != nil {
@@ -0,0 +1,192 @@
+package visual_debug_servcie
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/ai_analyze_core"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
+)
+
+// maxRecordOutputLength 记录的程序输出的最大长度，只保留末尾
+const maxRecordOutputLength = 4096
+
+// DebugRecorder 记录一次调试过程中程序的状态，供AI调试助手使用
+// 调试会话销毁以后仍然保留一段时间，用于分析程序退出的原因
+type DebugRecorder struct {
+	mutex sync.Mutex
+	// captureMutex 保证同一时间只有一个goroutine读取调试器的状态
+	captureMutex sync.Mutex
+
+	language constants.LanguageType
+	code     string
+
+	// stopSeq 程序暂停的次数，capturedSeq 已经读取过状态的暂停次数
+	stopSeq     int
+	capturedSeq int
+	stopped     bool
+	reason      constants.StoppedReasonType
+	// runningSince 程序最近一次开始运行的时间
+	runningSince time.Time
+
+	stack        []*debug_core.StackFrame
+	variables    []*debug_core.Variable
+	preVariables []*debug_core.Variable
+
+	// compile 最后一次编译的结果
+	compile *debug_core.CompileEvent
+
+	exited      bool
+	exitCode    int
+	exitMessage string
+	output      string
+}
+
+// NewDebugRecorder 创建调试记录
+func NewDebugRecorder() *DebugRecorder {
+	return &DebugRecorder{}
+}
+
+// RecordStart 记录用户程序的代码
+func (r *DebugRecorder) RecordStart(language constants.LanguageType, code string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.language = language
+	r.code = code
+	r.runningSince = time.Now()
+}
+
+// RecordEvent 根据调试器的事件更新程序状态，返回程序是否刚刚暂停
+func (r *DebugRecorder) RecordEvent(event interface{}) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	switch ev := event.(type) {
+	case *debug_core.StoppedEvent:
+		r.stopSeq++
+		r.stopped = true
+		r.reason = ev.Reason
+		return true
+	case *debug_core.CompileEvent:
+		r.compile = ev
+	case *debug_core.ContinuedEvent:
+		r.stopped = false
+		r.runningSince = time.Now()
+	case *debug_core.OutputEvent:
+		r.output += ev.Output
+		if len(r.output) > maxRecordOutputLength {
+			r.output = r.output[len(r.output)-maxRecordOutputLength:]
+		}
+	case *debug_core.ExitedEvent:
+		r.exited = true
+		r.stopped = false
+		r.exitCode = ev.ExitCode
+		r.exitMessage = ev.Message
+	case *debug_core.TerminalEvent:
+		r.exited = true
+		r.stopped = false
+	}
+	return false
+}
+
+// Capture 读取程序暂停时的栈帧和栈顶变量，同一次暂停只读取一次
+func (r *DebugRecorder) Capture(ctx context.Context, debugger debug_core.Debugger) error {
+	r.captureMutex.Lock()
+	defer r.captureMutex.Unlock()
+
+	r.mutex.Lock()
+	seq, stopped := r.stopSeq, r.stopped
+	captured := r.capturedSeq == seq
+	r.mutex.Unlock()
+	if captured || !stopped {
+		return nil
+	}
+
+	stack, err := debugger.GetStackTrace(ctx)
+	if err != nil {
+		return err
+	}
+	var variables []*debug_core.Variable
+	if len(stack) != 0 {
+		if variables, err = debugger.GetFrameVariables(ctx, stack[0].ID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.capturedSeq = seq
+	r.stack = stack
+	r.preVariables = r.variables
+	r.variables = variables
+	return nil
+}
+
+// StepContext 生成讲解当前步骤需要的程序状态，程序没有暂停时返回false
+func (r *DebugRecorder) StepContext() (*ai_analyze_core.StepContext, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.stopped || r.capturedSeq != r.stopSeq {
+		return nil, false
+	}
+	return &ai_analyze_core.StepContext{
+		Language:         r.language,
+		Code:             r.code,
+		StoppedReason:    r.reason,
+		Stack:            r.stack,
+		Variables:        r.variables,
+		ChangedVariables: ai_analyze_core.DiffVariables(r.preVariables, r.variables),
+	}, true
+}
+
+// CompileErrorContext 生成讲解编译错误需要的信息，没有编译失败时返回false
+func (r *DebugRecorder) CompileErrorContext() (*ai_analyze_core.CompileErrorContext, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.compile == nil || r.compile.Success {
+		return nil, false
+	}
+	return &ai_analyze_core.CompileErrorContext{
+		Language:    r.language,
+		Code:        r.code,
+		Message:     r.compile.Message,
+		Diagnostics: r.compile.Diagnostics,
+	}, true
+}
+
+// DiagnoseContext 生成诊断程序需要的程序状态
+func (r *DebugRecorder) DiagnoseContext() *ai_analyze_core.DiagnoseContext {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	answer := &ai_analyze_core.DiagnoseContext{
+		Language:      r.language,
+		Code:          r.code,
+		StoppedReason: r.reason,
+		ExitCode:      r.exitCode,
+		ExitMessage:   r.exitMessage,
+		Stack:         r.stack,
+		Variables:     r.variables,
+		Output:        r.output,
+	}
+	switch {
+	// 程序因为异常暂停以后退出，最后一次暂停的原因就是退出的原因
+	case (r.stopped || r.exited) && isCrashReason(r.reason):
+		answer.Status = ai_analyze_core.ProgramCrashed
+	case r.exited:
+		answer.Status = ai_analyze_core.ProgramExited
+	case r.stopped:
+		answer.Status = ai_analyze_core.ProgramStopped
+	default:
+		answer.Status = ai_analyze_core.ProgramRunning
+		answer.RunningFor = time.Since(r.runningSince)
+	}
+	return answer
+}
+
+// isCrashReason 判断程序是否因为异常暂停
+func isCrashReason(reason constants.StoppedReasonType) bool {
+	reason = constants.StoppedReasonType(strings.ToLower(string(reason)))
+	return reason == constants.ExceptionStopped || reason == constants.SignalStopped
+}
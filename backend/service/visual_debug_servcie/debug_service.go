@@ -4,13 +4,16 @@ import (
 	"context"
 	json2 "encoding/json"
 	"fmt"
+	"github.com/fansqz/fancode-backend/common/artifact_cache"
 	"github.com/fansqz/fancode-backend/common/config"
 	e "github.com/fansqz/fancode-backend/common/error"
 	"github.com/fansqz/fancode-backend/common/logger"
 	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/models/vo"
+	"github.com/fansqz/fancode-backend/service/user_saved_code_service"
 	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
+	"github.com/fansqz/fancode-backend/service/visual_document_service"
 	"github.com/fansqz/fancode-backend/utils"
 	"log"
 	"net/http"
@@ -31,6 +34,10 @@ type DebugService interface {
 
 	// Start 加载并启动用户程序
 	Start(ctx context.Context, startReq dto.StartDebugRequest) error
+	// StartSnippet 加载并启动文档中的代码示例
+	StartSnippet(ctx context.Context, req dto.StartSnippetDebugRequest) error
+	// StartShare 加载并启动通过链接分享的代码，不需要登录
+	StartShare(ctx context.Context, req dto.StartShareDebugRequest) error
 	SendToConsole(ctx context.Context, id string, input string) error
 	StepIn(ctx context.Context, id string) error
 	StepOver(ctx context.Context, id string) error
@@ -46,11 +53,25 @@ type DebugService interface {
 
 type debugService struct {
 	config *config.AppConfig
+	// compileCache 编译产物缓存，所有调试共享
+	compileCache artifact_cache.Cache
+	// documentService 读取文档中的代码示例
+	documentService visual_document_service.VisualDocumentService
+	// progressService 调试文档示例结束后标记文档已完成
+	progressService visual_document_service.VisualDocumentProgressService
+	// savedCodeService 读取分享的代码
+	savedCodeService user_saved_code_service.UserSavedCodeService
 }
 
-func NewDebugService(cf *config.AppConfig) DebugService {
+func NewDebugService(cf *config.AppConfig, documentService visual_document_service.VisualDocumentService,
+	progressService visual_document_service.VisualDocumentProgressService,
+	savedCodeService user_saved_code_service.UserSavedCodeService) DebugService {
 	return &debugService{
-		config: cf,
+		config:           cf,
+		compileCache:     artifact_cache.New(cf.CompileCacheConfig),
+		documentService:  documentService,
+		progressService:  progressService,
+		savedCodeService: savedCodeService,
 	}
 }
 
@@ -138,6 +159,11 @@ func (d *debugService) Terminate(ctx context.Context, debugID string) error {
 }
 
 func (d *debugService) Start(ctx context.Context, startReq dto.StartDebugRequest) error {
+	return d.start(ctx, startReq, 0)
+}
+
+// start 加载并启动用户程序，documentID不为0时表示调试的是该文档自己的代码示例
+func (d *debugService) start(ctx context.Context, startReq dto.StartDebugRequest, documentID uint) error {
 	// 如果已经获取不到，代表已经被提前close了
 	if _, ok := DebugSessionManage.GetDebugSession(ctx, startReq.ID); !ok {
 		return e.ErrDebuggerIsClosed
@@ -151,26 +177,100 @@ func (d *debugService) Start(ctx context.Context, startReq dto.StartDebugRequest
 	debugge := debugSession.Debugger
 	visualDescriptionAnalyzer := debugSession.VisualDescriptionAnalyzer
 
-	// 启动代码分析
-	if err := visualDescriptionAnalyzer.StartAnalyzeCode(ctx, startReq.Code, startReq.Language); err != nil {
+	// 用户或者文档指定了可视化描述时直接使用，否则启动代码分析
+	if startReq.VisualDescription != nil {
+		if err := startReq.VisualDescription.Validate(); err != nil {
+			return e.NewError(e.CodeVisualDescriptionInvalid, err.Error(), e.ErrTypeBadReq)
+		}
+		visualDescriptionAnalyzer.SetVisualDescription(startReq.VisualDescription)
+	} else if err := visualDescriptionAnalyzer.StartAnalyzeCode(ctx, startReq.Code, startReq.Language); err != nil {
 		return fmt.Errorf("start analyze code error, err = %v", err)
 	}
 
+	debugSession.Recorder.RecordStart(startReq.Language, startReq.Code)
+
 	//启动用户程序
-	return debugge.Start(ctx, &debug_core.Option{
+	err := debugge.Start(ctx, &debug_core.Option{
 		Language:      startReq.Language,
 		Code:          startReq.Code,
 		BreakPoints:   startReq.Breakpoints,
 		TempDir:       d.config.FilePathConfig.TempDir,
 		DebuggerImage: d.config.DebuggerImage,
 		// Callback 事件回调
-		Callback:       d.notificationCallback(ctx, startReq.ID),
+		Callback:       d.completeDocumentCallback(ctx, documentID, d.notificationCallback(ctx, startReq.ID)),
 		CompileTimeout: 30 * time.Second,
 		OptionTimeout:  2 * time.Second,
 		DebugTimeout:   10 * 60 * time.Second,
 		MemoryLimit:    1024 * 1024 * 1024,
 		CPUQuota:       5 * 60 * 1000000,
+		CompileCache:   d.compileCache,
 	})
+	if err != nil || startReq.Stdin == "" {
+		return err
+	}
+	// 发送预设的标准输入
+	if err = debugge.Send(ctx, startReq.Stdin); err != nil {
+		logger.WithCtx(ctx).Errorf("[Start] send stdin fail, err = %v", err)
+		return err
+	}
+	return nil
+}
+
+func (d *debugService) StartSnippet(ctx context.Context, req dto.StartSnippetDebugRequest) error {
+	snippet, err := d.documentService.GetVisualDocumentSnippet(ctx, req.SnippetID)
+	if err != nil {
+		return err
+	}
+	for _, code := range snippet.CodeList {
+		if code.Language != string(req.Language) {
+			continue
+		}
+		breakpoints := req.Breakpoints
+		if len(breakpoints) == 0 {
+			breakpoints = code.Breakpoints
+		}
+		return d.start(ctx, dto.StartDebugRequest{
+			ID:                req.ID,
+			Code:              code.Code,
+			Language:          req.Language,
+			Breakpoints:       breakpoints,
+			VisualDescription: code.VisualDescription,
+			Stdin:             code.Stdin,
+		}, snippet.DocumentID)
+	}
+	return e.NewParamErr("代码示例不支持该语言")
+}
+
+func (d *debugService) StartShare(ctx context.Context, req dto.StartShareDebugRequest) error {
+	code, err := d.savedCodeService.GetSharedCode(ctx, req.ShareID)
+	if err != nil {
+		return err
+	}
+	return d.Start(ctx, dto.StartDebugRequest{
+		ID:                req.ID,
+		Code:              code.Code,
+		Language:          constants.LanguageType(code.Language),
+		Breakpoints:       req.Breakpoints,
+		VisualDescription: code.VisualDescription,
+	})
+}
+
+// completeDocumentCallback 调试的是文档自己的示例时，程序正常退出后标记用户完成了文档
+// 编译失败、超时、运行出错或者被用户关闭都不算完成
+func (d *debugService) completeDocumentCallback(ctx context.Context, documentID uint,
+	callback func(data interface{})) func(data interface{}) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if documentID == 0 || userID == 0 {
+		return callback
+	}
+	return func(data interface{}) {
+		if event, ok := data.(*debug_core.ExitedEvent); ok && event.ExitCode == 0 {
+			if err := d.progressService.CompleteDocument(ctx, userID, documentID); err != nil {
+				logger.WithCtx(ctx).Warnf("[completeDocumentCallback] CompleteDocument fail, err = %v", err)
+			}
+		}
+		callback(data)
+	}
 }
 
 // notificationCallback 处理调试对象返回的事件
@@ -181,7 +281,12 @@ func (d *debugService) notificationCallback(ctx context.Context, debugID string)
 				log.Println(r)
 			}
 		}()
+		d.recordEvent(ctx, debugID, data)
 		event := d.getDebuggerEventToDtoEvent(data)
+		// 退出码等只在服务端使用的事件不发送给用户
+		if event == nil {
+			return
+		}
 		// 发送event给用户
 		DebugSessionManage.SendEvent(ctx, debugID, event)
 
@@ -192,6 +297,23 @@ func (d *debugService) notificationCallback(ctx context.Context, debugID string)
 	}
 }
 
+// recordEvent 更新调试记录，程序暂停时异步读取栈帧和变量
+// 程序退出以后无法再读取，所以每次暂停都需要记录
+func (d *debugService) recordEvent(ctx context.Context, debugID string, data interface{}) {
+	session, ok := DebugSessionManage.GetDebugSession(ctx, debugID)
+	if !ok {
+		return
+	}
+	if !session.Recorder.RecordEvent(data) {
+		return
+	}
+	go func() {
+		if err := session.Recorder.Capture(ctx, session.Debugger); err != nil {
+			logger.WithCtx(ctx).Warnf("[recordEvent] capture debug state fail, err = %v", err)
+		}
+	}()
+}
+
 func (d *debugService) sendEventToSse(ctx context.Context, key string, event interface{}) error {
 	session, ok := DebugSessionManage.GetDebugSession(ctx, key)
 	if !ok {
@@ -218,7 +340,7 @@ func (d *debugService) getDebuggerEventToDtoEvent(data interface{}) interface{}
 		event = dto.NewTerminatedEvent()
 	}
 	if cevent, ok := data.(*debug_core.CompileEvent); ok {
-		event = dto.NewCompileEvent(cevent.Success, cevent.Message)
+		event = dto.NewCompileEvent(cevent.Success, cevent.Message, cevent.Diagnostics)
 	}
 	return event
 }
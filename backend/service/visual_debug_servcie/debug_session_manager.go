@@ -10,11 +10,17 @@ import (
 	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
 	"github.com/fansqz/fancode-backend/utils"
 	"sync"
+	"time"
 )
 
+// recorderKeepTime 调试会话销毁以后调试记录的保留时间
+const recorderKeepTime = 10 * time.Minute
+
 type debugSessionManage struct {
 	// debugID - debugSession
 	debugContextMap sync.Map
+	// debugID - 已经销毁的调试会话的调试记录
+	recorderMap sync.Map
 }
 
 // DebugSession 调试上下文对象，用于存储用户的一次调试的信息
@@ -27,6 +33,8 @@ type DebugSession struct {
 	Debugger debug_core.Debugger
 	// 可视化描述分析器
 	VisualDescriptionAnalyzer *ai_analyze_core.VisualDescriptionAnalyzer
+	// Recorder 调试记录，供AI调试助手使用
+	Recorder *DebugRecorder
 }
 
 var DebugSessionManage = &debugSessionManage{}
@@ -44,6 +52,7 @@ func (d *debugSessionManage) CreateDebugSession(ctx context.Context, aiConfig *c
 		DtoEventChan:              make(chan interface{}, 10),
 		Debugger:                  de,
 		VisualDescriptionAnalyzer: va,
+		Recorder:                  NewDebugRecorder(),
 	})
 	return nil
 }
@@ -71,6 +80,24 @@ func (d *debugSessionManage) DestroyDebugSession(ctx context.Context, id string)
 	close(debugContext.DestroyEventChan)
 	close(debugContext.DtoEventChan)
 	d.debugContextMap.Delete(id)
+
+	// 保留调试记录，用于分析程序退出的原因
+	d.recorderMap.Store(id, debugContext.Recorder)
+	time.AfterFunc(recorderKeepTime, func() {
+		d.recorderMap.Delete(id)
+	})
+}
+
+// GetDebugRecorder 获取调试记录，调试会话销毁以后的一段时间内仍然可以获取
+func (d *debugSessionManage) GetDebugRecorder(ctx context.Context, id string) (*DebugRecorder, bool) {
+	if session, ok := d.GetDebugSession(ctx, id); ok {
+		return session.Recorder, true
+	}
+	recorder, ok := d.recorderMap.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return recorder.(*DebugRecorder), true
 }
 
 // SendEvent 向用户发送调试事件
@@ -0,0 +1,661 @@
+package static_analyze_core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cTokenKind c/c++词法单元的种类
+type cTokenKind int
+
+const (
+	cIdent cTokenKind = iota
+	cNumber
+	cString
+	cPunct
+)
+
+// cToken c/c++词法单元
+type cToken struct {
+	kind cTokenKind
+	text string
+}
+
+// 需要识别的双字符运算符，>>不做合并，避免影响模板的解析，比如vector<vector<int>>
+var cDoublePuncts = map[string]bool{
+	"->": true, "::": true, "==": true, "!=": true, "<=": true, ">=": true, "&&": true, "||": true,
+	"+=": true, "-=": true, "*=": true, "/=": true, "%=": true, "++": true, "--": true, "<<": true,
+}
+
+// c/c++中的基础类型
+var cBasicTypes = map[string]bool{
+	"int": true, "char": true, "short": true, "long": true, "float": true, "double": true, "bool": true,
+	"unsigned": true, "signed": true, "string": true, "size_t": true, "int64_t": true, "int32_t": true,
+	"uint64_t": true, "uint32_t": true,
+}
+
+// 不能出现在变量定义的类型位置上的关键字，比如return a[i]
+var cNonTypeKeywords = map[string]bool{
+	"return": true, "else": true, "case": true, "do": true, "goto": true, "throw": true, "sizeof": true,
+	"new": true, "delete": true, "typedef": true, "co_return": true, "co_yield": true, "operator": true,
+}
+
+// 不会作为数组下标变量的关键字
+var cKeywords = map[string]bool{
+	"sizeof": true, "true": true, "false": true, "NULL": true, "nullptr": true, "this": true,
+	"int": true, "char": true, "long": true, "unsigned": true, "static_cast": true,
+}
+
+// c++中按照数组展示的容器，value为容器是否带模板参数
+var cppArrayContainers = map[string]bool{
+	"vector": true, "array": true, "deque": true, "string": false,
+}
+
+// c++中存放节点指针的集合容器
+var cppCollectionContainers = map[string]bool{
+	"vector": true, "list": true, "deque": true, "array": true, "set": true, "unordered_set": true,
+}
+
+// tokenizeC 将c/c++代码拆分为词法单元，跳过注释和预处理指令，并返回#define定义的宏
+func tokenizeC(code string) ([]cToken, map[string]bool) {
+	var tokens []cToken
+	macros := map[string]bool{}
+	runes := []rune(code)
+	lineStart := true
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == '\n':
+			lineStart = true
+			i++
+			continue
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case c == '#' && lineStart:
+			// 预处理指令，支持\续行
+			start := i
+			for i < len(runes) && runes[i] != '\n' {
+				if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '\n' {
+					i++
+				}
+				i++
+			}
+			fields := strings.Fields(strings.TrimPrefix(string(runes[start:i]), "#"))
+			if len(fields) >= 2 && fields[0] == "define" {
+				name := fields[1]
+				if idx := strings.Index(name, "("); idx >= 0 {
+					name = name[:idx]
+				}
+				macros[name] = true
+			}
+			continue
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+			continue
+		}
+		lineStart = false
+
+		start := i
+		switch {
+		case c == '_' || unicode.IsLetter(c):
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, cToken{kind: cIdent, text: string(runes[start:i])})
+		case unicode.IsDigit(c):
+			for i < len(runes) && (runes[i] == '.' || runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, cToken{kind: cNumber, text: string(runes[start:i])})
+		case c == '"' || c == '\'':
+			i++
+			for i < len(runes) && runes[i] != c && runes[i] != '\n' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			if i > len(runes) {
+				i = len(runes)
+			}
+			tokens = append(tokens, cToken{kind: cString, text: string(runes[start:i])})
+		default:
+			i++
+			if i < len(runes) && cDoublePuncts[string(runes[start:i+1])] {
+				i++
+			}
+			tokens = append(tokens, cToken{kind: cPunct, text: string(runes[start:i])})
+		}
+	}
+	return tokens, macros
+}
+
+// cParser 轻量的c/c++解析器，只识别可视化需要的结构体、数组定义和数组下标访问
+type cParser struct {
+	tokens []cToken
+	macros map[string]bool
+	// consts const修饰的常量，不作为下标变量
+	consts map[string]bool
+	model  *codeModel
+}
+
+// parseC 解析c/c++代码
+func parseC(code string) *codeModel {
+	tokens, macros := tokenizeC(code)
+	p := &cParser{
+		tokens: tokens,
+		macros: macros,
+		consts: map[string]bool{},
+		model:  newCodeModel(),
+	}
+	p.parse()
+	return p.model
+}
+
+func (p *cParser) text(i int) string {
+	if i < 0 || i >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[i].text
+}
+
+func (p *cParser) isIdent(i int) bool {
+	return i >= 0 && i < len(p.tokens) && p.tokens[i].kind == cIdent
+}
+
+// matching 返回与位置i的左括号匹配的右括号位置
+func (p *cParser) matching(i int) int {
+	open := p.text(i)
+	closeMap := map[string]string{"(": ")", "[": "]", "{": "}", "<": ">"}
+	closeText := closeMap[open]
+	depth := 0
+	for j := i; j < len(p.tokens); j++ {
+		switch p.text(j) {
+		case open:
+			depth++
+		case closeText:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		case ";":
+			// 模板参数中不会出现分号，用于避免把小于号当做模板
+			if open == "<" {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+func (p *cParser) parse() {
+	for i := 0; i < len(p.tokens); i++ {
+		if p.isIdent(i) {
+			p.model.refCount[p.text(i)]++
+		}
+	}
+	for i := 0; i < len(p.tokens); i++ {
+		if !p.isIdent(i) {
+			continue
+		}
+		word := p.text(i)
+		// 成员访问不做处理
+		if prev := p.text(i - 1); prev == "." || prev == "->" {
+			continue
+		}
+		switch {
+		case word == "const" || word == "constexpr":
+			p.parseConst(i)
+		case word == "struct" || word == "class":
+			p.parseStruct(i)
+		case word == "swap" && p.text(i+1) == "(":
+			p.parseSwap(i + 1)
+		}
+		if next, ok := p.parseContainerDecl(i); ok {
+			i = next - 1
+			continue
+		}
+		if p.text(i+1) == "[" {
+			i = p.parseIndex(i) - 1
+		}
+	}
+}
+
+// parseConst 记录const常量的名称，比如const int N = 100
+func (p *cParser) parseConst(i int) {
+	for j := i + 1; j < len(p.tokens) && p.text(j) != ";" && p.text(j) != "("; j++ {
+		if p.isIdent(j) && p.text(j+1) == "=" {
+			p.consts[p.text(j)] = true
+			return
+		}
+	}
+}
+
+// parseIndex 解析形如 name[...][...] 的数组定义或者数组访问，返回解析结束的位置
+func (p *cParser) parseIndex(i int) int {
+	name := p.text(i)
+	var groups [][2]int
+	j := i + 1
+	for p.text(j) == "[" {
+		end := p.matching(j)
+		if end < 0 {
+			return i + 1
+		}
+		groups = append(groups, [2]int{j + 1, end})
+		j = end + 1
+	}
+
+	// 前一个词法单元是类型，说明是数组定义，比如int a[10][10]
+	if p.isDeclaration(i) {
+		p.model.addArray(name, len(groups))
+		return j
+	}
+
+	// 数组访问
+	rows := p.indexIdents(groups[0][0], groups[0][1])
+	if len(groups) >= 2 {
+		cols := p.indexIdents(groups[1][0], groups[1][1])
+		p.model.addIndexUse(name, rows, cols, true)
+	} else {
+		p.model.addIndexUse(name, rows, nil, false)
+		// a[j+1] = a[j];
+		if p.text(j) == "=" && p.text(j+1) == name && p.text(j+2) == "[" {
+			if end := p.matching(j + 2); end > 0 && p.text(end+1) == ";" {
+				p.model.markSwapped(name)
+			}
+		}
+	}
+	// 继续解析下标中的嵌套访问，比如a[b[i]]
+	return i + 2
+}
+
+// isDeclaration 判断位置i的标识符是否处于变量定义中，比如int a[10]、int n, a[10]
+func (p *cParser) isDeclaration(i int) bool {
+	prev := p.text(i - 1)
+	if p.isIdent(i - 1) {
+		return !cNonTypeKeywords[prev]
+	}
+	if prev != "," {
+		return false
+	}
+	// 逗号分隔的多个定义，找到语句的开头，判断是否以 类型 变量名 开始
+	start := i - 1
+	for start > 0 {
+		t := p.text(start - 1)
+		if t == ";" || t == "{" || t == "}" || t == "(" {
+			break
+		}
+		start--
+	}
+	first, second := p.text(start), p.text(start+1)
+	return p.isIdent(start) && !cNonTypeKeywords[first] && (p.isIdent(start+1) || second == "*") &&
+		!(second == "*" && p.text(start+2) == "=")
+}
+
+// indexIdents 读取下标表达式中使用到的变量
+func (p *cParser) indexIdents(start, end int) []string {
+	var answer []string
+	for j := start; j < end; j++ {
+		if !p.isIdent(j) {
+			continue
+		}
+		word := p.text(j)
+		prev, next := p.text(j-1), p.text(j+1)
+		if prev == "." || prev == "->" || prev == "::" || next == "::" || next == "(" || next == "[" {
+			continue
+		}
+		if cKeywords[word] || p.macros[word] || p.consts[word] {
+			continue
+		}
+		answer = appendUnique(answer, word)
+	}
+	return answer
+}
+
+// parseSwap 解析swap(a[i], a[j])
+func (p *cParser) parseSwap(i int) {
+	end := p.matching(i)
+	if end < 0 {
+		return
+	}
+	var args []string
+	argStart := i + 1
+	depth := 0
+	for j := i + 1; j <= end; j++ {
+		switch p.text(j) {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if j == end {
+				args = append(args, p.text(argStart)+p.text(argStart+1))
+			}
+			depth--
+		case ",":
+			if depth == 0 {
+				args = append(args, p.text(argStart)+p.text(argStart+1))
+				argStart = j + 1
+			}
+		}
+	}
+	if len(args) == 2 && args[0] == args[1] && strings.HasSuffix(args[0], "[") {
+		p.model.markSwapped(strings.TrimSuffix(args[0], "["))
+	}
+}
+
+// parseContainerDecl 解析c++容器的变量定义，比如vector<vector<int>> grid、string s
+// 返回解析结束的位置
+func (p *cParser) parseContainerDecl(i int) (int, bool) {
+	word := p.text(i)
+	hasTemplate, ok := cppArrayContainers[word]
+	if !ok {
+		return 0, false
+	}
+	dim := 1
+	j := i + 1
+	if hasTemplate {
+		if p.text(j) != "<" {
+			return 0, false
+		}
+		end := p.matching(j)
+		if end < 0 {
+			return 0, false
+		}
+		for k := j + 1; k < end; k++ {
+			if cppArrayContainers[p.text(k)] && p.text(k+1) == "<" && p.text(k-1) != "." {
+				dim++
+			}
+		}
+		j = end + 1
+	}
+	for p.text(j) == "&" || p.text(j) == "&&" || p.text(j) == "const" {
+		j++
+	}
+	if !p.isIdent(j) || p.text(j+1) == "::" {
+		return 0, false
+	}
+	name := p.text(j)
+	// 函数的返回值，比如vector<int> twoSum(vector<int>& nums) {
+	if p.text(j+1) == "(" {
+		end := p.matching(j + 1)
+		if end < 0 {
+			return 0, false
+		}
+		if after := p.text(end + 1); after == "{" || after == "const" || after == "->" || after == "override" || p.braceDepth(i) == 0 {
+			return j + 1, true
+		}
+	}
+	if dim > 2 {
+		dim = 2
+	}
+	p.model.addArray(name, dim)
+	return j + 1, true
+}
+
+// braceDepth 计算位置i所在的大括号深度
+func (p *cParser) braceDepth(i int) int {
+	depth := 0
+	for j := 0; j < i && j < len(p.tokens); j++ {
+		switch p.text(j) {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+	}
+	return depth
+}
+
+// parseStruct 解析结构体定义，比如 struct Node {...}、typedef struct Node {...} Node;
+func (p *cParser) parseStruct(i int) {
+	var names []string
+	j := i + 1
+	if p.isIdent(j) {
+		names = append(names, p.text(j))
+		j++
+	}
+	// 继承列表，比如class B : public A {
+	if p.text(j) == ":" {
+		for j < len(p.tokens) && p.text(j) != "{" && p.text(j) != ";" {
+			j++
+		}
+	}
+	if p.text(j) != "{" {
+		return
+	}
+	bodyEnd := p.matching(j)
+	if bodyEnd < 0 {
+		return
+	}
+	// typedef定义的别名
+	if p.text(i-1) == "typedef" {
+		for k := bodyEnd + 1; k < len(p.tokens) && p.text(k) != ";"; k++ {
+			if p.isIdent(k) && p.text(k-1) != "*" {
+				names = append(names, p.text(k))
+			}
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	nameSet := map[string]bool{}
+	for _, name := range names {
+		nameSet[name] = true
+	}
+	fields := p.parseStructFields(j+1, bodyEnd, nameSet)
+
+	// 结构体名称选择在代码中出现次数最多的名称，和调试器中的类型名称一致
+	name := names[0]
+	for _, n := range names {
+		if p.model.refCount[n] > p.model.refCount[name] {
+			name = n
+		}
+	}
+	s := &structInfo{name: name, fields: fields}
+	end := bodyEnd
+	if p.text(i-1) == "typedef" {
+		for end < len(p.tokens) && p.text(end) != ";" {
+			end++
+		}
+	}
+	for k := i; k <= end && k < len(p.tokens); k++ {
+		if p.text(k) == name {
+			s.defRefs++
+		}
+	}
+	p.model.structs = append(p.model.structs, s)
+}
+
+// parseStructFields 解析结构体中的成员变量，跳过成员函数
+func (p *cParser) parseStructFields(start, end int, nameSet map[string]bool) []*fieldInfo {
+	var fields []*fieldInfo
+	var member []int
+	isMethod := false
+	for j := start; j < end; j++ {
+		t := p.text(j)
+		switch {
+		case (t == "public" || t == "private" || t == "protected") && p.text(j+1) == ":":
+			j++
+			continue
+		case t == "(":
+			isMethod = true
+			if e := p.matching(j); e > 0 {
+				j = e
+			}
+			continue
+		case t == "{":
+			e := p.matching(j)
+			if e < 0 {
+				return fields
+			}
+			j = e
+			if isMethod {
+				member, isMethod = nil, false
+				if p.text(j+1) == ";" {
+					j++
+				}
+			}
+			continue
+		case t == ";":
+			if !isMethod && len(member) > 0 {
+				fields = append(fields, p.parseMember(member, nameSet)...)
+			}
+			member, isMethod = nil, false
+			continue
+		}
+		member = append(member, j)
+	}
+	return fields
+}
+
+// parseMember 解析一条成员变量声明，比如 struct Node *left, *right;
+func (p *cParser) parseMember(member []int, nameSet map[string]bool) []*fieldInfo {
+	for _, j := range member {
+		if t := p.text(j); t == "static" || t == "friend" || t == "using" || t == "typedef" {
+			return nil
+		}
+	}
+	// 按照逗号拆分声明，模板参数中的逗号不做拆分
+	var declarators [][]int
+	var current []int
+	angle := 0
+	for _, j := range member {
+		switch p.text(j) {
+		case "<":
+			angle++
+		case ">":
+			angle--
+		case ",":
+			if angle == 0 {
+				declarators = append(declarators, current)
+				current = nil
+				continue
+			}
+		}
+		current = append(current, j)
+	}
+	declarators = append(declarators, current)
+
+	var typeTokens []string
+	var fields []*fieldInfo
+	for idx, decl := range declarators {
+		// 去掉初始化部分
+		for k, j := range decl {
+			if t := p.text(j); t == "=" || t == "{" {
+				decl = decl[:k]
+				break
+			}
+		}
+		nameIdx, isArray, stars, angle := -1, false, 0, 0
+		for k, j := range decl {
+			switch {
+			case p.text(j) == "<":
+				angle++
+			case p.text(j) == ">":
+				angle--
+			case p.text(j) == "[":
+				isArray = true
+			case p.text(j) == "*" && angle == 0:
+				stars++
+			case p.isIdent(j) && !isArray:
+				nameIdx = k
+			}
+		}
+		if nameIdx < 0 {
+			continue
+		}
+		if idx == 0 {
+			for _, j := range decl[:nameIdx] {
+				typeTokens = append(typeTokens, p.text(j))
+			}
+			if len(typeTokens) == 0 {
+				return nil
+			}
+		} else {
+			// 后续的声明只看自己的指针符号
+			stars = 0
+			for _, j := range decl[:nameIdx] {
+				if p.text(j) == "*" {
+					stars++
+				}
+			}
+		}
+		name := p.text(decl[nameIdx])
+		fields = append(fields, &fieldInfo{
+			name:  name,
+			kind:  cFieldKind(typeTokens, stars, isArray, nameSet),
+			basic: stars == 0 && !isArray && cBasicTypes[cBaseType(typeTokens)],
+		})
+	}
+	return fields
+}
+
+// cBaseType 读取类型中的基础类型名称，比如 struct Node 读取为 Node
+func cBaseType(typeTokens []string) string {
+	for k := len(typeTokens) - 1; k >= 0; k-- {
+		t := typeTokens[k]
+		if t == "*" || t == "&" || t == "const" || t == ">" {
+			continue
+		}
+		if t == "<" {
+			break
+		}
+		return t
+	}
+	return ""
+}
+
+// cFieldKind 判断成员变量的种类
+func cFieldKind(typeTokens []string, stars int, isArray bool, nameSet map[string]bool) fieldKind {
+	// 模板类型，比如vector<Node*>
+	if k := indexOf(typeTokens, "<"); k > 0 {
+		container := typeTokens[k-1]
+		hasSelf, hasStar := false, false
+		for _, t := range typeTokens[k:] {
+			if nameSet[t] {
+				hasSelf = true
+			}
+			if t == "*" {
+				hasStar = true
+			}
+		}
+		if cppCollectionContainers[container] && hasSelf && hasStar && stars == 0 {
+			return fieldSelfCollection
+		}
+		return fieldData
+	}
+	if !nameSet[cBaseType(typeTokens)] {
+		if stars > 0 {
+			return fieldOther
+		}
+		return fieldData
+	}
+	// 自身类型的成员只能是指针，指针数组或者二级指针当做节点集合
+	switch {
+	case stars == 1 && !isArray:
+		return fieldSelfPointer
+	case stars == 1 && isArray, stars == 2:
+		return fieldSelfCollection
+	}
+	return fieldOther
+}
+
+func indexOf(list []string, target string) int {
+	for i, item := range list {
+		if item == target {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,243 @@
+package static_analyze_core
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// go中的基础类型
+var goBasicTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "string": true, "bool": true, "byte": true, "rune": true,
+}
+
+// go中预定义的标识符，不会作为数组的下标变量
+var goPredeclared = map[string]bool{
+	"len": true, "cap": true, "true": true, "false": true, "nil": true, "iota": true,
+}
+
+// parseGo 使用go/ast解析go代码，代码存在语法错误时尽可能使用解析出来的部分
+func parseGo(code string) *codeModel {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "main.go", code, 0)
+	if file == nil {
+		return nil
+	}
+	model := newCodeModel()
+
+	// 二维数组的内层下标访问，比如a[i][j]中的a[i]
+	innerIndexes := map[*ast.IndexExpr]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if index, ok := n.(*ast.IndexExpr); ok {
+			if inner, ok := index.X.(*ast.IndexExpr); ok {
+				innerIndexes[inner] = true
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.Ident:
+			model.refCount[node.Name]++
+		case *ast.TypeSpec:
+			if structType, ok := node.Type.(*ast.StructType); ok {
+				model.structs = append(model.structs, parseGoStruct(node, structType))
+			}
+		case *ast.ValueSpec:
+			for i, name := range node.Names {
+				dim := goTypeDim(node.Type)
+				if node.Type == nil && i < len(node.Values) {
+					dim = goExprDim(node.Values[i])
+				}
+				model.addArray(name.Name, dim)
+			}
+		case *ast.AssignStmt:
+			parseGoAssign(model, node)
+		case *ast.FuncType:
+			if node.Params != nil {
+				for _, field := range node.Params.List {
+					for _, name := range field.Names {
+						model.addArray(name.Name, goTypeDim(field.Type))
+					}
+				}
+			}
+		case *ast.IndexExpr:
+			if innerIndexes[node] {
+				return true
+			}
+			if name, ok := node.X.(*ast.Ident); ok {
+				model.addIndexUse(name.Name, goIndexIdents(node.Index), nil, false)
+			} else if inner, ok := node.X.(*ast.IndexExpr); ok {
+				if name, ok := inner.X.(*ast.Ident); ok {
+					model.addIndexUse(name.Name, goIndexIdents(inner.Index), goIndexIdents(node.Index), true)
+				}
+			}
+		}
+		return true
+	})
+	return model
+}
+
+// parseGoStruct 解析结构体的字段
+func parseGoStruct(spec *ast.TypeSpec, structType *ast.StructType) *structInfo {
+	s := &structInfo{name: spec.Name.Name}
+	ast.Inspect(spec, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == s.name {
+			s.defRefs++
+		}
+		return true
+	})
+	if structType.Fields == nil {
+		return s
+	}
+	for _, field := range structType.Fields.List {
+		kind, basic := goFieldKind(s.name, field.Type)
+		for _, name := range field.Names {
+			s.fields = append(s.fields, &fieldInfo{name: name.Name, kind: kind, basic: basic})
+		}
+	}
+	return s
+}
+
+// goFieldKind 判断字段的种类
+func goFieldKind(structName string, expr ast.Expr) (fieldKind, bool) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		if goTypeName(t.X) == structName {
+			return fieldSelfPointer, false
+		}
+		return fieldOther, false
+	case *ast.ArrayType:
+		if star, ok := t.Elt.(*ast.StarExpr); ok && goTypeName(star.X) == structName {
+			return fieldSelfCollection, false
+		}
+		return fieldData, false
+	case *ast.Ident:
+		return fieldData, goBasicTypes[t.Name]
+	default:
+		return fieldData, false
+	}
+}
+
+// goTypeName 读取类型名称，泛型类型比如Node[T]读取为Node
+func goTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return goTypeName(t.X)
+	case *ast.IndexListExpr:
+		return goTypeName(t.X)
+	}
+	return ""
+}
+
+// parseGoAssign 解析短变量声明中的数组定义，以及数组元素之间的交换
+func parseGoAssign(model *codeModel, stmt *ast.AssignStmt) {
+	if stmt.Tok == token.DEFINE && len(stmt.Lhs) == len(stmt.Rhs) {
+		for i, lhs := range stmt.Lhs {
+			if name, ok := lhs.(*ast.Ident); ok {
+				model.addArray(name.Name, goExprDim(stmt.Rhs[i]))
+			}
+		}
+	}
+	if stmt.Tok != token.ASSIGN || len(stmt.Lhs) != len(stmt.Rhs) {
+		return
+	}
+	// a[i], a[j] = a[j], a[i] 或者 a[j+1] = a[j]
+	for i, lhs := range stmt.Lhs {
+		lName := goIndexedName(lhs)
+		if lName != "" && lName == goIndexedName(stmt.Rhs[i]) {
+			model.markSwapped(lName)
+		}
+	}
+	if len(stmt.Lhs) == 2 {
+		lName := goIndexedName(stmt.Lhs[0])
+		if lName != "" && lName == goIndexedName(stmt.Lhs[1]) &&
+			lName == goIndexedName(stmt.Rhs[0]) && lName == goIndexedName(stmt.Rhs[1]) {
+			model.markSwapped(lName)
+		}
+	}
+}
+
+// goIndexedName 如果表达式是一维下标访问，返回数组名称
+func goIndexedName(expr ast.Expr) string {
+	index, ok := expr.(*ast.IndexExpr)
+	if !ok {
+		return ""
+	}
+	if name, ok := index.X.(*ast.Ident); ok {
+		return name.Name
+	}
+	return ""
+}
+
+// goTypeDim 计算类型的数组维度，字符串当做一维数组
+func goTypeDim(expr ast.Expr) int {
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		dim := 1
+		for elt, ok := t.Elt.(*ast.ArrayType); ok; elt, ok = elt.Elt.(*ast.ArrayType) {
+			dim++
+		}
+		return dim
+	case *ast.Ident:
+		if t.Name == "string" {
+			return 1
+		}
+	}
+	return 0
+}
+
+// goExprDim 根据初始化表达式推断数组维度
+func goExprDim(expr ast.Expr) int {
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return goTypeDim(e.Type)
+	case *ast.CallExpr:
+		if fun, ok := e.Fun.(*ast.Ident); ok && fun.Name == "make" && len(e.Args) > 0 {
+			return goTypeDim(e.Args[0])
+		}
+		// []byte(s)这类类型转换
+		return goTypeDim(e.Fun)
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			return 1
+		}
+	}
+	return 0
+}
+
+// goIndexIdents 读取下标表达式中使用到的变量，比如a[i+j]读取i和j
+func goIndexIdents(expr ast.Expr) []string {
+	var answer []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			// 函数名不是下标变量，只读取参数
+			for _, arg := range node.Args {
+				answer = appendUnique(answer, goIndexIdents(arg)...)
+			}
+			return false
+		case *ast.SelectorExpr:
+			return false
+		case *ast.IndexExpr:
+			// 嵌套的数组名不是下标变量，比如a[b[i]]中的b
+			answer = appendUnique(answer, goIndexIdents(node.Index)...)
+			return false
+		case *ast.Ident:
+			if goPredeclared[node.Name] || node.Name == "_" {
+				return false
+			}
+			if node.Obj != nil && node.Obj.Kind != ast.Var {
+				return false
+			}
+			answer = appendUnique(answer, node.Name)
+		}
+		return true
+	})
+	return answer
+}
@@ -0,0 +1,355 @@
+package static_analyze_core
+
+import (
+	"strings"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
+)
+
+// fieldKind 结构体字段的种类
+type fieldKind int
+
+const (
+	// fieldOther 无法识别的字段
+	fieldOther fieldKind = iota
+	// fieldData 数据域
+	fieldData
+	// fieldSelfPointer 指向自身类型的指针，比如 next *Node
+	fieldSelfPointer
+	// fieldSelfCollection 存放自身类型指针的集合，比如 children []*Node、vector<Node*> adj
+	fieldSelfCollection
+)
+
+// 这些名称的指针通常指向父节点，识别树结构时需要排除
+var parentFieldNames = map[string]bool{
+	"parent": true, "father": true, "fa": true, "par": true, "up": true,
+}
+
+// 数据域常用的名称，按优先级排列
+var dataFieldNames = []string{"val", "value", "data", "key", "num", "v"}
+
+// codeModel 从用户代码中提取出来的结构，与语言无关
+type codeModel struct {
+	structs []*structInfo
+	// arrays 数组以及数组的下标使用情况，key为数组名称
+	arrays     map[string]*arrayInfo
+	arrayOrder []string
+	// refCount 每个标识符在代码中出现的次数
+	refCount map[string]int
+}
+
+// structInfo 结构体定义
+type structInfo struct {
+	// name 结构体名称，也是调试器中变量类型的名称
+	name   string
+	fields []*fieldInfo
+	// defRefs 结构体名称在结构体定义中出现的次数
+	defRefs int
+}
+
+// fieldInfo 结构体字段
+type fieldInfo struct {
+	name  string
+	kind  fieldKind
+	basic bool
+}
+
+// arrayInfo 数组定义以及使用情况
+type arrayInfo struct {
+	name string
+	// declared 是否识别到了数组的定义，只有定义过的变量才会被当做数组
+	declared bool
+	dim      int
+	// uses 数组被下标访问的次数
+	uses      int
+	rowPoints []string
+	colPoints []string
+	// swapped 数组元素之间存在交换或移动，通常是排序算法
+	swapped bool
+}
+
+func newCodeModel() *codeModel {
+	return &codeModel{
+		arrays:   map[string]*arrayInfo{},
+		refCount: map[string]int{},
+	}
+}
+
+func (m *codeModel) getArray(name string) *arrayInfo {
+	if array, ok := m.arrays[name]; ok {
+		return array
+	}
+	array := &arrayInfo{name: name}
+	m.arrays[name] = array
+	m.arrayOrder = append(m.arrayOrder, name)
+	return array
+}
+
+// addArray 记录数组定义，dim为数组维度
+func (m *codeModel) addArray(name string, dim int) {
+	if name == "" || name == "_" || dim <= 0 {
+		return
+	}
+	array := m.getArray(name)
+	array.declared = true
+	if dim > array.dim {
+		array.dim = dim
+	}
+}
+
+// addIndexUse 记录一次数组下标访问，cols为空表示一维访问
+func (m *codeModel) addIndexUse(name string, rows []string, cols []string, is2D bool) {
+	array := m.getArray(name)
+	array.uses++
+	array.rowPoints = appendUnique(array.rowPoints, rows...)
+	if is2D {
+		array.colPoints = appendUnique(array.colPoints, cols...)
+	}
+}
+
+// markSwapped 标记数组中存在元素交换
+func (m *codeModel) markSwapped(name string) {
+	m.getArray(name).swapped = true
+}
+
+// Analyze 静态分析用户代码，推断出可视化描述
+// 结果只依赖代码本身，相同的代码总能得到相同的结果
+func Analyze(code string, language constants.LanguageType) *dto.VisualDescription {
+	var model *codeModel
+	switch language {
+	case constants.LanguageGo:
+		model = parseGo(code)
+	case constants.LanguageC, constants.LanguageCPP:
+		model = parseC(code)
+	}
+	if model == nil {
+		return defaultVisualDescription()
+	}
+	if description := model.inferStructDescription(); description != nil {
+		return description
+	}
+	if description := model.inferArrayDescription(); description != nil {
+		return description
+	}
+	return defaultVisualDescription()
+}
+
+// defaultVisualDescription 无法识别时的默认描述
+func defaultVisualDescription() *dto.VisualDescription {
+	return &dto.VisualDescription{
+		VisualType: constants.ArrayType,
+		Description: dto.ArrayDescription{
+			ArrayName:   "array",
+			PointNames:  []string{},
+			DisplayType: "array",
+		},
+	}
+}
+
+// inferStructDescription 根据自引用的结构体推断链表、二叉树或者图
+func (m *codeModel) inferStructDescription() *dto.VisualDescription {
+	var target *structInfo
+	maxRefs := 0
+	for _, s := range m.structs {
+		if !s.hasSelfReference() {
+			continue
+		}
+		// 只在定义中出现的结构体没有被使用，不做展示
+		refs := m.refCount[s.name] - s.defRefs
+		if refs > maxRefs {
+			target, maxRefs = s, refs
+		}
+	}
+	if target == nil {
+		return nil
+	}
+
+	var pointers, collections, parents []string
+	for _, f := range target.fields {
+		switch f.kind {
+		case fieldSelfPointer:
+			if parentFieldNames[strings.ToLower(f.name)] {
+				parents = append(parents, f.name)
+			} else {
+				pointers = append(pointers, f.name)
+			}
+		case fieldSelfCollection:
+			collections = append(collections, f.name)
+		}
+	}
+	data := target.dataField()
+
+	switch {
+	case len(collections) == 0 && len(pointers) == 1:
+		return &dto.VisualDescription{
+			VisualType: constants.LinkListType,
+			Description: dto.LinkListDescription{
+				LinkNode: target.name,
+				Data:     data,
+				Next:     pointers[0],
+			},
+		}
+	case len(collections) == 0 && len(pointers) == 2:
+		if prev, next, ok := matchPrevNext(pointers[0], pointers[1]); ok {
+			return &dto.VisualDescription{
+				VisualType: constants.LinkListType,
+				Description: dto.LinkListDescription{
+					LinkNode: target.name,
+					Data:     data,
+					Next:     next,
+					Prev:     prev,
+				},
+			}
+		}
+		left, right := matchLeftRight(pointers[0], pointers[1])
+		return &dto.VisualDescription{
+			VisualType: constants.BinaryTreeType,
+			Description: dto.BinaryTreeDescription{
+				TreeNode: target.name,
+				Data:     data,
+				Left:     left,
+				Right:    right,
+			},
+		}
+	default:
+		nexts := append(append([]string{}, pointers...), collections...)
+		if len(nexts) == 0 {
+			// 只有父指针的情况，比如并查集
+			nexts = parents
+		}
+		return &dto.VisualDescription{
+			VisualType: constants.GraphType,
+			Description: dto.GraphDescription{
+				GraphNode: target.name,
+				Data:      data,
+				Nexts:     nexts,
+			},
+		}
+	}
+}
+
+// inferArrayDescription 根据数组的定义和下标访问推断一维或二维数组
+func (m *codeModel) inferArrayDescription() *dto.VisualDescription {
+	var target *arrayInfo
+	for _, name := range m.arrayOrder {
+		array := m.arrays[name]
+		if !array.declared {
+			continue
+		}
+		if target == nil || array.uses > target.uses {
+			target = array
+		}
+	}
+	if target == nil {
+		return nil
+	}
+
+	if target.dim >= 2 {
+		return &dto.VisualDescription{
+			VisualType: constants.Array2DType,
+			Description: dto.Array2DDescription{
+				ArrayName:     target.name,
+				RowPointNames: nonNil(target.rowPoints),
+				ColPointNames: nonNil(target.colPoints),
+			},
+		}
+	}
+	displayType := "array"
+	if target.swapped {
+		displayType = "array-bar"
+	}
+	return &dto.VisualDescription{
+		VisualType: constants.ArrayType,
+		Description: dto.ArrayDescription{
+			ArrayName:   target.name,
+			PointNames:  nonNil(target.rowPoints),
+			DisplayType: displayType,
+		},
+	}
+}
+
+// hasSelfReference 结构体是否包含指向自身的指针
+func (s *structInfo) hasSelfReference() bool {
+	for _, f := range s.fields {
+		if f.kind == fieldSelfPointer || f.kind == fieldSelfCollection {
+			return true
+		}
+	}
+	return false
+}
+
+// dataField 选择结构体的数据域，优先使用常见的名称，其次使用第一个基础类型字段
+func (s *structInfo) dataField() string {
+	for _, name := range dataFieldNames {
+		for _, f := range s.fields {
+			if f.kind == fieldData && strings.ToLower(f.name) == name {
+				return f.name
+			}
+		}
+	}
+	for _, f := range s.fields {
+		if f.kind == fieldData && f.basic {
+			return f.name
+		}
+	}
+	for _, f := range s.fields {
+		if f.kind == fieldData {
+			return f.name
+		}
+	}
+	return ""
+}
+
+// matchPrevNext 判断两个指针是否是双向链表的prev和next
+func matchPrevNext(a, b string) (string, string, bool) {
+	isPrev := func(name string) bool {
+		name = strings.ToLower(name)
+		return strings.Contains(name, "prev") || strings.HasPrefix(name, "pre") || strings.Contains(name, "last")
+	}
+	isNext := func(name string) bool {
+		return strings.Contains(strings.ToLower(name), "next")
+	}
+	if isPrev(a) && isNext(b) {
+		return a, b, true
+	}
+	if isNext(a) && isPrev(b) {
+		return b, a, true
+	}
+	return "", "", false
+}
+
+// matchLeftRight 识别二叉树的左右子树，无法识别时按照定义顺序
+func matchLeftRight(a, b string) (string, string) {
+	isRight := func(name string) bool {
+		name = strings.ToLower(name)
+		return strings.Contains(name, "right") || name == "r" || name == "rc" || name == "rchild"
+	}
+	if isRight(a) && !isRight(b) {
+		return b, a
+	}
+	return a, b
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		exists := false
+		for _, item := range list {
+			if item == v {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,212 @@
+package static_analyze_core
+
+import (
+	"testing"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze_GoLinkList(t *testing.T) {
+	code := `package main
+
+type ListNode struct {
+	Val  int
+	Next *ListNode
+}
+
+func main() {
+	head := &ListNode{Val: 1}
+	head.Next = &ListNode{Val: 2}
+}
+`
+	answer := Analyze(code, constants.LanguageGo)
+	assert.Equal(t, constants.LinkListType, answer.VisualType)
+	assert.Equal(t, dto.LinkListDescription{LinkNode: "ListNode", Data: "Val", Next: "Next"}, answer.Description)
+}
+
+func TestAnalyze_GoGraph(t *testing.T) {
+	code := `package main
+
+type Node struct {
+	Name      string
+	Neighbors []*Node
+}
+
+func main() {
+	a := &Node{Name: "a"}
+	b := &Node{Name: "b"}
+	a.Neighbors = append(a.Neighbors, b)
+}
+`
+	answer := Analyze(code, constants.LanguageGo)
+	assert.Equal(t, constants.GraphType, answer.VisualType)
+	assert.Equal(t, dto.GraphDescription{GraphNode: "Node", Data: "Name", Nexts: []string{"Neighbors"}}, answer.Description)
+}
+
+func TestAnalyze_GoBubbleSort(t *testing.T) {
+	code := `package main
+
+const n = 5
+
+func main() {
+	arr := []int{5, 4, 3, 2, 1}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n-i-1; j++ {
+			if arr[j] > arr[j+1] {
+				arr[j], arr[j+1] = arr[j+1], arr[j]
+			}
+		}
+	}
+}
+`
+	answer := Analyze(code, constants.LanguageGo)
+	assert.Equal(t, constants.ArrayType, answer.VisualType)
+	assert.Equal(t, dto.ArrayDescription{ArrayName: "arr", PointNames: []string{"j"}, DisplayType: "array-bar"}, answer.Description)
+}
+
+func TestAnalyze_Go2DArray(t *testing.T) {
+	code := `package main
+
+func main() {
+	grid := make([][]int, 3)
+	for i := range grid {
+		grid[i] = make([]int, 3)
+		for j := 0; j < 3; j++ {
+			grid[i][j] = i * j
+		}
+	}
+}
+`
+	answer := Analyze(code, constants.LanguageGo)
+	assert.Equal(t, constants.Array2DType, answer.VisualType)
+	assert.Equal(t, dto.Array2DDescription{ArrayName: "grid", RowPointNames: []string{"i"}, ColPointNames: []string{"j"}}, answer.Description)
+}
+
+func TestAnalyze_CBinaryTree(t *testing.T) {
+	code := `
+#include <stdio.h>
+#include <stdlib.h>
+
+typedef struct TreeNode {
+	int val;
+	struct TreeNode *left, *right;
+} TreeNode;
+
+TreeNode* newNode(int val) {
+	TreeNode *node = (TreeNode*)malloc(sizeof(TreeNode));
+	node->val = val;
+	node->left = node->right = NULL;
+	return node;
+}
+
+int main() {
+	TreeNode *root = newNode(1);
+	root->left = newNode(2);
+	return 0;
+}
+`
+	answer := Analyze(code, constants.LanguageC)
+	assert.Equal(t, constants.BinaryTreeType, answer.VisualType)
+	assert.Equal(t, dto.BinaryTreeDescription{TreeNode: "TreeNode", Data: "val", Left: "left", Right: "right"}, answer.Description)
+}
+
+func TestAnalyze_CDoublyLinkList(t *testing.T) {
+	code := `
+struct Node {
+	int data;
+	struct Node *next;
+	struct Node *prev;
+};
+
+int main() {
+	struct Node a, b;
+	a.next = &b;
+	b.prev = &a;
+	return 0;
+}
+`
+	answer := Analyze(code, constants.LanguageC)
+	assert.Equal(t, constants.LinkListType, answer.VisualType)
+	assert.Equal(t, dto.LinkListDescription{LinkNode: "Node", Data: "data", Next: "next", Prev: "prev"}, answer.Description)
+}
+
+func TestAnalyze_CInsertionSort(t *testing.T) {
+	code := `
+#include <stdio.h>
+#define N 6
+
+int main() {
+	int n = N, a[N] = {5, 2, 4, 6, 1, 3};
+	for (int i = 1; i < n; i++) {
+		int key = a[i];
+		int j = i - 1;
+		while (j >= 0 && a[j] > key) {
+			a[j + 1] = a[j];
+			j--;
+		}
+		a[j + 1] = key;
+	}
+	printf("%d", a[0]);
+	return 0;
+}
+`
+	answer := Analyze(code, constants.LanguageC)
+	assert.Equal(t, constants.ArrayType, answer.VisualType)
+	assert.Equal(t, dto.ArrayDescription{ArrayName: "a", PointNames: []string{"i", "j"}, DisplayType: "array-bar"}, answer.Description)
+}
+
+func TestAnalyze_CppClassWithVector(t *testing.T) {
+	code := `
+#include <vector>
+using namespace std;
+
+class Solution {
+public:
+	int minPathSum(vector<vector<int>>& grid) {
+		int m = grid.size(), n = grid[0].size();
+		vector<vector<int>> dp(m, vector<int>(n, 0));
+		for (int i = 0; i < m; i++) {
+			for (int j = 0; j < n; j++) {
+				dp[i][j] = grid[i][j] + min(dp[i - 1][j], dp[i][j - 1]);
+			}
+		}
+		return dp[m - 1][n - 1];
+	}
+};
+`
+	answer := Analyze(code, constants.LanguageCPP)
+	assert.Equal(t, constants.Array2DType, answer.VisualType)
+	assert.Equal(t, dto.Array2DDescription{ArrayName: "dp", RowPointNames: []string{"i", "m"}, ColPointNames: []string{"j", "n"}}, answer.Description)
+}
+
+func TestAnalyze_CppGraph(t *testing.T) {
+	code := `
+#include <vector>
+
+struct GraphNode {
+	int val;
+	std::vector<GraphNode*> neighbors;
+	GraphNode(int v) : val(v) {}
+};
+
+int main() {
+	GraphNode *a = new GraphNode(1);
+	GraphNode *b = new GraphNode(2);
+	a->neighbors.push_back(b);
+	return 0;
+}
+`
+	answer := Analyze(code, constants.LanguageCPP)
+	assert.Equal(t, constants.GraphType, answer.VisualType)
+	assert.Equal(t, dto.GraphDescription{GraphNode: "GraphNode", Data: "val", Nexts: []string{"neighbors"}}, answer.Description)
+}
+
+func TestAnalyze_Default(t *testing.T) {
+	answer := Analyze("int main() { return 0; }", constants.LanguageC)
+	assert.Equal(t, defaultVisualDescription(), answer)
+
+	answer = Analyze("class Main {}", constants.LanguageJava)
+	assert.Equal(t, defaultVisualDescription(), answer)
+}
@@ -2,16 +2,23 @@ package visual_debug_servcie
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	"github.com/fansqz/fancode-backend/common"
 	e "github.com/fansqz/fancode-backend/common/error"
 	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/dao"
 	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
+	"github.com/fansqz/fancode-backend/utils"
 )
 
 type VisualService interface {
 	// GetVisualDescription 获取用户代码的分析结果
 	GetVisualDescription(ctx context.Context, debugID string) (*dto.VisualDescription, error)
+	// UpdateVisualDescription 用户指定调试会话的可视化描述，可以同时保存到用户保存的代码中
+	UpdateVisualDescription(ctx context.Context, req *dto.UpdateVisualDescriptionRequest) (*debug_core.VisualCheckResult, error)
 	// StructVisual 结构体导向可视化数据结构
 	StructVisual(ctx context.Context, request *dto.StructVisualRequest) (*debug_core.StructVisualData, error)
 	// ArrayVisual 数组可视化请求
@@ -21,10 +28,13 @@ type VisualService interface {
 }
 
 type visualService struct {
+	savedCodeDao dao.UserSavedCodeDao
 }
 
-func NewVisualService() VisualService {
-	return &visualService{}
+func NewVisualService(savedCodeDao dao.UserSavedCodeDao) VisualService {
+	return &visualService{
+		savedCodeDao: savedCodeDao,
+	}
 }
 
 // GetVisualDescription 获取用户代码的分析结果
@@ -40,6 +50,68 @@ func (v *visualService) GetVisualDescription(ctx context.Context, debugID string
 	return visualDescription, nil
 }
 
+// UpdateVisualDescription 用户指定调试会话的可视化描述
+// 程序暂停时会根据调试器中的变量校验描述，程序未暂停时无法读取变量，跳过校验
+func (v *visualService) UpdateVisualDescription(ctx context.Context, req *dto.UpdateVisualDescriptionRequest) (*debug_core.VisualCheckResult, error) {
+	if req.VisualDescription == nil {
+		return nil, e.ErrBadRequest
+	}
+	if err := req.VisualDescription.Validate(); err != nil {
+		return nil, e.NewError(e.CodeVisualDescriptionInvalid, err.Error(), e.ErrTypeBadReq)
+	}
+	debugSession, ok := DebugSessionManage.GetDebugSession(ctx, req.DebugID)
+	if !ok {
+		return nil, e.ErrDebuggerIsClosed
+	}
+
+	var checkResult *debug_core.VisualCheckResult
+	if !req.Force {
+		query := req.VisualDescription.NewVisualCheckQuery()
+		result, err := debugSession.Debugger.VisualCheck(ctx, query)
+		if err != nil {
+			logger.WithCtx(ctx).Warnf("[UpdateVisualDescription] visual check skipped, err = %v", err)
+		} else {
+			checkResult = result
+			if message := visualCheckMessage(query, result); message != "" {
+				return nil, e.NewError(e.CodeVisualDescriptionMismatch, message, e.ErrTypeBus)
+			}
+		}
+	}
+	debugSession.VisualDescriptionAnalyzer.SetVisualDescription(req.VisualDescription)
+
+	// 保存到用户保存的代码中
+	if req.SavedCodeID != 0 {
+		userID := utils.GetUserIDWithCtx(ctx)
+		exists, err := v.savedCodeDao.CheckUserSavedCodeExists(common.Mysql, req.SavedCodeID, userID)
+		if err != nil {
+			logger.WithCtx(ctx).Errorf("[UpdateVisualDescription] CheckUserSavedCodeExists fail, err = %v", err)
+			return nil, e.ErrMysql
+		}
+		if !exists {
+			return nil, e.NewRecordNotFoundErr("saved code not found")
+		}
+		if err = v.savedCodeDao.UpdateUserSavedCodeVisualDescription(common.Mysql, req.SavedCodeID, userID,
+			dto.FormatVisualDescription(req.VisualDescription)); err != nil {
+			logger.WithCtx(ctx).Errorf("[UpdateVisualDescription] UpdateUserSavedCodeVisualDescription fail, err = %v", err)
+			return nil, e.ErrMysql
+		}
+	}
+	return checkResult, nil
+}
+
+// visualCheckMessage 根据校验结果生成错误信息，校验通过时返回空字符串
+// 结构体变量可能还没有创建，找不到结构体时不当做错误
+func visualCheckMessage(query *debug_core.VisualCheckQuery, result *debug_core.VisualCheckResult) string {
+	var messages []string
+	if len(result.MissingVariables) != 0 {
+		messages = append(messages, fmt.Sprintf("变量不存在: %s", strings.Join(result.MissingVariables, ", ")))
+	}
+	if result.StructFound && len(result.MissingFields) != 0 {
+		messages = append(messages, fmt.Sprintf("结构体%s中不存在字段: %s", query.Struct, strings.Join(result.MissingFields, ", ")))
+	}
+	return strings.Join(messages, "; ")
+}
+
 // StructVisual 结构体导向可视化数据结构
 func (v *visualService) StructVisual(ctx context.Context, request *dto.StructVisualRequest) (*debug_core.StructVisualData, error) {
 	debugContext, _ := DebugSessionManage.GetDebugSession(ctx, request.DebugID)
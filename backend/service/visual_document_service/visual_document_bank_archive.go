@@ -0,0 +1,393 @@
+package visual_document_service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
+)
+
+const (
+	// bankArchiveVersion 导出文件的格式版本
+	bankArchiveVersion = 1
+	// bankManifestFile 导出文件中描述知识库结构的文件
+	bankManifestFile = "manifest.json"
+	// bankDocumentDir 导出文件中存放文档的目录
+	bankDocumentDir = "documents"
+	// bankContentFile 每篇文档的markdown内容
+	bankContentFile = "index.md"
+	// bankSnippetDir 文档目录中存放代码示例的目录，每个示例一个子目录
+	bankSnippetDir = "snippets"
+	// bankTitleLimit 文档目录名中标题的最大长度
+	bankTitleLimit = 50
+	// bankArchiveFileLimit 上传的导入文件大小上限（以字节为单位）
+	bankArchiveFileLimit = 32 << 20
+	// bankArchiveSizeLimit 导入文件解压后的大小上限（以字节为单位）
+	bankArchiveSizeLimit = 128 << 20
+	// bankArchiveEntryLimit 导入文件中的文件和目录数量上限
+	bankArchiveEntryLimit = 10000
+)
+
+// checkBankArchive 解压前检查zip文件中的条目数量和解压后的大小，防止解压炸弹
+// 解压时读取的内容超过声明的大小会返回错误，所以只需要检查声明的大小
+func checkBankArchive(archiveFile string) error {
+	r, err := zip.OpenReader(archiveFile)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if len(r.File) > bankArchiveEntryLimit {
+		return fmt.Errorf("too many entries in archive: %d", len(r.File))
+	}
+	var size uint64
+	for _, f := range r.File {
+		size += f.UncompressedSize64
+		if size > bankArchiveSizeLimit {
+			return errors.New("archive is too large after extraction")
+		}
+	}
+	return nil
+}
+
+// bankCodeFiles 各语言代码在导出文件中的文件名
+var bankCodeFiles = map[constants.LanguageType]string{
+	constants.LanguageC:    "main.c",
+	constants.LanguageCPP:  "main.cpp",
+	constants.LanguageGo:   "main.go",
+	constants.LanguageJava: "Main.java",
+}
+
+// bankManifest 导出文件的清单，记录知识库信息和文档的目录结构
+type bankManifest struct {
+	Version   int                     `json:"version"`
+	Bank      bankManifestBank        `json:"bank"`
+	Documents []*bankManifestDocument `json:"documents"`
+}
+
+type bankManifestBank struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enable      bool   `json:"enable"`
+}
+
+// bankManifestDocument 一篇文档，父文档总是排在子文档之前
+type bankManifestDocument struct {
+	// Path 文档所在目录，同时作为文档在导出文件中的标识
+	Path string `json:"path"`
+	// Parent 父文档的目录，根文档为空
+	Parent  string              `json:"parent"`
+	Order   uint                `json:"order"`
+	Title   string              `json:"title"`
+	Enable  bool                `json:"enable"`
+	Content string              `json:"content"`
+	Codes   []*bankManifestCode `json:"codes"`
+	// Snippets 文档中的代码示例，没有示例时省略，兼容之前导出的文件
+	Snippets []*bankManifestSnippet `json:"snippets,omitempty"`
+}
+
+type bankManifestCode struct {
+	Language          string                 `json:"language"`
+	File              string                 `json:"file"`
+	Breakpoints       []int                  `json:"breakpoints"`
+	VisualDescription *dto.VisualDescription `json:"visualDescription,omitempty"`
+	// Stdin 代码示例预设的标准输入
+	Stdin string `json:"stdin,omitempty"`
+}
+
+type bankManifestSnippet struct {
+	Key   string              `json:"key"`
+	Title string              `json:"title"`
+	Codes []*bankManifestCode `json:"codes"`
+}
+
+// archiveBank 导入导出的知识库
+type archiveBank struct {
+	Name        string
+	Description string
+	Enable      bool
+	Documents   []*archiveDocument
+}
+
+// archiveDocument 导入导出的文档，Children按Order排序
+type archiveDocument struct {
+	Title    string
+	Order    uint
+	Enable   bool
+	Content  string
+	CodeList []*dto.VisualDocumentCodeDto
+	Snippets []*dto.VisualDocumentSnippetDto
+	Children []*archiveDocument
+}
+
+// writeBankArchive 将知识库打包为zip文件
+func writeBankArchive(bank *archiveBank) ([]byte, error) {
+	manifest := &bankManifest{
+		Version: bankArchiveVersion,
+		Bank: bankManifestBank{
+			Name:        bank.Name,
+			Description: bank.Description,
+			Enable:      bank.Enable,
+		},
+		Documents: []*bankManifestDocument{},
+	}
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	var writeDocuments func(parent string, documents []*archiveDocument) error
+	writeDocuments = func(parent string, documents []*archiveDocument) error {
+		for i, document := range documents {
+			dir := path.Join(parent, fmt.Sprintf("%02d-%s", i+1, documentDirName(document.Title)))
+			if parent == "" {
+				dir = path.Join(bankDocumentDir, dir)
+			}
+			item := &bankManifestDocument{
+				Path:    dir,
+				Order:   document.Order,
+				Title:   document.Title,
+				Enable:  document.Enable,
+				Content: path.Join(dir, bankContentFile),
+				Codes:   []*bankManifestCode{},
+			}
+			if parent != "" {
+				item.Parent = parent
+			}
+			if err := writeZipFile(w, item.Content, []byte(document.Content)); err != nil {
+				return err
+			}
+			for _, code := range document.CodeList {
+				c, err := writeArchiveCode(w, dir, code)
+				if err != nil {
+					return err
+				}
+				item.Codes = append(item.Codes, c)
+			}
+			for _, snippet := range document.Snippets {
+				s := &bankManifestSnippet{
+					Key:   snippet.Key,
+					Title: snippet.Title,
+					Codes: make([]*bankManifestCode, 0, len(snippet.CodeList)),
+				}
+				for _, code := range snippet.CodeList {
+					c, err := writeArchiveCode(w, path.Join(dir, bankSnippetDir, snippet.Key), &code.VisualDocumentCodeDto)
+					if err != nil {
+						return err
+					}
+					c.Stdin = code.Stdin
+					s.Codes = append(s.Codes, c)
+				}
+				item.Snippets = append(item.Snippets, s)
+			}
+			manifest.Documents = append(manifest.Documents, item)
+			if err := writeDocuments(dir, document.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := writeDocuments("", bank.Documents); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = writeZipFile(w, bankManifestFile, data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeArchiveCode 将代码写入dir目录下该语言对应的文件
+func writeArchiveCode(w *zip.Writer, dir string, code *dto.VisualDocumentCodeDto) (*bankManifestCode, error) {
+	fileName, ok := bankCodeFiles[constants.LanguageType(code.Language)]
+	if !ok {
+		return nil, fmt.Errorf("language %s not support", code.Language)
+	}
+	c := &bankManifestCode{
+		Language:          code.Language,
+		File:              path.Join(dir, fileName),
+		Breakpoints:       code.Breakpoints,
+		VisualDescription: code.VisualDescription,
+	}
+	if c.Breakpoints == nil {
+		c.Breakpoints = []int{}
+	}
+	if err := writeZipFile(w, c.File, []byte(code.Code)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func writeZipFile(w *zip.Writer, name string, data []byte) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// documentDirName 将标题转换为可以作为目录名的字符串
+func documentDirName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if runes := []rune(name); len(runes) > bankTitleLimit {
+		name = string(runes[:bankTitleLimit])
+	}
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		name = "untitled"
+	}
+	return name
+}
+
+// readBankArchive 读取解压后的导出文件，文档的内容和代码以文件为准，可以在导出后直接修改
+func readBankArchive(dir string) (*archiveBank, error) {
+	data, err := os.ReadFile(filepath.Join(dir, bankManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &bankManifest{}
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != bankArchiveVersion {
+		return nil, fmt.Errorf("archive version %d not support", manifest.Version)
+	}
+	if strings.TrimSpace(manifest.Bank.Name) == "" {
+		return nil, errors.New("bank name is empty")
+	}
+	bank := &archiveBank{
+		Name:        manifest.Bank.Name,
+		Description: manifest.Bank.Description,
+		Enable:      manifest.Bank.Enable,
+		Documents:   []*archiveDocument{},
+	}
+	documentMap := make(map[string]*archiveDocument, len(manifest.Documents))
+	for _, item := range manifest.Documents {
+		if item.Path == "" || strings.TrimSpace(item.Title) == "" {
+			return nil, errors.New("document path or title is empty")
+		}
+		if _, ok := documentMap[item.Path]; ok {
+			return nil, fmt.Errorf("document %s is duplicate", item.Path)
+		}
+		document := &archiveDocument{
+			Title:    item.Title,
+			Order:    item.Order,
+			Enable:   item.Enable,
+			CodeList: make([]*dto.VisualDocumentCodeDto, 0, len(item.Codes)),
+			Children: []*archiveDocument{},
+		}
+		if item.Content != "" {
+			content, err := readArchiveFile(dir, item.Content)
+			if err != nil {
+				return nil, err
+			}
+			document.Content = string(content)
+		}
+		languages := make(map[string]bool, len(item.Codes))
+		for _, c := range item.Codes {
+			if languages[c.Language] {
+				return nil, fmt.Errorf("document %s has duplicate language %s", item.Path, c.Language)
+			}
+			languages[c.Language] = true
+			code, err := readArchiveCode(dir, c)
+			if err != nil {
+				return nil, err
+			}
+			document.CodeList = append(document.CodeList, code)
+		}
+		for _, s := range item.Snippets {
+			snippet := &dto.VisualDocumentSnippetDto{
+				Key:      s.Key,
+				Title:    s.Title,
+				CodeList: make([]*dto.VisualDocumentSnippetCodeDto, 0, len(s.Codes)),
+			}
+			for _, c := range s.Codes {
+				code, err := readArchiveCode(dir, c)
+				if err != nil {
+					return nil, err
+				}
+				snippet.CodeList = append(snippet.CodeList, &dto.VisualDocumentSnippetCodeDto{
+					VisualDocumentCodeDto: *code,
+					Stdin:                 c.Stdin,
+				})
+			}
+			document.Snippets = append(document.Snippets, snippet)
+		}
+		// 代码示例与保存文档时的校验一致，文档中引用的示例必须存在
+		if err = validateSnippets(document.Content, document.Snippets); err != nil {
+			return nil, fmt.Errorf("document %s: %v", item.Path, err)
+		}
+		// 父文档需要在子文档之前出现，保证目录结构中没有环
+		if item.Parent == "" {
+			bank.Documents = append(bank.Documents, document)
+		} else if parent, ok := documentMap[item.Parent]; ok {
+			parent.Children = append(parent.Children, document)
+		} else {
+			return nil, fmt.Errorf("parent of document %s not found", item.Path)
+		}
+		documentMap[item.Path] = document
+	}
+	sortArchiveDocuments(bank.Documents)
+	return bank, nil
+}
+
+// readArchiveCode 读取导出文件中的代码
+func readArchiveCode(dir string, c *bankManifestCode) (*dto.VisualDocumentCodeDto, error) {
+	if _, ok := bankCodeFiles[constants.LanguageType(c.Language)]; !ok {
+		return nil, fmt.Errorf("language %s not support", c.Language)
+	}
+	if c.VisualDescription != nil {
+		if err := c.VisualDescription.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	code, err := readArchiveFile(dir, c.File)
+	if err != nil {
+		return nil, err
+	}
+	breakpoints := c.Breakpoints
+	if breakpoints == nil {
+		breakpoints = []int{}
+	}
+	return &dto.VisualDocumentCodeDto{
+		Code:              string(code),
+		Language:          c.Language,
+		Breakpoints:       breakpoints,
+		VisualDescription: c.VisualDescription,
+	}, nil
+}
+
+// readArchiveFile 读取导出文件中的文件，不允许访问解压目录之外的文件
+func readArchiveFile(dir string, name string) ([]byte, error) {
+	name = path.Clean(name)
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return nil, fmt.Errorf("illegal file path: %s", name)
+	}
+	return os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+}
+
+func sortArchiveDocuments(documents []*archiveDocument) {
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].Order < documents[j].Order
+	})
+	for _, document := range documents {
+		sortArchiveDocuments(document.Children)
+	}
+}
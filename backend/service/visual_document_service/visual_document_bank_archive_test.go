@@ -0,0 +1,154 @@
+package visual_document_service
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// extractBankArchiveForTest 将导出的文件解压到临时目录
+func extractBankArchiveForTest(t *testing.T, data []byte) string {
+	dir := t.TempDir()
+	archiveFile := filepath.Join(dir, "bank.zip")
+	assert.Nil(t, os.WriteFile(archiveFile, data, 0644))
+	dataDir := filepath.Join(dir, "data")
+	assert.Nil(t, utils.Extract(archiveFile, dataDir))
+	return dataDir
+}
+
+func TestBankArchive(t *testing.T) {
+	bank := &archiveBank{
+		Name:        "数据结构",
+		Description: "基础数据结构",
+		Enable:      true,
+		Documents: []*archiveDocument{
+			{
+				Title:   "链表",
+				Order:   1,
+				Enable:  true,
+				Content: "# 链表\n",
+				CodeList: []*dto.VisualDocumentCodeDto{
+					{Code: "int main() {}", Language: "c", Breakpoints: []int{1}},
+					{Code: "package main", Language: "go", Breakpoints: []int{}},
+				},
+				Children: []*archiveDocument{
+					{Title: "双向/链表", Order: 1, Content: "双向", CodeList: []*dto.VisualDocumentCodeDto{}, Children: []*archiveDocument{}},
+					{Title: "双向/链表", Order: 2, Content: "同名", CodeList: []*dto.VisualDocumentCodeDto{}, Children: []*archiveDocument{}},
+				},
+			},
+			{Title: "数组", Order: 2, Content: "", CodeList: []*dto.VisualDocumentCodeDto{}, Children: []*archiveDocument{}},
+		},
+	}
+	data, err := writeBankArchive(bank)
+	assert.Nil(t, err)
+	dir := extractBankArchiveForTest(t, data)
+
+	content, err := os.ReadFile(filepath.Join(dir, "documents", "01-链表", "index.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "# 链表\n", string(content))
+	// 同名文档使用序号区分目录
+	content, err = os.ReadFile(filepath.Join(dir, "documents", "01-链表", "02-双向_链表", "index.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "同名", string(content))
+
+	answer, err := readBankArchive(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, bank, answer)
+
+	// 导出后修改的文件在导入时生效
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "documents", "02-数组", "index.md"), []byte("修改"), 0644))
+	answer, err = readBankArchive(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "修改", answer.Documents[1].Content)
+}
+
+func TestBankArchive_Snippets(t *testing.T) {
+	snippet := newSnippetForTest("sort", "c")
+	snippet.Title = "排序"
+	snippet.CodeList[0].Breakpoints = []int{2}
+	snippet.CodeList[0].Stdin = "3\n1 2 3\n"
+	bank := &archiveBank{
+		Name: "算法",
+		Documents: []*archiveDocument{
+			{
+				Title:    "排序",
+				Order:    1,
+				Content:  "<!-- snippet:sort -->",
+				CodeList: []*dto.VisualDocumentCodeDto{},
+				Snippets: []*dto.VisualDocumentSnippetDto{snippet},
+				Children: []*archiveDocument{},
+			},
+		},
+	}
+	data, err := writeBankArchive(bank)
+	assert.Nil(t, err)
+	dir := extractBankArchiveForTest(t, data)
+	code, err := os.ReadFile(filepath.Join(dir, "documents", "01-排序", "snippets", "sort", "main.c"))
+	assert.Nil(t, err)
+	assert.Equal(t, "int main() {}", string(code))
+	answer, err := readBankArchive(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, bank, answer)
+
+	// 文档引用的示例不存在时导入失败
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "documents", "01-排序", "index.md"), []byte("<!-- snippet:other -->"), 0644))
+	_, err = readBankArchive(dir)
+	assert.NotNil(t, err)
+}
+
+func TestReadBankArchive_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+	}{
+		{"version", `{"version":2,"bank":{"name":"a"}}`},
+		{"name", `{"version":1,"bank":{"name":" "}}`},
+		{"parent", `{"version":1,"bank":{"name":"a"},"documents":[{"path":"b","parent":"c","title":"b"}]}`},
+		{"language", `{"version":1,"bank":{"name":"a"},"documents":[{"path":"b","title":"b","codes":[{"language":"py","file":"x"}]}]}`},
+		{"path", `{"version":1,"bank":{"name":"a"},"documents":[{"path":"b","title":"b","content":"../secret"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.Nil(t, os.WriteFile(filepath.Join(dir, bankManifestFile), []byte(tt.manifest), 0644))
+			_, err := readBankArchive(dir)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+// writeZipForTest 生成包含count个文件、每个文件size字节的zip文件
+func writeZipForTest(t *testing.T, count int, size int) string {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for i := 0; i < count; i++ {
+		f, err := w.Create(filepath.Join("documents", strings.Repeat("a", i+1)))
+		assert.Nil(t, err)
+		_, err = f.Write(make([]byte, size))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	archiveFile := filepath.Join(t.TempDir(), "bank.zip")
+	assert.Nil(t, os.WriteFile(archiveFile, buf.Bytes(), 0644))
+	return archiveFile
+}
+
+func TestCheckBankArchive(t *testing.T) {
+	assert.Nil(t, checkBankArchive(writeZipForTest(t, 2, 1024)))
+	// 压缩率很高的大文件在解压前被拒绝
+	assert.NotNil(t, checkBankArchive(writeZipForTest(t, 2, bankArchiveSizeLimit/2+1)))
+	assert.NotNil(t, checkBankArchive(filepath.Join(t.TempDir(), "missing.zip")))
+}
+
+func TestDocumentDirName(t *testing.T) {
+	assert.Equal(t, "a_b_c", documentDirName(" a/b:c "))
+	assert.Equal(t, "untitled", documentDirName(".."))
+	assert.Equal(t, 50, len([]rune(documentDirName(strings.Repeat("题", 60)))))
+}
@@ -0,0 +1,108 @@
+package visual_document_service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+func (v *visualDocumentBankService) GetVisualDocumentBankMembers(ctx context.Context, bankID uint) ([]*dto.VisualDocumentBankMemberDto, error) {
+	if err := v.permission.checkBankRole(ctx, bankID, constants.BankRoleViewer); err != nil {
+		return nil, err
+	}
+	bank, err := v.visualDocumentBankDao.GetVisualDocumentBankByID(common.Mysql, bankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetVisualDocumentBankMembers] GetVisualDocumentBankByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	members, err := v.memberDao.GetMemberListByBankID(common.Mysql, bankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetVisualDocumentBankMembers] GetMemberListByBankID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.VisualDocumentBankMemberDto, 0, len(members)+1)
+	// 之前创建的知识库没有协作者记录，创建者同样是所有者
+	creatorFound := bank.CreatorID == 0
+	for _, member := range members {
+		creatorFound = creatorFound || member.UserID == bank.CreatorID
+	}
+	if !creatorFound {
+		answer = append(answer, dto.NewVisualDocumentBankMemberDto(&po.VisualDocumentBankMember{
+			BankID:    bankID,
+			UserID:    bank.CreatorID,
+			Role:      constants.BankRoleOwner,
+			CreatedAt: bank.CreatedAt,
+		}))
+	}
+	for _, member := range members {
+		answer = append(answer, dto.NewVisualDocumentBankMemberDto(member))
+	}
+	for _, member := range answer {
+		member.Username, _ = v.sysUserDao.GetUserNameByID(common.Mysql, member.UserID)
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentBankService) SaveVisualDocumentBankMember(ctx context.Context, bankID uint,
+	req *dto.SaveVisualDocumentBankMemberReq) error {
+	if bankRoleLevel(req.Role) == 0 {
+		return e.NewParamErr("不支持的角色")
+	}
+	bank, err := v.checkMemberChange(ctx, bankID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if _, err = v.sysUserDao.GetUserByID(common.Mysql, req.UserID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.NewRecordNotFoundErr("user not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[SaveVisualDocumentBankMember] GetUserByID fail, err = %v", err)
+		return e.ErrMysql
+	}
+	if err = v.memberDao.SaveMember(common.Mysql, &po.VisualDocumentBankMember{
+		BankID: bank.ID,
+		UserID: req.UserID,
+		Role:   req.Role,
+	}); err != nil {
+		logger.WithCtx(ctx).Errorf("[SaveVisualDocumentBankMember] SaveMember fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (v *visualDocumentBankService) DeleteVisualDocumentBankMember(ctx context.Context, bankID uint, userID uint) error {
+	if _, err := v.checkMemberChange(ctx, bankID, userID); err != nil {
+		return err
+	}
+	if err := v.memberDao.DeleteMember(common.Mysql, bankID, userID); err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteVisualDocumentBankMember] DeleteMember fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+// checkMemberChange 校验当前用户可以修改协作者，只有所有者可以修改，并且创建者的角色不能修改
+func (v *visualDocumentBankService) checkMemberChange(ctx context.Context, bankID uint, userID uint) (*po.VisualDocumentBank, error) {
+	if err := v.permission.checkBankRole(ctx, bankID, constants.BankRoleOwner); err != nil {
+		return nil, err
+	}
+	bank, err := v.visualDocumentBankDao.GetVisualDocumentBankByID(common.Mysql, bankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[checkMemberChange] GetVisualDocumentBankByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	if userID == 0 {
+		return nil, e.NewParamErr("用户不能为空")
+	}
+	if userID == bank.CreatorID {
+		return nil, e.NewParamErr("不能修改知识库创建者的角色")
+	}
+	return bank, nil
+}
@@ -0,0 +1,186 @@
+package visual_document_service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+// bankPermission 校验当前用户在知识库中的角色
+// 超级管理员和知识库的创建者是所有者，其他用户的角色由协作者列表决定
+type bankPermission struct {
+	bankDao     dao.VisualDocumentBankDao
+	memberDao   dao.VisualDocumentBankMemberDao
+	documentDao dao.VisualDocumentDao
+	sysUserDao  dao.SysUserDao
+}
+
+func newBankPermission(bankDao dao.VisualDocumentBankDao, memberDao dao.VisualDocumentBankMemberDao,
+	documentDao dao.VisualDocumentDao, sysUserDao dao.SysUserDao) *bankPermission {
+	return &bankPermission{
+		bankDao:     bankDao,
+		memberDao:   memberDao,
+		documentDao: documentDao,
+		sysUserDao:  sysUserDao,
+	}
+}
+
+// getBankRole 获取当前用户在知识库中的角色，不是协作者时返回空字符串
+func (b *bankPermission) getBankRole(ctx context.Context, bankID uint) (string, error) {
+	bank, err := b.bankDao.GetVisualDocumentBankByID(common.Mysql, bankID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", e.NewRecordNotFoundErr("bank not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getBankRole] GetVisualDocumentBankByID fail, err = %v", err)
+		return "", e.ErrMysql
+	}
+	return b.getRole(ctx, bank)
+}
+
+// getRole 获取当前用户在知识库中的角色
+func (b *bankPermission) getRole(ctx context.Context, bank *po.VisualDocumentBank) (string, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	if userID == 0 {
+		return "", nil
+	}
+	if bank.CreatorID == userID {
+		return constants.BankRoleOwner, nil
+	}
+	isAdmin, err := b.isSuperAdmin(ctx)
+	if err != nil {
+		return "", err
+	}
+	if isAdmin {
+		return constants.BankRoleOwner, nil
+	}
+	member, err := b.memberDao.GetMember(common.Mysql, bank.ID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getRole] GetMember fail, err = %v", err)
+		return "", e.ErrMysql
+	}
+	return member.Role, nil
+}
+
+// getBankRoles 批量获取当前用户在知识库中的角色，key为知识库id，不是协作者的知识库不在结果中
+func (b *bankPermission) getBankRoles(ctx context.Context, banks []*po.VisualDocumentBank) (map[uint]string, error) {
+	answer := make(map[uint]string, len(banks))
+	userID := utils.GetUserIDWithCtx(ctx)
+	if userID == 0 {
+		return answer, nil
+	}
+	isAdmin, err := b.isSuperAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := b.memberDao.GetMemberListByUserID(common.Mysql, userID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getBankRoles] GetMemberListByUserID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	memberRoles := make(map[uint]string, len(members))
+	for _, member := range members {
+		memberRoles[member.BankID] = member.Role
+	}
+	for _, bank := range banks {
+		if isAdmin || bank.CreatorID == userID {
+			answer[bank.ID] = constants.BankRoleOwner
+		} else if role, ok := memberRoles[bank.ID]; ok {
+			answer[bank.ID] = role
+		}
+	}
+	return answer, nil
+}
+
+// isSuperAdmin 当前用户是否是超级管理员，超级管理员可以管理所有知识库
+func (b *bankPermission) isSuperAdmin(ctx context.Context) (bool, error) {
+	roleIDs, err := b.sysUserDao.GetRoleIDsByUserID(common.Mysql, utils.GetUserIDWithCtx(ctx))
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[isSuperAdmin] GetRoleIDsByUserID fail, err = %v", err)
+		return false, e.ErrMysql
+	}
+	for _, roleID := range roleIDs {
+		if roleID == constants.AdminID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkBankRole 校验当前用户在知识库中的角色不低于role
+func (b *bankPermission) checkBankRole(ctx context.Context, bankID uint, role string) error {
+	current, err := b.getBankRole(ctx, bankID)
+	if err != nil {
+		return err
+	}
+	if !hasBankRole(current, role) {
+		return e.ErrPermissionInvalid
+	}
+	return nil
+}
+
+// checkDocumentRole 校验当前用户在文档所在知识库中的角色不低于role，并返回文档
+func (b *bankPermission) checkDocumentRole(ctx context.Context, documentID uint, role string) (*po.VisualDocument, error) {
+	document, err := b.documentDao.GetVisualDocumentByID(common.Mysql, documentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("document not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[checkDocumentRole] GetVisualDocumentByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	if err = b.checkBankRole(ctx, document.BankID, role); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// getEnabledDocument 获取用户可以看到的文档，文档和所在的知识库都需要启用
+func (b *bankPermission) getEnabledDocument(ctx context.Context, documentID uint) (*po.VisualDocument, error) {
+	document, err := b.documentDao.GetVisualDocumentByID(common.Mysql, documentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !document.Enable) {
+		return nil, e.NewRecordNotFoundErr("document not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getEnabledDocument] GetVisualDocumentByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	bank, err := b.bankDao.GetVisualDocumentBankByID(common.Mysql, document.BankID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !bank.Enable) {
+		return nil, e.NewRecordNotFoundErr("document not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getEnabledDocument] GetVisualDocumentBankByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return document, nil
+}
+
+// hasBankRole 判断current角色是否拥有role角色的权限
+func hasBankRole(current string, role string) bool {
+	return bankRoleLevel(current) >= bankRoleLevel(role) && bankRoleLevel(current) != 0
+}
+
+func bankRoleLevel(role string) int {
+	switch role {
+	case constants.BankRoleOwner:
+		return 3
+	case constants.BankRoleEditor:
+		return 2
+	case constants.BankRoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,144 @@
+package visual_document_service
+
+import (
+	"context"
+	"testing"
+
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/dao/mock"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type fakeBankDao struct {
+	dao.VisualDocumentBankDao
+	banks map[uint]*po.VisualDocumentBank
+}
+
+func (f *fakeBankDao) GetVisualDocumentBankByID(db *gorm.DB, bankID uint) (*po.VisualDocumentBank, error) {
+	if bank, ok := f.banks[bankID]; ok {
+		return bank, nil
+	}
+	return &po.VisualDocumentBank{}, gorm.ErrRecordNotFound
+}
+
+type fakeBankMemberDao struct {
+	dao.VisualDocumentBankMemberDao
+	members []*po.VisualDocumentBankMember
+}
+
+func (f *fakeBankMemberDao) GetMember(db *gorm.DB, bankID uint, userID uint) (*po.VisualDocumentBankMember, error) {
+	for _, member := range f.members {
+		if member.BankID == bankID && member.UserID == userID {
+			return member, nil
+		}
+	}
+	return &po.VisualDocumentBankMember{}, gorm.ErrRecordNotFound
+}
+
+func (f *fakeBankMemberDao) GetMemberListByUserID(db *gorm.DB, userID uint) ([]*po.VisualDocumentBankMember, error) {
+	var answer []*po.VisualDocumentBankMember
+	for _, member := range f.members {
+		if member.UserID == userID {
+			answer = append(answer, member)
+		}
+	}
+	return answer, nil
+}
+
+type fakeDocumentDao struct {
+	dao.VisualDocumentDao
+	documents map[uint]*po.VisualDocument
+}
+
+func (f *fakeDocumentDao) GetVisualDocumentByID(db *gorm.DB, id uint) (*po.VisualDocument, error) {
+	if document, ok := f.documents[id]; ok {
+		return document, nil
+	}
+	return &po.VisualDocument{}, gorm.ErrRecordNotFound
+}
+
+func TestBankPermission(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	bank := func(id uint, creatorID uint) *po.VisualDocumentBank {
+		return &po.VisualDocumentBank{Model: gorm.Model{ID: id}, CreatorID: creatorID}
+	}
+	bankDao := &fakeBankDao{banks: map[uint]*po.VisualDocumentBank{1: bank(1, 10), 2: bank(2, 0)}}
+	memberDao := &fakeBankMemberDao{members: []*po.VisualDocumentBankMember{
+		{BankID: 1, UserID: 11, Role: constants.BankRoleEditor},
+		{BankID: 1, UserID: 12, Role: constants.BankRoleViewer},
+	}}
+	userDao := mock.NewMockSysUserDao(mockCtl)
+	userDao.EXPECT().GetRoleIDsByUserID(gomock.Any(), uint(1)).Return([]uint{constants.AdminID}, nil).AnyTimes()
+	userDao.EXPECT().GetRoleIDsByUserID(gomock.Any(), gomock.Any()).Return([]uint{constants.UserID}, nil).AnyTimes()
+	permission := newBankPermission(bankDao, memberDao, nil, userDao)
+	withUser := func(userID uint) context.Context {
+		return context.WithValue(context.Background(), utils.CtxUserIDKey, userID)
+	}
+
+	// 创建者和超级管理员是所有者
+	assert.Nil(t, permission.checkBankRole(withUser(10), 1, constants.BankRoleOwner))
+	assert.Nil(t, permission.checkBankRole(withUser(1), 2, constants.BankRoleOwner))
+	// 编辑者可以编辑，但不能管理知识库
+	assert.Nil(t, permission.checkBankRole(withUser(11), 1, constants.BankRoleEditor))
+	assert.Nil(t, permission.checkBankRole(withUser(11), 1, constants.BankRoleViewer))
+	assert.Equal(t, e.ErrPermissionInvalid, permission.checkBankRole(withUser(11), 1, constants.BankRoleOwner))
+	// 查看者只能查看
+	assert.Nil(t, permission.checkBankRole(withUser(12), 1, constants.BankRoleViewer))
+	assert.Equal(t, e.ErrPermissionInvalid, permission.checkBankRole(withUser(12), 1, constants.BankRoleEditor))
+	// 不是协作者
+	assert.Equal(t, e.ErrPermissionInvalid, permission.checkBankRole(withUser(11), 2, constants.BankRoleViewer))
+	assert.Equal(t, e.ErrPermissionInvalid, permission.checkBankRole(withUser(0), 1, constants.BankRoleViewer))
+	// 知识库不存在
+	err := permission.checkBankRole(withUser(10), 3, constants.BankRoleViewer)
+	assert.Equal(t, e.CodeRecordNotFound, err.(*e.Error).Code)
+
+	roles, err := permission.getBankRoles(withUser(11), []*po.VisualDocumentBank{bank(1, 10), bank(2, 0), bank(3, 11)})
+	assert.Nil(t, err)
+	assert.Equal(t, map[uint]string{1: constants.BankRoleEditor, 3: constants.BankRoleOwner}, roles)
+	roles, err = permission.getBankRoles(withUser(1), []*po.VisualDocumentBank{bank(1, 10), bank(2, 0)})
+	assert.Nil(t, err)
+	assert.Equal(t, map[uint]string{1: constants.BankRoleOwner, 2: constants.BankRoleOwner}, roles)
+}
+
+func TestBankPermission_GetEnabledDocument(t *testing.T) {
+	bankDao := &fakeBankDao{banks: map[uint]*po.VisualDocumentBank{
+		1: {Model: gorm.Model{ID: 1}, Enable: true},
+		2: {Model: gorm.Model{ID: 2}, Enable: false},
+	}}
+	document := func(id uint, bankID uint, enable bool) *po.VisualDocument {
+		return &po.VisualDocument{Model: gorm.Model{ID: id}, BankID: bankID, Enable: enable}
+	}
+	documentDao := &fakeDocumentDao{documents: map[uint]*po.VisualDocument{
+		1: document(1, 1, true),
+		2: document(2, 1, false),
+		3: document(3, 2, true),
+		4: document(4, 3, true),
+	}}
+	permission := newBankPermission(bankDao, nil, documentDao, nil)
+	ctx := context.Background()
+
+	answer, err := permission.getEnabledDocument(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), answer.ID)
+	// 文档未启用、知识库未启用、知识库不存在和文档不存在时都看不到
+	for _, id := range []uint{2, 3, 4, 5} {
+		_, err = permission.getEnabledDocument(ctx, id)
+		assert.Equal(t, e.CodeRecordNotFound, err.(*e.Error).Code)
+	}
+}
+
+func TestHasBankRole(t *testing.T) {
+	assert.True(t, hasBankRole(constants.BankRoleOwner, constants.BankRoleEditor))
+	assert.True(t, hasBankRole(constants.BankRoleViewer, constants.BankRoleViewer))
+	assert.False(t, hasBankRole(constants.BankRoleViewer, constants.BankRoleEditor))
+	assert.False(t, hasBankRole("", ""))
+	assert.False(t, hasBankRole("admin", constants.BankRoleViewer))
+}
@@ -3,13 +3,21 @@ package visual_document_service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"mime/multipart"
+	"os"
+	"path"
+	"strings"
+
 	"github.com/fansqz/fancode-backend/common"
 	conf "github.com/fansqz/fancode-backend/common/config"
 	e "github.com/fansqz/fancode-backend/common/error"
 	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/dao"
 	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/service/search_service"
 	"github.com/fansqz/fancode-backend/utils"
 	"gorm.io/gorm"
 )
@@ -24,31 +32,66 @@ type VisualDocumentBankService interface {
 	DeleteVisualDocumentBank(ctx context.Context, id uint) error
 	// GetAllVisualDocumentBank 获取所有可视化题库列表
 	GetAllVisualDocumentBank(ctx context.Context, enable *bool) ([]*dto.VisualDocumentBankDto, error)
-	// GetVisualDocumentBankByID 获取可视化题库信息
-	GetVisualDocumentBankByID(ctx context.Context, id uint) (*dto.VisualDocumentBankDto, error)
+	// GetVisualDocumentBankByID 获取可视化题库信息，enable为nil时是管理端读取，需要是知识库的协作者
+	GetVisualDocumentBankByID(ctx context.Context, id uint, enable *bool) (*dto.VisualDocumentBankDto, error)
+	// ExportVisualDocumentBank 导出知识库及其所有文档，返回文件名和zip文件内容
+	ExportVisualDocumentBank(ctx context.Context, id uint) (string, []byte, error)
+	// ImportVisualDocumentBank 导入知识库，conflict为存在同名知识库时的处理方式
+	ImportVisualDocumentBank(ctx context.Context, file *multipart.FileHeader, conflict string) (*dto.ImportVisualDocumentBankResult, error)
+
+	// GetVisualDocumentBankMembers 获取知识库的协作者
+	GetVisualDocumentBankMembers(ctx context.Context, bankID uint) ([]*dto.VisualDocumentBankMemberDto, error)
+	// SaveVisualDocumentBankMember 添加协作者或修改协作者的角色，只有所有者可以操作
+	SaveVisualDocumentBankMember(ctx context.Context, bankID uint, req *dto.SaveVisualDocumentBankMemberReq) error
+	// DeleteVisualDocumentBankMember 移除协作者，只有所有者可以操作
+	DeleteVisualDocumentBankMember(ctx context.Context, bankID uint, userID uint) error
 }
 
 type visualDocumentBankService struct {
 	config                *conf.AppConfig
 	visualDocumentBankDao dao.VisualDocumentBankDao
 	visualDocumentDao     dao.VisualDocumentDao
+	revisionDao           dao.VisualDocumentRevisionDao
+	snippetDao            dao.VisualDocumentSnippetDao
+	progressDao           dao.VisualDocumentProgressDao
 	sysUserDao            dao.SysUserDao
+	searchService         search_service.SearchService
+	memberDao             dao.VisualDocumentBankMemberDao
+	permission            *bankPermission
 }
 
 func NewVisualDocumentBankService(config *conf.AppConfig, bankDao dao.VisualDocumentBankDao, documentDao dao.VisualDocumentDao,
-	sysUserDao dao.SysUserDao) VisualDocumentBankService {
+	revisionDao dao.VisualDocumentRevisionDao, snippetDao dao.VisualDocumentSnippetDao, progressDao dao.VisualDocumentProgressDao, memberDao dao.VisualDocumentBankMemberDao,
+	sysUserDao dao.SysUserDao, searchService search_service.SearchService) VisualDocumentBankService {
 	return &visualDocumentBankService{
 		config:                config,
 		visualDocumentBankDao: bankDao,
 		visualDocumentDao:     documentDao,
+		revisionDao:           revisionDao,
+		snippetDao:            snippetDao,
+		progressDao:           progressDao,
 		sysUserDao:            sysUserDao,
+		searchService:         searchService,
+		memberDao:             memberDao,
+		permission:            newBankPermission(bankDao, memberDao, documentDao, sysUserDao),
 	}
 }
 
 func (v *visualDocumentBankService) InsertVisualDocumentBank(ctx context.Context, visualDocumentBank *po.VisualDocumentBank) (uint, error) {
 	userID := utils.GetUserIDWithCtx(ctx)
 	visualDocumentBank.CreatorID = userID
-	if err := v.visualDocumentBankDao.InsertVisualDocumentBank(common.Mysql, visualDocumentBank); err != nil {
+	// 创建者是知识库的所有者
+	err := common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := v.visualDocumentBankDao.InsertVisualDocumentBank(tx, visualDocumentBank); err != nil {
+			return err
+		}
+		return v.memberDao.SaveMember(tx, &po.VisualDocumentBankMember{
+			BankID: visualDocumentBank.ID,
+			UserID: userID,
+			Role:   constants.BankRoleOwner,
+		})
+	})
+	if err != nil {
 		logger.WithCtx(ctx).Errorf("[InsertVisualDocumentBank] insert visual document bank error, err = %v", err)
 		return 0, e.ErrMysql
 	}
@@ -56,17 +99,24 @@ func (v *visualDocumentBankService) InsertVisualDocumentBank(ctx context.Context
 }
 
 func (v *visualDocumentBankService) UpdateVisualDocumentBank(ctx context.Context, visualDocumentBank *po.VisualDocumentBank) error {
+	if err := v.permission.checkBankRole(ctx, visualDocumentBank.ID, constants.BankRoleOwner); err != nil {
+		return err
+	}
 	visualDocumentBank.CreatorID = 0
 	err := v.visualDocumentBankDao.UpdateVisualDocumentBank(common.Mysql, visualDocumentBank)
 	if err != nil {
 		logger.WithCtx(ctx).Errorf("[UpdateVisualDocumentBank] update visual document bank error, err = %v", err)
 		return e.ErrMysql
 	}
+	v.searchService.IndexBank(ctx, visualDocumentBank.ID)
 	return nil
 }
 
 func (v *visualDocumentBankService) DeleteVisualDocumentBank(ctx context.Context, id uint) error {
 	var err error
+	if err = v.permission.checkBankRole(ctx, id, constants.BankRoleOwner); err != nil {
+		return err
+	}
 	if err = v.visualDocumentBankDao.DeleteVisualDocumentBankByID(common.Mysql, id); err != nil {
 		logger.WithCtx(ctx).Errorf("[DeleteVisualDocumentBank] delete visual document bank error, err = %v", err)
 		return e.ErrMysql
@@ -75,6 +125,15 @@ func (v *visualDocumentBankService) DeleteVisualDocumentBank(ctx context.Context
 		logger.WithCtx(ctx).Errorf("[DeleteVisualDocumentBank] DeleteVisualDocumentByBankID error, err = %v", err)
 		return e.ErrMysql
 	}
+	if err = v.progressDao.DeleteProgressByBankID(common.Mysql, id); err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteVisualDocumentBank] DeleteProgressByBankID error, err = %v", err)
+		return e.ErrMysql
+	}
+	if err = v.memberDao.DeleteMemberByBankID(common.Mysql, id); err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteVisualDocumentBank] DeleteMemberByBankID error, err = %v", err)
+		return e.ErrMysql
+	}
+	v.searchService.IndexBank(ctx, id)
 
 	return nil
 }
@@ -95,17 +154,30 @@ func (v *visualDocumentBankService) GetAllVisualDocumentBank(ctx context.Context
 		}
 		banks = newDocumentList
 	}
+	// 管理端只返回用户参与的知识库
+	var roles map[uint]string
+	if enable == nil {
+		if roles, err = v.permission.getBankRoles(ctx, banks); err != nil {
+			return nil, err
+		}
+	}
 
 	// 设置更多信息
 	answer := []*dto.VisualDocumentBankDto{}
-	for i, _ := range banks {
-		answer = append(answer, dto.NewVisualDocumentBankDto(banks[i]))
-		answer[i].CreatorName, err = v.sysUserDao.GetUserNameByID(common.Mysql, answer[i].CreatorID)
+	for _, bank := range banks {
+		bankDto := dto.NewVisualDocumentBankDto(bank)
+		if enable == nil {
+			if bankDto.Role = roles[bank.ID]; bankDto.Role == "" {
+				continue
+			}
+		}
+		bankDto.CreatorName, err = v.sysUserDao.GetUserNameByID(common.Mysql, bankDto.CreatorID)
+		answer = append(answer, bankDto)
 	}
 	return answer, nil
 }
 
-func (v *visualDocumentBankService) GetVisualDocumentBankByID(ctx context.Context, id uint) (*dto.VisualDocumentBankDto, error) {
+func (v *visualDocumentBankService) GetVisualDocumentBankByID(ctx context.Context, id uint, enable *bool) (*dto.VisualDocumentBankDto, error) {
 	bank, err := v.visualDocumentBankDao.GetVisualDocumentBankByID(common.Mysql, id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, e.ErrProblemNotExist
@@ -114,7 +186,348 @@ func (v *visualDocumentBankService) GetVisualDocumentBankByID(ctx context.Contex
 		logger.WithCtx(ctx).Errorf("[GetvisualDocumentBankByID] get problem bank error, err = %v", err)
 		return nil, e.ErrMysql
 	}
+	if enable != nil && bank.Enable != *enable {
+		return nil, e.ErrProblemNotExist
+	}
 	answer := dto.NewVisualDocumentBankDto(bank)
+	if enable == nil {
+		if answer.Role, err = v.permission.getRole(ctx, bank); err != nil {
+			return nil, err
+		}
+		if answer.Role == "" {
+			return nil, e.ErrPermissionInvalid
+		}
+	}
 	answer.CreatorName, err = v.sysUserDao.GetUserNameByID(common.Mysql, answer.CreatorID)
 	return answer, nil
 }
+
+func (v *visualDocumentBankService) ExportVisualDocumentBank(ctx context.Context, id uint) (string, []byte, error) {
+	if err := v.permission.checkBankRole(ctx, id, constants.BankRoleViewer); err != nil {
+		return "", nil, err
+	}
+	bank, err := v.visualDocumentBankDao.GetVisualDocumentBankByID(common.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil, e.NewRecordNotFoundErr("bank not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[ExportVisualDocumentBank] get bank error, err = %v", err)
+		return "", nil, e.ErrMysql
+	}
+	documents, err := v.visualDocumentDao.GetVisualDocumentListByBankID(common.Mysql, id)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[ExportVisualDocumentBank] get documents error, err = %v", err)
+		return "", nil, e.ErrMysql
+	}
+	items, err := v.loadArchiveDocuments(common.Mysql, documents)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[ExportVisualDocumentBank] load documents error, err = %v", err)
+		return "", nil, e.ErrMysql
+	}
+	children := make(map[uint][]*po.VisualDocument, len(documents))
+	for _, document := range documents {
+		children[document.ParentID] = append(children[document.ParentID], document)
+	}
+	// 父文档不存在的文档在目录中不可见，不导出
+	var buildTree func(parentID uint) []*archiveDocument
+	buildTree = func(parentID uint) []*archiveDocument {
+		answer := make([]*archiveDocument, 0, len(children[parentID]))
+		for _, document := range children[parentID] {
+			item := items[document.ID]
+			item.Children = buildTree(document.ID)
+			answer = append(answer, item)
+		}
+		sortArchiveDocuments(answer)
+		return answer
+	}
+	archive := &archiveBank{
+		Name:        bank.Name,
+		Description: bank.Description,
+		Enable:      bank.Enable,
+	}
+	archive.Documents = buildTree(0)
+	data, err := writeBankArchive(archive)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[ExportVisualDocumentBank] write archive error, err = %v", err)
+		return "", nil, e.ErrServer
+	}
+	return documentDirName(bank.Name) + ".zip", data, nil
+}
+
+// loadArchiveDocuments 批量读取文档导出的内容，不包括子文档
+// 发布过的文档导出已发布的版本，与用户看到的内容一致，没有发布过的文档导出文档本身的内容
+func (v *visualDocumentBankService) loadArchiveDocuments(db *gorm.DB, documents []*po.VisualDocument) (map[uint]*archiveDocument, error) {
+	revisionIDs := make([]uint, 0, len(documents))
+	for _, document := range documents {
+		if document.PublishedRevisionID != 0 {
+			revisionIDs = append(revisionIDs, document.PublishedRevisionID)
+		}
+	}
+	revisionList, err := v.revisionDao.GetRevisionListByIDs(db, revisionIDs)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make(map[uint]*po.VisualDocumentRevision, len(revisionList))
+	for _, revision := range revisionList {
+		revisions[revision.ID] = revision
+	}
+	draftIDs := make([]uint, 0, len(documents))
+	for _, document := range documents {
+		if revisions[document.PublishedRevisionID] == nil {
+			draftIDs = append(draftIDs, document.ID)
+		}
+	}
+	codeList, err := v.visualDocumentDao.GetCodeListByDocumentIDs(db, draftIDs)
+	if err != nil {
+		return nil, err
+	}
+	codes := make(map[uint][]*dto.VisualDocumentCodeDto, len(draftIDs))
+	for _, code := range codeList {
+		codes[code.DocumentID] = append(codes[code.DocumentID], dto.NewVisualDocumentCodeDto(code))
+	}
+	snippetList, err := v.snippetDao.GetSnippetListByDocumentIDs(db, draftIDs)
+	if err != nil {
+		return nil, err
+	}
+	snippets := make(map[uint][]*dto.VisualDocumentSnippetDto, len(draftIDs))
+	for _, snippet := range snippetList {
+		snippets[snippet.DocumentID] = append(snippets[snippet.DocumentID], newVisualDocumentSnippetDto(snippet))
+	}
+
+	answer := make(map[uint]*archiveDocument, len(documents))
+	for _, document := range documents {
+		item := &archiveDocument{
+			Title:  document.Title,
+			Order:  document.Order,
+			Enable: document.Enable,
+		}
+		if revision := revisions[document.PublishedRevisionID]; revision != nil {
+			item.Content = revision.Content
+			item.CodeList = parseRevisionCodeList(revision.CodeList)
+			item.Snippets = parseRevisionSnippets(revision.Snippets)
+		} else {
+			item.Content = document.Content
+			item.CodeList = codes[document.ID]
+			item.Snippets = snippets[document.ID]
+		}
+		if item.CodeList == nil {
+			item.CodeList = []*dto.VisualDocumentCodeDto{}
+		}
+		if len(item.Snippets) == 0 {
+			item.Snippets = nil
+		}
+		answer[document.ID] = item
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentBankService) ImportVisualDocumentBank(ctx context.Context, file *multipart.FileHeader,
+	conflict string) (*dto.ImportVisualDocumentBankResult, error) {
+	if conflict == "" {
+		conflict = constants.BankImportRename
+	}
+	if conflict != constants.BankImportRename && conflict != constants.BankImportMerge && conflict != constants.BankImportFail {
+		return nil, e.NewParamErr("不支持的冲突处理方式")
+	}
+	archive, err := v.extractBankArchive(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	// 合并到已有的知识库需要是该知识库的编辑者
+	if conflict == constants.BankImportMerge {
+		existing, err := v.visualDocumentBankDao.GetVisualDocumentBankByName(common.Mysql, archive.Name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WithCtx(ctx).Errorf("[ImportVisualDocumentBank] GetVisualDocumentBankByName error, err = %v", err)
+			return nil, e.ErrMysql
+		}
+		if err == nil {
+			if err = v.permission.checkBankRole(ctx, existing.ID, constants.BankRoleEditor); err != nil {
+				return nil, err
+			}
+		}
+	}
+	userID := utils.GetUserIDWithCtx(ctx)
+	result := &dto.ImportVisualDocumentBankResult{}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		name := archive.Name
+		existing, err := v.visualDocumentBankDao.GetVisualDocumentBankByName(tx, name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			switch conflict {
+			case constants.BankImportFail:
+				return e.ErrVisualDocumentBankExist
+			case constants.BankImportMerge:
+				result.BankID = existing.ID
+			case constants.BankImportRename:
+				if name, err = v.availableBankName(tx, name); err != nil {
+					return err
+				}
+			}
+		}
+		// 合并时按父文档和标题匹配已有的文档
+		children := map[uint][]*po.VisualDocument{}
+		if result.BankID != 0 {
+			documents, err := v.visualDocumentDao.GetAllSimpleDocument(tx, result.BankID)
+			if err != nil {
+				return err
+			}
+			for _, document := range documents {
+				children[document.ParentID] = append(children[document.ParentID], document)
+			}
+		} else {
+			bank := &po.VisualDocumentBank{
+				Name:        name,
+				Description: archive.Description,
+				CreatorID:   userID,
+				Enable:      archive.Enable,
+			}
+			if err = v.visualDocumentBankDao.InsertVisualDocumentBank(tx, bank); err != nil {
+				return err
+			}
+			if err = v.memberDao.SaveMember(tx, &po.VisualDocumentBankMember{
+				BankID: bank.ID,
+				UserID: userID,
+				Role:   constants.BankRoleOwner,
+			}); err != nil {
+				return err
+			}
+			result.BankID = bank.ID
+		}
+		return v.importDocuments(tx, result.BankID, 0, archive.Documents, children, userID, result)
+	})
+	if errors.Is(err, e.ErrVisualDocumentBankExist) {
+		return nil, e.ErrVisualDocumentBankExist
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[ImportVisualDocumentBank] import bank error, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	v.searchService.IndexBank(ctx, result.BankID)
+	return result, nil
+}
+
+// extractBankArchive 解压上传的zip文件，并读取其中的知识库
+func (v *visualDocumentBankService) extractBankArchive(ctx context.Context, file *multipart.FileHeader) (*archiveBank, error) {
+	if strings.ToLower(path.Ext(file.Filename)) != ".zip" {
+		return nil, e.ErrVisualDocumentBankArchiveInvalid
+	}
+	if file.Size > bankArchiveFileLimit {
+		return nil, e.NewError(e.CodeVisualDocumentBankArchiveInvalid, "知识库导入文件过大", e.ErrTypeBus)
+	}
+	tempDir := path.Join(v.config.TempDir, "visual_document_bank", utils.GetUUID())
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			logger.WithCtx(ctx).Errorf("[extractBankArchive] remove temp dir fail, err = %v", err)
+		}
+	}()
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		logger.WithCtx(ctx).Errorf("[extractBankArchive] create temp dir fail, err = %v", err)
+		return nil, e.ErrServer
+	}
+	archiveFile := path.Join(tempDir, "bank.zip")
+	if err := utils.SaveUploadedFile(file, archiveFile); err != nil {
+		logger.WithCtx(ctx).Errorf("[extractBankArchive] save file fail, err = %v", err)
+		return nil, e.ErrServer
+	}
+	if err := checkBankArchive(archiveFile); err != nil {
+		logger.WithCtx(ctx).Warnf("[extractBankArchive] check file fail, err = %v", err)
+		return nil, e.NewError(e.CodeVisualDocumentBankArchiveInvalid, "知识库导入文件不合法: "+err.Error(), e.ErrTypeBus)
+	}
+	dataDir := path.Join(tempDir, "data")
+	if err := utils.Extract(archiveFile, dataDir); err != nil {
+		logger.WithCtx(ctx).Warnf("[extractBankArchive] extract file fail, err = %v", err)
+		return nil, e.ErrVisualDocumentBankArchiveInvalid
+	}
+	archive, err := readBankArchive(dataDir)
+	if err != nil {
+		logger.WithCtx(ctx).Warnf("[extractBankArchive] read archive fail, err = %v", err)
+		return nil, e.NewError(e.CodeVisualDocumentBankArchiveInvalid, "知识库导入文件不合法: "+err.Error(), e.ErrTypeBus)
+	}
+	return archive, nil
+}
+
+// availableBankName 在名称后面添加序号，直到没有同名的知识库
+func (v *visualDocumentBankService) availableBankName(tx *gorm.DB, name string) (string, error) {
+	for i := 2; ; i++ {
+		newName := fmt.Sprintf("%s(%d)", name, i)
+		_, err := v.visualDocumentBankDao.GetVisualDocumentBankByName(tx, newName)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return newName, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// importDocuments 导入文档及其子文档，每篇文档生成一个版本，启用的文档同时发布该版本
+// children为知识库中已有的文档，匹配上的文档会被覆盖
+func (v *visualDocumentBankService) importDocuments(tx *gorm.DB, bankID uint, parentID uint, documents []*archiveDocument,
+	children map[uint][]*po.VisualDocument, userID uint, result *dto.ImportVisualDocumentBankResult) error {
+	for _, document := range documents {
+		var documentID uint
+		if existing := takeDocumentByTitle(children, parentID, document.Title); existing != nil {
+			documentID = existing.ID
+			if err := v.visualDocumentDao.UpdateVisualDocument(tx, &po.VisualDocument{
+				Model:    gorm.Model{ID: documentID},
+				ParentID: parentID,
+				Title:    document.Title,
+				Content:  document.Content,
+				Enable:   document.Enable,
+			}); err != nil {
+				return err
+			}
+			if err := v.visualDocumentDao.UpdateVisualDocumentParentAndOrder(tx, documentID, parentID, document.Order); err != nil {
+				return err
+			}
+			result.Updated++
+		} else {
+			d := &po.VisualDocument{
+				BankID:    bankID,
+				ParentID:  parentID,
+				Order:     document.Order,
+				Title:     document.Title,
+				Content:   document.Content,
+				CreatorID: userID,
+				Enable:    document.Enable,
+			}
+			if err := v.visualDocumentDao.InsertVisualDocument(tx, d); err != nil {
+				return err
+			}
+			documentID = d.ID
+			result.Created++
+		}
+		if err := replaceCodeList(tx, v.visualDocumentDao, documentID, document.CodeList); err != nil {
+			return err
+		}
+		if err := replaceSnippets(tx, v.snippetDao, documentID, document.Snippets); err != nil {
+			return err
+		}
+		revision, err := saveRevision(tx, v.visualDocumentDao, v.revisionDao, documentID, document.Content, document.CodeList, document.Snippets, userID)
+		if err != nil {
+			return err
+		}
+		if document.Enable {
+			if err = v.visualDocumentDao.PublishVisualDocument(tx, documentID, revision.ID); err != nil {
+				return err
+			}
+		}
+		if err = v.importDocuments(tx, bankID, documentID, document.Children, children, userID, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// takeDocumentByTitle 找到父文档下指定标题的文档，每篇文档只匹配一次
+func takeDocumentByTitle(children map[uint][]*po.VisualDocument, parentID uint, title string) *po.VisualDocument {
+	for i, document := range children[parentID] {
+		if document.Title == title {
+			children[parentID] = append(children[parentID][:i:i], children[parentID][i+1:]...)
+			return document
+		}
+	}
+	return nil
+}
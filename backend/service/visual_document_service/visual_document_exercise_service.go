@@ -0,0 +1,536 @@
+package visual_document_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common"
+	conf "github.com/fansqz/fancode-backend/common/config"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/service/user_coding_service"
+	"github.com/fansqz/fancode-backend/service/user_coding_service/judger"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxExerciseCases 补全代码的测试用例数量上限，提交时同步运行所有用例
+	maxExerciseCases = 10
+)
+
+var (
+	// exerciseAnswerSeparator 变量追踪的答案按逗号和空白切分，忽略括号
+	exerciseAnswerSeparator = regexp.MustCompile(`[\s,\[\]{}]+`)
+	exerciseVariableRegexp  = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+)
+
+// VisualDocumentExerciseService 文档中的练习
+type VisualDocumentExerciseService interface {
+	// GetExerciseList 管理端获取文档的所有练习，包括答案和测试用例
+	GetExerciseList(ctx context.Context, documentID uint) ([]*dto.VisualDocumentExerciseDto, error)
+	// InsertExercise 添加练习，变量追踪的答案由调试器运行代码得到
+	InsertExercise(ctx context.Context, exercise *dto.VisualDocumentExerciseDto) (uint, error)
+	// UpdateExercise 修改练习
+	UpdateExercise(ctx context.Context, exercise *dto.VisualDocumentExerciseDto) error
+	// DeleteExercise 删除练习和所有用户的作答结果
+	DeleteExercise(ctx context.Context, id uint) error
+	// GetExerciseResults 获取所有用户在练习上的作答结果
+	GetExerciseResults(ctx context.Context, id uint) ([]*dto.ExerciseResultDto, error)
+
+	// GetDocumentExercises 用户获取文档的练习和自己的作答结果，不包含答案
+	GetDocumentExercises(ctx context.Context, documentID uint) ([]*dto.VisualDocumentExerciseDto, error)
+	// SubmitExercise 提交练习的答案并判断是否正确，文档的练习全部通过时标记文档已完成
+	SubmitExercise(ctx context.Context, id uint, req *dto.SubmitExerciseReq) (*dto.ExerciseResultDto, error)
+}
+
+type visualDocumentExerciseService struct {
+	config            *conf.AppConfig
+	exerciseDao       dao.VisualDocumentExerciseDao
+	visualDocumentDao dao.VisualDocumentDao
+	progressDao       dao.VisualDocumentProgressDao
+	sysUserDao        dao.SysUserDao
+	// judgeService 运行预测输出的代码和补全代码的测试用例
+	judgeService user_coding_service.JudgeService
+	permission   *bankPermission
+	// trace 使用调试器运行变量追踪的代码，返回变量的值
+	trace func(ctx context.Context, exercise *dto.VisualDocumentExerciseDto) (string, error)
+}
+
+func NewVisualDocumentExerciseService(config *conf.AppConfig, exerciseDao dao.VisualDocumentExerciseDao,
+	documentDao dao.VisualDocumentDao, progressDao dao.VisualDocumentProgressDao, bankDao dao.VisualDocumentBankDao,
+	memberDao dao.VisualDocumentBankMemberDao, sysUserDao dao.SysUserDao,
+	judgeService user_coding_service.JudgeService) VisualDocumentExerciseService {
+	answer := &visualDocumentExerciseService{
+		config:            config,
+		exerciseDao:       exerciseDao,
+		visualDocumentDao: documentDao,
+		progressDao:       progressDao,
+		sysUserDao:        sysUserDao,
+		judgeService:      judgeService,
+		permission:        newBankPermission(bankDao, memberDao, documentDao, sysUserDao),
+	}
+	answer.trace = answer.traceVariable
+	return answer
+}
+
+func (v *visualDocumentExerciseService) GetExerciseList(ctx context.Context, documentID uint) ([]*dto.VisualDocumentExerciseDto, error) {
+	if _, err := v.permission.checkDocumentRole(ctx, documentID, constants.BankRoleViewer); err != nil {
+		return nil, err
+	}
+	exercises, err := v.exerciseDao.GetExerciseListByDocumentID(common.Mysql, documentID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetExerciseList] GetExerciseListByDocumentID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.VisualDocumentExerciseDto, 0, len(exercises))
+	for _, exercise := range exercises {
+		answer = append(answer, dto.NewVisualDocumentExerciseDto(exercise))
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentExerciseService) InsertExercise(ctx context.Context, exercise *dto.VisualDocumentExerciseDto) (uint, error) {
+	if _, err := v.permission.checkDocumentRole(ctx, exercise.DocumentID, constants.BankRoleEditor); err != nil {
+		return 0, err
+	}
+	p, err := v.prepareExercise(ctx, exercise)
+	if err != nil {
+		return 0, err
+	}
+	if err = v.exerciseDao.InsertExercise(common.Mysql, p); err != nil {
+		logger.WithCtx(ctx).Errorf("[InsertExercise] InsertExercise fail, err = %v", err)
+		return 0, e.ErrMysql
+	}
+	return p.ID, nil
+}
+
+func (v *visualDocumentExerciseService) UpdateExercise(ctx context.Context, exercise *dto.VisualDocumentExerciseDto) error {
+	old, err := v.getExercise(ctx, exercise.ID)
+	if err != nil {
+		return err
+	}
+	if _, err = v.permission.checkDocumentRole(ctx, old.DocumentID, constants.BankRoleEditor); err != nil {
+		return err
+	}
+	// 练习不能移动到其他文档
+	exercise.DocumentID = old.DocumentID
+	p, err := v.prepareExercise(ctx, exercise)
+	if err != nil {
+		return err
+	}
+	p.ID = old.ID
+	if err = v.exerciseDao.UpdateExercise(common.Mysql, p); err != nil {
+		logger.WithCtx(ctx).Errorf("[UpdateExercise] UpdateExercise fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (v *visualDocumentExerciseService) DeleteExercise(ctx context.Context, id uint) error {
+	exercise, err := v.getExercise(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err = v.permission.checkDocumentRole(ctx, exercise.DocumentID, constants.BankRoleEditor); err != nil {
+		return err
+	}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := v.exerciseDao.DeleteResultByExerciseID(tx, id); err != nil {
+			return err
+		}
+		return v.exerciseDao.DeleteExerciseByID(tx, id)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[DeleteExercise] delete exercise fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (v *visualDocumentExerciseService) GetExerciseResults(ctx context.Context, id uint) ([]*dto.ExerciseResultDto, error) {
+	exercise, err := v.getExercise(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = v.permission.checkDocumentRole(ctx, exercise.DocumentID, constants.BankRoleViewer); err != nil {
+		return nil, err
+	}
+	results, err := v.exerciseDao.GetResultListByExerciseID(common.Mysql, id)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetExerciseResults] GetResultListByExerciseID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.ExerciseResultDto, 0, len(results))
+	for _, result := range results {
+		resultDto := dto.NewExerciseResultDto(result)
+		resultDto.UserID = result.UserID
+		resultDto.Username, _ = v.sysUserDao.GetUserNameByID(common.Mysql, result.UserID)
+		answer = append(answer, resultDto)
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentExerciseService) GetDocumentExercises(ctx context.Context, documentID uint) ([]*dto.VisualDocumentExerciseDto, error) {
+	if _, err := v.permission.getEnabledDocument(ctx, documentID); err != nil {
+		return nil, err
+	}
+	exercises, err := v.exerciseDao.GetExerciseListByDocumentID(common.Mysql, documentID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetDocumentExercises] GetExerciseListByDocumentID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	resultMap := map[uint]*po.VisualDocumentExerciseResult{}
+	if userID := utils.GetUserIDWithCtx(ctx); userID != 0 {
+		results, err := v.exerciseDao.GetResultListByDocumentID(common.Mysql, userID, documentID)
+		if err != nil {
+			logger.WithCtx(ctx).Errorf("[GetDocumentExercises] GetResultListByDocumentID fail, err = %v", err)
+			return nil, e.ErrMysql
+		}
+		for _, result := range results {
+			resultMap[result.ExerciseID] = result
+		}
+	}
+	answer := make([]*dto.VisualDocumentExerciseDto, 0, len(exercises))
+	for _, exercise := range exercises {
+		exerciseDto := hideExerciseAnswer(dto.NewVisualDocumentExerciseDto(exercise))
+		if result, ok := resultMap[exercise.ID]; ok {
+			exerciseDto.Result = dto.NewExerciseResultDto(result)
+			exerciseDto.Result.Explanation = exercise.Explanation
+		}
+		answer = append(answer, exerciseDto)
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentExerciseService) SubmitExercise(ctx context.Context, id uint, req *dto.SubmitExerciseReq) (*dto.ExerciseResultDto, error) {
+	// 游客不保存作答结果
+	userID := utils.GetUserIDWithCtx(ctx)
+	if userID == 0 {
+		return nil, e.ErrPermissionInvalid
+	}
+	exercise, err := v.getExercise(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	document, err := v.permission.getEnabledDocument(ctx, exercise.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+	correct, message, err := v.grade(ctx, dto.NewVisualDocumentExerciseDto(exercise), req)
+	if err != nil {
+		return nil, err
+	}
+	submitted, err := formatSubmittedAnswer(exercise.Type, req)
+	if err != nil {
+		return nil, e.ErrBadRequest
+	}
+	err = v.exerciseDao.SaveResult(common.Mysql, &po.VisualDocumentExerciseResult{
+		UserID:     userID,
+		ExerciseID: id,
+		DocumentID: exercise.DocumentID,
+		Answer:     submitted,
+		Correct:    correct,
+		Message:    message,
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[SubmitExercise] SaveResult fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	result, err := v.exerciseDao.GetResult(common.Mysql, userID, id)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[SubmitExercise] GetResult fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	if correct {
+		v.completeDocument(ctx, userID, document)
+	}
+	answer := dto.NewExerciseResultDto(result)
+	answer.Explanation = exercise.Explanation
+	return answer, nil
+}
+
+// completeDocument 文档的所有练习都通过时标记文档已完成，失败不影响作答结果
+func (v *visualDocumentExerciseService) completeDocument(ctx context.Context, userID uint, document *po.VisualDocument) {
+	exercises, err := v.exerciseDao.GetExerciseListByDocumentID(common.Mysql, document.ID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[completeDocument] GetExerciseListByDocumentID fail, err = %v", err)
+		return
+	}
+	results, err := v.exerciseDao.GetResultListByDocumentID(common.Mysql, userID, document.ID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[completeDocument] GetResultListByDocumentID fail, err = %v", err)
+		return
+	}
+	passed := make(map[uint]bool, len(results))
+	for _, result := range results {
+		passed[result.ExerciseID] = result.Passed
+	}
+	for _, exercise := range exercises {
+		if !passed[exercise.ID] {
+			return
+		}
+	}
+	if err = v.progressDao.CompleteDocument(common.Mysql, userID, document.ID, document.BankID, time.Now()); err != nil {
+		logger.WithCtx(ctx).Errorf("[completeDocument] CompleteDocument fail, err = %v", err)
+	}
+}
+
+// grade 判断答案是否正确，返回错误时的提示
+func (v *visualDocumentExerciseService) grade(ctx context.Context, exercise *dto.VisualDocumentExerciseDto,
+	req *dto.SubmitExerciseReq) (bool, string, error) {
+	switch exercise.Type {
+	case constants.ExerciseChoice:
+		if gradeChoices(exercise.Choices, req.Choices) {
+			return true, "", nil
+		}
+		return false, "答案不正确", nil
+	case constants.ExerciseOutput:
+		if ok, line := judger.CompareOutput([]byte(exercise.Answer), []byte(req.Answer)); !ok {
+			return false, fmt.Sprintf("第%d行输出不正确", line), nil
+		}
+		return true, "", nil
+	case constants.ExerciseTrace:
+		if gradeTraceAnswer(exercise.Answer, req.Answer) {
+			return true, "", nil
+		}
+		return false, "变量的值不正确", nil
+	case constants.ExerciseCode:
+		return v.gradeCode(ctx, exercise, req.Code)
+	}
+	return false, "", e.NewError(e.CodeExerciseInvalid, "练习类型不支持", e.ErrTypeBus)
+}
+
+// gradeCode 使用提交的代码运行所有测试用例，遇到第一个未通过的用例时停止
+func (v *visualDocumentExerciseService) gradeCode(ctx context.Context, exercise *dto.VisualDocumentExerciseDto,
+	code string) (bool, string, error) {
+	if strings.TrimSpace(code) == "" {
+		return false, "", e.NewParamErr("代码不能为空")
+	}
+	for i, c := range exercise.Cases {
+		result, err := v.judgeService.Run(ctx, &dto.RunRequest{
+			Language: exercise.Language,
+			Code:     code,
+			Input:    c.Input,
+		})
+		if err != nil {
+			return false, "", err
+		}
+		if result.Status != constants.RunSuccess {
+			message := fmt.Sprintf("第%d个测试用例%s", i+1, result.StatusMessage)
+			if result.ErrorMessage != "" {
+				message += ": " + result.ErrorMessage
+			}
+			return false, message, nil
+		}
+		if ok, _ := judger.CompareOutput([]byte(c.Output), []byte(result.Stdout)); !ok {
+			return false, fmt.Sprintf("第%d个测试用例输出不正确", i+1), nil
+		}
+	}
+	return true, "", nil
+}
+
+// prepareExercise 校验练习并转换为保存的格式，预测输出和变量追踪需要运行代码得到答案
+func (v *visualDocumentExerciseService) prepareExercise(ctx context.Context, exercise *dto.VisualDocumentExerciseDto) (*po.VisualDocumentExercise, error) {
+	if err := validateExercise(exercise); err != nil {
+		return nil, e.NewError(e.CodeExerciseInvalid, err.Error(), e.ErrTypeBadReq)
+	}
+	switch exercise.Type {
+	case constants.ExerciseOutput:
+		if exercise.Answer == "" {
+			result, err := v.judgeService.Run(ctx, &dto.RunRequest{
+				Language: exercise.Language,
+				Code:     exercise.Code,
+				Input:    exercise.Stdin,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if result.Status != constants.RunSuccess {
+				return nil, e.NewError(e.CodeExerciseInvalid, "练习的代码运行失败: "+result.StatusMessage, e.ErrTypeBus)
+			}
+			exercise.Answer = result.Stdout
+		}
+	case constants.ExerciseTrace:
+		value, err := v.trace(ctx, exercise)
+		if err != nil {
+			logger.WithCtx(ctx).Warnf("[prepareExercise] trace fail, err = %v", err)
+			return nil, e.NewError(e.CodeExerciseTraceFailed, e.ErrExerciseTraceFailed.Message+": "+err.Error(), e.ErrTypeBus)
+		}
+		exercise.Answer = value
+	}
+	return newExercisePo(exercise), nil
+}
+
+func (v *visualDocumentExerciseService) getExercise(ctx context.Context, id uint) (*po.VisualDocumentExercise, error) {
+	exercise, err := v.exerciseDao.GetExerciseByID(common.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("exercise not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getExercise] GetExerciseByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return exercise, nil
+}
+
+// validateExercise 校验练习，不同类型需要的字段不同
+func validateExercise(exercise *dto.VisualDocumentExerciseDto) error {
+	if strings.TrimSpace(exercise.Question) == "" {
+		return errors.New("题目不能为空")
+	}
+	needCode := func() error {
+		if !isSupportLanguage(exercise.Language) {
+			return fmt.Errorf("语言不支持: %s", exercise.Language)
+		}
+		if strings.TrimSpace(exercise.Code) == "" {
+			return errors.New("代码不能为空")
+		}
+		return nil
+	}
+	switch exercise.Type {
+	case constants.ExerciseChoice:
+		if len(exercise.Options) < 2 {
+			return errors.New("选择题至少需要两个选项")
+		}
+		if len(exercise.Choices) == 0 {
+			return errors.New("选择题需要正确选项")
+		}
+		chosen := make(map[int]bool, len(exercise.Choices))
+		for _, choice := range exercise.Choices {
+			if choice < 0 || choice >= len(exercise.Options) || chosen[choice] {
+				return fmt.Errorf("正确选项不合法: %d", choice)
+			}
+			chosen[choice] = true
+		}
+		return nil
+	case constants.ExerciseOutput:
+		return needCode()
+	case constants.ExerciseTrace:
+		if err := needCode(); err != nil {
+			return err
+		}
+		if len(exercise.Breakpoints) == 0 {
+			return errors.New("变量追踪需要设置断点")
+		}
+		if exercise.Step < 1 {
+			return errors.New("步骤需要从1开始")
+		}
+		if !exerciseVariableRegexp.MatchString(exercise.Variable) {
+			return fmt.Errorf("变量名不合法: %s", exercise.Variable)
+		}
+		return nil
+	case constants.ExerciseCode:
+		if err := needCode(); err != nil {
+			return err
+		}
+		if len(exercise.Cases) == 0 || len(exercise.Cases) > maxExerciseCases {
+			return fmt.Errorf("测试用例的数量需要在1到%d之间", maxExerciseCases)
+		}
+		return nil
+	}
+	return fmt.Errorf("练习类型不支持: %s", exercise.Type)
+}
+
+// newExercisePo 将练习转换为保存的格式，与类型无关的字段会被清空
+func newExercisePo(exercise *dto.VisualDocumentExerciseDto) *po.VisualDocumentExercise {
+	answer := &po.VisualDocumentExercise{
+		DocumentID:  exercise.DocumentID,
+		Type:        exercise.Type,
+		Title:       exercise.Title,
+		Question:    exercise.Question,
+		Explanation: exercise.Explanation,
+		Order:       exercise.Order,
+	}
+	marshal := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	switch exercise.Type {
+	case constants.ExerciseChoice:
+		choices := append([]int{}, exercise.Choices...)
+		sort.Ints(choices)
+		answer.Options = marshal(exercise.Options)
+		answer.Answer = marshal(choices)
+	case constants.ExerciseOutput, constants.ExerciseTrace:
+		answer.Language = exercise.Language
+		answer.Code = exercise.Code
+		answer.Stdin = exercise.Stdin
+		answer.Answer = exercise.Answer
+		if exercise.Type == constants.ExerciseTrace {
+			answer.Breakpoints = marshal(exercise.Breakpoints)
+			answer.Step = exercise.Step
+			answer.Variable = exercise.Variable
+		}
+	case constants.ExerciseCode:
+		answer.Language = exercise.Language
+		answer.Code = exercise.Code
+		answer.Cases = marshal(exercise.Cases)
+	}
+	return answer
+}
+
+// hideExerciseAnswer 去掉返回给用户的答案、测试用例和解析
+func hideExerciseAnswer(exercise *dto.VisualDocumentExerciseDto) *dto.VisualDocumentExerciseDto {
+	exercise.Choices = nil
+	exercise.Answer = ""
+	exercise.Cases = nil
+	exercise.Explanation = ""
+	return exercise
+}
+
+// formatSubmittedAnswer 将用户提交的答案转换为保存的格式
+func formatSubmittedAnswer(exerciseType string, req *dto.SubmitExerciseReq) (string, error) {
+	switch exerciseType {
+	case constants.ExerciseChoice:
+		data, err := json.Marshal(req.Choices)
+		return string(data), err
+	case constants.ExerciseCode:
+		return req.Code, nil
+	}
+	return req.Answer, nil
+}
+
+// gradeChoices 选择的选项与正确选项完全一致时正确，与顺序无关
+func gradeChoices(expected []int, actual []int) bool {
+	chosen := make(map[int]bool, len(actual))
+	for _, choice := range actual {
+		chosen[choice] = true
+	}
+	if len(chosen) != len(expected) {
+		return false
+	}
+	for _, choice := range expected {
+		if !chosen[choice] {
+			return false
+		}
+	}
+	return true
+}
+
+// gradeTraceAnswer 比较变量追踪的答案，数组可以写成[1, 2, 3]、{1,2,3}或者1 2 3
+func gradeTraceAnswer(expected string, actual string) bool {
+	split := func(s string) []string {
+		return strings.Fields(exerciseAnswerSeparator.ReplaceAllString(s, " "))
+	}
+	expectedValues, actualValues := split(expected), split(actual)
+	if len(expectedValues) == 0 || len(expectedValues) != len(actualValues) {
+		return false
+	}
+	for i := range expectedValues {
+		if expectedValues[i] != actualValues[i] {
+			return false
+		}
+	}
+	return true
+}
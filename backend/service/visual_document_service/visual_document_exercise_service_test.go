@@ -0,0 +1,115 @@
+package visual_document_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateExercise(t *testing.T) {
+	tests := []struct {
+		name     string
+		exercise *dto.VisualDocumentExerciseDto
+		valid    bool
+	}{
+		{"choice", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseChoice, Question: "q", Options: []string{"a", "b"}, Choices: []int{1}}, true},
+		{"choice options", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseChoice, Question: "q", Options: []string{"a"}, Choices: []int{0}}, false},
+		{"choice range", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseChoice, Question: "q", Options: []string{"a", "b"}, Choices: []int{2}}, false},
+		{"choice duplicate", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseChoice, Question: "q", Options: []string{"a", "b"}, Choices: []int{0, 0}}, false},
+		{"question", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseChoice, Options: []string{"a", "b"}, Choices: []int{0}}, false},
+		{"output", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseOutput, Question: "q", Language: "c", Code: "int main() {}"}, true},
+		{"output language", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseOutput, Question: "q", Language: "py", Code: "print(1)"}, false},
+		{"trace", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseTrace, Question: "q", Language: "c", Code: "int main() {}",
+			Breakpoints: []int{3}, Step: 2, Variable: "arr"}, true},
+		{"trace breakpoints", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseTrace, Question: "q", Language: "c", Code: "int main() {}",
+			Step: 2, Variable: "arr"}, false},
+		{"trace step", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseTrace, Question: "q", Language: "c", Code: "int main() {}",
+			Breakpoints: []int{3}, Variable: "arr"}, false},
+		{"trace variable", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseTrace, Question: "q", Language: "c", Code: "int main() {}",
+			Breakpoints: []int{3}, Step: 1, Variable: "arr[0]"}, false},
+		{"code", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseCode, Question: "q", Language: "go", Code: "package main",
+			Cases: []*dto.ExerciseCaseDto{{Input: "1", Output: "1"}}}, true},
+		{"code cases", &dto.VisualDocumentExerciseDto{Type: constants.ExerciseCode, Question: "q", Language: "go", Code: "package main"}, false},
+		{"type", &dto.VisualDocumentExerciseDto{Type: "essay", Question: "q"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateExercise(tt.exercise)
+			assert.Equal(t, tt.valid, err == nil, err)
+		})
+	}
+}
+
+func TestGradeChoices(t *testing.T) {
+	assert.True(t, gradeChoices([]int{0, 2}, []int{2, 0}))
+	assert.True(t, gradeChoices([]int{1}, []int{1, 1}))
+	assert.False(t, gradeChoices([]int{0, 2}, []int{0}))
+	assert.False(t, gradeChoices([]int{0}, []int{0, 1}))
+	assert.False(t, gradeChoices([]int{0}, nil))
+}
+
+func TestGradeTraceAnswer(t *testing.T) {
+	assert.True(t, gradeTraceAnswer("[1, 2, 3]", "1 2 3"))
+	assert.True(t, gradeTraceAnswer("[1, 2, 3]", "{1,2,3}"))
+	assert.True(t, gradeTraceAnswer("5", " 5 "))
+	assert.False(t, gradeTraceAnswer("[1, 2, 3]", "[1, 3, 2]"))
+	assert.False(t, gradeTraceAnswer("[1, 2, 3]", "[1, 2]"))
+	assert.False(t, gradeTraceAnswer("", ""))
+}
+
+func TestPrepareExercise_Trace(t *testing.T) {
+	service := &visualDocumentExerciseService{}
+	exercise := &dto.VisualDocumentExerciseDto{
+		DocumentID:  1,
+		Type:        constants.ExerciseTrace,
+		Question:    "第3步时arr的值是什么",
+		Options:     []string{"ignored"},
+		Answer:      "作者填写的答案会被覆盖",
+		Language:    "c",
+		Code:        "int main() {}",
+		Breakpoints: []int{4},
+		Step:        3,
+		Variable:    "arr",
+	}
+	// 答案由调试器得到
+	service.trace = func(ctx context.Context, e *dto.VisualDocumentExerciseDto) (string, error) {
+		assert.Equal(t, 3, e.Step)
+		return "[3, 1, 2]", nil
+	}
+	p, err := service.prepareExercise(context.Background(), exercise)
+	assert.Nil(t, err)
+	assert.Equal(t, "[3, 1, 2]", p.Answer)
+	assert.Equal(t, "[4]", p.Breakpoints)
+	assert.Equal(t, "arr", p.Variable)
+	// 与类型无关的字段不保存
+	assert.Equal(t, "", p.Options)
+
+	service.trace = func(ctx context.Context, e *dto.VisualDocumentExerciseDto) (string, error) {
+		return "", errors.New("程序在第3步之前已经结束")
+	}
+	_, err = service.prepareExercise(context.Background(), exercise)
+	assert.NotNil(t, err)
+}
+
+func TestNewExercisePo_Choice(t *testing.T) {
+	p := newExercisePo(&dto.VisualDocumentExerciseDto{
+		Type:     constants.ExerciseChoice,
+		Question: "q",
+		Options:  []string{"a", "b", "c"},
+		Choices:  []int{2, 0},
+		Code:     "ignored",
+	})
+	assert.Equal(t, "[0,2]", p.Answer)
+	assert.Equal(t, "", p.Code)
+
+	exercise := dto.NewVisualDocumentExerciseDto(p)
+	assert.Equal(t, []int{0, 2}, exercise.Choices)
+	assert.Equal(t, "", exercise.Answer)
+	exercise = hideExerciseAnswer(exercise)
+	assert.Nil(t, exercise.Choices)
+	assert.Equal(t, []string{"a", "b", "c"}, exercise.Options)
+}
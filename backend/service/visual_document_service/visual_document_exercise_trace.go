@@ -0,0 +1,114 @@
+package visual_document_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/service/visual_debug_servcie/debug_core"
+)
+
+// exerciseTraceTimeout 调试器运行变量追踪代码的超时时间，包括编译时间
+const exerciseTraceTimeout = 60 * time.Second
+
+// traceVariable 使用调试器运行代码，程序在断点处第一次暂停记为第1步，之后每单步执行一次加一步
+// 返回第exercise.Step步时变量的值，数组返回所有元素，例如[1, 2, 3]
+func (v *visualDocumentExerciseService) traceVariable(ctx context.Context, exercise *dto.VisualDocumentExerciseDto) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, exerciseTraceTimeout)
+	defer cancel()
+	events := make(chan interface{}, 16)
+	debugger := debug_core.NewDebugger()
+	err := debugger.Start(ctx, &debug_core.Option{
+		Language:      constants.LanguageType(exercise.Language),
+		Code:          exercise.Code,
+		BreakPoints:   exercise.Breakpoints,
+		TempDir:       v.config.FilePathConfig.TempDir,
+		DebuggerImage: v.config.DebuggerImage,
+		// 返回以后不再接收事件，避免阻塞调试器
+		Callback: func(data interface{}) {
+			select {
+			case events <- data:
+			case <-ctx.Done():
+			}
+		},
+		CompileTimeout: 30 * time.Second,
+		OptionTimeout:  2 * time.Second,
+		DebugTimeout:   exerciseTraceTimeout,
+		MemoryLimit:    1024 * 1024 * 1024,
+		CPUQuota:       5 * 60 * 1000000,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := debugger.Terminate(context.Background()); err != nil {
+			logger.WithCtx(ctx).Warnf("[traceVariable] terminate fail, err = %v", err)
+		}
+	}()
+	if exercise.Stdin != "" {
+		if err = debugger.Send(ctx, exercise.Stdin); err != nil {
+			return "", err
+		}
+	}
+	for step := 0; ; {
+		select {
+		case <-ctx.Done():
+			return "", errors.New("运行超时")
+		case event := <-events:
+			switch ev := event.(type) {
+			case *debug_core.CompileEvent:
+				if !ev.Success {
+					return "", fmt.Errorf("编译失败: %s", ev.Message)
+				}
+			case *debug_core.StoppedEvent:
+				step++
+				if step == exercise.Step {
+					return readTraceVariable(ctx, debugger, exercise.Variable)
+				}
+				if err = debugger.StepOver(ctx); err != nil {
+					return "", err
+				}
+			case *debug_core.ExitedEvent, *debug_core.TerminalEvent:
+				return "", fmt.Errorf("程序在第%d步之前已经结束", exercise.Step)
+			}
+		}
+	}
+}
+
+// readTraceVariable 读取栈顶中变量的值，数组读取所有元素
+func readTraceVariable(ctx context.Context, debugger debug_core.Debugger, name string) (string, error) {
+	stack, err := debugger.GetStackTrace(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(stack) == 0 {
+		return "", errors.New("程序没有暂停")
+	}
+	variables, err := debugger.GetFrameVariables(ctx, stack[0].ID)
+	if err != nil {
+		return "", err
+	}
+	for _, variable := range variables {
+		if variable.Name != name {
+			continue
+		}
+		if variable.IndexedVariables == 0 && variable.Reference == 0 {
+			return variable.Value, nil
+		}
+		data, err := debugger.ArrayVisual(ctx, &debug_core.ArrayVisualQuery{ArrayName: name})
+		if err != nil || len(data.Array) == 0 {
+			return variable.Value, nil
+		}
+		values := make([]string, 0, len(data.Array))
+		for _, element := range data.Array {
+			values = append(values, element.Value)
+		}
+		return "[" + strings.Join(values, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("变量%s不存在", name)
+}
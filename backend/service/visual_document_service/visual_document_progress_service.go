@@ -0,0 +1,281 @@
+package visual_document_service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"gorm.io/gorm"
+)
+
+// VisualDocumentProgressService 用户的学习进度
+type VisualDocumentProgressService interface {
+	// ViewDocument 记录用户阅读了文档
+	ViewDocument(ctx context.Context, userID uint, documentID uint) error
+	// CompleteDocument 标记用户完成了文档
+	CompleteDocument(ctx context.Context, userID uint, documentID uint) error
+	// GetBankProgress 获取当前用户在知识库中的学习进度
+	GetBankProgress(ctx context.Context, bankID uint) (*dto.BankProgressDto, error)
+	// GetContinueLearning 获取当前用户继续学习的位置，没有学习记录时返回nil
+	GetContinueLearning(ctx context.Context) (*dto.ContinueLearningDto, error)
+	// GetBankFunnel 获取知识库的学习漏斗
+	GetBankFunnel(ctx context.Context, bankID uint) (*dto.BankFunnelDto, error)
+}
+
+type visualDocumentProgressService struct {
+	progressDao       dao.VisualDocumentProgressDao
+	visualDocumentDao dao.VisualDocumentDao
+	// permission 检查文档所在的知识库是否启用，学习漏斗只有知识库的协作者可以查看
+	permission *bankPermission
+}
+
+func NewVisualDocumentProgressService(progressDao dao.VisualDocumentProgressDao, documentDao dao.VisualDocumentDao,
+	bankDao dao.VisualDocumentBankDao, memberDao dao.VisualDocumentBankMemberDao, sysUserDao dao.SysUserDao) VisualDocumentProgressService {
+	return &visualDocumentProgressService{
+		progressDao:       progressDao,
+		visualDocumentDao: documentDao,
+		permission:        newBankPermission(bankDao, memberDao, documentDao, sysUserDao),
+	}
+}
+
+func (v *visualDocumentProgressService) ViewDocument(ctx context.Context, userID uint, documentID uint) error {
+	if userID == 0 {
+		return e.ErrPermissionInvalid
+	}
+	document, err := v.permission.getEnabledDocument(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if err = v.progressDao.ViewDocument(common.Mysql, userID, documentID, document.BankID, time.Now()); err != nil {
+		logger.WithCtx(ctx).Errorf("[ViewDocument] ViewDocument fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (v *visualDocumentProgressService) CompleteDocument(ctx context.Context, userID uint, documentID uint) error {
+	// 游客不记录进度
+	if userID == 0 {
+		return e.ErrPermissionInvalid
+	}
+	document, err := v.permission.getEnabledDocument(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if err = v.progressDao.CompleteDocument(common.Mysql, userID, documentID, document.BankID, time.Now()); err != nil {
+		logger.WithCtx(ctx).Errorf("[CompleteDocument] CompleteDocument fail, err = %v", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (v *visualDocumentProgressService) GetBankProgress(ctx context.Context, bankID uint) (*dto.BankProgressDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	documents, err := v.visualDocumentDao.GetAllSimpleDocument(common.Mysql, bankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetBankProgress] GetAllSimpleDocument fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	progressMap, err := v.getProgressMap(ctx, userID, bankID)
+	if err != nil {
+		return nil, err
+	}
+	answer := &dto.BankProgressDto{
+		BankID:    bankID,
+		Documents: []*dto.DocumentProgressDto{},
+	}
+	for _, document := range readingOrder(documents) {
+		answer.Total++
+		progress, ok := progressMap[document.ID]
+		if !ok {
+			continue
+		}
+		answer.Viewed++
+		if progress.Status == constants.DocumentProgressCompleted {
+			answer.Completed++
+		}
+		answer.Documents = append(answer.Documents, dto.NewDocumentProgressDto(progress))
+	}
+	if answer.Total != 0 {
+		answer.Percent = answer.Completed * 100 / answer.Total
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentProgressService) GetContinueLearning(ctx context.Context) (*dto.ContinueLearningDto, error) {
+	userID := utils.GetUserIDWithCtx(ctx)
+	last, err := v.progressDao.GetLastViewedProgress(common.Mysql, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetContinueLearning] GetLastViewedProgress fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	documents, err := v.visualDocumentDao.GetAllSimpleDocument(common.Mysql, last.BankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetContinueLearning] GetAllSimpleDocument fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	progressMap, err := v.getProgressMap(ctx, userID, last.BankID)
+	if err != nil {
+		return nil, err
+	}
+	order := readingOrder(documents)
+	document, completed := nextDocument(order, progressMap, last.DocumentID)
+	if document == nil {
+		return nil, nil
+	}
+	answer := &dto.ContinueLearningDto{
+		BankID:        last.BankID,
+		DocumentID:    document.ID,
+		Title:         document.Title,
+		BankCompleted: completed,
+	}
+	if progress, ok := progressMap[document.ID]; ok {
+		answer.Status = progress.Status
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentProgressService) GetBankFunnel(ctx context.Context, bankID uint) (*dto.BankFunnelDto, error) {
+	if err := v.permission.checkBankRole(ctx, bankID, constants.BankRoleViewer); err != nil {
+		return nil, err
+	}
+	documents, err := v.visualDocumentDao.GetAllSimpleDocument(common.Mysql, bankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetBankFunnel] GetAllSimpleDocument fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	stats, err := v.progressDao.GetDocumentProgressStats(common.Mysql, bankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetBankFunnel] GetDocumentProgressStats fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	learners, err := v.progressDao.GetBankLearnerCount(common.Mysql, bankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetBankFunnel] GetBankLearnerCount fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	statMap := make(map[uint]*dao.DocumentProgressStat, len(stats))
+	for _, stat := range stats {
+		statMap[stat.DocumentID] = stat
+	}
+	answer := &dto.BankFunnelDto{
+		BankID:    bankID,
+		Learners:  learners,
+		Documents: []*dto.DocumentFunnelDto{},
+	}
+	for _, document := range readingOrder(documents) {
+		funnel := &dto.DocumentFunnelDto{
+			DocumentID: document.ID,
+			ParentID:   document.ParentID,
+			Title:      document.Title,
+		}
+		if stat, ok := statMap[document.ID]; ok {
+			funnel.Viewed = stat.Viewed
+			funnel.Completed = stat.Completed
+		}
+		funnel.ViewedRate = rate(funnel.Viewed, learners)
+		funnel.CompletedRate = rate(funnel.Completed, learners)
+		answer.Documents = append(answer.Documents, funnel)
+	}
+	return answer, nil
+}
+
+// getProgressMap 获取用户在知识库中的进度，key为文档id
+func (v *visualDocumentProgressService) getProgressMap(ctx context.Context, userID uint,
+	bankID uint) (map[uint]*po.VisualDocumentProgress, error) {
+	progressList, err := v.progressDao.GetProgressListByBankID(common.Mysql, userID, bankID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getProgressMap] GetProgressListByBankID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	progressMap := make(map[uint]*po.VisualDocumentProgress, len(progressList))
+	for _, progress := range progressList {
+		progressMap[progress.DocumentID] = progress
+	}
+	return progressMap, nil
+}
+
+// readingOrder 按目录的顺序返回用户可以看到的文档，未启用的文档和它的子文档不可见
+func readingOrder(documents []*po.VisualDocument) []*po.VisualDocument {
+	children := make(map[uint][]*po.VisualDocument, len(documents))
+	for _, document := range documents {
+		if document.Enable {
+			children[document.ParentID] = append(children[document.ParentID], document)
+		}
+	}
+	answer := make([]*po.VisualDocument, 0, len(documents))
+	visited := make(map[uint]bool, len(documents))
+	var walk func(parentID uint)
+	walk = func(parentID uint) {
+		list := children[parentID]
+		// 与目录一致，order大的排在前面
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Order > list[j].Order
+		})
+		for _, document := range list {
+			if visited[document.ID] {
+				continue
+			}
+			visited[document.ID] = true
+			answer = append(answer, document)
+			walk(document.ID)
+		}
+	}
+	walk(0)
+	return answer
+}
+
+// nextDocument 获取继续学习的文档，最后阅读的文档未完成时继续学习该文档，否则学习之后第一篇未完成的文档
+// 所有文档都已完成时返回最后阅读的文档，并且completed为true
+func nextDocument(order []*po.VisualDocument, progressMap map[uint]*po.VisualDocumentProgress,
+	lastDocumentID uint) (*po.VisualDocument, bool) {
+	if len(order) == 0 {
+		return nil, false
+	}
+	isCompleted := func(document *po.VisualDocument) bool {
+		progress, ok := progressMap[document.ID]
+		return ok && progress.Status == constants.DocumentProgressCompleted
+	}
+	// 最后阅读的文档不可见时从头开始
+	start := -1
+	for i, document := range order {
+		if document.ID == lastDocumentID {
+			start = i
+			break
+		}
+	}
+	if start >= 0 && !isCompleted(order[start]) {
+		return order[start], false
+	}
+	for i := 1; i <= len(order); i++ {
+		document := order[(start+i+len(order))%len(order)]
+		if !isCompleted(document) {
+			return document, false
+		}
+	}
+	if start >= 0 {
+		return order[start], true
+	}
+	return order[0], true
+}
+
+// rate 计算比例，保留4位小数
+func rate(count int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)/float64(total)*10000) / 10000
+}
@@ -0,0 +1,85 @@
+package visual_document_service
+
+import (
+	"testing"
+
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func progressTestDocument(id uint, parentID uint, order uint, enable bool) *po.VisualDocument {
+	return &po.VisualDocument{Model: gorm.Model{ID: id}, ParentID: parentID, Order: order, Enable: enable}
+}
+
+func documentIDs(documents []*po.VisualDocument) []uint {
+	ids := make([]uint, 0, len(documents))
+	for _, d := range documents {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+func TestReadingOrder(t *testing.T) {
+	documents := []*po.VisualDocument{
+		progressTestDocument(1, 0, 1, true),
+		progressTestDocument(2, 0, 2, true),
+		progressTestDocument(3, 1, 1, true),
+		progressTestDocument(4, 1, 2, true),
+		// 未启用的文档和它的子文档不可见
+		progressTestDocument(5, 0, 3, false),
+		progressTestDocument(6, 5, 1, true),
+		// 父文档不存在
+		progressTestDocument(7, 100, 1, true),
+	}
+	assert.Equal(t, []uint{2, 1, 4, 3}, documentIDs(readingOrder(documents)))
+}
+
+func TestNextDocument(t *testing.T) {
+	order := []*po.VisualDocument{
+		progressTestDocument(1, 0, 0, true),
+		progressTestDocument(2, 0, 0, true),
+		progressTestDocument(3, 0, 0, true),
+	}
+	progress := func(status ...int) map[uint]*po.VisualDocumentProgress {
+		answer := map[uint]*po.VisualDocumentProgress{}
+		for i, s := range status {
+			if s != 0 {
+				answer[uint(i+1)] = &po.VisualDocumentProgress{DocumentID: uint(i + 1), Status: s}
+			}
+		}
+		return answer
+	}
+	viewed, completed := constants.DocumentProgressViewed, constants.DocumentProgressCompleted
+
+	// 最后阅读的文档未完成
+	document, done := nextDocument(order, progress(completed, viewed), 2)
+	assert.Equal(t, uint(2), document.ID)
+	assert.False(t, done)
+	// 最后阅读的文档已完成，继续后面未完成的文档
+	document, done = nextDocument(order, progress(0, completed), 2)
+	assert.Equal(t, uint(3), document.ID)
+	assert.False(t, done)
+	// 后面的文档都已完成时从头查找
+	document, done = nextDocument(order, progress(viewed, completed, completed), 3)
+	assert.Equal(t, uint(1), document.ID)
+	assert.False(t, done)
+	// 最后阅读的文档不可见时从头开始
+	document, done = nextDocument(order, progress(completed), 100)
+	assert.Equal(t, uint(2), document.ID)
+	assert.False(t, done)
+	// 全部完成
+	document, done = nextDocument(order, progress(completed, completed, completed), 2)
+	assert.Equal(t, uint(2), document.ID)
+	assert.True(t, done)
+
+	document, _ = nextDocument(nil, progress(), 1)
+	assert.Nil(t, document)
+}
+
+func TestRate(t *testing.T) {
+	assert.Equal(t, 0.0, rate(1, 0))
+	assert.Equal(t, 0.3333, rate(1, 3))
+	assert.Equal(t, 1.0, rate(2, 2))
+}
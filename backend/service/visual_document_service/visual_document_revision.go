@@ -0,0 +1,325 @@
+package visual_document_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/utils"
+	"github.com/pmezard/go-difflib/difflib"
+	"gorm.io/gorm"
+)
+
+func (v *visualDocumentService) GetVisualDocumentRevisionList(ctx context.Context, documentID uint) ([]*dto.VisualDocumentRevisionDto, error) {
+	document, err := v.permission.checkDocumentRole(ctx, documentID, constants.BankRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := v.revisionDao.GetRevisionListByDocumentID(common.Mysql, documentID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetVisualDocumentRevisionList] GetRevisionListByDocumentID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.VisualDocumentRevisionDto, 0, len(revisions))
+	for _, revision := range revisions {
+		revisionDto := dto.NewVisualDocumentRevisionDto(revision)
+		revisionDto.Published = revision.ID == document.PublishedRevisionID
+		revisionDto.AuthorName, _ = v.sysUserDao.GetUserNameByID(common.Mysql, revision.AuthorID)
+		answer = append(answer, revisionDto)
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentService) GetVisualDocumentRevision(ctx context.Context, revisionID uint) (*dto.VisualDocumentRevisionDetailDto, error) {
+	revision, err := v.getRevision(ctx, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	document, err := v.permission.checkDocumentRole(ctx, revision.DocumentID, constants.BankRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	answer := &dto.VisualDocumentRevisionDetailDto{
+		VisualDocumentRevisionDto: *dto.NewVisualDocumentRevisionDto(revision),
+		Content:                   revision.Content,
+		CodeList:                  parseRevisionCodeList(revision.CodeList),
+		Snippets:                  parseRevisionSnippets(revision.Snippets),
+	}
+	answer.Published = document.PublishedRevisionID == revision.ID
+	answer.AuthorName, _ = v.sysUserDao.GetUserNameByID(common.Mysql, revision.AuthorID)
+	return answer, nil
+}
+
+func (v *visualDocumentService) DiffVisualDocumentRevision(ctx context.Context, fromRevisionID uint, toRevisionID uint) (*dto.VisualDocumentRevisionDiffDto, error) {
+	from, err := v.getRevision(ctx, fromRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := v.getRevision(ctx, toRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	if from.DocumentID != to.DocumentID {
+		return nil, e.NewParamErr("只能比较同一篇文档的版本")
+	}
+	if _, err = v.permission.checkDocumentRole(ctx, from.DocumentID, constants.BankRoleViewer); err != nil {
+		return nil, err
+	}
+	fromName, toName := fmt.Sprintf("v%d", from.Version), fmt.Sprintf("v%d", to.Version)
+	answer := &dto.VisualDocumentRevisionDiffDto{
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Content:     unifiedDiff(from.Content, to.Content, fromName, toName),
+		CodeList:    []*dto.VisualDocumentCodeDiffDto{},
+		Snippets:    diffSnippets(from.Snippets, to.Snippets),
+	}
+
+	// 按语言比较代码，某个版本中没有的语言当做空代码
+	fromCodes, toCodes := codeMapByLanguage(from.CodeList), codeMapByLanguage(to.CodeList)
+	languages := make([]string, 0, len(fromCodes)+len(toCodes))
+	for language := range fromCodes {
+		languages = append(languages, language)
+	}
+	for language := range toCodes {
+		if _, ok := fromCodes[language]; !ok {
+			languages = append(languages, language)
+		}
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		fromCode, toCode := codeOrEmpty(fromCodes, language), codeOrEmpty(toCodes, language)
+		codeDiff := &dto.VisualDocumentCodeDiffDto{
+			Language:                 language,
+			Code:                     unifiedDiff(fromCode.Code, toCode.Code, fromName, toName),
+			BreakpointsChanged:       fmt.Sprint(fromCode.Breakpoints) != fmt.Sprint(toCode.Breakpoints),
+			VisualDescriptionChanged: dto.FormatVisualDescription(fromCode.VisualDescription) != dto.FormatVisualDescription(toCode.VisualDescription),
+		}
+		if codeDiff.Code != "" || codeDiff.BreakpointsChanged || codeDiff.VisualDescriptionChanged {
+			answer.CodeList = append(answer.CodeList, codeDiff)
+		}
+	}
+	return answer, nil
+}
+
+func (v *visualDocumentService) RestoreVisualDocumentRevision(ctx context.Context, revisionID uint) (*dto.VisualDocumentRevisionDto, error) {
+	revision, err := v.getRevision(ctx, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	document, err := v.permission.checkDocumentRole(ctx, revision.DocumentID, constants.BankRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	// 恢复的内容作为草稿保存，并生成新的版本，不会删除之后的版本
+	codeList := parseRevisionCodeList(revision.CodeList)
+	snippets := parseRevisionSnippets(revision.Snippets)
+	var newRevision *po.VisualDocumentRevision
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := v.visualDocumentDao.UpdateVisualDocumentContent(tx, revision.DocumentID, revision.Content); err != nil {
+			return err
+		}
+		if err := replaceCodeList(tx, v.visualDocumentDao, revision.DocumentID, codeList); err != nil {
+			return err
+		}
+		if err := replaceSnippets(tx, v.snippetDao, revision.DocumentID, snippets); err != nil {
+			return err
+		}
+		var err error
+		newRevision, err = saveRevision(tx, v.visualDocumentDao, v.revisionDao, revision.DocumentID, revision.Content, codeList, snippets, utils.GetUserIDWithCtx(ctx))
+		return err
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[RestoreVisualDocumentRevision] restore revision fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	v.searchService.IndexDocument(ctx, document.BankID, revision.DocumentID)
+	return dto.NewVisualDocumentRevisionDto(newRevision), nil
+}
+
+func (v *visualDocumentService) PublishVisualDocument(ctx context.Context, documentID uint, revisionID uint) error {
+	document, err := v.permission.checkDocumentRole(ctx, documentID, constants.BankRoleEditor)
+	if err != nil {
+		return err
+	}
+	if revisionID != 0 {
+		revision, err := v.getRevision(ctx, revisionID)
+		if err != nil {
+			return err
+		}
+		if revision.DocumentID != documentID {
+			return e.NewParamErr("版本不属于该文档")
+		}
+	}
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		if revisionID == 0 {
+			// 发布当前的草稿，草稿没有变化时就是最新的版本
+			codes, err := v.visualDocumentDao.GetCodeListByDocumentID(tx, documentID)
+			if err != nil {
+				return err
+			}
+			codeList := make([]*dto.VisualDocumentCodeDto, 0, len(codes))
+			for _, code := range codes {
+				codeList = append(codeList, dto.NewVisualDocumentCodeDto(code))
+			}
+			snippets, err := getDraftSnippets(tx, v.snippetDao, documentID)
+			if err != nil {
+				return err
+			}
+			revision, err := saveRevision(tx, v.visualDocumentDao, v.revisionDao, documentID, document.Content, codeList, snippets, utils.GetUserIDWithCtx(ctx))
+			if err != nil {
+				return err
+			}
+			revisionID = revision.ID
+		}
+		return v.visualDocumentDao.PublishVisualDocument(tx, documentID, revisionID)
+	})
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[PublishVisualDocument] publish fail, err = %v", err)
+		return e.ErrMysql
+	}
+	v.searchService.IndexDocument(ctx, document.BankID, documentID)
+	return nil
+}
+
+// getRevision 获取版本，不存在时返回RecordNotFound错误
+func (v *visualDocumentService) getRevision(ctx context.Context, revisionID uint) (*po.VisualDocumentRevision, error) {
+	revision, err := v.revisionDao.GetRevisionByID(common.Mysql, revisionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("revision not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[getRevision] GetRevisionByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	return revision, nil
+}
+
+// saveRevision 保存文档的新版本，内容与最新版本相同时不生成新版本，直接返回最新版本
+// 版本号由最新版本加1得到，需要先锁定文档，同一篇文档并发保存时依次分配版本号
+func saveRevision(tx *gorm.DB, documentDao dao.VisualDocumentDao, revisionDao dao.VisualDocumentRevisionDao, documentID uint,
+	content string, codeList []*dto.VisualDocumentCodeDto, snippetList []*dto.VisualDocumentSnippetDto, authorID uint) (*po.VisualDocumentRevision, error) {
+	codes, err := formatRevisionCodeList(codeList)
+	if err != nil {
+		return nil, err
+	}
+	snippets, err := formatRevisionSnippets(snippetList)
+	if err != nil {
+		return nil, err
+	}
+	if err = documentDao.LockVisualDocument(tx, documentID); err != nil {
+		return nil, err
+	}
+	var version uint = 1
+	latest, err := revisionDao.GetLatestRevision(tx, documentID)
+	if err == nil {
+		// 代码示例功能之前的版本没有保存示例
+		if latest.Content == content && latest.CodeList == codes && (latest.Snippets == snippets ||
+			(latest.Snippets == "" && len(snippetList) == 0)) {
+			return latest, nil
+		}
+		version = latest.Version + 1
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	revision := &po.VisualDocumentRevision{
+		DocumentID: documentID,
+		Version:    version,
+		Content:    content,
+		CodeList:   codes,
+		Snippets:   snippets,
+		AuthorID:   authorID,
+	}
+	if err = revisionDao.InsertRevision(tx, revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// replaceCodeList 使用新的代码列表替换文档的代码
+func replaceCodeList(tx *gorm.DB, documentDao dao.VisualDocumentDao, documentID uint, codeList []*dto.VisualDocumentCodeDto) error {
+	if err := documentDao.DeleteVisualDocumentCodeByDocumentID(tx, documentID); err != nil {
+		return err
+	}
+	for _, code := range codeList {
+		bps, err := json.Marshal(code.Breakpoints)
+		if err != nil {
+			return err
+		}
+		err = documentDao.InsertVisualDocumentCode(tx, &po.VisualDocumentCode{
+			DocumentID:        documentID,
+			Code:              code.Code,
+			Language:          code.Language,
+			Breakpoints:       string(bps),
+			VisualDescription: dto.FormatVisualDescription(code.VisualDescription),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatRevisionCodeList 将代码列表转换为保存在版本中的json，空断点统一为空数组，便于比较内容是否变化
+func formatRevisionCodeList(codeList []*dto.VisualDocumentCodeDto) (string, error) {
+	codes := make([]*dto.VisualDocumentCodeDto, 0, len(codeList))
+	for _, code := range codeList {
+		c := *code
+		if c.Breakpoints == nil {
+			c.Breakpoints = []int{}
+		}
+		codes = append(codes, &c)
+	}
+	data, err := json.Marshal(codes)
+	return string(data), err
+}
+
+// parseRevisionCodeList 解析版本中的代码列表，格式错误时当做没有代码
+func parseRevisionCodeList(codeList string) []*dto.VisualDocumentCodeDto {
+	codes := []*dto.VisualDocumentCodeDto{}
+	if err := json.Unmarshal([]byte(codeList), &codes); err != nil {
+		return []*dto.VisualDocumentCodeDto{}
+	}
+	return codes
+}
+
+// codeMapByLanguage 将版本中的代码按语言分组
+func codeMapByLanguage(codeList string) map[string]*dto.VisualDocumentCodeDto {
+	codes := parseRevisionCodeList(codeList)
+	answer := make(map[string]*dto.VisualDocumentCodeDto, len(codes))
+	for _, code := range codes {
+		answer[code.Language] = code
+	}
+	return answer
+}
+
+// codeOrEmpty 获取指定语言的代码，不存在时返回空代码
+func codeOrEmpty(codes map[string]*dto.VisualDocumentCodeDto, language string) *dto.VisualDocumentCodeDto {
+	if code, ok := codes[language]; ok {
+		return code
+	}
+	return &dto.VisualDocumentCodeDto{Language: language, Breakpoints: []int{}}
+}
+
+// unifiedDiff 生成unified diff格式的差异，没有差异时返回空字符串
+func unifiedDiff(from string, to string, fromName string, toName string) string {
+	if from == to {
+		return ""
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	return diff
+}
@@ -0,0 +1,32 @@
+package visual_document_service
+
+import (
+	"testing"
+
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	assert.Equal(t, "", unifiedDiff("a\nb\n", "a\nb\n", "v1", "v2"))
+	diff := unifiedDiff("a\nb\nc\n", "a\nB\nc\n", "v1", "v2")
+	assert.Contains(t, diff, "--- v1")
+	assert.Contains(t, diff, "+++ v2")
+	assert.Contains(t, diff, "-b\n")
+	assert.Contains(t, diff, "+B\n")
+}
+
+func TestFormatRevisionCodeList(t *testing.T) {
+	// 断点为空和空数组视为相同的内容
+	withNil, err := formatRevisionCodeList([]*dto.VisualDocumentCodeDto{{Language: "c", Code: "int main() {}"}})
+	assert.Nil(t, err)
+	withEmpty, err := formatRevisionCodeList([]*dto.VisualDocumentCodeDto{{Language: "c", Code: "int main() {}", Breakpoints: []int{}}})
+	assert.Nil(t, err)
+	assert.Equal(t, withNil, withEmpty)
+
+	codes := parseRevisionCodeList(withNil)
+	assert.Equal(t, 1, len(codes))
+	assert.Equal(t, "c", codes[0].Language)
+	assert.Equal(t, []int{}, codes[0].Breakpoints)
+	assert.Equal(t, 0, len(parseRevisionCodeList("broken")))
+}
@@ -2,18 +2,20 @@ package visual_document_service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"github.com/fansqz/fancode-backend/common"
 	conf "github.com/fansqz/fancode-backend/common/config"
 	e "github.com/fansqz/fancode-backend/common/error"
 	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
 	"github.com/fansqz/fancode-backend/dao"
 	"github.com/fansqz/fancode-backend/models/dto"
 	"github.com/fansqz/fancode-backend/models/po"
+	"github.com/fansqz/fancode-backend/service/search_service"
 	"github.com/fansqz/fancode-backend/utils"
 	"gorm.io/gorm"
 	"sort"
+	"time"
 )
 
 type VisualDocumentService interface {
@@ -25,28 +27,66 @@ type VisualDocumentService interface {
 	// InsertVisualDocument 添加可视化文档
 	InsertVisualDocument(ctx context.Context, document *po.VisualDocument) (uint, error)
 	// UpdateVisualDocument 更新可视化文档
-	UpdateVisualDocument(ctx context.Context, document *dto.VisualDocumentDto) error
+	// document.Version需要是读取文档时的版本，文档已被其他人修改时返回错误，成功时返回新的版本
+	UpdateVisualDocument(ctx context.Context, document *dto.VisualDocumentDto) (uint, error)
 	// UpdateVisualDocumentDirectory 更新可视化文档的目录结构
 	UpdateVisualDocumentDirectory(ctx context.Context, req *dto.UpdateVisualDocumentReq) error
 	// DeleteVisualDocumentByID 删除可视化文档
 	DeleteVisualDocumentByID(ctx context.Context, id uint) error
+
+	// GetVisualDocumentRevisionList 获取文档的所有版本
+	GetVisualDocumentRevisionList(ctx context.Context, documentID uint) ([]*dto.VisualDocumentRevisionDto, error)
+	// GetVisualDocumentRevision 获取某个版本的内容
+	GetVisualDocumentRevision(ctx context.Context, revisionID uint) (*dto.VisualDocumentRevisionDetailDto, error)
+	// DiffVisualDocumentRevision 比较同一篇文档的两个版本
+	DiffVisualDocumentRevision(ctx context.Context, fromRevisionID uint, toRevisionID uint) (*dto.VisualDocumentRevisionDiffDto, error)
+	// RestoreVisualDocumentRevision 将文档的草稿恢复为某个版本的内容，返回恢复后的版本
+	RestoreVisualDocumentRevision(ctx context.Context, revisionID uint) (*dto.VisualDocumentRevisionDto, error)
+	// PublishVisualDocument 发布文档的某个版本，revisionID为0时发布当前的草稿
+	PublishVisualDocument(ctx context.Context, documentID uint, revisionID uint) error
+
+	// GetVisualDocumentSnippet 获取用户可以运行的代码示例，文档已发布时返回已发布版本中的示例
+	GetVisualDocumentSnippet(ctx context.Context, snippetID uint) (*dto.VisualDocumentSnippetDto, error)
 }
 
-func NewVisualDocumentService(vdd dao.VisualDocumentDao, config *conf.AppConfig) VisualDocumentService {
+func NewVisualDocumentService(vdd dao.VisualDocumentDao, revisionDao dao.VisualDocumentRevisionDao,
+	snippetDao dao.VisualDocumentSnippetDao, exerciseDao dao.VisualDocumentExerciseDao, progressDao dao.VisualDocumentProgressDao, bankDao dao.VisualDocumentBankDao, memberDao dao.VisualDocumentBankMemberDao,
+	sysUserDao dao.SysUserDao, searchService search_service.SearchService, config *conf.AppConfig) VisualDocumentService {
 	return &visualDocumentService{
 		visualDocumentDao: vdd,
+		revisionDao:       revisionDao,
+		snippetDao:        snippetDao,
+		exerciseDao:       exerciseDao,
+		progressDao:       progressDao,
+		sysUserDao:        sysUserDao,
+		searchService:     searchService,
+		permission:        newBankPermission(bankDao, memberDao, vdd, sysUserDao),
 		config:            config,
 	}
 }
 
 type visualDocumentService struct {
 	visualDocumentDao dao.VisualDocumentDao
-	config            *conf.AppConfig
+	revisionDao       dao.VisualDocumentRevisionDao
+	snippetDao        dao.VisualDocumentSnippetDao
+	exerciseDao       dao.VisualDocumentExerciseDao
+	progressDao       dao.VisualDocumentProgressDao
+	sysUserDao        dao.SysUserDao
+	searchService     search_service.SearchService
+	// permission 管理端的操作需要校验用户在知识库中的角色
+	permission *bankPermission
+	config     *conf.AppConfig
 }
 
 func (v *visualDocumentService) GetVisualDocumentDirectory(ctx context.Context, bankID uint, enable *bool) ([]*dto.VisualDocumentDirectoryDto, error) {
 	var documentList []*po.VisualDocument
 	var err error
+	// 管理端需要是知识库的协作者
+	if enable == nil {
+		if err = v.permission.checkBankRole(ctx, bankID, constants.BankRoleViewer); err != nil {
+			return nil, err
+		}
+	}
 	if documentList, err = v.visualDocumentDao.GetAllSimpleDocument(common.Mysql, bankID); err != nil {
 		logger.WithCtx(ctx).Errorf("[GetMenuTree] get all menu error, err = %v", err)
 		return nil, e.ErrMysql
@@ -102,20 +142,66 @@ func (v *visualDocumentService) GetVisualDocumentByID(ctx context.Context, id ui
 	if enable != nil && document.Enable != *enable {
 		return nil, e.NewRecordNotFoundErr("document not exist")
 	}
+	if enable == nil {
+		if err = v.permission.checkBankRole(ctx, document.BankID, constants.BankRoleViewer); err != nil {
+			return nil, err
+		}
+	}
+	// 记录用户的阅读进度，失败不影响阅读
+	if userID := utils.GetUserIDWithCtx(ctx); enable != nil && userID != 0 {
+		if err = v.progressDao.ViewDocument(common.Mysql, userID, id, document.BankID, time.Now()); err != nil {
+			logger.WithCtx(ctx).Errorf("[GetVisualDocumentByID] ViewDocument fail, err = %v", err)
+		}
+	}
+	// 用户读取已发布的版本，作者正在编辑的草稿对用户不可见
+	if enable != nil && document.PublishedRevisionID != 0 {
+		revision, err := v.revisionDao.GetRevisionByID(common.Mysql, document.PublishedRevisionID)
+		if err != nil {
+			logger.WithCtx(ctx).Errorf("[GetVisualDocumentByID] GetRevisionByID fail, err = %v", err)
+			return nil, e.ErrMysql
+		}
+		answer := dto.NewVisualDocumentDto(document)
+		answer.Content = revision.Content
+		answer.CodeList = parseRevisionCodeList(revision.CodeList)
+		answer.Snippets = parseRevisionSnippets(revision.Snippets)
+		return answer, nil
+	}
 	// 获取可视化文档支持的所有语言
 	codeList, err := v.visualDocumentDao.GetCodeListByDocumentID(common.Mysql, id)
 	vcodes := make([]*dto.VisualDocumentCodeDto, 0, len(codeList))
 	for _, code := range codeList {
 		vcodes = append(vcodes, dto.NewVisualDocumentCodeDto(code))
 	}
+	snippets, err := getDraftSnippets(common.Mysql, v.snippetDao, id)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetVisualDocumentByID] getDraftSnippets fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
 	answer := dto.NewVisualDocumentDto(document)
 	answer.CodeList = vcodes
+	answer.Snippets = snippets
 	return answer, nil
 }
 
 func (v *visualDocumentService) InsertVisualDocument(ctx context.Context, document *po.VisualDocument) (uint, error) {
+	if err := v.permission.checkBankRole(ctx, document.BankID, constants.BankRoleEditor); err != nil {
+		return 0, err
+	}
+	// 父文档需要在同一个知识库中
+	if document.ParentID != 0 {
+		parent, err := v.permission.checkDocumentRole(ctx, document.ParentID, constants.BankRoleEditor)
+		if err != nil {
+			return 0, err
+		}
+		if parent.BankID != document.BankID {
+			return 0, e.NewParamErr("父文档不属于该知识库")
+		}
+	}
 	userID := utils.GetUserIDWithCtx(ctx)
+	document.Version = 0
 	document.CreatorID = userID
+	// 新文档需要通过发布生成版本
+	document.PublishedRevisionID = 0
 	documentList, err := v.visualDocumentDao.GetSimpleDocumentByParentID(common.Mysql, document.ParentID)
 	if err != nil {
 		logger.WithCtx(ctx).Errorf("[InsertVisualDocument] GetAllSimpleDocument fail, err = %v", err)
@@ -134,62 +220,95 @@ func (v *visualDocumentService) InsertVisualDocument(ctx context.Context, docume
 		logger.WithCtx(ctx).Errorf("[InsertVisualDocument] InsertVisualDocument fail, err = %v", err)
 		return 0, err
 	}
+	v.searchService.IndexDocument(ctx, document.BankID, document.ID)
 	return document.ID, nil
 }
 
-func (v *visualDocumentService) UpdateVisualDocument(ctx context.Context, document *dto.VisualDocumentDto) error {
-	err := common.Mysql.Transaction(func(tx *gorm.DB) error {
-		// 更新可视化文档
-		if err := v.visualDocumentDao.UpdateVisualDocument(common.Mysql, &po.VisualDocument{
+func (v *visualDocumentService) UpdateVisualDocument(ctx context.Context, document *dto.VisualDocumentDto) (uint, error) {
+	// 校验作者指定的可视化描述
+	for _, code := range document.CodeList {
+		if code.VisualDescription == nil {
+			continue
+		}
+		if err := code.VisualDescription.Validate(); err != nil {
+			return 0, e.NewError(e.CodeVisualDescriptionInvalid, err.Error(), e.ErrTypeBadReq)
+		}
+	}
+	if err := validateSnippets(document.Content, document.Snippets); err != nil {
+		return 0, err
+	}
+	stored, err := v.permission.checkDocumentRole(ctx, document.ID, constants.BankRoleEditor)
+	if err != nil {
+		return 0, err
+	}
+	userID := utils.GetUserIDWithCtx(ctx)
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
+		// 更新可视化文档，文档本身的内容作为草稿，已发布的版本不受影响
+		// 文档的位置只能通过目录接口修改，目录接口会校验目标位置的权限
+		updated, err := v.visualDocumentDao.UpdateVisualDocumentWithVersion(tx, &po.VisualDocument{
 			Model: gorm.Model{
 				ID: document.ID,
 			},
-			ParentID: document.ParentID,
-			Title:    document.Title,
-			Content:  document.Content,
-			Enable:   document.Enable,
-		}); err != nil {
+			Title:   document.Title,
+			Content: document.Content,
+			Enable:  document.Enable,
+			Version: document.Version,
+		})
+		if err != nil {
 			logger.WithCtx(ctx).Errorf("[UpdateVisualDocument] UpdateVisualDocument fail, err = %v", err)
 			return err
 		}
-		// 删除code
-		if err := v.visualDocumentDao.DeleteVisualDocumentCodeByDocumentID(tx, document.ID); err != nil {
+		// 版本不一致说明保存前文档已经被其他人修改
+		if !updated {
+			return e.ErrVisualDocumentVersionConflict
+		}
+		if err := replaceCodeList(tx, v.visualDocumentDao, document.ID, document.CodeList); err != nil {
 			return err
 		}
-		// 插入code
-		for _, code := range document.CodeList {
-			bps, err := json.Marshal(code.Breakpoints)
-			if err != nil {
-				return err
-			}
-			err = v.visualDocumentDao.InsertVisualDocumentCode(tx, &po.VisualDocumentCode{
-				DocumentID:  document.ID,
-				Code:        code.Code,
-				Language:    code.Language,
-				Breakpoints: string(bps),
-			})
-			if err != nil {
-				return err
-			}
+		if err := replaceSnippets(tx, v.snippetDao, document.ID, document.Snippets); err != nil {
+			return err
 		}
-		return nil
+		// 每次保存生成一个版本
+		_, err = saveRevision(tx, v.visualDocumentDao, v.revisionDao, document.ID, document.Content, document.CodeList, document.Snippets, userID)
+		return err
 	})
+	if errors.Is(err, e.ErrVisualDocumentVersionConflict) {
+		return 0, e.ErrVisualDocumentVersionConflict
+	}
 	if err != nil {
-		logger.WithCtx(ctx).Errorf("[DeleteApiByID] delete api error, err = %v", err)
-		return e.ErrMysql
+		logger.WithCtx(ctx).Errorf("[UpdateVisualDocument] update document error, err = %v", err)
+		return 0, e.ErrMysql
 	}
-	return nil
+	v.searchService.IndexDocument(ctx, stored.BankID, document.ID)
+	return document.Version + 1, nil
 
 }
 
 func (v *visualDocumentService) UpdateVisualDocumentDirectory(ctx context.Context, req *dto.UpdateVisualDocumentReq) error {
+	dragging, err := v.permission.checkDocumentRole(ctx, req.DraggingDocumentID, constants.BankRoleEditor)
+	if err != nil {
+		return err
+	}
+	// 只能在同一个知识库中移动
+	if req.DragDocumentID != 0 {
+		drag, err := v.permission.checkDocumentRole(ctx, req.DragDocumentID, constants.BankRoleEditor)
+		if err != nil {
+			return err
+		}
+		if dragging.BankID != drag.BankID {
+			return e.NewParamErr("不能将文档移动到其他知识库")
+		}
+	}
 	if req.EventType == "inner" {
-		return v.innerDocument(ctx, req.DraggingDocumentID, req.DragDocumentID)
+		err = v.innerDocument(ctx, req.DraggingDocumentID, req.DragDocumentID)
 	} else if req.EventType == "before" {
-		return v.afterOrBeforeDocument(ctx, req.DraggingDocumentID, req.DragDocumentID, false)
+		err = v.afterOrBeforeDocument(ctx, req.DraggingDocumentID, req.DragDocumentID, false)
 	} else {
-		return v.afterOrBeforeDocument(ctx, req.DraggingDocumentID, req.DragDocumentID, true)
+		err = v.afterOrBeforeDocument(ctx, req.DraggingDocumentID, req.DragDocumentID, true)
 	}
+	// 移动到未启用的文档下面时，文档对用户不可见
+	v.searchService.IndexDocument(ctx, dragging.BankID, req.DraggingDocumentID)
+	return err
 }
 
 func (v *visualDocumentService) innerDocument(ctx context.Context, draggingDocumentID uint, dragDocumentID uint) error {
@@ -260,11 +379,32 @@ func (v *visualDocumentService) afterOrBeforeDocument(ctx context.Context, dragg
 }
 
 func (v *visualDocumentService) DeleteVisualDocumentByID(ctx context.Context, id uint) error {
-	err := common.Mysql.Transaction(func(tx *gorm.DB) error {
+	// 删除后需要更新文档所在知识库的索引
+	document, err := v.permission.checkDocumentRole(ctx, id, constants.BankRoleEditor)
+	if err != nil {
+		return err
+	}
+	bankID := document.BankID
+	err = common.Mysql.Transaction(func(tx *gorm.DB) error {
 		// 删除code
 		if err := v.visualDocumentDao.DeleteVisualDocumentCodeByDocumentID(tx, id); err != nil {
 			return err
 		}
+		if err := v.snippetDao.DeleteSnippetByDocumentID(tx, id); err != nil {
+			return err
+		}
+		if err := v.exerciseDao.DeleteResultByDocumentID(tx, id); err != nil {
+			return err
+		}
+		if err := v.exerciseDao.DeleteExerciseByDocumentID(tx, id); err != nil {
+			return err
+		}
+		if err := v.revisionDao.DeleteRevisionByDocumentID(tx, id); err != nil {
+			return err
+		}
+		if err := v.progressDao.DeleteProgressByDocumentID(tx, id); err != nil {
+			return err
+		}
 		if err := v.visualDocumentDao.DeleteVisualDocumentByID(tx, id); err != nil {
 			return err
 		}
@@ -274,5 +414,7 @@ func (v *visualDocumentService) DeleteVisualDocumentByID(ctx context.Context, id
 		logger.WithCtx(ctx).Errorf("[DeleteApiByID] delete api error, err = %v", err)
 		return e.ErrMysql
 	}
+	// 删除的文档和留下的子文档都不再可见
+	v.searchService.IndexDocument(ctx, bankID, id)
 	return nil
 }
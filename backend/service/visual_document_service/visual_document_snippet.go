@@ -0,0 +1,267 @@
+package visual_document_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/fansqz/fancode-backend/common"
+	e "github.com/fansqz/fancode-backend/common/error"
+	"github.com/fansqz/fancode-backend/common/logger"
+	"github.com/fansqz/fancode-backend/constants"
+	"github.com/fansqz/fancode-backend/dao"
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/fansqz/fancode-backend/models/po"
+	"gorm.io/gorm"
+)
+
+var (
+	// snippetAnchorRegexp markdown中引用代码示例的锚点，例如<!-- snippet:bubble-sort -->
+	snippetAnchorRegexp = regexp.MustCompile(`<!--\s*snippet:\s*([A-Za-z0-9_-]+)\s*-->`)
+	snippetKeyRegexp    = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+func (v *visualDocumentService) GetVisualDocumentSnippet(ctx context.Context, snippetID uint) (*dto.VisualDocumentSnippetDto, error) {
+	snippet, err := v.snippetDao.GetSnippetByID(common.Mysql, snippetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.NewRecordNotFoundErr("snippet not exist")
+	}
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetVisualDocumentSnippet] GetSnippetByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	// 文档或者知识库未启用时用户看不到示例
+	document, err := v.permission.getEnabledDocument(ctx, snippet.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+	// 没有发布过的文档用户看到的是草稿
+	if document.PublishedRevisionID == 0 {
+		if snippet.DeletedAt.Valid {
+			return nil, e.NewRecordNotFoundErr("snippet not exist")
+		}
+		return newVisualDocumentSnippetDto(snippet), nil
+	}
+	// 用户只能运行已发布版本中的示例
+	revision, err := v.revisionDao.GetRevisionByID(common.Mysql, document.PublishedRevisionID)
+	if err != nil {
+		logger.WithCtx(ctx).Errorf("[GetVisualDocumentSnippet] GetRevisionByID fail, err = %v", err)
+		return nil, e.ErrMysql
+	}
+	for _, s := range parseRevisionSnippets(revision.Snippets) {
+		if s.Key == snippet.Key {
+			s.ID = snippet.ID
+			s.DocumentID = snippet.DocumentID
+			return s, nil
+		}
+	}
+	return nil, e.NewRecordNotFoundErr("snippet not exist")
+}
+
+// parseSnippetAnchors 获取markdown中引用的所有代码示例的key
+func parseSnippetAnchors(content string) []string {
+	matches := snippetAnchorRegexp.FindAllStringSubmatch(content, -1)
+	keys := make([]string, 0, len(matches))
+	for _, match := range matches {
+		keys = append(keys, match[1])
+	}
+	return keys
+}
+
+// validateSnippets 校验代码示例，markdown中引用的示例必须存在
+func validateSnippets(content string, snippets []*dto.VisualDocumentSnippetDto) error {
+	keys := make(map[string]bool, len(snippets))
+	for _, snippet := range snippets {
+		if !snippetKeyRegexp.MatchString(snippet.Key) {
+			return e.NewParamErr(fmt.Sprintf("代码示例的标识不合法: %s", snippet.Key))
+		}
+		if keys[snippet.Key] {
+			return e.NewParamErr(fmt.Sprintf("代码示例的标识重复: %s", snippet.Key))
+		}
+		keys[snippet.Key] = true
+		if len(snippet.CodeList) == 0 {
+			return e.NewParamErr(fmt.Sprintf("代码示例没有代码: %s", snippet.Key))
+		}
+		languages := make(map[string]bool, len(snippet.CodeList))
+		for _, code := range snippet.CodeList {
+			if !isSupportLanguage(code.Language) {
+				return e.NewParamErr(fmt.Sprintf("代码示例%s的语言不支持: %s", snippet.Key, code.Language))
+			}
+			if languages[code.Language] {
+				return e.NewParamErr(fmt.Sprintf("代码示例%s的语言重复: %s", snippet.Key, code.Language))
+			}
+			languages[code.Language] = true
+			if code.VisualDescription == nil {
+				continue
+			}
+			if err := code.VisualDescription.Validate(); err != nil {
+				return e.NewError(e.CodeVisualDescriptionInvalid, err.Error(), e.ErrTypeBadReq)
+			}
+		}
+	}
+	for _, key := range parseSnippetAnchors(content) {
+		if !keys[key] {
+			return e.NewParamErr(fmt.Sprintf("文档引用的代码示例不存在: %s", key))
+		}
+	}
+	return nil
+}
+
+func isSupportLanguage(language string) bool {
+	for _, l := range constants.SupportLanguages {
+		if string(l) == language {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceSnippets 使用新的代码示例替换文档草稿中的示例，并设置示例的id
+// 相同key的示例复用原来的记录，保证已发布版本中的示例id不变
+func replaceSnippets(tx *gorm.DB, snippetDao dao.VisualDocumentSnippetDao, documentID uint, snippets []*dto.VisualDocumentSnippetDto) error {
+	existing, err := snippetDao.GetAllSnippetListByDocumentID(tx, documentID)
+	if err != nil {
+		return err
+	}
+	existingMap := make(map[string]*po.VisualDocumentSnippet, len(existing))
+	for _, snippet := range existing {
+		existingMap[snippet.Key] = snippet
+	}
+	keys := make(map[string]bool, len(snippets))
+	for _, snippet := range snippets {
+		keys[snippet.Key] = true
+		snippet.DocumentID = documentID
+		codeList, err := formatSnippetCodeList(snippet.CodeList)
+		if err != nil {
+			return err
+		}
+		if old, ok := existingMap[snippet.Key]; ok {
+			old.Title = snippet.Title
+			old.CodeList = codeList
+			if err = snippetDao.UpdateSnippet(tx, old); err != nil {
+				return err
+			}
+			snippet.ID = old.ID
+			continue
+		}
+		newSnippet := &po.VisualDocumentSnippet{
+			DocumentID: documentID,
+			Key:        snippet.Key,
+			Title:      snippet.Title,
+			CodeList:   codeList,
+		}
+		if err = snippetDao.InsertSnippet(tx, newSnippet); err != nil {
+			return err
+		}
+		snippet.ID = newSnippet.ID
+	}
+	for _, snippet := range existing {
+		if !keys[snippet.Key] && !snippet.DeletedAt.Valid {
+			if err = snippetDao.DeleteSnippetByID(tx, snippet.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getDraftSnippets 获取文档草稿中的代码示例
+func getDraftSnippets(tx *gorm.DB, snippetDao dao.VisualDocumentSnippetDao, documentID uint) ([]*dto.VisualDocumentSnippetDto, error) {
+	snippets, err := snippetDao.GetSnippetListByDocumentID(tx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	answer := make([]*dto.VisualDocumentSnippetDto, 0, len(snippets))
+	for _, snippet := range snippets {
+		answer = append(answer, newVisualDocumentSnippetDto(snippet))
+	}
+	return answer, nil
+}
+
+func newVisualDocumentSnippetDto(snippet *po.VisualDocumentSnippet) *dto.VisualDocumentSnippetDto {
+	codeList := []*dto.VisualDocumentSnippetCodeDto{}
+	if err := json.Unmarshal([]byte(snippet.CodeList), &codeList); err != nil {
+		codeList = []*dto.VisualDocumentSnippetCodeDto{}
+	}
+	return &dto.VisualDocumentSnippetDto{
+		ID:         snippet.ID,
+		DocumentID: snippet.DocumentID,
+		Key:        snippet.Key,
+		Title:      snippet.Title,
+		CodeList:   codeList,
+	}
+}
+
+// formatSnippetCodeList 将示例的代码转换为json，空断点统一为空数组
+func formatSnippetCodeList(codeList []*dto.VisualDocumentSnippetCodeDto) (string, error) {
+	codes := make([]*dto.VisualDocumentSnippetCodeDto, 0, len(codeList))
+	for _, code := range codeList {
+		c := *code
+		if c.Breakpoints == nil {
+			c.Breakpoints = []int{}
+		}
+		codes = append(codes, &c)
+	}
+	data, err := json.Marshal(codes)
+	return string(data), err
+}
+
+// formatRevisionSnippets 将代码示例转换为保存在版本中的json，便于比较内容是否变化
+func formatRevisionSnippets(snippets []*dto.VisualDocumentSnippetDto) (string, error) {
+	answer := make([]*dto.VisualDocumentSnippetDto, 0, len(snippets))
+	for _, snippet := range snippets {
+		s := *snippet
+		s.CodeList = make([]*dto.VisualDocumentSnippetCodeDto, 0, len(snippet.CodeList))
+		for _, code := range snippet.CodeList {
+			c := *code
+			if c.Breakpoints == nil {
+				c.Breakpoints = []int{}
+			}
+			s.CodeList = append(s.CodeList, &c)
+		}
+		answer = append(answer, &s)
+	}
+	data, err := json.Marshal(answer)
+	return string(data), err
+}
+
+// parseRevisionSnippets 解析版本中的代码示例，格式错误或者没有示例时返回空列表
+func parseRevisionSnippets(snippets string) []*dto.VisualDocumentSnippetDto {
+	answer := []*dto.VisualDocumentSnippetDto{}
+	if snippets == "" {
+		return answer
+	}
+	if err := json.Unmarshal([]byte(snippets), &answer); err != nil {
+		return []*dto.VisualDocumentSnippetDto{}
+	}
+	return answer
+}
+
+// diffSnippets 比较两个版本的代码示例，返回新增、删除或修改的示例的key
+func diffSnippets(from string, to string) []string {
+	fromSnippets, toSnippets := parseRevisionSnippets(from), parseRevisionSnippets(to)
+	format := func(snippet *dto.VisualDocumentSnippetDto) string {
+		data, _ := formatRevisionSnippets([]*dto.VisualDocumentSnippetDto{snippet})
+		return data
+	}
+	fromMap := make(map[string]string, len(fromSnippets))
+	for _, snippet := range fromSnippets {
+		fromMap[snippet.Key] = format(snippet)
+	}
+	answer := []string{}
+	for _, snippet := range toSnippets {
+		old, ok := fromMap[snippet.Key]
+		if !ok || old != format(snippet) {
+			answer = append(answer, snippet.Key)
+		}
+		delete(fromMap, snippet.Key)
+	}
+	for _, snippet := range fromSnippets {
+		if _, ok := fromMap[snippet.Key]; ok {
+			answer = append(answer, snippet.Key)
+		}
+	}
+	return answer
+}
@@ -0,0 +1,63 @@
+package visual_document_service
+
+import (
+	"testing"
+
+	"github.com/fansqz/fancode-backend/models/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSnippetForTest(key string, languages ...string) *dto.VisualDocumentSnippetDto {
+	snippet := &dto.VisualDocumentSnippetDto{Key: key, CodeList: []*dto.VisualDocumentSnippetCodeDto{}}
+	for _, language := range languages {
+		snippet.CodeList = append(snippet.CodeList, &dto.VisualDocumentSnippetCodeDto{
+			VisualDocumentCodeDto: dto.VisualDocumentCodeDto{Language: language, Code: "int main() {}"},
+		})
+	}
+	return snippet
+}
+
+func TestParseSnippetAnchors(t *testing.T) {
+	content := "# 排序\n<!-- snippet:bubble-sort -->\n正文\n<!--snippet: quick_sort-->\n<!-- snippet -->"
+	assert.Equal(t, []string{"bubble-sort", "quick_sort"}, parseSnippetAnchors(content))
+	assert.Equal(t, 0, len(parseSnippetAnchors("没有示例")))
+}
+
+func TestValidateSnippets(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		snippets []*dto.VisualDocumentSnippetDto
+		valid    bool
+	}{
+		{"ok", "<!-- snippet:a -->", []*dto.VisualDocumentSnippetDto{newSnippetForTest("a", "c", "go")}, true},
+		{"unused", "", []*dto.VisualDocumentSnippetDto{newSnippetForTest("a", "c")}, true},
+		{"missing", "<!-- snippet:b -->", []*dto.VisualDocumentSnippetDto{newSnippetForTest("a", "c")}, false},
+		{"key", "", []*dto.VisualDocumentSnippetDto{newSnippetForTest("a b", "c")}, false},
+		{"duplicate key", "", []*dto.VisualDocumentSnippetDto{newSnippetForTest("a", "c"), newSnippetForTest("a", "go")}, false},
+		{"no code", "", []*dto.VisualDocumentSnippetDto{newSnippetForTest("a")}, false},
+		{"language", "", []*dto.VisualDocumentSnippetDto{newSnippetForTest("a", "py")}, false},
+		{"duplicate language", "", []*dto.VisualDocumentSnippetDto{newSnippetForTest("a", "c", "c")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSnippets(tt.content, tt.snippets)
+			assert.Equal(t, tt.valid, err == nil)
+		})
+	}
+}
+
+func TestDiffSnippets(t *testing.T) {
+	a, b, c := newSnippetForTest("a", "c"), newSnippetForTest("b", "c"), newSnippetForTest("c", "c")
+	from, err := formatRevisionSnippets([]*dto.VisualDocumentSnippetDto{a, b})
+	assert.Nil(t, err)
+	changed := newSnippetForTest("b", "c")
+	changed.CodeList[0].Stdin = "1 2\n"
+	to, err := formatRevisionSnippets([]*dto.VisualDocumentSnippetDto{a, changed, c})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b", "c"}, diffSnippets(from, to))
+	assert.Equal(t, []string{"c"}, diffSnippets(to, from)[1:])
+	// 代码示例功能之前的版本没有保存示例
+	assert.Equal(t, 0, len(parseRevisionSnippets("")))
+	assert.Equal(t, []string{"a", "b"}, diffSnippets("", from))
+}
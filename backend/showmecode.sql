@@ -293,6 +293,113 @@ INSERT INTO `role_apis` VALUES (3, 214);
 INSERT INTO `role_apis` VALUES (3, 215);
 INSERT INTO `role_apis` VALUES (2, 218);
 INSERT INTO `role_apis` VALUES (3, 218);
+INSERT INTO `role_apis` VALUES (3, 220);
+INSERT INTO `role_apis` VALUES (3, 221);
+INSERT INTO `role_apis` VALUES (3, 222);
+INSERT INTO `role_apis` VALUES (2, 223);
+INSERT INTO `role_apis` VALUES (3, 223);
+INSERT INTO `role_apis` VALUES (2, 224);
+INSERT INTO `role_apis` VALUES (3, 224);
+INSERT INTO `role_apis` VALUES (3, 225);
+INSERT INTO `role_apis` VALUES (2, 226);
+INSERT INTO `role_apis` VALUES (3, 226);
+INSERT INTO `role_apis` VALUES (2, 227);
+INSERT INTO `role_apis` VALUES (3, 227);
+INSERT INTO `role_apis` VALUES (2, 228);
+INSERT INTO `role_apis` VALUES (3, 228);
+INSERT INTO `role_apis` VALUES (2, 229);
+INSERT INTO `role_apis` VALUES (3, 229);
+INSERT INTO `role_apis` VALUES (3, 230);
+INSERT INTO `role_apis` VALUES (2, 231);
+INSERT INTO `role_apis` VALUES (3, 231);
+INSERT INTO `role_apis` VALUES (3, 232);
+INSERT INTO `role_apis` VALUES (3, 233);
+INSERT INTO `role_apis` VALUES (3, 234);
+INSERT INTO `role_apis` VALUES (3, 235);
+INSERT INTO `role_apis` VALUES (2, 236);
+INSERT INTO `role_apis` VALUES (3, 236);
+INSERT INTO `role_apis` VALUES (3, 237);
+INSERT INTO `role_apis` VALUES (2, 238);
+INSERT INTO `role_apis` VALUES (3, 238);
+INSERT INTO `role_apis` VALUES (2, 239);
+INSERT INTO `role_apis` VALUES (3, 239);
+INSERT INTO `role_apis` VALUES (2, 240);
+INSERT INTO `role_apis` VALUES (3, 240);
+INSERT INTO `role_apis` VALUES (2, 241);
+INSERT INTO `role_apis` VALUES (3, 241);
+INSERT INTO `role_apis` VALUES (3, 242);
+INSERT INTO `role_apis` VALUES (3, 243);
+INSERT INTO `role_apis` VALUES (2, 244);
+INSERT INTO `role_apis` VALUES (3, 244);
+INSERT INTO `role_apis` VALUES (2, 245);
+INSERT INTO `role_apis` VALUES (3, 245);
+INSERT INTO `role_apis` VALUES (1, 246);
+INSERT INTO `role_apis` VALUES (1, 247);
+INSERT INTO `role_apis` VALUES (1, 248);
+INSERT INTO `role_apis` VALUES (1, 249);
+INSERT INTO `role_apis` VALUES (1, 250);
+INSERT INTO `role_apis` VALUES (1, 251);
+INSERT INTO `role_apis` VALUES (1, 252);
+INSERT INTO `role_apis` VALUES (2, 253);
+INSERT INTO `role_apis` VALUES (3, 253);
+INSERT INTO `role_apis` VALUES (2, 254);
+INSERT INTO `role_apis` VALUES (3, 254);
+INSERT INTO `role_apis` VALUES (2, 255);
+INSERT INTO `role_apis` VALUES (3, 255);
+INSERT INTO `role_apis` VALUES (1, 256);
+INSERT INTO `role_apis` VALUES (2, 257);
+INSERT INTO `role_apis` VALUES (3, 257);
+INSERT INTO `role_apis` VALUES (1, 258);
+INSERT INTO `role_apis` VALUES (1, 259);
+INSERT INTO `role_apis` VALUES (1, 260);
+INSERT INTO `role_apis` VALUES (1, 261);
+INSERT INTO `role_apis` VALUES (1, 262);
+INSERT INTO `role_apis` VALUES (1, 263);
+INSERT INTO `role_apis` VALUES (1, 264);
+INSERT INTO `role_apis` VALUES (2, 265);
+INSERT INTO `role_apis` VALUES (3, 265);
+INSERT INTO `role_apis` VALUES (1, 266);
+INSERT INTO `role_apis` VALUES (1, 267);
+INSERT INTO `role_apis` VALUES (3, 268);
+INSERT INTO `role_apis` VALUES (3, 269);
+INSERT INTO `role_apis` VALUES (3, 270);
+INSERT INTO `role_apis` VALUES (3, 271);
+INSERT INTO `role_apis` VALUES (1, 272);
+INSERT INTO `role_apis` VALUES (1, 273);
+INSERT INTO `role_apis` VALUES (1, 274);
+INSERT INTO `role_apis` VALUES (1, 275);
+INSERT INTO `role_apis` VALUES (1, 276);
+INSERT INTO `role_apis` VALUES (2, 277);
+INSERT INTO `role_apis` VALUES (3, 277);
+INSERT INTO `role_apis` VALUES (1, 278);
+INSERT INTO `role_apis` VALUES (1, 279);
+INSERT INTO `role_apis` VALUES (1, 280);
+INSERT INTO `role_apis` VALUES (1, 281);
+INSERT INTO `role_apis` VALUES (1, 282);
+INSERT INTO `role_apis` VALUES (1, 283);
+INSERT INTO `role_apis` VALUES (2, 284);
+INSERT INTO `role_apis` VALUES (3, 284);
+INSERT INTO `role_apis` VALUES (2, 285);
+INSERT INTO `role_apis` VALUES (3, 285);
+INSERT INTO `role_apis` VALUES (3, 286);
+INSERT INTO `role_apis` VALUES (3, 287);
+INSERT INTO `role_apis` VALUES (3, 288);
+INSERT INTO `role_apis` VALUES (3, 289);
+INSERT INTO `role_apis` VALUES (3, 290);
+INSERT INTO `role_apis` VALUES (3, 291);
+INSERT INTO `role_apis` VALUES (3, 292);
+INSERT INTO `role_apis` VALUES (3, 293);
+INSERT INTO `role_apis` VALUES (3, 294);
+INSERT INTO `role_apis` VALUES (3, 295);
+INSERT INTO `role_apis` VALUES (3, 296);
+INSERT INTO `role_apis` VALUES (3, 297);
+INSERT INTO `role_apis` VALUES (3, 298);
+INSERT INTO `role_apis` VALUES (3, 299);
+INSERT INTO `role_apis` VALUES (3, 300);
+INSERT INTO `role_apis` VALUES (3, 301);
+INSERT INTO `role_apis` VALUES (3, 302);
+INSERT INTO `role_apis` VALUES (3, 303);
+INSERT INTO `role_apis` VALUES (3, 304);
 
 -- ----------------------------
 -- Table structure for role_menus
@@ -345,7 +452,7 @@ CREATE TABLE `sys_apis`  (
   `sort` int NULL DEFAULT NULL,
   PRIMARY KEY (`id`) USING BTREE,
   INDEX `idx_sys_apis_deleted_at`(`deleted_at` ASC) USING BTREE
-) ENGINE = InnoDB AUTO_INCREMENT = 220 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = DYNAMIC;
+) ENGINE = InnoDB AUTO_INCREMENT = 305 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci ROW_FORMAT = DYNAMIC;
 
 -- ----------------------------
 -- Records of sys_apis
@@ -568,6 +675,91 @@ INSERT INTO `sys_apis` VALUES (216, '2025-07-31 00:58:52.157', '2025-07-31 00:58
 INSERT INTO `sys_apis` VALUES (217, '2025-07-31 00:59:12.742', '2025-07-31 00:59:12.742', '2025-08-03 16:42:04.798', 210, '/user/savedCode/batch-sort', 'put', '批量更新排序顺序', '', NULL);
 INSERT INTO `sys_apis` VALUES (218, '2026-01-10 02:14:20.697', '2026-01-10 02:14:32.933', NULL, 165, '/visual/debug/array', 'post', '数组可视化', '', NULL);
 INSERT INTO `sys_apis` VALUES (219, '2026-01-10 02:18:33.836', '2026-01-10 02:18:33.836', NULL, 165, '/visual/debug/array2d', 'post', '二位数组可视化', '', NULL);
+INSERT INTO `sys_apis` VALUES (220, '2026-02-10 21:05:12.318', '2026-02-10 21:05:12.318', NULL, 210, '/user/savedCode/share', 'post', '创建代码分享链接', '', NULL);
+INSERT INTO `sys_apis` VALUES (221, '2026-02-10 21:05:40.722', '2026-02-10 21:05:40.722', NULL, 210, '/user/savedCode/:id/shares', 'get', '获取代码的分享链接列表', '', NULL);
+INSERT INTO `sys_apis` VALUES (222, '2026-02-10 21:06:03.105', '2026-02-10 21:06:03.105', NULL, 210, '/user/savedCode/share/:shareID', 'delete', '删除代码分享链接', '', NULL);
+INSERT INTO `sys_apis` VALUES (223, '2026-02-10 21:06:31.947', '2026-02-10 21:06:31.947', NULL, 66, '/share/code', '', '代码分享', '', NULL);
+INSERT INTO `sys_apis` VALUES (224, '2026-02-10 21:06:58.260', '2026-02-10 21:06:58.260', NULL, 223, '/share/code/:shareID', 'get', '查看分享的代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (225, '2026-02-10 21:07:20.533', '2026-02-10 21:07:20.533', NULL, 223, '/share/code/:shareID/fork', 'post', '复制分享的代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (226, '2026-02-10 21:07:49.871', '2026-02-10 21:07:49.871', NULL, 145, '/debug/start/share', 'post', '调试分享的代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (227, '2026-03-02 22:14:36.402', '2026-03-02 22:14:36.402', NULL, 2, '/auth/oauth/providers', 'get', '获取第三方登录方式', '', NULL);
+INSERT INTO `sys_apis` VALUES (228, '2026-03-02 22:15:08.917', '2026-03-02 22:15:08.917', NULL, 2, '/auth/oauth/:provider/authorize', 'get', '获取第三方授权地址', '', NULL);
+INSERT INTO `sys_apis` VALUES (229, '2026-03-02 22:15:33.560', '2026-03-02 22:15:33.560', NULL, 2, '/auth/oauth/:provider/login', 'post', '第三方登录', '', NULL);
+INSERT INTO `sys_apis` VALUES (230, '2026-03-02 22:16:01.284', '2026-03-02 22:16:01.284', NULL, 2, '/auth/oauth/bindings', 'get', '获取绑定的第三方账号', '', NULL);
+INSERT INTO `sys_apis` VALUES (231, '2026-03-09 20:41:17.635', '2026-03-09 20:41:17.635', NULL, 2, '/auth/token/refresh', 'post', '刷新token', '', NULL);
+INSERT INTO `sys_apis` VALUES (232, '2026-03-09 20:41:45.208', '2026-03-09 20:41:45.208', NULL, 2, '/auth/logout', 'post', '退出登录', '', NULL);
+INSERT INTO `sys_apis` VALUES (233, '2026-03-09 20:42:09.771', '2026-03-09 20:42:09.771', NULL, 2, '/auth/logout/all', 'post', '退出所有设备', '', NULL);
+INSERT INTO `sys_apis` VALUES (234, '2026-03-09 20:42:36.094', '2026-03-09 20:42:36.094', NULL, 2, '/auth/sessions', 'get', '获取已登录的设备', '', NULL);
+INSERT INTO `sys_apis` VALUES (235, '2026-03-09 20:43:02.519', '2026-03-09 20:43:02.519', NULL, 2, '/auth/sessions/:sessionID', 'delete', '退出指定设备', '', NULL);
+INSERT INTO `sys_apis` VALUES (236, '2026-04-06 20:12:41.332', '2026-04-06 20:12:41.332', NULL, 165, '/visual/debug/description', 'put', '更新可视化描述', '', NULL);
+INSERT INTO `sys_apis` VALUES (237, '2026-04-06 20:13:01.801', '2026-04-06 20:13:01.801', NULL, 210, '/user/savedCode/visualDescription', 'put', '更新保存代码的可视化描述', '', NULL);
+INSERT INTO `sys_apis` VALUES (238, '2026-04-06 20:20:58.606', '2026-04-06 20:20:58.606', NULL, 145, '/debug/assist', '', '调试助手', '', NULL);
+INSERT INTO `sys_apis` VALUES (239, '2026-04-06 20:21:33.349', '2026-04-06 20:21:33.349', NULL, 238, '/debug/assist/explain/:id', 'get', '解释当前执行步骤', '', NULL);
+INSERT INTO `sys_apis` VALUES (240, '2026-04-06 20:21:52.229', '2026-04-06 20:21:52.229', NULL, 238, '/debug/assist/diagnose/:id', 'get', '诊断程序错误', '', NULL);
+INSERT INTO `sys_apis` VALUES (241, '2026-04-06 20:22:17.246', '2026-04-06 20:22:17.246', NULL, 238, '/debug/assist/compile/:id', 'get', '解释编译错误', '', NULL);
+INSERT INTO `sys_apis` VALUES (242, '2026-04-06 20:31:44.154', '2026-04-06 20:31:44.154', NULL, 117, '/judge/submission/list', 'get', '获取提交记录列表', '', NULL);
+INSERT INTO `sys_apis` VALUES (243, '2026-04-06 20:32:23.445', '2026-04-06 20:32:23.445', NULL, 117, '/judge/submission/:id', 'get', '获取提交记录详情', '', NULL);
+INSERT INTO `sys_apis` VALUES (244, '2026-04-06 20:32:46.873', '2026-04-06 20:32:46.873', NULL, 120, '/problem/template', 'get', '获取题目代码模板', '', NULL);
+INSERT INTO `sys_apis` VALUES (245, '2026-04-06 20:33:17.438', '2026-04-06 20:33:17.438', NULL, 120, '/problem/tag/all', 'get', '获取所有题目标签', '', NULL);
+INSERT INTO `sys_apis` VALUES (246, '2026-04-06 20:33:55.140', '2026-04-06 20:33:55.140', NULL, 107, '/manage/problem/tag/all', 'get', '获取所有题目标签', '', NULL);
+INSERT INTO `sys_apis` VALUES (247, '2026-04-06 20:41:09.839', '2026-04-06 20:41:09.839', NULL, 65, '/manage/problem/bank', '', '题库管理', '', NULL);
+INSERT INTO `sys_apis` VALUES (248, '2026-04-06 20:41:38.815', '2026-04-06 20:41:38.815', NULL, 247, '/manage/problem/bank', 'post', '添加题库', '', NULL);
+INSERT INTO `sys_apis` VALUES (249, '2026-04-06 20:42:13.928', '2026-04-06 20:42:13.928', NULL, 247, '/manage/problem/bank', 'put', '更新题库', '', NULL);
+INSERT INTO `sys_apis` VALUES (250, '2026-04-06 20:42:33.178', '2026-04-06 20:42:33.178', NULL, 247, '/manage/problem/bank/:id', 'delete', '删除题库', '', NULL);
+INSERT INTO `sys_apis` VALUES (251, '2026-04-06 20:42:59.565', '2026-04-06 20:42:59.565', NULL, 247, '/manage/problem/bank/:id', 'get', '读取题库', '', NULL);
+INSERT INTO `sys_apis` VALUES (252, '2026-04-06 20:43:33.089', '2026-04-06 20:43:33.089', NULL, 247, '/manage/problem/bank/all', 'get', '获取所有题库', '', NULL);
+INSERT INTO `sys_apis` VALUES (253, '2026-04-06 20:43:50.750', '2026-04-06 20:43:50.750', NULL, 66, '/problem/bank', '', '题库信息', '', NULL);
+INSERT INTO `sys_apis` VALUES (254, '2026-04-06 20:44:15.548', '2026-04-06 20:44:15.548', NULL, 253, '/problem/bank/all', 'get', '获取所有题库', '', NULL);
+INSERT INTO `sys_apis` VALUES (255, '2026-04-06 20:44:47.483', '2026-04-06 20:44:47.483', NULL, 253, '/problem/bank/:id', 'get', '获取题库信息', '', NULL);
+INSERT INTO `sys_apis` VALUES (256, '2026-04-06 20:45:25.555', '2026-04-06 20:45:25.555', NULL, 134, '/manage/problem/case/upload/:problemID', 'post', '上传用例文件', '', NULL);
+INSERT INTO `sys_apis` VALUES (257, '2026-04-06 20:52:41.209', '2026-04-06 20:52:41.209', NULL, 117, '/judge/run', 'post', '使用自定义输入运行代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (258, '2026-04-06 21:04:06.346', '2026-04-06 21:04:06.346', NULL, 180, '/manage/visual/document/:id/publish', 'post', '发布可视化文档', '', NULL);
+INSERT INTO `sys_apis` VALUES (259, '2026-04-06 21:04:42.829', '2026-04-06 21:04:42.829', NULL, 180, '/manage/visual/document/:id/revisions', 'get', '获取可视化文档的版本列表', '', NULL);
+INSERT INTO `sys_apis` VALUES (260, '2026-04-06 21:05:03.449', '2026-04-06 21:05:03.449', NULL, 180, '/manage/visual/document/revision/:revisionID', 'get', '获取可视化文档的版本', '', NULL);
+INSERT INTO `sys_apis` VALUES (261, '2026-04-06 21:05:31.206', '2026-04-06 21:05:31.206', NULL, 180, '/manage/visual/document/revision/:revisionID/restore', 'post', '恢复可视化文档的版本', '', NULL);
+INSERT INTO `sys_apis` VALUES (262, '2026-04-06 21:06:06.100', '2026-04-06 21:06:06.100', NULL, 180, '/manage/visual/document/revision/diff', 'get', '比较可视化文档的版本', '', NULL);
+INSERT INTO `sys_apis` VALUES (263, '2026-04-06 21:14:20.031', '2026-04-06 21:14:20.031', NULL, 200, '/manage/visual/document/bank/:id/export', 'get', '导出知识库', '', NULL);
+INSERT INTO `sys_apis` VALUES (264, '2026-04-06 21:14:45.199', '2026-04-06 21:14:45.199', NULL, 200, '/manage/visual/document/bank/import', 'post', '导入知识库', '', NULL);
+INSERT INTO `sys_apis` VALUES (265, '2026-04-06 21:26:16.305', '2026-04-06 21:26:16.305', NULL, 66, '/search', 'get', '搜索', '', NULL);
+INSERT INTO `sys_apis` VALUES (266, '2026-04-06 21:26:55.747', '2026-04-06 21:26:55.747', NULL, 65, '/manage/search', '', '搜索管理', '', NULL);
+INSERT INTO `sys_apis` VALUES (267, '2026-04-06 21:27:19.326', '2026-04-06 21:27:19.326', NULL, 266, '/manage/search/rebuild', 'post', '重建搜索索引', '', NULL);
+INSERT INTO `sys_apis` VALUES (268, '2026-04-06 21:36:57.716', '2026-04-06 21:36:57.716', NULL, 66, '/learn/visual/progress', '', '学习进度', '', NULL);
+INSERT INTO `sys_apis` VALUES (269, '2026-04-06 21:37:35.569', '2026-04-06 21:37:35.569', NULL, 268, '/learn/visual/progress/bank/:bankID', 'get', '获取知识库的学习进度', '', NULL);
+INSERT INTO `sys_apis` VALUES (270, '2026-04-06 21:37:57.559', '2026-04-06 21:37:57.559', NULL, 268, '/learn/visual/progress/continue', 'get', '继续学习', '', NULL);
+INSERT INTO `sys_apis` VALUES (271, '2026-04-06 21:38:25.686', '2026-04-06 21:38:25.686', NULL, 268, '/learn/visual/progress/document/:documentID/complete', 'post', '完成可视化文档', '', NULL);
+INSERT INTO `sys_apis` VALUES (272, '2026-04-06 21:39:00.950', '2026-04-06 21:39:00.950', NULL, 65, '/manage/visual/progress', '', '学习进度统计', '', NULL);
+INSERT INTO `sys_apis` VALUES (273, '2026-04-06 21:39:20.351', '2026-04-06 21:39:20.351', NULL, 272, '/manage/visual/progress/bank/:bankID/funnel', 'get', '获取知识库的学习漏斗', '', NULL);
+INSERT INTO `sys_apis` VALUES (274, '2026-04-06 21:48:16.538', '2026-04-06 21:48:16.538', NULL, 200, '/manage/visual/document/bank/:id/member', 'put', '保存知识库协作者', '', NULL);
+INSERT INTO `sys_apis` VALUES (275, '2026-04-06 21:48:50.213', '2026-04-06 21:48:50.213', NULL, 200, '/manage/visual/document/bank/:id/member/:userID', 'delete', '删除知识库协作者', '', NULL);
+INSERT INTO `sys_apis` VALUES (276, '2026-04-06 21:49:08.025', '2026-04-06 21:49:08.025', NULL, 200, '/manage/visual/document/bank/:id/members', 'get', '获取知识库协作者列表', '', NULL);
+INSERT INTO `sys_apis` VALUES (277, '2026-04-06 21:58:37.949', '2026-04-06 21:58:37.949', NULL, 145, '/debug/start/snippet', 'post', '调试文档中的代码片段', '', NULL);
+INSERT INTO `sys_apis` VALUES (278, '2026-04-06 22:10:07.086', '2026-04-06 22:10:07.086', NULL, 65, '/manage/visual/exercise', '', '练习管理', '', NULL);
+INSERT INTO `sys_apis` VALUES (279, '2026-04-06 22:10:45.309', '2026-04-06 22:10:45.309', NULL, 278, '/manage/visual/exercise/document/:documentID', 'get', '获取文档的练习列表', '', NULL);
+INSERT INTO `sys_apis` VALUES (280, '2026-04-06 22:11:07.669', '2026-04-06 22:11:07.669', NULL, 278, '/manage/visual/exercise', 'post', '添加练习', '', NULL);
+INSERT INTO `sys_apis` VALUES (281, '2026-04-06 22:11:37.166', '2026-04-06 22:11:37.166', NULL, 278, '/manage/visual/exercise', 'put', '更新练习', '', NULL);
+INSERT INTO `sys_apis` VALUES (282, '2026-04-06 22:12:13.800', '2026-04-06 22:12:13.800', NULL, 278, '/manage/visual/exercise/:id', 'delete', '删除练习', '', NULL);
+INSERT INTO `sys_apis` VALUES (283, '2026-04-06 22:12:34.571', '2026-04-06 22:12:34.571', NULL, 278, '/manage/visual/exercise/:id/results', 'get', '获取练习的提交结果', '', NULL);
+INSERT INTO `sys_apis` VALUES (284, '2026-04-06 22:13:02.479', '2026-04-06 22:13:02.479', NULL, 66, '/learn/visual/exercise', '', '练习', '', NULL);
+INSERT INTO `sys_apis` VALUES (285, '2026-04-06 22:13:36.524', '2026-04-06 22:13:36.524', NULL, 284, '/learn/visual/exercise/document/:documentID', 'get', '获取文档的练习', '', NULL);
+INSERT INTO `sys_apis` VALUES (286, '2026-04-06 22:13:54.706', '2026-04-06 22:13:54.706', NULL, 284, '/learn/visual/exercise/:id/submit', 'post', '提交练习', '', NULL);
+INSERT INTO `sys_apis` VALUES (287, '2026-04-06 22:21:23.319', '2026-04-06 22:21:23.319', NULL, 66, '/user/code', '', '用户代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (288, '2026-04-06 22:21:55.775', '2026-04-06 22:21:55.775', NULL, 287, '/user/code', 'put', '保存用户代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (289, '2026-04-06 22:22:35.368', '2026-04-06 22:22:35.368', NULL, 287, '/user/code/:problemID', 'get', '获取用户代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (290, '2026-04-06 22:22:59.098', '2026-04-06 22:22:59.098', NULL, 287, '/user/code/revision', '', '代码历史版本', '', NULL);
+INSERT INTO `sys_apis` VALUES (291, '2026-04-06 22:23:29.965', '2026-04-06 22:23:29.965', NULL, 290, '/user/code/revision/list', 'get', '获取代码的历史版本列表', '', NULL);
+INSERT INTO `sys_apis` VALUES (292, '2026-04-06 22:24:06.969', '2026-04-06 22:24:06.969', NULL, 290, '/user/code/revision/:id', 'get', '获取代码的历史版本', '', NULL);
+INSERT INTO `sys_apis` VALUES (293, '2026-04-06 22:24:28.110', '2026-04-06 22:24:28.110', NULL, 290, '/user/code/revision/diff', 'get', '比较代码的历史版本', '', NULL);
+INSERT INTO `sys_apis` VALUES (294, '2026-04-06 22:24:56.388', '2026-04-06 22:24:56.388', NULL, 290, '/user/code/revision/:id/restore', 'post', '恢复代码的历史版本', '', NULL);
+INSERT INTO `sys_apis` VALUES (295, '2026-04-06 22:25:31.803', '2026-04-06 22:25:31.803', NULL, 290, '/user/code/revision/checkpoint', 'post', '创建检查点', '', NULL);
+INSERT INTO `sys_apis` VALUES (296, '2026-04-06 22:25:51.355', '2026-04-06 22:25:51.355', NULL, 290, '/user/code/revision/checkpoint/:id', 'delete', '删除检查点', '', NULL);
+INSERT INTO `sys_apis` VALUES (297, '2026-04-06 22:32:47.689', '2026-04-06 22:32:47.689', NULL, 210, '/user/savedCode/batch/delete', 'post', '批量删除保存的代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (298, '2026-04-06 22:33:21.515', '2026-04-06 22:33:21.515', NULL, 210, '/user/savedCode/batch/move', 'post', '批量移动保存的代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (299, '2026-04-06 22:33:39.478', '2026-04-06 22:33:39.478', NULL, 210, '/user/savedCode/folder', 'post', '保存文件夹', '', NULL);
+INSERT INTO `sys_apis` VALUES (300, '2026-04-06 22:34:03.578', '2026-04-06 22:34:03.578', NULL, 210, '/user/savedCode/folder/:id', 'delete', '删除文件夹', '', NULL);
+INSERT INTO `sys_apis` VALUES (301, '2026-04-06 22:34:34.815', '2026-04-06 22:34:34.815', NULL, 210, '/user/savedCode/folder/list', 'get', '获取文件夹列表', '', NULL);
+INSERT INTO `sys_apis` VALUES (302, '2026-04-06 22:35:13.189', '2026-04-06 22:35:13.189', NULL, 210, '/user/savedCode/meta', 'put', '更新保存代码的信息', '', NULL);
+INSERT INTO `sys_apis` VALUES (303, '2026-04-06 22:35:35.700', '2026-04-06 22:35:35.700', NULL, 210, '/user/savedCode/star', 'put', '收藏保存的代码', '', NULL);
+INSERT INTO `sys_apis` VALUES (304, '2026-04-06 22:36:05.348', '2026-04-06 22:36:05.348', NULL, 210, '/user/savedCode/tag/list', 'get', '获取使用过的标签', '', NULL);
 
 -- ----------------------------
 -- Table structure for sys_menus
@@ -7,6 +7,7 @@ import (
 const (
 	CtxUserIDKey  = "userID"
 	CtxVisitorUID = "visitorUID"
+	CtxSessionID  = "sessionID"
 )
 
 type UserInfo struct {
@@ -38,3 +39,12 @@ func GetVisitorIDWithCtx(ctx context.Context) string {
 	}
 	return uid
 }
+
+// GetSessionIDWithCtx 获取当前token所属的登录会话id
+func GetSessionIDWithCtx(ctx context.Context) string {
+	sessionID, ok := ctx.Value(CtxSessionID).(string)
+	if !ok {
+		return ""
+	}
+	return sessionID
+}
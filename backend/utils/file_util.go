@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"os"
 )
 
@@ -77,3 +79,19 @@ func CheckAndDeletePath(filename string) error {
 
 	return nil
 }
+
+// SaveUploadedFile 保存上传的文件
+func SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, src)
+	return err
+}
@@ -0,0 +1,6 @@
+//go:build !dev
+
+package utils
+
+// defaultJWTKey 正式版本中没有默认密钥，必须在配置文件中配置签名密钥
+var defaultJWTKey []byte
@@ -0,0 +1,6 @@
+//go:build dev
+
+package utils
+
+// defaultJWTKey 没有配置密钥时使用的密钥，只在使用dev标签编译的本地开发版本中存在
+var defaultJWTKey = []byte("fan_code_key...naliyoucaihonggaosuwo")
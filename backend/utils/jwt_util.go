@@ -1,78 +1,139 @@
 package utils
 
 import (
-	"github.com/dgrijalva/jwt-go"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
+
+	"github.com/dgrijalva/jwt-go"
 )
 
 const (
-	key                   = "fan_code_key...naliyoucaihonggaosuwo"
-	expiredTime           = 3 * 24 * time.Hour
-	shouldUpdateTokenTime = 3*24*time.Hour - time.Hour
+	// TokenTypeAccess 访问接口使用的token
+	TokenTypeAccess = "access"
+	// TokenTypeRefresh 用于获取新的access token
+	TokenTypeRefresh = "refresh"
+
+	// defaultKid 没有配置密钥时使用的kid
+	defaultKid = "default"
+	issuer     = "fancode"
 )
 
+// ErrJWTKeysNotConfigured 没有配置签名密钥，并且不是使用dev标签编译的本地开发版本
+var ErrJWTKeysNotConfigured = errors.New("jwt keys not configured, set [jwt.keys] or build with -tags dev")
+
+// jwtKeys 签发和校验token使用的密钥，调用InitJWTKeys之前为空，不能签发和校验token
+var jwtKeys = struct {
+	sync.RWMutex
+	keys       map[string][]byte
+	currentKid string
+}{}
+
 type Claims struct {
 	// 用户id
 	ID uint `json:"id"`
+	// 登录会话id，退出登录时会话中的所有token失效
+	SessionID string `json:"sid"`
+	// token类型，access或refresh
+	TokenType string `json:"typ"`
 	jwt.StandardClaims
 }
 
+// InitJWTKeys
+//
+//	@Description: 设置签名密钥，keys为kid到密钥的映射，使用currentKid对应的密钥签发token
+//	@param keys 为空时使用默认密钥，只有使用dev标签编译时存在默认密钥，否则返回ErrJWTKeysNotConfigured
+//	@return bool 是否使用了默认密钥
+func InitJWTKeys(keys map[string]string, currentKid string) (bool, error) {
+	jwtKeys.Lock()
+	defer jwtKeys.Unlock()
+	if len(keys) == 0 {
+		if len(defaultJWTKey) == 0 {
+			return false, ErrJWTKeysNotConfigured
+		}
+		jwtKeys.keys = map[string][]byte{defaultKid: defaultJWTKey}
+		jwtKeys.currentKid = defaultKid
+		return true, nil
+	}
+	if _, ok := keys[currentKid]; !ok {
+		return false, fmt.Errorf("jwt key %s not exist", currentKid)
+	}
+	jwtKeys.keys = make(map[string][]byte, len(keys))
+	for kid, key := range keys {
+		jwtKeys.keys[kid] = []byte(key)
+	}
+	jwtKeys.currentKid = currentKid
+	return false, nil
+}
+
 // GenerateToken
 // https://www.jianshu.com/p/202b04426368
 //
-//	@Description:  通过user生成token
-//	@param user    用户
+//	@Description:  生成token，每个token有唯一的jti，header中的kid为签名使用的密钥
+//	@param claims  用户id、会话id和token类型，claims.Id为jti，为空时随机生成
+//	@param expire  有效时间
 //	@return string 生成的token
 //	@return error
-func GenerateToken(claims Claims) (string, error) {
+func GenerateToken(claims Claims, expire time.Duration) (string, error) {
+	jti := claims.Id
+	if jti == "" {
+		var err error
+		if jti, err = GetSecureRandomHex(16); err != nil {
+			return "", err
+		}
+	}
 	nowTime := time.Now()
-	expiredTime := nowTime.Add(expiredTime)
 	claims.StandardClaims = jwt.StandardClaims{
-		//过期时间
-		ExpiresAt: expiredTime.Unix(),
+		Id:        jti,
+		IssuedAt:  nowTime.Unix(),
+		ExpiresAt: nowTime.Add(expire).Unix(),
 		//指定token发行人
-		Issuer: "fancode",
+		Issuer: issuer,
+	}
+	jwtKeys.RLock()
+	kid, key := jwtKeys.currentKid, jwtKeys.keys[jwtKeys.currentKid]
+	jwtKeys.RUnlock()
+	if len(key) == 0 {
+		return "", ErrJWTKeysNotConfigured
 	}
 	//设置加密算法，生成token对象
 	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenClaims.Header["kid"] = kid
 	//通过私钥获取已签名token
-	token, err := tokenClaims.SignedString([]byte(key))
-	return token, err
+	return tokenClaims.SignedString(key)
 }
 
 // ParseToken
 //
-//	@Description: 解析token，返回user
+//	@Description: 解析token，根据header中的kid选择密钥，没有kid或者kid不存在的token无效
 //	@param token
-//	@return user
+//	@return claims
 func ParseToken(token string) (*Claims, error) {
 	//获取到token对象
 	tokenClaims, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(key), nil
-	})
-	//通过断言获取到claim
-	if tokenClaims != nil {
-		if claims, ok := tokenClaims.Claims.(*Claims); ok && tokenClaims.Valid {
-			return claims, nil
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
 		}
-	}
-	return nil, err
-}
-
-// ShouldUpdateToken 检查是否需要更新token
-func ShouldUpdateToken(token string) (bool, error) {
-	//获取到token对象
-	tokenClaims, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(key), nil
+		kid, _ := token.Header["kid"].(string)
+		jwtKeys.RLock()
+		defer jwtKeys.RUnlock()
+		key, ok := jwtKeys.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("jwt key %s not exist", kid)
+		}
+		return key, nil
 	})
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	//通过断言获取到claim
-	if tokenClaims != nil {
-		if claims, ok := tokenClaims.Claims.(*Claims); ok && tokenClaims.Valid {
-			return claims.StandardClaims.ExpiresAt-time.Now().Unix() < int64(shouldUpdateTokenTime), nil
-		}
+	claims, ok := tokenClaims.Claims.(*Claims)
+	if !ok || !tokenClaims.Valid {
+		return nil, errors.New("invalid token")
 	}
-	return false, nil
+	if claims.Issuer != issuer || claims.Id == "" || claims.SessionID == "" {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
 }
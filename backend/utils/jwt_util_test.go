@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndParseToken(t *testing.T) {
+	_, err := InitJWTKeys(map[string]string{"k1": "secret-1"}, "k1")
+	require.NoError(t, err)
+	defer InitJWTKeys(nil, "")
+
+	token, err := GenerateToken(Claims{ID: 1, SessionID: "s1", TokenType: TokenTypeAccess}, time.Minute)
+	require.NoError(t, err)
+	claims, err := ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), claims.ID)
+	assert.Equal(t, "s1", claims.SessionID)
+	assert.Equal(t, TokenTypeAccess, claims.TokenType)
+	assert.NotEmpty(t, claims.Id)
+
+	// 每个token的jti不同，指定jti时使用指定的值
+	other, err := GenerateToken(Claims{ID: 1, SessionID: "s1", TokenType: TokenTypeAccess}, time.Minute)
+	require.NoError(t, err)
+	otherClaims, err := ParseToken(other)
+	require.NoError(t, err)
+	assert.NotEqual(t, claims.Id, otherClaims.Id)
+	refresh := Claims{ID: 1, SessionID: "s1", TokenType: TokenTypeRefresh}
+	refresh.Id = "jti"
+	token, err = GenerateToken(refresh, time.Minute)
+	require.NoError(t, err)
+	claims, err = ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "jti", claims.Id)
+}
+
+func TestParseToken_KeyRotation(t *testing.T) {
+	defer InitJWTKeys(nil, "")
+	_, err := InitJWTKeys(map[string]string{"k1": "secret-1"}, "k1")
+	require.NoError(t, err)
+	oldToken, err := GenerateToken(Claims{ID: 1, SessionID: "s1", TokenType: TokenTypeAccess}, time.Minute)
+	require.NoError(t, err)
+
+	// 加入新密钥后，旧密钥签发的token仍然有效，新token使用新密钥
+	_, err = InitJWTKeys(map[string]string{"k1": "secret-1", "k2": "secret-2"}, "k2")
+	require.NoError(t, err)
+	_, err = ParseToken(oldToken)
+	assert.NoError(t, err)
+	newToken, err := GenerateToken(Claims{ID: 1, SessionID: "s1", TokenType: TokenTypeAccess}, time.Minute)
+	require.NoError(t, err)
+	parsed, _ := jwt.Parse(newToken, nil)
+	assert.Equal(t, "k2", parsed.Header["kid"])
+
+	// 删除旧密钥后，旧token失效
+	_, err = InitJWTKeys(map[string]string{"k2": "secret-2"}, "k2")
+	require.NoError(t, err)
+	_, err = ParseToken(oldToken)
+	assert.Error(t, err)
+	_, err = ParseToken(newToken)
+	assert.NoError(t, err)
+
+	_, err = InitJWTKeys(map[string]string{"k2": "secret-2"}, "k3")
+	assert.Error(t, err)
+}
+
+func TestParseToken_Invalid(t *testing.T) {
+	defer InitJWTKeys(nil, "")
+	_, err := InitJWTKeys(map[string]string{"k1": "secret-1"}, "k1")
+	require.NoError(t, err)
+
+	expired, err := GenerateToken(Claims{ID: 1, SessionID: "s1", TokenType: TokenTypeAccess}, -time.Minute)
+	require.NoError(t, err)
+	_, err = ParseToken(expired)
+	assert.Error(t, err)
+
+	// 没有kid和会话id的旧token无效
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{ID: 1, StandardClaims: jwt.StandardClaims{
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		Issuer:    issuer,
+	}})
+	legacyToken, err := legacy.SignedString([]byte("secret-1"))
+	require.NoError(t, err)
+	_, err = ParseToken(legacyToken)
+	assert.Error(t, err)
+
+	// 使用其他密钥签名的token无效
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{ID: 1, SessionID: "s1", StandardClaims: jwt.StandardClaims{
+		Id:        "jti",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		Issuer:    issuer,
+	}})
+	forged.Header["kid"] = "k1"
+	forgedToken, err := forged.SignedString([]byte("other"))
+	require.NoError(t, err)
+	_, err = ParseToken(forgedToken)
+	assert.Error(t, err)
+
+	// 不接受none算法
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{ID: 1, SessionID: "s1", StandardClaims: jwt.StandardClaims{
+		Id:        "jti",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		Issuer:    issuer,
+	}})
+	none.Header["kid"] = "k1"
+	noneToken, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = ParseToken(noneToken)
+	assert.Error(t, err)
+}
+
+func TestInitJWTKeys_NotConfigured(t *testing.T) {
+	defer InitJWTKeys(nil, "")
+	useDefaultKey, err := InitJWTKeys(nil, "")
+	// 只有使用dev标签编译时存在默认密钥
+	if len(defaultJWTKey) == 0 {
+		assert.ErrorIs(t, err, ErrJWTKeysNotConfigured)
+		assert.False(t, useDefaultKey)
+		return
+	}
+	require.NoError(t, err)
+	assert.True(t, useDefaultKey)
+	token, err := GenerateToken(Claims{ID: 1, SessionID: "s1", TokenType: TokenTypeAccess}, time.Minute)
+	require.NoError(t, err)
+	_, err = ParseToken(token)
+	assert.NoError(t, err)
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"github.com/google/uuid"
 	"log"
@@ -47,3 +49,12 @@ func GetRandomPassword(length int) string {
 	}
 	return string(bytes)
 }
+
+// GetSecureRandomHex 使用加密安全的随机数生成指定字节数的16进制字符串，结果长度为2*n
+func GetSecureRandomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := cryptorand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
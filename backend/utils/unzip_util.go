@@ -13,6 +13,9 @@ import (
 
 func Extract(archiveFile, destDir string) error {
 	fileExt := strings.ToLower(filepath.Ext(archiveFile))
+	if strings.HasSuffix(strings.ToLower(archiveFile), ".tar.gz") {
+		fileExt = ".tar.gz"
+	}
 
 	switch fileExt {
 	case ".zip":
@@ -36,7 +39,10 @@ func UnZip(archiveFile, destDir string) error {
 	defer r.Close()
 
 	for _, f := range r.File {
-		path := filepath.Join(destDir, f.Name)
+		path, err := extractPath(destDir, f.Name)
+		if err != nil {
+			return err
+		}
 
 		if f.FileInfo().IsDir() {
 			os.MkdirAll(path, f.Mode())
@@ -97,7 +103,10 @@ func UnTar(archiveFile, destDir string) error {
 			return err
 		}
 
-		path := filepath.Join(destDir, header.Name)
+		path, err := extractPath(destDir, header.Name)
+		if err != nil {
+			return err
+		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
@@ -105,6 +114,9 @@ func UnTar(archiveFile, destDir string) error {
 				return err
 			}
 		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
 			outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode())
 			if err != nil {
 				return err
@@ -119,3 +131,13 @@ func UnTar(archiveFile, destDir string) error {
 
 	return nil
 }
+
+// extractPath 获取压缩包中的文件解压后的路径，不允许解压到目标目录之外
+func extractPath(destDir, name string) (string, error) {
+	path := filepath.Join(destDir, name)
+	rel, err := filepath.Rel(destDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("illegal file path in archive: %s", name)
+	}
+	return path, nil
+}
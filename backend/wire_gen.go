@@ -14,8 +14,12 @@ import (
 	"github.com/fansqz/fancode-backend/dao"
 	"github.com/fansqz/fancode-backend/interceptor"
 	"github.com/fansqz/fancode-backend/routers"
+	"github.com/fansqz/fancode-backend/service/code_revision_service"
 	"github.com/fansqz/fancode-backend/service/common_service"
+	"github.com/fansqz/fancode-backend/service/problem_service"
+	"github.com/fansqz/fancode-backend/service/search_service"
 	"github.com/fansqz/fancode-backend/service/system_service"
+	"github.com/fansqz/fancode-backend/service/user_coding_service"
 	"github.com/fansqz/fancode-backend/service/user_saved_code_service"
 	"github.com/fansqz/fancode-backend/service/visual_debug_servcie"
 	"github.com/fansqz/fancode-backend/service/visual_document_service"
@@ -28,14 +32,32 @@ func initApp(appConfig *config.AppConfig) (*http.Server, error) {
 	sysUserDao := dao.NewSysUserDao()
 	sysMenuDao := dao.NewSysMenuDao()
 	sysRoleDao := dao.NewSysRoleDao()
-	authService := common_service.NewAuthService(appConfig, sysUserDao, sysMenuDao, sysRoleDao)
-	authController := controller.NewAuthController(authService)
+	sysUserSessionDao := dao.NewSysUserSessionDao()
+	sessionService := common_service.NewSessionService(appConfig, sysUserSessionDao)
+	authService := common_service.NewAuthService(appConfig, sysUserDao, sysMenuDao, sysRoleDao, sessionService)
+	authController := controller.NewAuthController(authService, sessionService)
+	sysUserOAuthDao := dao.NewSysUserOAuthDao()
+	oauthService := common_service.NewOAuthService(appConfig, sysUserDao, sysUserOAuthDao, sessionService)
+	oauthController := controller.NewOAuthController(oauthService)
 	accountService := common_service.NewAccountService(appConfig, sysUserDao, sysRoleDao)
 	accountController := controller.NewAccountController(accountService)
 	commonService := common_service.NewCommonService(appConfig)
 	commonController := controller.NewCommonController(commonService)
 	userSavedCodeDao := dao.NewUserSavedCodeDao()
-	userSavedCodeService := user_saved_code_service.NewUserSavedCodeService(appConfig, userSavedCodeDao)
+	userSavedCodeShareDao := dao.NewUserSavedCodeShareDao()
+	userCodeRevisionDao := dao.NewUserCodeRevisionDao()
+	userSavedCodeFolderDao := dao.NewUserSavedCodeFolderDao()
+	userSavedCodeTagDao := dao.NewUserSavedCodeTagDao()
+	searchEntryDao := dao.NewSearchEntryDao()
+	visualDocumentBankDao := dao.NewVisualDocumentBankDao()
+	visualDocumentDao := dao.NewVisualDocumentDao()
+	visualDocumentRevisionDao := dao.NewVisualDocumentRevisionDao()
+	visualDocumentSnippetDao := dao.NewVisualDocumentSnippetDao()
+	visualDocumentExerciseDao := dao.NewVisualDocumentExerciseDao()
+	visualDocumentProgressDao := dao.NewVisualDocumentProgressDao()
+	visualDocumentBankMemberDao := dao.NewVisualDocumentBankMemberDao()
+	searchService := search_service.NewSearchService(searchEntryDao, visualDocumentBankDao, visualDocumentDao, visualDocumentRevisionDao, userSavedCodeDao)
+	userSavedCodeService := user_saved_code_service.NewUserSavedCodeService(appConfig, userSavedCodeDao, userSavedCodeShareDao, userCodeRevisionDao, userSavedCodeFolderDao, userSavedCodeTagDao, sysUserDao, searchService)
 	userSavedCodeHandler := user.NewUserSavedCodeHandler(userSavedCodeService)
 	sysApiDao := dao.NewSysApiDao()
 	sysApiService := system_service.NewSysApiService(sysApiDao)
@@ -46,23 +68,50 @@ func initApp(appConfig *config.AppConfig) (*http.Server, error) {
 	sysRoleController := admin.NewSysRoleController(sysRoleService)
 	sysUserService := system_service.NewSysUserService(appConfig, sysUserDao, sysRoleDao)
 	sysUserController := admin.NewSysUserController(sysUserService)
-	visualDocumentDao := dao.NewVisualDocumentDao()
-	visualDocumentService := visual_document_service.NewVisualDocumentService(visualDocumentDao, appConfig)
+	visualDocumentService := visual_document_service.NewVisualDocumentService(visualDocumentDao, visualDocumentRevisionDao, visualDocumentSnippetDao, visualDocumentExerciseDao, visualDocumentProgressDao, visualDocumentBankDao, visualDocumentBankMemberDao, sysUserDao, searchService, appConfig)
 	visualDocumentManageController := admin.NewVisualDocumentManageController(visualDocumentService)
-	visualDocumentBankDao := dao.NewVisualDocumentBankDao()
-	visualDocumentBankService := visual_document_service.NewVisualDocumentBankService(appConfig, visualDocumentBankDao, visualDocumentDao, sysUserDao)
+	visualDocumentBankService := visual_document_service.NewVisualDocumentBankService(appConfig, visualDocumentBankDao, visualDocumentDao, visualDocumentRevisionDao, visualDocumentSnippetDao, visualDocumentProgressDao, visualDocumentBankMemberDao, sysUserDao, searchService)
 	visualDocumentBankManageController := admin.NewVisualDocumentBankManageController(visualDocumentBankService)
-	debugService := visual_debug_servcie.NewDebugService(appConfig)
+	visualDocumentProgressService := visual_document_service.NewVisualDocumentProgressService(visualDocumentProgressDao, visualDocumentDao, visualDocumentBankDao, visualDocumentBankMemberDao, sysUserDao)
+	debugService := visual_debug_servcie.NewDebugService(appConfig, visualDocumentService, visualDocumentProgressService, userSavedCodeService)
 	debugController := user.NewDebugController(debugService)
-	visualService := visual_debug_servcie.NewVisualService()
+	debugAssistService := visual_debug_servcie.NewDebugAssistService(appConfig)
+	debugAssistController := user.NewDebugAssistController(debugAssistService)
+	visualService := visual_debug_servcie.NewVisualService(userSavedCodeDao)
 	visualController := user.NewVisualController(visualService)
 	visualDocumentController := user.NewVisualDocumentController(visualDocumentService)
 	visualDocumentBankController := user.NewVisualDocumentBankController(visualDocumentBankService)
+	problemDao := dao.NewProblemDao()
+	problemCaseDao := dao.NewProblemCaseDao()
+	problemBankDao := dao.NewProblemBankDao()
+	problemTagDao := dao.NewProblemTagDao()
+	problemTemplateDao := dao.NewProblemTemplateDao()
+	problemService := problem_service.NewProblemService(appConfig, problemDao, problemCaseDao, problemBankDao, problemTagDao, problemTemplateDao)
+	problemManageController := admin.NewProblemManageController(problemService)
+	problemController := user.NewProblemController(problemService)
+	problemBankService := problem_service.NewProblemBankService(problemBankDao, problemDao, problemCaseDao, problemTemplateDao, sysUserDao)
+	problemBankManageController := admin.NewProblemBankManageController(problemBankService)
+	problemBankController := user.NewProblemBankController(problemBankService)
+	submissionDao := dao.NewSubmissionDao()
+	judgeService := user_coding_service.NewJudgeService(appConfig, problemDao, problemCaseDao, submissionDao)
+	judgeController := user.NewJudgeController(judgeService)
+	searchController := user.NewSearchController(searchService)
+	searchManageController := admin.NewSearchManageController(searchService)
+	visualDocumentProgressController := user.NewVisualDocumentProgressController(visualDocumentProgressService)
+	visualDocumentProgressManageController := admin.NewVisualDocumentProgressManageController(visualDocumentProgressService)
+	visualDocumentExerciseService := visual_document_service.NewVisualDocumentExerciseService(appConfig, visualDocumentExerciseDao, visualDocumentDao, visualDocumentProgressDao, visualDocumentBankDao, visualDocumentBankMemberDao, sysUserDao, judgeService)
+	visualDocumentExerciseController := user.NewVisualDocumentExerciseController(visualDocumentExerciseService)
+	visualDocumentExerciseManageController := admin.NewVisualDocumentExerciseManageController(visualDocumentExerciseService)
+	userCodeDao := dao.NewUserCodeDao()
+	userCodeService := user_coding_service.NewUserCodeService(appConfig, userCodeDao, problemTemplateDao, userCodeRevisionDao)
+	userCodeController := user.NewUserCodeController(userCodeService)
+	codeRevisionService := code_revision_service.NewCodeRevisionService(userCodeRevisionDao, userSavedCodeDao, userCodeDao, searchService)
+	codeRevisionController := user.NewCodeRevisionController(codeRevisionService)
 	recoverPanicInterceptor := interceptor.NewRecoverPanicInterceptor()
 	corsInterceptor := interceptor.NewCorsInterceptor()
-	requestInterceptor := interceptor.NewRequestInterceptor(sysRoleService, sysUserService)
+	requestInterceptor := interceptor.NewRequestInterceptor(sysRoleService, sysUserService, sessionService)
 	loggerInterceptor := interceptor.NewLoggerInterceptor()
-	engine := routers.SetupRouter(authController, accountController, commonController, userSavedCodeHandler, sysApiController, sysMenuController, sysRoleController, sysUserController, visualDocumentManageController, visualDocumentBankManageController, debugController, visualController, visualDocumentController, visualDocumentBankController, appConfig, recoverPanicInterceptor, corsInterceptor, requestInterceptor, loggerInterceptor)
+	engine := routers.SetupRouter(authController, oauthController, accountController, commonController, userSavedCodeHandler, sysApiController, sysMenuController, sysRoleController, sysUserController, visualDocumentManageController, visualDocumentBankManageController, debugController, debugAssistController, visualController, visualDocumentController, visualDocumentBankController, problemManageController, problemController, problemBankManageController, problemBankController, judgeController, searchController, searchManageController, visualDocumentProgressController, visualDocumentProgressManageController, visualDocumentExerciseController, visualDocumentExerciseManageController, userCodeController, codeRevisionController, appConfig, recoverPanicInterceptor, corsInterceptor, requestInterceptor, loggerInterceptor)
 	server := newApp(engine, appConfig)
 	return server, nil
 }